@@ -19,10 +19,19 @@ package cli
 import (
 	gocontext "context"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	api "github.com/containerd/cri/pkg/api/v1"
 	"github.com/containerd/cri/pkg/client"
+	criconfig "github.com/containerd/cri/pkg/config"
+	"github.com/containerd/cri/pkg/server"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli"
 )
@@ -33,6 +42,13 @@ var Command = cli.Command{
 	Usage: "interact with cri plugin",
 	Subcommands: cli.Commands{
 		loadCommand,
+		checkpointCommand,
+		pullsCommand,
+		drainCommand,
+		eventsCommand,
+		prePullCommand,
+		validateConfigCommand,
+		logLevelCommand,
 	},
 }
 
@@ -76,3 +92,358 @@ var loadCommand = cli.Command{
 		return nil
 	},
 }
+
+var checkpointCommand = cli.Command{
+	Name:        "checkpoint",
+	Usage:       "checkpoint a running container into a tar archive.",
+	ArgsUsage:   "[flags] CONTAINER TAR",
+	Description: "checkpoint a running container into a tar archive, which can be restored with the io.kubernetes.cri.restore-checkpoint container annotation if it is in the configured checkpoint_dir.",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "exit",
+			Usage: "stop the container after checkpointing",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		var (
+			ctx     = gocontext.Background()
+			address = context.GlobalString("address")
+			timeout = context.GlobalDuration("timeout")
+			cancel  gocontext.CancelFunc
+		)
+		if context.NArg() != 2 {
+			return errors.New("container id and archive path must be provided")
+		}
+		if timeout > 0 {
+			ctx, cancel = gocontext.WithTimeout(gocontext.Background(), timeout)
+		} else {
+			ctx, cancel = gocontext.WithCancel(ctx)
+		}
+		defer cancel()
+		cl, err := client.NewCRIPluginClient(ctx, address)
+		if err != nil {
+			return errors.Wrap(err, "failed to create grpc client")
+		}
+		absPath, err := filepath.Abs(context.Args().Get(1))
+		if err != nil {
+			return errors.Wrap(err, "failed to get absolute path")
+		}
+		if _, err := cl.CheckpointContainer(ctx, &api.CheckpointContainerRequest{
+			ContainerId: context.Args().First(),
+			Location:    absPath,
+			Exit:        context.Bool("exit"),
+		}); err != nil {
+			return errors.Wrap(err, "failed to checkpoint container")
+		}
+		fmt.Println("Checkpointed container to:", absPath)
+		return nil
+	},
+}
+
+var pullsCommand = cli.Command{
+	Name:        "pulls",
+	Usage:       "list in progress image pulls.",
+	ArgsUsage:   "[flags]",
+	Description: "list in progress image pulls and the progress of the contents they are fetching.",
+	Flags:       []cli.Flag{},
+	Action: func(context *cli.Context) error {
+		var (
+			ctx     = gocontext.Background()
+			address = context.GlobalString("address")
+			timeout = context.GlobalDuration("timeout")
+			cancel  gocontext.CancelFunc
+		)
+		if timeout > 0 {
+			ctx, cancel = gocontext.WithTimeout(gocontext.Background(), timeout)
+		} else {
+			ctx, cancel = gocontext.WithCancel(ctx)
+		}
+		defer cancel()
+		cl, err := client.NewCRIPluginClient(ctx, address)
+		if err != nil {
+			return errors.Wrap(err, "failed to create grpc client")
+		}
+		res, err := cl.ListImagePulls(ctx, &api.ListImagePullsRequest{})
+		if err != nil {
+			return errors.Wrap(err, "failed to list image pulls")
+		}
+		w := tabwriter.NewWriter(os.Stdout, 1, 8, 1, ' ', 0)
+		fmt.Fprintln(w, "IMAGE\tDIGEST\tMEDIATYPE\tPROGRESS")
+		for _, pull := range res.GetPulls() {
+			for _, c := range pull.GetContents() {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\n", pull.GetImage(), c.GetDigest(), c.GetMediaType(), c.GetOffset(), c.GetTotal())
+			}
+		}
+		return w.Flush()
+	},
+}
+
+var drainCommand = cli.Command{
+	Name:        "drain",
+	Usage:       "put the cri plugin into drain mode.",
+	ArgsUsage:   "[flags]",
+	Description: "put the cri plugin into drain mode, which rejects new pod sandboxes and containers with a retriable error and waits for the in-flight ones to be created, so that containerd can be restarted safely.",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "off",
+			Usage: "leave drain mode",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		var (
+			ctx     = gocontext.Background()
+			address = context.GlobalString("address")
+			timeout = context.GlobalDuration("timeout")
+			cancel  gocontext.CancelFunc
+		)
+		if timeout > 0 {
+			ctx, cancel = gocontext.WithTimeout(gocontext.Background(), timeout)
+		} else {
+			ctx, cancel = gocontext.WithCancel(ctx)
+		}
+		defer cancel()
+		cl, err := client.NewCRIPluginClient(ctx, address)
+		if err != nil {
+			return errors.Wrap(err, "failed to create grpc client")
+		}
+		drain := !context.Bool("off")
+		if _, err := cl.SetDrain(ctx, &api.SetDrainRequest{Drain: drain}); err != nil {
+			return errors.Wrap(err, "failed to set drain mode")
+		}
+		if drain {
+			fmt.Println("Entered drain mode")
+		} else {
+			fmt.Println("Left drain mode")
+		}
+		return nil
+	},
+}
+
+var eventsCommand = cli.Command{
+	Name:        "events",
+	Usage:       "stream container and pod sandbox lifecycle events.",
+	ArgsUsage:   "[flags]",
+	Description: "stream lifecycle events of containers and pod sandboxes until interrupted. The events are served by the cri plugin API only, not by the CRI.",
+	Flags:       []cli.Flag{},
+	Action: func(context *cli.Context) error {
+		var (
+			ctx     = gocontext.Background()
+			address = context.GlobalString("address")
+			timeout = context.GlobalDuration("timeout")
+			cancel  gocontext.CancelFunc
+		)
+		if timeout > 0 {
+			ctx, cancel = gocontext.WithTimeout(gocontext.Background(), timeout)
+		} else {
+			ctx, cancel = gocontext.WithCancel(ctx)
+		}
+		defer cancel()
+		cl, err := client.NewCRIPluginClient(ctx, address)
+		if err != nil {
+			return errors.Wrap(err, "failed to create grpc client")
+		}
+		stream, err := cl.GetContainerEvents(ctx, &api.GetEventsRequest{})
+		if err != nil {
+			return errors.Wrap(err, "failed to get container events")
+		}
+		for {
+			evt, err := stream.Recv()
+			if err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return errors.Wrap(err, "failed to receive container event")
+			}
+			fmt.Println(time.Unix(0, evt.GetCreatedAt()).Format(time.RFC3339Nano), evt.GetContainerEventType(), evt.GetContainerId(), evt.GetPodSandboxId())
+		}
+	},
+}
+
+var prePullCommand = cli.Command{
+	Name:        "prepull",
+	Usage:       "pull one or more images to warm the image cache.",
+	ArgsUsage:   "[flags] IMAGE [IMAGE, ...]",
+	Description: "pull one or more images with bounded concurrency and print the progress of each image, without creating pods.",
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "max-concurrent-pulls",
+			Usage: "maximum number of images pulled at the same time, the plugin default is used if not set",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		var (
+			ctx     = gocontext.Background()
+			address = context.GlobalString("address")
+			timeout = context.GlobalDuration("timeout")
+			cancel  gocontext.CancelFunc
+		)
+		if context.NArg() == 0 {
+			return errors.New("at least one image must be provided")
+		}
+		if timeout > 0 {
+			ctx, cancel = gocontext.WithTimeout(gocontext.Background(), timeout)
+		} else {
+			ctx, cancel = gocontext.WithCancel(ctx)
+		}
+		defer cancel()
+		cl, err := client.NewCRIPluginClient(ctx, address)
+		if err != nil {
+			return errors.Wrap(err, "failed to create grpc client")
+		}
+		stream, err := cl.PrePullImages(ctx, &api.PrePullImagesRequest{
+			Images:             context.Args(),
+			MaxConcurrentPulls: int32(context.Int("max-concurrent-pulls")),
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to pre-pull images")
+		}
+		var failed int
+		for {
+			res, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					return errors.Wrap(err, "failed to receive pre-pull progress")
+				}
+				break
+			}
+			switch res.GetState() {
+			case api.PrePullImageState_PRE_PULL_IMAGE_STARTED:
+				fmt.Println("Pulling image:", res.GetImage())
+			case api.PrePullImageState_PRE_PULL_IMAGE_IN_PROGRESS:
+				fmt.Printf("Pulling image: %s %d/%d\n", res.GetImage(), res.GetOffset(), res.GetTotal())
+			case api.PrePullImageState_PRE_PULL_IMAGE_SUCCEEDED:
+				fmt.Println("Pulled image:", res.GetImage(), res.GetImageRef())
+			case api.PrePullImageState_PRE_PULL_IMAGE_FAILED:
+				failed++
+				fmt.Println("Failed to pull image:", res.GetImage(), res.GetError())
+			}
+		}
+		if failed > 0 {
+			return errors.Errorf("failed to pull %d images", failed)
+		}
+		return nil
+	},
+}
+
+var logLevelCommand = cli.Command{
+	Name:        "log-level",
+	Usage:       "set or show the log levels of cri plugin subsystems.",
+	ArgsUsage:   "[flags] [SUBSYSTEM=LEVEL, ...]",
+	Description: "set the log levels of subsystems (sandbox, container, image, cni, streaming, events), and show the log levels of all subsystems. An empty level resets the subsystem to the containerd log level, e.g. \"image=\".",
+	Flags:       []cli.Flag{},
+	Action: func(context *cli.Context) error {
+		var (
+			ctx     = gocontext.Background()
+			address = context.GlobalString("address")
+			timeout = context.GlobalDuration("timeout")
+			cancel  gocontext.CancelFunc
+		)
+		levels := make(map[string]string)
+		for _, arg := range context.Args() {
+			parts := strings.SplitN(arg, "=", 2)
+			if len(parts) != 2 {
+				return errors.Errorf("invalid argument %q, must be SUBSYSTEM=LEVEL", arg)
+			}
+			levels[parts[0]] = parts[1]
+		}
+		if timeout > 0 {
+			ctx, cancel = gocontext.WithTimeout(gocontext.Background(), timeout)
+		} else {
+			ctx, cancel = gocontext.WithCancel(ctx)
+		}
+		defer cancel()
+		cl, err := client.NewCRIPluginClient(ctx, address)
+		if err != nil {
+			return errors.Wrap(err, "failed to create grpc client")
+		}
+		res, err := cl.SetLogLevels(ctx, &api.SetLogLevelsRequest{Levels: levels})
+		if err != nil {
+			return errors.Wrap(err, "failed to set log levels")
+		}
+		var subsystems []string
+		for subsystem := range res.GetLevels() {
+			subsystems = append(subsystems, subsystem)
+		}
+		sort.Strings(subsystems)
+		w := tabwriter.NewWriter(os.Stdout, 1, 8, 1, ' ', 0)
+		fmt.Fprintln(w, "SUBSYSTEM\tLEVEL")
+		for _, subsystem := range subsystems {
+			level := res.GetLevels()[subsystem]
+			if level == "" {
+				level = res.GetDefaultLevel() + " (default)"
+			}
+			fmt.Fprintf(w, "%s\t%s\n", subsystem, level)
+		}
+		return w.Flush()
+	},
+}
+
+var validateConfigCommand = cli.Command{
+	Name:        "validate-config",
+	Usage:       "validate the cri plugin config in a containerd config file.",
+	ArgsUsage:   "[flags] [CONFIG]",
+	Description: "load and validate the cri plugin config in a containerd config file, /etc/containerd/config.toml by default, without starting the plugin. Unknown keys are rejected, and problems which likely break pods are printed as warnings.",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "print",
+			Usage: "print the effective config, including the defaults",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		path := "/etc/containerd/config.toml"
+		if context.NArg() > 0 {
+			path = context.Args().First()
+		}
+		config, err := loadPluginConfig(path)
+		if err != nil {
+			return err
+		}
+		if err := server.ValidatePluginConfig(config); err != nil {
+			return errors.Wrapf(err, "invalid cri plugin config in %q", path)
+		}
+		for _, warning := range server.PluginConfigWarnings(config) {
+			fmt.Fprintln(os.Stderr, "Warning:", warning)
+		}
+		if context.Bool("print") {
+			effective := map[string]interface{}{
+				"plugins": map[string]interface{}{"cri": config},
+			}
+			if err := toml.NewEncoder(os.Stdout).Encode(effective); err != nil {
+				return errors.Wrap(err, "failed to print config")
+			}
+			return nil
+		}
+		fmt.Println("Config is valid:", path)
+		return nil
+	},
+}
+
+// loadPluginConfig loads the cri plugin config in a containerd config file on
+// top of the default config, the same way as containerd. Unknown keys in the
+// cri plugin section are rejected, because containerd silently ignores them.
+func loadPluginConfig(path string) (criconfig.PluginConfig, error) {
+	config := criconfig.DefaultConfig()
+	var file struct {
+		Plugins map[string]toml.Primitive `toml:"plugins"`
+	}
+	md, err := toml.DecodeFile(path, &file)
+	if err != nil {
+		return config, errors.Wrapf(err, "failed to decode %q", path)
+	}
+	if plugin, ok := file.Plugins["cri"]; ok {
+		if err := md.PrimitiveDecode(plugin, &config); err != nil {
+			return config, errors.Wrapf(err, "failed to decode cri plugin config in %q", path)
+		}
+	}
+	var unknown []string
+	for _, key := range md.Undecoded() {
+		if len(key) > 2 && key[0] == "plugins" && key[1] == "cri" {
+			unknown = append(unknown, key.String())
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return config, errors.Errorf("unknown keys %q in %q", unknown, path)
+	}
+	return config, nil
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package cri
 
 import (
+	"context"
 	"flag"
 	"path/filepath"
 
@@ -80,10 +81,30 @@ func initCRIService(ic *plugin.InitContext) (interface{}, error) {
 		return nil, errors.Wrap(err, "failed to get services")
 	}
 
+	s, err := runCRIService(ctx, c, servicesOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Additional instances are only served on their own sockets, the
+	// containerd socket serves the plugin.
+	for name, instanceConfig := range server.InstanceConfigs(c) {
+		log.G(ctx).Infof("Start cri instance %q in namespace %q on %q", name,
+			instanceConfig.ContainerdConfig.Namespace, instanceConfig.GRPC.Address)
+		if _, err := runCRIService(ctx, instanceConfig, servicesOpts); err != nil {
+			return nil, errors.Wrapf(err, "failed to start cri instance %q", name)
+		}
+	}
+	return s, nil
+}
+
+// runCRIService creates a CRI service in the containerd namespace of the
+// config, and runs it in the background.
+func runCRIService(ctx context.Context, c criconfig.Config, servicesOpts []containerd.ServicesOpt) (server.CRIService, error) {
 	log.G(ctx).Info("Connect containerd service")
 	client, err := containerd.New(
 		"",
-		containerd.WithDefaultNamespace(constants.K8sContainerdNamespace),
+		containerd.WithDefaultNamespace(c.ContainerdConfig.Namespace),
 		containerd.WithServices(servicesOpts...),
 	)
 	if err != nil {
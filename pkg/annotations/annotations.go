@@ -35,4 +35,136 @@ const (
 	// UntrustedWorkload is the sandbox annotation for untrusted workload. Untrusted
 	// workload can only run on dedicated runtime for untrusted workload.
 	UntrustedWorkload = "io.kubernetes.cri.untrusted-workload"
+
+	// RuntimeHandler is the sandbox annotation selecting one of the runtimes
+	// configured in `plugins.cri.containerd.runtimes`. It stands in for the
+	// CRI runtime_handler field, which is not in the vendored CRI API yet.
+	RuntimeHandler = "io.kubernetes.cri.runtime-handler"
+
+	// RestoreCheckpoint is the container annotation holding the absolute path of
+	// a checkpoint archive created by CheckpointContainer, which must be in the
+	// configured checkpoint_dir. The container is restored from the checkpoint
+	// instead of starting a new process.
+	RestoreCheckpoint = "io.kubernetes.cri.restore-checkpoint"
+
+	// UserNamespaceMode is the sandbox annotation selecting the user namespace
+	// mode of the pod. With "pod", the sandbox and all containers in it run in
+	// a user namespace of the pod, mapped to a range of host ids allocated from
+	// `plugins.cri.user_namespace`.
+	// It stands in for the CRI user namespace option, which is not in the
+	// vendored CRI API yet.
+	UserNamespaceMode = "io.kubernetes.cri.userns-mode"
+
+	// UserNamespaceModePod is the user namespace mode running the pod in its
+	// own user namespace.
+	UserNamespaceModePod = "pod"
+
+	// LogFormat is the sandbox annotation selecting the format of the log
+	// files of all containers in the pod, "cri" or "json". It overrides
+	// `plugins.cri.container_log_format`.
+	LogFormat = "io.kubernetes.cri.log-format"
+
+	// HugepageLimitPrefix is the prefix of container annotations setting the
+	// hugetlb limit in bytes of a page size, e.g.
+	// "io.kubernetes.cri.hugepage-limit.2MB: 1073741824". They stand in for the
+	// CRI hugepage limits, which are not in the vendored CRI API yet.
+	HugepageLimitPrefix = "io.kubernetes.cri.hugepage-limit."
+
+	// MemorySwapLimit is the container annotation setting the memory plus swap
+	// limit in bytes, -1 means unlimited swap. It stands in for the CRI memory
+	// swap limit, which is not in the vendored CRI API yet.
+	MemorySwapLimit = "io.kubernetes.cri.memory-swap-limit"
+
+	// NamespaceTarget is the container annotation holding the id of a running
+	// container in the same pod, whose pid namespace the container joins, e.g.
+	// for an ephemeral debug container. It stands in for the CRI namespace
+	// target_id, which is not in the vendored CRI API yet.
+	NamespaceTarget = "io.kubernetes.cri.namespace-target"
+
+	// OCIHooks is the sandbox annotation listing the comma separated names of
+	// `plugins.cri.annotated_oci_hooks`, which are injected into all containers
+	// in the pod. Names which are not configured are rejected.
+	OCIHooks = "io.kubernetes.cri.oci-hooks"
+
+	// Rlimits is the sandbox annotation overriding the resource limits of all
+	// containers in the pod, e.g. "nofile=65536:65536,core=0". Each limit is
+	// "soft:hard", or a single value for both, and "unlimited" is allowed.
+	// It overrides `plugins.cri.default_rlimits` and the runtime defaults, but
+	// can't raise their hard limits or set a limit without a default.
+	Rlimits = "io.kubernetes.cri.rlimits"
+
+	// MaskedPaths is the container annotation replacing the masked paths of
+	// the container with a comma separated list. It can only leave out the
+	// paths in `plugins.cri.masked_paths.removable_paths`. It stands in for
+	// the CRI security context masked_paths, which is not in the vendored CRI
+	// API yet.
+	MaskedPaths = "io.kubernetes.cri.masked-paths"
+
+	// ReadonlyPaths is the container annotation replacing the readonly paths
+	// of the container with a comma separated list. It can only leave out the
+	// paths in `plugins.cri.readonly_paths.removable_paths`. It stands in for
+	// the CRI security context readonly_paths, which is not in the vendored
+	// CRI API yet.
+	ReadonlyPaths = "io.kubernetes.cri.readonly-paths"
+
+	// StopSignals is the sandbox annotation overriding the signals sent to
+	// stop containers in the pod, e.g. "SIGTERM:10s,SIGINT:5s". Each signal
+	// is sent after the previous one doesn't stop the container in its
+	// timeout, a signal without a timeout waits for the rest of the grace
+	// period. SIGKILL is sent at the end of the grace period.
+	StopSignals = "io.kubernetes.cri.stop-signals"
+
+	// SelinuxPrivateVolumes is the container annotation listing the comma
+	// separated container paths of the volumes relabeled with the private
+	// SELinux label (":Z") of the container, which other containers in the pod
+	// can't access. Other volumes requesting relabeling get the shared label
+	// (":z").
+	SelinuxPrivateVolumes = "io.kubernetes.cri.selinux-private-volumes"
+
+	// TmpfsSizes is the container annotation setting the sizes in bytes of the
+	// tmpfs mounts of CRI mounts without a host path, keyed by the container
+	// path, e.g. "/cache=67108864". It stands in for the size limit of memory
+	// backed volumes, which is not in the vendored CRI API yet.
+	TmpfsSizes = "io.kubernetes.cri.tmpfs-sizes"
+
+	// FSGroup is the sandbox annotation setting the gid owning the volumes of
+	// the containers in the pod with `plugins.cri.volume_ownership_policy`.
+	// It stands in for the pod fsGroup, which is not in the vendored CRI API.
+	FSGroup = "io.kubernetes.cri.fs-group"
+
+	// ImageVolumes is the container annotation mounting images read-only into
+	// the container, keyed by the container path, e.g.
+	// "/models=docker.io/library/model:v1". Images not found are pulled. It
+	// stands in for the CRI image volume mounts, which are not in the vendored
+	// CRI API yet.
+	ImageVolumes = "io.kubernetes.cri.image-volumes"
+
+	// NetNSPath is the sandbox annotation holding the path of a network
+	// namespace created and managed by an external network agent, in one of
+	// `plugins.cri.allowed_netns_dirs`. The sandbox joins it instead of a new
+	// network namespace, and CNI is skipped for the sandbox.
+	NetNSPath = "io.kubernetes.cri.netns-path"
+	// HostAliases is the sandbox annotation adding entries to the hosts file
+	// of the pod, e.g. "10.0.0.1=foo,foo.local;fd00::1=bar". Each entry is an
+	// ip and its comma separated hostnames. It stands in for the kubelet
+	// managed hosts file with the pod hostAliases.
+	HostAliases = "io.kubernetes.cri.host-aliases"
+	// ShmSize is the sandbox annotation setting the size in bytes of the
+	// /dev/shm of the pod, which is shared by its containers, e.g.
+	// "1073741824". The default is 64MB. It can't be set for pods in the host
+	// ipc namespace.
+	ShmSize = "io.kubernetes.cri.shm-size"
+	// CDIDevicesPrefix is the prefix of container annotations requesting
+	// Container Device Interface devices, e.g.
+	// "cdi.k8s.io/gpu: nvidia.com/gpu=0,nvidia.com/gpu=1". The value is a
+	// comma separated list of fully qualified device names. They stand in for
+	// the CRI CDI devices, which are not in the vendored CRI API yet.
+	CDIDevicesPrefix = "cdi.k8s.io/"
+	// UnifiedResourcePrefix is the prefix of container annotations setting
+	// a cgroup controller file of the container, e.g.
+	// "io.kubernetes.cri.unified.rdma.max: mlx5_0 hca_handle=2 hca_object=2000".
+	// Only the rdma and misc controllers are supported, and only rdma on
+	// cgroup v1. They stand in for the CRI unified resources, which are not in
+	// the vendored CRI API yet.
+	UnifiedResourcePrefix = "io.kubernetes.cri.unified."
 )
@@ -16,7 +16,11 @@ limitations under the License.
 
 package config
 
-import "github.com/containerd/containerd"
+import (
+	"github.com/containerd/containerd"
+
+	"github.com/containerd/cri/pkg/constants"
+)
 
 // Runtime struct to contain the type(ID), engine, and root variables for a default runtime
 // and a runtime for untrusted worload.
@@ -27,16 +31,120 @@ type Runtime struct {
 	Engine string `toml:"runtime_engine" json:"runtimeEngine"`
 	// Root is the directory used by containerd for runtime state.
 	Root string `toml:"runtime_root" json:"runtimeRoot"`
+	// Snapshotter is the snapshotter used by containerd for containers running
+	// with this runtime. Empty means the snapshotter in ContainerdConfig.
+	Snapshotter string `toml:"snapshotter" json:"snapshotter"`
+	// SystemdCgroup overrides PluginConfig.SystemdCgroup for containers running
+	// with this runtime. Nil means the plugin level option.
+	SystemdCgroup *bool `toml:"systemd_cgroup" json:"systemdCgroup,omitempty"`
+	// SandboxMode is how sandboxes of pods running with this runtime are
+	// created. "pause" (or empty) runs a pause container holding the pod
+	// namespaces. "no_pause" creates the pod namespaces directly without a
+	// pause container, except for pods sharing the pid namespace, whose pause
+	// container holds the shared pid namespace.
+	SandboxMode string `toml:"sandbox_mode" json:"sandboxMode"`
+	// OCIHooks are OCI hooks injected into containers running with this
+	// runtime, after the plugin level ones.
+	OCIHooks OCIHooks `toml:"oci_hooks" json:"ociHooks"`
+	// BaseRuntimeSpec is the path of a JSON OCI runtime spec, which container
+	// specs of this runtime are generated from instead of the default spec.
+	BaseRuntimeSpec string `toml:"base_runtime_spec" json:"baseRuntimeSpec"`
+	// PodAnnotations are glob patterns of pod annotations, which are passed
+	// into the OCI specs of the sandbox and containers of pods running with
+	// this runtime, e.g. "io.katacontainers.*" for per pod VM sizing.
+	PodAnnotations []string `toml:"pod_annotations" json:"podAnnotations"`
+	// ContainerAnnotations are glob patterns of container annotations, which
+	// are passed into the OCI specs of containers running with this runtime.
+	ContainerAnnotations []string `toml:"container_annotations" json:"containerAnnotations"`
+	// Options are the options of the runtime engine, e.g. the platform of
+	// runsc. They are passed to the engine as annotations of the sandbox and
+	// container specs, with OptionsAnnotationPrefix prepended to the names.
+	Options map[string]string `toml:"options" json:"options"`
+	// OptionsAnnotationPrefix is the prefix of the spec annotations the
+	// engine reads its options from, e.g. "dev.gvisor.flag." for runsc.
+	OptionsAnnotationPrefix string `toml:"options_annotation_prefix" json:"optionsAnnotationPrefix"`
+	// PodOverhead is the resources consumed by the sandbox of pods running
+	// with this runtime, e.g. the VM of kata, which are added to the limits
+	// of managed pod cgroups.
+	PodOverhead PodOverhead `toml:"pod_overhead" json:"podOverhead"`
+}
+
+// PodOverhead is the resources consumed by a pod sandbox besides its
+// containers.
+type PodOverhead struct {
+	// MilliCPU is the cpu in thousandths of a cpu.
+	MilliCPU int64 `toml:"milli_cpu" json:"milliCPU"`
+	// Memory is the memory in bytes.
+	Memory int64 `toml:"memory" json:"memory"`
+}
+
+// Rlimit is a POSIX resource limit of container processes.
+type Rlimit struct {
+	// Type is the resource without the "RLIMIT_" prefix in lower case, e.g.
+	// "nofile".
+	Type string `toml:"type" json:"type"`
+	// Soft is the soft limit.
+	Soft uint64 `toml:"soft" json:"soft"`
+	// Hard is the hard limit.
+	Hard uint64 `toml:"hard" json:"hard"`
+}
+
+// ProcPaths are the masked or readonly paths of containers.
+type ProcPaths struct {
+	// Paths are added to the default paths.
+	Paths []string `toml:"paths" json:"paths"`
+	// ClearDefaults clears the default paths, e.g. to unmask /proc entries.
+	ClearDefaults bool `toml:"clear_defaults" json:"clearDefaults"`
+	// RemovablePaths are the paths a container annotation may remove. By
+	// default the annotation can only add paths.
+	RemovablePaths []string `toml:"removable_paths" json:"removablePaths"`
+}
+
+// OCIHook is an OCI hook injected into container specs.
+type OCIHook struct {
+	// Path is the absolute path of the hook binary.
+	Path string `toml:"path" json:"path"`
+	// Args are the arguments of the hook, including argv[0].
+	Args []string `toml:"args" json:"args"`
+	// Env is the environment of the hook.
+	Env []string `toml:"env" json:"env"`
+	// Timeout is the timeout of the hook in seconds, 0 means no timeout.
+	Timeout int `toml:"timeout" json:"timeout"`
+}
+
+// OCIHooks are OCI hooks injected into container specs.
+type OCIHooks struct {
+	// Prestart hooks are run after the container namespaces are created.
+	Prestart []OCIHook `toml:"prestart" json:"prestart"`
+	// Poststart hooks are run after the container process is started.
+	Poststart []OCIHook `toml:"poststart" json:"poststart"`
+	// Poststop hooks are run after the container is deleted.
+	Poststop []OCIHook `toml:"poststop" json:"poststop"`
 }
 
 // ContainerdConfig contains toml config related to containerd
 type ContainerdConfig struct {
+	// Namespace is the containerd namespace of the sandboxes, containers and
+	// images managed by the cri plugin.
+	Namespace string `toml:"namespace" json:"namespace"`
 	// Snapshotter is the snapshotter used by containerd.
 	Snapshotter string `toml:"snapshotter" json:"snapshotter"`
+	// MaxConcurrentUnpacks is the maximum number of images unpacked into the
+	// snapshotter at the same time. Non-positive value means no limit.
+	MaxConcurrentUnpacks int `toml:"max_concurrent_unpacks" json:"maxConcurrentUnpacks"`
+	// UnpackDuringPull unpacks each image layer as soon as it and all its
+	// parent layers are fetched, instead of after the whole image is fetched.
+	UnpackDuringPull bool `toml:"unpack_during_pull" json:"unpackDuringPull"`
+	// LazyPull skips fetching image layers which the snapshotter can lazily
+	// mount from the registry, e.g. eStargz layers with a remote snapshotter.
+	LazyPull bool `toml:"lazy_pull" json:"lazyPull"`
 	// DefaultRuntime is the runtime to use in containerd.
 	DefaultRuntime Runtime `toml:"default_runtime" json:"defaultRuntime"`
 	// UntrustedWorkloadRuntime is a runtime to run untrusted workloads on it.
 	UntrustedWorkloadRuntime Runtime `toml:"untrusted_workload_runtime" json:"untrustedWorkloadRuntime"`
+	// Runtimes is a map from runtime handler names, which select the runtime
+	// configuration for a pod, to the matching runtime configurations.
+	Runtimes map[string]Runtime `toml:"runtimes" json:"runtimes"`
 }
 
 // CniConfig contains toml config related to cni
@@ -56,21 +164,358 @@ type CniConfig struct {
 	// a temporary backward-compatible solution for them.
 	// TODO(random-liu): Deprecate this option when kubenet is deprecated.
 	NetworkPluginConfTemplate string `toml:"conf_template" json:"confTemplate"`
+	// NetworksAnnotation is the pod annotation listing the names of additional
+	// networks to attach the pod to, separated by comma. Empty means pods are
+	// never attached to additional networks.
+	NetworksAnnotation string `toml:"networks_annotation" json:"networksAnnotation"`
+	// NetworksConfDir is the directory of the CNI confs of additional networks.
+	// Unlike NetworkPluginConfDir, pods are only attached to the networks in
+	// it when requested in NetworksAnnotation.
+	NetworksConfDir string `toml:"networks_conf_dir" json:"networksConfDir"`
 }
 
 // Mirror contains the config related to the registry mirror
 type Mirror struct {
 	// Endpoints are endpoints for a namespace. CRI plugin will try the endpoints
-	// one by one until a working one is found.
+	// one by one until a working one is found. The upstream registry is always
+	// tried last if it is not in the list.
 	Endpoints []string `toml:"endpoint" json:"endpoint"`
+	// NoUpstream disables falling back to the upstream registry when none of
+	// the endpoints works, e.g. for an air-gapped mirror.
+	NoUpstream bool `toml:"no_upstream" json:"noUpstream"`
 	// TODO (Abhi) We might need to add auth per namespace. Looks like
 	// image auth information is passed by kube itself.
 }
 
+// AuthConfig contains the config related to authentication to a specific registry
+type AuthConfig struct {
+	// Username is the username to login the registry.
+	Username string `toml:"username" json:"username"`
+	// Password is the password to login the registry.
+	Password string `toml:"password" json:"password"`
+	// Auth is a base64 encoded string from the concatenation of the username,
+	// a colon, and the password.
+	Auth string `toml:"auth" json:"auth"`
+	// IdentityToken is used to authenticate the user and get
+	// an access token for the registry.
+	IdentityToken string `toml:"identitytoken" json:"identityToken"`
+}
+
+// TLSConfig contains the CA/Cert/Key used for a registry
+type TLSConfig struct {
+	// InsecureSkipVerify skips verification of the registry certificate.
+	InsecureSkipVerify bool `toml:"insecure_skip_verify" json:"insecureSkipVerify"`
+	// CAFile is the path to the CA certificate used to verify the registry,
+	// in addition to the host trust store.
+	CAFile string `toml:"ca_file" json:"caFile"`
+	// CertFile is the path to the client certificate presented to the registry.
+	CertFile string `toml:"cert_file" json:"certFile"`
+	// KeyFile is the path to the key of the client certificate.
+	KeyFile string `toml:"key_file" json:"keyFile"`
+}
+
+// RegistryConfig contains configuration used to communicate with the registry.
+type RegistryConfig struct {
+	// Auth contains information to authenticate to the registry. It is only
+	// used when kubelet doesn't pass in any credential for the image.
+	Auth *AuthConfig `toml:"auth" json:"auth"`
+	// CredentialHelper is the name of the docker credential helper getting
+	// the credentials of the registry, i.e. "docker-credential-<name>" in
+	// PATH. It is only used when kubelet doesn't pass in any credential for
+	// the image and Auth is not set.
+	CredentialHelper string `toml:"credential_helper" json:"credentialHelper"`
+	// TLS is a pair of CA/Cert/Key which then are used when creating the transport
+	// that communicates with the registry.
+	TLS *TLSConfig `toml:"tls" json:"tls"`
+}
+
 // Registry is registry settings configured
 type Registry struct {
 	// Mirrors are namespace to mirror mapping for all namespaces.
 	Mirrors map[string]Mirror `toml:"mirrors" json:"mirrors"`
+	// Configs are configs for each registry.
+	// The key is the domain name or IP of the registry.
+	Configs map[string]RegistryConfig `toml:"configs" json:"configs"`
+	// P2P is the config of the peer-to-peer image distributor image pulls
+	// are routed through.
+	P2P P2PConfig `toml:"p2p" json:"p2p"`
+	// ShortNames is the config of resolving image names without registry,
+	// e.g. "busybox".
+	ShortNames ShortNameConfig `toml:"short_names" json:"shortNames"`
+}
+
+// ShortNamePolicy is the policy of resolving image names without registry.
+type ShortNamePolicy struct {
+	// Mode is how short names are resolved. "docker" or empty assumes the
+	// docker.io registry, "search" tries Registries in order, and "reject"
+	// rejects short names.
+	Mode string `toml:"mode" json:"mode"`
+	// Registries are the registry prefixes tried in order in "search" mode,
+	// e.g. "quay.io" or "registry.example.com/mirror".
+	Registries []string `toml:"registries" json:"registries"`
+}
+
+// ShortNameConfig contains the short name policies.
+type ShortNameConfig struct {
+	// Default is the policy of pulls not overridden by Namespaces.
+	Default ShortNamePolicy `toml:"default" json:"default"`
+	// Namespaces are the policies overriding Default for image pulls of pods
+	// in kubernetes namespaces, keyed by namespace.
+	Namespaces map[string]ShortNamePolicy `toml:"namespaces" json:"namespaces"`
+}
+
+// ReloadableConfig contains the config sections read from
+// ReloadableConfigPath, which are re-read on SIGHUP or when the file is
+// changed without restarting containerd. A section set in the file overrides
+// the same section of the plugin config as a whole.
+type ReloadableConfig struct {
+	// LogLevel is the log level of containerd, e.g. "debug".
+	LogLevel string `toml:"log_level" json:"logLevel"`
+	// SandboxImage is the image used by sandbox container.
+	SandboxImage string `toml:"sandbox_image" json:"sandboxImage"`
+	// Registry contains the reloadable registry config.
+	Registry ReloadableRegistry `toml:"registry" json:"registry"`
+}
+
+// ReloadableRegistry contains the registry config which can be reloaded.
+type ReloadableRegistry struct {
+	// Mirrors are namespace to mirror mapping for all namespaces.
+	Mirrors map[string]Mirror `toml:"mirrors" json:"mirrors"`
+	// Configs are configs for each registry, including their auths.
+	Configs map[string]RegistryConfig `toml:"configs" json:"configs"`
+}
+
+// P2PConfig contains the config of a peer-to-peer image distributor, e.g.
+// dragonfly or spegel. Image pulls fall back to the origin registry when the
+// distributor fails.
+type P2PConfig struct {
+	// Distributor is the type of the distributor. Image pulls are not routed
+	// through a distributor if it is empty. Only "registry" is supported,
+	// which is a distributor serving a registry compatible endpoint.
+	Distributor string `toml:"distributor" json:"distributor"`
+	// Endpoint is the endpoint of the distributor, e.g. "http://127.0.0.1:65001".
+	Endpoint string `toml:"endpoint" json:"endpoint"`
+	// Registries are the hosts of the registries whose images are pulled
+	// through the distributor. Images of all registries are pulled through
+	// the distributor if it is empty.
+	Registries []string `toml:"registries" json:"registries"`
+}
+
+// X509KeyPairStreaming contains the x509 configuration for streaming
+type X509KeyPairStreaming struct {
+	// TLSCertFile is the path to a certificate file
+	TLSCertFile string `toml:"tls_cert_file" json:"tlsCertFile"`
+	// TLSKeyFile is the path to a private key file
+	TLSKeyFile string `toml:"tls_key_file" json:"tlsKeyFile"`
+}
+
+// ImageGCConfig contains config related to the image garbage collection done
+// by the CRI plugin itself, for nodes where kubelet image garbage collection
+// is not used.
+type ImageGCConfig struct {
+	// Enabled enables the image garbage collection.
+	Enabled bool `toml:"enabled" json:"enabled"`
+	// Period is the period of checking the disk usage of the image filesystem,
+	// e.g. "5m".
+	Period string `toml:"period" json:"period"`
+	// HighThresholdPercent is the percent of disk usage of the image filesystem
+	// after which image garbage collection is always run.
+	HighThresholdPercent int `toml:"high_threshold_percent" json:"highThresholdPercent"`
+	// LowThresholdPercent is the percent of disk usage of the image filesystem
+	// which image garbage collection attempts to free to. Least recently used
+	// images are removed first.
+	LowThresholdPercent int `toml:"low_threshold_percent" json:"lowThresholdPercent"`
+}
+
+// OrphanGCConfig contains config related to the garbage collection on
+// recovery of the resources left by sandboxes and containers which were
+// removed while the plugin was down.
+type OrphanGCConfig struct {
+	// Enabled enables the orphan garbage collection.
+	Enabled bool `toml:"enabled" json:"enabled"`
+	// DryRun only logs the orphaned resources instead of removing them.
+	DryRun bool `toml:"dry_run" json:"dryRun"`
+}
+
+const (
+	// TracingExporterLog logs the traces into the containerd log.
+	TracingExporterLog = "log"
+	// TracingExporterOTLP sends the traces to an OpenTelemetry collector.
+	TracingExporterOTLP = "otlp"
+)
+
+// TracingConfig contains config related to the tracing of cri requests.
+type TracingConfig struct {
+	// Enabled enables tracing the phases of cri requests.
+	Enabled bool `toml:"enabled" json:"enabled"`
+	// Threshold is the minimum duration of a request to be exported, e.g.
+	// "1s". Traces of faster requests are dropped.
+	Threshold string `toml:"threshold" json:"threshold"`
+	// Exporter is where traces are exported, "log" or "otlp".
+	Exporter string `toml:"exporter" json:"exporter"`
+	// OTLPEndpoint is the OTLP/HTTP traces endpoint of the collector of the
+	// "otlp" exporter.
+	OTLPEndpoint string `toml:"otlp_endpoint" json:"otlpEndpoint"`
+}
+
+// AuditConfig contains config related to the audit log of cri requests.
+type AuditConfig struct {
+	// Enabled enables recording every cri request into the audit log.
+	Enabled bool `toml:"enabled" json:"enabled"`
+	// Backend is where audit records are written, "file" or "journald".
+	Backend string `toml:"backend" json:"backend"`
+	// Path is the path of the audit log file of the "file" backend.
+	Path string `toml:"path" json:"path"`
+	// MaxSizeMB is the size of the audit log file in megabytes at which it
+	// is rotated. 0 means the file is never rotated.
+	MaxSizeMB int `toml:"max_size_mb" json:"maxSizeMB"`
+	// MaxBackups is the number of rotated audit log files kept, e.g.
+	// "audit.log.1".
+	MaxBackups int `toml:"max_backups" json:"maxBackups"`
+}
+
+// InstanceConfig contains the config of an additional cri instance, which is
+// run by the same containerd process with an isolated view of sandboxes,
+// containers and images. Options not set here are the same as the plugin
+// config.
+type InstanceConfig struct {
+	// Namespace is the containerd namespace of the instance, which must not
+	// be used by any other instance.
+	Namespace string `toml:"namespace" json:"namespace"`
+	// Address is the path of the unix socket serving the cri services of the
+	// instance, e.g. "/run/containerd/cri-user.sock".
+	Address string `toml:"address" json:"address"`
+	// StreamServerPort is the port of the streaming server of the instance.
+	// Empty means a random port.
+	StreamServerPort string `toml:"stream_server_port" json:"streamServerPort"`
+	// NetworkPluginConfDir overrides the cni conf dir of the instance.
+	NetworkPluginConfDir string `toml:"cni_conf_dir" json:"cniConfDir"`
+}
+
+// RateLimit contains the limits of requests of a cri rpc. Zero values mean
+// no limit.
+type RateLimit struct {
+	// QPS is the sustained number of requests per second.
+	QPS float64 `toml:"qps" json:"qps"`
+	// Burst is the number of requests allowed at once over QPS. It defaults
+	// to QPS rounded up.
+	Burst int `toml:"burst" json:"burst"`
+	// MaxConcurrent is the maximum number of requests in progress.
+	MaxConcurrent int `toml:"max_concurrent" json:"maxConcurrent"`
+}
+
+// ExecLimitsConfig contains limits of exec sessions in containers, both
+// ExecSync and streaming exec. Zero values mean no limit.
+type ExecLimitsConfig struct {
+	// MaxSessionsPerContainer is the maximum number of concurrent exec
+	// sessions in a container. New sessions are rejected over the limit.
+	MaxSessionsPerContainer int `toml:"max_sessions_per_container" json:"maxSessionsPerContainer"`
+	// MaxSessionDuration is the maximum duration of an exec session, e.g.
+	// "1h". The exec process is killed when it is exceeded.
+	MaxSessionDuration string `toml:"max_session_duration" json:"maxSessionDuration"`
+	// MaxOutputBytes is the maximum number of stdout and stderr bytes of an
+	// exec session. The exec process is killed when it is exceeded.
+	MaxOutputBytes int64 `toml:"max_output_bytes" json:"maxOutputBytes"`
+	// MaxExecSyncCapturedBytes is the maximum number of bytes of each of
+	// stdout and stderr captured in memory for an ExecSync response. Output
+	// over it is dropped and a truncation marker is appended, the exec
+	// process is not killed.
+	MaxExecSyncCapturedBytes int64 `toml:"max_exec_sync_captured_bytes" json:"maxExecSyncCapturedBytes"`
+	// SpillExecSyncOutput writes the whole output of a truncated ExecSync
+	// stream into the container root directory. Only the latest truncated
+	// output of each stream is kept.
+	SpillExecSyncOutput bool `toml:"spill_exec_sync_output" json:"spillExecSyncOutput"`
+}
+
+// SpecHooksConfig contains config of the spec hooks, which adjust the OCI spec
+// of sandboxes and containers. They are not node resource interface (NRI)
+// plugins.
+type SpecHooksConfig struct {
+	// PluginDir is the directory of the plugin binaries.
+	PluginDir string `toml:"plugin_dir" json:"pluginDir"`
+	// Plugins are the names of the plugins in the plugin directory, which are
+	// invoked in order.
+	Plugins []string `toml:"plugins" json:"plugins"`
+	// PluginTimeout is the timeout of a plugin invocation, e.g. "2s".
+	PluginTimeout string `toml:"plugin_timeout" json:"pluginTimeout"`
+}
+
+// GRPCConfig contains config related to the dedicated grpc server of the cri
+// plugin.
+type GRPCConfig struct {
+	// Address is the path of a unix socket on which cri services are served
+	// by a dedicated grpc server with the options below. Empty means cri
+	// services are only served on the containerd socket, whose options are
+	// configured in the containerd "grpc" section.
+	Address string `toml:"address" json:"address"`
+	// MaxRecvMessageSize is the maximum message size in bytes the server can
+	// receive.
+	MaxRecvMessageSize int `toml:"max_recv_message_size" json:"maxRecvMessageSize"`
+	// MaxSendMessageSize is the maximum message size in bytes the server can
+	// send, e.g. of a ListContainers response.
+	MaxSendMessageSize int `toml:"max_send_message_size" json:"maxSendMessageSize"`
+	// MaxConcurrentStreams is the maximum number of concurrent streams of each
+	// client connection. 0 means no limit.
+	MaxConcurrentStreams uint32 `toml:"max_concurrent_streams" json:"maxConcurrentStreams"`
+	// KeepaliveTime is the idle time after which the server pings the client,
+	// e.g. "2h". Empty means the grpc default.
+	KeepaliveTime string `toml:"keepalive_time" json:"keepaliveTime"`
+	// KeepaliveTimeout is the time the server waits for a ping ack before
+	// closing the connection, e.g. "20s". Empty means the grpc default.
+	KeepaliveTimeout string `toml:"keepalive_timeout" json:"keepaliveTimeout"`
+	// KeepaliveMinTime is the minimum time clients should wait between pings,
+	// e.g. "5m". Connections of clients pinging more often are closed. Empty
+	// means the grpc default.
+	KeepaliveMinTime string `toml:"keepalive_min_time" json:"keepaliveMinTime"`
+	// KeepalivePermitWithoutStream allows clients to ping when there is no
+	// active stream.
+	KeepalivePermitWithoutStream bool `toml:"keepalive_permit_without_stream" json:"keepalivePermitWithoutStream"`
+	// AllowedUIDs are the uids of the processes allowed to connect to the
+	// socket. Connections of other processes are closed, unless their gid is
+	// allowed. All processes are allowed if both lists are empty.
+	AllowedUIDs []uint32 `toml:"allowed_uids" json:"allowedUIDs"`
+	// AllowedGIDs are the primary gids of the processes allowed to connect to
+	// the socket.
+	AllowedGIDs []uint32 `toml:"allowed_gids" json:"allowedGIDs"`
+}
+
+// OperationTimeouts contains the timeouts of the internal phases of sandbox
+// and container operations, e.g. "30s". Empty means no timeout other than
+// the deadline of the request.
+type OperationTimeouts struct {
+	// ImageResolve is the timeout of ensuring the images of a sandbox or
+	// container exist, which may pull them.
+	ImageResolve string `toml:"image_resolve" json:"imageResolve"`
+	// SnapshotPrepare is the timeout of creating the containerd container
+	// and its rootfs snapshot.
+	SnapshotPrepare string `toml:"snapshot_prepare" json:"snapshotPrepare"`
+	// CNISetup is the timeout of setting up the networks of a sandbox.
+	CNISetup string `toml:"cni_setup" json:"cniSetup"`
+	// TaskStart is the timeout of creating and starting the containerd task.
+	TaskStart string `toml:"task_start" json:"taskStart"`
+}
+
+// IDMapping is a mapping of a range of container user or group ids to host ids.
+type IDMapping struct {
+	// ContainerID is the first id of the range in the user namespace.
+	ContainerID uint32 `toml:"container_id" json:"containerID"`
+	// HostID is the first id of the range on the host.
+	HostID uint32 `toml:"host_id" json:"hostID"`
+	// Size is the size of the range.
+	Size uint32 `toml:"size" json:"size"`
+}
+
+// UserNamespaceConfig contains config of the user namespaces of pods which opt
+// in to run in their own user namespace. Each pod is allocated its own range
+// of host ids, which is mapped to the container ids starting at 0.
+type UserNamespaceConfig struct {
+	// HostIDStart is the first host id of the ranges allocated to pods.
+	HostIDStart uint32 `toml:"host_id_start" json:"hostIDStart"`
+	// HostIDCount is the number of host ids of the ranges allocated to pods.
+	// 0 disables user namespaces of pods.
+	HostIDCount uint32 `toml:"host_id_count" json:"hostIDCount"`
+	// PodIDCount is the number of ids mapped in the user namespace of a pod.
+	PodIDCount uint32 `toml:"pod_id_count" json:"podIDCount"`
 }
 
 // PluginConfig contains toml config related to CRI plugin,
@@ -90,16 +535,163 @@ type PluginConfig struct {
 	EnableSelinux bool `toml:"enable_selinux" json:"enableSelinux"`
 	// SandboxImage is the image used by sandbox container.
 	SandboxImage string `toml:"sandbox_image" json:"sandboxImage"`
+	// ReloadableConfigPath is the path of a toml file overriding the log
+	// level, sandbox image, registry mirrors and registry configs, which is
+	// reloaded on SIGHUP or when it is changed. Empty disables reloading.
+	ReloadableConfigPath string `toml:"reloadable_config_path" json:"reloadableConfigPath"`
+	// PinnedImages are references of images which are never removed by image
+	// garbage collection or RemoveImage, in addition to the sandbox image.
+	PinnedImages []string `toml:"pinned_images" json:"pinnedImages"`
+	// ImageVerificationPolicy is the path of the JSON policy file verifying
+	// image signatures in PullImage. Empty means images are not verified.
+	ImageVerificationPolicy string `toml:"image_verification_policy" json:"imageVerificationPolicy"`
+	// OCIHooks are OCI hooks injected into all containers.
+	OCIHooks OCIHooks `toml:"oci_hooks" json:"ociHooks"`
+	// AnnotatedOCIHooks are named OCI hooks, which are only injected into
+	// containers of pods listing the name in the oci hooks annotation.
+	AnnotatedOCIHooks map[string]OCIHooks `toml:"annotated_oci_hooks" json:"annotatedOCIHooks"`
+	// EnableCDI enables injecting the Container Device Interface devices
+	// requested by container annotations into the container spec.
+	EnableCDI bool `toml:"enable_cdi" json:"enableCDI"`
+	// CDISpecDirs are the directories of the CDI spec files, a device in a
+	// later directory overrides the one in an earlier directory.
+	CDISpecDirs []string `toml:"cdi_spec_dirs" json:"cdiSpecDirs"`
+	// DefaultRlimits are the resource limits of all containers, which replace
+	// the runtime defaults. They can be overridden per pod with an annotation.
+	DefaultRlimits []Rlimit `toml:"default_rlimits" json:"defaultRlimits"`
+	// RestrictUnsafeSysctls rejects the sysctls of pods which are neither safe
+	// nor in AllowedUnsafeSysctls. Otherwise all namespaced sysctls are
+	// allowed, and kubelet is relied on to restrict them.
+	RestrictUnsafeSysctls bool `toml:"restrict_unsafe_sysctls" json:"restrictUnsafeSysctls"`
+	// AllowedUnsafeSysctls are the unsafe sysctls allowed in pods, in addition
+	// to the safe ones, if RestrictUnsafeSysctls is set. A pattern ending with
+	// "*" allows sysctls with the prefix.
+	AllowedUnsafeSysctls []string `toml:"allowed_unsafe_sysctls" json:"allowedUnsafeSysctls"`
+	// AllowedNetNSDirs are the directories of the network namespaces created
+	// by external network agents, which pods may join with an annotation
+	// instead of setting up their network with CNI. Empty means pods can't
+	// join external network namespaces.
+	AllowedNetNSDirs []string `toml:"allowed_netns_dirs" json:"allowedNetNSDirs"`
+	// CheckpointDir is the directory of the checkpoint archives containers
+	// may be restored from with an annotation. Empty means containers can't
+	// be restored from checkpoints.
+	CheckpointDir string `toml:"checkpoint_dir" json:"checkpointDir"`
+	// HostNetworkDNSPassthrough makes the resolv.conf of host network
+	// sandboxes without dns config follow the host resolv.conf. It is
+	// rewritten when the host one changes, which is checked periodically and
+	// on UpdateRuntimeConfig.
+	HostNetworkDNSPassthrough bool `toml:"host_network_dns_passthrough" json:"hostNetworkDNSPassthrough"`
+	// DefaultCapabilities are the capabilities of non-privileged containers
+	// before the CRI add and drop capabilities apply, which replace the
+	// containerd defaults. "ALL" means all capabilities.
+	DefaultCapabilities []string `toml:"default_capabilities" json:"defaultCapabilities"`
+	// MaskedPaths are the masked paths of non-privileged containers.
+	MaskedPaths ProcPaths `toml:"masked_paths" json:"maskedPaths"`
+	// ReadonlyPaths are the readonly paths of non-privileged containers.
+	ReadonlyPaths ProcPaths `toml:"readonly_paths" json:"readonlyPaths"`
 	// StatsCollectPeriod is the period (in seconds) of snapshots stats collection.
 	StatsCollectPeriod int `toml:"stats_collect_period" json:"statsCollectPeriod"`
+	// StatsMaxStaleness is the max age (in seconds) of the cached disk usage
+	// of active snapshots, e.g. container writable layers. The usage of an
+	// active snapshot is only collected again when it would be older than
+	// this by the next collection, which spreads the collection over several
+	// periods. The usage is collected in every period if it is not larger
+	// than the period.
+	StatsMaxStaleness int `toml:"stats_max_staleness" json:"statsMaxStaleness"`
 	// SystemdCgroup enables systemd cgroup support.
 	SystemdCgroup bool `toml:"systemd_cgroup" json:"systemdCgroup"`
+	// ManagePodCgroup enables creating the pod level cgroup from the cgroup
+	// parent of the sandbox, and removing it when the sandbox is removed. The
+	// cgroup driver is selected by SystemdCgroup.
+	ManagePodCgroup bool `toml:"manage_pod_cgroup" json:"managePodCgroup"`
+	// PodPIDReaper makes containers which would have their own pid namespace
+	// share the pod pid namespace instead, whose init process is the pause
+	// container. The pause container reaps the zombie processes left by the
+	// containers, e.g. by exec sessions.
+	PodPIDReaper bool `toml:"pod_pid_reaper" json:"podPIDReaper"`
+	// SandboxCleanupRetries is the number of times the rollback of a failed
+	// RunPodSandbox step, e.g. the network teardown, or of the pod cgroup
+	// removal in RemovePodSandbox, is retried in the background before it is
+	// given up. 0 means no retry.
+	SandboxCleanupRetries int `toml:"sandbox_cleanup_retries" json:"sandboxCleanupRetries"`
+	// MaxPrePullConcurrency is the maximum number of images pulled at the
+	// same time by a PrePullImages request. The concurrency requested by the
+	// client is capped at it.
+	MaxPrePullConcurrency int `toml:"max_pre_pull_concurrency" json:"maxPrePullConcurrency"`
+	// StreamIdleTimeout is the maximum time a streaming connection
+	// can be idle before the connection is automatically closed.
+	StreamIdleTimeout string `toml:"stream_idle_timeout" json:"streamIdleTimeout"`
 	// EnableTLSStreaming indicates to enable the TLS streaming support.
 	EnableTLSStreaming bool `toml:"enable_tls_streaming" json:"enableTLSStreaming"`
+	// X509KeyPairStreaming is a x509 key pair used for TLS streaming. A self-signed
+	// certificate is generated if it is not set.
+	X509KeyPairStreaming `toml:"x509_key_pair_streaming" json:"x509KeyPairStreaming"`
 	// MaxContainerLogLineSize is the maximum log line size in bytes for a container.
 	// Log line longer than the limit will be split into multiple lines. Non-positive
 	// value means no limit.
 	MaxContainerLogLineSize int `toml:"max_container_log_line_size" json:"maxContainerLogSize"`
+	// ContainerLogFormat is the format of container log files, "cri" or
+	// "json". It can be overridden per pod with a sandbox annotation.
+	ContainerLogFormat string `toml:"container_log_format" json:"containerLogFormat"`
+	// MaxContainerLogFileSize is the maximum size in bytes of a container log
+	// file, after which the file is rotated by the cri plugin. Non-positive
+	// value means no limit, and the log file is expected to be rotated by
+	// kubelet.
+	MaxContainerLogFileSize int64 `toml:"max_container_log_file_size" json:"maxContainerLogFileSize"`
+	// DefaultTmpfsSize is the size in bytes of the tmpfs mounts of CRI mounts
+	// without a host path, unless set by annotation. Zero leaves it to the
+	// kernel default of half of the RAM.
+	DefaultTmpfsSize int64 `toml:"default_tmpfs_size" json:"defaultTmpfsSize"`
+	// VolumeOwnershipPolicy is when the writable directory volumes of a
+	// container are chowned to its runAsUser and the fs group of the pod:
+	// "on_demand" if the volume root doesn't have the ownership yet, or
+	// "always". Empty disables it.
+	VolumeOwnershipPolicy string `toml:"volume_ownership_policy" json:"volumeOwnershipPolicy"`
+	// VolumeOwnershipDirs are the host directories, besides the kubelet volume
+	// directory of the pod, which volumes chowned by VolumeOwnershipPolicy
+	// can be in.
+	VolumeOwnershipDirs []string `toml:"volume_ownership_dirs" json:"volumeOwnershipDirs"`
+	// MaxContainerLogFiles is the number of rotated container log files kept,
+	// e.g. "0.log.1". With 0, the log file is truncated in place instead.
+	MaxContainerLogFiles int `toml:"max_container_log_files" json:"maxContainerLogFiles"`
+	// DeviceOwnershipFromSecurityContext sets the uid/gid of container devices from
+	// RunAsUser/RunAsGroup of the container security context, instead of the uid/gid
+	// of the devices on the host.
+	DeviceOwnershipFromSecurityContext bool `toml:"device_ownership_from_security_context" json:"deviceOwnershipFromSecurityContext"`
+	// PluginRootDir overrides the root directory of persistent plugin files,
+	// e.g. sandbox and container checkpoints. The content of the default one
+	// is migrated into it.
+	PluginRootDir string `toml:"plugin_root_dir" json:"pluginRootDir"`
+	// PluginStateDir overrides the state directory of volatile plugin files,
+	// e.g. container io fifos. The content of the default one is migrated
+	// into it, which requires that no sandbox or container exists.
+	PluginStateDir string `toml:"plugin_state_dir" json:"pluginStateDir"`
+	// ImageGC contains config related to image garbage collection.
+	ImageGC ImageGCConfig `toml:"image_gc" json:"imageGC"`
+	// OrphanGC contains config related to orphan garbage collection.
+	OrphanGC OrphanGCConfig `toml:"orphan_gc" json:"orphanGC"`
+	// UserNamespace contains config of the user namespace of pods.
+	UserNamespace UserNamespaceConfig `toml:"user_namespace" json:"userNamespace"`
+	// Tracing contains config related to the tracing of cri requests.
+	Tracing TracingConfig `toml:"tracing" json:"tracing"`
+	// GRPC contains config related to the dedicated grpc server.
+	GRPC GRPCConfig `toml:"grpc" json:"grpc"`
+	// OperationTimeouts contains the timeouts of the internal phases of
+	// sandbox and container operations.
+	OperationTimeouts OperationTimeouts `toml:"operation_timeouts" json:"operationTimeouts"`
+	// ExecLimits contains limits of exec sessions in containers.
+	ExecLimits ExecLimitsConfig `toml:"exec_limits" json:"execLimits"`
+	// SpecHooks contains config of the spec hooks.
+	SpecHooks SpecHooksConfig `toml:"spec_hooks" json:"specHooks"`
+	// Audit contains config related to the audit log of cri requests.
+	Audit AuditConfig `toml:"audit" json:"audit"`
+	// RateLimits are the limits of requests keyed by cri rpc, e.g.
+	// "ListContainers" or "PullImage". Requests over the limits are rejected
+	// with ResourceExhausted.
+	RateLimits map[string]RateLimit `toml:"rate_limits" json:"rateLimits"`
+	// Instances are the additional cri instances keyed by name, e.g. to run
+	// system and user workloads with separate kubelets.
+	Instances map[string]InstanceConfig `toml:"instances" json:"instances"`
 }
 
 // Config contains all configurations for cri server.
@@ -126,6 +718,7 @@ func DefaultConfig() PluginConfig {
 			NetworkPluginConfTemplate: "",
 		},
 		ContainerdConfig: ContainerdConfig{
+			Namespace:   constants.K8sContainerdNamespace,
 			Snapshotter: containerd.DefaultSnapshotter,
 			DefaultRuntime: Runtime{
 				Type:   "io.containerd.runtime.v1.linux",
@@ -135,12 +728,53 @@ func DefaultConfig() PluginConfig {
 		},
 		StreamServerAddress:     "",
 		StreamServerPort:        "10010",
+		StreamIdleTimeout:       "4h0m0s", // 4 hour
 		EnableSelinux:           false,
 		EnableTLSStreaming:      false,
 		SandboxImage:            "k8s.gcr.io/pause:3.1",
 		StatsCollectPeriod:      10,
 		SystemdCgroup:           false,
 		MaxContainerLogLineSize: 16 * 1024,
+		MaxContainerLogFiles:    1,
+		SandboxCleanupRetries:   5,
+		MaxPrePullConcurrency:   5,
+		ContainerLogFormat:      "cri",
+		ImageGC: ImageGCConfig{
+			Enabled:              false,
+			Period:               "5m",
+			HighThresholdPercent: 85,
+			LowThresholdPercent:  80,
+		},
+		UserNamespace: UserNamespaceConfig{
+			HostIDStart: 100000,
+			HostIDCount: 0,
+			PodIDCount:  65536,
+		},
+		Tracing: TracingConfig{
+			Enabled:      false,
+			Threshold:    "1s",
+			Exporter:     TracingExporterLog,
+			OTLPEndpoint: "http://localhost:4318/v1/traces",
+		},
+		ExecLimits: ExecLimitsConfig{
+			MaxExecSyncCapturedBytes: 4 * 1024 * 1024,
+		},
+		SpecHooks: SpecHooksConfig{
+			PluginDir:     "/opt/containerd/spec-hooks",
+			PluginTimeout: "2s",
+		},
+		Audit: AuditConfig{
+			Enabled:    false,
+			Backend:    "file",
+			Path:       "/var/log/containerd/cri-audit.log",
+			MaxSizeMB:  100,
+			MaxBackups: 5,
+		},
+		CDISpecDirs: []string{"/etc/cdi", "/var/run/cdi"},
+		GRPC: GRPCConfig{
+			MaxRecvMessageSize: 16 * 1024 * 1024,
+			MaxSendMessageSize: 16 * 1024 * 1024,
+		},
 		Registry: Registry{
 			Mirrors: map[string]Mirror{
 				"docker.io": {
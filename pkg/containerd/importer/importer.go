@@ -32,6 +32,7 @@ import (
 	"github.com/containerd/containerd/images"
 	"github.com/containerd/containerd/leases"
 	"github.com/containerd/containerd/log"
+	"github.com/containerd/containerd/namespaces"
 	"github.com/opencontainers/go-digest"
 	"github.com/opencontainers/image-spec/specs-go"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -94,12 +95,16 @@ type imageConfig struct {
 // which is not explicitly documented in the spec. (e.g. foobar/layer.tar)
 // It returns a group of image references successfully loaded.
 func Import(ctx context.Context, client *containerd.Client, reader io.Reader) (_ []string, retErr error) {
+	namespace, err := namespaces.NamespaceRequired(ctx)
+	if err != nil {
+		return nil, err
+	}
 	ctx, done, err := client.WithLease(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer func() {
-		deferCtx, deferCancel := ctrdutil.DeferContext()
+		deferCtx, deferCancel := ctrdutil.DeferContext(namespace)
 		defer deferCancel()
 		if err := done(deferCtx); err != nil {
 			// Get lease id from context still works after context is done.
@@ -177,7 +182,7 @@ func Import(ctx context.Context, client *containerd.Client, reader io.Reader) (_
 		// even when there is an error.
 		for _, ref := range refs {
 			func() {
-				deferCtx, deferCancel := ctrdutil.DeferContext()
+				deferCtx, deferCancel := ctrdutil.DeferContext(namespace)
 				defer deferCancel()
 				if err := is.Delete(deferCtx, ref); err != nil {
 					log.G(ctx).WithError(err).Errorf("Failed to remove image %q", ref)
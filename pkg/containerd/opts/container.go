@@ -18,15 +18,18 @@ package opts
 
 import (
 	"context"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/content"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/mount"
 	"github.com/containerd/continuity/fs"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -34,7 +37,19 @@ import (
 // WithNewSnapshot wraps `containerd.WithNewSnapshot` so that if creating the
 // snapshot fails we make sure the image is actually unpacked and and retry.
 func WithNewSnapshot(id string, i containerd.Image) containerd.NewContainerOpts {
-	f := containerd.WithNewSnapshot(id, i)
+	return withUnpackRetry(containerd.WithNewSnapshot(id, i), i)
+}
+
+// WithRemappedSnapshot wraps `containerd.WithRemappedSnapshot` the same way
+// as WithNewSnapshot. The files in the snapshot are owned by the host uid and
+// gid container root is mapped to.
+func WithRemappedSnapshot(id string, i containerd.Image, uid, gid uint32) containerd.NewContainerOpts {
+	return withUnpackRetry(containerd.WithRemappedSnapshot(id, i, uid, gid), i)
+}
+
+// withUnpackRetry retries a snapshot creating option after unpacking the image,
+// if it fails because the image is not unpacked.
+func withUnpackRetry(f containerd.NewContainerOpts, i containerd.Image) containerd.NewContainerOpts {
 	return func(ctx context.Context, client *containerd.Client, c *containers.Container) error {
 		if err := f(ctx, client, c); err != nil {
 			if !errdefs.IsNotFound(err) {
@@ -104,6 +119,41 @@ func WithVolumes(volumeMounts map[string]string) containerd.NewContainerOpts {
 	}
 }
 
+// WithCheckpointRWLayer applies the read-write layer saved in a checkpoint
+// image onto the container rootfs. It must be used after the rootfs snapshot
+// is created, e.g. by WithNewSnapshot.
+func WithCheckpointRWLayer(checkpoint containerd.Image) containerd.NewContainerOpts {
+	return func(ctx context.Context, client *containerd.Client, c *containers.Container) error {
+		if c.Snapshotter == "" {
+			return errors.New("no snapshotter set for container")
+		}
+		if c.SnapshotKey == "" {
+			return errors.New("rootfs not created for container")
+		}
+		p, err := content.ReadBlob(ctx, client.ContentStore(), checkpoint.Target())
+		if err != nil {
+			return errors.Wrap(err, "failed to read checkpoint index")
+		}
+		var index imagespec.Index
+		if err := json.Unmarshal(p, &index); err != nil {
+			return errors.Wrap(err, "failed to unmarshal checkpoint index")
+		}
+		for _, m := range index.Manifests {
+			if m.MediaType != imagespec.MediaTypeImageLayer && m.MediaType != imagespec.MediaTypeImageLayerGzip {
+				continue
+			}
+			mounts, err := client.SnapshotService(c.Snapshotter).Mounts(ctx, c.SnapshotKey)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get mounts for %q", c.SnapshotKey)
+			}
+			if _, err := client.DiffService().Apply(ctx, m, mounts); err != nil {
+				return errors.Wrap(err, "failed to apply checkpoint rw layer")
+			}
+		}
+		return nil
+	}
+}
+
 // copyExistingContents copies from the source to the destination and
 // ensures the ownership is appropriately set.
 func copyExistingContents(source, destination string) error {
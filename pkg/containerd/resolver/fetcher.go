@@ -64,19 +64,24 @@ func (r dockerFetcher) Fetch(ctx context.Context, desc ocispec.Descriptor) (io.R
 	}
 
 	return newHTTPReadSeeker(desc.Size, func(offset int64) (io.ReadCloser, error) {
+		var lastErr error
 		for _, u := range urls {
 			rc, err := r.open(ctx, u, desc.MediaType, offset)
 			if err != nil {
-				if errdefs.IsNotFound(err) {
-					continue // try one of the other urls.
+				if !errdefs.IsNotFound(err) {
+					// Fall back to the next endpoint if the current one is unavailable.
+					log.G(ctx).WithError(err).WithField("url", u).Warn("failed to fetch from url")
+					lastErr = err
 				}
-
-				return nil, err
+				continue // try one of the other urls.
 			}
 
 			return rc, nil
 		}
 
+		if lastErr != nil {
+			return nil, lastErr
+		}
 		return nil, errors.Wrapf(errdefs.ErrNotFound,
 			"could not fetch content descriptor %v (%v) from remote",
 			desc.Digest, desc.MediaType)
@@ -30,6 +30,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/images"
 	"github.com/containerd/containerd/log"
 	"github.com/containerd/containerd/reference"
@@ -70,6 +71,7 @@ type containerdResolver struct {
 	client      *http.Client
 	tracker     StatusTracker
 	registry    map[string][]string
+	noUpstream  map[string]bool
 }
 
 // Options are used to configured a new Docker register resolver
@@ -91,6 +93,10 @@ type Options struct {
 	Tracker StatusTracker
 
 	Registry map[string][]string
+
+	// NoUpstream disables falling back to the upstream registry of the
+	// registry hosts set to true when none of their Registry endpoints works.
+	NoUpstream map[string]bool
 }
 
 // NewResolver returns a new resolver to a Docker registry
@@ -106,6 +112,7 @@ func NewResolver(options Options) remotes.Resolver {
 		client:      options.Client,
 		tracker:     tracker,
 		registry:    options.Registry,
+		noUpstream:  options.NoUpstream,
 	}
 }
 
@@ -155,6 +162,9 @@ func (r *containerdResolver) Resolve(ctx context.Context, ref string) (string, o
 	if err != nil {
 		return "", ocispec.Descriptor{}, err
 	}
+	// lastErr is the error of the last unavailable endpoint, it is returned
+	// if none of the endpoints works.
+	var lastErr error
 	for _, u := range urls {
 		log.G(ctx).WithFields(logrus.Fields{
 			"url": u,
@@ -174,7 +184,10 @@ func (r *containerdResolver) Resolve(ctx context.Context, ref string) (string, o
 		log.G(ctx).Info("resolving")
 		resp, err := fetcher.doRequestWithRetries(ctx, req, nil)
 		if err != nil {
-			return "", ocispec.Descriptor{}, err
+			// Try the next endpoint if the current one is unavailable.
+			log.G(ctx).WithError(err).WithField("url", u).Warn("failed to resolve from url")
+			lastErr = err
+			continue
 		}
 		resp.Body.Close() // don't care about body contents.
 
@@ -182,7 +195,12 @@ func (r *containerdResolver) Resolve(ctx context.Context, ref string) (string, o
 			if resp.StatusCode == http.StatusNotFound {
 				continue
 			}
-			return "", ocispec.Descriptor{}, errors.Errorf("unexpected status code %v: %v", u, resp.Status)
+			// Try the next endpoint on any other error, e.g. a mirror
+			// rejecting the request or the credentials of the upstream
+			// registry.
+			lastErr = errors.Errorf("unexpected status code %v: %v", u, resp.Status)
+			log.G(ctx).WithError(lastErr).Warn("failed to resolve from url")
+			continue
 		}
 
 		// this is the only point at which we trust the registry. we use the
@@ -225,7 +243,10 @@ func (r *containerdResolver) Resolve(ctx context.Context, ref string) (string, o
 		return ref, desc, nil
 	}
 
-	return "", ocispec.Descriptor{}, errors.Errorf("%v not found", ref)
+	if lastErr != nil {
+		return "", ocispec.Descriptor{}, lastErr
+	}
+	return "", ocispec.Descriptor{}, errors.Wrapf(errdefs.ErrNotFound, "%v", ref)
 }
 
 func (r *containerdResolver) Fetcher(ctx context.Context, ref string) (remotes.Fetcher, error) {
@@ -275,14 +296,12 @@ func (r *containerdResolver) base(refspec reference.Spec) (*dockerBase, error) {
 			return nil, errors.Wrap(err, "failed to fetch v2 urls")
 		}
 		base = append(base, urls...)
-	} else if host == "docker.io" {
-		base = append(base, []url.URL{{Host: "registry-1.docker.io", Scheme: "https", Path: path.Join("/v2", prefix)}}...)
-	} else {
-		scheme := "https"
-		if r.plainHTTP || strings.HasPrefix(host, "localhost:") {
-			scheme = "http"
-		}
-		base = append(base, []url.URL{{Host: host, Scheme: scheme, Path: path.Join("/v2", prefix)}}...)
+	}
+	// Fall back to the upstream registry, so that image pull still works
+	// when none of the mirrors are available.
+	upstream := r.defaultURL(host, prefix)
+	if (!r.noUpstream[host] || len(base) == 0) && !containsURL(base, upstream) {
+		base = append(base, upstream)
 	}
 
 	if r.credentials != nil {
@@ -608,6 +627,28 @@ func (r *dockerBase) fetchToken(ctx context.Context, to tokenOptions) (string, e
 	return tr.Token, nil
 }
 
+// defaultURL returns the upstream registry url of an image repository.
+func (r *containerdResolver) defaultURL(host, imagePath string) url.URL {
+	if host == "docker.io" {
+		return url.URL{Host: "registry-1.docker.io", Scheme: "https", Path: path.Join("/v2", imagePath)}
+	}
+	scheme := "https"
+	if r.plainHTTP || strings.HasPrefix(host, "localhost:") {
+		scheme = "http"
+	}
+	return url.URL{Host: host, Scheme: scheme, Path: path.Join("/v2", imagePath)}
+}
+
+// containsURL checks whether a url is in the url list.
+func containsURL(urls []url.URL, u url.URL) bool {
+	for _, c := range urls {
+		if c.String() == u.String() {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *containerdResolver) getV2Urls(urls []string, imagePath string) ([]url.URL, error) {
 	v2Urls := []url.URL{}
 	for _, u := range urls {
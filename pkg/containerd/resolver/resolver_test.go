@@ -0,0 +1,129 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containerd/containerd/reference"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseURLs(t *testing.T) {
+	for desc, test := range map[string]struct {
+		ref        string
+		registry   map[string][]string
+		noUpstream map[string]bool
+		expected   []string
+	}{
+		"docker hub without mirror": {
+			ref:      "docker.io/library/busybox:latest",
+			expected: []string{"https://registry-1.docker.io/v2/library/busybox"},
+		},
+		"docker hub with mirror should fall back to upstream": {
+			ref: "docker.io/library/busybox:latest",
+			registry: map[string][]string{
+				"docker.io": {"http://mirror-1.local", "https://mirror-2.local"},
+			},
+			expected: []string{
+				"http://mirror-1.local/v2/library/busybox",
+				"https://mirror-2.local/v2/library/busybox",
+				"https://registry-1.docker.io/v2/library/busybox",
+			},
+		},
+		"upstream in mirror list should not be duplicated": {
+			ref: "gcr.io/test/image:latest",
+			registry: map[string][]string{
+				"gcr.io": {"https://gcr.io", "https://mirror.local"},
+			},
+			expected: []string{
+				"https://gcr.io/v2/test/image",
+				"https://mirror.local/v2/test/image",
+			},
+		},
+		"mirror without upstream should not fall back": {
+			ref: "docker.io/library/busybox:latest",
+			registry: map[string][]string{
+				"docker.io": {"http://127.0.0.1:65001"},
+			},
+			noUpstream: map[string]bool{"docker.io": true},
+			expected:   []string{"http://127.0.0.1:65001/v2/library/busybox"},
+		},
+		"no upstream of another registry should fall back": {
+			ref: "docker.io/library/busybox:latest",
+			registry: map[string][]string{
+				"docker.io": {"http://127.0.0.1:65001"},
+			},
+			noUpstream: map[string]bool{"gcr.io": true},
+			expected: []string{
+				"http://127.0.0.1:65001/v2/library/busybox",
+				"https://registry-1.docker.io/v2/library/busybox",
+			},
+		},
+		"localhost registry should use plain http": {
+			ref:      "localhost:5000/test/image:latest",
+			expected: []string{"http://localhost:5000/v2/test/image"},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		r := &containerdResolver{registry: test.registry, noUpstream: test.noUpstream}
+		refspec, err := reference.Parse(test.ref)
+		require.NoError(t, err)
+		base, err := r.base(refspec)
+		require.NoError(t, err)
+		var urls []string
+		for _, u := range base.base {
+			urls = append(urls, u.String())
+		}
+		assert.Equal(t, test.expected, urls)
+	}
+}
+
+func TestResolveMirrorFallback(t *testing.T) {
+	const dgst = "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	for desc, test := range map[string]struct {
+		status int
+	}{
+		"should fall back on server error": {status: http.StatusServiceUnavailable},
+		"should fall back on forbidden":    {status: http.StatusForbidden},
+		"should fall back on not found":    {status: http.StatusNotFound},
+		"should fall back on bad request":  {status: http.StatusBadRequest},
+	} {
+		t.Logf("TestCase %q", desc)
+		failing := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(test.status)
+		}))
+		working := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("Docker-Content-Digest", dgst)
+			rw.Header().Set("Content-Length", "0")
+		}))
+		r := NewResolver(Options{
+			Client:     http.DefaultClient,
+			Registry:   map[string][]string{"docker.io": {failing.URL, working.URL}},
+			NoUpstream: map[string]bool{"docker.io": true},
+		})
+		_, d, err := r.Resolve(context.Background(), "docker.io/library/busybox:latest")
+		failing.Close()
+		working.Close()
+		require.NoError(t, err)
+		assert.Equal(t, dgst, d.Digest.String())
+	}
+}
@@ -21,8 +21,6 @@ import (
 
 	"github.com/containerd/containerd/namespaces"
 	"golang.org/x/net/context"
-
-	"github.com/containerd/cri/pkg/constants"
 )
 
 // deferCleanupTimeout is the default timeout for containerd cleanup operations
@@ -31,16 +29,16 @@ const deferCleanupTimeout = 1 * time.Minute
 
 // DeferContext returns a context for containerd cleanup operations in defer.
 // A default timeout is applied to avoid cleanup operation pending forever.
-func DeferContext() (context.Context, context.CancelFunc) {
-	return context.WithTimeout(NamespacedContext(), deferCleanupTimeout)
+func DeferContext(namespace string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(NamespacedContext(namespace), deferCleanupTimeout)
 }
 
-// NamespacedContext returns a context with kubernetes namespace set.
-func NamespacedContext() context.Context {
-	return WithNamespace(context.Background())
+// NamespacedContext returns a context with the containerd namespace set.
+func NamespacedContext(namespace string) context.Context {
+	return WithNamespace(context.Background(), namespace)
 }
 
-// WithNamespace adds kubernetes namespace to the context.
-func WithNamespace(ctx context.Context) context.Context {
-	return namespaces.WithNamespace(ctx, constants.K8sContainerdNamespace)
+// WithNamespace adds the containerd namespace to the context.
+func WithNamespace(ctx context.Context, namespace string) context.Context {
+	return namespaces.WithNamespace(ctx, namespace)
 }
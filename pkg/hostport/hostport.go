@@ -0,0 +1,190 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostport
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"sync"
+
+	cni "github.com/containerd/go-cni"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DNATChain is the nat chain which forwards host ports to pods.
+	DNATChain = "CRI-HOSTPORT-DNAT"
+	// MasqChain is the nat chain which masquerades hairpin traffic, i.e.
+	// traffic from a pod to its own host port.
+	MasqChain = "CRI-HOSTPORT-MASQ"
+)
+
+// rule is an iptables rule in the nat table.
+type rule struct {
+	chain string
+	args  []string
+}
+
+// jumpRules are the rules jumping from the builtin chains to the host port
+// chains.
+var jumpRules = []rule{
+	{chain: "PREROUTING", args: []string{"-m", "addrtype", "--dst-type", "LOCAL", "-j", DNATChain}},
+	{chain: "OUTPUT", args: []string{"-m", "addrtype", "--dst-type", "LOCAL", "-j", DNATChain}},
+	{chain: "POSTROUTING", args: []string{"-j", MasqChain}},
+}
+
+// Manager programs host port mappings of pods with iptables. It is used
+// when there is no cni plugin handling the port mappings.
+type Manager struct {
+	mu sync.Mutex
+	// iptables runs an iptables command in the nat table.
+	iptables func(ipv6 bool, args ...string) error
+}
+
+// NewManager creates a new host port manager.
+func NewManager() *Manager {
+	return &Manager{iptables: runIPTables}
+}
+
+// Add programs the port mappings of a pod. It is idempotent, so that
+// mappings can be restored after a restart.
+func (m *Manager) Add(id, podIP string, mappings []cni.PortMapping) error {
+	if len(mappings) == 0 {
+		return nil
+	}
+	ipv6, err := isIPv6(podIP)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.ensureChains(ipv6); err != nil {
+		return err
+	}
+	for _, r := range podRules(id, podIP, mappings) {
+		if err := m.ensureRule(ipv6, r); err != nil {
+			m.removeRules(ipv6, podRules(id, podIP, mappings)) // nolint: errcheck
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove removes the port mappings of a pod. It is idempotent, and
+// succeeds if the mappings are already removed.
+func (m *Manager) Remove(id, podIP string, mappings []cni.PortMapping) error {
+	if len(mappings) == 0 {
+		return nil
+	}
+	ipv6, err := isIPv6(podIP)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.removeRules(ipv6, podRules(id, podIP, mappings))
+}
+
+// ensureChains creates the host port chains and the jump rules to them.
+func (m *Manager) ensureChains(ipv6 bool) error {
+	for _, chain := range []string{DNATChain, MasqChain} {
+		if err := m.iptables(ipv6, "-n", "-L", chain); err == nil {
+			continue
+		}
+		if err := m.iptables(ipv6, "-N", chain); err != nil {
+			return errors.Wrapf(err, "failed to create chain %q", chain)
+		}
+	}
+	for _, r := range jumpRules {
+		if err := m.ensureRule(ipv6, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureRule appends a rule if it doesn't exist.
+func (m *Manager) ensureRule(ipv6 bool, r rule) error {
+	if err := m.iptables(ipv6, append([]string{"-C", r.chain}, r.args...)...); err == nil {
+		return nil
+	}
+	if err := m.iptables(ipv6, append([]string{"-A", r.chain}, r.args...)...); err != nil {
+		return errors.Wrapf(err, "failed to add rule %v to chain %q", r.args, r.chain)
+	}
+	return nil
+}
+
+// removeRules deletes the rules which exist. It tries all the rules, and
+// returns the last error.
+func (m *Manager) removeRules(ipv6 bool, rules []rule) error {
+	var lastErr error
+	for _, r := range rules {
+		if err := m.iptables(ipv6, append([]string{"-C", r.chain}, r.args...)...); err != nil {
+			continue
+		}
+		if err := m.iptables(ipv6, append([]string{"-D", r.chain}, r.args...)...); err != nil {
+			lastErr = errors.Wrapf(err, "failed to delete rule %v from chain %q", r.args, r.chain)
+		}
+	}
+	return lastErr
+}
+
+// podRules returns the rules of the port mappings of a pod. Each rule is
+// commented with the pod id, so that the rules of different pods never
+// collide.
+func podRules(id, podIP string, mappings []cni.PortMapping) []rule {
+	comment := []string{"-m", "comment", "--comment", fmt.Sprintf("hostport %s", id)}
+	var rules []rule
+	for _, pm := range mappings {
+		containerPort := strconv.Itoa(int(pm.ContainerPort))
+		dnat := []string{"-p", pm.Protocol, "--dport", strconv.Itoa(int(pm.HostPort))}
+		if ip := net.ParseIP(pm.HostIP); ip != nil && !ip.IsUnspecified() {
+			dnat = append(dnat, "-d", pm.HostIP)
+		}
+		dnat = append(dnat, comment...)
+		dnat = append(dnat, "-j", "DNAT", "--to-destination", net.JoinHostPort(podIP, containerPort))
+		masq := []string{"-s", podIP, "-d", podIP, "-p", pm.Protocol, "--dport", containerPort}
+		masq = append(masq, comment...)
+		masq = append(masq, "-j", "MASQUERADE")
+		rules = append(rules, rule{chain: DNATChain, args: dnat}, rule{chain: MasqChain, args: masq})
+	}
+	return rules
+}
+
+func isIPv6(podIP string) (bool, error) {
+	ip := net.ParseIP(podIP)
+	if ip == nil {
+		return false, errors.Errorf("invalid pod ip %q", podIP)
+	}
+	return ip.To4() == nil, nil
+}
+
+// runIPTables runs iptables or ip6tables in the nat table. It waits for the
+// xtables lock, so that it doesn't fail when other programs hold the lock.
+func runIPTables(ipv6 bool, args ...string) error {
+	bin := "iptables"
+	if ipv6 {
+		bin = "ip6tables"
+	}
+	out, err := exec.Command(bin, append([]string{"-w", "-t", "nat"}, args...)...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "%s %v: %s", bin, args, out)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostport
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	cni "github.com/containerd/go-cni"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+// fakeIPTables keeps the nat table in memory.
+type fakeIPTables struct {
+	chains map[string][]string
+	ipv6   bool
+}
+
+func (f *fakeIPTables) run(ipv6 bool, args ...string) error {
+	f.ipv6 = ipv6
+	op, chain, spec := args[0], args[1], strings.Join(args[2:], " ")
+	if op == "-n" {
+		op, chain = args[1], args[2]
+	}
+	rules, ok := f.chains[chain]
+	switch op {
+	case "-L":
+		if !ok {
+			return errors.New("no chain")
+		}
+	case "-N":
+		if ok {
+			return errors.New("chain exists")
+		}
+		f.chains[chain] = nil
+	case "-C", "-D":
+		for i, r := range rules {
+			if r == spec {
+				if op == "-D" {
+					f.chains[chain] = append(rules[:i], rules[i+1:]...)
+				}
+				return nil
+			}
+		}
+		return errors.New("no rule")
+	case "-A":
+		f.chains[chain] = append(rules, spec)
+	}
+	return nil
+}
+
+func TestManager(t *testing.T) {
+	assert := assertlib.New(t)
+	ipt := &fakeIPTables{chains: map[string][]string{
+		"PREROUTING":  nil,
+		"OUTPUT":      nil,
+		"POSTROUTING": nil,
+	}}
+	m := &Manager{iptables: ipt.run}
+	mappings := []cni.PortMapping{
+		{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+		{HostPort: 5353, ContainerPort: 53, Protocol: "udp", HostIP: "192.168.1.1"},
+	}
+
+	t.Logf("should program the port mappings")
+	assert.NoError(m.Add("test-id", "10.0.0.2", mappings))
+	assert.False(ipt.ipv6)
+	assert.Equal([]string{
+		"-p tcp --dport 8080 -m comment --comment hostport test-id -j DNAT --to-destination 10.0.0.2:80",
+		"-p udp --dport 5353 -d 192.168.1.1 -m comment --comment hostport test-id -j DNAT --to-destination 10.0.0.2:53",
+	}, ipt.chains[DNATChain])
+	assert.Equal([]string{
+		"-s 10.0.0.2 -d 10.0.0.2 -p tcp --dport 80 -m comment --comment hostport test-id -j MASQUERADE",
+		"-s 10.0.0.2 -d 10.0.0.2 -p udp --dport 53 -m comment --comment hostport test-id -j MASQUERADE",
+	}, ipt.chains[MasqChain])
+	assert.Len(ipt.chains["PREROUTING"], 1)
+	assert.Len(ipt.chains["OUTPUT"], 1)
+	assert.Len(ipt.chains["POSTROUTING"], 1)
+
+	t.Logf("should not duplicate the rules when the mappings are restored")
+	assert.NoError(m.Add("test-id", "10.0.0.2", mappings))
+	assert.Len(ipt.chains[DNATChain], 2)
+	assert.Len(ipt.chains[MasqChain], 2)
+	assert.Len(ipt.chains["PREROUTING"], 1)
+
+	t.Logf("should remove the port mappings")
+	assert.NoError(m.Remove("test-id", "10.0.0.2", mappings))
+	assert.Empty(ipt.chains[DNATChain])
+	assert.Empty(ipt.chains[MasqChain])
+
+	t.Logf("should succeed when the port mappings are already removed")
+	assert.NoError(m.Remove("test-id", "10.0.0.2", mappings))
+
+	t.Logf("should use ip6tables for ipv6 pods")
+	assert.NoError(m.Add("test-id", "fd00::2", mappings[:1]))
+	assert.True(ipt.ipv6)
+	assert.Equal([]string{
+		"-p tcp --dport 8080 -m comment --comment hostport test-id -j DNAT --to-destination [fd00::2]:80",
+	}, ipt.chains[DNATChain])
+
+	t.Logf("should fail with invalid pod ip")
+	assert.Error(m.Add("test-id", "invalid", mappings))
+}
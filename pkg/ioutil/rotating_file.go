@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ioutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// rotatingFile is a file write closer which rotates the file when a write
+// would grow it over the max size. Rotated files are numbered by age, e.g.
+// "0.log.1" is the newest and "0.log.<maxFiles>" is the oldest, and older
+// ones are removed. With maxFiles 0, the file is truncated in place instead.
+// Each write goes into a single file, so a file is never split in the middle
+// of a write. It is not safe for concurrent use.
+type rotatingFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	f        *os.File
+	size     int64
+}
+
+// NewRotatingFile opens the file at path for appending, and rotates it when
+// its size would exceed maxSize. maxFiles is the number of rotated files kept.
+func NewRotatingFile(path string, maxSize int64, maxFiles int) (io.WriteCloser, error) {
+	if maxSize <= 0 {
+		return nil, errors.Errorf("invalid max size %d, must be positive", maxSize)
+	}
+	if maxFiles < 0 {
+		return nil, errors.Errorf("invalid max files %d, must not be negative", maxFiles)
+	}
+	r := &rotatingFile{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := r.open(os.O_APPEND); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the file with the extra flag, and records its current size.
+func (r *rotatingFile) open(flag int) error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|flag, 0640)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %q", r.path)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to stat %q", r.path)
+	}
+	r.f = f
+	r.size = info.Size()
+	return nil
+}
+
+// Write writes the data into the file, after rotating the file if the data
+// doesn't fit in it.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the rotated files, moves the current file to the first
+// rotated file and starts a new file.
+func (r *rotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close %q", r.path)
+	}
+	if r.maxFiles == 0 {
+		return r.open(os.O_TRUNC)
+	}
+	if err := os.Remove(r.rotatedPath(r.maxFiles)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove the oldest rotated file")
+	}
+	for i := r.maxFiles - 1; i > 0; i-- {
+		if err := os.Rename(r.rotatedPath(i), r.rotatedPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to rename rotated file %q", r.rotatedPath(i))
+		}
+	}
+	if err := os.Rename(r.path, r.rotatedPath(1)); err != nil {
+		return errors.Wrapf(err, "failed to rotate %q", r.path)
+	}
+	return r.open(os.O_TRUNC)
+}
+
+func (r *rotatingFile) rotatedPath(i int) string {
+	return fmt.Sprintf("%s.%d", r.path, i)
+}
+
+// Close closes the file.
+func (r *rotatingFile) Close() error {
+	return r.f.Close()
+}
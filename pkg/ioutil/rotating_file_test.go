@@ -0,0 +1,102 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ioutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	for desc, test := range map[string]struct {
+		maxFiles int
+		expected map[string]string
+	}{
+		"should rotate into numbered files": {
+			maxFiles: 2,
+			expected: map[string]string{
+				"0.log":   "line5\n",
+				"0.log.1": "line3\nline4\n",
+				"0.log.2": "line1\nline2\n",
+			},
+		},
+		"should remove the oldest rotated file": {
+			maxFiles: 1,
+			expected: map[string]string{
+				"0.log":   "line5\n",
+				"0.log.1": "line3\nline4\n",
+			},
+		},
+		"should truncate in place without rotated files": {
+			maxFiles: 0,
+			expected: map[string]string{
+				"0.log": "line5\n",
+			},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		dir, err := ioutil.TempDir("", "test-rotating-file")
+		require.NoError(t, err)
+		path := filepath.Join(dir, "0.log")
+		require.NoError(t, ioutil.WriteFile(path, []byte("line1\n"), 0640))
+
+		// Each file fits 2 lines.
+		f, err := NewRotatingFile(path, 12, test.maxFiles)
+		require.NoError(t, err)
+		for _, line := range []string{"line2\n", "line3\n", "line4\n", "line5\n"} {
+			n, err := f.Write([]byte(line))
+			require.NoError(t, err)
+			assert.Equal(t, len(line), n)
+		}
+		require.NoError(t, f.Close())
+
+		files, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		contents := make(map[string]string)
+		for _, file := range files {
+			data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+			require.NoError(t, err)
+			contents[file.Name()] = string(data)
+		}
+		assert.Equal(t, test.expected, contents)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRotatingFileWriteLargerThanMaxSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-rotating-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "0.log")
+
+	f, err := NewRotatingFile(path, 4, 1)
+	require.NoError(t, err)
+	defer f.Close()
+	t.Logf("should write data larger than max size into an empty file")
+	_, err = f.Write([]byte("too long\n"))
+	require.NoError(t, err)
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "too long\n", string(data))
+	_, err = os.Stat(path + ".1")
+	assert.True(t, os.IsNotExist(err))
+}
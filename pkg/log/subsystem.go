@@ -0,0 +1,180 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"sync/atomic"
+
+	"github.com/containerd/containerd/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Loggers of the subsystems of the cri plugin. A subsystem logs with the
+// level of the standard logger, unless its own level is set, e.g. to trace
+// image pulls without the noise of other subsystems.
+var (
+	Sandbox   = newLogger("sandbox")
+	Container = newLogger("container")
+	Image     = newLogger("image")
+	CNI       = newLogger("cni")
+	Streaming = newLogger("streaming")
+	Events    = newLogger("events")
+)
+
+// subsystems are the loggers of all subsystems, keyed by name.
+var subsystems = map[string]*Logger{}
+
+func init() {
+	for _, l := range []*Logger{Sandbox, Container, Image, CNI, Streaming, Events} {
+		subsystems[l.name] = l
+	}
+}
+
+// Logger is the logger of a subsystem.
+type Logger struct {
+	name string
+	// state is the current *loggerState.
+	state atomic.Value
+}
+
+// loggerState is the level of a subsystem and the entry logging with it.
+type loggerState struct {
+	// level is the level of the subsystem, empty if it is not set.
+	level string
+	// entry is the entry of the standard logger if the level is not set,
+	// or the entry of a logger with the level.
+	entry *logrus.Entry
+}
+
+func newLogger(name string) *Logger {
+	l := &Logger{name: name}
+	l.state.Store(&loggerState{entry: logrus.NewEntry(logrus.StandardLogger())})
+	return l
+}
+
+func (l *Logger) e() *logrus.Entry {
+	return l.state.Load().(*loggerState).entry
+}
+
+// WithError adds an error as single field to the log entry.
+func (l *Logger) WithError(err error) *logrus.Entry { return l.e().WithError(err) }
+
+// WithField adds a single field to the log entry.
+func (l *Logger) WithField(key string, value interface{}) *logrus.Entry {
+	return l.e().WithField(key, value)
+}
+
+// WithFields adds a map of fields to the log entry.
+func (l *Logger) WithFields(fields logrus.Fields) *logrus.Entry { return l.e().WithFields(fields) }
+
+// Trace logs a message at level Trace.
+func (l *Logger) Trace(args ...interface{}) { log.Trace(l.e(), args...) }
+
+// Tracef logs a message at level Trace.
+func (l *Logger) Tracef(format string, args ...interface{}) { log.Tracef(l.e(), format, args...) }
+
+// Debug logs a message at level Debug.
+func (l *Logger) Debug(args ...interface{}) { l.e().Debug(args...) }
+
+// Debugf logs a message at level Debug.
+func (l *Logger) Debugf(format string, args ...interface{}) { l.e().Debugf(format, args...) }
+
+// Info logs a message at level Info.
+func (l *Logger) Info(args ...interface{}) { l.e().Info(args...) }
+
+// Infof logs a message at level Info.
+func (l *Logger) Infof(format string, args ...interface{}) { l.e().Infof(format, args...) }
+
+// Warnf logs a message at level Warn.
+func (l *Logger) Warnf(format string, args ...interface{}) { l.e().Warnf(format, args...) }
+
+// Errorf logs a message at level Error.
+func (l *Logger) Errorf(format string, args ...interface{}) { l.e().Errorf(format, args...) }
+
+// stdFormatter formats entries with the formatter of the standard logger,
+// which containerd configures after the subsystem loggers are created.
+type stdFormatter struct{}
+
+func (stdFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	return logrus.StandardLogger().Formatter.Format(entry)
+}
+
+// stdWriter writes into the output of the standard logger.
+type stdWriter struct{}
+
+func (stdWriter) Write(p []byte) (int, error) {
+	return logrus.StandardLogger().Out.Write(p)
+}
+
+// SetLevels sets the log levels of subsystems keyed by subsystem, e.g.
+// "debug" or "trace". An empty level resets the subsystem to the level of the
+// standard logger. No level is set if any of them is invalid.
+func SetLevels(levels map[string]string) error {
+	states := make(map[*Logger]*loggerState)
+	for subsystem, level := range levels {
+		l, ok := subsystems[subsystem]
+		if !ok {
+			return errors.Errorf("unknown subsystem %q", subsystem)
+		}
+		if level == "" {
+			states[l] = &loggerState{entry: logrus.NewEntry(logrus.StandardLogger())}
+			continue
+		}
+		lvl, err := ParseLevel(level)
+		if err != nil {
+			return errors.Wrapf(err, "invalid level of subsystem %q", subsystem)
+		}
+		states[l] = &loggerState{
+			level: level,
+			entry: logrus.NewEntry(&logrus.Logger{
+				Out:       stdWriter{},
+				Formatter: stdFormatter{},
+				Hooks:     logrus.StandardLogger().Hooks,
+				Level:     lvl,
+			}),
+		}
+	}
+	for l, state := range states {
+		l.state.Store(state)
+	}
+	return nil
+}
+
+// Levels returns the log levels of all subsystems, which are empty if they
+// are not set.
+func Levels() map[string]string {
+	levels := make(map[string]string)
+	for name, l := range subsystems {
+		levels[name] = l.state.Load().(*loggerState).level
+	}
+	return levels
+}
+
+// ParseLevel parses a log level, including the trace level.
+func ParseLevel(level string) (logrus.Level, error) {
+	return log.ParseLevel(level)
+}
+
+// GetLevel returns the level of the standard logger.
+func GetLevel() string {
+	l := logrus.GetLevel()
+	if l == log.TraceLevel {
+		return "trace"
+	}
+	return l.String()
+}
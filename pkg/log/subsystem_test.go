@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLevels(t *testing.T) {
+	var buf bytes.Buffer
+	std := logrus.StandardLogger()
+	defer func(out io.Writer, level logrus.Level) {
+		logrus.SetOutput(out)
+		logrus.SetLevel(level)
+	}(std.Out, logrus.GetLevel())
+	logrus.SetOutput(&buf)
+	logrus.SetLevel(logrus.InfoLevel)
+	defer SetLevels(map[string]string{"image": "", "events": ""}) // nolint: errcheck
+
+	t.Logf("should use the level of the standard logger by default")
+	Image.Debugf("image debug")
+	assert.NotContains(t, buf.String(), "image debug")
+
+	t.Logf("should reject invalid levels without setting any")
+	for _, levels := range []map[string]string{
+		{"image": "debug", "unknown": "debug"},
+		{"image": "debug", "events": "verbose"},
+	} {
+		assert.Error(t, SetLevels(levels))
+		assert.Equal(t, "", Levels()["image"])
+	}
+
+	t.Logf("should log with the level of the subsystem")
+	require.NoError(t, SetLevels(map[string]string{"image": "trace", "events": "error"}))
+	Image.Tracef("image trace")
+	Events.Infof("events info")
+	Container.Infof("container info")
+	assert.Contains(t, buf.String(), "image trace")
+	assert.NotContains(t, buf.String(), "events info")
+	assert.Contains(t, buf.String(), "container info")
+	assert.Equal(t, "trace", Levels()["image"])
+	assert.Equal(t, "", Levels()["container"])
+
+	t.Logf("should reset the level of the subsystem")
+	require.NoError(t, SetLevels(map[string]string{"image": ""}))
+	buf.Reset()
+	Image.Debugf("image debug")
+	assert.NotContains(t, buf.String(), "image debug")
+	assert.Equal(t, "info", GetLevel())
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// appArmorProfilesPath is the file listing all apparmor profiles loaded into
+// the kernel.
+const appArmorProfilesPath = "/sys/kernel/security/apparmor/profiles"
+
+// appArmorAnnotationPrefix is the prefix of the kubelet pod annotations
+// holding the apparmor profiles of the containers of a pod, which kubelet
+// passes into the sandbox config.
+const appArmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+// validateSandboxAppArmorProfiles checks whether the localhost apparmor
+// profiles of the containers of a pod are loaded, so that a pod with a missing
+// profile fails at RunPodSandbox instead of at the creation of each container.
+// LinuxSandboxSecurityContext has no apparmor profile, the profiles are read
+// from the kubelet pod annotations in the sandbox config.
+func (c *criService) validateSandboxAppArmorProfiles(config *runtime.PodSandboxConfig) error {
+	if !c.apparmorEnabled {
+		return nil
+	}
+	for _, profile := range getSandboxAppArmorProfiles(config.GetAnnotations()) {
+		if err := checkAppArmorProfileLoaded(profile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getSandboxAppArmorProfiles returns the localhost apparmor profiles in the
+// kubelet pod annotations of a sandbox in order.
+func getSandboxAppArmorProfiles(sandboxAnnotations map[string]string) []string {
+	var profiles []string
+	seen := make(map[string]bool)
+	for k, v := range sandboxAnnotations {
+		if !strings.HasPrefix(k, appArmorAnnotationPrefix) || !strings.HasPrefix(v, profileNamePrefix) {
+			continue
+		}
+		profile := strings.TrimPrefix(v, profileNamePrefix)
+		if !seen[profile] {
+			seen[profile] = true
+			profiles = append(profiles, profile)
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// checkAppArmorProfileLoaded checks whether a localhost apparmor profile is
+// loaded into the kernel. Unlike the default profile, localhost profiles are
+// never loaded by the CRI plugin.
+func checkAppArmorProfileLoaded(profile string) error {
+	f, err := os.Open(appArmorProfilesPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %q", appArmorProfilesPath)
+	}
+	defer f.Close()
+	loaded, err := isAppArmorProfileLoaded(f, profile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %q", appArmorProfilesPath)
+	}
+	if !loaded {
+		return errors.Errorf("apparmor profile %q is not loaded", profile)
+	}
+	return nil
+}
+
+// isAppArmorProfileLoaded checks whether a profile is in the loaded profile
+// list, in which each line is in the form of "<profile> (<mode>)".
+func isAppArmorProfileLoaded(r io.Reader, profile string) (bool, error) {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.LastIndex(line, " ("); i >= 0 {
+			line = line[:i]
+		}
+		if line == profile {
+			return true, nil
+		}
+	}
+	return false, s.Err()
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsAppArmorProfileLoaded(t *testing.T) {
+	profiles := `cri-containerd.apparmor.d (enforce)
+/usr/bin/man (enforce)
+test-profile (complain)
+test profile with space (enforce)
+`
+	for desc, test := range map[string]struct {
+		profile  string
+		expected bool
+	}{
+		"loaded profile": {
+			profile:  "test-profile",
+			expected: true,
+		},
+		"loaded profile with path name": {
+			profile:  "/usr/bin/man",
+			expected: true,
+		},
+		"loaded profile with space in name": {
+			profile:  "test profile with space",
+			expected: true,
+		},
+		"profile not loaded": {
+			profile:  "test",
+			expected: false,
+		},
+		"profile name should not include mode": {
+			profile:  "test-profile (complain)",
+			expected: false,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		loaded, err := isAppArmorProfileLoaded(strings.NewReader(profiles), test.profile)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, loaded)
+	}
+}
+
+func TestGetSandboxAppArmorProfiles(t *testing.T) {
+	for desc, test := range map[string]struct {
+		annotations map[string]string
+		expected    []string
+	}{
+		"should return no profiles without annotations": {},
+		"should return localhost profiles of containers in order": {
+			annotations: map[string]string{
+				"container.apparmor.security.beta.kubernetes.io/b": "localhost/profile-b",
+				"container.apparmor.security.beta.kubernetes.io/a": "localhost/profile-a",
+				"container.apparmor.security.beta.kubernetes.io/c": "localhost/profile-a",
+			},
+			expected: []string{"profile-a", "profile-b"},
+		},
+		"should skip runtime default and unconfined profiles": {
+			annotations: map[string]string{
+				"container.apparmor.security.beta.kubernetes.io/a": "runtime/default",
+				"container.apparmor.security.beta.kubernetes.io/b": "unconfined",
+			},
+		},
+		"should skip other annotations": {
+			annotations: map[string]string{
+				"seccomp.security.alpha.kubernetes.io/pod": "localhost/profile",
+			},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		assert.Equal(t, test.expected, getSandboxAppArmorProfiles(test.annotations))
+	}
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	cioutil "github.com/containerd/cri/pkg/ioutil"
+)
+
+const (
+	// auditBackendFile writes audit records into a rotated file.
+	auditBackendFile = "file"
+	// auditBackendJournald writes audit records into the systemd journal.
+	auditBackendJournald = "journald"
+	// journaldSocket is the socket of the native journald protocol.
+	journaldSocket = "/run/systemd/journal/socket"
+	// auditSyslogIdentifier identifies audit records in the journal.
+	auditSyslogIdentifier = "containerd-cri-audit"
+	// redacted replaces secrets in audit records.
+	redacted = "<redacted>"
+)
+
+// auditor records cri requests into the audit log, one json object per
+// request. A nil auditor records nothing.
+type auditor struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+// auditRecord is the audit log record of a cri request.
+type auditRecord struct {
+	Time      time.Time   `json:"time"`
+	RPC       string      `json:"rpc"`
+	Peer      string      `json:"peer,omitempty"`
+	UserAgent string      `json:"userAgent,omitempty"`
+	Request   interface{} `json:"request,omitempty"`
+	Code      string      `json:"code"`
+	Error     string      `json:"error,omitempty"`
+	Duration  string      `json:"duration"`
+}
+
+// newAuditor creates an auditor, it returns nil if the audit log is disabled.
+func newAuditor(config criconfig.AuditConfig) (*auditor, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	w, err := openAuditWriter(config)
+	if err != nil {
+		return nil, err
+	}
+	return &auditor{w: w}, nil
+}
+
+// validateAuditConfig validates the audit config without opening the audit log.
+func validateAuditConfig(config criconfig.AuditConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	switch config.Backend {
+	case auditBackendFile:
+		if config.Path == "" {
+			return errors.New("path must be set for the file backend")
+		}
+		if config.MaxSizeMB < 0 {
+			return errors.Errorf("invalid max_size_mb %d, must not be negative", config.MaxSizeMB)
+		}
+		if config.MaxBackups < 0 {
+			return errors.Errorf("invalid max_backups %d, must not be negative", config.MaxBackups)
+		}
+	case auditBackendJournald:
+	default:
+		return errors.Errorf("unknown backend %q, must be %q or %q", config.Backend, auditBackendFile, auditBackendJournald)
+	}
+	return nil
+}
+
+// openAuditWriter opens the writer of the audit backend.
+func openAuditWriter(config criconfig.AuditConfig) (io.WriteCloser, error) {
+	if err := validateAuditConfig(config); err != nil {
+		return nil, err
+	}
+	if config.Backend == auditBackendJournald {
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to connect to journald")
+		}
+		return &journaldWriter{conn: conn}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(config.Path), 0750); err != nil {
+		return nil, errors.Wrapf(err, "failed to create directory of %q", config.Path)
+	}
+	if config.MaxSizeMB > 0 {
+		return cioutil.NewRotatingFile(config.Path, int64(config.MaxSizeMB)*1024*1024, config.MaxBackups)
+	}
+	return os.OpenFile(config.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+}
+
+// record records a finished cri request, with the secrets in the request
+// redacted. Failures are logged, they never fail the request.
+func (a *auditor) record(ctx context.Context, rpc string, r interface{}, start time.Time, err *error) {
+	if a == nil {
+		return
+	}
+	record := auditRecord{
+		Time:     start,
+		RPC:      rpc,
+		Request:  redactRequest(r),
+		Code:     status.Code(*err).String(),
+		Duration: time.Since(start).String(),
+	}
+	if *err != nil {
+		record.Error = (*err).Error()
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		record.Peer = p.Addr.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		record.UserAgent = strings.Join(md["user-agent"], " ")
+	}
+	data, merr := json.Marshal(record)
+	if merr != nil {
+		logrus.WithError(merr).Errorf("Failed to marshal audit record of %s", rpc)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, werr := a.w.Write(append(data, '\n')); werr != nil {
+		logrus.WithError(werr).Errorf("Failed to write audit record of %s", rpc)
+	}
+}
+
+// close closes the audit log.
+func (a *auditor) close() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.w.Close()
+}
+
+// redactRequest returns the request with its secrets replaced, i.e. registry
+// credentials, the values of container environment variables, and the
+// arguments of exec commands, which often carry tokens. The request itself is
+// not modified.
+func redactRequest(r interface{}) interface{} {
+	switch req := r.(type) {
+	case *runtime.PullImageRequest:
+		if req.GetAuth() == nil {
+			return req
+		}
+		req = proto.Clone(req).(*runtime.PullImageRequest)
+		for _, secret := range []*string{&req.Auth.Password, &req.Auth.Auth, &req.Auth.IdentityToken, &req.Auth.RegistryToken} {
+			if *secret != "" {
+				*secret = redacted
+			}
+		}
+		return req
+	case *runtime.CreateContainerRequest:
+		if len(req.GetConfig().GetEnvs()) == 0 {
+			return req
+		}
+		req = proto.Clone(req).(*runtime.CreateContainerRequest)
+		for _, env := range req.Config.Envs {
+			env.Value = redacted
+		}
+		return req
+	case *runtime.ExecSyncRequest:
+		if len(req.GetCmd()) <= 1 {
+			return req
+		}
+		req = proto.Clone(req).(*runtime.ExecSyncRequest)
+		redactArgs(req.Cmd)
+		return req
+	case *runtime.ExecRequest:
+		if len(req.GetCmd()) <= 1 {
+			return req
+		}
+		req = proto.Clone(req).(*runtime.ExecRequest)
+		redactArgs(req.Cmd)
+		return req
+	}
+	return r
+}
+
+// redactArgs replaces the arguments of a command, only the binary name is
+// kept.
+func redactArgs(cmd []string) {
+	for i := 1; i < len(cmd); i++ {
+		cmd[i] = redacted
+	}
+}
+
+// journaldWriter writes each audit record as a journal entry with the native
+// journald protocol.
+type journaldWriter struct {
+	conn *net.UnixConn
+}
+
+func (j *journaldWriter) Write(p []byte) (int, error) {
+	var buf bytes.Buffer
+	buf.WriteString("SYSLOG_IDENTIFIER=" + auditSyslogIdentifier + "\n")
+	buf.WriteString("PRIORITY=6\n")
+	buf.WriteString("MESSAGE=")
+	buf.Write(bytes.TrimSuffix(p, []byte("\n")))
+	buf.WriteString("\n")
+	if _, err := j.conn.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (j *journaldWriter) Close() error {
+	return j.conn.Close()
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestValidateAuditConfig(t *testing.T) {
+	for desc, test := range map[string]struct {
+		config    criconfig.AuditConfig
+		expectErr bool
+	}{
+		"should accept disabled audit log": {
+			config: criconfig.AuditConfig{Backend: "unknown"},
+		},
+		"should accept file backend": {
+			config: criconfig.AuditConfig{Enabled: true, Backend: "file", Path: "/var/log/audit.log", MaxSizeMB: 10},
+		},
+		"should accept journald backend": {
+			config: criconfig.AuditConfig{Enabled: true, Backend: "journald"},
+		},
+		"should reject file backend without path": {
+			config:    criconfig.AuditConfig{Enabled: true, Backend: "file"},
+			expectErr: true,
+		},
+		"should reject negative max backups": {
+			config:    criconfig.AuditConfig{Enabled: true, Backend: "file", Path: "/var/log/audit.log", MaxBackups: -1},
+			expectErr: true,
+		},
+		"should reject unknown backend": {
+			config:    criconfig.AuditConfig{Enabled: true, Backend: "syslog"},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		err := validateAuditConfig(test.config)
+		assert.Equal(t, test.expectErr, err != nil, err)
+	}
+}
+
+func TestRedactRequest(t *testing.T) {
+	pull := &runtime.PullImageRequest{
+		Image: &runtime.ImageSpec{Image: "busybox"},
+		Auth:  &runtime.AuthConfig{Username: "user", Password: "pass", IdentityToken: "token"},
+	}
+	redactedPull := redactRequest(pull).(*runtime.PullImageRequest)
+	assert.Equal(t, "user", redactedPull.Auth.Username)
+	assert.Equal(t, redacted, redactedPull.Auth.Password)
+	assert.Equal(t, redacted, redactedPull.Auth.IdentityToken)
+	assert.Empty(t, redactedPull.Auth.RegistryToken)
+	assert.Equal(t, "pass", pull.Auth.Password, "request should not be modified")
+
+	create := &runtime.CreateContainerRequest{
+		PodSandboxId: "sandbox",
+		Config: &runtime.ContainerConfig{
+			Envs: []*runtime.KeyValue{{Key: "PASSWORD", Value: "secret"}},
+		},
+	}
+	redactedCreate := redactRequest(create).(*runtime.CreateContainerRequest)
+	assert.Equal(t, "PASSWORD", redactedCreate.Config.Envs[0].Key)
+	assert.Equal(t, redacted, redactedCreate.Config.Envs[0].Value)
+	assert.Equal(t, "secret", create.Config.Envs[0].Value, "request should not be modified")
+
+	execSync := &runtime.ExecSyncRequest{
+		ContainerId: "container",
+		Cmd:         []string{"curl", "-H", "Authorization: Bearer token"},
+	}
+	redactedExecSync := redactRequest(execSync).(*runtime.ExecSyncRequest)
+	assert.Equal(t, []string{"curl", redacted, redacted}, redactedExecSync.Cmd)
+	assert.Equal(t, "-H", execSync.Cmd[1], "request should not be modified")
+
+	exec := &runtime.ExecRequest{
+		ContainerId: "container",
+		Cmd:         []string{"mysql", "--password=secret"},
+	}
+	redactedExec := redactRequest(exec).(*runtime.ExecRequest)
+	assert.Equal(t, []string{"mysql", redacted}, redactedExec.Cmd)
+	assert.Equal(t, "--password=secret", exec.Cmd[1], "request should not be modified")
+
+	stop := &runtime.StopContainerRequest{ContainerId: "container"}
+	assert.Equal(t, stop, redactRequest(stop))
+}
+
+func TestAuditorRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log", "audit.log")
+	a, err := newAuditor(criconfig.AuditConfig{Enabled: true, Backend: "file", Path: path})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("user-agent", "kubelet"))
+	start := time.Now()
+	var pullErr error
+	a.record(ctx, "PullImage", &runtime.PullImageRequest{
+		Image: &runtime.ImageSpec{Image: "busybox"},
+		Auth:  &runtime.AuthConfig{Password: "pass"},
+	}, start, &pullErr)
+	stopErr := status.Error(codes.NotFound, "container not found")
+	a.record(context.Background(), "StopContainer", &runtime.StopContainerRequest{ContainerId: "container"}, start, &stopErr)
+	require.NoError(t, a.close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 2)
+	assert.Equal(t, "PullImage", records[0]["rpc"])
+	assert.Equal(t, "kubelet", records[0]["userAgent"])
+	assert.Equal(t, "OK", records[0]["code"])
+	assert.Equal(t, redacted, records[0]["request"].(map[string]interface{})["auth"].(map[string]interface{})["password"])
+	assert.Equal(t, "StopContainer", records[1]["rpc"])
+	assert.Equal(t, "NotFound", records[1]["code"])
+	assert.Contains(t, records[1]["error"], "container not found")
+
+	t.Logf("nil auditor should record nothing")
+	var nilAuditor *auditor
+	nilAuditor.record(ctx, "PullImage", &runtime.PullImageRequest{}, start, &pullErr)
+	assert.NoError(t, nilAuditor.close())
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+// loadBaseOCISpecs loads the base runtime specs of the runtimes, keyed by
+// the path of the spec file.
+func loadBaseOCISpecs(runtimes []criconfig.Runtime) (map[string]*runtimespec.Spec, error) {
+	specs := make(map[string]*runtimespec.Spec)
+	for _, r := range runtimes {
+		path := r.BaseRuntimeSpec
+		if path == "" || specs[path] != nil {
+			continue
+		}
+		spec, err := loadBaseOCISpec(path)
+		if err != nil {
+			return nil, err
+		}
+		specs[path] = spec
+	}
+	return specs, nil
+}
+
+// loadBaseOCISpec loads a base runtime spec from a JSON file.
+func loadBaseOCISpec(path string) (*runtimespec.Spec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read base runtime spec %q", path)
+	}
+	var spec runtimespec.Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal base runtime spec %q", path)
+	}
+	if spec.Process == nil {
+		spec.Process = &runtimespec.Process{}
+	}
+	if spec.Process.Capabilities == nil {
+		spec.Process.Capabilities = &runtimespec.LinuxCapabilities{}
+	}
+	if spec.Linux == nil {
+		spec.Linux = &runtimespec.Linux{}
+	}
+	// CRI plugin handles `/dev/shm` itself.
+	var mounts []runtimespec.Mount
+	for _, m := range spec.Mounts {
+		if m.Destination != devShm {
+			mounts = append(mounts, m)
+		}
+	}
+	spec.Mounts = mounts
+	return &spec, nil
+}
+
+// runtimeSpec returns the spec a container spec is generated from, which is
+// a copy of the base runtime spec of the runtime, or the default spec.
+func (c *criService) runtimeSpec(id string, ociRuntime criconfig.Runtime) (*runtimespec.Spec, error) {
+	base, ok := c.baseOCISpecs[ociRuntime.BaseRuntimeSpec]
+	if !ok {
+		return defaultRuntimeSpec(id)
+	}
+	// Deep copy the base spec, the generated spec is modified.
+	data, err := json.Marshal(base)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal base runtime spec")
+	}
+	var spec runtimespec.Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal base runtime spec")
+	}
+	return &spec, nil
+}
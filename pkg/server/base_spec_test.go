@@ -0,0 +1,126 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestLoadBaseOCISpecs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-base-spec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	valid := filepath.Join(dir, "valid.json")
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, ioutil.WriteFile(valid, []byte(`{"ociVersion": "1.0.1"}`), 0600))
+	require.NoError(t, ioutil.WriteFile(invalid, []byte(`{"ociVersion": `), 0600))
+	for desc, test := range map[string]struct {
+		runtimes  []criconfig.Runtime
+		expectErr bool
+		expected  []string
+	}{
+		"should load nothing without base runtime spec": {
+			runtimes: []criconfig.Runtime{{}},
+		},
+		"should load base runtime spec once": {
+			runtimes: []criconfig.Runtime{{BaseRuntimeSpec: valid}, {BaseRuntimeSpec: valid}},
+			expected: []string{valid},
+		},
+		"should return error with invalid base runtime spec": {
+			runtimes:  []criconfig.Runtime{{BaseRuntimeSpec: invalid}},
+			expectErr: true,
+		},
+		"should return error with missing base runtime spec": {
+			runtimes:  []criconfig.Runtime{{BaseRuntimeSpec: filepath.Join(dir, "missing.json")}},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		specs, err := loadBaseOCISpecs(test.runtimes)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		var paths []string
+		for path, spec := range specs {
+			paths = append(paths, path)
+			assert.NotNil(t, spec.Process)
+			assert.NotNil(t, spec.Linux)
+		}
+		assert.Equal(t, test.expected, paths)
+	}
+}
+
+func TestContainerSpecWithBaseRuntimeSpec(t *testing.T) {
+	testID := "test-id"
+	testSandboxID := "sandbox-id"
+	testPid := uint32(1234)
+	dir, err := ioutil.TempDir("", "test-base-spec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "base.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{
+	"ociVersion": "1.0.1",
+	"process": {
+		"cwd": "/",
+		"env": ["BASE=true"],
+		"rlimits": [{"type": "RLIMIT_NOFILE", "hard": 4096, "soft": 4096}]
+	},
+	"mounts": [
+		{"destination": "/dev/shm", "type": "tmpfs", "source": "shm"},
+		{"destination": "/etc/base", "type": "bind", "source": "/etc/base", "options": ["rbind", "ro"]}
+	],
+	"linux": {
+		"sysctl": {"net.core.somaxconn": "1024"}
+	}
+}`), 0600))
+	c := newTestCRIService()
+	c.baseOCISpecs, err = loadBaseOCISpecs([]criconfig.Runtime{{BaseRuntimeSpec: path}})
+	require.NoError(t, err)
+	ociRuntime := criconfig.Runtime{BaseRuntimeSpec: path}
+	config, sandboxConfig, imageConfig, _ := getCreateContainerTestData()
+
+	spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, ociRuntime)
+	require.NoError(t, err)
+	assert.Contains(t, spec.Process.Env, "BASE=true")
+	for _, e := range config.GetEnvs() {
+		assert.Contains(t, spec.Process.Env, e.GetKey()+"="+e.GetValue())
+	}
+	assert.Equal(t, []runtimespec.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 4096, Soft: 4096}}, spec.Process.Rlimits)
+	assert.Equal(t, "1024", spec.Linux.Sysctl["net.core.somaxconn"])
+	var destinations []string
+	for _, m := range spec.Mounts {
+		destinations = append(destinations, m.Destination)
+	}
+	assert.Contains(t, destinations, "/etc/base")
+	assert.NotContains(t, destinations, "/dev/shm")
+	assert.Equal(t, relativeRootfsPath, spec.Root.Path)
+
+	t.Logf("the base runtime spec should not be modified")
+	assert.Equal(t, []string{"BASE=true"}, c.baseOCISpecs[path].Process.Env)
+	assert.Nil(t, c.baseOCISpecs[path].Root)
+}
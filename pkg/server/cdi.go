@@ -0,0 +1,336 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/opencontainers/runc/libcontainer/devices"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/pkg/errors"
+
+	"github.com/containerd/cri/pkg/annotations"
+	"github.com/containerd/cri/pkg/log"
+)
+
+// cdiVersions are the CDI spec versions whose container edits are all
+// supported. Later versions add edits, which would be silently dropped.
+var cdiVersions = map[string]bool{
+	"0.1.0": true,
+	"0.2.0": true,
+	"0.3.0": true,
+	"0.4.0": true,
+	"0.5.0": true,
+	"0.6.0": true,
+}
+
+// cdiSpec is a Container Device Interface spec file, with the fields the cri
+// plugin supports.
+type cdiSpec struct {
+	Version        string            `json:"cdiVersion"`
+	Kind           string            `json:"kind"`
+	Devices        []cdiDevice       `json:"devices"`
+	ContainerEdits cdiContainerEdits `json:"containerEdits,omitempty"`
+}
+
+// cdiDevice is a device in a CDI spec.
+type cdiDevice struct {
+	Name           string            `json:"name"`
+	ContainerEdits cdiContainerEdits `json:"containerEdits"`
+}
+
+// cdiContainerEdits are the changes of the container spec of a CDI device.
+type cdiContainerEdits struct {
+	Env         []string        `json:"env,omitempty"`
+	DeviceNodes []cdiDeviceNode `json:"deviceNodes,omitempty"`
+	Hooks       []cdiHook       `json:"hooks,omitempty"`
+	Mounts      []cdiMount      `json:"mounts,omitempty"`
+}
+
+// cdiDeviceNode is a device node injected into the container.
+type cdiDeviceNode struct {
+	Path        string       `json:"path"`
+	HostPath    string       `json:"hostPath,omitempty"`
+	Type        string       `json:"type,omitempty"`
+	Major       int64        `json:"major,omitempty"`
+	Minor       int64        `json:"minor,omitempty"`
+	FileMode    *os.FileMode `json:"fileMode,omitempty"`
+	Permissions string       `json:"permissions,omitempty"`
+	UID         *uint32      `json:"uid,omitempty"`
+	GID         *uint32      `json:"gid,omitempty"`
+}
+
+// cdiHook is a hook injected into the container.
+type cdiHook struct {
+	HookName string   `json:"hookName"`
+	Path     string   `json:"path"`
+	Args     []string `json:"args,omitempty"`
+	Env      []string `json:"env,omitempty"`
+	Timeout  *int     `json:"timeout,omitempty"`
+}
+
+// cdiMount is a mount injected into the container.
+type cdiMount struct {
+	HostPath      string   `json:"hostPath"`
+	ContainerPath string   `json:"containerPath"`
+	Type          string   `json:"type,omitempty"`
+	Options       []string `json:"options,omitempty"`
+}
+
+// cdiResolvedDevice is a device found in the CDI specs, with the edits of its
+// spec.
+type cdiResolvedDevice struct {
+	device *cdiDevice
+	spec   *cdiSpec
+}
+
+// getCDIDevices returns the fully qualified names of the CDI devices requested
+// by the container annotations, in the order of the annotation keys.
+func getCDIDevices(containerAnnotations map[string]string) []string {
+	var keys []string
+	for key := range containerAnnotations {
+		if strings.HasPrefix(key, annotations.CDIDevicesPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var names []string
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		for _, name := range strings.Split(containerAnnotations[key], ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// loadCDIDevices loads the devices in the CDI spec files of the directories
+// keyed by their fully qualified names. A device in a later directory
+// overrides the one in an earlier directory, missing directories are
+// skipped. Invalid spec files are skipped with a warning like the CDI library
+// does, so that they don't break the devices of other specs.
+func loadCDIDevices(dirs []string) (map[string]cdiResolvedDevice, error) {
+	resolved := make(map[string]cdiResolvedDevice)
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to read cdi spec dir %q", dir)
+		}
+		for _, f := range files {
+			switch filepath.Ext(f.Name()) {
+			case ".json", ".yaml", ".yml":
+			default:
+				continue
+			}
+			if f.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, f.Name())
+			spec, err := loadCDISpec(path)
+			if err != nil {
+				log.Container.WithError(err).Warnf("Skip invalid cdi spec %q", path)
+				continue
+			}
+			for i := range spec.Devices {
+				resolved[spec.Kind+"="+spec.Devices[i].Name] = cdiResolvedDevice{
+					device: &spec.Devices[i],
+					spec:   spec,
+				}
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// loadCDISpec loads and validates a CDI spec file.
+func loadCDISpec(path string) (*cdiSpec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read cdi spec")
+	}
+	spec := &cdiSpec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, errors.Wrap(err, "failed to parse cdi spec")
+	}
+	if !cdiVersions[spec.Version] {
+		return nil, errors.Errorf("unsupported cdiVersion %q", spec.Version)
+	}
+	if !strings.Contains(spec.Kind, "/") {
+		return nil, errors.Errorf("invalid kind %q", spec.Kind)
+	}
+	for _, d := range spec.Devices {
+		if d.Name == "" {
+			return nil, errors.New("device without name")
+		}
+	}
+	return spec, nil
+}
+
+// injectCDIDevices injects the CDI devices requested by the container
+// annotations into the container spec. The edits of a spec are applied once
+// before the edits of its first requested device.
+func (c *criService) injectCDIDevices(g *generate.Generator, containerAnnotations map[string]string) error {
+	names := getCDIDevices(containerAnnotations)
+	if len(names) == 0 {
+		return nil
+	}
+	if !c.config.EnableCDI {
+		return errors.Errorf("cdi devices %v are requested, but cdi is not enabled", names)
+	}
+	resolved, err := loadCDIDevices(c.config.CDISpecDirs)
+	if err != nil {
+		return err
+	}
+	applied := make(map[*cdiSpec]bool)
+	for _, name := range names {
+		d, ok := resolved[name]
+		if !ok {
+			return errors.Errorf("unresolvable cdi device %q", name)
+		}
+		if !applied[d.spec] {
+			applied[d.spec] = true
+			if err := applyCDIEdits(g, d.spec.ContainerEdits); err != nil {
+				return errors.Wrapf(err, "failed to apply edits of cdi spec %q", d.spec.Kind)
+			}
+		}
+		if err := applyCDIEdits(g, d.device.ContainerEdits); err != nil {
+			return errors.Wrapf(err, "failed to inject cdi device %q", name)
+		}
+	}
+	return nil
+}
+
+// applyCDIEdits applies CDI container edits to the container spec.
+func applyCDIEdits(g *generate.Generator, edits cdiContainerEdits) error {
+	for _, env := range edits.Env {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return errors.Errorf("invalid env %q", env)
+		}
+		g.AddProcessEnv(parts[0], parts[1])
+	}
+	for _, node := range edits.DeviceNodes {
+		dev, err := toOCIDevice(node)
+		if err != nil {
+			return err
+		}
+		access := node.Permissions
+		if access == "" {
+			access = "rwm"
+		}
+		g.AddDevice(dev)
+		g.Config.Linux.Resources.Devices = append(g.Config.Linux.Resources.Devices, runtimespec.LinuxDeviceCgroup{
+			Allow:  true,
+			Type:   dev.Type,
+			Major:  &dev.Major,
+			Minor:  &dev.Minor,
+			Access: access,
+		})
+	}
+	for _, mount := range edits.Mounts {
+		m := runtimespec.Mount{
+			Source:      mount.HostPath,
+			Destination: mount.ContainerPath,
+			Type:        mount.Type,
+			Options:     mount.Options,
+		}
+		if m.Type == "" {
+			m.Type = "bind"
+		}
+		if m.Type == "bind" && !hasBindOption(m.Options) {
+			m.Options = append([]string{"bind"}, m.Options...)
+		}
+		g.RemoveMount(m.Destination)
+		g.AddMount(m)
+	}
+	for _, hook := range edits.Hooks {
+		h := runtimespec.Hook{
+			Path:    hook.Path,
+			Args:    hook.Args,
+			Env:     hook.Env,
+			Timeout: hook.Timeout,
+		}
+		// The vendored runtime spec only has the original hooks, the
+		// createRuntime and createContainer hooks run at the same time as
+		// the prestart hooks.
+		switch hook.HookName {
+		case "prestart", "createRuntime", "createContainer":
+			g.AddPreStartHook(h) // nolint: errcheck
+		case "poststart":
+			g.AddPostStartHook(h) // nolint: errcheck
+		case "poststop":
+			g.AddPostStopHook(h) // nolint: errcheck
+		default:
+			return errors.Errorf("unsupported hook %q", hook.HookName)
+		}
+	}
+	return nil
+}
+
+// toOCIDevice converts a CDI device node to an OCI device. The type and
+// numbers of the device are read from the host device if not set.
+func toOCIDevice(node cdiDeviceNode) (runtimespec.LinuxDevice, error) {
+	dev := runtimespec.LinuxDevice{
+		Path:     node.Path,
+		Type:     node.Type,
+		Major:    node.Major,
+		Minor:    node.Minor,
+		FileMode: node.FileMode,
+		UID:      node.UID,
+		GID:      node.GID,
+	}
+	if dev.Type != "" && (dev.Major != 0 || dev.Type == "p") {
+		return dev, nil
+	}
+	hostPath := node.HostPath
+	if hostPath == "" {
+		hostPath = node.Path
+	}
+	hostDev, err := devices.DeviceFromPath(hostPath, "rwm")
+	if err != nil {
+		return dev, errors.Wrapf(err, "failed to get device %q", hostPath)
+	}
+	dev.Type, dev.Major, dev.Minor = string(hostDev.Type), hostDev.Major, hostDev.Minor
+	if dev.FileMode == nil {
+		dev.FileMode = &hostDev.FileMode
+	}
+	return dev, nil
+}
+
+// hasBindOption returns whether the mount options have a bind option.
+func hasBindOption(options []string) bool {
+	for _, o := range options {
+		if o == "bind" || o == "rbind" {
+			return true
+		}
+	}
+	return false
+}
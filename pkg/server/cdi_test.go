@@ -0,0 +1,147 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCDISpecJSON = `{
+  "cdiVersion": "0.5.0",
+  "kind": "vendor.com/gpu",
+  "containerEdits": {"env": ["VENDOR_DRIVER=1"]},
+  "devices": [
+    {
+      "name": "0",
+      "containerEdits": {
+        "env": ["VENDOR_VISIBLE_DEVICES=0"],
+        "deviceNodes": [{"path": "/dev/vendor0", "type": "c", "major": 195, "minor": 0}],
+        "mounts": [{"hostPath": "/usr/lib/vendor", "containerPath": "/usr/lib/vendor", "options": ["ro"]}],
+        "hooks": [{"hookName": "createContainer", "path": "/usr/bin/vendor-hook", "args": ["vendor-hook", "ldcache"]}]
+      }
+    },
+    {"name": "1", "containerEdits": {"env": ["VENDOR_VISIBLE_DEVICES=1"]}}
+  ]
+}`
+
+const testCDISpecYAML = `cdiVersion: 0.5.0
+kind: vendor.com/gpu
+devices:
+- name: "1"
+  containerEdits:
+    env:
+    - VENDOR_VISIBLE_DEVICES=override
+`
+
+func TestGetCDIDevices(t *testing.T) {
+	assert.Equal(t, []string{"vendor.com/gpu=0", "vendor.com/gpu=1", "vendor.com/nic=0"}, getCDIDevices(map[string]string{
+		"cdi.k8s.io/b":   "vendor.com/nic=0, vendor.com/gpu=0",
+		"cdi.k8s.io/a":   "vendor.com/gpu=0,vendor.com/gpu=1",
+		"other.io/label": "vendor.com/gpu=2",
+	}))
+	assert.Empty(t, getCDIDevices(nil))
+}
+
+func TestLoadCDIDevices(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-cdi")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	etcDir, runDir := filepath.Join(dir, "etc"), filepath.Join(dir, "run")
+	for _, d := range []string{etcDir, runDir} {
+		require.NoError(t, os.MkdirAll(d, 0755))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(etcDir, "gpu.json"), []byte(testCDISpecJSON), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(runDir, "gpu.yaml"), []byte(testCDISpecYAML), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(runDir, "README"), []byte("not a spec"), 0644))
+
+	devices, err := loadCDIDevices([]string{etcDir, runDir, filepath.Join(dir, "not-exist")})
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+	assert.Equal(t, []string{"VENDOR_VISIBLE_DEVICES=0"}, devices["vendor.com/gpu=0"].device.ContainerEdits.Env)
+	assert.Equal(t, []string{"VENDOR_DRIVER=1"}, devices["vendor.com/gpu=0"].spec.ContainerEdits.Env)
+	assert.Equal(t, []string{"VENDOR_VISIBLE_DEVICES=override"}, devices["vendor.com/gpu=1"].device.ContainerEdits.Env,
+		"device in later directory should override")
+
+	t.Logf("should skip invalid specs")
+	for name, spec := range map[string]string{
+		"invalid-kind.json":    `{"cdiVersion": "0.5.0", "kind": "gpu", "devices": [{"name": "2"}]}`,
+		"invalid-version.json": `{"cdiVersion": "1.0.0", "kind": "vendor.com/gpu", "devices": [{"name": "2"}]}`,
+		"no-version.json":      `{"kind": "vendor.com/gpu", "devices": [{"name": "2"}]}`,
+		"no-device-name.json":  `{"cdiVersion": "0.5.0", "kind": "vendor.com/gpu", "devices": [{"name": "2"}, {}]}`,
+		"malformed.yaml":       `cdiVersion: [`,
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(runDir, name), []byte(spec), 0644))
+	}
+	devices, err = loadCDIDevices([]string{etcDir, runDir})
+	require.NoError(t, err)
+	assert.Len(t, devices, 2)
+	assert.Contains(t, devices, "vendor.com/gpu=0")
+	assert.Contains(t, devices, "vendor.com/gpu=1")
+}
+
+func TestInjectCDIDevices(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-cdi")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "gpu.json"), []byte(testCDISpecJSON), 0644))
+
+	c := newTestCRIService()
+	c.config.CDISpecDirs = []string{dir}
+	request := map[string]string{"cdi.k8s.io/gpu": "vendor.com/gpu=0"}
+
+	t.Logf("should reject cdi devices when cdi is disabled")
+	spec, err := defaultRuntimeSpec("test-id")
+	require.NoError(t, err)
+	g := newSpecGenerator(spec)
+	assert.Error(t, c.injectCDIDevices(&g, request))
+
+	c.config.EnableCDI = true
+	t.Logf("should reject unresolvable cdi device")
+	assert.Error(t, c.injectCDIDevices(&g, map[string]string{"cdi.k8s.io/gpu": "vendor.com/gpu=9"}))
+
+	t.Logf("should inject cdi device")
+	spec, err = defaultRuntimeSpec("test-id")
+	require.NoError(t, err)
+	g = newSpecGenerator(spec)
+	require.NoError(t, c.injectCDIDevices(&g, request))
+	assert.Contains(t, spec.Process.Env, "VENDOR_DRIVER=1")
+	assert.Contains(t, spec.Process.Env, "VENDOR_VISIBLE_DEVICES=0")
+	major, minor := int64(195), int64(0)
+	assert.Contains(t, spec.Linux.Devices, runtimespec.LinuxDevice{Path: "/dev/vendor0", Type: "c", Major: major, Minor: minor})
+	assert.Contains(t, spec.Linux.Resources.Devices, runtimespec.LinuxDeviceCgroup{
+		Allow:  true,
+		Type:   "c",
+		Major:  &major,
+		Minor:  &minor,
+		Access: "rwm",
+	})
+	assert.Contains(t, spec.Mounts, runtimespec.Mount{
+		Source:      "/usr/lib/vendor",
+		Destination: "/usr/lib/vendor",
+		Type:        "bind",
+		Options:     []string{"bind", "ro"},
+	})
+	require.NotNil(t, spec.Hooks)
+	assert.Equal(t, []runtimespec.Hook{{Path: "/usr/bin/vendor-hook", Args: []string{"vendor-hook", "ldcache"}}}, spec.Hooks.Prestart)
+}
@@ -0,0 +1,408 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containerd/cgroups"
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/api/types"
+	"github.com/containerd/typeurl"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"golang.org/x/sys/unix"
+
+	"github.com/containerd/cri/pkg/log"
+)
+
+// NOTE: The vendored runtime spec only has cgroup v1 resources (cpu shares,
+// quota, memory limit, pids limit), which runtimes without cgroup v2 support
+// can't apply on the unified hierarchy. So on cgroup v2 the resources are
+// translated into cgroup v2 controller files, which are written into the
+// cgroup of the container after the task is created and on resource updates.
+// The vendored containerd shim only reports cgroup v1 metrics, so container
+// metrics are read from the cgroup v2 filesystem directly, and converted into
+// the cgroup v1 metrics format.
+
+const (
+	// defaultCPUPeriod is the cfs period of a cpu quota without period.
+	defaultCPUPeriod = 100000
+	// cgroupRoot is the mount point of the cgroup filesystem.
+	cgroupRoot = "/sys/fs/cgroup"
+	// cgroup2SuperMagic is the filesystem magic number of cgroup2.
+	cgroup2SuperMagic = 0x63677270
+	// cgroupMax is the value of an unlimited cgroup v2 resource.
+	cgroupMax = "max"
+)
+
+// isUnifiedCgroupHierarchy returns whether the host only mounts the cgroup v2
+// unified hierarchy.
+func isUnifiedCgroupHierarchy() bool {
+	var st unix.Statfs_t
+	if err := unix.Statfs(cgroupRoot, &st); err != nil {
+		return false
+	}
+	return st.Type == cgroup2SuperMagic
+}
+
+// getUnifiedCgroupMetrics returns metrics of a running container read from the
+// cgroup v2 unified hierarchy, in the same format as the metrics returned by
+// containerd task service.
+func (c *criService) getUnifiedCgroupMetrics(ctx context.Context, cntr containerd.Container) (*types.Metric, error) {
+	path, err := c.getContainerCgroupPath(ctx, cntr)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := readUnifiedCgroupMetrics(filepath.Join(cgroupRoot, path))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read cgroup metrics")
+	}
+	data, err := typeurl.MarshalAny(metrics)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal cgroup metrics")
+	}
+	return &types.Metric{
+		Timestamp: time.Now(),
+		ID:        cntr.ID(),
+		Data:      data,
+	}, nil
+}
+
+// setCgroupV2Resources writes the resources in the runtime spec of a container
+// into its cgroup on the cgroup v2 unified hierarchy. It is a no-op on cgroup
+// v1, where the runtime applies the resources.
+func (c *criService) setCgroupV2Resources(ctx context.Context, cntr containerd.Container) error {
+	if !c.unifiedCgroup {
+		return nil
+	}
+	spec, err := cntr.Spec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get container spec")
+	}
+	if spec.Linux == nil {
+		return nil
+	}
+	resources := cgroupV2Resources(spec.Linux.Resources)
+	if len(resources) == 0 {
+		return nil
+	}
+	path, err := c.getContainerCgroupPath(ctx, cntr)
+	if err != nil {
+		return err
+	}
+	// Skip the files of the controllers not enabled in the cgroup.
+	for file := range resources {
+		if _, err := os.Stat(filepath.Join(cgroupRoot, path, file)); os.IsNotExist(err) {
+			log.Container.Debugf("Skip cgroup file %q of container %q, its controller is not enabled", file, cntr.ID())
+			delete(resources, file)
+		}
+	}
+	return writeCgroupFiles(cgroupRoot, path, true, resources)
+}
+
+// cgroupV2Resources translates the cgroup v1 resources of a runtime spec into
+// cgroup v2 controller files and their values.
+func cgroupV2Resources(r *runtimespec.LinuxResources) map[string]string {
+	files := make(map[string]string)
+	if r == nil {
+		return files
+	}
+	if cpu := r.CPU; cpu != nil {
+		if cpu.Shares != nil && *cpu.Shares != 0 {
+			files["cpu.weight"] = strconv.FormatUint(cpuSharesToWeight(*cpu.Shares), 10)
+		}
+		if cpu.Quota != nil {
+			period := uint64(defaultCPUPeriod)
+			if cpu.Period != nil && *cpu.Period != 0 {
+				period = *cpu.Period
+			}
+			quota := cgroupMax
+			if *cpu.Quota > 0 {
+				quota = strconv.FormatInt(*cpu.Quota, 10)
+			}
+			files["cpu.max"] = quota + " " + strconv.FormatUint(period, 10)
+		}
+		if cpu.Cpus != "" {
+			files["cpuset.cpus"] = cpu.Cpus
+		}
+		if cpu.Mems != "" {
+			files["cpuset.mems"] = cpu.Mems
+		}
+	}
+	if memory := r.Memory; memory != nil {
+		if memory.Limit != nil {
+			files["memory.max"] = cgroupLimit(*memory.Limit)
+			// The cgroup v1 swap limit is the limit of memory and swap, the
+			// cgroup v2 one is the limit of swap only.
+			if memory.Swap != nil && *memory.Limit > 0 {
+				if *memory.Swap < 0 {
+					files["memory.swap.max"] = cgroupMax
+				} else if *memory.Swap >= *memory.Limit {
+					files["memory.swap.max"] = strconv.FormatInt(*memory.Swap-*memory.Limit, 10)
+				}
+			}
+		}
+		if memory.Reservation != nil && *memory.Reservation > 0 {
+			files["memory.low"] = strconv.FormatInt(*memory.Reservation, 10)
+		}
+	}
+	if r.Pids != nil {
+		files["pids.max"] = cgroupLimit(r.Pids.Limit)
+	}
+	return files
+}
+
+// cpuSharesToWeight converts cgroup v1 cpu shares in [2, 262144] into cgroup
+// v2 cpu weight in [1, 10000].
+func cpuSharesToWeight(shares uint64) uint64 {
+	if shares < 2 {
+		shares = 2
+	} else if shares > 262144 {
+		shares = 262144
+	}
+	return 1 + ((shares-2)*9999)/262142
+}
+
+// cgroupLimit returns the cgroup v2 value of a limit, which is "max" if the
+// limit is not positive.
+func cgroupLimit(limit int64) string {
+	if limit <= 0 {
+		return cgroupMax
+	}
+	return strconv.FormatInt(limit, 10)
+}
+
+// getContainerCgroupPath returns the path of the cgroup of a container
+// relative to the cgroup mount point. The spec and the runtime are both read
+// from the container info, so that it costs one containerd request.
+func (c *criService) getContainerCgroupPath(ctx context.Context, cntr containerd.Container) (string, error) {
+	info, err := cntr.Info(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get container info")
+	}
+	if info.Spec == nil {
+		return "", errors.New("container spec is not set")
+	}
+	var spec runtimespec.Spec
+	if err := json.Unmarshal(info.Spec.Value, &spec); err != nil {
+		return "", errors.Wrap(err, "failed to unmarshal container spec")
+	}
+	if spec.Linux == nil || spec.Linux.CgroupsPath == "" {
+		return "", errors.New("cgroups path is not set in container spec")
+	}
+	ociRuntime, err := getRuntimeConfigFromContainerInfo(info)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get runtime config")
+	}
+	path, err := unifiedCgroupPath(spec.Linux.CgroupsPath, c.runtimeSystemdCgroup(ociRuntime))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get cgroup path for %q", spec.Linux.CgroupsPath)
+	}
+	return path, nil
+}
+
+// unifiedCgroupPath returns the path of a cgroup relative to the cgroup v2
+// mount point. With systemd cgroup driver, the cgroups path is in the form of
+// "slice:prefix:name", e.g. "kubepods-pod1.slice:cri-containerd:id".
+func unifiedCgroupPath(cgroupsPath string, systemdCgroup bool) (string, error) {
+	if !systemdCgroup {
+		return cgroupsPath, nil
+	}
+	parts := strings.Split(cgroupsPath, ":")
+	if len(parts) != 3 {
+		return "", errors.Errorf("expected cgroups path of the form slice:prefix:name, got %q", cgroupsPath)
+	}
+	slice, err := expandSlice(parts[0])
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(slice, parts[1]+"-"+parts[2]+".scope"), nil
+}
+
+// expandSlice expands a systemd slice name into its cgroup path, e.g.
+// "kubepods-burstable.slice" is expanded into
+// "kubepods.slice/kubepods-burstable.slice".
+func expandSlice(slice string) (string, error) {
+	const suffix = ".slice"
+	if slice == "" {
+		slice = "system.slice"
+	}
+	if !strings.HasSuffix(slice, suffix) || strings.Contains(slice, "/") {
+		return "", errors.Errorf("invalid slice name %q", slice)
+	}
+	name := strings.TrimSuffix(slice, suffix)
+	if name == "-" {
+		return "/", nil
+	}
+	var path, prefix string
+	for _, component := range strings.Split(name, "-") {
+		if component == "" {
+			return "", errors.Errorf("invalid slice name %q", slice)
+		}
+		path = filepath.Join(path, prefix+component+suffix)
+		prefix += component + "-"
+	}
+	return path, nil
+}
+
+// readUnifiedCgroupMetrics reads cgroup v2 controller files in a cgroup
+// directory, and converts them into cgroup v1 metrics.
+func readUnifiedCgroupMetrics(dir string) (*cgroups.Metrics, error) {
+	metrics := &cgroups.Metrics{}
+
+	cpuStat, err := readKeyValueFile(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return nil, err
+	}
+	// cpu.stat is in microseconds, while cgroup v1 metrics are in nanoseconds.
+	metrics.CPU = &cgroups.CPUStat{
+		Usage: &cgroups.CPUUsage{
+			Total:  cpuStat["usage_usec"] * 1000,
+			User:   cpuStat["user_usec"] * 1000,
+			Kernel: cpuStat["system_usec"] * 1000,
+		},
+		Throttling: &cgroups.Throttle{
+			Periods:          cpuStat["nr_periods"],
+			ThrottledPeriods: cpuStat["nr_throttled"],
+			ThrottledTime:    cpuStat["throttled_usec"] * 1000,
+		},
+	}
+
+	usage, err := readSingleValueFile(filepath.Join(dir, "memory.current"))
+	if err != nil {
+		return nil, err
+	}
+	limit, err := readSingleValueFile(filepath.Join(dir, "memory.max"))
+	if err != nil {
+		return nil, err
+	}
+	memoryStat, err := readKeyValueFile(filepath.Join(dir, "memory.stat"))
+	if err != nil {
+		return nil, err
+	}
+	metrics.Memory = &cgroups.MemoryStat{
+		Cache:             memoryStat["file"],
+		RSS:               memoryStat["anon"],
+		MappedFile:        memoryStat["file_mapped"],
+		Dirty:             memoryStat["file_dirty"],
+		Writeback:         memoryStat["file_writeback"],
+		PgFault:           memoryStat["pgfault"],
+		PgMajFault:        memoryStat["pgmajfault"],
+		InactiveAnon:      memoryStat["inactive_anon"],
+		ActiveAnon:        memoryStat["active_anon"],
+		InactiveFile:      memoryStat["inactive_file"],
+		ActiveFile:        memoryStat["active_file"],
+		Unevictable:       memoryStat["unevictable"],
+		TotalInactiveFile: memoryStat["inactive_file"],
+		Usage: &cgroups.MemoryEntry{
+			Usage: usage,
+			Limit: limit,
+		},
+	}
+
+	// The pids controller may not be enabled in the cgroup.
+	current, err := readSingleValueFile(filepath.Join(dir, "pids.current"))
+	if err == nil {
+		limit, err := readSingleValueFile(filepath.Join(dir, "pids.max"))
+		if err != nil {
+			return nil, err
+		}
+		metrics.Pids = &cgroups.PidsStat{
+			Current: current,
+			Limit:   limit,
+		}
+	} else if !os.IsNotExist(errors.Cause(err)) {
+		return nil, err
+	}
+
+	// The hugetlb controller may not be enabled in the cgroup, in which case
+	// there are no hugetlb files.
+	currentFiles, err := filepath.Glob(filepath.Join(dir, "hugetlb.*.current"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list hugetlb files")
+	}
+	for _, currentFile := range currentFiles {
+		pageSize := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(currentFile), "hugetlb."), ".current")
+		usage, err := readSingleValueFile(currentFile)
+		if err != nil {
+			return nil, err
+		}
+		events, err := readKeyValueFile(filepath.Join(dir, "hugetlb."+pageSize+".events"))
+		if err != nil {
+			return nil, err
+		}
+		metrics.Hugetlb = append(metrics.Hugetlb, &cgroups.HugetlbStat{
+			Usage:    usage,
+			Failcnt:  events["max"],
+			Pagesize: pageSize,
+		})
+	}
+	return metrics, nil
+}
+
+// readSingleValueFile reads a cgroup file containing a single value. "max" is
+// returned as 0, which means no limit in cgroup v1 metrics.
+func readSingleValueFile(path string) (uint64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read %q", path)
+	}
+	value := strings.TrimSpace(string(data))
+	if value == cgroupMax {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse %q", path)
+	}
+	return v, nil
+}
+
+// readKeyValueFile reads a flat keyed cgroup file, e.g. cpu.stat.
+func readKeyValueFile(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %q", path)
+	}
+	defer f.Close()
+	values := make(map[string]uint64)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %q in %q", s.Text(), path)
+		}
+		values[fields[0]] = v
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read %q", path)
+	}
+	return values, nil
+}
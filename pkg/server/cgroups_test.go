@@ -0,0 +1,193 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/cgroups"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedCgroupPath(t *testing.T) {
+	for desc, test := range map[string]struct {
+		cgroupsPath   string
+		systemdCgroup bool
+		expected      string
+		expectErr     bool
+	}{
+		"cgroupfs path should be used as is": {
+			cgroupsPath: "/kubepods/burstable/pod123/id",
+			expected:    "/kubepods/burstable/pod123/id",
+		},
+		"systemd path should be expanded": {
+			cgroupsPath:   "kubepods-burstable-pod123.slice:cri-containerd:id",
+			systemdCgroup: true,
+			expected:      "kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod123.slice/cri-containerd-id.scope",
+		},
+		"empty systemd slice should default to system.slice": {
+			cgroupsPath:   ":cri-containerd:id",
+			systemdCgroup: true,
+			expected:      "system.slice/cri-containerd-id.scope",
+		},
+		"invalid systemd path should return error": {
+			cgroupsPath:   "/kubepods/id",
+			systemdCgroup: true,
+			expectErr:     true,
+		},
+		"invalid slice name should return error": {
+			cgroupsPath:   "kubepods--pod.slice:cri-containerd:id",
+			systemdCgroup: true,
+			expectErr:     true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		path, err := unifiedCgroupPath(test.cgroupsPath, test.systemdCgroup)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, path)
+	}
+}
+
+func TestCgroupV2Resources(t *testing.T) {
+	int64Ptr := func(i int64) *int64 { return &i }
+	uint64Ptr := func(i uint64) *uint64 { return &i }
+	for desc, test := range map[string]struct {
+		resources *runtimespec.LinuxResources
+		expected  map[string]string
+	}{
+		"should return no files without resources": {
+			expected: map[string]string{},
+		},
+		"should translate cpu resources": {
+			resources: &runtimespec.LinuxResources{
+				CPU: &runtimespec.LinuxCPU{
+					Shares: uint64Ptr(1024),
+					Quota:  int64Ptr(50000),
+					Period: uint64Ptr(100000),
+					Cpus:   "0-1",
+					Mems:   "0",
+				},
+			},
+			expected: map[string]string{
+				"cpu.weight":  "39",
+				"cpu.max":     "50000 100000",
+				"cpuset.cpus": "0-1",
+				"cpuset.mems": "0",
+			},
+		},
+		"should use max without cpu quota and the default period": {
+			resources: &runtimespec.LinuxResources{
+				CPU: &runtimespec.LinuxCPU{Quota: int64Ptr(-1)},
+			},
+			expected: map[string]string{"cpu.max": "max 100000"},
+		},
+		"should translate memory resources": {
+			resources: &runtimespec.LinuxResources{
+				Memory: &runtimespec.LinuxMemory{
+					Limit:       int64Ptr(1 << 30),
+					Swap:        int64Ptr(3 << 29),
+					Reservation: int64Ptr(1 << 29),
+				},
+			},
+			expected: map[string]string{
+				"memory.max":      "1073741824",
+				"memory.swap.max": "536870912",
+				"memory.low":      "536870912",
+			},
+		},
+		"should use max without memory limit": {
+			resources: &runtimespec.LinuxResources{
+				Memory: &runtimespec.LinuxMemory{Limit: int64Ptr(0)},
+			},
+			expected: map[string]string{"memory.max": "max"},
+		},
+		"should allow unlimited swap": {
+			resources: &runtimespec.LinuxResources{
+				Memory: &runtimespec.LinuxMemory{Limit: int64Ptr(1 << 30), Swap: int64Ptr(-1)},
+			},
+			expected: map[string]string{"memory.max": "1073741824", "memory.swap.max": "max"},
+		},
+		"should translate pids limit": {
+			resources: &runtimespec.LinuxResources{
+				Pids: &runtimespec.LinuxPids{Limit: 1024},
+			},
+			expected: map[string]string{"pids.max": "1024"},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		assert.Equal(t, test.expected, cgroupV2Resources(test.resources))
+	}
+}
+
+func TestCPUSharesToWeight(t *testing.T) {
+	for shares, weight := range map[uint64]uint64{
+		0:      1,
+		2:      1,
+		1024:   39,
+		262144: 10000,
+		300000: 10000,
+	} {
+		assert.Equal(t, weight, cpuSharesToWeight(shares), "shares %d", shares)
+	}
+}
+
+func TestReadUnifiedCgroupMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-cgroup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for file, content := range map[string]string{
+		"cpu.stat":            "usage_usec 100\nuser_usec 60\nsystem_usec 40\nnr_periods 3\nnr_throttled 2\nthrottled_usec 1\n",
+		"memory.current":      "4096\n",
+		"memory.max":          "max\n",
+		"memory.stat":         "anon 1024\nfile 2048\ninactive_file 1024\n",
+		"pids.current":        "5\n",
+		"pids.max":            "100\n",
+		"hugetlb.2MB.current": "4194304\n",
+		"hugetlb.2MB.events":  "max 3\n",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0644))
+	}
+
+	metrics, err := readUnifiedCgroupMetrics(dir)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100000), metrics.CPU.Usage.Total)
+	assert.Equal(t, uint64(60000), metrics.CPU.Usage.User)
+	assert.Equal(t, uint64(40000), metrics.CPU.Usage.Kernel)
+	assert.Equal(t, uint64(2), metrics.CPU.Throttling.ThrottledPeriods)
+	assert.Equal(t, uint64(4096), metrics.Memory.Usage.Usage)
+	assert.Equal(t, uint64(0), metrics.Memory.Usage.Limit)
+	assert.Equal(t, uint64(3072), getWorkingSet(metrics.Memory))
+	assert.Equal(t, uint64(5), metrics.Pids.Current)
+	assert.Equal(t, uint64(100), metrics.Pids.Limit)
+	require.Len(t, metrics.Hugetlb, 1)
+	assert.Equal(t, &cgroups.HugetlbStat{Usage: 4194304, Failcnt: 3, Pagesize: "2MB"}, metrics.Hugetlb[0])
+
+	// pids controller is optional.
+	require.NoError(t, os.Remove(filepath.Join(dir, "pids.current")))
+	metrics, err = readUnifiedCgroupMetrics(dir)
+	require.NoError(t, err)
+	assert.Nil(t, metrics.Pids)
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+
+	cni "github.com/containerd/go-cni"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/containerd/cri/pkg/log"
+)
+
+// cniConfEvents are the inotify events on the cni config directory which
+// trigger a reload. Files created but not yet written are ignored.
+const cniConfEvents = unix.IN_CLOSE_WRITE | unix.IN_MOVED_TO | unix.IN_MOVED_FROM |
+	unix.IN_DELETE | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF
+
+// cniConfDirRecreatePeriod is the period of checking whether a removed cni
+// config directory is recreated.
+var cniConfDirRecreatePeriod = time.Second
+
+// cniNetConfSyncer watches the cni config directory with inotify, and reloads
+// the cni config when it is changed.
+type cniNetConfSyncer struct {
+	sync.RWMutex
+	// lastSyncStatus is the error of the last reload.
+	lastSyncStatus error
+	// networks are the pod networks loaded with the cni config, which pods
+	// are set up with. networksErr is the error of loading them.
+	networks    []podNetwork
+	networksErr error
+	// watcher is the inotify instance, fd is its file descriptor and wd is
+	// the watch of the cni config directory.
+	watcher   *os.File
+	fd        int
+	wd        int
+	stopCh    chan struct{}
+	confDir   string
+	netPlugin cni.CNI
+	loadOpts  []cni.CNIOpt
+}
+
+// newCNINetConfSyncer creates the cni config directory if it doesn't exist,
+// starts watching it and loads the cni config.
+func newCNINetConfSyncer(confDir string, netPlugin cni.CNI, loadOpts []cni.CNIOpt) (*cniNetConfSyncer, error) {
+	if err := os.MkdirAll(confDir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create cni conf dir %q", confDir)
+	}
+	// A non-blocking inotify instance is managed by the go runtime poller,
+	// so that the blocking read is interrupted when the file is closed.
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create inotify instance")
+	}
+	wd, err := unix.InotifyAddWatch(fd, confDir, cniConfEvents)
+	if err != nil {
+		unix.Close(fd) // nolint: errcheck
+		return nil, errors.Wrapf(err, "failed to watch cni conf dir %q", confDir)
+	}
+	syncer := &cniNetConfSyncer{
+		watcher:   os.NewFile(uintptr(fd), "inotify"),
+		fd:        fd,
+		wd:        wd,
+		stopCh:    make(chan struct{}),
+		confDir:   confDir,
+		netPlugin: netPlugin,
+		loadOpts:  loadOpts,
+	}
+	if err := syncer.reload(); err != nil {
+		log.CNI.WithError(err).Error("Failed to load cni during init, please check CRI plugin status before setting up network for pods")
+	}
+	return syncer, nil
+}
+
+// syncLoop reloads the cni config on each batch of changes in the cni config
+// directory. When the directory is removed or moved, it waits for the
+// directory to be recreated and watches it again. It returns when the syncer
+// is stopped, or the inotify events can't be read anymore.
+func (syncer *cniNetConfSyncer) syncLoop() error {
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := syncer.watcher.Read(buf)
+		if err != nil {
+			if pathErr, ok := err.(*os.PathError); ok && pathErr.Err == os.ErrClosed {
+				return nil
+			}
+			return errors.Wrap(err, "failed to read inotify events")
+		}
+		var mask uint32
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			// Skip the events of a previous watch of the directory.
+			if int(event.Wd) == syncer.wd {
+				mask |= event.Mask
+			}
+			offset += unix.SizeofInotifyEvent + int(event.Len)
+		}
+		if mask == 0 {
+			continue
+		}
+		if mask&(unix.IN_DELETE_SELF|unix.IN_MOVE_SELF|unix.IN_IGNORED) != 0 {
+			log.CNI.Errorf("CNI conf dir %q is removed, waiting for it to be recreated", syncer.confDir)
+			// The reload fails without the directory, which is reported in
+			// the network status.
+			syncer.reload() // nolint: errcheck
+			if !syncer.rewatch() {
+				return nil
+			}
+			log.CNI.Infof("Watching recreated cni conf dir %q", syncer.confDir)
+		}
+		log.CNI.Debugf("Reload cni config after receiving inotify events %#x", mask)
+		if err := syncer.reload(); err != nil {
+			log.CNI.WithError(err).Error("Failed to reload cni configuration after receiving fs change event")
+		}
+	}
+}
+
+// rewatch waits for the removed or moved cni config directory to be recreated,
+// and watches it again. It returns false if the syncer is stopped.
+func (syncer *cniNetConfSyncer) rewatch() bool {
+	// The watch of a moved directory is still there.
+	unix.InotifyRmWatch(syncer.fd, uint32(syncer.wd)) // nolint: errcheck
+	ticker := time.NewTicker(cniConfDirRecreatePeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-syncer.stopCh:
+			return false
+		case <-ticker.C:
+		}
+		wd, err := unix.InotifyAddWatch(syncer.fd, syncer.confDir, cniConfEvents)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.CNI.WithError(err).Errorf("Failed to watch cni conf dir %q", syncer.confDir)
+			}
+			continue
+		}
+		syncer.wd = wd
+		return true
+	}
+}
+
+// reload reloads the cni config and records the result as the last status.
+// The pod networks are loaded from the same config under the lock, so that
+// pods are never set up with networks of a different config.
+func (syncer *cniNetConfSyncer) reload() error {
+	syncer.Lock()
+	defer syncer.Unlock()
+	err := syncer.netPlugin.Load(syncer.loadOpts...)
+	syncer.lastSyncStatus = err
+	syncer.networks, syncer.networksErr = nil, err
+	if err == nil {
+		syncer.networks, syncer.networksErr = loadPodNetworks(syncer.confDir)
+	}
+	return err
+}
+
+// podNetworks returns the pod networks of the loaded cni config.
+func (syncer *cniNetConfSyncer) podNetworks() ([]podNetwork, error) {
+	syncer.RLock()
+	defer syncer.RUnlock()
+	if syncer.networksErr != nil {
+		return nil, syncer.networksErr
+	}
+	return syncer.networks, nil
+}
+
+// lastStatus returns the error of the last reload.
+func (syncer *cniNetConfSyncer) lastStatus() error {
+	syncer.RLock()
+	defer syncer.RUnlock()
+	return syncer.lastSyncStatus
+}
+
+func (syncer *cniNetConfSyncer) updateLastStatus(err error) {
+	syncer.Lock()
+	defer syncer.Unlock()
+	syncer.lastSyncStatus = err
+}
+
+// stop stops watching the cni config directory.
+func (syncer *cniNetConfSyncer) stop() error {
+	close(syncer.stopCh)
+	return syncer.watcher.Close()
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	cni "github.com/containerd/go-cni"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	servertesting "github.com/containerd/cri/pkg/server/testing"
+)
+
+// loadNotifyingCNIPlugin notifies each load of the network config.
+type loadNotifyingCNIPlugin struct {
+	*servertesting.FakeCNIPlugin
+	sync.Mutex
+	loadErr error
+	loaded  chan struct{}
+}
+
+func (p *loadNotifyingCNIPlugin) Load(opts ...cni.CNIOpt) error {
+	p.loaded <- struct{}{}
+	p.Lock()
+	defer p.Unlock()
+	return p.loadErr
+}
+
+func (p *loadNotifyingCNIPlugin) setLoadErr(err error) {
+	p.Lock()
+	defer p.Unlock()
+	p.loadErr = err
+}
+
+func TestCNINetConfSyncer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-cni-conf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	confDir := filepath.Join(dir, "net.d")
+	defer func(period time.Duration) { cniConfDirRecreatePeriod = period }(cniConfDirRecreatePeriod)
+	cniConfDirRecreatePeriod = 10 * time.Millisecond
+	var syncer *cniNetConfSyncer
+	netPlugin := &loadNotifyingCNIPlugin{
+		FakeCNIPlugin: servertesting.NewFakeCNIPlugin(),
+		loaded:        make(chan struct{}, 10),
+	}
+	waitLoad := func() {
+		select {
+		case <-netPlugin.loaded:
+		case <-time.After(10 * time.Second):
+			t.Fatal("cni config is not loaded")
+		}
+	}
+	waitStatus := func(failed bool) {
+		for i := 0; i < 100 && (syncer.lastStatus() != nil) != failed; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, failed, syncer.lastStatus() != nil)
+	}
+
+	t.Logf("should create the cni conf dir and load the config")
+	syncer, err = newCNINetConfSyncer(confDir, netPlugin, nil)
+	require.NoError(t, err)
+	waitLoad()
+	_, err = os.Stat(confDir)
+	assert.NoError(t, err)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- syncer.syncLoop()
+	}()
+
+	t.Logf("should reload the config when a config file is written")
+	netPlugin.setLoadErr(errors.New("invalid config"))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(confDir, "10-test.conflist"), []byte("{}"), 0644))
+	waitLoad()
+	waitStatus(true)
+
+	t.Logf("should reload the config when a config file is removed")
+	netPlugin.setLoadErr(nil)
+	require.NoError(t, os.Remove(filepath.Join(confDir, "10-test.conflist")))
+	waitLoad()
+	waitStatus(false)
+
+	t.Logf("should watch the cni conf dir again after it is recreated")
+	require.NoError(t, os.RemoveAll(confDir))
+	waitLoad()
+	require.NoError(t, os.Mkdir(confDir, 0755))
+	waitLoad()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(confDir, "10-test.conflist"), []byte("{}"), 0644))
+	waitLoad()
+
+	t.Logf("should stop syncing after the syncer is stopped")
+	require.NoError(t, syncer.stop())
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("sync loop is not stopped")
+	}
+}
+
+func TestCNINetConfSyncerPodNetworks(t *testing.T) {
+	confDir, err := ioutil.TempDir("", "test-cni-conf")
+	require.NoError(t, err)
+	defer os.RemoveAll(confDir)
+	netPlugin := servertesting.NewFakeCNIPlugin()
+	syncer := &cniNetConfSyncer{confDir: confDir, netPlugin: netPlugin}
+	writeConf := func(name, network string) {
+		conf := `{"cniVersion": "0.3.1", "name": "` + network + `", "type": "bridge"}`
+		require.NoError(t, ioutil.WriteFile(filepath.Join(confDir, name), []byte(conf), 0644))
+	}
+	networkNames := func() []string {
+		networks, err := syncer.podNetworks()
+		require.NoError(t, err)
+		var names []string
+		for _, network := range networks {
+			names = append(names, network.confList.Name)
+		}
+		return names
+	}
+
+	t.Logf("should load the pod networks with the cni config")
+	writeConf("10-first.conf", "first")
+	require.NoError(t, syncer.reload())
+	assert.Equal(t, []string{"cni-loopback", "first"}, networkNames())
+
+	t.Logf("should keep the pod networks until the cni config is reloaded")
+	writeConf("20-second.conf", "second")
+	assert.Equal(t, []string{"cni-loopback", "first"}, networkNames())
+	require.NoError(t, syncer.reload())
+	assert.Equal(t, []string{"cni-loopback", "first", "second"}, networkNames())
+
+	t.Logf("should not return pod networks if the cni config fails to load")
+	netPlugin.LoadErr = errors.New("invalid config")
+	assert.Error(t, syncer.reload())
+	_, err = syncer.podNetworks()
+	assert.Error(t, err)
+}
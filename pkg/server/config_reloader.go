@@ -0,0 +1,296 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"unsafe"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	"github.com/containerd/cri/pkg/log"
+	"github.com/containerd/cri/pkg/util"
+)
+
+// reloadableConfigEvents are the inotify events on the directory of the
+// reloadable config file which trigger a reload. Editors usually replace the
+// file by renaming, so the directory is watched instead of the file.
+const reloadableConfigEvents = unix.IN_CLOSE_WRITE | unix.IN_MOVED_TO | unix.IN_MOVED_FROM |
+	unix.IN_DELETE | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF
+
+// configReloader re-reads the reloadable config file on SIGHUP or when it is
+// changed. The new config is validated as a whole before it replaces the
+// current one, and the current one is kept if it is invalid.
+type configReloader struct {
+	// mu serializes reloads and guards current.
+	mu sync.RWMutex
+	// base is the reloadable config in the plugin config, which is used for
+	// the sections not set in the file.
+	base criconfig.ReloadableConfig
+	// current is the config in effect.
+	current criconfig.ReloadableConfig
+	// generation is incremented whenever current is replaced.
+	generation uint64
+	// lastReloadStatus is the error of the last reload.
+	lastReloadStatus lastError
+	path             string
+	// watcher is the inotify instance.
+	watcher *os.File
+	signals chan os.Signal
+}
+
+// newConfigReloader starts watching the reloadable config file, and loads it.
+// An invalid file fails the start, because there is no previous config to
+// fall back to other than the plugin config.
+func newConfigReloader(path string, config criconfig.Config) (*configReloader, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create reloadable config dir %q", dir)
+	}
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create inotify instance")
+	}
+	if _, err := unix.InotifyAddWatch(fd, dir, reloadableConfigEvents); err != nil {
+		unix.Close(fd) // nolint: errcheck
+		return nil, errors.Wrapf(err, "failed to watch reloadable config dir %q", dir)
+	}
+	r := &configReloader{
+		base:    baseReloadableConfig(config.PluginConfig),
+		path:    path,
+		watcher: os.NewFile(uintptr(fd), "inotify"),
+		signals: make(chan os.Signal, 1),
+	}
+	r.current = r.base
+	if err := r.reload(); err != nil {
+		r.watcher.Close() // nolint: errcheck
+		return nil, err
+	}
+	return r, nil
+}
+
+// get returns the config in effect.
+func (r *configReloader) get() criconfig.ReloadableConfig {
+	config, _ := r.getWithGeneration()
+	return config
+}
+
+// getWithGeneration returns the config in effect and its generation.
+func (r *configReloader) getWithGeneration() (criconfig.ReloadableConfig, uint64) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current, r.generation
+}
+
+// reload reads, validates and applies the reloadable config file, and
+// records the result as the last status. A missing file resets the config to
+// the plugin config.
+func (r *configReloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	config, err := r.load()
+	r.lastReloadStatus.set(err)
+	if err != nil {
+		return err
+	}
+	if config.LogLevel != r.current.LogLevel {
+		level, _ := log.ParseLevel(config.LogLevel)
+		logrus.SetLevel(level)
+	}
+	r.current = config
+	r.generation++
+	logrus.Infof("Loaded reloadable config %q", r.path)
+	return nil
+}
+
+// load reads the reloadable config file, overrides the base config with it,
+// and validates the result.
+func (r *configReloader) load() (criconfig.ReloadableConfig, error) {
+	config := r.base
+	var file criconfig.ReloadableConfig
+	meta, err := toml.DecodeFile(r.path, &file)
+	if err != nil && !os.IsNotExist(err) {
+		return config, errors.Wrapf(err, "failed to decode reloadable config %q", r.path)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		var keys []string
+		for _, key := range undecoded {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		return config, errors.Errorf("unsupported keys %q in reloadable config %q", keys, r.path)
+	}
+	if file.LogLevel != "" {
+		config.LogLevel = file.LogLevel
+	}
+	if file.SandboxImage != "" {
+		config.SandboxImage = file.SandboxImage
+	}
+	if file.Registry.Mirrors != nil {
+		config.Registry.Mirrors = file.Registry.Mirrors
+	}
+	if file.Registry.Configs != nil {
+		config.Registry.Configs = file.Registry.Configs
+	}
+	if err := validateReloadableConfig(config); err != nil {
+		return config, errors.Wrapf(err, "invalid reloadable config %q", r.path)
+	}
+	return config, nil
+}
+
+// validateReloadableConfig validates a reloadable config before it is
+// applied, including the registry auths and TLS files.
+func validateReloadableConfig(config criconfig.ReloadableConfig) error {
+	if _, err := log.ParseLevel(config.LogLevel); err != nil {
+		return errors.Wrap(err, "invalid log_level")
+	}
+	if _, err := util.NormalizeImageRef(config.SandboxImage); err != nil {
+		return errors.Wrapf(err, "invalid sandbox_image %q", config.SandboxImage)
+	}
+	for ns, mirror := range config.Registry.Mirrors {
+		for _, endpoint := range mirror.Endpoints {
+			u, err := url.Parse(endpoint)
+			if err != nil {
+				return errors.Wrapf(err, "invalid endpoint of mirror %q", ns)
+			}
+			if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return errors.Errorf("endpoint %q of mirror %q is not a http or https url", endpoint, ns)
+			}
+		}
+	}
+	for host, registry := range config.Registry.Configs {
+		if registry.Auth != nil {
+			if _, _, err := ParseAuth(&runtime.AuthConfig{
+				Username:      registry.Auth.Username,
+				Password:      registry.Auth.Password,
+				Auth:          registry.Auth.Auth,
+				IdentityToken: registry.Auth.IdentityToken,
+			}); err != nil {
+				return errors.Wrapf(err, "invalid auth of registry %q", host)
+			}
+		}
+		if registry.TLS != nil {
+			if _, err := getTLSConfig(*registry.TLS); err != nil {
+				return errors.Wrapf(err, "invalid tls of registry %q", host)
+			}
+		}
+	}
+	return nil
+}
+
+// run reloads the config on SIGHUP and on each batch of changes of the
+// reloadable config file. It returns when the reloader is stopped, or the
+// directory of the file can't be watched anymore, in which case SIGHUP still
+// triggers reloads.
+func (r *configReloader) run() error {
+	signal.Notify(r.signals, unix.SIGHUP)
+	go func() {
+		for range r.signals {
+			logrus.Info("Reload config after receiving SIGHUP")
+			if err := r.reload(); err != nil {
+				logrus.WithError(err).Error("Failed to reload config, keep the previous one")
+			}
+		}
+	}()
+	name := filepath.Base(r.path)
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := r.watcher.Read(buf)
+		if err != nil {
+			if pathErr, ok := err.(*os.PathError); ok && pathErr.Err == os.ErrClosed {
+				return nil
+			}
+			return errors.Wrap(err, "failed to read inotify events")
+		}
+		var changed bool
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			if event.Mask&(unix.IN_DELETE_SELF|unix.IN_MOVE_SELF|unix.IN_IGNORED) != 0 {
+				return errors.Errorf("reloadable config dir %q is removed", filepath.Dir(r.path))
+			}
+			nameStart := offset + unix.SizeofInotifyEvent
+			eventName := string(bytes.TrimRight(buf[nameStart:nameStart+int(event.Len)], "\x00"))
+			if eventName == name {
+				changed = true
+			}
+			offset = nameStart + int(event.Len)
+		}
+		if !changed {
+			continue
+		}
+		logrus.Debugf("Reload config after %q is changed", r.path)
+		if err := r.reload(); err != nil {
+			logrus.WithError(err).Error("Failed to reload config, keep the previous one")
+		}
+	}
+}
+
+// stop stops reloading the config.
+func (r *configReloader) stop() error {
+	signal.Stop(r.signals)
+	close(r.signals)
+	return r.watcher.Close()
+}
+
+// reloadableConfig returns the reloadable config in effect, which is the
+// plugin config if reloading is disabled.
+func (c *criService) reloadableConfig() criconfig.ReloadableConfig {
+	config, _ := c.reloadableConfigWithGeneration()
+	return config
+}
+
+// reloadableConfigWithGeneration returns the reloadable config in effect and
+// its generation, which changes on every reload. The generation of the plugin
+// config is always 0.
+func (c *criService) reloadableConfigWithGeneration() (criconfig.ReloadableConfig, uint64) {
+	if c.configReloader != nil {
+		return c.configReloader.getWithGeneration()
+	}
+	return baseReloadableConfig(c.config.PluginConfig), 0
+}
+
+// baseReloadableConfig returns the reloadable config in the plugin config,
+// with the current log level.
+func baseReloadableConfig(config criconfig.PluginConfig) criconfig.ReloadableConfig {
+	return criconfig.ReloadableConfig{
+		LogLevel:     log.GetLevel(),
+		SandboxImage: config.SandboxImage,
+		Registry: criconfig.ReloadableRegistry{
+			Mirrors: config.Registry.Mirrors,
+			Configs: config.Registry.Configs,
+		},
+	}
+}
+
+// lastConfigReloadStatus returns the error of the last config reload.
+func (c *criService) lastConfigReloadStatus() error {
+	if c.configReloader == nil {
+		return nil
+	}
+	return c.configReloader.lastReloadStatus.get()
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestValidateReloadableConfig(t *testing.T) {
+	valid := criconfig.ReloadableConfig{
+		LogLevel:     "info",
+		SandboxImage: "k8s.gcr.io/pause:3.1",
+	}
+	for desc, test := range map[string]struct {
+		update    func(*criconfig.ReloadableConfig)
+		expectErr bool
+	}{
+		"should accept valid config": {},
+		"should accept mirrors and auths": {
+			update: func(config *criconfig.ReloadableConfig) {
+				config.Registry.Mirrors = map[string]criconfig.Mirror{
+					"docker.io": {Endpoints: []string{"https://mirror.example.com", "http://127.0.0.1:5000"}},
+				}
+				config.Registry.Configs = map[string]criconfig.RegistryConfig{
+					"gcr.io": {Auth: &criconfig.AuthConfig{Username: "user", Password: "pass"}},
+				}
+			},
+		},
+		"should reject invalid log level": {
+			update:    func(config *criconfig.ReloadableConfig) { config.LogLevel = "verbose" },
+			expectErr: true,
+		},
+		"should reject invalid sandbox image": {
+			update:    func(config *criconfig.ReloadableConfig) { config.SandboxImage = "Invalid:Image" },
+			expectErr: true,
+		},
+		"should reject mirror endpoint which is not a url": {
+			update: func(config *criconfig.ReloadableConfig) {
+				config.Registry.Mirrors = map[string]criconfig.Mirror{
+					"docker.io": {Endpoints: []string{"mirror.example.com"}},
+				}
+			},
+			expectErr: true,
+		},
+		"should reject empty auth": {
+			update: func(config *criconfig.ReloadableConfig) {
+				config.Registry.Configs = map[string]criconfig.RegistryConfig{
+					"gcr.io": {Auth: &criconfig.AuthConfig{}},
+				}
+			},
+			expectErr: true,
+		},
+		"should reject missing tls files": {
+			update: func(config *criconfig.ReloadableConfig) {
+				config.Registry.Configs = map[string]criconfig.RegistryConfig{
+					"gcr.io": {TLS: &criconfig.TLSConfig{CAFile: "/nonexistent/ca.pem"}},
+				}
+			},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		config := valid
+		if test.update != nil {
+			test.update(&config)
+		}
+		err := validateReloadableConfig(config)
+		assert.Equal(t, test.expectErr, err != nil)
+	}
+}
+
+func TestConfigReloader(t *testing.T) {
+	defer logrus.SetLevel(logrus.GetLevel())
+	logrus.SetLevel(logrus.InfoLevel)
+	dir, err := ioutil.TempDir("", "config-reloader")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "reloadable.toml")
+	config := criconfig.Config{PluginConfig: criconfig.DefaultConfig()}
+	r, err := newConfigReloader(path, config)
+	require.NoError(t, err)
+	defer r.stop()
+
+	t.Logf("should use plugin config without the file")
+	base := r.get()
+	assert.Equal(t, "info", base.LogLevel)
+	assert.Equal(t, config.SandboxImage, base.SandboxImage)
+	assert.Equal(t, config.Registry.Mirrors, base.Registry.Mirrors)
+
+	t.Logf("should override sections set in the file")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+log_level = "debug"
+[registry.configs."gcr.io".auth]
+  username = "user"
+  password = "pass"
+`), 0600))
+	require.NoError(t, r.reload())
+	current := r.get()
+	assert.Equal(t, "debug", current.LogLevel)
+	assert.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+	assert.Equal(t, config.SandboxImage, current.SandboxImage)
+	assert.Equal(t, config.Registry.Mirrors, current.Registry.Mirrors)
+	assert.Equal(t, "user", current.Registry.Configs["gcr.io"].Auth.Username)
+
+	t.Logf("should keep previous config if the file is invalid")
+	for _, content := range []string{
+		`log_level = "debug`,
+		`sandbox_image = "Invalid:Image"`,
+		`unknown_option = true`,
+	} {
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+		assert.Error(t, r.reload())
+		assert.Equal(t, current, r.get())
+		assert.Error(t, r.lastReloadStatus.get())
+	}
+
+	t.Logf("should reload when the file is changed")
+	go r.run() // nolint: errcheck
+	tmp := filepath.Join(dir, "reloadable.toml.tmp")
+	require.NoError(t, ioutil.WriteFile(tmp, []byte(`sandbox_image = "k8s.gcr.io/pause:3.2"`), 0600))
+	require.NoError(t, os.Rename(tmp, path))
+	for i := 0; i < 100 && r.get().SandboxImage != "k8s.gcr.io/pause:3.2"; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.Equal(t, "k8s.gcr.io/pause:3.2", r.get().SandboxImage)
+	assert.Equal(t, "info", r.get().LogLevel)
+	assert.NoError(t, r.lastReloadStatus.get())
+
+	t.Logf("should reset to plugin config when the file is removed")
+	require.NoError(t, os.Remove(path))
+	for i := 0; i < 100 && r.get().SandboxImage != config.SandboxImage; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.Equal(t, base, r.get())
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+// systemdRunDir exists if systemd is the init system.
+const systemdRunDir = "/run/systemd/system"
+
+// ValidatePluginConfig validates the cri plugin config without starting the
+// plugin, e.g. to check a config before restarting containerd with it. It
+// returns the errors NewCRIService would return, except for the ones which
+// depend on containerd, e.g. unknown snapshotters. It also returns the errors
+// of the registry and sandbox_image configs, which only fail image pulls.
+func ValidatePluginConfig(config criconfig.PluginConfig) error {
+	if err := validatePluginComponents(config); err != nil {
+		return err
+	}
+	if err := validatePluginConfig(config); err != nil {
+		return err
+	}
+	if err := validateReloadableConfig(baseReloadableConfig(config)); err != nil {
+		return errors.Wrap(err, "invalid registry or sandbox_image")
+	}
+	return nil
+}
+
+// validatePluginComponents validates the configs of the plugin components by
+// building them. NewCRIService doesn't call it, it builds the components
+// itself and returns the same errors.
+func validatePluginComponents(config criconfig.PluginConfig) error {
+	if _, err := newExecLimiter(config.ExecLimits); err != nil {
+		return errors.Wrap(err, "invalid exec limits")
+	}
+	if _, err := newOperationTimeouts(config.OperationTimeouts); err != nil {
+		return errors.Wrap(err, "invalid operation timeouts")
+	}
+	if _, err := newSpecHooks(config.SpecHooks); err != nil {
+		return errors.Wrap(err, "invalid spec_hooks config")
+	}
+	if _, err := newImageDistributor(config.Registry.P2P); err != nil {
+		return errors.Wrap(err, "invalid p2p config")
+	}
+	if _, err := newTracer(config.Tracing); err != nil {
+		return errors.Wrap(err, "invalid tracing config")
+	}
+	if _, err := newRPCLimiter(config.RateLimits); err != nil {
+		return errors.Wrap(err, "invalid rate_limits")
+	}
+	if config.ImageGC.Enabled {
+		if _, err := newImageGCManager(nil, config.ImageGC); err != nil {
+			return errors.Wrap(err, "invalid image gc config")
+		}
+	}
+	if config.ReloadableConfigPath != "" {
+		r := &configReloader{path: config.ReloadableConfigPath, base: baseReloadableConfig(config)}
+		if _, err := r.load(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validatePluginConfig validates the plugin config except for the configs of
+// the components built by NewCRIService.
+func validatePluginConfig(config criconfig.PluginConfig) error {
+	if err := validateLogFormat(config.ContainerLogFormat); err != nil {
+		return errors.Wrap(err, "invalid container_log_format")
+	}
+	if err := validateNetNSDirs(config.AllowedNetNSDirs); err != nil {
+		return errors.Wrap(err, "invalid allowed_netns_dirs")
+	}
+	if config.CheckpointDir != "" && !filepath.IsAbs(config.CheckpointDir) {
+		return errors.Errorf("invalid checkpoint_dir: directory %q is not absolute", config.CheckpointDir)
+	}
+	if err := validateInstances(config); err != nil {
+		return errors.Wrap(err, "invalid instances")
+	}
+	if err := validatePeerAllowList(config.GRPC); err != nil {
+		return errors.Wrap(err, "invalid grpc config")
+	}
+	if err := validateAuditConfig(config.Audit); err != nil {
+		return errors.Wrap(err, "invalid audit config")
+	}
+	if config.StreamIdleTimeout != "" {
+		if _, err := time.ParseDuration(config.StreamIdleTimeout); err != nil {
+			return errors.Wrapf(err, "invalid stream_idle_timeout %q", config.StreamIdleTimeout)
+		}
+	}
+	if _, err := getStreamListenerMode(&criService{config: criconfig.Config{PluginConfig: config}}); err != nil {
+		return errors.Wrap(err, "invalid stream server configuration")
+	}
+	for handler, r := range config.ContainerdConfig.Runtimes {
+		if err := validateRuntime(r); err != nil {
+			return errors.Wrapf(err, "invalid runtime %q", handler)
+		}
+	}
+	if err := validateRuntime(config.ContainerdConfig.DefaultRuntime); err != nil {
+		return errors.Wrap(err, "invalid default runtime")
+	}
+	if err := validateRuntime(config.ContainerdConfig.UntrustedWorkloadRuntime); err != nil {
+		return errors.Wrap(err, "invalid untrusted workload runtime")
+	}
+	if err := validateOCIHooks(config.OCIHooks); err != nil {
+		return errors.Wrap(err, "invalid oci_hooks")
+	}
+	if err := validateRlimits(config.DefaultRlimits); err != nil {
+		return errors.Wrap(err, "invalid default_rlimits")
+	}
+	if err := validateSysctlPatterns(config.AllowedUnsafeSysctls); err != nil {
+		return errors.Wrap(err, "invalid allowed_unsafe_sysctls")
+	}
+	if err := validateCapabilities(config.DefaultCapabilities); err != nil {
+		return errors.Wrap(err, "invalid default_capabilities")
+	}
+	if err := validateUserNamespaceConfig(config.UserNamespace); err != nil {
+		return errors.Wrap(err, "invalid user_namespace")
+	}
+	if err := validateVolumeOwnershipPolicy(config.VolumeOwnershipPolicy); err != nil {
+		return errors.Wrap(err, "invalid volume_ownership_policy")
+	}
+	for _, dir := range config.VolumeOwnershipDirs {
+		if !filepath.IsAbs(dir) {
+			return errors.Errorf("invalid volume_ownership_dirs: directory %q is not absolute", dir)
+		}
+	}
+	if err := validateShortNameConfig(config.Registry.ShortNames); err != nil {
+		return errors.Wrap(err, "invalid short_names")
+	}
+	if config.SandboxCleanupRetries < 0 {
+		return errors.Errorf("invalid sandbox_cleanup_retries %d", config.SandboxCleanupRetries)
+	}
+	if config.MaxPrePullConcurrency <= 0 {
+		return errors.Errorf("invalid max_pre_pull_concurrency %d", config.MaxPrePullConcurrency)
+	}
+	if config.DefaultTmpfsSize < 0 {
+		return errors.Errorf("invalid default_tmpfs_size %d", config.DefaultTmpfsSize)
+	}
+	if err := validateProcPaths(append(append([]string{}, config.MaskedPaths.Paths...), config.MaskedPaths.RemovablePaths...)); err != nil {
+		return errors.Wrap(err, "invalid masked_paths")
+	}
+	if err := validateProcPaths(append(append([]string{}, config.ReadonlyPaths.Paths...), config.ReadonlyPaths.RemovablePaths...)); err != nil {
+		return errors.Wrap(err, "invalid readonly_paths")
+	}
+	for name, hooks := range config.AnnotatedOCIHooks {
+		if err := validateOCIHooks(hooks); err != nil {
+			return errors.Wrapf(err, "invalid annotated_oci_hooks %q", name)
+		}
+	}
+	if config.EnableCDI {
+		for _, dir := range config.CDISpecDirs {
+			if !filepath.IsAbs(dir) {
+				return errors.Errorf("invalid cdi_spec_dirs, directory %q is not absolute", dir)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRuntime validates the config of a runtime.
+func validateRuntime(r criconfig.Runtime) error {
+	if err := validateSandboxMode(r.SandboxMode); err != nil {
+		return errors.Wrap(err, "invalid sandbox_mode")
+	}
+	if err := validateOCIHooks(r.OCIHooks); err != nil {
+		return errors.Wrap(err, "invalid oci_hooks")
+	}
+	if err := validateAnnotationPatterns(r.PodAnnotations); err != nil {
+		return errors.Wrap(err, "invalid pod_annotations")
+	}
+	if err := validateAnnotationPatterns(r.ContainerAnnotations); err != nil {
+		return errors.Wrap(err, "invalid container_annotations")
+	}
+	if err := validateRuntimeOptions(r); err != nil {
+		return errors.Wrap(err, "invalid options")
+	}
+	if err := validatePodOverhead(r.PodOverhead); err != nil {
+		return errors.Wrap(err, "invalid pod_overhead")
+	}
+	if r.BaseRuntimeSpec != "" {
+		if _, err := loadBaseOCISpec(r.BaseRuntimeSpec); err != nil {
+			return errors.Wrap(err, "invalid base_runtime_spec")
+		}
+	}
+	return nil
+}
+
+// PluginConfigWarnings returns the problems of the cri plugin config which
+// don't fail the start of the plugin, but likely break pods, e.g. missing cni
+// binaries or inconsistent cgroup drivers.
+func PluginConfigWarnings(config criconfig.PluginConfig) []string {
+	var warnings []string
+	if !isDir(config.NetworkPluginBinDir) {
+		warnings = append(warnings, fmt.Sprintf("cni bin_dir %q is not a directory", config.NetworkPluginBinDir))
+	}
+	if _, err := os.Stat(config.NetworkPluginConfDir); err == nil && !isDir(config.NetworkPluginConfDir) {
+		warnings = append(warnings, fmt.Sprintf("cni conf_dir %q is not a directory", config.NetworkPluginConfDir))
+	}
+	if config.NetworkPluginConfTemplate != "" {
+		if _, err := os.Stat(config.NetworkPluginConfTemplate); err != nil {
+			warnings = append(warnings, fmt.Sprintf("cni conf_template %q is not readable: %v", config.NetworkPluginConfTemplate, err))
+		}
+	}
+
+	// Kubelet passes cgroup parents in the format of its own cgroup driver,
+	// which all runtimes must use.
+	runtimes := map[string]criconfig.Runtime{"default_runtime": config.ContainerdConfig.DefaultRuntime}
+	if config.ContainerdConfig.UntrustedWorkloadRuntime.Type != "" {
+		runtimes["untrusted_workload_runtime"] = config.ContainerdConfig.UntrustedWorkloadRuntime
+	}
+	var handlers []string
+	for handler := range config.ContainerdConfig.Runtimes {
+		handlers = append(handlers, handler)
+	}
+	sort.Strings(handlers)
+	for _, handler := range handlers {
+		r := config.ContainerdConfig.Runtimes[handler]
+		if r.Type == "" {
+			warnings = append(warnings, fmt.Sprintf("runtime %q has no runtime_type", handler))
+		}
+		runtimes[handler] = r
+	}
+	var systemd, cgroupfs []string
+	for name, r := range runtimes {
+		if r.SystemdCgroup == nil && config.SystemdCgroup || r.SystemdCgroup != nil && *r.SystemdCgroup {
+			systemd = append(systemd, name)
+		} else {
+			cgroupfs = append(cgroupfs, name)
+		}
+	}
+	sort.Strings(systemd)
+	sort.Strings(cgroupfs)
+	if len(systemd) > 0 && len(cgroupfs) > 0 {
+		warnings = append(warnings, fmt.Sprintf("runtimes %v use the systemd cgroup driver, but runtimes %v use cgroupfs", systemd, cgroupfs))
+	}
+	if len(systemd) > 0 && !isDir(systemdRunDir) {
+		warnings = append(warnings, "the systemd cgroup driver is used, but systemd is not running")
+	}
+	if len(config.GRPC.AllowedUIDs) > 0 || len(config.GRPC.AllowedGIDs) > 0 {
+		warnings = append(warnings, fmt.Sprintf("grpc allowed_uids and allowed_gids only guard %q, "+
+			"the cri services are still served on the containerd socket to any process with access to it", config.GRPC.Address))
+	}
+	return warnings
+}
+
+// isDir returns whether a path is an existing directory.
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestValidatePluginConfig(t *testing.T) {
+	for desc, test := range map[string]struct {
+		update    func(*criconfig.PluginConfig)
+		expectErr bool
+	}{
+		"should accept default config": {},
+		"should reject invalid stream idle timeout": {
+			update:    func(config *criconfig.PluginConfig) { config.StreamIdleTimeout = "4 hours" },
+			expectErr: true,
+		},
+		"should reject tls key pair without tls streaming": {
+			update: func(config *criconfig.PluginConfig) {
+				config.X509KeyPairStreaming.TLSCertFile = "/etc/cert.pem"
+				config.X509KeyPairStreaming.TLSKeyFile = "/etc/key.pem"
+			},
+			expectErr: true,
+		},
+		"should reject invalid mirror endpoint": {
+			update: func(config *criconfig.PluginConfig) {
+				config.Registry.Mirrors = map[string]criconfig.Mirror{
+					"docker.io": {Endpoints: []string{"registry-1.docker.io"}},
+				}
+			},
+			expectErr: true,
+		},
+		"should reject invalid runtime handler": {
+			update: func(config *criconfig.PluginConfig) {
+				config.ContainerdConfig.Runtimes = map[string]criconfig.Runtime{
+					"runsc": {Type: "io.containerd.runsc.v1", Options: map[string]string{"platform": "kvm"}},
+				}
+			},
+			expectErr: true,
+		},
+		"should reject missing base runtime spec": {
+			update: func(config *criconfig.PluginConfig) {
+				config.ContainerdConfig.DefaultRuntime.BaseRuntimeSpec = "/nonexistent/spec.json"
+			},
+			expectErr: true,
+		},
+		"should reject invalid image gc config": {
+			update: func(config *criconfig.PluginConfig) {
+				config.ImageGC.Enabled = true
+				config.ImageGC.LowThresholdPercent = 90
+			},
+			expectErr: true,
+		},
+		"should reject unknown audit backend": {
+			update: func(config *criconfig.PluginConfig) {
+				config.Audit.Enabled = true
+				config.Audit.Backend = "syslog"
+			},
+			expectErr: true,
+		},
+		"should reject relative cdi spec dir": {
+			update: func(config *criconfig.PluginConfig) {
+				config.EnableCDI = true
+				config.CDISpecDirs = []string{"etc/cdi"}
+			},
+			expectErr: true,
+		},
+		"should reject relative volume ownership dir": {
+			update: func(config *criconfig.PluginConfig) {
+				config.VolumeOwnershipDirs = []string{"data/volumes"}
+			},
+			expectErr: true,
+		},
+		"should reject relative checkpoint dir": {
+			update: func(config *criconfig.PluginConfig) {
+				config.CheckpointDir = "var/lib/checkpoints"
+			},
+			expectErr: true,
+		},
+		"should reject non-positive max pre-pull concurrency": {
+			update: func(config *criconfig.PluginConfig) {
+				config.MaxPrePullConcurrency = 0
+			},
+			expectErr: true,
+		},
+		"should reject invalid reloadable config file": {
+			update: func(config *criconfig.PluginConfig) {
+				f, err := ioutil.TempFile("", "reloadable")
+				require.NoError(t, err)
+				defer f.Close()
+				_, err = f.WriteString(`log_level = "verbose"`)
+				require.NoError(t, err)
+				config.ReloadableConfigPath = f.Name()
+			},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		config := criconfig.DefaultConfig()
+		if test.update != nil {
+			test.update(&config)
+		}
+		if config.ReloadableConfigPath != "" {
+			defer os.Remove(config.ReloadableConfigPath)
+		}
+		err := ValidatePluginConfig(config)
+		assert.Equal(t, test.expectErr, err != nil, err)
+	}
+}
+
+func TestValidatePluginConfigAllowsInvalidRegistryAtStart(t *testing.T) {
+	config := criconfig.DefaultConfig()
+	config.Registry.Mirrors = map[string]criconfig.Mirror{
+		"docker.io": {Endpoints: []string{"registry-1.docker.io"}},
+	}
+	config.Registry.Configs = map[string]criconfig.RegistryConfig{
+		"registry.example.com": {TLS: &criconfig.TLSConfig{CAFile: "/non-existent/ca.pem"}},
+	}
+	assert.NoError(t, validatePluginConfig(config), "plugin start should not fail on registry configs")
+	assert.Error(t, ValidatePluginConfig(config), "validate-config should report registry configs")
+}
+
+func TestPluginConfigWarnings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-warnings")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	systemd := true
+	for desc, test := range map[string]struct {
+		update   func(*criconfig.PluginConfig)
+		expected []string
+	}{
+		"should not warn about consistent config": {},
+		"should warn about missing cni bin dir": {
+			update: func(config *criconfig.PluginConfig) { config.NetworkPluginBinDir = "/nonexistent/bin" },
+			expected: []string{
+				`cni bin_dir "/nonexistent/bin" is not a directory`,
+			},
+		},
+		"should warn about runtime handler without type": {
+			update: func(config *criconfig.PluginConfig) {
+				config.ContainerdConfig.Runtimes = map[string]criconfig.Runtime{"runc": {}}
+			},
+			expected: []string{
+				`runtime "runc" has no runtime_type`,
+			},
+		},
+		"should warn about mixed cgroup drivers": {
+			update: func(config *criconfig.PluginConfig) {
+				config.ContainerdConfig.Runtimes = map[string]criconfig.Runtime{
+					"kata": {Type: "io.containerd.kata.v2", SystemdCgroup: &systemd},
+					"runc": {Type: "io.containerd.runc.v1"},
+				}
+			},
+			expected: []string{
+				"runtimes [kata] use the systemd cgroup driver, but runtimes [default_runtime runc] use cgroupfs",
+			},
+		},
+		"should warn that the peer allow-list doesn't guard the containerd socket": {
+			update: func(config *criconfig.PluginConfig) {
+				config.GRPC.Address = "/run/containerd/cri.sock"
+				config.GRPC.AllowedUIDs = []uint32{0}
+			},
+			expected: []string{
+				`grpc allowed_uids and allowed_gids only guard "/run/containerd/cri.sock", ` +
+					"the cri services are still served on the containerd socket to any process with access to it",
+			},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		config := criconfig.DefaultConfig()
+		config.NetworkPluginBinDir = dir
+		if test.update != nil {
+			test.update(&config)
+		}
+		warnings := PluginConfigWarnings(config)
+		if !isDir(systemdRunDir) {
+			// The systemd warning depends on the host.
+			var filtered []string
+			for _, w := range warnings {
+				if w != "the systemd cgroup driver is used, but systemd is not running" {
+					filtered = append(filtered, w)
+				}
+			}
+			warnings = filtered
+		}
+		assert.Equal(t, test.expected, warnings)
+	}
+}
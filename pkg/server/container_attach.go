@@ -21,7 +21,6 @@ import (
 
 	"github.com/containerd/containerd"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"k8s.io/client-go/tools/remotecommand"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
@@ -60,12 +59,6 @@ func (c *criService) attachContainer(ctx context.Context, id string, stdin io.Re
 	if err != nil {
 		return errors.Wrap(err, "failed to load task")
 	}
-	handleResizing(resize, func(size remotecommand.TerminalSize) {
-		if err := task.Resize(ctx, uint32(size.Width), uint32(size.Height)); err != nil {
-			logrus.WithError(err).Errorf("Failed to resize task %q console", id)
-		}
-	})
-
 	opts := cio.AttachOptions{
 		Stdin:     stdin,
 		Stdout:    stdout,
@@ -75,6 +68,12 @@ func (c *criService) attachContainer(ctx context.Context, id string, stdin io.Re
 		CloseStdin: func() error {
 			return task.CloseIO(ctx, containerd.WithStdinCloser)
 		},
+		// Resizing is handled by the container io, which tracks the
+		// terminal sizes of all attached clients.
+		Resize: resize,
+		ResizeTerminal: func(size remotecommand.TerminalSize) error {
+			return task.Resize(ctx, uint32(size.Width), uint32(size.Height))
+		},
 	}
 	// TODO(random-liu): Figure out whether we need to support historical output.
 	cntr.IO.Attach(opts)
@@ -0,0 +1,154 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images/oci"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+	"github.com/containerd/cri/pkg/log"
+)
+
+// CheckpointContainer checkpoints a running container into an OCI image archive.
+// The archive contains the process state dumped by the runtime (e.g. CRIU) and
+// the container read-write layer, and can be restored into a new container with
+// the `io.kubernetes.cri.restore-checkpoint` container annotation.
+func (c *criService) CheckpointContainer(ctx context.Context, r *api.CheckpointContainerRequest) (*api.CheckpointContainerResponse, error) {
+	path := r.GetLocation()
+	if !filepath.IsAbs(path) {
+		return nil, errors.Errorf("path %q is not an absolute path", path)
+	}
+	container, err := c.containerStore.Get(r.GetContainerId())
+	if err != nil {
+		return nil, errors.Wrapf(err, "an error occurred when try to find container %q", r.GetContainerId())
+	}
+	id := container.ID
+	state := container.Status.Get().State()
+	if state != runtime.ContainerState_CONTAINER_RUNNING {
+		return nil, errors.Errorf("container %q is in %s state", id, criContainerStateToString(state))
+	}
+	task, err := container.Container.Task(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get task for container %q", id)
+	}
+
+	var opts []containerd.CheckpointTaskOpts
+	if r.GetExit() {
+		opts = append(opts, containerd.WithExit)
+	}
+	checkpoint, err := task.Checkpoint(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to checkpoint container %q", id)
+	}
+	defer func() {
+		// The checkpoint image is only an intermediate step to produce the
+		// archive, the content will be garbage collected after it's removed.
+		deferCtx, deferCancel := c.deferContext()
+		defer deferCancel()
+		if err := c.client.ImageService().Delete(deferCtx, checkpoint.Name()); err != nil && !errdefs.IsNotFound(err) {
+			log.Container.WithError(err).Errorf("Failed to delete checkpoint image %q", checkpoint.Name())
+		}
+	}()
+
+	if err := c.exportCheckpoint(ctx, checkpoint, path); err != nil {
+		return nil, errors.Wrapf(err, "failed to export checkpoint of container %q to %q", id, path)
+	}
+	return &api.CheckpointContainerResponse{}, nil
+}
+
+// exportCheckpoint writes the checkpoint image into an OCI image archive.
+func (c *criService) exportCheckpoint(ctx context.Context, checkpoint containerd.Image, path string) (retErr error) {
+	r, err := c.client.Export(ctx, &oci.V1Exporter{}, checkpoint.Target())
+	if err != nil {
+		return errors.Wrap(err, "failed to export checkpoint image")
+	}
+	defer r.Close()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to create checkpoint archive")
+	}
+	defer func() {
+		f.Close()
+		if retErr != nil {
+			if err := os.Remove(path); err != nil {
+				log.Container.WithError(err).Errorf("Failed to remove checkpoint archive %q", path)
+			}
+		}
+	}()
+	if _, err := io.Copy(f, r); err != nil {
+		return errors.Wrap(err, "failed to write checkpoint archive")
+	}
+	return nil
+}
+
+// importCheckpoint imports a checkpoint archive created by CheckpointContainer,
+// and returns the checkpoint image. The image is named after the container
+// restored from it, so that it can be found again when the container starts.
+func (c *criService) importCheckpoint(ctx context.Context, id, path string) (containerd.Image, error) {
+	path, err := c.getCheckpointArchivePath(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open checkpoint archive")
+	}
+	defer f.Close()
+	imgs, err := c.client.Import(ctx, &oci.V1Importer{ImageName: checkpointImageName(id)}, f)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to import checkpoint archive")
+	}
+	if len(imgs) != 1 {
+		return nil, errors.Errorf("unexpected %d images in checkpoint archive", len(imgs))
+	}
+	return imgs[0], nil
+}
+
+// getCheckpointArchivePath returns the resolved path of a checkpoint archive a
+// container is restored from. The archive must be in the checkpoint dir, so
+// that a pod can't restore a container from an arbitrary host file.
+func (c *criService) getCheckpointArchivePath(path string) (string, error) {
+	if c.config.CheckpointDir == "" {
+		return "", errors.New("restoring from checkpoints is disabled, checkpoint_dir is not configured")
+	}
+	if !filepath.IsAbs(path) {
+		return "", errors.Errorf("path %q is not an absolute path", path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve path %q", path)
+	}
+	if !inDirs(resolved, []string{c.config.CheckpointDir}) {
+		return "", errors.Errorf("path %q is not in checkpoint_dir", path)
+	}
+	return resolved, nil
+}
+
+// checkpointImageName returns the name of the checkpoint image a container is
+// restored from.
+func checkpointImageName(id string) string {
+	return "checkpoint/" + id
+}
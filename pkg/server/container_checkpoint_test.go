@@ -0,0 +1,152 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+	containerstore "github.com/containerd/cri/pkg/store/container"
+)
+
+func TestCheckpointContainer(t *testing.T) {
+	testID := "test-id"
+	for desc, test := range map[string]struct {
+		location string
+		status   *containerstore.Status
+	}{
+		"should return error when location is not absolute": {
+			location: "checkpoint.tar",
+			status: &containerstore.Status{
+				CreatedAt: time.Now().UnixNano(),
+				StartedAt: time.Now().UnixNano(),
+			},
+		},
+		"should return error when container does not exist": {
+			location: "/checkpoints/checkpoint.tar",
+		},
+		"should return error when container is not running": {
+			location: "/checkpoints/checkpoint.tar",
+			status: &containerstore.Status{
+				CreatedAt: time.Now().UnixNano(),
+			},
+		},
+		"should return error when container is exited": {
+			location: "/checkpoints/checkpoint.tar",
+			status: &containerstore.Status{
+				CreatedAt:  time.Now().UnixNano(),
+				StartedAt:  time.Now().UnixNano(),
+				FinishedAt: time.Now().UnixNano(),
+			},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		if test.status != nil {
+			container, err := containerstore.NewContainer(
+				containerstore.Metadata{ID: testID},
+				containerstore.WithFakeStatus(*test.status),
+			)
+			require.NoError(t, err)
+			require.NoError(t, c.containerStore.Add(container))
+		}
+		_, err := c.CheckpointContainer(context.Background(), &api.CheckpointContainerRequest{
+			ContainerId: testID,
+			Location:    test.location,
+		})
+		assert.Error(t, err)
+	}
+}
+
+func TestGetCheckpointArchivePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	checkpointDir := filepath.Join(dir, "checkpoints")
+	require.NoError(t, os.Mkdir(checkpointDir, 0700))
+	archive := filepath.Join(checkpointDir, "checkpoint.tar")
+	require.NoError(t, ioutil.WriteFile(archive, nil, 0600))
+	outside := filepath.Join(dir, "outside.tar")
+	require.NoError(t, ioutil.WriteFile(outside, nil, 0600))
+	link := filepath.Join(checkpointDir, "link.tar")
+	require.NoError(t, os.Symlink(outside, link))
+
+	for desc, test := range map[string]struct {
+		checkpointDir string
+		path          string
+		expected      string
+		expectErr     bool
+	}{
+		"should return the path of an archive in the checkpoint dir": {
+			checkpointDir: checkpointDir,
+			path:          archive,
+			expected:      archive,
+		},
+		"should reject restore if the checkpoint dir is not configured": {
+			path:      archive,
+			expectErr: true,
+		},
+		"should reject relative path": {
+			checkpointDir: checkpointDir,
+			path:          "checkpoint.tar",
+			expectErr:     true,
+		},
+		"should reject path outside the checkpoint dir": {
+			checkpointDir: checkpointDir,
+			path:          outside,
+			expectErr:     true,
+		},
+		"should reject path escaping the checkpoint dir with dot dot": {
+			checkpointDir: checkpointDir,
+			path:          filepath.Join(checkpointDir, "..", "outside.tar"),
+			expectErr:     true,
+		},
+		"should reject symlink to a file outside the checkpoint dir": {
+			checkpointDir: checkpointDir,
+			path:          link,
+			expectErr:     true,
+		},
+		"should reject nonexistent archive": {
+			checkpointDir: checkpointDir,
+			path:          filepath.Join(checkpointDir, "nonexistent.tar"),
+			expectErr:     true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.CheckpointDir = test.checkpointDir
+		path, err := c.getCheckpointArchivePath(test.path)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, path)
+	}
+}
+
+func TestCheckpointImageName(t *testing.T) {
+	assert.Equal(t, "checkpoint/test-id", checkpointImageName("test-id"))
+}
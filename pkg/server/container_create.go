@@ -27,29 +27,36 @@ import (
 	"github.com/containerd/containerd/containers"
 	"github.com/containerd/containerd/contrib/apparmor"
 	"github.com/containerd/containerd/contrib/seccomp"
+	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/mount"
 	"github.com/containerd/containerd/oci"
 	"github.com/containerd/containerd/runtime/linux/runctypes"
 	"github.com/containerd/typeurl"
 	"github.com/davecgh/go-spew/spew"
 	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/devices"
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/generate"
 	"github.com/opencontainers/runtime-tools/validate"
+	"github.com/opencontainers/selinux/go-selinux"
 	"github.com/opencontainers/selinux/go-selinux/label"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"github.com/syndtr/gocapability/capability"
 	"golang.org/x/net/context"
 	"golang.org/x/sys/unix"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
 	"github.com/containerd/cri/pkg/annotations"
+	api "github.com/containerd/cri/pkg/api/v1"
+	criconfig "github.com/containerd/cri/pkg/config"
+	"github.com/containerd/cri/pkg/constants"
 	customopts "github.com/containerd/cri/pkg/containerd/opts"
 	ctrdutil "github.com/containerd/cri/pkg/containerd/util"
+	"github.com/containerd/cri/pkg/log"
 	cio "github.com/containerd/cri/pkg/server/io"
 	containerstore "github.com/containerd/cri/pkg/store/container"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
 	"github.com/containerd/cri/pkg/util"
 )
 
@@ -82,18 +89,27 @@ func (c *criService) CreateContainer(ctx context.Context, r *runtime.CreateConta
 		return nil, errors.Wrapf(err, "failed to find sandbox id %q", r.GetPodSandboxId())
 	}
 	sandboxID := sandbox.ID
-	s, err := sandbox.Container.Task(ctx, nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get sandbox container task")
+	// A sandbox without a pause container has no task, its containers join
+	// the pinned sandbox namespaces instead.
+	var sandboxPid uint32
+	if sandbox.NoPauseContainer {
+		if sandbox.Status.Get().State != sandboxstore.StateReady {
+			return nil, errors.Errorf("sandbox %q is not ready", sandboxID)
+		}
+	} else {
+		s, err := sandbox.Container.Task(ctx, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get sandbox container task")
+		}
+		sandboxPid = s.Pid()
 	}
-	sandboxPid := s.Pid()
 
 	// Generate unique id and name for the container and reserve the name.
 	// Reserve the container name to avoid concurrent `CreateContainer` request creating
 	// the same container.
 	id := util.GenerateID()
 	name := makeContainerName(config.GetMetadata(), sandboxConfig.GetMetadata())
-	logrus.Debugf("Generated id %q for container %q", id, name)
+	log.Container.Debugf("Generated id %q for container %q", id, name)
 	if err = c.containerNameIndex.Reserve(name, id); err != nil {
 		return nil, errors.Wrapf(err, "failed to reserve container name %q", name)
 	}
@@ -104,18 +120,30 @@ func (c *criService) CreateContainer(ctx context.Context, r *runtime.CreateConta
 		}
 	}()
 
+	imageVolumes, err := parseImageVolumes(config.GetAnnotations()[annotations.ImageVolumes])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %q annotation", annotations.ImageVolumes)
+	}
+
 	// Create initial internal container metadata.
+	logFormat, err := c.getContainerLogFormat(sandbox.Config)
+	if err != nil {
+		return nil, err
+	}
 	meta := containerstore.Metadata{
 		ID:        id,
 		Name:      name,
 		SandboxID: sandboxID,
 		Config:    config,
+		LogFormat: logFormat,
 	}
 
 	// Prepare container image snapshot. For container, the image should have
 	// been pulled before creating the container, so do not ensure the image.
+	// A short name is resolved under the policy of the pod namespace, as it
+	// is pulled.
 	imageRef := config.GetImage().GetImage()
-	image, err := c.localResolve(ctx, imageRef)
+	image, err := c.localResolveInNamespace(ctx, imageRef, sandbox.Config.GetMetadata().GetNamespace())
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to resolve image %q", imageRef)
 	}
@@ -123,16 +151,18 @@ func (c *criService) CreateContainer(ctx context.Context, r *runtime.CreateConta
 		return nil, errors.Errorf("image %q not found", imageRef)
 	}
 
-	// Run container using the same runtime with sandbox.
-	sandboxInfo, err := sandbox.Container.Info(ctx)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get sandbox %q info", sandboxID)
-	}
-	ociRuntime, err := getRuntimeConfigFromContainerInfo(sandboxInfo)
+	// Run container using the same runtime with sandbox. The runtime is
+	// checkpointed in sandbox metadata, so it survives restart and config
+	// changes.
+	ociRuntime, err := c.sandboxRuntime(sandbox)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get OCI runtime")
 	}
-	logrus.Debugf("Use OCI %+v for container %q", ociRuntime, id)
+	log.Container.Debugf("Use OCI %+v for container %q", ociRuntime, id)
+	meta.Snapshotter = c.runtimeSnapshotter(ociRuntime)
+	if err := c.ensureImageUnpacked(ctx, image.Image, meta.Snapshotter); err != nil {
+		return nil, errors.Wrapf(err, "failed to unpack image %q", imageRef)
+	}
 
 	// Create container root directory.
 	containerRootDir := c.getContainerRootDir(id)
@@ -144,7 +174,7 @@ func (c *criService) CreateContainer(ctx context.Context, r *runtime.CreateConta
 		if retErr != nil {
 			// Cleanup the container root directory.
 			if err = c.os.RemoveAll(containerRootDir); err != nil {
-				logrus.WithError(err).Errorf("Failed to remove container root directory %q",
+				log.Container.WithError(err).Errorf("Failed to remove container root directory %q",
 					containerRootDir)
 			}
 		}
@@ -158,7 +188,7 @@ func (c *criService) CreateContainer(ctx context.Context, r *runtime.CreateConta
 		if retErr != nil {
 			// Cleanup the volatile container root directory.
 			if err = c.os.RemoveAll(volatileContainerRootDir); err != nil {
-				logrus.WithError(err).Errorf("Failed to remove volatile container root directory %q",
+				log.Container.WithError(err).Errorf("Failed to remove volatile container root directory %q",
 					volatileContainerRootDir)
 			}
 		}
@@ -170,22 +200,87 @@ func (c *criService) CreateContainer(ctx context.Context, r *runtime.CreateConta
 	// Generate container runtime spec.
 	mounts := c.generateContainerMounts(sandboxID, config)
 
-	spec, err := c.generateContainerSpec(id, sandboxID, sandboxPid, config, sandboxConfig, &image.ImageSpec.Config, append(mounts, volumeMounts...))
+	spec, err := c.generateContainerSpec(id, sandboxID, sandboxPid, config, sandboxConfig, &image.ImageSpec.Config, append(mounts, volumeMounts...), ociRuntime)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to generate container %q spec", id)
 	}
+	var imageVolumeLabels map[string]string
+	if len(imageVolumes) > 0 {
+		// The lease keeps the image volume snapshots until they are
+		// referenced by the created container.
+		var done func(context.Context) error
+		ctx, done, err = c.client.WithLease(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create lease")
+		}
+		defer func() {
+			deferCtx, deferCancel := c.deferContext()
+			defer deferCancel()
+			if err := done(deferCtx); err != nil {
+				log.Container.WithError(err).Errorf("Failed to release lease of container %q", id)
+			}
+		}()
+		var imageVolumeMounts []runtimespec.Mount
+		imageCtx, imageCancel := withPhaseTimeout(ctx, c.timeouts.imageResolve)
+		imageVolumeMounts, imageVolumeLabels, err = c.prepareImageVolumes(imageCtx, id, meta.Snapshotter, imageVolumes, sandbox.Config)
+		imageCancel()
+		if err != nil {
+			return nil, phaseError(imageCtx, err, "failed to prepare image volumes")
+		}
+		g := newSpecGenerator(spec)
+		for _, m := range imageVolumeMounts {
+			g.AddMount(m)
+		}
+	}
+	if sandbox.NoPauseContainer {
+		g := newSpecGenerator(spec)
+		c.setOCINoPauseNamespaces(&g, sandbox, config.GetLinux().GetSecurityContext().GetNamespaceOptions())
+	}
+	if targetID, ok := config.GetAnnotations()[annotations.NamespaceTarget]; ok {
+		targetPid, err := c.getTargetContainerPid(sandboxID, targetID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %q annotation", annotations.NamespaceTarget)
+		}
+		g := newSpecGenerator(spec)
+		g.AddOrReplaceLinuxNamespace(string(runtimespec.PIDNamespace), getPIDNamespace(targetPid)) // nolint: errcheck
+	}
+	spec, err = c.specHooks.adjustSpec(ctx, specHookRequest{
+		Event:       specHookCreateContainer,
+		ID:          id,
+		SandboxID:   sandboxID,
+		Labels:      config.GetLabels(),
+		Annotations: config.GetAnnotations(),
+		Spec:        spec,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to adjust container %q spec", id)
+	}
+
+	snapshotOpt := customopts.WithNewSnapshot(id, image.Image)
+	if sandbox.UserNamespace != nil {
+		uid, gid, err := getRemappedRoot(sandbox.UserNamespace)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get remapped root")
+		}
+		// Volumes not in the image rootfs are owned by container root. Others
+		// are chowned when their contents are copied from the remapped rootfs.
+		if err := chownVolumes(volumeMounts, uid, gid); err != nil {
+			return nil, err
+		}
+		snapshotOpt = customopts.WithRemappedSnapshot(id, image.Image, uid, gid)
+	}
 
-	logrus.Debugf("Container %q spec: %#+v", id, spew.NewFormatter(spec))
+	log.Container.Debugf("Container %q spec: %#+v", id, spew.NewFormatter(spec))
 
 	// Set snapshotter before any other options.
 	opts := []containerd.NewContainerOpts{
-		containerd.WithSnapshotter(c.config.ContainerdConfig.Snapshotter),
+		containerd.WithSnapshotter(meta.Snapshotter),
 		// Prepare container rootfs. This is always writeable even if
 		// the container wants a readonly rootfs since we want to give
 		// the runtime (runc) a chance to modify (e.g. to create mount
 		// points corresponding to spec.Mounts) before making the
 		// rootfs readonly (requested by spec.Root.Readonly).
-		customopts.WithNewSnapshot(id, image.Image),
+		snapshotOpt,
 	}
 
 	if len(volumeMounts) > 0 {
@@ -195,6 +290,26 @@ func (c *criService) CreateContainer(ctx context.Context, r *runtime.CreateConta
 		}
 		opts = append(opts, customopts.WithVolumes(mountMap))
 	}
+
+	// Restore the container rootfs from checkpoint if requested. The process
+	// state is restored when the container is started.
+	if path, ok := config.GetAnnotations()[annotations.RestoreCheckpoint]; ok {
+		checkpoint, err := c.importCheckpoint(ctx, id, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to import checkpoint %q", path)
+		}
+		defer func() {
+			if retErr != nil {
+				deferCtx, deferCancel := c.deferContext()
+				defer deferCancel()
+				if err := c.client.ImageService().Delete(deferCtx, checkpoint.Name()); err != nil && !errdefs.IsNotFound(err) {
+					log.Container.WithError(err).Errorf("Failed to delete checkpoint image %q", checkpoint.Name())
+				}
+			}
+		}()
+		opts = append(opts, customopts.WithCheckpointRWLayer(checkpoint))
+		meta.Checkpoint = checkpoint.Name()
+	}
 	meta.ImageRef = image.ID
 
 	// Get container log path.
@@ -210,7 +325,7 @@ func (c *criService) CreateContainer(ctx context.Context, r *runtime.CreateConta
 	defer func() {
 		if retErr != nil {
 			if err := containerIO.Close(); err != nil {
-				logrus.WithError(err).Errorf("Failed to close container io %q", id)
+				log.Container.WithError(err).Errorf("Failed to close container io %q", id)
 			}
 		}
 	}()
@@ -239,11 +354,16 @@ func (c *criService) CreateContainer(ctx context.Context, r *runtime.CreateConta
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to generate apparmor spec opts")
 	}
+	if apparmorProf := securityContext.GetApparmorProfile(); c.apparmorEnabled && strings.HasPrefix(apparmorProf, profileNamePrefix) {
+		if err := checkAppArmorProfileLoaded(strings.TrimPrefix(apparmorProf, profileNamePrefix)); err != nil {
+			return nil, errors.Wrap(err, "failed to validate apparmor profile")
+		}
+	}
 	if apparmorSpecOpts != nil {
 		specOpts = append(specOpts, apparmorSpecOpts)
 	}
 
-	seccompSpecOpts, err := generateSeccompSpecOpts(
+	seccompSpecOpts, err := c.generateSeccompSpecOpts(
 		securityContext.GetSeccompProfilePath(),
 		securityContext.GetPrivileged(),
 		c.seccompEnabled)
@@ -254,6 +374,9 @@ func (c *criService) CreateContainer(ctx context.Context, r *runtime.CreateConta
 		specOpts = append(specOpts, seccompSpecOpts)
 	}
 	containerLabels := buildLabels(config.Labels, containerKindContainer)
+	for k, v := range imageVolumeLabels {
+		containerLabels[k] = v
+	}
 
 	opts = append(opts,
 		containerd.WithSpec(spec, specOpts...),
@@ -262,19 +385,29 @@ func (c *criService) CreateContainer(ctx context.Context, r *runtime.CreateConta
 			&runctypes.RuncOptions{
 				Runtime:       ociRuntime.Engine,
 				RuntimeRoot:   ociRuntime.Root,
-				SystemdCgroup: c.config.SystemdCgroup}), // TODO (mikebrow): add CriuPath when we add support for pause
+				SystemdCgroup: c.runtimeSystemdCgroup(ociRuntime)}), // TODO (mikebrow): add CriuPath when we add support for pause
 		containerd.WithContainerLabels(containerLabels),
 		containerd.WithContainerExtension(containerMetadataExtension, &meta))
+	// Change the volume ownership after the request is validated, so that a
+	// rejected request doesn't change the host.
+	if err := c.setVolumeOwnership(config, sandboxConfig, sandbox.UserNamespace); err != nil {
+		return nil, errors.Wrap(err, "failed to set volume ownership")
+	}
 	var cntr containerd.Container
-	if cntr, err = c.client.NewContainer(ctx, id, opts...); err != nil {
-		return nil, errors.Wrap(err, "failed to create containerd container")
+	snapshotCtx, snapshotCancel := withPhaseTimeout(ctx, c.timeouts.snapshotPrepare)
+	_, span := startSpan(snapshotCtx, "snapshot prepare")
+	cntr, err = c.client.NewContainer(snapshotCtx, id, opts...)
+	span.end(err)
+	snapshotCancel()
+	if err != nil {
+		return nil, phaseError(snapshotCtx, err, "failed to create containerd container")
 	}
 	defer func() {
 		if retErr != nil {
-			deferCtx, deferCancel := ctrdutil.DeferContext()
+			deferCtx, deferCancel := c.deferContext()
 			defer deferCancel()
 			if err := cntr.Delete(deferCtx, containerd.WithSnapshotCleanup); err != nil {
-				logrus.WithError(err).Errorf("Failed to delete containerd container %q", id)
+				log.Container.WithError(err).Errorf("Failed to delete containerd container %q", id)
 			}
 		}
 	}()
@@ -292,7 +425,7 @@ func (c *criService) CreateContainer(ctx context.Context, r *runtime.CreateConta
 		if retErr != nil {
 			// Cleanup container checkpoint on error.
 			if err := container.Delete(); err != nil {
-				logrus.WithError(err).Errorf("Failed to cleanup container checkpoint for %q", id)
+				log.Container.WithError(err).Errorf("Failed to cleanup container checkpoint for %q", id)
 			}
 		}
 	}()
@@ -302,13 +435,19 @@ func (c *criService) CreateContainer(ctx context.Context, r *runtime.CreateConta
 		return nil, errors.Wrapf(err, "failed to add container %q into store", id)
 	}
 
+	if err := c.updatePodCgroup(sandboxID); err != nil {
+		log.Container.WithError(err).Errorf("Failed to update pod cgroup of sandbox %q", sandboxID)
+	}
+
+	c.containerEvents.publish(id, sandboxID, api.ContainerEventType_CONTAINER_CREATED_EVENT)
 	return &runtime.CreateContainerResponse{ContainerId: id}, nil
 }
 
 func (c *criService) generateContainerSpec(id string, sandboxID string, sandboxPid uint32, config *runtime.ContainerConfig,
-	sandboxConfig *runtime.PodSandboxConfig, imageConfig *imagespec.ImageConfig, extraMounts []*runtime.Mount) (*runtimespec.Spec, error) {
-	// Creates a spec Generator with the default spec.
-	spec, err := defaultRuntimeSpec(id)
+	sandboxConfig *runtime.PodSandboxConfig, imageConfig *imagespec.ImageConfig, extraMounts []*runtime.Mount,
+	ociRuntime criconfig.Runtime) (*runtimespec.Spec, error) {
+	// Creates a spec Generator with the base spec of the runtime.
+	spec, err := c.runtimeSpec(id, ociRuntime)
 	if err != nil {
 		return nil, err
 	}
@@ -344,14 +483,22 @@ func (c *criService) generateContainerSpec(id string, sandboxID string, sandboxP
 
 	securityContext := config.GetLinux().GetSecurityContext()
 	selinuxOpt := securityContext.GetSelinuxOptions()
-	processLabel, mountLabel, err := initSelinuxOpts(selinuxOpt)
+	processLabel, mountLabel, err := c.initContainerSelinuxLabels(sandboxID, selinuxOpt)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to init selinux options %+v", securityContext.GetSelinuxOptions())
 	}
 
 	// Add extra mounts first so that CRI specified mounts can override.
 	mounts := append(extraMounts, config.GetMounts()...)
-	if err := c.addOCIBindMounts(&g, mounts, mountLabel); err != nil {
+	tmpfsSizes, err := parseTmpfsSizes(config.GetAnnotations()[annotations.TmpfsSizes])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %q annotation", annotations.TmpfsSizes)
+	}
+	privateLabelPaths, err := parseSelinuxPrivateVolumes(config.GetAnnotations()[annotations.SelinuxPrivateVolumes])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %q annotation", annotations.SelinuxPrivateVolumes)
+	}
+	if err := c.addOCIBindMounts(&g, mounts, mountLabel, tmpfsSizes, privateLabelPaths); err != nil {
 		return nil, errors.Wrapf(err, "failed to set OCI bind mounts %+v", mounts)
 	}
 
@@ -359,18 +506,26 @@ func (c *criService) generateContainerSpec(id string, sandboxID string, sandboxP
 		if !sandboxConfig.GetLinux().GetSecurityContext().GetPrivileged() {
 			return nil, errors.New("no privileged container allowed in sandbox")
 		}
+		if userNamespaceEnabled(sandboxConfig) {
+			return nil, errors.New("privileged container is not supported in user namespace")
+		}
 		if err := setOCIPrivileged(&g, config); err != nil {
 			return nil, err
 		}
 	} else { // not privileged
-		if err := c.addOCIDevices(&g, config.GetDevices()); err != nil {
+		if err := c.addOCIDevices(&g, config.GetDevices(), securityContext); err != nil {
 			return nil, errors.Wrapf(err, "failed to set devices mapping %+v", config.GetDevices())
 		}
 
+		c.setOCIDefaultCapabilities(&g)
 		if err := setOCICapabilities(&g, securityContext.GetCapabilities()); err != nil {
 			return nil, errors.Wrapf(err, "failed to set capabilities %+v",
 				securityContext.GetCapabilities())
 		}
+
+		if err := c.setOCIProcPaths(&g, config.GetAnnotations()); err != nil {
+			return nil, err
+		}
 	}
 	// Clear all ambient capabilities. The implication of non-root + caps
 	// is not clearly defined in Kubernetes.
@@ -389,27 +544,88 @@ func (c *criService) generateContainerSpec(id string, sandboxID string, sandboxP
 	g.SetRootReadonly(securityContext.GetReadonlyRootfs())
 
 	setOCILinuxResource(&g, config.GetLinux().GetResources())
+	if err := setOCIHugepageAndSwapLimits(&g, config.GetAnnotations(),
+		config.GetLinux().GetResources().GetMemoryLimitInBytes()); err != nil {
+		return nil, err
+	}
+	// Validate the unified resources early, they are set on start.
+	if _, err := getUnifiedResources(config.GetAnnotations(), c.unifiedCgroup); err != nil {
+		return nil, errors.Wrapf(err, "invalid %q annotations", annotations.UnifiedResourcePrefix)
+	}
 
 	if sandboxConfig.GetLinux().GetCgroupParent() != "" {
 		cgroupsPath := getCgroupsPath(sandboxConfig.GetLinux().GetCgroupParent(), id,
-			c.config.SystemdCgroup)
+			c.runtimeSystemdCgroup(ociRuntime))
 		g.SetLinuxCgroupsPath(cgroupsPath)
 	}
 
 	// Set namespaces, share namespace with sandbox container.
-	setOCINamespaces(&g, securityContext.GetNamespaceOptions(), sandboxPid)
+	setOCINamespaces(&g, c.containerNamespaceOptions(securityContext.GetNamespaceOptions(), sandboxConfig), sandboxPid)
+	userns, err := c.getSandboxUserNamespace(sandboxID, sandboxConfig)
+	if err != nil {
+		return nil, err
+	}
+	if userns != nil {
+		setOCIUserNamespace(&g, getUserNamespace(sandboxPid), userns)
+	}
 
 	supplementalGroups := securityContext.GetSupplementalGroups()
 	for _, group := range supplementalGroups {
 		g.AddProcessAdditionalGid(uint32(group))
 	}
 
+	if err := c.setOCIHooks(&g, ociRuntime, sandboxConfig); err != nil {
+		return nil, err
+	}
+
+	if err := c.injectCDIDevices(&g, config.GetAnnotations()); err != nil {
+		return nil, err
+	}
+
+	if err := c.setOCIRlimits(&g, sandboxConfig); err != nil {
+		return nil, err
+	}
+
+	addSpecAnnotations(&g, sandboxConfig.GetAnnotations(), ociRuntime.PodAnnotations)
+	addSpecAnnotations(&g, config.GetAnnotations(), ociRuntime.ContainerAnnotations)
+	setOCIRuntimeOptions(&g, ociRuntime)
 	g.AddAnnotation(annotations.ContainerType, annotations.ContainerTypeContainer)
 	g.AddAnnotation(annotations.SandboxID, sandboxID)
 
 	return g.Config, nil
 }
 
+// initContainerSelinuxLabels returns the SELinux process and mount labels of a
+// container. A container without SELinux options uses the level of the sandbox,
+// so that all containers in a pod can access the volumes of the pod.
+func (c *criService) initContainerSelinuxLabels(sandboxID string, selinuxOpt *runtime.SELinuxOption) (string, string, error) {
+	processLabel, mountLabel, err := initSelinuxOpts(selinuxOpt)
+	if err != nil || processLabel != "" {
+		return processLabel, mountLabel, err
+	}
+	sandbox, err := c.sandboxStore.Get(sandboxID)
+	if err != nil || sandbox.ProcessLabel == "" {
+		return "", "", nil
+	}
+	return initLabels(selinux.DupSecOpt(sandbox.ProcessLabel))
+}
+
+// parseSelinuxPrivateVolumes parses the comma separated container paths of the
+// volumes relabeled with the private label of the container.
+func parseSelinuxPrivateVolumes(s string) (map[string]bool, error) {
+	paths := make(map[string]bool)
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			return nil, errors.Errorf("path %q is not absolute", p)
+		}
+		paths[filepath.Clean(p)] = true
+	}
+	return paths, nil
+}
+
 // generateVolumeMounts sets up image volumes for container. Rely on the removal of container
 // root directory to do cleanup. Note that image volume will be skipped, if there is criMounts
 // specified with the same destination.
@@ -433,7 +649,7 @@ func (c *criService) generateVolumeMounts(containerRootDir string, criMounts []*
 			ContainerPath: dst,
 			HostPath:      src,
 			// Use default mount propagation.
-			// TODO(random-liu): What about selinux relabel?
+			SelinuxRelabel: true,
 		})
 	}
 	return mounts
@@ -531,38 +747,103 @@ func clearReadOnly(m *runtimespec.Mount) {
 	m.Options = append(opt, "rw")
 }
 
-// addDevices set device mapping without privilege.
-func (c *criService) addOCIDevices(g *generate.Generator, devs []*runtime.Device) error {
+// addDevices set device mapping without privilege. A device cgroup rule allowing
+// the requested permissions is added for each device on top of the default rule
+// denying all devices. If the host path is a directory, all devices under it are
+// added.
+func (c *criService) addOCIDevices(g *generate.Generator, devs []*runtime.Device, securityContext *runtime.LinuxContainerSecurityContext) error {
 	spec := g.Config
 	for _, device := range devs {
 		path, err := c.os.ResolveSymbolicLink(device.HostPath)
 		if err != nil {
 			return err
 		}
-		dev, err := devices.DeviceFromPath(path, device.Permissions)
+		hostDevices, err := getDevicesFromPath(path, device.Permissions)
 		if err != nil {
-			return err
+			return errors.Wrapf(err, "failed to get devices from %q", device.HostPath)
 		}
-		rd := runtimespec.LinuxDevice{
-			Path:  device.ContainerPath,
-			Type:  string(dev.Type),
-			Major: dev.Major,
-			Minor: dev.Minor,
-			UID:   &dev.Uid,
-			GID:   &dev.Gid,
+		for _, dev := range hostDevices {
+			containerPath := device.ContainerPath
+			if dev.Path != path {
+				// Keep the relative path of devices under a directory.
+				containerPath = filepath.Join(containerPath, strings.TrimPrefix(dev.Path, path))
+			}
+			uid, gid := dev.Uid, dev.Gid
+			if c.config.DeviceOwnershipFromSecurityContext {
+				uid, gid = getDeviceOwnership(securityContext)
+			}
+			rd := runtimespec.LinuxDevice{
+				Path:  containerPath,
+				Type:  string(dev.Type),
+				Major: dev.Major,
+				Minor: dev.Minor,
+				UID:   &uid,
+				GID:   &gid,
+			}
+			g.AddDevice(rd)
+			spec.Linux.Resources.Devices = append(spec.Linux.Resources.Devices, runtimespec.LinuxDeviceCgroup{
+				Allow:  true,
+				Type:   string(dev.Type),
+				Major:  &dev.Major,
+				Minor:  &dev.Minor,
+				Access: dev.Permissions,
+			})
 		}
-		g.AddDevice(rd)
-		spec.Linux.Resources.Devices = append(spec.Linux.Resources.Devices, runtimespec.LinuxDeviceCgroup{
-			Allow:  true,
-			Type:   string(dev.Type),
-			Major:  &dev.Major,
-			Minor:  &dev.Minor,
-			Access: dev.Permissions,
-		})
 	}
 	return nil
 }
 
+// getDevicesFromPath returns the device at path, or all devices under path if
+// it is a directory.
+func getDevicesFromPath(path, permissions string) ([]*configs.Device, error) {
+	dev, err := devices.DeviceFromPath(path, permissions)
+	if err == nil {
+		return []*configs.Device{dev}, nil
+	}
+	if err != devices.ErrNotADevice {
+		return nil, err
+	}
+	var devs []*configs.Device
+	if err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		dev, err := devices.DeviceFromPath(p, permissions)
+		if err != nil {
+			// Skip symbolic links and other files which are not devices.
+			if err == devices.ErrNotADevice {
+				return nil
+			}
+			return err
+		}
+		devs = append(devs, dev)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(devs) == 0 {
+		return nil, errors.Errorf("no device found at %q", path)
+	}
+	return devs, nil
+}
+
+// getDeviceOwnership returns the user and group the container runs as, which
+// own the devices of the container. Root is used if the user or group is not
+// set in the security context.
+func getDeviceOwnership(securityContext *runtime.LinuxContainerSecurityContext) (uint32, uint32) {
+	var uid, gid uint32
+	if runAsUser := securityContext.GetRunAsUser(); runAsUser != nil {
+		uid = uint32(runAsUser.GetValue())
+	}
+	if runAsGroup := securityContext.GetRunAsGroup(); runAsGroup != nil {
+		gid = uint32(runAsGroup.GetValue())
+	}
+	return uid, gid
+}
+
 // addDevices set device mapping with privilege.
 func setOCIDevicesPrivileged(g *generate.Generator) error {
 	spec := g.Config
@@ -595,7 +876,9 @@ func setOCIDevicesPrivileged(g *generate.Generator) error {
 }
 
 // addOCIBindMounts adds bind mounts.
-func (c *criService) addOCIBindMounts(g *generate.Generator, mounts []*runtime.Mount, mountLabel string) error {
+// Mounts without a host path are tmpfs mounts, whose sizes are keyed by the
+// container path, or the default tmpfs size.
+func (c *criService) addOCIBindMounts(g *generate.Generator, mounts []*runtime.Mount, mountLabel string, tmpfsSizes map[string]int64, privateLabelPaths map[string]bool) error {
 	// Mount cgroup into the container as readonly, which inherits docker's behavior.
 	g.AddMount(runtimespec.Mount{
 		Source:      "cgroup",
@@ -606,6 +889,14 @@ func (c *criService) addOCIBindMounts(g *generate.Generator, mounts []*runtime.M
 	for _, mount := range mounts {
 		dst := mount.GetContainerPath()
 		src := mount.GetHostPath()
+		if src == "" {
+			size, ok := tmpfsSizes[filepath.Clean(dst)]
+			if !ok {
+				size = c.config.DefaultTmpfsSize
+			}
+			g.AddMount(tmpfsMount(mount, size))
+			continue
+		}
 		// Create the host path if it doesn't exist.
 		// TODO(random-liu): Add CRI validation test for this case.
 		if _, err := c.os.Stat(src); err != nil {
@@ -645,7 +936,7 @@ func (c *criService) addOCIBindMounts(g *generate.Generator, mounts []*runtime.M
 				g.SetLinuxRootPropagation("rslave") // nolint: errcheck
 			}
 		default:
-			logrus.Warnf("Unknown propagation mode for hostPath %q", mount.HostPath)
+			log.Container.Warnf("Unknown propagation mode for hostPath %q", mount.HostPath)
 			options = append(options, "rprivate")
 		}
 
@@ -657,8 +948,12 @@ func (c *criService) addOCIBindMounts(g *generate.Generator, mounts []*runtime.M
 			options = append(options, "rw")
 		}
 
+		// Relabel the volume with the shared label (":z") by default, so that it can
+		// be shared by all containers in the pod, or with the private label (":Z")
+		// of the container if requested.
 		if mount.GetSelinuxRelabel() {
-			if err := label.Relabel(src, mountLabel, true); err != nil && err != unix.ENOTSUP {
+			shared := !privateLabelPaths[filepath.Clean(dst)]
+			if err := label.Relabel(src, mountLabel, shared); err != nil && err != unix.ENOTSUP {
 				return errors.Wrapf(err, "relabel %q with %q failed", src, mountLabel)
 			}
 		}
@@ -748,11 +1043,69 @@ func dropProcessRootCapability(g *generate.Generator, c string) error {
 	return nil
 }
 
+// normalizeCapability returns the OCI name of a capability. Capabilities in
+// CRI don't have the `CAP_` prefix, but it is accepted.
+func normalizeCapability(c string) string {
+	c = strings.ToUpper(c)
+	if !strings.HasPrefix(c, "CAP_") {
+		c = "CAP_" + c
+	}
+	return c
+}
+
+// validateCapabilities validates a list of capabilities, "ALL" is allowed.
+func validateCapabilities(caps []string) error {
+	for _, c := range caps {
+		if strings.ToUpper(c) == "ALL" {
+			continue
+		}
+		if err := validate.CapValid(normalizeCapability(c), false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandCapabilities returns the OCI names of a list of capabilities, with
+// "ALL" expanded to all available capabilities.
+func expandCapabilities(caps []string) []string {
+	var res []string
+	for _, c := range caps {
+		if strings.ToUpper(c) == "ALL" {
+			res = append(res, getOCICapabilitiesList()...)
+			continue
+		}
+		res = append(res, normalizeCapability(c))
+	}
+	return res
+}
+
+// setOCIDefaultCapabilities replaces the capabilities of the default spec
+// with the configured default capabilities, if any.
+func (c *criService) setOCIDefaultCapabilities(g *generate.Generator) {
+	if len(c.config.DefaultCapabilities) == 0 {
+		return
+	}
+	// Each set gets its own slice, because they are modified separately.
+	g.Config.Process.Capabilities = &runtimespec.LinuxCapabilities{
+		Bounding:    expandCapabilities(c.config.DefaultCapabilities),
+		Effective:   expandCapabilities(c.config.DefaultCapabilities),
+		Inheritable: expandCapabilities(c.config.DefaultCapabilities),
+		Permitted:   expandCapabilities(c.config.DefaultCapabilities),
+	}
+}
+
 // setOCICapabilities adds/drops process capabilities.
 func setOCICapabilities(g *generate.Generator, capabilities *runtime.Capability) error {
 	if capabilities == nil {
 		return nil
 	}
+	if err := validateCapabilities(capabilities.GetAddCapabilities()); err != nil {
+		return errors.Wrap(err, "invalid add capabilities")
+	}
+	if err := validateCapabilities(capabilities.GetDropCapabilities()); err != nil {
+		return errors.Wrap(err, "invalid drop capabilities")
+	}
 
 	// Add/drop all capabilities if "all" is specified, so that
 	// following individual add/drop could still work. E.g.
@@ -777,8 +1130,7 @@ func setOCICapabilities(g *generate.Generator, capabilities *runtime.Capability)
 		if strings.ToUpper(c) == "ALL" {
 			continue
 		}
-		// Capabilities in CRI doesn't have `CAP_` prefix, so add it.
-		if err := addProcessRootCapability(g, "CAP_"+strings.ToUpper(c)); err != nil {
+		if err := addProcessRootCapability(g, normalizeCapability(c)); err != nil {
 			return err
 		}
 	}
@@ -787,13 +1139,31 @@ func setOCICapabilities(g *generate.Generator, capabilities *runtime.Capability)
 		if strings.ToUpper(c) == "ALL" {
 			continue
 		}
-		if err := dropProcessRootCapability(g, "CAP_"+strings.ToUpper(c)); err != nil {
+		if err := dropProcessRootCapability(g, normalizeCapability(c)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// getTargetContainerPid returns the pid of the target container, whose pid
+// namespace a container joins. The target must be a running container in the
+// same sandbox. The network and ipc namespaces are always shared in the pod.
+func (c *criService) getTargetContainerPid(sandboxID, targetID string) (uint32, error) {
+	target, err := c.containerStore.Get(targetID)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to find target container %q", targetID)
+	}
+	if target.SandboxID != sandboxID {
+		return 0, errors.Errorf("target container %q is not in sandbox %q", target.ID, sandboxID)
+	}
+	status := target.Status.Get()
+	if status.State() != runtime.ContainerState_CONTAINER_RUNNING || status.Pid == 0 {
+		return 0, errors.Errorf("target container %q is not running", target.ID)
+	}
+	return status.Pid, nil
+}
+
 // setOCINamespaces sets namespaces.
 func setOCINamespaces(g *generate.Generator, namespaces *runtime.NamespaceOption, sandboxPid uint32) {
 	g.AddOrReplaceLinuxNamespace(string(runtimespec.NetworkNamespace), getNetworkNamespace(sandboxPid)) // nolint: errcheck
@@ -807,8 +1177,8 @@ func setOCINamespaces(g *generate.Generator, namespaces *runtime.NamespaceOption
 
 // defaultRuntimeSpec returns a default runtime spec used in cri-containerd.
 func defaultRuntimeSpec(id string) (*runtimespec.Spec, error) {
-	// GenerateSpec needs namespace.
-	ctx := ctrdutil.NamespacedContext()
+	// GenerateSpec needs namespace, the spec doesn't depend on it.
+	ctx := ctrdutil.NamespacedContext(constants.K8sContainerdNamespace)
 	spec, err := oci.GenerateSpec(ctx, nil, &containers.Container{ID: id})
 	if err != nil {
 		return nil, err
@@ -843,8 +1213,9 @@ func defaultRuntimeSpec(id string) (*runtimespec.Spec, error) {
 	return spec, nil
 }
 
-// generateSeccompSpecOpts generates containerd SpecOpts for seccomp.
-func generateSeccompSpecOpts(seccompProf string, privileged, seccompEnabled bool) (oci.SpecOpts, error) {
+// generateSeccompSpecOpts generates containerd SpecOpts for seccomp. Localhost
+// profiles are loaded through the seccomp profile cache.
+func (c *criService) generateSeccompSpecOpts(seccompProf string, privileged, seccompEnabled bool) (oci.SpecOpts, error) {
 	if privileged {
 		// Do not set seccomp profile when container is privileged
 		return nil, nil
@@ -872,7 +1243,7 @@ func generateSeccompSpecOpts(seccompProf string, privileged, seccompEnabled bool
 		if !strings.HasPrefix(seccompProf, profileNamePrefix) {
 			return nil, errors.Errorf("invalid seccomp profile %q", seccompProf)
 		}
-		return seccomp.WithProfile(strings.TrimPrefix(seccompProf, profileNamePrefix)), nil
+		return withSeccompProfile(c.seccompProfiles, strings.TrimPrefix(seccompProf, profileNamePrefix)), nil
 	}
 }
 
@@ -912,7 +1283,7 @@ func generateApparmorSpecOpts(apparmorProf string, privileged, apparmorEnabled b
 func ensureShared(path string, lookupMount func(string) (mount.Info, error)) error {
 	mountInfo, err := lookupMount(path)
 	if err != nil {
-		return err
+		return errors.Wrapf(err, "failed to find the mount of path %q", path)
 	}
 
 	// Make sure source mount point is shared.
@@ -923,14 +1294,15 @@ func ensureShared(path string, lookupMount func(string) (mount.Info, error)) err
 		}
 	}
 
-	return errors.Errorf("path %q is mounted on %q but it is not a shared mount", path, mountInfo.Mountpoint)
+	return errors.Errorf("path %q is mounted on %q but it is not a shared mount, which bidirectional mount propagation requires; "+
+		"make it shared on the host with \"mount --make-rshared %s\"", path, mountInfo.Mountpoint, mountInfo.Mountpoint)
 }
 
 // Ensure mount point on which path is mounted, is either shared or slave.
 func ensureSharedOrSlave(path string, lookupMount func(string) (mount.Info, error)) error {
 	mountInfo, err := lookupMount(path)
 	if err != nil {
-		return err
+		return errors.Wrapf(err, "failed to find the mount of path %q", path)
 	}
 	// Make sure source mount point is shared.
 	optsSplit := strings.Split(mountInfo.Optional, " ")
@@ -941,7 +1313,8 @@ func ensureSharedOrSlave(path string, lookupMount func(string) (mount.Info, erro
 			return nil
 		}
 	}
-	return errors.Errorf("path %q is mounted on %q but it is not a shared or slave mount", path, mountInfo.Mountpoint)
+	return errors.Errorf("path %q is mounted on %q but it is not a shared or slave mount, which host to container mount propagation requires; "+
+		"make it shared on the host with \"mount --make-rshared %s\"", path, mountInfo.Mountpoint, mountInfo.Mountpoint)
 }
 
 // generateUserString generates valid user string based on OCI Image Spec v1.0.0.
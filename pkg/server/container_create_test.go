@@ -17,9 +17,13 @@ limitations under the License.
 package server
 
 import (
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/containerd/containerd/contrib/apparmor"
 	"github.com/containerd/containerd/contrib/seccomp"
@@ -28,12 +32,18 @@ import (
 	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/opencontainers/selinux/go-selinux"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
 	"github.com/containerd/cri/pkg/annotations"
+	criconfig "github.com/containerd/cri/pkg/config"
 	ostesting "github.com/containerd/cri/pkg/os/testing"
+	containerstore "github.com/containerd/cri/pkg/store/container"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
 	"github.com/containerd/cri/pkg/util"
 )
 
@@ -190,7 +200,7 @@ func TestGeneralContainerSpec(t *testing.T) {
 	config, sandboxConfig, imageConfig, specCheck := getCreateContainerTestData()
 	c := newTestCRIService()
 	testSandboxID := "sandbox-id"
-	spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil)
+	spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
 	require.NoError(t, err)
 	specCheck(t, testID, testSandboxID, testPid, spec)
 }
@@ -203,6 +213,8 @@ func TestContainerCapabilities(t *testing.T) {
 	c := newTestCRIService()
 	for desc, test := range map[string]struct {
 		capability *runtime.Capability
+		defaults   []string
+		expectErr  bool
 		includes   []string
 		excludes   []string
 	}{
@@ -242,10 +254,44 @@ func TestContainerCapabilities(t *testing.T) {
 			includes: []string{"CAP_SYS_ADMIN"},
 			excludes: util.SubtractStringSlice(getOCICapabilitiesList(), "CAP_SYS_ADMIN"),
 		},
+		"should accept capabilities with CAP_ prefix": {
+			capability: &runtime.Capability{
+				AddCapabilities:  []string{"CAP_SYS_ADMIN"},
+				DropCapabilities: []string{"cap_chown"},
+			},
+			includes: []string{"CAP_SYS_ADMIN"},
+			excludes: []string{"CAP_CHOWN", "CAP_CAP_SYS_ADMIN"},
+		},
+		"should reject unknown capability": {
+			capability: &runtime.Capability{
+				AddCapabilities: []string{"SYS_UNKNOWN"},
+			},
+			expectErr: true,
+		},
+		"should reject unknown dropped capability": {
+			capability: &runtime.Capability{
+				DropCapabilities: []string{"SYS_UNKNOWN"},
+			},
+			expectErr: true,
+		},
+		"should replace default capabilities with config": {
+			capability: &runtime.Capability{
+				AddCapabilities:  []string{"NET_ADMIN"},
+				DropCapabilities: []string{"KILL"},
+			},
+			defaults: []string{"CHOWN", "CAP_KILL"},
+			includes: []string{"CAP_CHOWN", "CAP_NET_ADMIN"},
+			excludes: []string{"CAP_KILL", "CAP_SETUID"},
+		},
 	} {
 		t.Logf("TestCase %q", desc)
+		c.config.DefaultCapabilities = test.defaults
 		config.Linux.SecurityContext.Capabilities = test.capability
-		spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil)
+		spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
 		require.NoError(t, err)
 		specCheck(t, testID, testSandboxID, testPid, spec)
 		t.Log(spec.Process.Capabilities.Bounding)
@@ -273,7 +319,7 @@ func TestContainerSpecTty(t *testing.T) {
 	c := newTestCRIService()
 	for _, tty := range []bool{true, false} {
 		config.Tty = tty
-		spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil)
+		spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
 		require.NoError(t, err)
 		specCheck(t, testID, testSandboxID, testPid, spec)
 		assert.Equal(t, tty, spec.Process.Terminal)
@@ -293,7 +339,7 @@ func TestContainerSpecReadonlyRootfs(t *testing.T) {
 	c := newTestCRIService()
 	for _, readonly := range []bool{true, false} {
 		config.Linux.SecurityContext.ReadonlyRootfs = readonly
-		spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil)
+		spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
 		require.NoError(t, err)
 		specCheck(t, testID, testSandboxID, testPid, spec)
 		assert.Equal(t, readonly, spec.Root.Readonly)
@@ -317,7 +363,7 @@ func TestContainerSpecWithExtraMounts(t *testing.T) {
 		HostPath:      "test-host-path-extra",
 		Readonly:      true,
 	}
-	spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, []*runtime.Mount{extraMount})
+	spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, []*runtime.Mount{extraMount}, criconfig.Runtime{})
 	require.NoError(t, err)
 	specCheck(t, testID, testSandboxID, testPid, spec)
 	var mounts []runtimespec.Mount
@@ -371,7 +417,7 @@ func TestContainerAndSandboxPrivileged(t *testing.T) {
 		sandboxConfig.Linux.SecurityContext = &runtime.LinuxSandboxSecurityContext{
 			Privileged: test.sandboxPrivileged,
 		}
-		_, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil)
+		_, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
 		if test.expectError {
 			assert.Error(t, err)
 		} else {
@@ -442,6 +488,45 @@ func TestContainerSpecCommand(t *testing.T) {
 	}
 }
 
+func TestInitContainerSelinuxLabels(t *testing.T) {
+	if !selinux.GetEnabled() {
+		t.Skip("selinux is not enabled")
+	}
+	const (
+		testSandboxID    = "sandbox-id"
+		testSandboxLabel = "system_u:system_r:container_t:s0:c1,c2"
+	)
+	c := newTestCRIService()
+	require.NoError(t, c.sandboxStore.Add(sandboxstore.Sandbox{
+		Metadata: sandboxstore.Metadata{
+			ID:           testSandboxID,
+			ProcessLabel: testSandboxLabel,
+		},
+	}))
+
+	t.Logf("should use the sandbox label level when selinux options are not specified")
+	processLabel, mountLabel, err := c.initContainerSelinuxLabels(testSandboxID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, testSandboxLabel, processLabel)
+	assert.True(t, strings.HasSuffix(mountLabel, ":s0:c1,c2"))
+
+	t.Logf("should use the container selinux options when specified")
+	processLabel, _, err = c.initContainerSelinuxLabels(testSandboxID, &runtime.SELinuxOption{
+		User:  "user_u",
+		Role:  "user_r",
+		Type:  "user_t",
+		Level: "s0:c3,c4",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "user_u:user_r:user_t:s0:c3,c4", processLabel)
+
+	t.Logf("should not set labels if the sandbox has no label")
+	processLabel, mountLabel, err = c.initContainerSelinuxLabels("unknown-sandbox", nil)
+	require.NoError(t, err)
+	assert.Empty(t, processLabel)
+	assert.Empty(t, mountLabel)
+}
+
 func TestGenerateVolumeMounts(t *testing.T) {
 	testContainerRootDir := "test-container-root"
 	for desc, test := range map[string]struct {
@@ -490,6 +575,7 @@ func TestGenerateVolumeMounts(t *testing.T) {
 					assert.Equal(t,
 						filepath.Dir(m.HostPath),
 						filepath.Join(testContainerRootDir, "volumes"))
+					assert.True(t, m.SelinuxRelabel)
 					break
 				}
 			}
@@ -624,7 +710,7 @@ func TestPrivilegedBindMount(t *testing.T) {
 		g, err := generate.New("linux")
 		assert.NoError(t, err)
 		c := newTestCRIService()
-		c.addOCIBindMounts(&g, nil, "")
+		c.addOCIBindMounts(&g, nil, "", nil, nil)
 		if test.privileged {
 			setOCIBindMountsPrivileged(&g)
 		}
@@ -669,6 +755,7 @@ func TestMountPropagation(t *testing.T) {
 		fakeLookupMountFn func(string) (mount.Info, error)
 		optionsCheck      []string
 		expectErr         bool
+		expectedErr       string
 	}{
 		"HostPath should mount as 'rprivate' if propagation is MountPropagation_PROPAGATION_PRIVATE": {
 			criMount: &runtime.Mount{
@@ -718,6 +805,7 @@ func TestMountPropagation(t *testing.T) {
 			},
 			fakeLookupMountFn: slaveLookupMountFn,
 			expectErr:         true,
+			expectedErr:       `make it shared on the host with "mount --make-rshared host-path"`,
 		},
 		"Expect an error if HostPath isn't slave or shared and mount propagation is MountPropagation_PROPAGATION_HOST_TO_CONTAINER": {
 			criMount: &runtime.Mount{
@@ -727,6 +815,19 @@ func TestMountPropagation(t *testing.T) {
 			},
 			fakeLookupMountFn: othersLookupMountFn,
 			expectErr:         true,
+			expectedErr:       `make it shared on the host with "mount --make-rshared host-path"`,
+		},
+		"Expect an error if the mount of HostPath isn't found": {
+			criMount: &runtime.Mount{
+				ContainerPath: "container-path",
+				HostPath:      "host-path",
+				Propagation:   runtime.MountPropagation_PROPAGATION_BIDIRECTIONAL,
+			},
+			fakeLookupMountFn: func(string) (mount.Info, error) {
+				return mount.Info{}, errors.New("not found")
+			},
+			expectErr:   true,
+			expectedErr: `failed to find the mount of path "host-path"`,
 		},
 	} {
 		t.Logf("TestCase %q", desc)
@@ -734,9 +835,10 @@ func TestMountPropagation(t *testing.T) {
 		assert.NoError(t, err)
 		c := newTestCRIService()
 		c.os.(*ostesting.FakeOS).LookupMountFn = test.fakeLookupMountFn
-		err = c.addOCIBindMounts(&g, []*runtime.Mount{test.criMount}, "")
+		err = c.addOCIBindMounts(&g, []*runtime.Mount{test.criMount}, "", nil, nil)
 		if test.expectErr {
 			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedErr)
 		} else {
 			require.NoError(t, err)
 			checkMount(t, g.Config.Mounts, test.criMount.HostPath, test.criMount.ContainerPath, "bind", test.optionsCheck, nil)
@@ -744,6 +846,114 @@ func TestMountPropagation(t *testing.T) {
 	}
 }
 
+func TestParseSelinuxPrivateVolumes(t *testing.T) {
+	for desc, test := range map[string]struct {
+		value     string
+		expected  map[string]bool
+		expectErr bool
+	}{
+		"should parse private volumes": {
+			value:    "/data, /cache/",
+			expected: map[string]bool{"/data": true, "/cache": true},
+		},
+		"should return nothing for empty value": {
+			expected: map[string]bool{},
+		},
+		"should reject relative path": {
+			value:     "data",
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		paths, err := parseSelinuxPrivateVolumes(test.value)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, paths)
+	}
+}
+
+func TestAddOCIDevices(t *testing.T) {
+	for desc, test := range map[string]struct {
+		deviceOwnershipFromSecurityContext bool
+		securityContext                    *runtime.LinuxContainerSecurityContext
+		expectedUID                        uint32
+		expectedGID                        uint32
+	}{
+		"should use device ownership on the host by default": {
+			securityContext: &runtime.LinuxContainerSecurityContext{
+				RunAsUser:  &runtime.Int64Value{Value: 1000},
+				RunAsGroup: &runtime.Int64Value{Value: 2000},
+			},
+		},
+		"should use device ownership from security context": {
+			deviceOwnershipFromSecurityContext: true,
+			securityContext: &runtime.LinuxContainerSecurityContext{
+				RunAsUser:  &runtime.Int64Value{Value: 1000},
+				RunAsGroup: &runtime.Int64Value{Value: 2000},
+			},
+			expectedUID: 1000,
+			expectedGID: 2000,
+		},
+		"should use root if user and group are not set in security context": {
+			deviceOwnershipFromSecurityContext: true,
+			securityContext:                    &runtime.LinuxContainerSecurityContext{},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		g, err := generate.New("linux")
+		require.NoError(t, err)
+		c := newTestCRIService()
+		c.config.DeviceOwnershipFromSecurityContext = test.deviceOwnershipFromSecurityContext
+		require.NoError(t, c.addOCIDevices(&g, []*runtime.Device{{
+			ContainerPath: "/dev/test-null",
+			HostPath:      "/dev/null",
+			Permissions:   "rw",
+		}}, test.securityContext))
+
+		require.Len(t, g.Config.Linux.Devices, 1)
+		dev := g.Config.Linux.Devices[0]
+		assert.Equal(t, "/dev/test-null", dev.Path)
+		assert.Equal(t, "c", dev.Type)
+		assert.EqualValues(t, 1, dev.Major)
+		assert.EqualValues(t, 3, dev.Minor)
+		assert.Equal(t, test.expectedUID, *dev.UID)
+		assert.Equal(t, test.expectedGID, *dev.GID)
+
+		rules := g.Config.Linux.Resources.Devices
+		require.NotEmpty(t, rules)
+		rule := rules[len(rules)-1]
+		assert.True(t, rule.Allow)
+		assert.Equal(t, "c", rule.Type)
+		assert.EqualValues(t, 1, *rule.Major)
+		assert.EqualValues(t, 3, *rule.Minor)
+		assert.Equal(t, "rw", rule.Access)
+	}
+}
+
+func TestGetDevicesFromPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-devices")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	if err := unix.Mknod(filepath.Join(dir, "sub", "null"), unix.S_IFCHR|0666, int(unix.Mkdev(1, 3))); err != nil {
+		t.Skipf("Failed to create device node: %v", err)
+	}
+	require.NoError(t, os.Symlink("/dev/null", filepath.Join(dir, "link")))
+
+	devs, err := getDevicesFromPath(dir, "rwm")
+	require.NoError(t, err)
+	require.Len(t, devs, 1)
+	assert.Equal(t, filepath.Join(dir, "sub", "null"), devs[0].Path)
+	assert.Equal(t, "rwm", devs[0].Permissions)
+
+	_, err = getDevicesFromPath(filepath.Join(dir, "link"), "rwm")
+	assert.Error(t, err, "symbolic link should not be treated as a device")
+}
+
 func TestPidNamespace(t *testing.T) {
 	testID := "test-id"
 	testPid := uint32(1234)
@@ -751,8 +961,10 @@ func TestPidNamespace(t *testing.T) {
 	config, sandboxConfig, imageConfig, _ := getCreateContainerTestData()
 	c := newTestCRIService()
 	for desc, test := range map[string]struct {
-		pidNS    runtime.NamespaceMode
-		expected runtimespec.LinuxNamespace
+		pidNS        runtime.NamespaceMode
+		sandboxPidNS runtime.NamespaceMode
+		podPIDReaper bool
+		expected     runtimespec.LinuxNamespace
 	}{
 		"node namespace mode": {
 			pidNS: runtime.NamespaceMode_NODE,
@@ -774,15 +986,93 @@ func TestPidNamespace(t *testing.T) {
 				Path: getPIDNamespace(testPid),
 			},
 		},
+		"container namespace mode with pod pid reaper": {
+			pidNS:        runtime.NamespaceMode_CONTAINER,
+			sandboxPidNS: runtime.NamespaceMode_CONTAINER,
+			podPIDReaper: true,
+			expected: runtimespec.LinuxNamespace{
+				Type: runtimespec.PIDNamespace,
+				Path: getPIDNamespace(testPid),
+			},
+		},
+		"node namespace mode with pod pid reaper": {
+			pidNS:        runtime.NamespaceMode_NODE,
+			sandboxPidNS: runtime.NamespaceMode_NODE,
+			podPIDReaper: true,
+			expected: runtimespec.LinuxNamespace{
+				Type: runtimespec.PIDNamespace,
+				Path: getPIDNamespace(testPid),
+			},
+		},
 	} {
 		t.Logf("TestCase %q", desc)
+		c.config.PodPIDReaper = test.podPIDReaper
 		config.Linux.SecurityContext.NamespaceOptions = &runtime.NamespaceOption{Pid: test.pidNS}
-		spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil)
+		sandboxConfig.Linux.SecurityContext = &runtime.LinuxSandboxSecurityContext{
+			NamespaceOptions: &runtime.NamespaceOption{Pid: test.sandboxPidNS},
+		}
+		spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
 		require.NoError(t, err)
 		assert.Contains(t, spec.Linux.Namespaces, test.expected)
 	}
 }
 
+func TestGetTargetContainerPid(t *testing.T) {
+	testSandboxID := "sandbox-id"
+	testPid := uint32(1234)
+	for desc, test := range map[string]struct {
+		sandboxID   string
+		status      containerstore.Status
+		expectErr   bool
+		expectedPid uint32
+	}{
+		"should return pid of running target container": {
+			sandboxID: testSandboxID,
+			status: containerstore.Status{
+				Pid:       testPid,
+				CreatedAt: time.Now().UnixNano(),
+				StartedAt: time.Now().UnixNano(),
+			},
+			expectedPid: testPid,
+		},
+		"should return error if target container is not running": {
+			sandboxID: testSandboxID,
+			status: containerstore.Status{
+				CreatedAt: time.Now().UnixNano(),
+			},
+			expectErr: true,
+		},
+		"should return error if target container is in another sandbox": {
+			sandboxID: "another-sandbox-id",
+			status: containerstore.Status{
+				Pid:       testPid,
+				CreatedAt: time.Now().UnixNano(),
+				StartedAt: time.Now().UnixNano(),
+			},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		target, err := containerstore.NewContainer(
+			containerstore.Metadata{ID: "target-id", SandboxID: test.sandboxID},
+			containerstore.WithFakeStatus(test.status),
+		)
+		require.NoError(t, err)
+		require.NoError(t, c.containerStore.Add(target))
+		pid, err := c.getTargetContainerPid(testSandboxID, "target-id")
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expectedPid, pid)
+	}
+	c := newTestCRIService()
+	_, err := c.getTargetContainerPid(testSandboxID, "missing-id")
+	assert.Error(t, err, "should return error if target container doesn't exist")
+}
+
 func TestDefaultRuntimeSpec(t *testing.T) {
 	spec, err := defaultRuntimeSpec("test-id")
 	assert.NoError(t, err)
@@ -792,6 +1082,7 @@ func TestDefaultRuntimeSpec(t *testing.T) {
 }
 
 func TestGenerateSeccompSpecOpts(t *testing.T) {
+	c := newTestCRIService()
 	for desc, test := range map[string]struct {
 		profile    string
 		privileged bool
@@ -832,7 +1123,7 @@ func TestGenerateSeccompSpecOpts(t *testing.T) {
 		},
 		"should set specified profile when local profile is specified": {
 			profile:  profileNamePrefix + "test-profile",
-			specOpts: seccomp.WithProfile("test-profile"),
+			specOpts: withSeccompProfile(nil, "test-profile"),
 		},
 		"should return error if specified profile is invalid": {
 			profile:   "test-profile",
@@ -840,7 +1131,7 @@ func TestGenerateSeccompSpecOpts(t *testing.T) {
 		},
 	} {
 		t.Logf("TestCase %q", desc)
-		specOpts, err := generateSeccompSpecOpts(test.profile, test.privileged, !test.disable)
+		specOpts, err := c.generateSeccompSpecOpts(test.profile, test.privileged, !test.disable)
 		assert.Equal(t,
 			reflect.ValueOf(test.specOpts).Pointer(),
 			reflect.ValueOf(specOpts).Pointer())
@@ -0,0 +1,104 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+	"github.com/containerd/cri/pkg/log"
+)
+
+// containerEventsBufferSize is the number of events buffered for each
+// subscriber. A subscriber which falls behind is closed after its buffered
+// events, and the client should relist and subscribe again.
+const containerEventsBufferSize = 1000
+
+// containerEventBroadcaster broadcasts lifecycle events of containers and
+// sandboxes to all subscribers.
+type containerEventBroadcaster struct {
+	sync.Mutex
+	subscribers map[chan *api.ContainerEventResponse]struct{}
+}
+
+func newContainerEventBroadcaster() *containerEventBroadcaster {
+	return &containerEventBroadcaster{
+		subscribers: make(map[chan *api.ContainerEventResponse]struct{}),
+	}
+}
+
+// subscribe returns a channel receiving all events published after it, and
+// a function to cancel the subscription. The channel is closed if an event
+// can't be buffered, so that the subscriber doesn't miss events silently.
+func (b *containerEventBroadcaster) subscribe() (<-chan *api.ContainerEventResponse, func()) {
+	ch := make(chan *api.ContainerEventResponse, containerEventsBufferSize)
+	b.Lock()
+	defer b.Unlock()
+	b.subscribers[ch] = struct{}{}
+	return ch, func() {
+		b.Lock()
+		defer b.Unlock()
+		delete(b.subscribers, ch)
+	}
+}
+
+// publish sends an event to all subscribers without blocking. Subscribers
+// with full buffers are closed and removed.
+func (b *containerEventBroadcaster) publish(id, sandboxID string, t api.ContainerEventType) {
+	evt := &api.ContainerEventResponse{
+		ContainerId:        id,
+		ContainerEventType: t,
+		CreatedAt:          time.Now().UnixNano(),
+		PodSandboxId:       sandboxID,
+	}
+	b.Lock()
+	defer b.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- evt:
+		default:
+			log.Events.Warnf("Close slow container event subscriber missing event %+v", evt)
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// GetContainerEvents streams lifecycle events of containers and sandboxes
+// until the client cancels the stream. The stream fails with
+// ResourceExhausted if the client falls behind and misses events.
+func (c *criService) GetContainerEvents(r *api.GetEventsRequest, s api.CRIPluginService_GetContainerEventsServer) error {
+	ch, cancel := c.containerEvents.subscribe()
+	defer cancel()
+	for {
+		select {
+		case evt, ok := <-ch:
+			if !ok {
+				return status.Errorf(codes.ResourceExhausted, "container events are dropped for falling behind, relist and subscribe again")
+			}
+			if err := s.Send(evt); err != nil {
+				return err
+			}
+		case <-s.Context().Done():
+			return nil
+		}
+	}
+}
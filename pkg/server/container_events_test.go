@@ -0,0 +1,134 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+)
+
+// fakeContainerEventsServer records the events sent to it.
+type fakeContainerEventsServer struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *api.ContainerEventResponse
+}
+
+func (s *fakeContainerEventsServer) Send(evt *api.ContainerEventResponse) error {
+	s.events <- evt
+	return nil
+}
+
+func (s *fakeContainerEventsServer) Context() context.Context {
+	return s.ctx
+}
+
+func TestContainerEventBroadcaster(t *testing.T) {
+	b := newContainerEventBroadcaster()
+
+	t.Logf("should publish events to all subscribers")
+	ch1, cancel1 := b.subscribe()
+	ch2, cancel2 := b.subscribe()
+	b.publish("container", "sandbox", api.ContainerEventType_CONTAINER_CREATED_EVENT)
+	for _, ch := range []<-chan *api.ContainerEventResponse{ch1, ch2} {
+		evt := <-ch
+		assert.Equal(t, "container", evt.ContainerId)
+		assert.Equal(t, "sandbox", evt.PodSandboxId)
+		assert.Equal(t, api.ContainerEventType_CONTAINER_CREATED_EVENT, evt.ContainerEventType)
+		assert.NotZero(t, evt.CreatedAt)
+	}
+
+	t.Logf("should not publish events to cancelled subscribers")
+	cancel1()
+	b.publish("container", "sandbox", api.ContainerEventType_CONTAINER_STARTED_EVENT)
+	assert.Len(t, ch1, 0)
+	assert.Len(t, ch2, 1)
+
+	t.Logf("should close a slow subscriber instead of blocking")
+	for i := 0; i < containerEventsBufferSize; i++ {
+		b.publish("container", "sandbox", api.ContainerEventType_CONTAINER_STOPPED_EVENT)
+	}
+	assert.Len(t, ch2, containerEventsBufferSize)
+	for i := 0; i < containerEventsBufferSize; i++ {
+		<-ch2
+	}
+	_, ok := <-ch2
+	assert.False(t, ok)
+	assert.Empty(t, b.subscribers)
+	cancel2()
+}
+
+func TestGetContainerEvents(t *testing.T) {
+	c := newTestCRIService()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &fakeContainerEventsServer{
+		ctx:    ctx,
+		events: make(chan *api.ContainerEventResponse, 1),
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.GetContainerEvents(&api.GetEventsRequest{}, s)
+	}()
+
+	t.Logf("should stream published events")
+	var evt *api.ContainerEventResponse
+	for i := 0; i < 100 && evt == nil; i++ {
+		c.containerEvents.publish("sandbox", "sandbox", api.ContainerEventType_CONTAINER_DELETED_EVENT)
+		select {
+		case evt = <-s.events:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	require.NotNil(t, evt)
+	assert.Equal(t, api.ContainerEventType_CONTAINER_DELETED_EVENT, evt.ContainerEventType)
+
+	t.Logf("should fail the stream when the client falls behind")
+	for i := 0; i < containerEventsBufferSize+10; i++ {
+		c.containerEvents.publish("sandbox", "sandbox", api.ContainerEventType_CONTAINER_DELETED_EVENT)
+	}
+	for done := false; !done; {
+		select {
+		case <-s.events:
+		case err := <-errCh:
+			assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+			done = true
+		case <-time.After(10 * time.Second):
+			t.Fatal("stream is not failed")
+		}
+	}
+
+	t.Logf("should stop streaming when the stream is cancelled")
+	go func() {
+		errCh <- c.GetContainerEvents(&api.GetEventsRequest{}, s)
+	}()
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("stream is not stopped")
+	}
+}
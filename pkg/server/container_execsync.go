@@ -17,7 +17,6 @@ limitations under the License.
 package server
 
 import (
-	"bytes"
 	"io"
 	"time"
 
@@ -25,14 +24,13 @@ import (
 	containerdio "github.com/containerd/containerd/cio"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/status"
 	"k8s.io/client-go/tools/remotecommand"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
-	ctrdutil "github.com/containerd/cri/pkg/containerd/util"
-	cioutil "github.com/containerd/cri/pkg/ioutil"
+	"github.com/containerd/cri/pkg/log"
 	cio "github.com/containerd/cri/pkg/server/io"
 	"github.com/containerd/cri/pkg/util"
 )
@@ -40,14 +38,23 @@ import (
 // ExecSync executes a command in the container, and returns the stdout output.
 // If command exits with a non-zero exit code, an error is returned.
 func (c *criService) ExecSync(ctx context.Context, r *runtime.ExecSyncRequest) (*runtime.ExecSyncResponse, error) {
-	var stdout, stderr bytes.Buffer
-	exitCode, err := c.execInContainer(ctx, r.GetContainerId(), execOptions{
+	cntr, err := c.containerStore.Get(r.GetContainerId())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find container %q in store", r.GetContainerId())
+	}
+	stdout := c.newExecSyncOutput(cntr.ID, "stdout")
+	stderr := c.newExecSyncOutput(cntr.ID, "stderr")
+	exitCode, err := c.execInContainer(ctx, cntr.ID, execOptions{
 		cmd:     r.GetCmd(),
-		stdout:  cioutil.NewNopWriteCloser(&stdout),
-		stderr:  cioutil.NewNopWriteCloser(&stderr),
+		stdout:  stdout,
+		stderr:  stderr,
 		timeout: time.Duration(r.GetTimeout()) * time.Second,
 	})
 	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			// Keep the grpc code of an exceeded exec limit.
+			return nil, err
+		}
 		return nil, errors.Wrap(err, "failed to exec in container")
 	}
 
@@ -90,6 +97,12 @@ func (c *criService) execInContainer(ctx context.Context, id string, opts execOp
 		return nil, errors.Errorf("container is in %s state", criContainerStateToString(state))
 	}
 
+	release, err := c.execLimiter.acquire(id)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	container := cntr.Container
 	spec, err := container.Spec(ctx)
 	if err != nil {
@@ -115,7 +128,7 @@ func (c *criService) execInContainer(ctx context.Context, id string, opts execOp
 		opts.stderr = cio.NewDiscardLogger()
 	}
 	execID := util.GenerateID()
-	logrus.Debugf("Generated exec id %q for container %q", execID, id)
+	log.Container.Debugf("Generated exec id %q for container %q", execID, id)
 	volatileRootDir := c.getVolatileContainerRootDir(id)
 	var execIO *cio.ExecIO
 	process, err := task.Exec(ctx, execID, pspec,
@@ -129,10 +142,10 @@ func (c *criService) execInContainer(ctx context.Context, id string, opts execOp
 		return nil, errors.Wrapf(err, "failed to create exec %q", execID)
 	}
 	defer func() {
-		deferCtx, deferCancel := ctrdutil.DeferContext()
+		deferCtx, deferCancel := c.deferContext()
 		defer deferCancel()
 		if _, err := process.Delete(deferCtx); err != nil {
-			logrus.WithError(err).Errorf("Failed to delete exec process %q for container %q", execID, id)
+			log.Container.WithError(err).Errorf("Failed to delete exec process %q for container %q", execID, id)
 		}
 	}()
 
@@ -146,14 +159,15 @@ func (c *criService) execInContainer(ctx context.Context, id string, opts execOp
 
 	handleResizing(opts.resize, func(size remotecommand.TerminalSize) {
 		if err := process.Resize(ctx, uint32(size.Width), uint32(size.Height)); err != nil {
-			logrus.WithError(err).Errorf("Failed to resize process %q console for container %q", execID, id)
+			log.Container.WithError(err).Errorf("Failed to resize process %q console for container %q", execID, id)
 		}
 	})
 
+	stdout, stderr, outputExceededCh := c.execLimiter.limitOutput(opts.stdout, opts.stderr)
 	attachDone := execIO.Attach(cio.AttachOptions{
 		Stdin:     opts.stdin,
-		Stdout:    opts.stdout,
-		Stderr:    opts.stderr,
+		Stdout:    stdout,
+		Stderr:    stderr,
 		Tty:       opts.tty,
 		StdinOnce: true,
 		CloseStdin: func() error {
@@ -161,35 +175,52 @@ func (c *criService) execInContainer(ctx context.Context, id string, opts execOp
 		},
 	})
 
+	// kill kills the exec process and waits for it to exit.
+	kill := func(reason string) error {
+		// Ignore the not found error because the process may exit itself before killing.
+		if err := process.Kill(ctx, unix.SIGKILL); err != nil && !errdefs.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to kill exec %q", execID)
+		}
+		// Wait for the process to be killed.
+		exitRes := <-exitCh
+		log.Container.Infof("%s received while waiting for exec process kill %q code %d and error %v",
+			reason, execID, exitRes.ExitCode(), exitRes.Error())
+		<-attachDone
+		log.Container.Debugf("Stream pipe for exec process %q done", execID)
+		return nil
+	}
+
 	var timeoutCh <-chan time.Time
-	if opts.timeout == 0 {
+	timeout, maxDuration := c.execLimiter.timeout(opts.timeout)
+	if timeout == 0 {
 		// Do not set timeout if it's 0.
 		timeoutCh = make(chan time.Time)
 	} else {
-		timeoutCh = time.After(opts.timeout)
+		timeoutCh = time.After(timeout)
 	}
 	select {
 	case <-timeoutCh:
 		//TODO(Abhi) Use context.WithDeadline instead of timeout.
-		// Ignore the not found error because the process may exit itself before killing.
-		if err := process.Kill(ctx, unix.SIGKILL); err != nil && !errdefs.IsNotFound(err) {
-			return nil, errors.Wrapf(err, "failed to kill exec %q", execID)
+		if err := kill("Timeout"); err != nil {
+			return nil, err
+		}
+		if maxDuration {
+			return nil, c.execLimiter.durationError()
 		}
-		// Wait for the process to be killed.
-		exitRes := <-exitCh
-		logrus.Infof("Timeout received while waiting for exec process kill %q code %d and error %v",
-			execID, exitRes.ExitCode(), exitRes.Error())
-		<-attachDone
-		logrus.Debugf("Stream pipe for exec process %q done", execID)
 		return nil, errors.Errorf("timeout %v exceeded", opts.timeout)
+	case <-outputExceededCh:
+		if err := kill("Output limit"); err != nil {
+			return nil, err
+		}
+		return nil, c.execLimiter.outputError()
 	case exitRes := <-exitCh:
 		code, _, err := exitRes.Result()
-		logrus.Infof("Exec process %q exits with exit code %d and error %v", execID, code, err)
+		log.Container.Infof("Exec process %q exits with exit code %d and error %v", execID, code, err)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed while waiting for exec %q", execID)
 		}
 		<-attachDone
-		logrus.Debugf("Stream pipe for exec process %q done", execID)
+		log.Container.Debugf("Stream pipe for exec process %q done", execID)
 		return &code, nil
 	}
 }
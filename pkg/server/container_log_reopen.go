@@ -31,14 +31,17 @@ func (c *criService) ReopenContainerLog(ctx context.Context, r *runtime.ReopenCo
 		return nil, errors.Wrapf(err, "an error occurred when try to find container %q", r.GetContainerId())
 	}
 
-	if container.Status.Get().State() != runtime.ContainerState_CONTAINER_RUNNING {
-		return nil, errors.New("container is not running")
+	if state := container.Status.Get().State(); state != runtime.ContainerState_CONTAINER_RUNNING {
+		return nil, errors.Errorf("container %q is in %s state", container.ID, criContainerStateToString(state))
 	}
 
-	// Create new container logger and replace the existing ones.
-	stdoutWC, stderrWC, err := c.createContainerLoggers(container.LogPath, container.Config.GetTty())
+	// Create new container logger and replace the existing ones. The log file
+	// is reopened by path, so new output goes to the file created by kubelet
+	// after rotation. The old loggers are closed, which closes the rotated
+	// file once all pending output is flushed.
+	stdoutWC, stderrWC, err := c.createContainerLoggers(container.LogPath, container.Config.GetTty(), container.LogFormat)
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrapf(err, "failed to create container loggers for %q", container.ID)
 	}
 	oldStdoutWC, oldStderrWC := container.IO.AddOutput("log", stdoutWC, stderrWC)
 	if oldStdoutWC != nil {
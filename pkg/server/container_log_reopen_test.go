@@ -0,0 +1,63 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	containerstore "github.com/containerd/cri/pkg/store/container"
+)
+
+func TestReopenContainerLogNotRunning(t *testing.T) {
+	testID := "test-id"
+	for desc, test := range map[string]struct {
+		status *containerstore.Status
+	}{
+		"should return error when container doesn't exist": {},
+		"should return error when container is created": {
+			status: &containerstore.Status{
+				CreatedAt: time.Now().UnixNano(),
+			},
+		},
+		"should return error when container is exited": {
+			status: &containerstore.Status{
+				CreatedAt:  time.Now().UnixNano(),
+				StartedAt:  time.Now().UnixNano(),
+				FinishedAt: time.Now().UnixNano(),
+			},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		if test.status != nil {
+			container, err := containerstore.NewContainer(
+				containerstore.Metadata{ID: testID},
+				containerstore.WithFakeStatus(*test.status),
+			)
+			require.NoError(t, err)
+			require.NoError(t, c.containerStore.Add(container))
+		}
+		_, err := c.ReopenContainerLog(context.Background(), &runtime.ReopenContainerLogRequest{ContainerId: testID})
+		assert.Error(t, err)
+	}
+}
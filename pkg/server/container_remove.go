@@ -21,10 +21,10 @@ import (
 	"github.com/containerd/containerd/errdefs"
 	"github.com/docker/docker/pkg/system"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
+	api "github.com/containerd/cri/pkg/api/v1"
 	"github.com/containerd/cri/pkg/log"
 	"github.com/containerd/cri/pkg/store"
 	containerstore "github.com/containerd/cri/pkg/store/container"
@@ -38,8 +38,13 @@ func (c *criService) RemoveContainer(ctx context.Context, r *runtime.RemoveConta
 		if err != store.ErrNotExist {
 			return nil, errors.Wrapf(err, "an error occurred when try to find container %q", r.GetContainerId())
 		}
+		// Finish the removal left by a previous attempt, whose metadata is
+		// already deleted.
+		if err := c.finishRemovalByTombstone(ctx, r.GetContainerId()); err != nil {
+			return nil, err
+		}
 		// Do not return error if container metadata doesn't exist.
-		log.Tracef("RemoveContainer called for container %q that does not exist", r.GetContainerId())
+		log.Container.Tracef("RemoveContainer called for container %q that does not exist", r.GetContainerId())
 		return &runtime.RemoveContainerResponse{}, nil
 	}
 	id := container.ID
@@ -53,7 +58,7 @@ func (c *criService) RemoveContainer(ctx context.Context, r *runtime.RemoveConta
 		if retErr != nil {
 			// Reset removing if remove failed.
 			if err := resetContainerRemoving(container); err != nil {
-				logrus.WithError(err).Errorf("failed to reset removing state for container %q", id)
+				log.Container.WithError(err).Errorf("failed to reset removing state for container %q", id)
 			}
 		}
 	}()
@@ -63,12 +68,31 @@ func (c *criService) RemoveContainer(ctx context.Context, r *runtime.RemoveConta
 	// kubelet implementation, we'll never start a container once we decide to remove it,
 	// so we don't need the "Dead" state for now.
 
+	// Record what is to be removed, in case the removal is interrupted.
+	t, err := newTombstone(ctx, id, false, container.Container)
+	if err != nil {
+		return nil, err
+	}
+	t.Checkpoint = container.Checkpoint
+	if err := c.writeTombstone(t); err != nil {
+		return nil, errors.Wrapf(err, "failed to write tombstone of container %q", id)
+	}
+
 	// Delete containerd container.
 	if err := container.Container.Delete(ctx, containerd.WithSnapshotCleanup); err != nil {
 		if !errdefs.IsNotFound(err) {
 			return nil, errors.Wrapf(err, "failed to delete containerd container %q", id)
 		}
-		log.Tracef("Remove called for containerd container %q that does not exist", id)
+		log.Container.Tracef("Remove called for containerd container %q that does not exist", id)
+	}
+
+	// Delete the checkpoint image the container is restored from. The
+	// container is already gone, a leftover image only holds content until
+	// it's deleted manually, so it doesn't fail the removal.
+	if container.Checkpoint != "" {
+		if err := c.client.ImageService().Delete(ctx, container.Checkpoint); err != nil && !errdefs.IsNotFound(err) {
+			log.Container.WithError(err).Errorf("Failed to delete checkpoint image %q of container %q", container.Checkpoint, id)
+		}
 	}
 
 	// Delete container checkpoint.
@@ -87,10 +111,27 @@ func (c *criService) RemoveContainer(ctx context.Context, r *runtime.RemoveConta
 			volatileContainerRootDir)
 	}
 
+	if err := c.removeTombstone(id); err != nil {
+		log.Container.WithError(err).Errorf("Failed to remove tombstone of container %q", id)
+	}
+
 	c.containerStore.Delete(id)
 
+	if err := c.updatePodCgroup(container.SandboxID); err != nil {
+		log.Container.WithError(err).Errorf("Failed to update pod cgroup of sandbox %q", container.SandboxID)
+	}
+
 	c.containerNameIndex.ReleaseByKey(id)
 
+	c.containerEvents.publish(id, container.SandboxID, api.ContainerEventType_CONTAINER_DELETED_EVENT)
+
+	c.specHooks.notify(ctx, specHookRequest{
+		Event:       specHookRemoveContainer,
+		ID:          id,
+		SandboxID:   container.SandboxID,
+		Labels:      container.Config.GetLabels(),
+		Annotations: container.Config.GetAnnotations(),
+	})
 	return &runtime.RemoveContainerResponse{}, nil
 }
 
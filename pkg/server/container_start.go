@@ -25,12 +25,13 @@ import (
 	containerdio "github.com/containerd/containerd/cio"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
-	ctrdutil "github.com/containerd/cri/pkg/containerd/util"
+	"github.com/containerd/cri/pkg/annotations"
+	api "github.com/containerd/cri/pkg/api/v1"
 	cioutil "github.com/containerd/cri/pkg/ioutil"
+	"github.com/containerd/cri/pkg/log"
 	cio "github.com/containerd/cri/pkg/server/io"
 	containerstore "github.com/containerd/cri/pkg/store/container"
 	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
@@ -55,6 +56,7 @@ func (c *criService) StartContainer(ctx context.Context, r *runtime.StartContain
 	} else if err != nil {
 		return nil, errors.Wrapf(err, "failed to update container %q metadata", container.ID)
 	}
+	c.containerEvents.publish(container.ID, container.SandboxID, api.ContainerEventType_CONTAINER_STARTED_EVENT)
 	return &runtime.StartContainerResponse{}, nil
 }
 
@@ -99,7 +101,7 @@ func (c *criService) startContainer(ctx context.Context,
 	}
 
 	ioCreation := func(id string) (_ containerdio.IO, err error) {
-		stdoutWC, stderrWC, err := c.createContainerLoggers(meta.LogPath, config.GetTty())
+		stdoutWC, stderrWC, err := c.createContainerLoggers(meta.LogPath, config.GetTty(), meta.LogFormat)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create container loggers")
 		}
@@ -108,24 +110,50 @@ func (c *criService) startContainer(ctx context.Context,
 		return cntr.IO, nil
 	}
 
-	task, err := container.NewTask(ctx, ioCreation)
+	var taskOpts []containerd.NewTaskOpts
+	if meta.Checkpoint != "" {
+		// Restore the container process from the checkpoint it is created from.
+		checkpoint, err := c.client.GetImage(ctx, meta.Checkpoint)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get checkpoint image %q", meta.Checkpoint)
+		}
+		taskOpts = append(taskOpts, containerd.WithTaskCheckpoint(checkpoint))
+	}
+
+	taskCtx, taskCancel := withPhaseTimeout(ctx, c.timeouts.taskStart)
+	defer taskCancel()
+	_, span := startSpan(taskCtx, "task create")
+	task, err := container.NewTask(taskCtx, ioCreation, taskOpts...)
+	span.end(err)
 	if err != nil {
-		return errors.Wrap(err, "failed to create containerd task")
+		return phaseError(taskCtx, err, "failed to create containerd task")
 	}
 	defer func() {
 		if retErr != nil {
-			deferCtx, deferCancel := ctrdutil.DeferContext()
+			deferCtx, deferCancel := c.deferContext()
 			defer deferCancel()
 			// It's possible that task is deleted by event monitor.
 			if _, err := task.Delete(deferCtx, containerd.WithProcessKill); err != nil && !errdefs.IsNotFound(err) {
-				logrus.WithError(err).Errorf("Failed to delete containerd task %q", id)
+				log.Container.WithError(err).Errorf("Failed to delete containerd task %q", id)
 			}
 		}
 	}()
 
+	// Set the cgroup limits missing in the runtime spec before the container
+	// process starts.
+	if err := c.setCgroupV2Resources(ctx, container); err != nil {
+		return errors.Wrapf(err, "failed to set cgroup v2 resources of container %q", id)
+	}
+	if err := c.setUnifiedResources(ctx, container, config.GetAnnotations()); err != nil {
+		return errors.Wrapf(err, "failed to set unified resources of container %q", id)
+	}
+
 	// Start containerd task.
-	if err := task.Start(ctx); err != nil {
-		return errors.Wrapf(err, "failed to start containerd task %q", id)
+	_, span = startSpan(taskCtx, "task start")
+	err = task.Start(taskCtx)
+	span.end(err)
+	if err != nil {
+		return phaseError(taskCtx, err, "failed to start containerd task %q", id)
 	}
 
 	// Update container start timestamp.
@@ -135,10 +163,10 @@ func (c *criService) startContainer(ctx context.Context,
 }
 
 // createContainerLoggers creates container loggers and return write closer for stdout and stderr.
-func (c *criService) createContainerLoggers(logPath string, tty bool) (stdout io.WriteCloser, stderr io.WriteCloser, err error) {
+func (c *criService) createContainerLoggers(logPath string, tty bool, format string) (stdout io.WriteCloser, stderr io.WriteCloser, err error) {
 	if logPath != "" {
 		// Only generate container log when log path is specified.
-		f, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+		f, err := c.openContainerLogFile(logPath)
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "failed to create and open log file")
 		}
@@ -149,10 +177,10 @@ func (c *criService) createContainerLoggers(logPath string, tty bool) (stdout io
 		}()
 		var stdoutCh, stderrCh <-chan struct{}
 		wc := cioutil.NewSerialWriteCloser(f)
-		stdout, stdoutCh = cio.NewCRILogger(logPath, wc, cio.Stdout, c.config.MaxContainerLogLineSize)
+		stdout, stdoutCh = cio.NewCRILogger(logPath, wc, cio.Stdout, c.config.MaxContainerLogLineSize, cio.LogFormat(format))
 		// Only redirect stderr when there is no tty.
 		if !tty {
-			stderr, stderrCh = cio.NewCRILogger(logPath, wc, cio.Stderr, c.config.MaxContainerLogLineSize)
+			stderr, stderrCh = cio.NewCRILogger(logPath, wc, cio.Stderr, c.config.MaxContainerLogLineSize, cio.LogFormat(format))
 		}
 		go func() {
 			if stdoutCh != nil {
@@ -161,7 +189,7 @@ func (c *criService) createContainerLoggers(logPath string, tty bool) (stdout io
 			if stderrCh != nil {
 				<-stderrCh
 			}
-			logrus.Debugf("Finish redirecting log file %q, closing it", logPath)
+			log.Container.Debugf("Finish redirecting log file %q, closing it", logPath)
 			f.Close()
 		}()
 	} else {
@@ -170,3 +198,38 @@ func (c *criService) createContainerLoggers(logPath string, tty bool) (stdout io
 	}
 	return
 }
+
+// openContainerLogFile opens the container log file for appending. The file
+// is rotated by the cri plugin if the max log file size is configured.
+func (c *criService) openContainerLogFile(logPath string) (io.WriteCloser, error) {
+	if c.config.MaxContainerLogFileSize > 0 {
+		return cioutil.NewRotatingFile(logPath, c.config.MaxContainerLogFileSize, c.config.MaxContainerLogFiles)
+	}
+	return os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+}
+
+// getContainerLogFormat returns the log format of containers in the sandbox.
+// The sandbox annotation overrides the configured format.
+func (c *criService) getContainerLogFormat(config *runtime.PodSandboxConfig) (string, error) {
+	if f, ok := config.GetAnnotations()[annotations.LogFormat]; ok {
+		if err := validateLogFormat(f); err != nil {
+			return "", errors.Wrapf(err, "invalid %q annotation", annotations.LogFormat)
+		}
+		return f, nil
+	}
+	format := c.config.ContainerLogFormat
+	if err := validateLogFormat(format); err != nil {
+		return "", errors.Wrap(err, "invalid container_log_format config")
+	}
+	return format, nil
+}
+
+// validateLogFormat validates a container log format. Empty means the CRI
+// logging format.
+func validateLogFormat(format string) error {
+	switch cio.LogFormat(format) {
+	case "", cio.LogFormatCRI, cio.LogFormatJSON:
+		return nil
+	}
+	return errors.Errorf("unsupported log format %q", format)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+)
+
+func TestGetContainerLogFormat(t *testing.T) {
+	for desc, test := range map[string]struct {
+		configFormat   string
+		annotations    map[string]string
+		expectedFormat string
+		expectedErr    string
+	}{
+		"should use the configured format": {
+			configFormat:   "json",
+			expectedFormat: "json",
+		},
+		"should override the configured format with the annotation": {
+			configFormat:   "json",
+			annotations:    map[string]string{annotations.LogFormat: "cri"},
+			expectedFormat: "cri",
+		},
+		"should report invalid annotation": {
+			annotations: map[string]string{annotations.LogFormat: "xml"},
+			expectedErr: "annotation",
+		},
+		"should report invalid config": {
+			configFormat: "xml",
+			expectedErr:  "container_log_format",
+		},
+		"should not report invalid config overridden by the annotation": {
+			configFormat:   "xml",
+			annotations:    map[string]string{annotations.LogFormat: "json"},
+			expectedFormat: "json",
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.ContainerLogFormat = test.configFormat
+		format, err := c.getContainerLogFormat(&runtime.PodSandboxConfig{Annotations: test.annotations})
+		if test.expectedErr != "" {
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedErr)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expectedFormat, format)
+	}
+}
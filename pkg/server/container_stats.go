@@ -18,9 +18,12 @@ package server
 
 import (
 	tasks "github.com/containerd/containerd/api/services/tasks/v1"
+	"github.com/containerd/containerd/api/types"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	containerstore "github.com/containerd/cri/pkg/store/container"
 )
 
 // ContainerStats returns stats of the container. If the container does not
@@ -30,6 +33,31 @@ func (c *criService) ContainerStats(ctx context.Context, in *runtime.ContainerSt
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to find container")
 	}
+	metric, err := c.getContainerMetric(ctx, cntr)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := c.getContainerMetrics(cntr.Metadata, metric)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode container metrics")
+	}
+	return &runtime.ContainerStatsResponse{Stats: cs}, nil
+}
+
+// getContainerMetric fetches the cgroup metrics of a container. On the cgroup
+// v2 unified hierarchy, nil is returned if the container is not running.
+func (c *criService) getContainerMetric(ctx context.Context, cntr containerstore.Container) (*types.Metric, error) {
+	if c.unifiedCgroup {
+		if cntr.Status.Get().State() != runtime.ContainerState_CONTAINER_RUNNING {
+			return nil, nil
+		}
+		metric, err := c.getUnifiedCgroupMetrics(ctx, cntr.Container)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to fetch metrics from cgroup")
+		}
+		return metric, nil
+	}
 	request := &tasks.MetricsRequest{Filters: []string{"id==" + cntr.ID}}
 	resp, err := c.client.TaskService().Metrics(ctx, request)
 	if err != nil {
@@ -38,10 +66,5 @@ func (c *criService) ContainerStats(ctx context.Context, in *runtime.ContainerSt
 	if len(resp.Metrics) != 1 {
 		return nil, errors.Errorf("unexpected metrics response: %+v", resp.Metrics)
 	}
-
-	cs, err := c.getContainerMetrics(cntr.Metadata, resp.Metrics[0])
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to decode container metrics")
-	}
-	return &runtime.ContainerStatsResponse{Stats: cs}, nil
+	return resp.Metrics[0], nil
 }
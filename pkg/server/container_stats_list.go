@@ -25,7 +25,9 @@ import (
 	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
+	"github.com/containerd/cri/pkg/log"
 	containerstore "github.com/containerd/cri/pkg/store/container"
+	snapshotstore "github.com/containerd/cri/pkg/store/snapshot"
 )
 
 // ListContainerStats returns stats of all running containers.
@@ -33,15 +35,41 @@ func (c *criService) ListContainerStats(
 	ctx context.Context,
 	in *runtime.ListContainerStatsRequest,
 ) (*runtime.ListContainerStatsResponse, error) {
-	request, containers, err := c.buildTaskMetricsRequest(in)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to build metrics request")
-	}
-	resp, err := c.client.TaskService().Metrics(ctx, &request)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to fetch metrics for tasks")
+	var (
+		metrics    []*types.Metric
+		containers []containerstore.Container
+	)
+	if c.unifiedCgroup {
+		containers = c.filterStatsContainers(in)
+		for _, cntr := range containers {
+			if cntr.Status.Get().State() != runtime.ContainerState_CONTAINER_RUNNING {
+				continue
+			}
+			metric, err := c.getUnifiedCgroupMetrics(ctx, cntr.Container)
+			if err != nil {
+				// The container may exit after the state check, skip it
+				// like task service does for tasks not running.
+				log.Container.WithError(err).Warnf("Failed to fetch metrics from cgroup for %q", cntr.ID)
+				continue
+			}
+			metrics = append(metrics, metric)
+		}
+	} else {
+		var (
+			request tasks.MetricsRequest
+			err     error
+		)
+		request, containers, err = c.buildTaskMetricsRequest(in)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to build metrics request")
+		}
+		resp, err := c.client.TaskService().Metrics(ctx, &request)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to fetch metrics for tasks")
+		}
+		metrics = resp.Metrics
 	}
-	criStats, err := c.toCRIContainerStats(resp.Metrics, containers)
+	criStats, err := c.toCRIContainerStats(metrics, containers)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to convert to cri containerd stats format")
 	}
@@ -73,17 +101,24 @@ func (c *criService) getContainerMetrics(
 ) (*runtime.ContainerStats, error) {
 	var cs runtime.ContainerStats
 	var usedBytes, inodesUsed uint64
-	sn, err := c.snapshotStore.Get(meta.ID)
+	var sn snapshotstore.Snapshot
+	snapshotter := c.containerSnapshotter(meta)
 	// If snapshotstore doesn't have cached snapshot information
-	// set WritableLayer usage to zero
-	if err == nil {
-		usedBytes = sn.Size
-		inodesUsed = sn.Inodes
+	// set WritableLayer usage to zero, and request it from the snapshotter
+	// in the background for the following calls.
+	if store, ok := c.snapshotStores[snapshotter]; ok {
+		if s, err := store.Get(meta.ID); err == nil {
+			sn = s
+			usedBytes = sn.Size
+			inodesUsed = sn.Inodes
+		} else if syncer, ok := c.snapshotsSyncers[snapshotter]; ok {
+			syncer.request(meta.ID)
+		}
 	}
 	cs.WritableLayer = &runtime.FilesystemUsage{
 		Timestamp: sn.Timestamp,
 		FsId: &runtime.FilesystemIdentifier{
-			Mountpoint: c.imageFSPath,
+			Mountpoint: c.imageFSPaths[snapshotter],
 		},
 		UsedBytes:  &runtime.UInt64Value{Value: usedBytes},
 		InodesUsed: &runtime.UInt64Value{Value: inodesUsed},
@@ -135,8 +170,18 @@ func (c *criService) buildTaskMetricsRequest(
 	r *runtime.ListContainerStatsRequest,
 ) (tasks.MetricsRequest, []containerstore.Container, error) {
 	var req tasks.MetricsRequest
+	containers := c.filterStatsContainers(r)
+	for _, cntr := range containers {
+		req.Filters = append(req.Filters, "id=="+cntr.ID)
+	}
+	return req, containers, nil
+}
+
+// filterStatsContainers returns the containers matching the filter of a
+// stats request.
+func (c *criService) filterStatsContainers(r *runtime.ListContainerStatsRequest) []containerstore.Container {
 	if r.GetFilter() == nil {
-		return req, nil, nil
+		return nil
 	}
 	c.normalizeContainerStatsFilter(r.GetFilter())
 	var containers []containerstore.Container
@@ -152,9 +197,8 @@ func (c *criService) buildTaskMetricsRequest(
 			continue
 		}
 		containers = append(containers, cntr)
-		req.Filters = append(req.Filters, "id=="+cntr.ID)
 	}
-	return req, containers, nil
+	return containers
 }
 
 func matchLabelSelector(selector, labels map[string]string) bool {
@@ -56,7 +56,11 @@ func (c *criService) ContainerStatus(ctx context.Context, r *runtime.ContainerSt
 		imageRef = image.RepoDigests[0]
 	}
 	status := toCRIContainerStatus(container, spec, imageRef)
-	info, err := toCRIContainerInfo(ctx, container, r.GetVerbose())
+	var runtimeHandler string
+	if sandbox, err := c.sandboxStore.Get(container.SandboxID); err == nil {
+		runtimeHandler = sandbox.RuntimeHandler
+	}
+	info, err := toCRIContainerInfo(ctx, container, runtimeHandler, r.GetVerbose())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get verbose container info")
 	}
@@ -101,19 +105,21 @@ func toCRIContainerStatus(container containerstore.Container, spec *runtime.Imag
 
 type containerInfo struct {
 	// TODO(random-liu): Add sandboxID in CRI container status.
-	SandboxID   string                   `json:"sandboxID"`
-	Pid         uint32                   `json:"pid"`
-	Removing    bool                     `json:"removing"`
-	SnapshotKey string                   `json:"snapshotKey"`
-	Snapshotter string                   `json:"snapshotter"`
-	Runtime     *criconfig.Runtime       `json:"runtime"`
-	Config      *runtime.ContainerConfig `json:"config"`
-	RuntimeSpec *runtimespec.Spec        `json:"runtimeSpec"`
+	SandboxID      string                   `json:"sandboxID"`
+	Pid            uint32                   `json:"pid"`
+	Removing       bool                     `json:"removing"`
+	SnapshotKey    string                   `json:"snapshotKey"`
+	Snapshotter    string                   `json:"snapshotter"`
+	RuntimeHandler string                   `json:"runtimeHandler"`
+	Runtime        *criconfig.Runtime       `json:"runtime"`
+	CgroupPath     string                   `json:"cgroupPath"`
+	Config         *runtime.ContainerConfig `json:"config"`
+	RuntimeSpec    *runtimespec.Spec        `json:"runtimeSpec"`
 }
 
 // toCRIContainerInfo converts internal container object information to CRI container status response info map.
 // TODO(random-liu): Return error instead of logging.
-func toCRIContainerInfo(ctx context.Context, container containerstore.Container, verbose bool) (map[string]string, error) {
+func toCRIContainerInfo(ctx context.Context, container containerstore.Container, runtimeHandler string, verbose bool) (map[string]string, error) {
 	if !verbose {
 		return nil, nil
 	}
@@ -123,10 +129,22 @@ func toCRIContainerInfo(ctx context.Context, container containerstore.Container,
 
 	// TODO(random-liu): Change CRI status info to use array instead of map.
 	ci := &containerInfo{
-		SandboxID: container.SandboxID,
-		Pid:       status.Pid,
-		Removing:  status.Removing,
-		Config:    meta.Config,
+		SandboxID:      container.SandboxID,
+		Pid:            status.Pid,
+		Removing:       status.Removing,
+		Config:         meta.Config,
+		RuntimeHandler: runtimeHandler,
+	}
+	if status.Resources != nil {
+		// Report resource limits updated by UpdateContainerResources.
+		config := *meta.Config
+		var linux runtime.LinuxContainerConfig
+		if config.Linux != nil {
+			linux = *config.Linux
+		}
+		linux.Resources = status.Resources
+		config.Linux = &linux
+		ci.Config = &config
 	}
 
 	var err error
@@ -134,6 +152,9 @@ func toCRIContainerInfo(ctx context.Context, container containerstore.Container,
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get container runtime spec")
 	}
+	if ci.RuntimeSpec.Linux != nil {
+		ci.CgroupPath = ci.RuntimeSpec.Linux.CgroupsPath
+	}
 
 	ctrInfo, err := container.Container.Info(ctx)
 	if err != nil {
@@ -155,6 +155,7 @@ func TestToCRIContainerInfo(t *testing.T) {
 
 	info, err := toCRIContainerInfo(context.Background(),
 		container,
+		"",
 		false)
 	assert.NoError(t, err)
 	assert.Nil(t, info)
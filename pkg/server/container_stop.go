@@ -21,13 +21,12 @@ import (
 
 	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/errdefs"
-	"github.com/docker/docker/pkg/signal"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"golang.org/x/sys/unix"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
+	"github.com/containerd/cri/pkg/log"
 	containerstore "github.com/containerd/cri/pkg/store/container"
 )
 
@@ -58,29 +57,16 @@ func (c *criService) stopContainer(ctx context.Context, container containerstore
 	// stop only takes real action after the container is started.
 	state := container.Status.Get().State()
 	if state != runtime.ContainerState_CONTAINER_RUNNING {
-		logrus.Infof("Container to stop %q is not running, current state %q",
+		log.Container.Infof("Container to stop %q is not running, current state %q",
 			id, criContainerStateToString(state))
 		return nil
 	}
 
 	if timeout > 0 {
-		stopSignal := unix.SIGTERM
-		image, err := c.imageStore.Get(container.ImageRef)
+		steps, err := c.getStopSteps(container)
 		if err != nil {
-			// NOTE(random-liu): It's possible that the container is stopped,
-			// deleted and image is garbage collected before this point. However,
-			// the chance is really slim, even it happens, it's still fine to return
-			// an error here.
-			return errors.Wrapf(err, "failed to get image metadata %q", container.ImageRef)
+			return err
 		}
-		if image.ImageSpec.Config.StopSignal != "" {
-			stopSignal, err = signal.ParseSignal(image.ImageSpec.Config.StopSignal)
-			if err != nil {
-				return errors.Wrapf(err, "failed to parse stop signal %q",
-					image.ImageSpec.Config.StopSignal)
-			}
-		}
-		logrus.Infof("Stop container %q with signal %v", id, stopSignal)
 		task, err := container.Container.Task(ctx, nil)
 		if err != nil {
 			if !errdefs.IsNotFound(err) {
@@ -88,20 +74,39 @@ func (c *criService) stopContainer(ctx context.Context, container containerstore
 			}
 			return nil
 		}
-		if task != nil {
-			if err = task.Kill(ctx, stopSignal); err != nil {
-				if !errdefs.IsNotFound(err) {
-					return errors.Wrapf(err, "failed to stop container %q", id)
+		// All steps are within the grace period.
+		deadline := time.Now().Add(timeout)
+		for _, step := range steps {
+			wait := time.Until(deadline)
+			if wait <= 0 {
+				break
+			}
+			if step.timeout > 0 && step.timeout < wait {
+				wait = step.timeout
+			}
+			log.Container.Infof("Stop container %q with signal %v", id, step.signal)
+			if task != nil {
+				if err = task.Kill(ctx, step.signal); err != nil {
+					if !errdefs.IsNotFound(err) {
+						return errors.Wrapf(err, "failed to stop container %q", id)
+					}
+					// Move on to make sure container status is updated.
 				}
-				// Move on to make sure container status is updated.
 			}
-		}
 
-		err = c.waitContainerStop(ctx, container, timeout)
-		if err == nil {
-			return nil
+			err = c.waitContainerStop(ctx, container, wait)
+			if err == nil {
+				return nil
+			}
+			log.Container.WithError(err).Errorf("Stop container %q with signal %v timed out", id, step.signal)
+		}
+		// SIGKILL is sent at the end of the grace period, even if the
+		// timeouts of all steps are shorter than it.
+		if wait := time.Until(deadline); wait > 0 {
+			if err := c.waitContainerStop(ctx, container, wait); err == nil {
+				return nil
+			}
 		}
-		logrus.WithError(err).Errorf("Stop container %q timed out", id)
 	}
 
 	task, err := container.Container.Task(ctx, nil)
@@ -112,7 +117,7 @@ func (c *criService) stopContainer(ctx context.Context, container containerstore
 		return nil
 	}
 	// Event handler will Delete the container from containerd after it handles the Exited event.
-	logrus.Infof("Kill container %q", id)
+	log.Container.Infof("Kill container %q", id)
 	if task != nil {
 		if err = task.Kill(ctx, unix.SIGKILL, containerd.WithKillAll); err != nil {
 			if !errdefs.IsNotFound(err) {
@@ -25,11 +25,10 @@ import (
 	"github.com/containerd/typeurl"
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
-	ctrdutil "github.com/containerd/cri/pkg/containerd/util"
+	"github.com/containerd/cri/pkg/log"
 	containerstore "github.com/containerd/cri/pkg/store/container"
 	"github.com/containerd/cri/pkg/util"
 )
@@ -43,11 +42,24 @@ func (c *criService) UpdateContainerResources(ctx context.Context, r *runtime.Up
 	// Update resources in status update transaction, so that:
 	// 1) There won't be race condition with container start.
 	// 2) There won't be concurrent resource update to the same container.
-	if err := container.Status.Update(func(status containerstore.Status) (containerstore.Status, error) {
-		return status, c.updateContainerResources(ctx, container, r.GetLinux(), status)
+	// The updated resources are checkpointed in container status, so that
+	// they survive restart.
+	if err := container.Status.UpdateSync(func(status containerstore.Status) (containerstore.Status, error) {
+		if err := c.updateContainerResources(ctx, container, r.GetLinux(), status); err != nil {
+			return status, err
+		}
+		old := status.Resources
+		if old == nil {
+			old = container.Config.GetLinux().GetResources()
+		}
+		status.Resources = mergeResources(old, r.GetLinux())
+		return status, nil
 	}); err != nil {
 		return nil, errors.Wrap(err, "failed to update resources")
 	}
+	if err := c.updatePodCgroup(container.SandboxID); err != nil {
+		return nil, errors.Wrapf(err, "failed to update pod cgroup of sandbox %q", container.SandboxID)
+	}
 	return &runtime.UpdateContainerResourcesResponse{}, nil
 }
 
@@ -73,17 +85,31 @@ func (c *criService) updateContainerResources(ctx context.Context,
 	if err != nil {
 		return errors.Wrap(err, "failed to update resource in spec")
 	}
+	newSpec, err = c.specHooks.adjustSpec(ctx, specHookRequest{
+		Event:       specHookUpdateContainer,
+		ID:          id,
+		SandboxID:   cntr.SandboxID,
+		Labels:      cntr.Config.GetLabels(),
+		Annotations: cntr.Config.GetAnnotations(),
+		Spec:        newSpec,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to adjust spec")
+	}
+	if newSpec.Linux == nil {
+		return errors.New("adjusted spec has no linux config")
+	}
 
 	if err := updateContainerSpec(ctx, cntr.Container, newSpec); err != nil {
 		return err
 	}
 	defer func() {
 		if retErr != nil {
-			deferCtx, deferCancel := ctrdutil.DeferContext()
+			deferCtx, deferCancel := c.deferContext()
 			defer deferCancel()
 			// Reset spec on error.
 			if err := updateContainerSpec(deferCtx, cntr.Container, oldSpec); err != nil {
-				logrus.WithError(err).Errorf("Failed to update spec %+v for container %q", oldSpec, id)
+				log.Container.WithError(err).Errorf("Failed to update spec %+v for container %q", oldSpec, id)
 			}
 		}
 	}()
@@ -110,6 +136,9 @@ func (c *criService) updateContainerResources(ctx context.Context,
 		}
 		return errors.Wrap(err, "failed to update resources")
 	}
+	if err := c.setCgroupV2Resources(ctx, cntr.Container); err != nil {
+		return errors.Wrap(err, "failed to update cgroup v2 resources")
+	}
 	return nil
 }
 
@@ -159,3 +188,32 @@ func updateOCILinuxResource(spec *runtimespec.Spec, new *runtime.LinuxContainerR
 
 	return g.Config, nil
 }
+
+// mergeResources returns the container resource limits after applying new
+// resource limits. Unset fields in new resource limits are not updated, which
+// is the same with updateOCILinuxResource.
+func mergeResources(old, new *runtime.LinuxContainerResources) *runtime.LinuxContainerResources {
+	merged := &runtime.LinuxContainerResources{}
+	if old != nil {
+		*merged = *old
+	}
+	if new.GetCpuPeriod() != 0 {
+		merged.CpuPeriod = new.GetCpuPeriod()
+	}
+	if new.GetCpuQuota() != 0 {
+		merged.CpuQuota = new.GetCpuQuota()
+	}
+	if new.GetCpuShares() != 0 {
+		merged.CpuShares = new.GetCpuShares()
+	}
+	if new.GetMemoryLimitInBytes() != 0 {
+		merged.MemoryLimitInBytes = new.GetMemoryLimitInBytes()
+	}
+	if new.GetCpusetCpus() != "" {
+		merged.CpusetCpus = new.GetCpusetCpus()
+	}
+	if new.GetCpusetMems() != "" {
+		merged.CpusetMems = new.GetCpusetMems()
+	}
+	return merged
+}
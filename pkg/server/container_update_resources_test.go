@@ -159,3 +159,67 @@ func TestUpdateOCILinuxResource(t *testing.T) {
 		assert.Equal(t, test.expected, got)
 	}
 }
+
+func TestMergeResources(t *testing.T) {
+	for desc, test := range map[string]struct {
+		old      *runtime.LinuxContainerResources
+		new      *runtime.LinuxContainerResources
+		expected *runtime.LinuxContainerResources
+	}{
+		"should update all set fields": {
+			old: &runtime.LinuxContainerResources{
+				CpuPeriod:          1111,
+				CpuQuota:           2222,
+				CpuShares:          3333,
+				MemoryLimitInBytes: 54321,
+				OomScoreAdj:        500,
+				CpusetCpus:         "0-1",
+				CpusetMems:         "2-3",
+			},
+			new: &runtime.LinuxContainerResources{
+				CpuPeriod:          6666,
+				CpuQuota:           5555,
+				CpuShares:          4444,
+				MemoryLimitInBytes: 12345,
+				CpusetCpus:         "4-5",
+				CpusetMems:         "6-7",
+			},
+			expected: &runtime.LinuxContainerResources{
+				CpuPeriod:          6666,
+				CpuQuota:           5555,
+				CpuShares:          4444,
+				MemoryLimitInBytes: 12345,
+				OomScoreAdj:        500,
+				CpusetCpus:         "4-5",
+				CpusetMems:         "6-7",
+			},
+		},
+		"should skip unset fields": {
+			old: &runtime.LinuxContainerResources{
+				CpuPeriod:          1111,
+				CpuQuota:           2222,
+				MemoryLimitInBytes: 54321,
+			},
+			new: &runtime.LinuxContainerResources{
+				CpuQuota: 5555,
+			},
+			expected: &runtime.LinuxContainerResources{
+				CpuPeriod:          1111,
+				CpuQuota:           5555,
+				MemoryLimitInBytes: 54321,
+			},
+		},
+		"should handle nil old resources": {
+			new: &runtime.LinuxContainerResources{
+				MemoryLimitInBytes: 12345,
+			},
+			expected: &runtime.LinuxContainerResources{
+				MemoryLimitInBytes: 12345,
+			},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		merged := mergeResources(test.old, test.new)
+		assert.Equal(t, test.expected, merged)
+	}
+}
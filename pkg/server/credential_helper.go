@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+const (
+	// credentialHelperPrefix is the prefix of the binary name of docker
+	// credential helpers.
+	credentialHelperPrefix = "docker-credential-"
+	// credentialHelperTimeout is the timeout of a credential helper call.
+	credentialHelperTimeout = 30 * time.Second
+	// credentialsNotFound is printed by a credential helper which has no
+	// credentials of the registry.
+	credentialsNotFound = "credentials not found in native keychain"
+	// identityTokenUsername is the username returned by a credential helper
+	// if the secret is an identity token.
+	identityTokenUsername = "<token>"
+)
+
+// credentialHelperOutput is the output of the "get" command of a docker
+// credential helper.
+type credentialHelperOutput struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// getHelperCredentials gets the credentials of a registry host from a docker
+// credential helper. Empty credentials are returned if the helper has none.
+func getHelperCredentials(ctx context.Context, helper, host string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, credentialHelperTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, credentialHelperPrefix+helper, "get")
+	cmd.Stdin = strings.NewReader(host)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if strings.Contains(stdout.String(), credentialsNotFound) {
+			return "", "", nil
+		}
+		return "", "", errors.Wrapf(err, "credential helper %q failed for %q with stderr %q",
+			helper, host, bytes.TrimSpace(stderr.Bytes()))
+	}
+	var out credentialHelperOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return "", "", errors.Wrapf(err, "failed to unmarshal output of credential helper %q", helper)
+	}
+	// An empty username makes the resolver use the secret as a token.
+	if out.Username == identityTokenUsername {
+		return "", out.Secret, nil
+	}
+	return out.Username, out.Secret, nil
+}
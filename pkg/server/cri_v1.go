@@ -0,0 +1,103 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"reflect"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+const (
+	// criV1RuntimeService and criV1ImageService are the services of the
+	// stable CRI v1 API.
+	criV1RuntimeService = "runtime.v1.RuntimeService"
+	criV1ImageService   = "runtime.v1.ImageService"
+	// criV1APIVersion is the runtime api version returned to CRI v1 clients.
+	criV1APIVersion = "v1"
+)
+
+// registerCRIV1 registers the CRI v1 RuntimeService and ImageService backed
+// by the v1alpha2 implementation. The messages of CRI v1 are wire compatible
+// with v1alpha2: v1 started as a copy of v1alpha2, and fields are only added
+// to both, never renumbered. So a v1 request is converted by decoding it into
+// the v1alpha2 message, which drops the fields this version doesn't know, and
+// a v1alpha2 response is a valid v1 response. Methods only in v1 return
+// Unimplemented.
+func registerCRIV1(s *grpc.Server, srv interface{}) {
+	runtimeDesc := criV1ServiceDesc(criV1RuntimeService, (*runtime.RuntimeServiceServer)(nil))
+	imageDesc := criV1ServiceDesc(criV1ImageService, (*runtime.ImageServiceServer)(nil))
+	s.RegisterService(&runtimeDesc, srv)
+	s.RegisterService(&imageDesc, srv)
+}
+
+// criV1ServiceDesc returns the service description of a CRI v1 service with
+// the methods of the v1alpha2 server interface. CRI has no streaming rpcs.
+func criV1ServiceDesc(name string, handlerType interface{}) grpc.ServiceDesc {
+	desc := grpc.ServiceDesc{
+		ServiceName: name,
+		HandlerType: handlerType,
+		Streams:     []grpc.StreamDesc{},
+		Metadata:    "api.proto",
+	}
+	iface := reflect.TypeOf(handlerType).Elem()
+	for i := 0; i < iface.NumMethod(); i++ {
+		method := iface.Method(i)
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: method.Name,
+			Handler:    criV1UnaryHandler(name, method),
+		})
+	}
+	return desc
+}
+
+// criV1UnaryHandler returns the grpc handler of a unary CRI v1 method, which
+// decodes the request into the v1alpha2 request and calls the v1alpha2 method
+// of the server.
+func criV1UnaryHandler(service string, method reflect.Method) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	// The method of an interface type has no receiver, the arguments are
+	// the context and the request.
+	reqType := method.Type.In(1).Elem()
+	fullMethod := "/" + service + "/" + method.Name
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := reflect.New(reqType).Interface()
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			out := reflect.ValueOf(srv).MethodByName(method.Name).Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(req)})
+			err, _ := out[1].Interface().(error)
+			if err != nil {
+				return nil, err
+			}
+			res := out[0].Interface()
+			if version, ok := res.(*runtime.VersionResponse); ok && version != nil {
+				// Report the api version the client talks.
+				v := *version
+				v.RuntimeApiVersion = criV1APIVersion
+				res = &v
+			}
+			return res, nil
+		}
+		if interceptor == nil {
+			return handler(ctx, req)
+		}
+		return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}, handler)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// fakeCRIV1Server implements some of the v1alpha2 runtime service methods.
+type fakeCRIV1Server struct {
+	versionRequest *runtime.VersionRequest
+}
+
+func (f *fakeCRIV1Server) Version(ctx context.Context, r *runtime.VersionRequest) (*runtime.VersionResponse, error) {
+	f.versionRequest = r
+	return &runtime.VersionResponse{Version: "0.1.0", RuntimeApiVersion: "v1alpha2"}, nil
+}
+
+func (f *fakeCRIV1Server) StopPodSandbox(ctx context.Context, r *runtime.StopPodSandboxRequest) (*runtime.StopPodSandboxResponse, error) {
+	return nil, errors.New("test error")
+}
+
+func TestCRIV1ServiceDesc(t *testing.T) {
+	desc := criV1ServiceDesc(criV1RuntimeService, (*runtime.RuntimeServiceServer)(nil))
+	assert.Equal(t, "runtime.v1.RuntimeService", desc.ServiceName)
+	handlers := make(map[string]grpc.MethodDesc)
+	for _, m := range desc.Methods {
+		handlers[m.MethodName] = m
+	}
+	for _, name := range []string{"Version", "RunPodSandbox", "CreateContainer", "ExecSync", "UpdateRuntimeConfig"} {
+		assert.Contains(t, handlers, name)
+	}
+	srv := &fakeCRIV1Server{}
+
+	t.Logf("should decode the request and report the v1 api version")
+	res, err := handlers["Version"].Handler(srv, context.Background(), func(req interface{}) error {
+		*req.(*runtime.VersionRequest) = runtime.VersionRequest{Version: "0.1.0"}
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "0.1.0", srv.versionRequest.Version)
+	assert.Equal(t, &runtime.VersionResponse{Version: "0.1.0", RuntimeApiVersion: criV1APIVersion}, res)
+
+	t.Logf("should return the error of the method")
+	_, err = handlers["StopPodSandbox"].Handler(srv, context.Background(), func(interface{}) error { return nil }, nil)
+	assert.EqualError(t, err, "test error")
+
+	t.Logf("should call the interceptor with the v1 method name")
+	var fullMethod string
+	_, err = handlers["Version"].Handler(srv, context.Background(), func(interface{}) error { return nil },
+		func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			fullMethod = info.FullMethod
+			return handler(ctx, req)
+		})
+	require.NoError(t, err)
+	assert.Equal(t, "/runtime.v1.RuntimeService/Version", fullMethod)
+
+	imageDesc := criV1ServiceDesc(criV1ImageService, (*runtime.ImageServiceServer)(nil))
+	assert.Equal(t, "runtime.v1.ImageService", imageDesc.ServiceName)
+	assert.NotEmpty(t, imageDesc.Methods)
+}
+
+func TestRegisterCRIV1(t *testing.T) {
+	s := grpc.NewServer()
+	defer s.Stop()
+	registerCRIV1(s, newInstrumentedService(newTestCRIService()))
+	info := s.GetServiceInfo()
+	assert.Contains(t, info, criV1RuntimeService)
+	assert.Contains(t, info, criV1ImageService)
+}
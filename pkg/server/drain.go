@@ -0,0 +1,124 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+)
+
+// drainTimeout is the maximum time SetDrain waits for the in-flight requests
+// creating pod sandboxes or containers, if the request has no earlier
+// deadline.
+const drainTimeout = 2 * time.Minute
+
+// drainTracker tracks drain mode and the in-flight requests rejected in drain
+// mode, so that entering drain mode can wait for them to finish.
+type drainTracker struct {
+	mu       sync.Mutex
+	draining bool
+	inflight int
+	// idle is closed when the last in-flight request finishes, nil if
+	// nobody waits for it.
+	idle chan struct{}
+}
+
+// start registers a request creating a pod sandbox or container, and returns
+// a function to call when it finishes. It returns a retriable error in drain
+// mode.
+func (d *drainTracker) start() (func(), error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return nil, status.Error(codes.Unavailable, "server is draining, retry later")
+	}
+	d.inflight++
+	return d.done, nil
+}
+
+func (d *drainTracker) done() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inflight--
+	if d.inflight == 0 && d.idle != nil {
+		close(d.idle)
+		d.idle = nil
+	}
+}
+
+func (d *drainTracker) setDraining(draining bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.draining = draining
+}
+
+func (d *drainTracker) isDraining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// wait waits until no request is in flight or the context is done.
+func (d *drainTracker) wait(ctx context.Context) error {
+	d.mu.Lock()
+	if d.inflight == 0 {
+		d.mu.Unlock()
+		return nil
+	}
+	if d.idle == nil {
+		d.idle = make(chan struct{})
+	}
+	idle := d.idle
+	d.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetDrain puts the cri plugin into or out of drain mode. In drain mode,
+// new pod sandboxes and containers are rejected with a retriable error,
+// while existing ones can still be stopped and removed. Entering drain mode
+// returns once the in-flight requests creating pod sandboxes or containers
+// finish, so that the plugin is quiesced. If they don't finish in time, the
+// plugin stays in drain mode and an error is returned. Operators use it to
+// restart containerd without racing new pod creations.
+func (c *criService) SetDrain(ctx context.Context, r *api.SetDrainRequest) (*api.SetDrainResponse, error) {
+	if !r.GetDrain() {
+		c.drain.setDraining(false)
+		logrus.Info("CRI plugin leaves drain mode")
+		return &api.SetDrainResponse{}, nil
+	}
+	c.drain.setDraining(true)
+	logrus.Info("CRI plugin enters drain mode")
+	ctx, cancel := context.WithTimeout(ctx, drainTimeout)
+	defer cancel()
+	if err := c.drain.wait(ctx); err != nil {
+		return nil, status.Errorf(codes.DeadlineExceeded, "in-flight requests didn't finish in drain mode: %v", err)
+	}
+	logrus.Info("CRI plugin is drained")
+	return &api.SetDrainResponse{}, nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+	"github.com/containerd/cri/pkg/atomic"
+)
+
+func TestDrain(t *testing.T) {
+	c := newTestCRIService()
+	c.initialized = atomic.NewBool(true)
+	in := newInstrumentedService(c)
+	ctx := context.Background()
+
+	t.Logf("should reject new pod sandboxes and containers in drain mode")
+	_, err := in.SetDrain(ctx, &api.SetDrainRequest{Drain: true})
+	require.NoError(t, err)
+	_, err = in.RunPodSandbox(ctx, &runtime.RunPodSandboxRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = in.CreateContainer(ctx, &runtime.CreateContainerRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	t.Logf("should not reject stops and removals in drain mode")
+	_, err = in.StopPodSandbox(ctx, &runtime.StopPodSandboxRequest{PodSandboxId: "non-exist"})
+	assert.NotEqual(t, codes.Unavailable, status.Code(err))
+	_, err = in.RemoveContainer(ctx, &runtime.RemoveContainerRequest{ContainerId: "non-exist"})
+	assert.NotEqual(t, codes.Unavailable, status.Code(err))
+
+	t.Logf("should accept new pod sandboxes and containers after leaving drain mode")
+	_, err = in.SetDrain(ctx, &api.SetDrainRequest{Drain: false})
+	require.NoError(t, err)
+	done, err := c.drain.start()
+	require.NoError(t, err)
+
+	t.Logf("should fail to drain when in-flight requests don't finish in time")
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = in.SetDrain(timeoutCtx, &api.SetDrainRequest{Drain: true})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.True(t, c.drain.isDraining(), "should stay in drain mode")
+
+	t.Logf("should wait for in-flight requests when entering drain mode")
+	drained := make(chan error, 1)
+	go func() {
+		_, err := in.SetDrain(ctx, &api.SetDrainRequest{Drain: true})
+		drained <- err
+	}()
+	select {
+	case err := <-drained:
+		t.Fatalf("drain returned before in-flight requests finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	done()
+	select {
+	case err := <-drained:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("drain didn't return after in-flight requests finished")
+	}
+}
@@ -17,6 +17,11 @@ limitations under the License.
 package server
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -25,13 +30,15 @@ import (
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/events"
 	"github.com/containerd/typeurl"
+	"github.com/docker/docker/pkg/ioutils"
 	gogotypes "github.com/gogo/protobuf/types"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"k8s.io/apimachinery/pkg/util/clock"
 
+	api "github.com/containerd/cri/pkg/api/v1"
 	ctrdutil "github.com/containerd/cri/pkg/containerd/util"
+	"github.com/containerd/cri/pkg/log"
 	"github.com/containerd/cri/pkg/store"
 	containerstore "github.com/containerd/cri/pkg/store/container"
 	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
@@ -54,6 +61,50 @@ type eventMonitor struct {
 	ctx            context.Context
 	cancel         context.CancelFunc
 	backOff        *backOff
+	// containerEvents publishes stop events of containers and sandboxes.
+	containerEvents *containerEventBroadcaster
+	// namespace is the containerd namespace of the monitored containers.
+	// Events of other namespaces are ignored.
+	namespace string
+	// backlogPath is the file persisting the events in backoff, so that
+	// they are replayed after restart. The backlog is not persisted if it
+	// is empty.
+	backlogPath string
+	// checkpointPath is the file persisting the event checkpoint.
+	checkpointPath string
+	// checkpointMu protects checkpoint.
+	checkpointMu sync.Mutex
+	// checkpoint is the latest event checkpoint. The event being handled is
+	// persisted before it is handled, the progress after it is persisted off
+	// the event loop, and changes made during a write are coalesced into the
+	// next write.
+	checkpoint eventCheckpoint
+	// checkpointCh notifies the checkpoint writer of checkpoint changes.
+	checkpointCh chan struct{}
+	// checkpointWriteMu serializes checkpoint writes, so that an older
+	// checkpoint never overwrites a newer one.
+	checkpointWriteMu sync.Mutex
+	// missedExitsMu protects missedExits.
+	missedExitsMu sync.Mutex
+	// missedExits are the exits of tasks which stopped while the cri plugin
+	// was down, found during recovery.
+	missedExits []*eventtypes.TaskExit
+}
+
+// eventCheckpoint is the persisted progress of event handling. The event
+// being handled is persisted before its handling starts, so that it is
+// replayed after restart if the handling is interrupted, e.g. a task is
+// deleted but the container exit is not checkpointed yet. Containerd doesn't
+// keep events, so the exit events missed while the cri plugin is down are
+// rebuilt from the stopped tasks found during recovery, and replayed from the
+// checkpointed timestamp.
+type eventCheckpoint struct {
+	// Timestamp is the timestamp of the last handled event.
+	Timestamp time.Time `json:"timestamp"`
+	// Inflight is the event being handled.
+	Inflight *gogotypes.Any `json:"inflight,omitempty"`
+	// InflightTimestamp is the timestamp of the event being handled.
+	InflightTimestamp time.Time `json:"inflightTimestamp,omitempty"`
 }
 
 type backOff struct {
@@ -76,15 +127,20 @@ type backOffQueue struct {
 
 // Create new event monitor. New event monitor will start subscribing containerd event. All events
 // happen after it should be monitored.
-func newEventMonitor(c *containerstore.Store, s *sandboxstore.Store) *eventMonitor {
+func newEventMonitor(c *containerstore.Store, s *sandboxstore.Store, containerEvents *containerEventBroadcaster, namespace, backlogPath, checkpointPath string) *eventMonitor {
 	// event subscribe doesn't need namespace.
 	ctx, cancel := context.WithCancel(context.Background())
 	return &eventMonitor{
-		containerStore: c,
-		sandboxStore:   s,
-		ctx:            ctx,
-		cancel:         cancel,
-		backOff:        newBackOff(),
+		containerStore:  c,
+		sandboxStore:    s,
+		ctx:             ctx,
+		cancel:          cancel,
+		backOff:         newBackOff(),
+		containerEvents: containerEvents,
+		namespace:       namespace,
+		backlogPath:     backlogPath,
+		checkpointPath:  checkpointPath,
+		checkpointCh:    make(chan struct{}, 1),
 	}
 }
 
@@ -94,6 +150,11 @@ func (em *eventMonitor) subscribe(subscriber events.Subscriber) {
 		`topic=="/tasks/exit"`,
 		`topic=="/tasks/oom"`,
 	}
+	if em.namespace != "" {
+		for i := range filters {
+			filters[i] = fmt.Sprintf(`namespace==%q,%s`, em.namespace, filters[i])
+		}
+	}
 	em.ch, em.errCh = subscriber.Subscribe(em.ctx, filters...)
 }
 
@@ -124,30 +185,37 @@ func (em *eventMonitor) start() <-chan error {
 		panic("event channel is nil")
 	}
 	backOffCheckCh := em.backOff.start()
+	if em.checkpointPath != "" {
+		go em.checkpointLoop()
+	}
 	go func() {
 		defer close(errCh)
 		for {
 			select {
 			case e := <-em.ch:
-				logrus.Debugf("Received containerd event timestamp - %v, namespace - %q, topic - %q", e.Timestamp, e.Namespace, e.Topic)
+				log.Events.Debugf("Received containerd event timestamp - %v, namespace - %q, topic - %q", e.Timestamp, e.Namespace, e.Topic)
 				cID, evt, err := convertEvent(e.Event)
 				if err != nil {
-					logrus.WithError(err).Errorf("Failed to convert event %+v", e)
+					log.Events.WithError(err).Errorf("Failed to convert event %+v", e)
 					break
 				}
 				if em.backOff.isInBackOff(cID) {
-					logrus.Infof("Events for container %q is in backoff, enqueue event %+v", cID, evt)
+					log.Events.Infof("Events for container %q is in backoff, enqueue event %+v", cID, evt)
 					em.backOff.enBackOff(cID, evt)
+					em.saveBacklog()
 					break
 				}
-				if err := em.handleEvent(evt); err != nil {
-					logrus.WithError(err).Errorf("Failed to handle event %+v for container %s", evt, cID)
+				em.checkpointInflight(e)
+				if err := em.handleEvent(evt, e.Timestamp); err != nil {
+					log.Events.WithError(err).Errorf("Failed to handle event %+v for container %s", evt, cID)
 					em.backOff.enBackOff(cID, evt)
+					em.saveBacklog()
 				}
+				em.checkpointHandled(e.Timestamp)
 			case err := <-em.errCh:
 				// Close errCh in defer directly if there is no error.
 				if err != nil {
-					logrus.WithError(err).Errorf("Failed to handle event stream")
+					log.Events.WithError(err).Errorf("Failed to handle event stream")
 					errCh <- err
 				}
 				return
@@ -156,19 +224,253 @@ func (em *eventMonitor) start() <-chan error {
 				for _, cID := range cIDs {
 					queue := em.backOff.deBackOff(cID)
 					for i, any := range queue.events {
-						if err := em.handleEvent(any); err != nil {
-							logrus.WithError(err).Errorf("Failed to handle backOff event %+v for container %s", any, cID)
+						if err := em.handleEvent(any, time.Time{}); err != nil {
+							log.Events.WithError(err).Errorf("Failed to handle backOff event %+v for container %s", any, cID)
 							em.backOff.reBackOff(cID, queue.events[i:], queue.duration)
 							break
 						}
 					}
 				}
+				if len(cIDs) > 0 {
+					em.saveBacklog()
+				}
 			}
 		}
 	}()
 	return errCh
 }
 
+// replayBacklog handles the events persisted in the backlog before restart,
+// e.g. exit events which failed to be handled. Events failing again are put
+// back into backoff. It must be called after recovery and before start.
+func (em *eventMonitor) replayBacklog() error {
+	backlog, err := em.loadBacklog()
+	if err != nil {
+		return err
+	}
+	for cID, evts := range backlog {
+		for i, evt := range evts {
+			log.Events.Infof("Replay event %+v for container %q", evt, cID)
+			if err := em.handleEvent(evt, time.Time{}); err != nil {
+				log.Events.WithError(err).Errorf("Failed to handle replayed event %+v for container %s", evt, cID)
+				for _, e := range evts[i:] {
+					em.backOff.enBackOff(cID, e)
+				}
+				break
+			}
+		}
+	}
+	em.saveBacklog()
+	return nil
+}
+
+// recordMissedExit records the exit of a task which stopped while the cri
+// plugin was down, to be replayed by replayCheckpoint. It is called during
+// recovery.
+func (em *eventMonitor) recordMissedExit(e *eventtypes.TaskExit) {
+	em.missedExitsMu.Lock()
+	defer em.missedExitsMu.Unlock()
+	em.missedExits = append(em.missedExits, e)
+}
+
+// replayCheckpoint handles the event whose handling is interrupted by
+// restart, and then the exits missed since the checkpointed timestamp in
+// the order they happened. It must be called after recovery and before
+// start.
+func (em *eventMonitor) replayCheckpoint() error {
+	cp, err := em.loadCheckpoint()
+	if err != nil {
+		// The missed exits are still replayed, the task states don't depend
+		// on the checkpoint.
+		log.Events.WithError(err).Error("Failed to load event checkpoint")
+	}
+	timestamp := cp.Timestamp
+	if cp.Inflight != nil {
+		cID, evt, err := convertEvent(cp.Inflight)
+		if err != nil {
+			log.Events.WithError(err).Error("Failed to convert inflight event")
+		} else {
+			log.Events.Infof("Replay interrupted event %+v for container %q", evt, cID)
+			em.replayEvent(cID, evt, cp.InflightTimestamp)
+		}
+		timestamp = cp.InflightTimestamp
+	}
+
+	em.missedExitsMu.Lock()
+	exits := em.missedExits
+	em.missedExits = nil
+	em.missedExitsMu.Unlock()
+	sort.Slice(exits, func(i, j int) bool {
+		return exits[i].ExitedAt.Before(exits[j].ExitedAt)
+	})
+	for _, e := range exits {
+		if e.ExitedAt.After(timestamp) {
+			log.Events.Infof("Replay exit event %+v missed since %v", e, timestamp)
+			timestamp = e.ExitedAt
+		} else {
+			// The exit is older than the last handled event, i.e. its
+			// event was handled but the task was not deleted.
+			log.Events.Infof("Replay exit event %+v of undeleted task", e)
+		}
+		em.replayEvent(e.ContainerID, e, e.ExitedAt)
+	}
+	em.checkpointHandled(timestamp)
+	em.saveCheckpoint()
+	return nil
+}
+
+// replayEvent handles a replayed event, and puts it into backoff if it fails.
+func (em *eventMonitor) replayEvent(cID string, evt interface{}, timestamp time.Time) {
+	if err := em.handleEvent(evt, timestamp); err != nil {
+		log.Events.WithError(err).Errorf("Failed to handle replayed event %+v for container %s", evt, cID)
+		em.backOff.enBackOff(cID, evt)
+		em.saveBacklog()
+	}
+}
+
+// loadCheckpoint loads the persisted event checkpoint. It returns an empty
+// checkpoint if there is none.
+func (em *eventMonitor) loadCheckpoint() (eventCheckpoint, error) {
+	var cp eventCheckpoint
+	if em.checkpointPath == "" {
+		return cp, nil
+	}
+	data, err := ioutil.ReadFile(em.checkpointPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cp, nil
+		}
+		return cp, errors.Wrapf(err, "failed to read event checkpoint %q", em.checkpointPath)
+	}
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return eventCheckpoint{}, errors.Wrapf(err, "failed to unmarshal event checkpoint %q", em.checkpointPath)
+	}
+	return cp, nil
+}
+
+// checkpointInflight records the event being handled in the checkpoint, and
+// persists it before the event is handled, so that the event is not lost if
+// the plugin crashes while handling it.
+func (em *eventMonitor) checkpointInflight(e *events.Envelope) {
+	em.checkpointMu.Lock()
+	em.checkpoint.Inflight = e.Event
+	em.checkpoint.InflightTimestamp = e.Timestamp
+	em.checkpointMu.Unlock()
+	em.saveCheckpoint()
+}
+
+// checkpointHandled records the timestamp of the last handled event in the
+// checkpoint, and clears the event being handled.
+func (em *eventMonitor) checkpointHandled(timestamp time.Time) {
+	em.checkpointMu.Lock()
+	em.checkpoint = eventCheckpoint{Timestamp: timestamp}
+	em.checkpointMu.Unlock()
+	em.notifyCheckpoint()
+}
+
+// notifyCheckpoint notifies the checkpoint writer without blocking, a pending
+// notification already covers the change.
+func (em *eventMonitor) notifyCheckpoint() {
+	select {
+	case em.checkpointCh <- struct{}{}:
+	default:
+	}
+}
+
+// checkpointLoop persists the checkpoint on changes until the event monitor
+// is stopped.
+func (em *eventMonitor) checkpointLoop() {
+	for {
+		select {
+		case <-em.checkpointCh:
+			em.saveCheckpoint()
+		case <-em.ctx.Done():
+			em.saveCheckpoint()
+			return
+		}
+	}
+}
+
+// saveCheckpoint persists the latest checkpoint. Errors are only logged,
+// because the checkpoint is best effort.
+func (em *eventMonitor) saveCheckpoint() {
+	if em.checkpointPath == "" {
+		return
+	}
+	em.checkpointWriteMu.Lock()
+	defer em.checkpointWriteMu.Unlock()
+	em.checkpointMu.Lock()
+	data, err := json.Marshal(&em.checkpoint)
+	em.checkpointMu.Unlock()
+	if err != nil {
+		log.Events.WithError(err).Error("Failed to marshal event checkpoint")
+		return
+	}
+	if err := ioutils.AtomicWriteFile(em.checkpointPath, data, 0600); err != nil {
+		log.Events.WithError(err).Errorf("Failed to write event checkpoint %q", em.checkpointPath)
+	}
+}
+
+// loadBacklog loads the events persisted in the backlog.
+func (em *eventMonitor) loadBacklog() (map[string][]interface{}, error) {
+	data, err := ioutil.ReadFile(em.backlogPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read event backlog %q", em.backlogPath)
+	}
+	var anys map[string][]*gogotypes.Any
+	if err := json.Unmarshal(data, &anys); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal event backlog %q", em.backlogPath)
+	}
+	backlog := make(map[string][]interface{})
+	for cID, as := range anys {
+		for _, a := range as {
+			evt, err := typeurl.UnmarshalAny(a)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to unmarshal event for container %q", cID)
+			}
+			backlog[cID] = append(backlog[cID], evt)
+		}
+	}
+	return backlog, nil
+}
+
+// saveBacklog persists the events in backoff, or removes the backlog if
+// there is none. Errors are only logged, the events are still retried
+// until restart.
+func (em *eventMonitor) saveBacklog() {
+	if em.backlogPath == "" {
+		return
+	}
+	if len(em.backOff.queuePool) == 0 {
+		if err := os.Remove(em.backlogPath); err != nil && !os.IsNotExist(err) {
+			log.Events.WithError(err).Errorf("Failed to remove event backlog %q", em.backlogPath)
+		}
+		return
+	}
+	anys := make(map[string][]*gogotypes.Any)
+	for cID, queue := range em.backOff.queuePool {
+		for _, evt := range queue.events {
+			a, err := typeurl.MarshalAny(evt)
+			if err != nil {
+				log.Events.WithError(err).Errorf("Failed to marshal event %+v for container %q", evt, cID)
+				continue
+			}
+			anys[cID] = append(anys[cID], a)
+		}
+	}
+	data, err := json.Marshal(anys)
+	if err != nil {
+		log.Events.WithError(err).Error("Failed to marshal event backlog")
+		return
+	}
+	if err := ioutils.AtomicWriteFile(em.backlogPath, data, 0600); err != nil {
+		log.Events.WithError(err).Errorf("Failed to write event backlog %q", em.backlogPath)
+	}
+}
+
 // stop stops the event monitor. It will close the event channel.
 // Once event monitor is stopped, it can't be started.
 func (em *eventMonitor) stop() {
@@ -176,9 +478,11 @@ func (em *eventMonitor) stop() {
 	em.cancel()
 }
 
-// handleEvent handles a containerd event.
-func (em *eventMonitor) handleEvent(any interface{}) error {
-	ctx := ctrdutil.NamespacedContext()
+// handleEvent handles a containerd event. The timestamp is the time the event
+// is published, which is zero for events in backoff or the backlog, because
+// they are queued without their envelope.
+func (em *eventMonitor) handleEvent(any interface{}, timestamp time.Time) error {
+	ctx := ctrdutil.NamespacedContext(em.namespace)
 	switch any.(type) {
 	// If containerd-shim exits unexpectedly, there will be no corresponding event.
 	// However, containerd could not retrieve container state in that case, so it's
@@ -186,12 +490,13 @@ func (em *eventMonitor) handleEvent(any interface{}) error {
 	// TODO(random-liu): [P2] Handle containerd-shim exit.
 	case *eventtypes.TaskExit:
 		e := any.(*eventtypes.TaskExit)
-		logrus.Infof("TaskExit event %+v", e)
+		log.Events.Infof("TaskExit event %+v", e)
 		cntr, err := em.containerStore.Get(e.ContainerID)
 		if err == nil {
 			if err := handleContainerExit(ctx, e, cntr); err != nil {
 				return errors.Wrap(err, "failed to handle container TaskExit event")
 			}
+			em.containerEvents.publish(cntr.ID, cntr.SandboxID, api.ContainerEventType_CONTAINER_STOPPED_EVENT)
 			return nil
 		} else if err != store.ErrNotExist {
 			return errors.Wrap(err, "can't find container for TaskExit event")
@@ -202,6 +507,7 @@ func (em *eventMonitor) handleEvent(any interface{}) error {
 			if err := handleSandboxExit(ctx, e, sb); err != nil {
 				return errors.Wrap(err, "failed to handle sandbox TaskExit event")
 			}
+			em.containerEvents.publish(sb.ID, sb.ID, api.ContainerEventType_CONTAINER_STOPPED_EVENT)
 			return nil
 		} else if err != store.ErrNotExist {
 			return errors.Wrap(err, "can't find sandbox for TaskExit event")
@@ -209,7 +515,7 @@ func (em *eventMonitor) handleEvent(any interface{}) error {
 		return nil
 	case *eventtypes.TaskOOM:
 		e := any.(*eventtypes.TaskOOM)
-		logrus.Infof("TaskOOM event %+v", e)
+		log.Events.Infof("TaskOOM event %+v", e)
 		cntr, err := em.containerStore.Get(e.ContainerID)
 		if err != nil {
 			if err != store.ErrNotExist {
@@ -223,8 +529,15 @@ func (em *eventMonitor) handleEvent(any interface{}) error {
 			}
 			return nil
 		}
+		// The oom event is published by the shim when the oom kill is
+		// notified, so its timestamp is the time of the oom kill.
+		message := "Container was OOM killed"
+		if !timestamp.IsZero() {
+			message = fmt.Sprintf("%s at %s", message, timestamp.Format(time.RFC3339Nano))
+		}
 		err = cntr.Status.UpdateSync(func(status containerstore.Status) (containerstore.Status, error) {
 			status.Reason = oomExitReason
+			status.Message = message
 			return status, nil
 		})
 		if err != nil {
@@ -262,10 +575,15 @@ func handleContainerExit(ctx context.Context, e *eventtypes.TaskExit, cntr conta
 	}
 	err = cntr.Status.UpdateSync(func(status containerstore.Status) (containerstore.Status, error) {
 		// If FinishedAt has been set (e.g. with start failure), keep as
-		// it is.
-		if status.FinishedAt != 0 {
+		// it is. The unknown exit set during recovery is replaced, because
+		// the exit is only unknown when its event was not handled before
+		// restart.
+		if status.FinishedAt != 0 && status.Reason != unknownExitReason {
 			return status, nil
 		}
+		if status.Reason == unknownExitReason {
+			status.Reason = ""
+		}
 		status.Pid = 0
 		status.FinishedAt = e.ExitedAt.UnixNano()
 		status.ExitCode = int32(e.ExitStatus)
@@ -17,15 +17,23 @@ limitations under the License.
 package server
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	eventtypes "github.com/containerd/containerd/api/events"
-	//"github.com/containerd/containerd/api/services/events/v1"
+	"github.com/containerd/containerd/events"
 	"github.com/containerd/typeurl"
-	//gogotypes "github.com/gogo/protobuf/types"
+	gogotypes "github.com/gogo/protobuf/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/util/clock"
+
+	containerstore "github.com/containerd/cri/pkg/store/container"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
 )
 
 // TestBackOff tests the logic of backOff struct.
@@ -134,3 +142,161 @@ func TestBackOff(t *testing.T) {
 		assert.Equal(t, actQueue, expQueue)
 	}
 }
+
+// TestEventBacklog tests persisting and replaying the event backlog.
+func TestEventBacklog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-event-backlog")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	backlogPath := filepath.Join(dir, eventBacklogFile)
+	events := map[string][]interface{}{
+		"container1": {
+			&eventtypes.TaskOOM{ContainerID: "container1"},
+			&eventtypes.TaskExit{ContainerID: "container1", ID: "1", Pid: 1, ExitStatus: 137},
+		},
+		"container2": {
+			&eventtypes.TaskExit{ContainerID: "container2", ID: "2", Pid: 2, ExitStatus: 1},
+		},
+	}
+
+	t.Logf("Should persist the events in backoff")
+	em := newEventMonitor(containerstore.NewStore(), sandboxstore.NewStore(), newContainerEventBroadcaster(), "", backlogPath, "")
+	for cID, evts := range events {
+		for _, evt := range evts {
+			em.backOff.enBackOff(cID, evt)
+		}
+	}
+	em.saveBacklog()
+	_, err = os.Stat(backlogPath)
+	require.NoError(t, err)
+
+	t.Logf("Should load the persisted events after restart")
+	em = newEventMonitor(containerstore.NewStore(), sandboxstore.NewStore(), newContainerEventBroadcaster(), "", backlogPath, "")
+	backlog, err := em.loadBacklog()
+	require.NoError(t, err)
+	assert.Equal(t, events, backlog)
+
+	t.Logf("Should remove the backlog once the events are replayed")
+	require.NoError(t, em.replayBacklog())
+	assert.Empty(t, em.backOff.queuePool)
+	_, err = os.Stat(backlogPath)
+	assert.True(t, os.IsNotExist(err))
+
+	t.Logf("Should replay nothing without backlog")
+	assert.NoError(t, em.replayBacklog())
+}
+
+// TestEventCheckpoint tests replaying the event whose handling is interrupted.
+func TestEventCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-event-checkpoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	checkpointPath := filepath.Join(dir, eventCheckpointFile)
+	handled := time.Unix(100, 0)
+	evt, err := typeurl.MarshalAny(&eventtypes.TaskExit{ContainerID: "container1", Pid: 1})
+	require.NoError(t, err)
+
+	for desc, test := range map[string]struct {
+		inflight           *gogotypes.Any
+		missedExits        []time.Time
+		expectedCheckpoint time.Time
+	}{
+		"should replay the interrupted event": {
+			inflight:           evt,
+			expectedCheckpoint: handled.Add(time.Second),
+		},
+		"should replay nothing without interrupted event": {
+			expectedCheckpoint: handled,
+		},
+		"should replay the missed exits after the interrupted event": {
+			inflight:           evt,
+			missedExits:        []time.Time{handled.Add(3 * time.Second), handled.Add(2 * time.Second)},
+			expectedCheckpoint: handled.Add(3 * time.Second),
+		},
+		"should not move the checkpoint back for exits before it": {
+			missedExits:        []time.Time{handled.Add(-time.Second)},
+			expectedCheckpoint: handled,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		em := newEventMonitor(containerstore.NewStore(), sandboxstore.NewStore(), newContainerEventBroadcaster(), "", "", checkpointPath)
+		em.checkpoint = eventCheckpoint{Timestamp: handled}
+		if test.inflight != nil {
+			em.checkpoint.Inflight = test.inflight
+			em.checkpoint.InflightTimestamp = handled.Add(time.Second)
+		}
+		em.saveCheckpoint()
+
+		em = newEventMonitor(containerstore.NewStore(), sandboxstore.NewStore(), newContainerEventBroadcaster(), "", "", checkpointPath)
+		for _, exitedAt := range test.missedExits {
+			em.recordMissedExit(&eventtypes.TaskExit{ContainerID: "container2", Pid: 2, ExitedAt: exitedAt})
+		}
+		require.NoError(t, em.replayCheckpoint())
+		assert.Empty(t, em.missedExits)
+		assert.True(t, test.expectedCheckpoint.Equal(em.checkpoint.Timestamp))
+
+		t.Logf("should clear the inflight event after replay")
+		data, err := ioutil.ReadFile(checkpointPath)
+		require.NoError(t, err)
+		var cp eventCheckpoint
+		require.NoError(t, json.Unmarshal(data, &cp))
+		assert.Nil(t, cp.Inflight)
+		assert.True(t, test.expectedCheckpoint.Equal(cp.Timestamp))
+	}
+
+	t.Logf("should persist the inflight event before it is handled")
+	em := newEventMonitor(containerstore.NewStore(), sandboxstore.NewStore(), newContainerEventBroadcaster(), "", "", checkpointPath)
+	em.checkpointInflight(&events.Envelope{Timestamp: handled.Add(2 * time.Second), Event: evt})
+	data, err := ioutil.ReadFile(checkpointPath)
+	require.NoError(t, err)
+	var cp eventCheckpoint
+	require.NoError(t, json.Unmarshal(data, &cp))
+	assert.NotNil(t, cp.Inflight)
+	assert.True(t, handled.Add(2*time.Second).Equal(cp.InflightTimestamp))
+
+	t.Logf("should persist the handled checkpoint off the event loop")
+	go em.checkpointLoop()
+	em.checkpointHandled(handled.Add(2 * time.Second))
+	em.cancel()
+	for i := 0; i < 100; i++ {
+		data, err := ioutil.ReadFile(checkpointPath)
+		require.NoError(t, err)
+		cp = eventCheckpoint{}
+		require.NoError(t, json.Unmarshal(data, &cp))
+		if cp.Timestamp.Equal(handled.Add(2*time.Second)) && cp.Inflight == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, cp.Inflight)
+	assert.True(t, handled.Add(2*time.Second).Equal(cp.Timestamp))
+}
+
+func TestHandleTaskOOM(t *testing.T) {
+	containerStore := containerstore.NewStore()
+	cntr, err := containerstore.NewContainer(
+		containerstore.Metadata{ID: "container1"},
+		containerstore.WithFakeStatus(containerstore.Status{
+			Pid:       1,
+			CreatedAt: time.Now().UnixNano(),
+			StartedAt: time.Now().UnixNano(),
+		}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, containerStore.Add(cntr))
+	em := newEventMonitor(containerStore, sandboxstore.NewStore(), newContainerEventBroadcaster(), "", "", "")
+
+	t.Logf("should record oom killed reason and event time in container status")
+	oomKilledAt := time.Unix(100, 0)
+	require.NoError(t, em.handleEvent(&eventtypes.TaskOOM{ContainerID: "container1"}, oomKilledAt))
+	status := cntr.Status.Get()
+	assert.Equal(t, oomExitReason, status.Reason)
+	assert.Equal(t, "Container was OOM killed at "+oomKilledAt.Format(time.RFC3339Nano), status.Message)
+
+	t.Logf("should not record time of oom event without timestamp")
+	require.NoError(t, em.handleEvent(&eventtypes.TaskOOM{ContainerID: "container1"}, time.Time{}))
+	assert.Equal(t, "Container was OOM killed", cntr.Status.Get().Message)
+
+	t.Logf("should ignore oom event of unknown container")
+	assert.NoError(t, em.handleEvent(&eventtypes.TaskOOM{ContainerID: "unknown"}, time.Time{}))
+}
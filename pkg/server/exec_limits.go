@@ -0,0 +1,155 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+// execLimiter enforces the limits of exec sessions in containers, so that
+// runaway exec sessions can't exhaust node pids and memory.
+type execLimiter struct {
+	maxSessions    int
+	maxDuration    time.Duration
+	maxOutputBytes int64
+
+	lock sync.Mutex
+	// sessions is the number of exec sessions of each container.
+	sessions map[string]int
+}
+
+// newExecLimiter creates an exec limiter from the config.
+func newExecLimiter(config criconfig.ExecLimitsConfig) (*execLimiter, error) {
+	maxDuration, err := parseOptionalDuration(config.MaxSessionDuration)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid max session duration")
+	}
+	return &execLimiter{
+		maxSessions:    config.MaxSessionsPerContainer,
+		maxDuration:    maxDuration,
+		maxOutputBytes: config.MaxOutputBytes,
+		sessions:       make(map[string]int),
+	}, nil
+}
+
+// acquire starts an exec session in the container. The returned function
+// must be called when the session ends.
+func (l *execLimiter) acquire(id string) (func(), error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.maxSessions > 0 && l.sessions[id] >= l.maxSessions {
+		return nil, status.Errorf(codes.ResourceExhausted,
+			"container %q has reached the limit of %d concurrent exec sessions", id, l.maxSessions)
+	}
+	l.sessions[id]++
+	return func() {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		if l.sessions[id]--; l.sessions[id] <= 0 {
+			delete(l.sessions, id)
+		}
+	}, nil
+}
+
+// timeout returns the timeout of an exec session with the requested timeout,
+// and whether it is the max session duration.
+func (l *execLimiter) timeout(requested time.Duration) (time.Duration, bool) {
+	if l.maxDuration > 0 && (requested == 0 || l.maxDuration < requested) {
+		return l.maxDuration, true
+	}
+	return requested, false
+}
+
+// durationError returns the error of an exec session exceeding the max
+// session duration.
+func (l *execLimiter) durationError() error {
+	return status.Errorf(codes.DeadlineExceeded, "exec session exceeded the max duration %v", l.maxDuration)
+}
+
+// limitOutput limits the total bytes written into stdout and stderr of an
+// exec session. The returned channel is closed when the limit is exceeded,
+// output over the limit is dropped. The channel is nil if there is no limit.
+func (l *execLimiter) limitOutput(stdout, stderr io.WriteCloser) (io.WriteCloser, io.WriteCloser, <-chan struct{}) {
+	if l.maxOutputBytes <= 0 {
+		return stdout, stderr, nil
+	}
+	o := &outputLimit{remaining: l.maxOutputBytes, exceeded: make(chan struct{})}
+	return &limitedWriteCloser{WriteCloser: stdout, limit: o},
+		&limitedWriteCloser{WriteCloser: stderr, limit: o},
+		o.exceeded
+}
+
+// outputError returns the error of an exec session exceeding the max output
+// bytes.
+func (l *execLimiter) outputError() error {
+	return status.Errorf(codes.ResourceExhausted, "exec session exceeded the max output of %d bytes", l.maxOutputBytes)
+}
+
+// outputLimit is the output limit shared by the streams of an exec session.
+type outputLimit struct {
+	lock      sync.Mutex
+	remaining int64
+	exceeded  chan struct{}
+}
+
+// take takes up to n bytes from the limit, and returns the number of bytes
+// allowed.
+func (o *outputLimit) take(n int) int {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if int64(n) <= o.remaining {
+		o.remaining -= int64(n)
+		return n
+	}
+	allowed := o.remaining
+	if allowed >= 0 {
+		// Close the channel only once.
+		close(o.exceeded)
+	}
+	o.remaining = -1
+	if allowed < 0 {
+		return 0
+	}
+	return int(allowed)
+}
+
+// limitedWriteCloser is a write closer with an output limit.
+type limitedWriteCloser struct {
+	io.WriteCloser
+	limit *outputLimit
+}
+
+// Write writes the data allowed by the limit. It always reports the whole
+// data as written, so that the stream keeps being drained until the exec
+// process is killed.
+func (w *limitedWriteCloser) Write(p []byte) (int, error) {
+	n := w.limit.take(len(p))
+	if n > 0 {
+		if _, err := w.WriteCloser.Write(p[:n]); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
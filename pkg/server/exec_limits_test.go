@@ -0,0 +1,132 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	cioutil "github.com/containerd/cri/pkg/ioutil"
+)
+
+func TestNewExecLimiter(t *testing.T) {
+	_, err := newExecLimiter(criconfig.ExecLimitsConfig{MaxSessionDuration: "invalid"})
+	assert.Error(t, err)
+	l, err := newExecLimiter(criconfig.ExecLimitsConfig{MaxSessionDuration: "1h"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, l.maxDuration)
+}
+
+func TestExecLimiterAcquire(t *testing.T) {
+	l, err := newExecLimiter(criconfig.ExecLimitsConfig{MaxSessionsPerContainer: 2})
+	require.NoError(t, err)
+	release1, err := l.acquire("c1")
+	require.NoError(t, err)
+	_, err = l.acquire("c1")
+	require.NoError(t, err)
+
+	t.Logf("should reject sessions over the limit")
+	_, err = l.acquire("c1")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	t.Logf("should not limit other containers")
+	_, err = l.acquire("c2")
+	assert.NoError(t, err)
+
+	t.Logf("should allow a new session after one ends")
+	release1()
+	_, err = l.acquire("c1")
+	assert.NoError(t, err)
+}
+
+func TestExecLimiterTimeout(t *testing.T) {
+	for desc, test := range map[string]struct {
+		maxDuration time.Duration
+		requested   time.Duration
+		expected    time.Duration
+		expectedMax bool
+	}{
+		"no limit": {
+			requested: time.Second,
+			expected:  time.Second,
+		},
+		"no requested timeout": {
+			maxDuration: time.Hour,
+			expected:    time.Hour,
+			expectedMax: true,
+		},
+		"requested timeout longer than the limit": {
+			maxDuration: time.Hour,
+			requested:   2 * time.Hour,
+			expected:    time.Hour,
+			expectedMax: true,
+		},
+		"requested timeout shorter than the limit": {
+			maxDuration: time.Hour,
+			requested:   time.Second,
+			expected:    time.Second,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		l := &execLimiter{maxDuration: test.maxDuration}
+		timeout, max := l.timeout(test.requested)
+		assert.Equal(t, test.expected, timeout)
+		assert.Equal(t, test.expectedMax, max)
+	}
+}
+
+func TestExecLimiterLimitOutput(t *testing.T) {
+	l := &execLimiter{maxOutputBytes: 10}
+	var stdoutBuf, stderrBuf bytes.Buffer
+	stdout, stderr, exceeded := l.limitOutput(cioutil.NewNopWriteCloser(&stdoutBuf), cioutil.NewNopWriteCloser(&stderrBuf))
+	for _, w := range []struct {
+		data   string
+		stderr bool
+	}{
+		{data: "12345"},
+		{data: "678", stderr: true},
+		{data: "9abcd"},
+		{data: "efg", stderr: true},
+	} {
+		wc := stdout
+		if w.stderr {
+			wc = stderr
+		}
+		n, err := wc.Write([]byte(w.data))
+		require.NoError(t, err)
+		assert.Equal(t, len(w.data), n)
+	}
+	assert.Equal(t, "123459a", stdoutBuf.String())
+	assert.Equal(t, "678", stderrBuf.String())
+	select {
+	case <-exceeded:
+	default:
+		t.Fatal("exceeded channel should be closed")
+	}
+
+	t.Logf("should not limit output without a limit")
+	l = &execLimiter{}
+	_, _, exceeded = l.limitOutput(cioutil.NewNopWriteCloser(&stdoutBuf), cioutil.NewNopWriteCloser(&stderrBuf))
+	assert.Nil(t, exceeded)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/containerd/cri/pkg/log"
+)
+
+// execSyncOutput captures an output stream of ExecSync in memory, up to a
+// max number of bytes. Output over the max is dropped, or spilled into a file
+// when spillPath is set, and a truncation marker is appended to the captured
+// output. The spill file of the latest truncated output is kept at spillPath.
+type execSyncOutput struct {
+	max       int64
+	spillPath string
+
+	buf       bytes.Buffer
+	truncated int64
+	spill     *os.File
+	spilled   bool
+}
+
+// newExecSyncOutput creates an ExecSync output stream of a container with
+// the configured limits.
+func (c *criService) newExecSyncOutput(id, stream string) *execSyncOutput {
+	var spillPath string
+	if c.config.ExecLimits.SpillExecSyncOutput {
+		spillPath = filepath.Join(c.getContainerRootDir(id), "execsync-"+stream)
+	}
+	return &execSyncOutput{max: c.config.ExecLimits.MaxExecSyncCapturedBytes, spillPath: spillPath}
+}
+
+// Write captures the data up to the max, and spills the whole output once it
+// is exceeded. It never fails, so that the exec process isn't blocked.
+func (o *execSyncOutput) Write(p []byte) (int, error) {
+	if o.max <= 0 {
+		return o.buf.Write(p)
+	}
+	if o.spillPath != "" && o.spill == nil && int64(o.buf.Len()+len(p)) > o.max {
+		o.startSpill()
+	}
+	if o.spill != nil {
+		if _, err := o.spill.Write(p); err != nil {
+			log.Container.WithError(err).Errorf("Failed to spill exec sync output into %q", o.spill.Name())
+			o.removeSpill()
+		}
+	}
+	remaining := o.max - int64(o.buf.Len())
+	if int64(len(p)) <= remaining {
+		return o.buf.Write(p)
+	}
+	if remaining > 0 {
+		o.buf.Write(p[:remaining])
+	}
+	o.truncated += int64(len(p)) - remaining
+	return len(p), nil
+}
+
+// startSpill creates the spill file with the output captured so far.
+func (o *execSyncOutput) startSpill() {
+	dir := filepath.Dir(o.spillPath)
+	f, err := ioutil.TempFile(dir, filepath.Base(o.spillPath))
+	if err != nil {
+		log.Container.WithError(err).Errorf("Failed to create exec sync spill file in %q", dir)
+		return
+	}
+	o.spill = f
+	if _, err := f.Write(o.buf.Bytes()); err != nil {
+		log.Container.WithError(err).Errorf("Failed to spill exec sync output into %q", f.Name())
+		o.removeSpill()
+	}
+}
+
+// removeSpill stops spilling and removes the spill file.
+func (o *execSyncOutput) removeSpill() {
+	o.spill.Close()
+	os.Remove(o.spill.Name())
+	o.spill = nil
+}
+
+// Close moves the spill file into the spill path. It is called when the
+// stream ends, and is a no-op if the output is not spilled.
+func (o *execSyncOutput) Close() error {
+	if o.spill == nil {
+		return nil
+	}
+	name := o.spill.Name()
+	o.spill.Close()
+	o.spill = nil
+	if err := os.Rename(name, o.spillPath); err != nil {
+		os.Remove(name)
+		log.Container.WithError(err).Errorf("Failed to rename exec sync spill file %q", name)
+		return errors.Wrapf(err, "failed to rename exec sync spill file %q", name)
+	}
+	o.spilled = true
+	return nil
+}
+
+// Bytes returns the captured output, with a truncation marker if the output
+// is truncated.
+func (o *execSyncOutput) Bytes() []byte {
+	if o.truncated == 0 {
+		return o.buf.Bytes()
+	}
+	marker := fmt.Sprintf("\n[truncated %d bytes]\n", o.truncated)
+	if o.spilled {
+		marker = fmt.Sprintf("\n[truncated %d bytes, full output in %s]\n", o.truncated, o.spillPath)
+	}
+	return append(o.buf.Bytes(), marker...)
+}
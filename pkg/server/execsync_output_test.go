@@ -0,0 +1,83 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecSyncOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-exec-sync-output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	spillPath := filepath.Join(dir, "execsync-stdout")
+
+	for desc, test := range map[string]struct {
+		max         int64
+		spill       bool
+		expected    string
+		expectSpill bool
+	}{
+		"should capture all output without a limit": {
+			expected: "12345678",
+		},
+		"should capture all output under the limit": {
+			max:      8,
+			expected: "12345678",
+		},
+		"should truncate output over the limit": {
+			max:      5,
+			expected: "12345\n[truncated 3 bytes]\n",
+		},
+		"should spill output over the limit": {
+			max:         5,
+			spill:       true,
+			expected:    "12345\n[truncated 3 bytes, full output in " + spillPath + "]\n",
+			expectSpill: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		os.Remove(spillPath)
+		o := &execSyncOutput{max: test.max}
+		if test.spill {
+			o.spillPath = spillPath
+		}
+		for _, data := range []string{"1234", "5", "678"} {
+			n, err := o.Write([]byte(data))
+			require.NoError(t, err)
+			assert.Equal(t, len(data), n)
+		}
+		require.NoError(t, o.Close())
+		assert.Equal(t, test.expected, string(o.Bytes()))
+		files, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		if test.expectSpill {
+			require.Len(t, files, 1, "temporary spill files should be removed")
+			data, err := ioutil.ReadFile(spillPath)
+			require.NoError(t, err)
+			assert.Equal(t, "12345678", string(data))
+		} else {
+			assert.Empty(t, files)
+		}
+	}
+}
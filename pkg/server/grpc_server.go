@@ -0,0 +1,257 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net"
+	"time"
+	"unsafe"
+
+	"github.com/containerd/containerd/sys"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+// newGRPCServer creates the dedicated grpc server serving cri services. It
+// returns nil if no dedicated address is configured.
+func newGRPCServer(c *criService, config criconfig.GRPCConfig) (*grpc.Server, error) {
+	if err := validatePeerAllowList(config); err != nil {
+		return nil, err
+	}
+	if config.Address == "" {
+		return nil, nil
+	}
+	opts, err := grpcServerOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	s := grpc.NewServer(opts...)
+	if err := c.Register(s); err != nil {
+		return nil, errors.Wrap(err, "failed to register cri services")
+	}
+	return s, nil
+}
+
+// grpcServerOptions converts the grpc config into grpc server options.
+func grpcServerOptions(config criconfig.GRPCConfig) ([]grpc.ServerOption, error) {
+	var opts []grpc.ServerOption
+	if config.MaxRecvMessageSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(config.MaxRecvMessageSize))
+	}
+	if config.MaxSendMessageSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(config.MaxSendMessageSize))
+	}
+	if config.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(config.MaxConcurrentStreams))
+	}
+	var (
+		params keepalive.ServerParameters
+		err    error
+	)
+	if params.Time, err = parseOptionalDuration(config.KeepaliveTime); err != nil {
+		return nil, errors.Wrapf(err, "invalid keepalive time %q", config.KeepaliveTime)
+	}
+	if params.Timeout, err = parseOptionalDuration(config.KeepaliveTimeout); err != nil {
+		return nil, errors.Wrapf(err, "invalid keepalive timeout %q", config.KeepaliveTimeout)
+	}
+	if params != (keepalive.ServerParameters{}) {
+		opts = append(opts, grpc.KeepaliveParams(params))
+	}
+	policy := keepalive.EnforcementPolicy{PermitWithoutStream: config.KeepalivePermitWithoutStream}
+	if policy.MinTime, err = parseOptionalDuration(config.KeepaliveMinTime); err != nil {
+		return nil, errors.Wrapf(err, "invalid keepalive min time %q", config.KeepaliveMinTime)
+	}
+	if policy != (keepalive.EnforcementPolicy{}) {
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(policy))
+	}
+	return opts, nil
+}
+
+// parseOptionalDuration parses a duration, empty means 0.
+func parseOptionalDuration(d string) (time.Duration, error) {
+	if d == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(d)
+}
+
+// serveGRPC serves the dedicated grpc server until it is stopped.
+func (c *criService) serveGRPC() error {
+	l, err := sys.GetLocalListener(c.config.GRPC.Address, 0, 0)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on %q", c.config.GRPC.Address)
+	}
+	if len(c.config.GRPC.AllowedUIDs) > 0 || len(c.config.GRPC.AllowedGIDs) > 0 {
+		l = newPeerAuthListener(l, c.config.GRPC.AllowedUIDs, c.config.GRPC.AllowedGIDs)
+	}
+	return c.grpcServer.Serve(l)
+}
+
+// validatePeerAllowList validates the allowed peers of the dedicated grpc
+// server. The containerd socket is not owned by the cri plugin, so peers can
+// only be restricted on the dedicated socket.
+func validatePeerAllowList(config criconfig.GRPCConfig) error {
+	if config.Address == "" && (len(config.AllowedUIDs) > 0 || len(config.AllowedGIDs) > 0) {
+		return errors.New("allowed_uids and allowed_gids require the dedicated grpc address")
+	}
+	return nil
+}
+
+// peerAuthListener is a unix socket listener which only accepts connections
+// of allowed processes, identified by the SO_PEERCRED credentials and the
+// SO_PEERGROUPS supplementary groups of the connection. Connections of other
+// processes are closed right away.
+type peerAuthListener struct {
+	net.Listener
+	uids map[uint32]bool
+	gids map[uint32]bool
+}
+
+func newPeerAuthListener(l net.Listener, uids, gids []uint32) net.Listener {
+	pl := &peerAuthListener{
+		Listener: l,
+		uids:     make(map[uint32]bool),
+		gids:     make(map[uint32]bool),
+	}
+	for _, uid := range uids {
+		pl.uids[uid] = true
+	}
+	for _, gid := range gids {
+		pl.gids[gid] = true
+	}
+	return pl
+}
+
+// Accept returns the next connection of an allowed process.
+func (l *peerAuthListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		cred, err := peerCredentials(conn)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to get peer credentials of grpc connection, closing it")
+			conn.Close()
+			continue
+		}
+		if l.uids[cred.Uid] || l.gids[cred.Gid] {
+			return conn, nil
+		}
+		if len(l.gids) > 0 {
+			groups, err := peerGroups(conn)
+			if err != nil {
+				logrus.WithError(err).Warnf("Failed to get supplementary groups of pid %d, closing its grpc connection", cred.Pid)
+				conn.Close()
+				continue
+			}
+			if l.allowedGroup(groups) {
+				return conn, nil
+			}
+		}
+		logrus.Warnf("Closing grpc connection of pid %d with uid %d and gid %d, which is not allowed",
+			cred.Pid, cred.Uid, cred.Gid)
+		conn.Close()
+	}
+}
+
+// allowedGroup returns whether any of the groups is allowed.
+func (l *peerAuthListener) allowedGroup(groups []uint32) bool {
+	for _, gid := range groups {
+		if l.gids[gid] {
+			return true
+		}
+	}
+	return false
+}
+
+// peerCredentials returns the credentials of the process on the other end of
+// a unix socket connection.
+func peerCredentials(conn net.Conn) (*unix.Ucred, error) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, errors.Errorf("unexpected connection type %T", conn)
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get raw connection")
+	}
+	var (
+		cred    *unix.Ucred
+		credErr error
+	)
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to access raw connection")
+	}
+	if credErr != nil {
+		return nil, errors.Wrap(credErr, "failed to get SO_PEERCRED")
+	}
+	return cred, nil
+}
+
+// peerGroups returns the supplementary groups of the process on the other end
+// of a unix socket connection, at the time it connected.
+func peerGroups(conn net.Conn) ([]uint32, error) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, errors.Errorf("unexpected connection type %T", conn)
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get raw connection")
+	}
+	var (
+		groups    []uint32
+		groupsErr error
+	)
+	if err := raw.Control(func(fd uintptr) {
+		groups, groupsErr = getsockoptPeerGroups(int(fd))
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to access raw connection")
+	}
+	if groupsErr != nil {
+		return nil, errors.Wrap(groupsErr, "failed to get SO_PEERGROUPS")
+	}
+	return groups, nil
+}
+
+// getsockoptPeerGroups gets SO_PEERGROUPS, which x/sys/unix has no helper
+// for. The kernel returns ERANGE with the needed size if the buffer is too
+// small.
+func getsockoptPeerGroups(fd int) ([]uint32, error) {
+	groups := make([]uint32, 32)
+	for {
+		size := uint32(len(groups) * 4)
+		_, _, errno := unix.Syscall6(unix.SYS_GETSOCKOPT, uintptr(fd), unix.SOL_SOCKET, unix.SO_PEERGROUPS,
+			uintptr(unsafe.Pointer(&groups[0])), uintptr(unsafe.Pointer(&size)), 0)
+		if errno == unix.ERANGE && int(size/4) > len(groups) {
+			groups = make([]uint32, size/4)
+			continue
+		}
+		if errno != 0 {
+			return nil, errno
+		}
+		return groups[:size/4], nil
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestGRPCServerOptions(t *testing.T) {
+	for desc, test := range map[string]struct {
+		config      criconfig.GRPCConfig
+		expectedLen int
+		expectErr   bool
+	}{
+		"should not set options by default": {
+			config:      criconfig.GRPCConfig{},
+			expectedLen: 0,
+		},
+		"should set message size and stream limits": {
+			config: criconfig.GRPCConfig{
+				MaxRecvMessageSize:   1024,
+				MaxSendMessageSize:   1024,
+				MaxConcurrentStreams: 10,
+			},
+			expectedLen: 3,
+		},
+		"should set keepalive parameters and enforcement policy": {
+			config: criconfig.GRPCConfig{
+				KeepaliveTime:                "1h",
+				KeepaliveMinTime:             "10s",
+				KeepalivePermitWithoutStream: true,
+			},
+			expectedLen: 2,
+		},
+		"should fail with invalid keepalive time": {
+			config:    criconfig.GRPCConfig{KeepaliveTimeout: "invalid"},
+			expectErr: true,
+		},
+		"should fail with invalid keepalive min time": {
+			config:    criconfig.GRPCConfig{KeepaliveMinTime: "invalid"},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		opts, err := grpcServerOptions(test.config)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Len(t, opts, test.expectedLen)
+	}
+}
+
+func TestNewGRPCServer(t *testing.T) {
+	c := newTestCRIService()
+	s, err := newGRPCServer(c, criconfig.GRPCConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, s, "should not create grpc server without address")
+
+	s, err = newGRPCServer(c, criconfig.GRPCConfig{Address: "/run/cri.sock", MaxSendMessageSize: 1024})
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+	assert.Contains(t, s.GetServiceInfo(), "runtime.v1alpha2.RuntimeService")
+}
+
+func TestValidatePeerAllowList(t *testing.T) {
+	assert.NoError(t, validatePeerAllowList(criconfig.GRPCConfig{}))
+	assert.NoError(t, validatePeerAllowList(criconfig.GRPCConfig{Address: "/run/cri.sock", AllowedUIDs: []uint32{0}}))
+	assert.Error(t, validatePeerAllowList(criconfig.GRPCConfig{AllowedGIDs: []uint32{0}}),
+		"should reject allowed peers without address")
+}
+
+func TestPeerAuthListener(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peer-auth")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	uid, gid := uint32(os.Getuid()), uint32(os.Getgid())
+	for desc, test := range map[string]struct {
+		uids, gids []uint32
+		expectConn bool
+	}{
+		"should accept allowed uid": {
+			uids:       []uint32{uid},
+			expectConn: true,
+		},
+		"should accept allowed gid": {
+			uids:       []uint32{uid + 1},
+			gids:       []uint32{gid},
+			expectConn: true,
+		},
+		"should close connection of other peers": {
+			uids: []uint32{uid + 1},
+			// Not gid+1, which may be a supplementary group.
+			gids: []uint32{math.MaxUint32 - 1},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		path := filepath.Join(dir, "cri.sock")
+		l, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		pl := newPeerAuthListener(l, test.uids, test.gids)
+		accepted := make(chan net.Conn, 1)
+		go func() {
+			defer close(accepted)
+			if conn, err := pl.Accept(); err == nil {
+				accepted <- conn
+			}
+		}()
+
+		client, err := net.Dial("unix", path)
+		require.NoError(t, err)
+		if test.expectConn {
+			conn := <-accepted
+			require.NotNil(t, conn)
+			_, err = client.Write([]byte("ping"))
+			assert.NoError(t, err)
+			conn.Close()
+		} else {
+			_, err = client.Read(make([]byte, 1))
+			assert.Equal(t, io.EOF, err)
+		}
+		client.Close()
+		pl.Close()
+		assert.Nil(t, <-accepted)
+	}
+}
+
+func TestPeerGroups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peer-groups")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cri.sock")
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer l.Close()
+	client, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer client.Close()
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	groups, err := peerGroups(conn)
+	require.NoError(t, err)
+	expected, err := os.Getgroups()
+	require.NoError(t, err)
+	if expected == nil {
+		expected = []int{}
+	}
+	actual := []int{}
+	for _, gid := range groups {
+		actual = append(actual, int(gid))
+	}
+	sort.Ints(expected)
+	sort.Ints(actual)
+	assert.Equal(t, expected, actual)
+
+	pl := &peerAuthListener{gids: map[uint32]bool{}}
+	assert.False(t, pl.allowedGroup(groups))
+	for _, gid := range groups {
+		pl.gids[gid] = true
+	}
+	assert.Equal(t, len(groups) > 0, pl.allowedGroup(groups))
+}
@@ -76,6 +76,12 @@ const (
 	sandboxesDir = "sandboxes"
 	// containersDir contains all container root.
 	containersDir = "containers"
+	// eventBacklogFile persists the containerd events which are not handled
+	// yet, so that they are replayed after restart.
+	eventBacklogFile = "event-backlog.json"
+	// eventCheckpointFile persists the timestamp of the last handled
+	// containerd event, and the event being handled.
+	eventCheckpointFile = "event-checkpoint.json"
 	// According to http://man7.org/linux/man-pages/man5/resolv.conf.5.html:
 	// "The search list is currently limited to six domains with a total of 256 characters."
 	maxDNSSearches = 6
@@ -89,6 +95,8 @@ const (
 	utsNSFormat = "/proc/%v/ns/uts"
 	// pidNSFormat is the format of pid namespace of a process.
 	pidNSFormat = "/proc/%v/ns/pid"
+	// userNSFormat is the format of user namespace of a process.
+	userNSFormat = "/proc/%v/ns/user"
 	// devShm is the default path of /dev/shm.
 	devShm = "/dev/shm"
 	// etcHosts is the default path of /etc/hosts file.
@@ -214,6 +222,11 @@ func getPIDNamespace(pid uint32) string {
 	return fmt.Sprintf(pidNSFormat, pid)
 }
 
+// getUserNamespace returns the user namespace of a process.
+func getUserNamespace(pid uint32) string {
+	return fmt.Sprintf(userNSFormat, pid)
+}
+
 // criContainerStateToString formats CRI container state to string.
 func criContainerStateToString(state runtime.ContainerState) string {
 	return runtime.ContainerState_name[int32(state)]
@@ -237,26 +250,33 @@ func getRepoDigestAndTag(namedRef reference.Named, digest imagedigest.Digest, sc
 // localResolve resolves image reference locally and returns corresponding image metadata. It returns
 // nil without error if the reference doesn't exist.
 func (c *criService) localResolve(ctx context.Context, refOrID string) (*imagestore.Image, error) {
+	return c.localResolveCandidates(ctx, refOrID, c.localShortNameCandidates(refOrID))
+}
+
+// localResolveCandidates resolves image reference locally with the candidate
+// references of a short name.
+func (c *criService) localResolveCandidates(ctx context.Context, refOrID string, candidates []string) (*imagestore.Image, error) {
 	getImageID := func(refOrId string) string {
 		if _, err := imagedigest.Parse(refOrID); err == nil {
 			return refOrID
 		}
-		return func(ref string) string {
-			// ref is not image id, try to resolve it locally.
+		// ref is not image id, try to resolve it locally.
+		for _, ref := range candidates {
 			normalized, err := util.NormalizeImageRef(ref)
 			if err != nil {
-				return ""
+				continue
 			}
 			image, err := c.client.GetImage(ctx, normalized.String())
 			if err != nil {
-				return ""
+				continue
 			}
 			desc, err := image.Config(ctx)
 			if err != nil {
-				return ""
+				continue
 			}
 			return desc.Digest.String()
-		}(refOrID)
+		}
+		return ""
 	}
 
 	imageID := getImageID(refOrID)
@@ -380,7 +400,46 @@ func initSelinuxOpts(selinuxOpt *runtime.SELinuxOption) (string, string, error)
 		selinuxOpt.GetRole(),
 		selinuxOpt.GetType(),
 		selinuxOpt.GetLevel())
-	return label.InitLabels(selinux.DupSecOpt(labelOpts))
+	return initLabels(selinux.DupSecOpt(labelOpts))
+}
+
+// initLabels is label.InitLabels, except that the random MCS level reserved
+// for the labels is released when the options override it. label.InitLabels
+// always reserves a new level and never releases it when it is overridden.
+func initLabels(options []string) (string, string, error) {
+	processLabel, mountLabel, err := label.InitLabels(nil)
+	if err != nil || processLabel == "" {
+		return "", "", err
+	}
+	pcon := selinux.NewContext(processLabel)
+	mcon := selinux.NewContext(mountLabel)
+	for _, opt := range options {
+		if opt == "disable" {
+			label.ReleaseLabel(processLabel) // nolint: errcheck
+			return "", "", nil
+		}
+		con := strings.SplitN(opt, ":", 2)
+		if len(con) != 2 || !validSelinuxOptions[con[0]] {
+			label.ReleaseLabel(processLabel) // nolint: errcheck
+			return "", "", errors.Errorf("bad label option %q, valid options are 'disable' or 'user, role, level, type' followed by ':' and a value", opt)
+		}
+		pcon[con[0]] = con[1]
+		if con[0] == "level" || con[0] == "user" {
+			mcon[con[0]] = con[1]
+		}
+	}
+	if pcon["level"] != selinux.NewContext(processLabel)["level"] {
+		label.ReleaseLabel(processLabel) // nolint: errcheck
+	}
+	return pcon.Get(), mcon.Get(), nil
+}
+
+// validSelinuxOptions are the keys of the label options accepted by initLabels.
+var validSelinuxOptions = map[string]bool{
+	"user":  true,
+	"role":  true,
+	"type":  true,
+	"level": true,
 }
 
 // isInCRIMounts checks whether a destination is in CRI mount list.
@@ -442,5 +501,6 @@ func getRuntimeConfigFromContainerInfo(c containers.Container) (criconfig.Runtim
 	runtimeOpts := data.(*runctypes.RuncOptions)
 	r.Engine = runtimeOpts.Runtime
 	r.Root = runtimeOpts.RuntimeRoot
+	r.SystemdCgroup = &runtimeOpts.SystemdCgroup
 	return r, nil
 }
@@ -153,6 +153,7 @@ func TestGetRuntimeConfigFromContainerInfo(t *testing.T) {
 		typ             string
 		engine          string
 		root            string
+		systemdCgroup   bool
 		expectErr       bool
 		expectedRuntime criconfig.Runtime
 	}{
@@ -161,13 +162,15 @@ func TestGetRuntimeConfigFromContainerInfo(t *testing.T) {
 			expectErr: true,
 		},
 		"should retrieve runtime from container info": {
-			typ:    "test.type",
-			engine: "test-engine",
-			root:   "/test/root",
+			typ:           "test.type",
+			engine:        "test-engine",
+			root:          "/test/root",
+			systemdCgroup: true,
 			expectedRuntime: criconfig.Runtime{
-				Type:   "test.type",
-				Engine: "test-engine",
-				Root:   "/test/root",
+				Type:          "test.type",
+				Engine:        "test-engine",
+				Root:          "/test/root",
+				SystemdCgroup: &[]bool{true}[0],
 			},
 		},
 	} {
@@ -175,8 +178,9 @@ func TestGetRuntimeConfigFromContainerInfo(t *testing.T) {
 			var opts interface{}
 			if test.engine != "" || test.root != "" {
 				opts = &runctypes.RuncOptions{
-					Runtime:     test.engine,
-					RuntimeRoot: test.root,
+					Runtime:       test.engine,
+					RuntimeRoot:   test.root,
+					SystemdCgroup: test.systemdCgroup,
 				}
 			}
 			c := containers.Container{}
@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/cgroups"
+	"github.com/containerd/typeurl"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/containerd/cri/pkg/annotations"
+	api "github.com/containerd/cri/pkg/api/v1"
+)
+
+// hugepageSizeRegexp matches hugepage sizes in the format used by the hugetlb
+// cgroup controller, e.g. "2MB" and "1GB".
+var hugepageSizeRegexp = regexp.MustCompile(`^[1-9][0-9]*[KMG]B$`)
+
+// setOCIHugepageAndSwapLimits sets the hugetlb and memory swap limits from
+// the container annotations.
+func setOCIHugepageAndSwapLimits(g *generate.Generator, containerAnnotations map[string]string, memoryLimit int64) error {
+	for k, v := range containerAnnotations {
+		if !strings.HasPrefix(k, annotations.HugepageLimitPrefix) {
+			continue
+		}
+		pageSize := strings.TrimPrefix(k, annotations.HugepageLimitPrefix)
+		if !hugepageSizeRegexp.MatchString(pageSize) {
+			return errors.Errorf("invalid hugepage size %q", pageSize)
+		}
+		limit, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return errors.Wrapf(err, "invalid hugepage limit %q of page size %q", v, pageSize)
+		}
+		g.AddLinuxResourcesHugepageLimit(pageSize, limit)
+	}
+
+	v, ok := containerAnnotations[annotations.MemorySwapLimit]
+	if !ok {
+		return nil
+	}
+	swap, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "invalid memory swap limit %q", v)
+	}
+	if swap != -1 {
+		// The swap limit includes memory, so it requires a memory limit
+		// no larger than itself.
+		if memoryLimit <= 0 {
+			return errors.New("memory swap limit requires a memory limit")
+		}
+		if swap < memoryLimit {
+			return errors.Errorf("memory swap limit %d is less than memory limit %d", swap, memoryLimit)
+		}
+	}
+	g.SetLinuxResourcesMemorySwap(swap)
+	return nil
+}
+
+// ContainerHugetlbStats returns hugetlb usage of a container.
+func (c *criService) ContainerHugetlbStats(ctx context.Context, r *api.ContainerHugetlbStatsRequest) (*api.ContainerHugetlbStatsResponse, error) {
+	cntr, err := c.containerStore.Get(r.GetContainerId())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find container")
+	}
+	metric, err := c.getContainerMetric(ctx, cntr)
+	if err != nil {
+		return nil, err
+	}
+	if metric == nil {
+		return &api.ContainerHugetlbStatsResponse{}, nil
+	}
+	s, err := typeurl.UnmarshalAny(metric.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to extract container metrics")
+	}
+	return &api.ContainerHugetlbStatsResponse{
+		Stats: toHugetlbStats(s.(*cgroups.Metrics).Hugetlb),
+	}, nil
+}
+
+// toHugetlbStats converts cgroup hugetlb metrics into hugetlb stats.
+func toHugetlbStats(metrics []*cgroups.HugetlbStat) []*api.HugetlbStats {
+	var stats []*api.HugetlbStats
+	for _, m := range metrics {
+		stats = append(stats, &api.HugetlbStats{
+			PageSize:      m.Pagesize,
+			UsageBytes:    m.Usage,
+			MaxUsageBytes: m.Max,
+			Failcnt:       m.Failcnt,
+		})
+	}
+	return stats
+}
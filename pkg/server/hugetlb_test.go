@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/containerd/cgroups"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containerd/cri/pkg/annotations"
+	api "github.com/containerd/cri/pkg/api/v1"
+)
+
+func TestSetOCIHugepageAndSwapLimits(t *testing.T) {
+	swap := int64(2048)
+	unlimitedSwap := int64(-1)
+	for desc, test := range map[string]struct {
+		annotations       map[string]string
+		memoryLimit       int64
+		expectedHugepages []runtimespec.LinuxHugepageLimit
+		expectedSwap      *int64
+		expectErr         bool
+	}{
+		"should not set limits without annotations": {
+			annotations: map[string]string{"foo": "bar"},
+		},
+		"should set hugepage limits": {
+			annotations: map[string]string{
+				annotations.HugepageLimitPrefix + "2MB": "1073741824",
+			},
+			expectedHugepages: []runtimespec.LinuxHugepageLimit{
+				{Pagesize: "2MB", Limit: 1073741824},
+			},
+		},
+		"should fail with invalid hugepage size": {
+			annotations: map[string]string{
+				annotations.HugepageLimitPrefix + "2M": "1073741824",
+			},
+			expectErr: true,
+		},
+		"should fail with invalid hugepage limit": {
+			annotations: map[string]string{
+				annotations.HugepageLimitPrefix + "2MB": "-1",
+			},
+			expectErr: true,
+		},
+		"should set swap limit": {
+			annotations:  map[string]string{annotations.MemorySwapLimit: "2048"},
+			memoryLimit:  1024,
+			expectedSwap: &swap,
+		},
+		"should set unlimited swap without memory limit": {
+			annotations:  map[string]string{annotations.MemorySwapLimit: "-1"},
+			expectedSwap: &unlimitedSwap,
+		},
+		"should fail with swap limit without memory limit": {
+			annotations: map[string]string{annotations.MemorySwapLimit: "2048"},
+			expectErr:   true,
+		},
+		"should fail with swap limit less than memory limit": {
+			annotations: map[string]string{annotations.MemorySwapLimit: "512"},
+			memoryLimit: 1024,
+			expectErr:   true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		g, err := generate.New("linux")
+		require.NoError(t, err)
+		err = setOCIHugepageAndSwapLimits(&g, test.annotations, test.memoryLimit)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		spec := g.Spec()
+		var hugepages []runtimespec.LinuxHugepageLimit
+		var swap *int64
+		if spec.Linux != nil && spec.Linux.Resources != nil {
+			hugepages = spec.Linux.Resources.HugepageLimits
+			if spec.Linux.Resources.Memory != nil {
+				swap = spec.Linux.Resources.Memory.Swap
+			}
+		}
+		assert.Equal(t, test.expectedHugepages, hugepages)
+		assert.Equal(t, test.expectedSwap, swap)
+	}
+}
+
+func TestToHugetlbStats(t *testing.T) {
+	stats := toHugetlbStats([]*cgroups.HugetlbStat{
+		{Usage: 2097152, Max: 4194304, Failcnt: 1, Pagesize: "2MB"},
+		{Pagesize: "1GB"},
+	})
+	assert.Equal(t, []*api.HugetlbStats{
+		{PageSize: "2MB", UsageBytes: 2097152, MaxUsageBytes: 4194304, Failcnt: 1},
+		{PageSize: "1GB"},
+	}, stats)
+}
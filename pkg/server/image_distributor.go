@@ -0,0 +1,191 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"net/url"
+
+	"github.com/containerd/containerd/remotes"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	containerdresolver "github.com/containerd/cri/pkg/containerd/resolver"
+	"github.com/containerd/cri/pkg/log"
+)
+
+const (
+	// distributorRegistry is a distributor serving a registry compatible
+	// endpoint, e.g. dragonfly dfdaemon or spegel.
+	distributorRegistry = "registry"
+)
+
+// Operations falling back to the origin registry used as the "operation"
+// label of imageDistributorFallbacks.
+const (
+	distributorResolve = "resolve"
+	distributorFetch   = "fetch"
+)
+
+// imageDistributor is a hook routing image pulls through a peer-to-peer image
+// distributor.
+type imageDistributor interface {
+	// resolver returns the resolver pulling images of the registry host
+	// through the distributor, and false if images of the host are not
+	// distributed by it. The options are the ones of the origin registry.
+	resolver(host string, options containerdresolver.Options) (remotes.Resolver, bool)
+}
+
+// newImageDistributor creates the image distributor from the config. It
+// returns nil if no distributor is configured.
+func newImageDistributor(config criconfig.P2PConfig) (imageDistributor, error) {
+	switch config.Distributor {
+	case "":
+		return nil, nil
+	case distributorRegistry:
+		u, err := url.Parse(config.Endpoint)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid endpoint %q", config.Endpoint)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return nil, errors.Errorf("endpoint %q is not a http or https url", config.Endpoint)
+		}
+		d := &registryDistributor{endpoint: u}
+		if len(config.Registries) > 0 {
+			d.registries = make(map[string]struct{})
+			for _, r := range config.Registries {
+				d.registries[r] = struct{}{}
+			}
+		}
+		return d, nil
+	}
+	return nil, errors.Errorf("unsupported distributor %q", config.Distributor)
+}
+
+// registryDistributor is a distributor serving a registry compatible
+// endpoint. The origin registry host is passed in the "ns" query parameter.
+type registryDistributor struct {
+	endpoint *url.URL
+	// registries are the distributed registry hosts, all registries are
+	// distributed if it is nil.
+	registries map[string]struct{}
+}
+
+func (d *registryDistributor) resolver(host string, options containerdresolver.Options) (remotes.Resolver, bool) {
+	if d.registries != nil {
+		if _, ok := d.registries[host]; !ok {
+			return nil, false
+		}
+	}
+	endpoint := *d.endpoint
+	endpoint.RawQuery = url.Values{"ns": {host}}.Encode()
+	// The distributor fetches from the origin registry with the credentials
+	// of the origin registry.
+	credentials := options.Credentials
+	options.Credentials = func(string) (string, string, error) {
+		if credentials == nil {
+			return "", "", nil
+		}
+		return credentials(host)
+	}
+	options.Registry = map[string][]string{host: {endpoint.String()}}
+	options.NoUpstream = map[string]bool{host: true}
+	return containerdresolver.NewResolver(options), true
+}
+
+// distributedResolver resolves and fetches images through a distributor, and
+// falls back to the origin registry when the distributor fails.
+type distributedResolver struct {
+	distributor remotes.Resolver
+	origin      remotes.Resolver
+}
+
+var _ remotes.Resolver = &distributedResolver{}
+
+func (r *distributedResolver) Resolve(ctx context.Context, ref string) (string, imagespec.Descriptor, error) {
+	name, desc, err := r.distributor.Resolve(ctx, ref)
+	if err == nil {
+		return name, desc, nil
+	}
+	log.Image.WithError(err).Warnf("Failed to resolve image %q through distributor, fall back to origin registry", ref)
+	imageDistributorFallbacks.WithValues(distributorResolve).Inc()
+	return r.origin.Resolve(ctx, ref)
+}
+
+func (r *distributedResolver) Fetcher(ctx context.Context, ref string) (remotes.Fetcher, error) {
+	origin, err := r.origin.Fetcher(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	distributor, err := r.distributor.Fetcher(ctx, ref)
+	if err != nil {
+		log.Image.WithError(err).Warnf("Failed to get distributor fetcher of image %q, fall back to origin registry", ref)
+		imageDistributorFallbacks.WithValues(distributorFetch).Inc()
+		return origin, nil
+	}
+	return remotes.FetcherFunc(func(ctx context.Context, desc imagespec.Descriptor) (io.ReadCloser, error) {
+		rc, err := distributor.Fetch(ctx, desc)
+		if err != nil {
+			log.Image.WithError(err).Warnf("Failed to fetch %q through distributor, fall back to origin registry", desc.Digest)
+			imageDistributorFallbacks.WithValues(distributorFetch).Inc()
+			return origin.Fetch(ctx, desc)
+		}
+		return &fallbackReadCloser{ctx: ctx, desc: desc, rc: rc, origin: origin}, nil
+	}), nil
+}
+
+// Pusher returns the pusher of the origin registry, images are never pushed
+// through the distributor.
+func (r *distributedResolver) Pusher(ctx context.Context, ref string) (remotes.Pusher, error) {
+	return r.origin.Pusher(ctx, ref)
+}
+
+// fallbackReadCloser reads a content fetched through the distributor, and
+// fetches it from the origin registry instead if the first read fails. The
+// fetch of the distributor is lazy, e.g. the request is only sent on read.
+type fallbackReadCloser struct {
+	ctx    context.Context
+	desc   imagespec.Descriptor
+	rc     io.ReadCloser
+	origin remotes.Fetcher
+	// started is true once any byte is read, or the origin is used.
+	started bool
+}
+
+func (r *fallbackReadCloser) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	if r.started || n > 0 || err == nil || err == io.EOF {
+		r.started = true
+		return n, err
+	}
+	r.started = true
+	log.Image.WithError(err).Warnf("Failed to read %q through distributor, fall back to origin registry", r.desc.Digest)
+	imageDistributorFallbacks.WithValues(distributorFetch).Inc()
+	r.rc.Close() // nolint: errcheck
+	rc, ferr := r.origin.Fetch(r.ctx, r.desc)
+	if ferr != nil {
+		return 0, errors.Wrapf(ferr, "failed to fetch from origin registry after distributor error %v", err)
+	}
+	r.rc = rc
+	return r.rc.Read(p)
+}
+
+func (r *fallbackReadCloser) Close() error {
+	return r.rc.Close()
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containerd/containerd/remotes"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	containerdresolver "github.com/containerd/cri/pkg/containerd/resolver"
+)
+
+// brokenResolver fails all resolutions, and returns readers failing on read.
+type brokenResolver struct{}
+
+func (brokenResolver) Resolve(ctx context.Context, ref string) (string, imagespec.Descriptor, error) {
+	return "", imagespec.Descriptor{}, errors.New("distributor unavailable")
+}
+
+func (brokenResolver) Fetcher(ctx context.Context, ref string) (remotes.Fetcher, error) {
+	return remotes.FetcherFunc(func(ctx context.Context, desc imagespec.Descriptor) (io.ReadCloser, error) {
+		return ioutil.NopCloser(&brokenReader{}), nil
+	}), nil
+}
+
+func (brokenResolver) Pusher(ctx context.Context, ref string) (remotes.Pusher, error) {
+	return nil, errors.New("not implemented")
+}
+
+type brokenReader struct{}
+
+func (*brokenReader) Read(p []byte) (int, error) {
+	return 0, errors.New("connection refused")
+}
+
+func TestNewImageDistributor(t *testing.T) {
+	for desc, test := range map[string]struct {
+		config    criconfig.P2PConfig
+		expectNil bool
+		expectErr bool
+	}{
+		"should return nil without distributor": {
+			expectNil: true,
+		},
+		"should create registry distributor": {
+			config: criconfig.P2PConfig{Distributor: "registry", Endpoint: "http://127.0.0.1:65001"},
+		},
+		"should reject endpoint which is not a url": {
+			config:    criconfig.P2PConfig{Distributor: "registry", Endpoint: "127.0.0.1:65001"},
+			expectErr: true,
+		},
+		"should reject unsupported distributor": {
+			config:    criconfig.P2PConfig{Distributor: "bittorrent", Endpoint: "http://127.0.0.1:65001"},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		d, err := newImageDistributor(test.config)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expectNil, d == nil)
+	}
+}
+
+func TestRegistryDistributor(t *testing.T) {
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		w.Header().Set("Docker-Content-Digest", "sha256:4d4e0b59b37f8cbd4cd1e8f3eb9bf1b5f1ff19f4a2c1c7ef2f86e0c86a8b3f4a")
+		w.Header().Set("Content-Type", imagespec.MediaTypeImageManifest)
+		w.Header().Set("Content-Length", "100")
+	}))
+	defer server.Close()
+	d, err := newImageDistributor(criconfig.P2PConfig{
+		Distributor: "registry",
+		Endpoint:    server.URL,
+		Registries:  []string{"docker.io"},
+	})
+	require.NoError(t, err)
+
+	t.Logf("should not distribute images of other registries")
+	_, ok := d.resolver("gcr.io", containerdresolver.Options{})
+	assert.False(t, ok)
+
+	t.Logf("should resolve images through the endpoint")
+	var credentialHosts []string
+	r, ok := d.resolver("docker.io", containerdresolver.Options{
+		Credentials: func(host string) (string, string, error) {
+			credentialHosts = append(credentialHosts, host)
+			return "", "", nil
+		},
+		Client: server.Client(),
+	})
+	require.True(t, ok)
+	_, desc, err := r.Resolve(context.Background(), "docker.io/library/busybox:latest")
+	require.NoError(t, err)
+	assert.EqualValues(t, 100, desc.Size)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/v2/library/busybox/manifests/latest", requests[0].URL.Path)
+	assert.Equal(t, "docker.io", requests[0].URL.Query().Get("ns"))
+	assert.Equal(t, []string{"docker.io"}, credentialHosts)
+}
+
+func TestDistributedResolver(t *testing.T) {
+	const ref = "docker.io/library/busybox:latest"
+	origin := newFakeSignatureResolver()
+	manifest := origin.add([]byte("manifest"), nil)
+	origin.refs[ref] = manifest
+	r := &distributedResolver{distributor: brokenResolver{}, origin: origin}
+
+	t.Logf("should fall back to origin registry on resolve failure")
+	_, desc, err := r.Resolve(context.Background(), ref)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, desc)
+
+	t.Logf("should fall back to origin registry on fetch failure")
+	fetcher, err := r.Fetcher(context.Background(), ref)
+	require.NoError(t, err)
+	rc, err := fetcher.Fetch(context.Background(), manifest)
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "manifest", string(data))
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"strings"
+
+	"github.com/containerd/containerd/errdefs"
+	containerdimages "github.com/containerd/containerd/images"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// encryptedLayerMediaTypeSuffix is the media type suffix of encrypted OCI
+// layers, e.g. "application/vnd.oci.image.layer.v1.tar+gzip+encrypted".
+const encryptedLayerMediaTypeSuffix = "+encrypted"
+
+// isEncryptedLayer returns whether a descriptor is an encrypted layer.
+func isEncryptedLayer(desc imagespec.Descriptor) bool {
+	return strings.HasSuffix(desc.MediaType, encryptedLayerMediaTypeSuffix)
+}
+
+// rejectEncryptedLayersHandler fails the pull of an image with encrypted
+// layers before they are fetched, instead of failing to unpack them. Image
+// decryption is not implemented: ocicrypt is not vendored, and the vendored
+// containerd can't unpack encrypted layers with a stream processor.
+func rejectEncryptedLayersHandler() containerdimages.Handler {
+	return containerdimages.HandlerFunc(func(ctx context.Context, desc imagespec.Descriptor) ([]imagespec.Descriptor, error) {
+		if isEncryptedLayer(desc) {
+			return nil, errors.Wrapf(errdefs.ErrNotImplemented, "layer %q with media type %q is encrypted, image decryption is not supported",
+				desc.Digest, desc.MediaType)
+		}
+		return nil, nil
+	})
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/opencontainers/go-digest"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestRejectEncryptedLayersHandler(t *testing.T) {
+	handler := rejectEncryptedLayersHandler()
+	for desc, test := range map[string]struct {
+		mediaType string
+		expectErr bool
+	}{
+		"should reject encrypted gzip layer": {
+			mediaType: imagespec.MediaTypeImageLayerGzip + encryptedLayerMediaTypeSuffix,
+			expectErr: true,
+		},
+		"should reject encrypted layer": {
+			mediaType: imagespec.MediaTypeImageLayer + encryptedLayerMediaTypeSuffix,
+			expectErr: true,
+		},
+		"should not reject plain layer": {
+			mediaType: imagespec.MediaTypeImageLayerGzip,
+		},
+		"should not reject manifest": {
+			mediaType: imagespec.MediaTypeImageManifest,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		children, err := handler.Handle(context.Background(), imagespec.Descriptor{
+			MediaType: test.mediaType,
+			Digest:    digest.FromString(desc),
+		})
+		assert.Nil(t, children)
+		if test.expectErr {
+			assert.True(t, errdefs.IsNotImplemented(err))
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"golang.org/x/sys/unix"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	"github.com/containerd/cri/pkg/log"
+)
+
+// imageGCManager removes images not used by any container in least recently
+// used order, when the disk usage of an image filesystem is over the high
+// threshold. Each filesystem holding the snapshots of a snapshotter is
+// checked, and only the images unpacked on an over used filesystem are
+// removed for it. It is an alternative of kubelet image garbage collection.
+type imageGCManager struct {
+	c                    *criService
+	period               time.Duration
+	highThresholdPercent int
+	lowThresholdPercent  int
+	// lastUsed is the last time an image is detected to be used by a container.
+	// Images never used are recorded with the time they are first detected.
+	// It is only accessed by the gc goroutine.
+	lastUsed map[string]time.Time
+}
+
+// imageRecord is the usage record of an image.
+type imageRecord struct {
+	id       string
+	size     uint64
+	lastUsed time.Time
+	inUse    bool
+	// snapshotters are the snapshotters the image is unpacked into.
+	snapshotters map[string]bool
+}
+
+// imageFilesystem is a filesystem holding the snapshots of one or more
+// snapshotters.
+type imageFilesystem struct {
+	path         string
+	snapshotters []string
+}
+
+// newImageGCManager creates an image gc manager.
+func newImageGCManager(c *criService, config criconfig.ImageGCConfig) (*imageGCManager, error) {
+	period, err := time.ParseDuration(config.Period)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid image gc period %q", config.Period)
+	}
+	if period <= 0 {
+		return nil, errors.Errorf("image gc period %q must be positive", config.Period)
+	}
+	if config.HighThresholdPercent < 0 || config.HighThresholdPercent > 100 {
+		return nil, errors.Errorf("invalid image gc high threshold %d, must be in range [0, 100]", config.HighThresholdPercent)
+	}
+	if config.LowThresholdPercent < 0 || config.LowThresholdPercent > config.HighThresholdPercent {
+		return nil, errors.Errorf("invalid image gc low threshold %d, must be in range [0, %d]",
+			config.LowThresholdPercent, config.HighThresholdPercent)
+	}
+	return &imageGCManager{
+		c:                    c,
+		period:               period,
+		highThresholdPercent: config.HighThresholdPercent,
+		lowThresholdPercent:  config.LowThresholdPercent,
+		lastUsed:             make(map[string]time.Time),
+	}, nil
+}
+
+// start starts the image gc manager. No stop function is needed because
+// the manager doesn't hold any persistent state, it's fine to let it exit
+// with the process.
+func (m *imageGCManager) start() {
+	tick := time.NewTicker(m.period)
+	go func() {
+		defer tick.Stop()
+		for {
+			if err := m.gc(); err != nil {
+				log.Image.WithError(err).Error("Failed to garbage collect images")
+			}
+			<-tick.C
+		}
+	}()
+}
+
+// gc removes least recently used images until the disk usage of each image
+// filesystem is under the low threshold, if it is over the high threshold.
+func (m *imageGCManager) gc() error {
+	ctx := m.c.namespacedContext()
+	images := m.detectImages(ctx, time.Now())
+	removed := make(map[string]bool)
+	var failed int
+	for _, fs := range m.imageFilesystems() {
+		if err := m.gcFilesystem(ctx, fs, images, removed); err != nil {
+			log.Image.WithError(err).Errorf("Failed to garbage collect images on filesystem %q", fs.path)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to garbage collect images on %d filesystems", failed)
+	}
+	return nil
+}
+
+// gcFilesystem removes least recently used images unpacked on a filesystem
+// until its disk usage is under the low threshold, if it is over the high
+// threshold. Images already removed for other filesystems are skipped.
+func (m *imageGCManager) gcFilesystem(ctx context.Context, fs imageFilesystem, images []imageRecord, removed map[string]bool) error {
+	var st unix.Statfs_t
+	if err := unix.Statfs(fs.path, &st); err != nil {
+		return errors.Wrapf(err, "failed to get filesystem info of %q", fs.path)
+	}
+	capacity := st.Blocks * uint64(st.Bsize)
+	available := st.Bavail * uint64(st.Bsize)
+	toFree := bytesToFree(capacity, available, m.highThresholdPercent, m.lowThresholdPercent)
+	if toFree == 0 {
+		return nil
+	}
+	log.Image.Infof("Image filesystem %q usage is over the high threshold %d%%, trying to free %d bytes",
+		fs.path, m.highThresholdPercent, toFree)
+
+	var candidates []imageRecord
+	for _, image := range images {
+		if !removed[image.id] && m.onFilesystem(image, fs) {
+			candidates = append(candidates, image)
+		}
+	}
+	var freed uint64
+	for _, image := range selectImagesToRemove(candidates, toFree) {
+		log.Image.Infof("Removing image %q to free %d bytes", image.id, image.size)
+		if _, err := m.c.RemoveImage(ctx, &runtime.RemoveImageRequest{
+			Image: &runtime.ImageSpec{Image: image.id},
+		}); err != nil {
+			log.Image.WithError(err).Errorf("Failed to remove image %q", image.id)
+			continue
+		}
+		delete(m.lastUsed, image.id)
+		removed[image.id] = true
+		freed += image.size
+	}
+	if freed < toFree {
+		return errors.Errorf("failed to free %d bytes, only %d bytes freed", toFree, freed)
+	}
+	return nil
+}
+
+// imageFilesystems returns the filesystems of the snapshotters. Snapshotters
+// on the same filesystem are grouped, so that the filesystem is checked once.
+func (m *imageGCManager) imageFilesystems() []imageFilesystem {
+	var filesystems []imageFilesystem
+	devices := make(map[uint64]int)
+	for _, snapshotter := range m.c.snapshotters() {
+		path := m.c.imageFSPaths[snapshotter]
+		var st unix.Stat_t
+		if err := unix.Stat(path, &st); err == nil {
+			if i, ok := devices[uint64(st.Dev)]; ok {
+				filesystems[i].snapshotters = append(filesystems[i].snapshotters, snapshotter)
+				continue
+			}
+			devices[uint64(st.Dev)] = len(filesystems)
+		}
+		filesystems = append(filesystems, imageFilesystem{path: path, snapshotters: []string{snapshotter}})
+	}
+	return filesystems
+}
+
+// onFilesystem returns whether an image is unpacked on a filesystem. An image
+// not unpacked into any snapshotter is counted on the filesystem of the
+// default snapshotter, where images are unpacked on pull.
+func (m *imageGCManager) onFilesystem(image imageRecord, fs imageFilesystem) bool {
+	for _, snapshotter := range fs.snapshotters {
+		if image.snapshotters[snapshotter] {
+			return true
+		}
+		if len(image.snapshotters) == 0 && snapshotter == m.c.config.ContainerdConfig.Snapshotter {
+			return true
+		}
+	}
+	return false
+}
+
+// detectImages updates the last used time of all images, and returns their
+// usage records.
+func (m *imageGCManager) detectImages(ctx context.Context, now time.Time) []imageRecord {
+	inUse := make(map[string]bool)
+	for _, cntr := range m.c.containerStore.List() {
+		inUse[cntr.ImageRef] = true
+	}
+	// Never remove pinned images, e.g. the sandbox image used by all pods.
+	for id := range m.c.pinnedImageIDs(ctx) {
+		inUse[id] = true
+	}
+
+	var records []imageRecord
+	current := make(map[string]bool)
+	snapshotters := m.c.snapshotters()
+	for _, image := range m.c.imageStore.List() {
+		current[image.ID] = true
+		if _, ok := m.lastUsed[image.ID]; !ok || inUse[image.ID] {
+			m.lastUsed[image.ID] = now
+		}
+		unpacked := make(map[string]bool)
+		for _, snapshotter := range snapshotters {
+			if image.Image == nil {
+				break
+			}
+			ok, err := image.Image.IsUnpacked(ctx, snapshotter)
+			if err != nil {
+				log.Image.WithError(err).Debugf("Failed to check whether image %q is unpacked into %q", image.ID, snapshotter)
+				continue
+			}
+			if ok {
+				unpacked[snapshotter] = true
+			}
+		}
+		records = append(records, imageRecord{
+			id:           image.ID,
+			size:         uint64(image.Size),
+			lastUsed:     m.lastUsed[image.ID],
+			inUse:        inUse[image.ID],
+			snapshotters: unpacked,
+		})
+	}
+	// Forget images which are already removed.
+	for id := range m.lastUsed {
+		if !current[id] {
+			delete(m.lastUsed, id)
+		}
+	}
+	return records
+}
+
+// bytesToFree returns the number of bytes to free to bring the disk usage under
+// the low threshold, or 0 if the disk usage is not over the high threshold.
+func bytesToFree(capacity, available uint64, highThresholdPercent, lowThresholdPercent int) uint64 {
+	if capacity == 0 || available > capacity {
+		return 0
+	}
+	usagePercent := (capacity - available) * 100 / capacity
+	if usagePercent < uint64(highThresholdPercent) {
+		return 0
+	}
+	targetAvailable := capacity * uint64(100-lowThresholdPercent) / 100
+	if available >= targetAvailable {
+		return 0
+	}
+	return targetAvailable - available
+}
+
+// selectImagesToRemove selects unused images in least recently used order,
+// until the total size of the selected images reaches toFree.
+func selectImagesToRemove(images []imageRecord, toFree uint64) []imageRecord {
+	var candidates []imageRecord
+	for _, image := range images {
+		if !image.inUse {
+			candidates = append(candidates, image)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].lastUsed.Before(candidates[j].lastUsed)
+	})
+	var (
+		selected []imageRecord
+		size     uint64
+	)
+	for _, image := range candidates {
+		if size >= toFree {
+			break
+		}
+		selected = append(selected, image)
+		size += image.size
+	}
+	return selected
+}
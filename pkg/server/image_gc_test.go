@@ -0,0 +1,193 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestNewImageGCManager(t *testing.T) {
+	for desc, test := range map[string]struct {
+		config      criconfig.ImageGCConfig
+		expectedErr bool
+	}{
+		"default config should be valid": {
+			config: criconfig.DefaultConfig().ImageGC,
+		},
+		"invalid period should fail": {
+			config:      criconfig.ImageGCConfig{Period: "invalid", HighThresholdPercent: 85, LowThresholdPercent: 80},
+			expectedErr: true,
+		},
+		"non-positive period should fail": {
+			config:      criconfig.ImageGCConfig{Period: "0s", HighThresholdPercent: 85, LowThresholdPercent: 80},
+			expectedErr: true,
+		},
+		"high threshold over 100 should fail": {
+			config:      criconfig.ImageGCConfig{Period: "5m", HighThresholdPercent: 101, LowThresholdPercent: 80},
+			expectedErr: true,
+		},
+		"low threshold over high threshold should fail": {
+			config:      criconfig.ImageGCConfig{Period: "5m", HighThresholdPercent: 80, LowThresholdPercent: 85},
+			expectedErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		_, err := newImageGCManager(newTestCRIService(), test.config)
+		assert.Equal(t, test.expectedErr, err != nil)
+	}
+}
+
+func TestBytesToFree(t *testing.T) {
+	for desc, test := range map[string]struct {
+		capacity  uint64
+		available uint64
+		expected  uint64
+	}{
+		"usage under high threshold": {
+			capacity:  1000,
+			available: 200,
+			expected:  0,
+		},
+		"usage at high threshold": {
+			capacity:  1000,
+			available: 150,
+			expected:  50,
+		},
+		"usage over high threshold": {
+			capacity:  1000,
+			available: 100,
+			expected:  100,
+		},
+		"zero capacity": {
+			capacity:  0,
+			available: 0,
+			expected:  0,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		assert.Equal(t, test.expected, bytesToFree(test.capacity, test.available, 85, 80))
+	}
+}
+
+func TestSelectImagesToRemove(t *testing.T) {
+	now := time.Now()
+	images := []imageRecord{
+		{id: "recent", size: 100, lastUsed: now},
+		{id: "in-use", size: 100, lastUsed: now.Add(-3 * time.Hour), inUse: true},
+		{id: "oldest", size: 100, lastUsed: now.Add(-2 * time.Hour)},
+		{id: "older", size: 100, lastUsed: now.Add(-time.Hour)},
+	}
+	for desc, test := range map[string]struct {
+		toFree   uint64
+		expected []string
+	}{
+		"nothing to free": {
+			toFree: 0,
+		},
+		"should remove least recently used image first": {
+			toFree:   50,
+			expected: []string{"oldest"},
+		},
+		"should remove images until enough space is freed": {
+			toFree:   150,
+			expected: []string{"oldest", "older"},
+		},
+		"should not remove image in use": {
+			toFree:   1000,
+			expected: []string{"oldest", "older", "recent"},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		var ids []string
+		for _, image := range selectImagesToRemove(images, test.toFree) {
+			ids = append(ids, image.id)
+		}
+		assert.Equal(t, test.expected, ids)
+	}
+}
+
+func TestImageFilesystems(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-image-filesystems")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	c := newTestCRIService()
+	c.config.ContainerdConfig.Snapshotter = "overlayfs"
+	c.config.ContainerdConfig.Runtimes = map[string]criconfig.Runtime{
+		"kata":   {Snapshotter: "devmapper"},
+		"gvisor": {Snapshotter: "native"},
+	}
+	c.imageFSPaths = map[string]string{
+		"overlayfs": filepath.Join(dir, "overlayfs"),
+		"native":    filepath.Join(dir, "native"),
+		"devmapper": filepath.Join(dir, "not-exist"),
+	}
+	require.NoError(t, os.MkdirAll(c.imageFSPaths["overlayfs"], 0755))
+	require.NoError(t, os.MkdirAll(c.imageFSPaths["native"], 0755))
+	m, err := newImageGCManager(c, criconfig.ImageGCConfig{Period: "5m", HighThresholdPercent: 85, LowThresholdPercent: 80})
+	require.NoError(t, err)
+
+	t.Logf("should group snapshotters on the same filesystem")
+	assert.Equal(t, []imageFilesystem{
+		{path: c.imageFSPaths["overlayfs"], snapshotters: []string{"overlayfs", "native"}},
+		{path: c.imageFSPaths["devmapper"], snapshotters: []string{"devmapper"}},
+	}, m.imageFilesystems())
+}
+
+func TestOnFilesystem(t *testing.T) {
+	c := newTestCRIService()
+	c.config.ContainerdConfig.Snapshotter = "overlayfs"
+	m, err := newImageGCManager(c, criconfig.ImageGCConfig{Period: "5m", HighThresholdPercent: 85, LowThresholdPercent: 80})
+	require.NoError(t, err)
+	defaultFS := imageFilesystem{path: "/default", snapshotters: []string{"overlayfs"}}
+	otherFS := imageFilesystem{path: "/other", snapshotters: []string{"devmapper"}}
+	for desc, test := range map[string]struct {
+		snapshotters    map[string]bool
+		expectedDefault bool
+		expectedOther   bool
+	}{
+		"image unpacked into the default snapshotter should be on its filesystem": {
+			snapshotters:    map[string]bool{"overlayfs": true},
+			expectedDefault: true,
+		},
+		"image unpacked into another snapshotter should be on its filesystem": {
+			snapshotters:  map[string]bool{"devmapper": true},
+			expectedOther: true,
+		},
+		"image unpacked into both snapshotters should be on both filesystems": {
+			snapshotters:    map[string]bool{"overlayfs": true, "devmapper": true},
+			expectedDefault: true,
+			expectedOther:   true,
+		},
+		"image not unpacked should be on the default filesystem": {
+			expectedDefault: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		image := imageRecord{id: "image", snapshotters: test.snapshotters}
+		assert.Equal(t, test.expectedDefault, m.onFilesystem(image, defaultFS))
+		assert.Equal(t, test.expectedOther, m.onFilesystem(image, otherFS))
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"strings"
+
+	"github.com/containerd/containerd/errdefs"
+	containerdimages "github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/containerd/remotes"
+	snapshot "github.com/containerd/containerd/snapshots"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/identity"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/containerd/cri/pkg/log"
+	"github.com/containerd/cri/pkg/util"
+)
+
+const (
+	// targetSnapshotLabel is the label telling the snapshotter the name of the
+	// committed snapshot a prepared snapshot is going to be. A remote
+	// snapshotter commits the snapshot itself and returns ErrAlreadyExists
+	// when the layer can be lazily mounted from the registry.
+	targetSnapshotLabel = "containerd.io/snapshot.ref"
+	// snapshotLabelPrefix is the prefix of labels passed to remote
+	// snapshotters. Layer annotations with the prefix, e.g. the eStargz TOC
+	// digest, are passed along.
+	snapshotLabelPrefix = "containerd.io/snapshot/"
+	// targetImageRefLabel is the label of the reference of the image being
+	// pulled, used by remote snapshotters to access the registry.
+	targetImageRefLabel = snapshotLabelPrefix + "cri.image-ref"
+	// targetLayerDigestLabel is the label of the digest of the layer being
+	// prepared.
+	targetLayerDigestLabel = snapshotLabelPrefix + "cri.layer-digest"
+)
+
+// prepareRemoteSnapshots fetches the manifest and config of an image, and asks
+// the snapshotter to prepare the image layers from the bottom up as remote
+// snapshots. It returns the layers lazily mounted by the snapshotter, which
+// don't need to be fetched. Layers above the first one the snapshotter can't
+// lazily mount are pulled as usual.
+func (c *criService) prepareRemoteSnapshots(ctx context.Context, ref string, resolver remotes.Resolver, target imagespec.Descriptor) (map[digest.Digest]struct{}, error) {
+	fetcher, err := resolver.Fetcher(ctx, ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get fetcher for %q", ref)
+	}
+	store := c.client.ContentStore()
+	// Fetch everything except layers.
+	children := containerdimages.FilterPlatforms(containerdimages.ChildrenHandler(store), platforms.Default())
+	handler := containerdimages.Handlers(
+		remotes.FetchHandler(store, fetcher),
+		containerdimages.HandlerFunc(func(ctx context.Context, desc imagespec.Descriptor) ([]imagespec.Descriptor, error) {
+			descs, err := children(ctx, desc)
+			if err != nil {
+				return nil, err
+			}
+			var nonLayers []imagespec.Descriptor
+			for _, d := range descs {
+				if !isLayerDescriptor(d) {
+					nonLayers = append(nonLayers, d)
+				}
+			}
+			return nonLayers, nil
+		}),
+	)
+	if err := containerdimages.Dispatch(ctx, handler, target); err != nil {
+		return nil, errors.Wrap(err, "failed to fetch image manifest and config")
+	}
+	manifest, err := containerdimages.Manifest(ctx, store, target, platforms.Default())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get image manifest")
+	}
+	diffIDs, err := containerdimages.RootFS(ctx, store, manifest.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get image rootfs")
+	}
+	if len(diffIDs) != len(manifest.Layers) {
+		return nil, errors.Errorf("mismatched image rootfs and manifest layers")
+	}
+
+	var (
+		sn     = c.client.SnapshotService(c.config.ContainerdConfig.Snapshotter)
+		chain  []digest.Digest
+		parent string
+		lazy   = make(map[digest.Digest]struct{})
+	)
+	for i, layer := range manifest.Layers {
+		chain = append(chain, diffIDs[i])
+		chainID := identity.ChainID(chain).String()
+		labels := map[string]string{
+			targetImageRefLabel:    ref,
+			targetLayerDigestLabel: layer.Digest.String(),
+		}
+		for k, v := range layer.Annotations {
+			if strings.HasPrefix(k, snapshotLabelPrefix) {
+				labels[k] = v
+			}
+		}
+		ok, err := prepareRemoteSnapshot(ctx, sn, chainID, parent, labels)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to prepare remote snapshot for layer %q", layer.Digest)
+		}
+		if !ok {
+			log.Image.Debugf("Layer %q of image %q can't be lazily pulled", layer.Digest, ref)
+			break
+		}
+		lazy[layer.Digest] = struct{}{}
+		parent = chainID
+	}
+	return lazy, nil
+}
+
+// prepareRemoteSnapshot prepares the snapshot of a layer with the target
+// snapshot label, and returns whether the snapshot is available without
+// fetching and applying the layer.
+func prepareRemoteSnapshot(ctx context.Context, sn snapshot.Snapshotter, chainID, parent string, labels map[string]string) (bool, error) {
+	if _, err := sn.Stat(ctx, chainID); err == nil {
+		return true, nil
+	} else if !errdefs.IsNotFound(err) {
+		return false, errors.Wrapf(err, "failed to stat snapshot %q", chainID)
+	}
+	labels[targetSnapshotLabel] = chainID
+	key := "remote-" + util.GenerateID()
+	if _, err := sn.Prepare(ctx, key, parent, snapshot.WithLabels(labels)); err != nil {
+		if errdefs.IsAlreadyExists(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	// The snapshotter is not a remote snapshotter, or it can't lazily mount
+	// the layer.
+	if err := sn.Remove(ctx, key); err != nil {
+		log.Image.WithError(err).Errorf("Failed to remove snapshot %q", key)
+	}
+	return false, nil
+}
+
+// skipLayersHandler stops fetching the given layers.
+func skipLayersHandler(layers map[digest.Digest]struct{}) containerdimages.Handler {
+	return containerdimages.HandlerFunc(func(ctx context.Context, desc imagespec.Descriptor) ([]imagespec.Descriptor, error) {
+		if _, ok := layers[desc.Digest]; ok {
+			return nil, containerdimages.ErrStopHandler
+		}
+		return nil, nil
+	})
+}
+
+// isLayerDescriptor returns whether a descriptor is an image layer.
+func isLayerDescriptor(desc imagespec.Descriptor) bool {
+	switch desc.MediaType {
+	case containerdimages.MediaTypeDockerSchema2Manifest, imagespec.MediaTypeImageManifest,
+		containerdimages.MediaTypeDockerSchema2ManifestList, imagespec.MediaTypeImageIndex,
+		containerdimages.MediaTypeDockerSchema2Config, imagespec.MediaTypeImageConfig:
+		return false
+	}
+	return true
+}
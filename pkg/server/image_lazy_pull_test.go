@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	containerdimages "github.com/containerd/containerd/images"
+	"github.com/opencontainers/go-digest"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestSkipLayersHandler(t *testing.T) {
+	lazy := digest.FromString("lazy")
+	handler := skipLayersHandler(map[digest.Digest]struct{}{lazy: {}})
+	for desc, test := range map[string]struct {
+		desc        imagespec.Descriptor
+		expectedErr error
+	}{
+		"should stop handling lazily pulled layer": {
+			desc:        imagespec.Descriptor{MediaType: imagespec.MediaTypeImageLayerGzip, Digest: lazy},
+			expectedErr: containerdimages.ErrStopHandler,
+		},
+		"should not stop handling other layer": {
+			desc: imagespec.Descriptor{MediaType: imagespec.MediaTypeImageLayerGzip, Digest: digest.FromString("other")},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		children, err := handler.Handle(context.Background(), test.desc)
+		assert.Equal(t, test.expectedErr, err)
+		assert.Empty(t, children)
+	}
+}
+
+func TestIsLayerDescriptor(t *testing.T) {
+	for mediaType, expected := range map[string]bool{
+		imagespec.MediaTypeImageManifest:                 false,
+		imagespec.MediaTypeImageIndex:                    false,
+		imagespec.MediaTypeImageConfig:                   false,
+		containerdimages.MediaTypeDockerSchema2Manifest:  false,
+		containerdimages.MediaTypeDockerSchema2Config:    false,
+		imagespec.MediaTypeImageLayerGzip:                true,
+		containerdimages.MediaTypeDockerSchema2LayerGzip: true,
+		"application/vnd.oci.image.layer.v1.tar+zstd":    true,
+	} {
+		assert.Equal(t, expected, isLayerDescriptor(imagespec.Descriptor{MediaType: mediaType}), mediaType)
+	}
+}
@@ -21,11 +21,11 @@ import (
 	"path/filepath"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 
 	api "github.com/containerd/cri/pkg/api/v1"
 	"github.com/containerd/cri/pkg/containerd/importer"
+	"github.com/containerd/cri/pkg/log"
 	imagestore "github.com/containerd/cri/pkg/store/image"
 )
 
@@ -49,7 +49,7 @@ func (c *criService) LoadImage(ctx context.Context, r *api.LoadImageRequest) (*a
 			return nil, errors.Wrapf(err, "failed to get image %q", repoTag)
 		}
 		if err := image.Unpack(ctx, c.config.ContainerdConfig.Snapshotter); err != nil {
-			logrus.WithError(err).Warnf("Failed to unpack image %q", repoTag)
+			log.Image.WithError(err).Warnf("Failed to unpack image %q", repoTag)
 			// Do not fail image importing. Unpack will be retried when container creation.
 		}
 		info, err := getImageInfo(ctx, image)
@@ -74,7 +74,7 @@ func (c *criService) LoadImage(ctx context.Context, r *api.LoadImageRequest) (*a
 		if err := c.imageStore.Add(img); err != nil {
 			return nil, errors.Wrapf(err, "failed to add image %q into store", id)
 		}
-		logrus.Debugf("Imported image with id %q, repo tag %q", id, repoTag)
+		log.Image.Debugf("Imported image with id %q, repo tag %q", id, repoTag)
 	}
 	return &api.LoadImageResponse{Images: repoTags}, nil
 }
@@ -0,0 +1,46 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"golang.org/x/net/context"
+
+	imagestore "github.com/containerd/cri/pkg/store/image"
+)
+
+// pinnedImageIDs returns the ids of the pinned images which exist. Pinned
+// images are never removed, neither by image garbage collection nor by
+// RemoveImage. The sandbox image, which is used by all pods, and the images
+// in the pinned_images config are pinned.
+// TODO: Report pinned images with the CRI pinned image field once it is in
+// the vendored CRI API. It is reported in the verbose image status for now.
+func (c *criService) pinnedImageIDs(ctx context.Context) map[string]bool {
+	ids := make(map[string]bool)
+	for _, ref := range append([]string{c.reloadableConfig().SandboxImage}, c.config.PinnedImages...) {
+		image, err := c.localResolve(ctx, ref)
+		if err != nil || image == nil {
+			continue
+		}
+		ids[image.ID] = true
+	}
+	return ids
+}
+
+// isPinnedImage returns whether an image is pinned.
+func (c *criService) isPinnedImage(ctx context.Context, image *imagestore.Image) bool {
+	return c.pinnedImageIDs(ctx)[image.ID]
+}
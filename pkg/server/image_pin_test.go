@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	imagestore "github.com/containerd/cri/pkg/store/image"
+)
+
+func TestPinnedImages(t *testing.T) {
+	sandboxImage := imagestore.Image{
+		ID: "sha256:d848ce12891bf78792cda4a23c58984033b0c397a55e93a1556202222ecc5ed4",
+	}
+	pinnedImage := imagestore.Image{
+		ID: "sha256:6b7a1e5a5d2ad5d6e9e3e4b8ad9f0e1fa1a2a3a4a5a6a7a8a9aaabacadaeafb0",
+	}
+	otherImage := imagestore.Image{
+		ID: "sha256:350f3e8cb9fd8bfeb2d3e3bdd6e7c4dac4bfa2fd4cbcfa2f5fd6d0c2a82ad4d5",
+	}
+	c := newTestCRIService()
+	// Use image ids as references to resolve them without containerd.
+	c.config.SandboxImage = sandboxImage.ID
+	c.config.PinnedImages = []string{pinnedImage.ID}
+	ctx := context.Background()
+
+	t.Logf("should not pin images which don't exist")
+	assert.Empty(t, c.pinnedImageIDs(ctx))
+
+	for _, image := range []imagestore.Image{sandboxImage, pinnedImage, otherImage} {
+		require.NoError(t, c.imageStore.Add(image))
+	}
+	t.Logf("should pin the sandbox image and configured images")
+	assert.Equal(t, map[string]bool{sandboxImage.ID: true, pinnedImage.ID: true}, c.pinnedImageIDs(ctx))
+	assert.True(t, c.isPinnedImage(ctx, &sandboxImage))
+	assert.True(t, c.isPinnedImage(ctx, &pinnedImage))
+	assert.False(t, c.isPinnedImage(ctx, &otherImage))
+
+	t.Logf("should not remove pinned images")
+	for _, image := range []imagestore.Image{sandboxImage, pinnedImage} {
+		_, err := c.RemoveImage(ctx, &runtime.RemoveImageRequest{
+			Image: &runtime.ImageSpec{Image: image.ID},
+		})
+		assert.Error(t, err)
+		_, err = c.imageStore.Get(image.ID)
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+	"github.com/containerd/cri/pkg/log"
+	"github.com/containerd/cri/pkg/util"
+)
+
+const (
+	// defaultPrePullConcurrency is the default maximum number of images
+	// pulled at the same time by PrePullImages.
+	defaultPrePullConcurrency = 3
+	// prePullProgressInterval is the interval of the progress reported for
+	// each image being pulled by PrePullImages.
+	prePullProgressInterval = time.Second
+)
+
+// prePullFunc pulls an image and returns the image id.
+type prePullFunc func(ctx context.Context, image string) (string, error)
+
+// prePullProgressFunc returns the fetched and total bytes of an image being
+// pulled, and false if the image is not being pulled.
+type prePullProgressFunc func(ctx context.Context, image string) (int64, int64, bool)
+
+// PrePullImages pulls a batch of images with bounded concurrency, and streams
+// the progress of each image. Failure of an image doesn't stop the others.
+func (c *criService) PrePullImages(r *api.PrePullImagesRequest, s api.CRIPluginService_PrePullImagesServer) error {
+	concurrency := prePullConcurrency(r.GetMaxConcurrentPulls(), c.config.MaxPrePullConcurrency)
+	// Send is not safe to be called concurrently.
+	var mu sync.Mutex
+	send := func(res *api.PrePullImagesResponse) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return s.Send(res)
+	}
+	ctx := c.withNamespace(s.Context())
+	return prePullImages(ctx, r.GetImages(), concurrency, c.prePullImage, c.imagePullProgress, send, prePullProgressInterval)
+}
+
+// prePullConcurrency returns the concurrency of a PrePullImages request, the
+// one requested by the client or the default, capped at the config maximum.
+func prePullConcurrency(requested int32, max int) int {
+	concurrency := int(requested)
+	if concurrency <= 0 {
+		concurrency = defaultPrePullConcurrency
+	}
+	if concurrency > max {
+		concurrency = max
+	}
+	return concurrency
+}
+
+// prePullImage pulls an image with the registry credentials in the config.
+// It goes through the instrumented PullImage, so that each pull is audited
+// and rate limited like the ones of kubelet.
+func (c *criService) prePullImage(ctx context.Context, image string) (string, error) {
+	res, err := newInstrumentedService(c).PullImage(ctx, &runtime.PullImageRequest{Image: &runtime.ImageSpec{Image: image}})
+	if err != nil {
+		return "", err
+	}
+	return res.GetImageRef(), nil
+}
+
+// imagePullProgress returns the fetched and total bytes of the contents of an
+// in progress image pull.
+func (c *criService) imagePullProgress(ctx context.Context, image string) (int64, int64, bool) {
+	namedRef, err := util.NormalizeImageRef(image)
+	if err != nil {
+		return 0, 0, false
+	}
+	store := c.client.ContentStore()
+	for _, p := range c.imagePullTracker.list() {
+		if p.image != namedRef.String() {
+			continue
+		}
+		var offset, total int64
+		for _, desc := range p.descriptors() {
+			progress := getContentProgress(ctx, store, desc)
+			offset += progress.Offset
+			total += progress.Total
+		}
+		return offset, total, true
+	}
+	return 0, 0, false
+}
+
+// prePullImages pulls the images with at most concurrency pulls at the same
+// time, and sends the progress of each image. It stops and returns the error
+// if the progress fails to be sent.
+func prePullImages(ctx context.Context, images []string, concurrency int, pull prePullFunc,
+	progress prePullProgressFunc, send func(*api.PrePullImagesResponse) error, interval time.Duration) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		sendErr error
+	)
+	trySend := func(res *api.PrePullImagesResponse) {
+		if err := send(res); err != nil {
+			errOnce.Do(func() {
+				sendErr = err
+				cancel()
+			})
+		}
+	}
+	sem := make(chan struct{}, concurrency)
+	for _, image := range images {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(image string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			prePullOneImage(ctx, image, pull, progress, trySend, interval)
+		}(image)
+	}
+	wg.Wait()
+	return sendErr
+}
+
+// prePullOneImage pulls an image, and sends its progress periodically until
+// the pull finishes.
+func prePullOneImage(ctx context.Context, image string, pull prePullFunc,
+	progress prePullProgressFunc, send func(*api.PrePullImagesResponse), interval time.Duration) {
+	send(&api.PrePullImagesResponse{Image: image, State: api.PrePullImageState_PRE_PULL_IMAGE_STARTED})
+	var (
+		imageRef string
+		err      error
+		done     = make(chan struct{})
+	)
+	go func() {
+		imageRef, err = pull(ctx, image)
+		close(done)
+	}()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			if err != nil {
+				log.Image.WithError(err).Errorf("Failed to pre-pull image %q", image)
+				send(&api.PrePullImagesResponse{
+					Image: image,
+					State: api.PrePullImageState_PRE_PULL_IMAGE_FAILED,
+					Error: err.Error(),
+				})
+				return
+			}
+			send(&api.PrePullImagesResponse{
+				Image:    image,
+				State:    api.PrePullImageState_PRE_PULL_IMAGE_SUCCEEDED,
+				ImageRef: imageRef,
+			})
+			return
+		case <-ticker.C:
+			if offset, total, ok := progress(ctx, image); ok {
+				send(&api.PrePullImagesResponse{
+					Image:  image,
+					State:  api.PrePullImageState_PRE_PULL_IMAGE_IN_PROGRESS,
+					Offset: offset,
+					Total:  total,
+				})
+			}
+		}
+	}
+}
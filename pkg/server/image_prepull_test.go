@@ -0,0 +1,134 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+)
+
+func TestPrePullImages(t *testing.T) {
+	images := []string{"busybox", "alpine", "nginx", "broken", "redis"}
+	var (
+		mu          sync.Mutex
+		inFlight    int
+		maxInFlight int
+		responses   = make(map[string][]*api.PrePullImagesResponse)
+	)
+	pull := func(ctx context.Context, image string) (string, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+		time.Sleep(20 * time.Millisecond)
+		if image == "broken" {
+			return "", errors.New("not found")
+		}
+		return "sha256:" + image, nil
+	}
+	progress := func(ctx context.Context, image string) (int64, int64, bool) {
+		return 1, 2, true
+	}
+	send := func(res *api.PrePullImagesResponse) error {
+		mu.Lock()
+		defer mu.Unlock()
+		responses[res.Image] = append(responses[res.Image], res)
+		return nil
+	}
+	err := prePullImages(context.Background(), images, 2, pull, progress, send, time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, maxInFlight)
+	for _, image := range images {
+		res := responses[image]
+		if !assert.True(t, len(res) > 2, image) {
+			continue
+		}
+		assert.Equal(t, api.PrePullImageState_PRE_PULL_IMAGE_STARTED, res[0].State)
+		for _, r := range res[1 : len(res)-1] {
+			assert.Equal(t, api.PrePullImageState_PRE_PULL_IMAGE_IN_PROGRESS, r.State)
+			assert.EqualValues(t, 1, r.Offset)
+			assert.EqualValues(t, 2, r.Total)
+		}
+		last := res[len(res)-1]
+		if image == "broken" {
+			assert.Equal(t, api.PrePullImageState_PRE_PULL_IMAGE_FAILED, last.State)
+			assert.Equal(t, "not found", last.Error)
+		} else {
+			assert.Equal(t, api.PrePullImageState_PRE_PULL_IMAGE_SUCCEEDED, last.State)
+			assert.Equal(t, "sha256:"+image, last.ImageRef)
+		}
+	}
+
+	t.Logf("should stop pulling if progress fails to be sent")
+	var pulled []string
+	pull = func(ctx context.Context, image string) (string, error) {
+		mu.Lock()
+		pulled = append(pulled, image)
+		mu.Unlock()
+		return "sha256:" + image, nil
+	}
+	send = func(res *api.PrePullImagesResponse) error {
+		return errors.New("stream closed")
+	}
+	err = prePullImages(context.Background(), images, 1, pull, progress, send, time.Hour)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"busybox"}, pulled)
+}
+
+func TestPrePullConcurrency(t *testing.T) {
+	for desc, test := range map[string]struct {
+		requested int32
+		max       int
+		expected  int
+	}{
+		"should use the default if not requested": {
+			max:      5,
+			expected: defaultPrePullConcurrency,
+		},
+		"should use the requested concurrency within the max": {
+			requested: 4,
+			max:       5,
+			expected:  4,
+		},
+		"should cap the requested concurrency at the max": {
+			requested: 100,
+			max:       5,
+			expected:  5,
+		},
+		"should cap the default at the max": {
+			max:      1,
+			expected: 1,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		assert.Equal(t, test.expected, prePullConcurrency(test.requested, test.max))
+	}
+}
@@ -17,20 +17,32 @@ limitations under the License.
 package server
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/errdefs"
 	containerdimages "github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/remotes"
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
 	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
+	criconfig "github.com/containerd/cri/pkg/config"
 	containerdresolver "github.com/containerd/cri/pkg/containerd/resolver"
+	"github.com/containerd/cri/pkg/log"
 	imagestore "github.com/containerd/cri/pkg/store/image"
 	"github.com/containerd/cri/pkg/util"
 )
@@ -77,37 +89,115 @@ import (
 // contents are missing but snapshots are ready, is the image still "READY"?
 
 // PullImage pulls an image with authentication config.
-func (c *criService) PullImage(ctx context.Context, r *runtime.PullImageRequest) (*runtime.PullImageResponse, error) {
-	imageRef := r.GetImage().GetImage()
+// The pull is stopped and partially fetched contents are cleaned up when the
+// request is cancelled by the client.
+func (c *criService) PullImage(ctx context.Context, r *runtime.PullImageRequest) (_ *runtime.PullImageResponse, retErr error) {
+	imageRef, err := c.resolveShortName(ctx, r.GetImage().GetImage(), r.GetAuth(), r.GetSandboxConfig().GetMetadata().GetNamespace())
+	if err != nil {
+		return nil, err
+	}
 	namedRef, err := util.NormalizeImageRef(imageRef)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse image reference %q", imageRef)
 	}
 	ref := namedRef.String()
 	if ref != imageRef {
-		logrus.Debugf("PullImage using normalized image ref: %q", ref)
+		log.Image.Debugf("PullImage using normalized image ref: %q", ref)
 	}
-	resolver := containerdresolver.NewResolver(containerdresolver.Options{
-		Credentials: func(string) (string, string, error) { return ParseAuth(r.GetAuth()) },
-		Client:      http.DefaultClient,
-		Registry:    c.getResolverOptions(),
-	})
-	_, desc, err := resolver.Resolve(ctx, ref)
+	start := time.Now()
+	pull, done := c.imagePullTracker.start(ref)
+	defer func() {
+		done()
+		switch {
+		case retErr == nil:
+			imagePulls.WithValues(imagePullSucceeded).Inc()
+			imagePullDuration.UpdateSince(start)
+		case ctx.Err() != nil:
+			imagePulls.WithValues(imagePullCancelled).Inc()
+		default:
+			imagePulls.WithValues(imagePullFailed).Inc()
+		}
+	}()
+	resolver, err := c.getResolver(ctx, namedRef, r.GetAuth())
+	if err != nil {
+		return nil, err
+	}
+	name, desc, err := resolver.Resolve(ctx, ref)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to resolve image %q", ref)
 	}
+	if err := c.verifyImage(ctx, namedRef, resolver, desc); err != nil {
+		return nil, errors.Wrapf(err, "failed to verify image %q", ref)
+	}
+	// Pull the verified manifest, so that a tag moved after the verification
+	// is not pulled unverified.
+	resolver = &pinnedResolver{Resolver: resolver, ref: ref, name: name, desc: desc}
+	// Count the bytes fetched from the registry, contents already in the
+	// content store are not fetched again. Layers unpacked during pull are
+	// applied when their fetches finish.
+	fetchNotifier := newFetchNotifier()
+	fetched := &observedResolver{Resolver: resolver, fetchDone: fetchNotifier.notify}
+	resolver = fetched
 	// We have to check schema1 here, because after `Pull`, schema1
 	// image has already been converted.
 	isSchema1 := desc.MediaType == containerdimages.MediaTypeDockerSchema1Manifest
 
-	image, err := c.client.Pull(ctx, ref,
+	// Skip fetching layers which can be lazily mounted by a remote snapshotter.
+	// Fetched contents are protected by the lease until the image is created.
+	var lazyLayers map[digest.Digest]struct{}
+	if c.config.ContainerdConfig.LazyPull && !isSchema1 {
+		var leaseDone func(context.Context) error
+		ctx, leaseDone, err = c.client.WithLease(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create lease")
+		}
+		defer leaseDone(ctx) // nolint: errcheck
+		lazyLayers, err = c.prepareRemoteSnapshots(ctx, ref, resolver, desc)
+		if err != nil {
+			log.Image.WithError(err).Warnf("Failed to prepare remote snapshots for image %q, pull all layers", ref)
+		}
+	}
+	pullOpts := []containerd.RemoteOpt{
 		containerd.WithSchema1Conversion,
 		containerd.WithResolver(resolver),
 		containerd.WithPullSnapshotter(c.config.ContainerdConfig.Snapshotter),
-		containerd.WithPullUnpack,
-	)
+		containerd.WithImageHandler(rejectEncryptedLayersHandler()),
+	}
+	if len(lazyLayers) > 0 {
+		log.Image.Debugf("Lazily pull %d layers of image %q", len(lazyLayers), ref)
+		pullOpts = append(pullOpts, containerd.WithImageHandler(skipLayersHandler(lazyLayers)))
+	}
+	pullOpts = append(pullOpts, containerd.WithImageHandler(pull.handler()))
+
+	// Unpack layers while the image is being fetched if configured. Schema1
+	// image is converted after being fetched, so it can only be unpacked after
+	// the pull.
+	var unpackErrCh <-chan error
+	pullDone := make(chan struct{})
+	if c.config.ContainerdConfig.UnpackDuringPull && !isSchema1 && len(lazyLayers) == 0 {
+		unpackCtx, unpackCancel := context.WithCancel(ctx)
+		defer unpackCancel()
+		unpackErrCh = c.unpackDuringPull(unpackCtx, desc, fetchNotifier, pullDone)
+	}
+	_, span := startSpan(ctx, "image fetch")
+	image, err := c.client.Pull(ctx, ref, pullOpts...)
+	span.end(err)
+	close(pullDone)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to pull and unpack image %q", ref)
+		c.abortImagePull(pull)
+		return nil, errors.Wrapf(err, "failed to pull image %q", ref)
+	}
+	if unpackErrCh != nil {
+		if err := <-unpackErrCh; err != nil {
+			log.Image.WithError(err).Warnf("Failed to unpack image %q during pull, unpack it after pull", ref)
+		}
+	}
+	// Layers already unpacked during pull are skipped.
+	_, span = startSpan(ctx, "image unpack")
+	err = c.unpackImage(ctx, image)
+	span.end(err)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unpack image %q", ref)
 	}
 
 	// Get image information.
@@ -127,7 +217,7 @@ func (c *criService) PullImage(ctx context.Context, r *runtime.PullImageRequest)
 		}
 	}
 
-	logrus.Debugf("Pulled image %q with image id %q, repo tag %q, repo digest %q", imageRef, imageID,
+	log.Image.Debugf("Pulled image %q with image id %q, repo tag %q, repo digest %q", imageRef, imageID,
 		repoTag, repoDigest)
 	img := imagestore.Image{
 		ID:        imageID,
@@ -146,6 +236,7 @@ func (c *criService) PullImage(ctx context.Context, r *runtime.PullImageRequest)
 	if err := c.imageStore.Add(img); err != nil {
 		return nil, errors.Wrapf(err, "failed to add image %q into store", img.ID)
 	}
+	imagePullBytes.Inc(float64(fetched.bytes()))
 
 	// NOTE(random-liu): the actual state in containerd is the source of truth, even we maintain
 	// in-memory image store, it's only for in-memory indexing. The image could be removed
@@ -184,6 +275,270 @@ func ParseAuth(auth *runtime.AuthConfig) (string, string, error) {
 	return "", "", errors.New("invalid auth config")
 }
 
+// credentials returns a credential function for the resolver of an image
+// pull. The auth config passed in by kubelet takes precedence over the static
+// credentials configured for the registry host, which take precedence over
+// its credential helper. The resolver asks for credentials on every request,
+// so credentials from a credential helper are cached per host for the pull.
+func (c *criService) credentials(ctx context.Context, auth *runtime.AuthConfig) func(string) (string, string, error) {
+	configs := c.reloadableConfig().Registry.Configs
+	var mu sync.Mutex
+	helperCredentials := make(map[string][2]string)
+	return func(host string) (string, string, error) {
+		if auth == nil {
+			config := configs[host]
+			if config.Auth != nil {
+				return ParseAuth(&runtime.AuthConfig{
+					Username:      config.Auth.Username,
+					Password:      config.Auth.Password,
+					Auth:          config.Auth.Auth,
+					IdentityToken: config.Auth.IdentityToken,
+				})
+			}
+			if config.CredentialHelper != "" {
+				mu.Lock()
+				defer mu.Unlock()
+				if cred, ok := helperCredentials[host]; ok {
+					return cred[0], cred[1], nil
+				}
+				username, secret, err := getHelperCredentials(ctx, config.CredentialHelper, host)
+				if err != nil {
+					return "", "", err
+				}
+				helperCredentials[host] = [2]string{username, secret}
+				return username, secret, nil
+			}
+		}
+		return ParseAuth(auth)
+	}
+}
+
+// registryTransport dispatches registry requests to the transport configured
+// for the registry host, and falls back to the default transport.
+type registryTransport struct {
+	transports map[string]http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *registryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if transport, ok := t.transports[req.URL.Host]; ok {
+		return transport.RoundTrip(req)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// registryHTTPClientCache caches the registry http client, so that image
+// pulls reuse the connections of the registry transports. The client is
+// rebuilt when the reloadable config is reloaded, e.g. to read renewed
+// certificates.
+type registryHTTPClientCache struct {
+	mu sync.Mutex
+	// generation is the reloadable config generation client is built from.
+	generation uint64
+	client     *http.Client
+}
+
+// getRegistryHTTPClient returns the http client used to communicate with
+// registries, with the TLS config of each registry applied.
+func (c *criService) getRegistryHTTPClient() (*http.Client, error) {
+	config, generation := c.reloadableConfigWithGeneration()
+	cache := &c.registryHTTPClient
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.client != nil && cache.generation == generation {
+		return cache.client, nil
+	}
+	client, err := newRegistryHTTPClient(config.Registry.Configs)
+	if err != nil {
+		return nil, err
+	}
+	cache.client = client
+	cache.generation = generation
+	return client, nil
+}
+
+// newRegistryHTTPClient creates the http client used to communicate with
+// registries, with the TLS config of each registry applied.
+func newRegistryHTTPClient(configs map[string]criconfig.RegistryConfig) (*http.Client, error) {
+	transports := make(map[string]http.RoundTripper)
+	for host, config := range configs {
+		if config.TLS == nil {
+			continue
+		}
+		tlsConfig, err := getTLSConfig(*config.TLS)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get TLS config for registry %q", host)
+		}
+		transports[host] = newTransport(tlsConfig)
+	}
+	if len(transports) == 0 {
+		return http.DefaultClient, nil
+	}
+	return &http.Client{Transport: &registryTransport{transports: transports}}, nil
+}
+
+// pinnedResolver resolves a reference to the descriptor it was resolved to
+// before, other references are resolved by the wrapped resolver.
+type pinnedResolver struct {
+	remotes.Resolver
+	ref  string
+	name string
+	desc imagespec.Descriptor
+}
+
+// Resolve returns the pinned descriptor of the reference.
+func (r *pinnedResolver) Resolve(ctx context.Context, ref string) (string, imagespec.Descriptor, error) {
+	if ref == r.ref {
+		return r.name, r.desc, nil
+	}
+	return r.Resolver.Resolve(ctx, ref)
+}
+
+// observedResolver counts the bytes of the contents fetched with the wrapped
+// resolver, and reports when their fetches finish.
+type observedResolver struct {
+	remotes.Resolver
+	fetched int64
+	// fetchDone is called when a fetched content is closed, which is after
+	// it's committed into the content store or its fetch failed.
+	fetchDone func()
+}
+
+// Fetcher returns a fetcher observing the fetched contents.
+func (r *observedResolver) Fetcher(ctx context.Context, ref string) (remotes.Fetcher, error) {
+	fetcher, err := r.Resolver.Fetcher(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return remotes.FetcherFunc(func(ctx context.Context, desc imagespec.Descriptor) (io.ReadCloser, error) {
+		rc, err := fetcher.Fetch(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
+		counting := &countingReadCloser{ReadCloser: rc, n: &r.fetched, closed: r.fetchDone}
+		// Keep the reader seekable, so that an interrupted fetch is resumed
+		// instead of discarding the bytes before the offset.
+		if seeker, ok := rc.(io.Seeker); ok {
+			return &countingReadSeekCloser{countingReadCloser: counting, Seeker: seeker}, nil
+		}
+		return counting, nil
+	}), nil
+}
+
+// bytes returns the number of bytes fetched.
+func (r *observedResolver) bytes() int64 {
+	return atomic.LoadInt64(&r.fetched)
+}
+
+// countingReadCloser adds the bytes read to a counter, and calls closed after
+// it's closed if it's not nil.
+type countingReadCloser struct {
+	io.ReadCloser
+	n      *int64
+	closed func()
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	atomic.AddInt64(r.n, int64(n))
+	return n, err
+}
+
+func (r *countingReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	if r.closed != nil {
+		r.closed()
+	}
+	return err
+}
+
+// countingReadSeekCloser is a seekable countingReadCloser.
+type countingReadSeekCloser struct {
+	*countingReadCloser
+	io.Seeker
+}
+
+// getResolver returns the resolver to pull an image with, which pulls through
+// the image distributor if the image is distributed by it.
+func (c *criService) getResolver(ctx context.Context, namedRef reference.Named, auth *runtime.AuthConfig) (remotes.Resolver, error) {
+	client, err := c.getRegistryHTTPClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create registry http client")
+	}
+	options := containerdresolver.Options{
+		Credentials: c.credentials(ctx, auth),
+		Client:      client,
+		Registry:    c.getResolverOptions(),
+		NoUpstream:  c.getNoUpstreamRegistries(),
+	}
+	resolver := containerdresolver.NewResolver(options)
+	if c.distributor == nil {
+		return resolver, nil
+	}
+	distributor, ok := c.distributor.resolver(reference.Domain(namedRef), options)
+	if !ok {
+		return resolver, nil
+	}
+	return &distributedResolver{distributor: distributor, origin: resolver}, nil
+}
+
+// getTLSConfig returns a TLSConfig configured with a CA/Cert/Key specified by registryTLSConfig
+func getTLSConfig(registryTLSConfig criconfig.TLSConfig) (*tls.Config, error) {
+	var (
+		tlsConfig = &tls.Config{}
+		cert      tls.Certificate
+		err       error
+	)
+	if registryTLSConfig.CertFile != "" && registryTLSConfig.KeyFile == "" {
+		return nil, errors.Errorf("cert file %q was specified, but no corresponding key file was specified", registryTLSConfig.CertFile)
+	}
+	if registryTLSConfig.CertFile == "" && registryTLSConfig.KeyFile != "" {
+		return nil, errors.Errorf("key file %q was specified, but no corresponding cert file was specified", registryTLSConfig.KeyFile)
+	}
+	if registryTLSConfig.CertFile != "" && registryTLSConfig.KeyFile != "" {
+		cert, err = tls.LoadX509KeyPair(registryTLSConfig.CertFile, registryTLSConfig.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load cert file")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if registryTLSConfig.CAFile != "" {
+		caCertPool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get system cert pool")
+		}
+		caCert, err := ioutil.ReadFile(registryTLSConfig.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load CA file")
+		}
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.Errorf("no valid certificate found in CA file %q", registryTLSConfig.CAFile)
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	tlsConfig.InsecureSkipVerify = registryTLSConfig.InsecureSkipVerify
+	return tlsConfig, nil
+}
+
+// newTransport returns a new HTTP transport used to pull image.
+func newTransport(tlsConfig *tls.Config) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			DualStack: true,
+		}).DialContext,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 5 * time.Second,
+		TLSClientConfig:       tlsConfig,
+	}
+}
+
 // createImageReference creates image reference inside containerd image store.
 // Note that because create and update are not finished in one transaction, there could be race. E.g.
 // the image reference is deleted by someone else after create returns already exists, but before update
@@ -208,8 +563,20 @@ func (c *criService) createImageReference(ctx context.Context, name string, desc
 
 func (c *criService) getResolverOptions() map[string][]string {
 	options := make(map[string][]string)
-	for ns, mirror := range c.config.Mirrors {
+	for ns, mirror := range c.reloadableConfig().Registry.Mirrors {
 		options[ns] = append(options[ns], mirror.Endpoints...)
 	}
 	return options
 }
+
+// getNoUpstreamRegistries returns the registries whose mirrors don't fall
+// back to the upstream registry.
+func (c *criService) getNoUpstreamRegistries() map[string]bool {
+	noUpstream := make(map[string]bool)
+	for ns, mirror := range c.reloadableConfig().Registry.Mirrors {
+		if mirror.NoUpstream {
+			noUpstream[ns] = true
+		}
+	}
+	return noUpstream
+}
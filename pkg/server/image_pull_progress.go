@@ -0,0 +1,159 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	containerdimages "github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/remotes"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/net/context"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+	"github.com/containerd/cri/pkg/log"
+	"github.com/containerd/cri/pkg/util"
+)
+
+// imagePull is an in progress image pull.
+type imagePull struct {
+	// image is the normalized image reference.
+	image string
+	// startedAt is the time the pull started.
+	startedAt time.Time
+
+	sync.Mutex
+	// contents are the descriptors of all contents fetched by the pull.
+	contents []imagespec.Descriptor
+}
+
+// handler returns an image handler recording all the contents fetched by the pull.
+func (p *imagePull) handler() containerdimages.Handler {
+	return containerdimages.HandlerFunc(func(ctx context.Context, desc imagespec.Descriptor) ([]imagespec.Descriptor, error) {
+		p.Lock()
+		defer p.Unlock()
+		p.contents = append(p.contents, desc)
+		return nil, nil
+	})
+}
+
+// descriptors returns a copy of the contents fetched by the pull.
+func (p *imagePull) descriptors() []imagespec.Descriptor {
+	p.Lock()
+	defer p.Unlock()
+	return append([]imagespec.Descriptor{}, p.contents...)
+}
+
+// imagePullTracker tracks all in progress image pulls.
+type imagePullTracker struct {
+	sync.RWMutex
+	pulls map[string]*imagePull
+}
+
+// newImagePullTracker creates an image pull tracker.
+func newImagePullTracker() *imagePullTracker {
+	return &imagePullTracker{pulls: make(map[string]*imagePull)}
+}
+
+// start starts tracking a new image pull. The returned function must be called
+// to stop tracking when the pull finishes.
+func (t *imagePullTracker) start(image string) (*imagePull, func()) {
+	id := util.GenerateID()
+	p := &imagePull{
+		image:     image,
+		startedAt: time.Now(),
+	}
+	t.Lock()
+	t.pulls[id] = p
+	t.Unlock()
+	imagePullsInProgress.Inc()
+	return p, func() {
+		t.Lock()
+		delete(t.pulls, id)
+		t.Unlock()
+		imagePullsInProgress.Dec()
+	}
+}
+
+// list returns all in progress image pulls ordered by start time.
+func (t *imagePullTracker) list() []*imagePull {
+	t.RLock()
+	defer t.RUnlock()
+	var pulls []*imagePull
+	for _, p := range t.pulls {
+		pulls = append(pulls, p)
+	}
+	sort.Slice(pulls, func(i, j int) bool {
+		return pulls[i].startedAt.Before(pulls[j].startedAt)
+	})
+	return pulls
+}
+
+// ListImagePulls lists in progress image pulls and the progress of the
+// contents they are fetching.
+func (c *criService) ListImagePulls(ctx context.Context, r *api.ListImagePullsRequest) (*api.ListImagePullsResponse, error) {
+	store := c.client.ContentStore()
+	var pulls []*api.ImagePull
+	for _, p := range c.imagePullTracker.list() {
+		pull := &api.ImagePull{
+			Image:     p.image,
+			StartedAt: p.startedAt.UnixNano(),
+		}
+		for _, desc := range p.descriptors() {
+			pull.Contents = append(pull.Contents, getContentProgress(ctx, store, desc))
+		}
+		pulls = append(pulls, pull)
+	}
+	return &api.ListImagePullsResponse{Pulls: pulls}, nil
+}
+
+// getContentProgress returns the fetch progress of a content.
+func getContentProgress(ctx context.Context, store content.Store, desc imagespec.Descriptor) *api.ContentProgress {
+	progress := &api.ContentProgress{
+		Digest:    desc.Digest.String(),
+		MediaType: desc.MediaType,
+		Total:     desc.Size,
+	}
+	if status, err := store.Status(ctx, remotes.MakeRefKey(ctx, desc)); err == nil {
+		progress.Offset = status.Offset
+		return progress
+	}
+	if _, err := store.Info(ctx, desc.Digest); err == nil {
+		// The content is already fetched.
+		progress.Offset = desc.Size
+	}
+	return progress
+}
+
+// abortImagePull aborts the ingestion of all contents fetched by a failed
+// image pull, so that partially fetched contents are cleaned up. The committed
+// contents are garbage collected by containerd after the pull lease expires.
+func (c *criService) abortImagePull(p *imagePull) {
+	ctx, cancel := c.deferContext()
+	defer cancel()
+	store := c.client.ContentStore()
+	for _, desc := range p.descriptors() {
+		ref := remotes.MakeRefKey(ctx, desc)
+		if err := store.Abort(ctx, ref); err != nil && !errdefs.IsNotFound(err) {
+			log.Image.WithError(err).Warnf("Failed to abort ingestion %q of image %q", ref, p.image)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestImagePullTracker(t *testing.T) {
+	tracker := newImagePullTracker()
+	p1, done1 := tracker.start("docker.io/library/busybox:latest")
+	p2, done2 := tracker.start("docker.io/library/alpine:latest")
+	pulls := tracker.list()
+	require.Len(t, pulls, 2)
+	assert.Contains(t, pulls, p1)
+	assert.Contains(t, pulls, p2)
+
+	layer := imagespec.Descriptor{
+		MediaType: imagespec.MediaTypeImageLayerGzip,
+		Digest:    "sha256:c6a7b2a1f0a5e2bb64a7c3a2b0c8b1e2d7b7b6c7d4e5f6a7b8c9d0e1f2a3b4c5",
+		Size:      1024,
+	}
+	_, err := p1.handler().Handle(context.Background(), layer)
+	require.NoError(t, err)
+	assert.Equal(t, []imagespec.Descriptor{layer}, p1.descriptors())
+	assert.Empty(t, p2.descriptors())
+
+	done1()
+	pulls = tracker.list()
+	require.Len(t, pulls, 1)
+	assert.Equal(t, p2, pulls[0])
+	done2()
+	assert.Empty(t, tracker.list())
+}
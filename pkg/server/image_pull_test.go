@@ -18,10 +18,22 @@ package server
 
 import (
 	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/containerd/containerd/remotes"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	criconfig "github.com/containerd/cri/pkg/config"
 )
 
 func TestParseAuth(t *testing.T) {
@@ -72,3 +84,293 @@ func TestParseAuth(t *testing.T) {
 		assert.Equal(t, test.expectedSecret, s)
 	}
 }
+
+func TestCredentials(t *testing.T) {
+	testHost := "registry.test"
+	dir, err := ioutil.TempDir("", "test-credential-helper")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	helper := `#!/bin/sh
+read host
+case "$host" in
+helper.test) echo '{"ServerURL": "helper.test", "Username": "helper-user", "Secret": "helper-password"}' ;;
+token.test) echo '{"ServerURL": "token.test", "Username": "<token>", "Secret": "helper-token"}' ;;
+*) echo "credentials not found in native keychain"; exit 1 ;;
+esac
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "docker-credential-test"), []byte(helper), 0755))
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	require.NoError(t, os.Setenv("PATH", dir+string(os.PathListSeparator)+path))
+
+	for desc, test := range map[string]struct {
+		auth           *runtime.AuthConfig
+		host           string
+		expectedUser   string
+		expectedSecret string
+	}{
+		"should use static credentials if no auth is passed in": {
+			host:           testHost,
+			expectedUser:   "static-user",
+			expectedSecret: "static-password",
+		},
+		"should prefer auth passed in": {
+			auth: &runtime.AuthConfig{
+				Username: "user",
+				Password: "password",
+			},
+			host:           testHost,
+			expectedUser:   "user",
+			expectedSecret: "password",
+		},
+		"should return empty credentials for unknown host": {
+			host: "unknown.test",
+		},
+		"should use credential helper if no static credentials": {
+			host:           "helper.test",
+			expectedUser:   "helper-user",
+			expectedSecret: "helper-password",
+		},
+		"should use identity token from credential helper": {
+			host:           "token.test",
+			expectedSecret: "helper-token",
+		},
+		"should return empty credentials if credential helper has none": {
+			host: "none.test",
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.Registry.Configs = map[string]criconfig.RegistryConfig{
+			testHost: {
+				Auth: &criconfig.AuthConfig{
+					Username: "static-user",
+					Password: "static-password",
+				},
+				CredentialHelper: "test",
+			},
+			"helper.test": {CredentialHelper: "test"},
+			"token.test":  {CredentialHelper: "test"},
+			"none.test":   {CredentialHelper: "test"},
+		}
+		u, s, err := c.credentials(context.Background(), test.auth)(test.host)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expectedUser, u)
+		assert.Equal(t, test.expectedSecret, s)
+	}
+}
+
+func TestCredentialsCacheHelperCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-credential-helper")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	calls := filepath.Join(dir, "calls")
+	helper := `#!/bin/sh
+read host
+echo "$host" >> ` + calls + `
+echo '{"ServerURL": "helper.test", "Username": "helper-user", "Secret": "helper-password"}'
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "docker-credential-test"), []byte(helper), 0755))
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	require.NoError(t, os.Setenv("PATH", dir+string(os.PathListSeparator)+path))
+
+	c := newTestCRIService()
+	c.config.Registry.Configs = map[string]criconfig.RegistryConfig{
+		"helper.test": {CredentialHelper: "test"},
+		"other.test":  {CredentialHelper: "test"},
+	}
+	credentials := c.credentials(context.Background(), nil)
+	for _, host := range []string{"helper.test", "helper.test", "other.test"} {
+		u, s, err := credentials(host)
+		require.NoError(t, err)
+		assert.Equal(t, "helper-user", u)
+		assert.Equal(t, "helper-password", s)
+	}
+	data, err := ioutil.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Equal(t, "helper.test\nother.test\n", string(data), "helper should be called once per host")
+
+	t.Logf("should not reuse credentials across pulls")
+	_, _, err = c.credentials(context.Background(), nil)("helper.test")
+	require.NoError(t, err)
+	data, err = ioutil.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Equal(t, "helper.test\nother.test\nhelper.test\n", string(data))
+
+	t.Logf("should run helper with the request context")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = c.credentials(ctx, nil)("helper.test")
+	assert.Error(t, err)
+}
+
+func TestGetTLSConfig(t *testing.T) {
+	for desc, test := range map[string]struct {
+		config    criconfig.TLSConfig
+		expectErr bool
+	}{
+		"should support insecure skip verify": {
+			config: criconfig.TLSConfig{InsecureSkipVerify: true},
+		},
+		"should return error if only cert file is specified": {
+			config:    criconfig.TLSConfig{CertFile: "/test/cert"},
+			expectErr: true,
+		},
+		"should return error if only key file is specified": {
+			config:    criconfig.TLSConfig{KeyFile: "/test/key"},
+			expectErr: true,
+		},
+		"should return error if CA file doesn't exist": {
+			config:    criconfig.TLSConfig{CAFile: "/non-exist/ca"},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		tlsConfig, err := getTLSConfig(test.config)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.config.InsecureSkipVerify, tlsConfig.InsecureSkipVerify)
+	}
+}
+
+// fakeResolver resolves every reference to the same descriptor.
+type fakeResolver struct {
+	remotes.Resolver
+	desc imagespec.Descriptor
+}
+
+func (r *fakeResolver) Resolve(ctx context.Context, ref string) (string, imagespec.Descriptor, error) {
+	return ref, r.desc, nil
+}
+
+func TestPinnedResolver(t *testing.T) {
+	verified := imagespec.Descriptor{Digest: "sha256:verified"}
+	resolver := &pinnedResolver{
+		Resolver: &fakeResolver{desc: imagespec.Descriptor{Digest: "sha256:moved"}},
+		ref:      "docker.io/library/busybox:latest",
+		name:     "docker.io/library/busybox:latest",
+		desc:     verified,
+	}
+
+	t.Logf("should resolve the pinned reference to the verified descriptor")
+	name, desc, err := resolver.Resolve(context.Background(), "docker.io/library/busybox:latest")
+	require.NoError(t, err)
+	assert.Equal(t, "docker.io/library/busybox:latest", name)
+	assert.Equal(t, verified, desc)
+
+	t.Logf("should resolve other references with the wrapped resolver")
+	_, desc, err = resolver.Resolve(context.Background(), "docker.io/library/busybox:1.0")
+	require.NoError(t, err)
+	assert.Equal(t, imagespec.Descriptor{Digest: "sha256:moved"}, desc)
+}
+
+func TestGetRegistryHTTPClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry-client")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "reloadable.toml")
+	c := newTestCRIService()
+	c.config.PluginConfig = criconfig.DefaultConfig()
+	c.configReloader, err = newConfigReloader(path, c.config)
+	require.NoError(t, err)
+	defer c.configReloader.stop()
+
+	t.Logf("should use the default client without registry tls config")
+	client, err := c.getRegistryHTTPClient()
+	require.NoError(t, err)
+	assert.Equal(t, http.DefaultClient, client)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+[registry.configs."gcr.io".tls]
+  insecure_skip_verify = true
+`), 0600))
+	require.NoError(t, c.configReloader.reload())
+
+	t.Logf("should rebuild the client on reload")
+	client, err = c.getRegistryHTTPClient()
+	require.NoError(t, err)
+	assert.NotEqual(t, http.DefaultClient, client)
+
+	t.Logf("should reuse the client until the next reload")
+	cached, err := c.getRegistryHTTPClient()
+	require.NoError(t, err)
+	assert.True(t, client == cached)
+
+	require.NoError(t, c.configReloader.reload())
+	reloaded, err := c.getRegistryHTTPClient()
+	require.NoError(t, err)
+	assert.False(t, client == reloaded)
+}
+
+// fakeFetchResolver fetches every content from a string.
+type fakeFetchResolver struct {
+	remotes.Resolver
+	content string
+}
+
+func (r *fakeFetchResolver) Fetcher(ctx context.Context, ref string) (remotes.Fetcher, error) {
+	return remotes.FetcherFunc(func(ctx context.Context, desc imagespec.Descriptor) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(r.content)), nil
+	}), nil
+}
+
+func TestObservedResolver(t *testing.T) {
+	closed := 0
+	resolver := &observedResolver{
+		Resolver:  &fakeFetchResolver{content: "0123456789"},
+		fetchDone: func() { closed++ },
+	}
+	fetcher, err := resolver.Fetcher(context.Background(), "docker.io/library/busybox:latest")
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, resolver.bytes())
+
+	t.Logf("should count the bytes read from fetched contents")
+	for i := 0; i < 2; i++ {
+		rc, err := fetcher.Fetch(context.Background(), imagespec.Descriptor{})
+		require.NoError(t, err)
+		_, err = io.CopyN(ioutil.Discard, rc, 4)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+	}
+	assert.EqualValues(t, 8, resolver.bytes())
+	assert.Equal(t, 2, closed, "should report finished fetches")
+
+	t.Logf("should keep only seekable fetched contents seekable")
+	rc, err := fetcher.Fetch(context.Background(), imagespec.Descriptor{})
+	require.NoError(t, err)
+	_, ok := rc.(io.Seeker)
+	assert.False(t, ok)
+	resolver.Resolver = &fakeSeekFetchResolver{}
+	fetcher, err = resolver.Fetcher(context.Background(), "docker.io/library/busybox:latest")
+	require.NoError(t, err)
+	rc, err = fetcher.Fetch(context.Background(), imagespec.Descriptor{})
+	require.NoError(t, err)
+	seeker, ok := rc.(io.Seeker)
+	require.True(t, ok)
+	_, err = seeker.Seek(8, io.SeekStart)
+	require.NoError(t, err)
+	_, err = io.Copy(ioutil.Discard, rc)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, resolver.bytes())
+}
+
+// fakeSeekFetchResolver fetches every content from a seekable string.
+type fakeSeekFetchResolver struct {
+	remotes.Resolver
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+func (r *fakeSeekFetchResolver) Fetcher(ctx context.Context, ref string) (remotes.Fetcher, error) {
+	return remotes.FetcherFunc(func(ctx context.Context, desc imagespec.Descriptor) (io.ReadCloser, error) {
+		return nopSeekCloser{strings.NewReader("0123456789")}, nil
+	}), nil
+}
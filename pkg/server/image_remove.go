@@ -20,9 +20,10 @@ import (
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/images"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/log"
 )
 
 // RemoveImage removes the image.
@@ -40,6 +41,9 @@ func (c *criService) RemoveImage(ctx context.Context, r *runtime.RemoveImageRequ
 		// return empty without error when image not found.
 		return &runtime.RemoveImageResponse{}, nil
 	}
+	if c.isPinnedImage(ctx, image) {
+		return nil, errors.Errorf("image %q is pinned and can't be removed", image.ID)
+	}
 
 	// Exclude outdated image tag.
 	for i, tag := range image.RepoTags {
@@ -63,13 +67,13 @@ func (c *criService) RemoveImage(ctx context.Context, r *runtime.RemoveImageRequ
 			// but different ids generated from compressed contents - manifest digest.
 			// So we decide to leave it.
 			// After all, the user can override the repoTag by pulling image again.
-			logrus.WithError(err).Errorf("Can't remove image,failed to get config for Image tag %q,id %q", tag, image.ID)
+			log.Image.WithError(err).Errorf("Can't remove image,failed to get config for Image tag %q,id %q", tag, image.ID)
 			image.RepoTags = append(image.RepoTags[:i], image.RepoTags[i+1:]...)
 			continue
 		}
 		cID := desc.Digest.String()
 		if cID != image.ID {
-			logrus.Debugf("Image tag %q for %q is outdated, it's currently used by %q", tag, image.ID, cID)
+			log.Image.Debugf("Image tag %q for %q is outdated, it's currently used by %q", tag, image.ID, cID)
 			image.RepoTags = append(image.RepoTags[:i], image.RepoTags[i+1:]...)
 			continue
 		}
@@ -19,11 +19,14 @@ package server
 import (
 	"encoding/json"
 
+	"github.com/containerd/containerd"
+	containerdimages "github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
+	"github.com/containerd/cri/pkg/log"
 	imagestore "github.com/containerd/cri/pkg/store/image"
 	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
 )
@@ -76,6 +79,30 @@ func toCRIRuntimeImage(image *imagestore.Image) *runtime.Image {
 type verboseImageInfo struct {
 	ChainID   string          `json:"chainID"`
 	ImageSpec imagespec.Image `json:"imageSpec"`
+	Pinned    bool            `json:"pinned"`
+	// Target is the descriptor of the image manifest or index.
+	Target *imagespec.Descriptor `json:"target,omitempty"`
+	// Platforms are the platforms of the image.
+	Platforms []string `json:"platforms,omitempty"`
+	// Layers are the layers of the image for the default platform.
+	Layers []verboseImageLayer `json:"layers,omitempty"`
+	// Snapshotter is the snapshotter images are unpacked into.
+	Snapshotter string `json:"snapshotter"`
+	// UnpackedPlatforms are the platforms unpacked into the snapshotter.
+	// Only the default platform is unpacked.
+	UnpackedPlatforms []string `json:"unpackedPlatforms"`
+}
+
+// verboseImageLayer is a layer of an image in the verbose image info.
+type verboseImageLayer struct {
+	// Digest is the digest of the compressed layer.
+	Digest string `json:"digest"`
+	// MediaType is the media type of the compressed layer.
+	MediaType string `json:"mediaType"`
+	// Size is the size of the compressed layer.
+	Size int64 `json:"size"`
+	// DiffID is the digest of the uncompressed layer.
+	DiffID string `json:"diffID"`
 }
 
 // toCRIImageInfo converts internal image object information to CRI image status response info map.
@@ -87,17 +114,64 @@ func (c *criService) toCRIImageInfo(ctx context.Context, image *imagestore.Image
 	info := make(map[string]string)
 
 	imi := &verboseImageInfo{
-		ChainID:   image.ChainID,
-		ImageSpec: image.ImageSpec,
+		ChainID:     image.ChainID,
+		ImageSpec:   image.ImageSpec,
+		Pinned:      c.isPinnedImage(ctx, image),
+		Snapshotter: c.config.ContainerdConfig.Snapshotter,
+	}
+	if image.Image != nil {
+		if err := c.getImageDetails(ctx, image.Image, imi); err != nil {
+			log.Image.WithError(err).Warnf("Failed to get details of image %q", image.ID)
+		}
 	}
 
 	m, err := json.Marshal(imi)
 	if err == nil {
 		info["info"] = string(m)
 	} else {
-		logrus.WithError(err).Errorf("failed to marshal info %v", imi)
+		log.Image.WithError(err).Errorf("failed to marshal info %v", imi)
 		info["info"] = err.Error()
 	}
 
 	return info, nil
 }
+
+// getImageDetails fills the manifest, layer and unpack details of a
+// containerd image into the verbose image info.
+func (c *criService) getImageDetails(ctx context.Context, image containerd.Image, imi *verboseImageInfo) error {
+	provider := c.client.ContentStore()
+	target := image.Target()
+	imi.Target = &target
+	ps, err := containerdimages.Platforms(ctx, provider, target)
+	if err != nil {
+		return errors.Wrap(err, "failed to get platforms")
+	}
+	for _, p := range ps {
+		imi.Platforms = append(imi.Platforms, platforms.Format(p))
+	}
+	platform := platforms.Default()
+	manifest, err := containerdimages.Manifest(ctx, provider, target, platform)
+	if err != nil {
+		return errors.Wrap(err, "failed to get manifest")
+	}
+	diffIDs := imi.ImageSpec.RootFS.DiffIDs
+	for i, l := range manifest.Layers {
+		layer := verboseImageLayer{
+			Digest:    l.Digest.String(),
+			MediaType: l.MediaType,
+			Size:      l.Size,
+		}
+		if i < len(diffIDs) {
+			layer.DiffID = diffIDs[i].String()
+		}
+		imi.Layers = append(imi.Layers, layer)
+	}
+	unpacked, err := image.IsUnpacked(ctx, imi.Snapshotter)
+	if err != nil {
+		return errors.Wrap(err, "failed to check whether image is unpacked")
+	}
+	if unpacked {
+		imi.UnpackedPlatforms = []string{platform}
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package server
 
 import (
+	"encoding/json"
 	"testing"
 
 	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -68,4 +69,17 @@ func TestImageStatus(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 	assert.Equal(t, expected, resp.GetImage())
+
+	t.Logf("should return verbose image info")
+	c.config.ContainerdConfig.Snapshotter = "overlayfs"
+	resp, err = c.ImageStatus(context.Background(), &runtime.ImageStatusRequest{
+		Image:   &runtime.ImageSpec{Image: testID},
+		Verbose: true,
+	})
+	require.NoError(t, err)
+	var info verboseImageInfo
+	require.NoError(t, json.Unmarshal([]byte(resp.GetInfo()["info"]), &info))
+	assert.Equal(t, "test-chain-id", info.ChainID)
+	assert.Equal(t, image.ImageSpec, info.ImageSpec)
+	assert.Equal(t, "overlayfs", info.Snapshotter)
 }
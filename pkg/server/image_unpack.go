@@ -0,0 +1,238 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	containerdimages "github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/containerd/rootfs"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/identity"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// fetchNotifier notifies the waiters of contents being fetched whenever a
+// fetch finishes, i.e. the content is committed into the content store or the
+// fetch failed.
+type fetchNotifier struct {
+	mu sync.Mutex
+	// fetched is closed and replaced when a fetch finishes.
+	fetched chan struct{}
+}
+
+func newFetchNotifier() *fetchNotifier {
+	return &fetchNotifier{fetched: make(chan struct{})}
+}
+
+// notify wakes up the current waiters.
+func (n *fetchNotifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	close(n.fetched)
+	n.fetched = make(chan struct{})
+}
+
+// wait returns a channel which is closed when the next fetch finishes.
+func (n *fetchNotifier) wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.fetched
+}
+
+// acquireUnpack waits until an image is allowed to be unpacked, and returns a
+// function to release it.
+func (c *criService) acquireUnpack(ctx context.Context) (func(), error) {
+	if c.unpackLimiter == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.unpackLimiter <- struct{}{}:
+		return func() { <-c.unpackLimiter }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// unpackImage unpacks an image into the configured snapshotter. Layers which
+// are already unpacked are skipped.
+func (c *criService) unpackImage(ctx context.Context, image containerd.Image) error {
+	return c.unpackImageInto(ctx, image, c.config.ContainerdConfig.Snapshotter)
+}
+
+func (c *criService) unpackImageInto(ctx context.Context, image containerd.Image, snapshotter string) error {
+	release, err := c.acquireUnpack(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to wait for unpack")
+	}
+	defer release()
+	return image.Unpack(ctx, snapshotter)
+}
+
+// ensureImageUnpacked unpacks an image into a snapshotter if it is not yet.
+// Images are only unpacked into the configured snapshotter when they are
+// pulled or loaded, so they are unpacked into the snapshotter of a runtime
+// when the first sandbox or container running with it is created.
+func (c *criService) ensureImageUnpacked(ctx context.Context, image containerd.Image, snapshotter string) error {
+	unpacked, err := image.IsUnpacked(ctx, snapshotter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check whether image is unpacked into snapshotter %q", snapshotter)
+	}
+	if unpacked {
+		return nil
+	}
+	if err := c.unpackImageInto(ctx, image, snapshotter); err != nil {
+		return errors.Wrapf(err, "failed to unpack image into snapshotter %q", snapshotter)
+	}
+	return nil
+}
+
+// unpackDuringPull unpacks the layers of an image being pulled concurrently
+// with the fetch. A layer is applied as soon as its blob is fetched, which the
+// fetches of the pull notify. The layers are still applied in order, the
+// snapshot of a layer is a child of the snapshot of the previous one.
+// pullDone should be closed once the pull finishes, after which contents
+// missing in the content store are never waited for, e.g. contents fetched by
+// another pull. The returned channel receives the unpack result.
+func (c *criService) unpackDuringPull(ctx context.Context, target imagespec.Descriptor, fetched *fetchNotifier, pullDone <-chan struct{}) <-chan error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.unpackLayersInOrder(ctx, target, fetched, pullDone)
+	}()
+	return errCh
+}
+
+func (c *criService) unpackLayersInOrder(ctx context.Context, target imagespec.Descriptor, fetched *fetchNotifier, pullDone <-chan struct{}) error {
+	release, err := c.acquireUnpack(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to wait for unpack")
+	}
+	defer release()
+
+	ctx, done, err := c.client.WithLease(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create lease")
+	}
+	defer done(ctx) // nolint: errcheck
+
+	var (
+		store       = c.client.ContentStore()
+		snapshotter = c.config.ContainerdConfig.Snapshotter
+		manifest    imagespec.Manifest
+		diffIDs     []digest.Digest
+	)
+	if err := waitForContent(ctx, fetched, pullDone, func() error {
+		manifest, err = containerdimages.Manifest(ctx, store, target, platforms.Default())
+		return err
+	}); err != nil {
+		return errors.Wrap(err, "failed to get image manifest")
+	}
+	if err := waitForContent(ctx, fetched, pullDone, func() error {
+		diffIDs, err = containerdimages.RootFS(ctx, store, manifest.Config)
+		return err
+	}); err != nil {
+		return errors.Wrap(err, "failed to get image rootfs")
+	}
+	if len(diffIDs) != len(manifest.Layers) {
+		return errors.Errorf("mismatched image rootfs and manifest layers")
+	}
+
+	var (
+		sn    = c.client.SnapshotService(snapshotter)
+		a     = c.client.DiffService()
+		chain []digest.Digest
+	)
+	for i, blob := range manifest.Layers {
+		if err := waitForContent(ctx, fetched, pullDone, func() error {
+			_, err := store.Info(ctx, blob.Digest)
+			return err
+		}); err != nil {
+			return errors.Wrapf(err, "failed to wait for layer %q", blob.Digest)
+		}
+		layer := rootfs.Layer{
+			Blob: blob,
+			Diff: imagespec.Descriptor{
+				MediaType: imagespec.MediaTypeImageLayer,
+				Digest:    diffIDs[i],
+			},
+		}
+		unpacked, err := rootfs.ApplyLayer(ctx, layer, chain, sn, a)
+		if err != nil {
+			return errors.Wrapf(err, "failed to apply layer %q", blob.Digest)
+		}
+		if unpacked {
+			// Set the uncompressed label after the uncompressed
+			// digest has been verified through apply.
+			cinfo := content.Info{
+				Digest: blob.Digest,
+				Labels: map[string]string{
+					"containerd.io/uncompressed": diffIDs[i].String(),
+				},
+			}
+			if _, err := store.Update(ctx, cinfo, "labels.containerd.io/uncompressed"); err != nil {
+				return errors.Wrapf(err, "failed to label layer %q", blob.Digest)
+			}
+		}
+		chain = append(chain, diffIDs[i])
+	}
+
+	// Reference the snapshot chain from the image config, so that it is not
+	// garbage collected after the lease is released. This is the same with
+	// what containerd does after unpacking an image.
+	label := fmt.Sprintf("containerd.io/gc.ref.snapshot.%s", snapshotter)
+	cinfo := content.Info{
+		Digest: manifest.Config.Digest,
+		Labels: map[string]string{
+			label: identity.ChainID(chain).String(),
+		},
+	}
+	if _, err := store.Update(ctx, cinfo, "labels."+label); err != nil {
+		return errors.Wrap(err, "failed to label image config")
+	}
+	return nil
+}
+
+// waitForContent calls get until the content it reads is available in the
+// content store, whenever a fetch finishes. It stops waiting once the pull is
+// done.
+func waitForContent(ctx context.Context, fetched *fetchNotifier, pullDone <-chan struct{}, get func() error) error {
+	for {
+		// Wait for the fetches finishing after the check, so that no
+		// notification is missed.
+		next := fetched.wait()
+		err := get()
+		if err == nil || !errdefs.IsNotFound(err) {
+			return err
+		}
+		select {
+		case <-pullDone:
+			// Check the last time, the content may be fetched right
+			// before the pull is done.
+			return get()
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-next:
+		}
+	}
+}
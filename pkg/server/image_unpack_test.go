@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestWaitForContent(t *testing.T) {
+	ctx := context.Background()
+
+	t.Logf("should check again whenever a fetch finishes")
+	fetched := newFetchNotifier()
+	attempts := make(chan int)
+	errCh := make(chan error, 1)
+	go func() {
+		attempt := 0
+		errCh <- waitForContent(ctx, fetched, make(chan struct{}), func() error {
+			attempt++
+			attempts <- attempt
+			if attempt < 3 {
+				return errdefs.ErrNotFound
+			}
+			return nil
+		})
+	}()
+	for i := 1; i <= 3; i++ {
+		assert.Equal(t, i, <-attempts)
+		if i < 3 {
+			fetched.notify()
+		}
+	}
+	assert.NoError(t, <-errCh)
+
+	t.Logf("should return other errors directly")
+	err := waitForContent(ctx, newFetchNotifier(), make(chan struct{}), func() error {
+		return errors.New("random error")
+	})
+	assert.EqualError(t, err, "random error")
+
+	t.Logf("should stop waiting once pull is done")
+	pullDone := make(chan struct{})
+	close(pullDone)
+	err = waitForContent(ctx, newFetchNotifier(), pullDone, func() error {
+		return errdefs.ErrNotFound
+	})
+	assert.True(t, errdefs.IsNotFound(err))
+
+	t.Logf("should stop waiting once context is cancelled")
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = waitForContent(cancelledCtx, newFetchNotifier(), make(chan struct{}), func() error {
+		return errdefs.ErrNotFound
+	})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestAcquireUnpack(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCRIService()
+
+	t.Logf("should not limit unpack without limiter")
+	for i := 0; i < 3; i++ {
+		_, err := c.acquireUnpack(ctx)
+		require.NoError(t, err)
+	}
+
+	t.Logf("should limit concurrent unpacks")
+	c.unpackLimiter = make(chan struct{}, 1)
+	release, err := c.acquireUnpack(ctx)
+	require.NoError(t, err)
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = c.acquireUnpack(cancelledCtx)
+	assert.Error(t, err)
+	release()
+	release, err = c.acquireUnpack(ctx)
+	require.NoError(t, err)
+	release()
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/remotes"
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/containerd/cri/pkg/util"
+)
+
+const (
+	// imagePolicyAccept accepts any image.
+	imagePolicyAccept = "accept"
+	// imagePolicyReject rejects any image.
+	imagePolicyReject = "reject"
+	// imagePolicyCosignKey requires a cosign signature by one of the keys.
+	imagePolicyCosignKey = "cosignKey"
+
+	// cosignSignatureAnnotation is the annotation of a cosign signature layer
+	// holding the base64 encoded signature of the layer content.
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// cosignSignatureTagSuffix is the suffix of the tag of the cosign
+	// signatures of a manifest, i.e. "<algorithm>-<hex>.sig".
+	cosignSignatureTagSuffix = ".sig"
+	// maxSignatureBlobSize is the max size of a signature manifest or payload.
+	maxSignatureBlobSize = 1 << 20
+)
+
+// imagePolicy is the image verification policy. The requirement of the
+// longest scope matching the image repository applies, or the default one.
+type imagePolicy struct {
+	Default *imagePolicyRequirement           `json:"default"`
+	Scopes  map[string]imagePolicyRequirement `json:"scopes"`
+}
+
+// imagePolicyRequirement is the requirement of the images in a scope.
+type imagePolicyRequirement struct {
+	Type string `json:"type"`
+	// KeyPaths are the PEM encoded public keys of cosignKey requirements.
+	KeyPaths []string `json:"keyPaths"`
+}
+
+// cosignPayload is the simple signing payload signed by cosign.
+type cosignPayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// loadImagePolicy loads and validates the image verification policy.
+func loadImagePolicy(path string) (*imagePolicy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read image verification policy %q", path)
+	}
+	var policy imagePolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal image verification policy %q", path)
+	}
+	if policy.Default == nil {
+		return nil, errors.New("default requirement is not set")
+	}
+	if err := policy.Default.validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid default requirement")
+	}
+	for scope, r := range policy.Scopes {
+		if err := r.validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid requirement of scope %q", scope)
+		}
+	}
+	return &policy, nil
+}
+
+func (r imagePolicyRequirement) validate() error {
+	switch r.Type {
+	case imagePolicyAccept, imagePolicyReject:
+		return nil
+	case imagePolicyCosignKey:
+		if len(r.KeyPaths) == 0 {
+			return errors.New("no key paths")
+		}
+		return nil
+	}
+	return errors.Errorf("unsupported requirement type %q", r.Type)
+}
+
+// requirement returns the requirement of an image repository, e.g.
+// "docker.io/library/busybox".
+func (p *imagePolicy) requirement(repo string) imagePolicyRequirement {
+	r := *p.Default
+	var matched string
+	for scope, sr := range p.Scopes {
+		if repo != scope && !strings.HasPrefix(repo, scope+"/") {
+			continue
+		}
+		if len(scope) > len(matched) {
+			matched = scope
+			r = sr
+		}
+	}
+	return r
+}
+
+// verifyImage verifies an image resolved to the descriptor against the image
+// verification policy before it is pulled.
+func (c *criService) verifyImage(ctx context.Context, namedRef reference.Named, resolver remotes.Resolver, desc imagespec.Descriptor) error {
+	if c.config.ImageVerificationPolicy == "" {
+		return nil
+	}
+	policy, err := loadImagePolicy(c.config.ImageVerificationPolicy)
+	if err != nil {
+		return errors.Wrap(err, "failed to load image verification policy")
+	}
+	repo := namedRef.Name()
+	r := policy.requirement(repo)
+	switch r.Type {
+	case imagePolicyAccept:
+		return nil
+	case imagePolicyReject:
+		return errors.Errorf("image %q is rejected by the image verification policy", namedRef)
+	}
+	keys, err := loadPublicKeys(r.KeyPaths)
+	if err != nil {
+		return err
+	}
+	return verifyCosignSignature(ctx, resolver, repo, desc.Digest, keys)
+}
+
+// loadPublicKeys loads PEM encoded public keys.
+func loadPublicKeys(paths []string) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read public key %q", path)
+		}
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, errors.Errorf("no PEM data in public key %q", path)
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse public key %q", path)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// verifyCosignSignature verifies that the manifest digest of an image in a
+// repository is signed by one of the keys with cosign.
+func verifyCosignSignature(ctx context.Context, resolver remotes.Resolver, repo string, dgst digest.Digest, keys []crypto.PublicKey) error {
+	sigRef := fmt.Sprintf("%s:%s-%s%s", repo, dgst.Algorithm(), dgst.Hex(), cosignSignatureTagSuffix)
+	_, sigDesc, err := resolver.Resolve(ctx, sigRef)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return errors.Errorf("image %s@%s is not signed", repo, dgst)
+		}
+		return errors.Wrapf(err, "failed to resolve signature %q", sigRef)
+	}
+	fetcher, err := resolver.Fetcher(ctx, sigRef)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get fetcher for signature %q", sigRef)
+	}
+	data, err := fetchSignatureBlob(ctx, fetcher, sigDesc)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch signature manifest %q", sigRef)
+	}
+	var manifest imagespec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal signature manifest %q", sigRef)
+	}
+	lastErr := errors.New("no cosign signature")
+	for _, layer := range manifest.Layers {
+		sig, ok := layer.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		payload, err := fetchSignatureBlob(ctx, fetcher, layer)
+		if err != nil {
+			return errors.Wrapf(err, "failed to fetch signature payload %q", layer.Digest)
+		}
+		if lastErr = verifyCosignPayload(payload, sig, keys, repo, dgst); lastErr == nil {
+			return nil
+		}
+	}
+	return errors.Wrapf(lastErr, "no valid signature of image %s@%s", repo, dgst)
+}
+
+// fetchSignatureBlob fetches a small blob and verifies its digest.
+func fetchSignatureBlob(ctx context.Context, fetcher remotes.Fetcher, desc imagespec.Descriptor) ([]byte, error) {
+	if desc.Size > maxSignatureBlobSize {
+		return nil, errors.Errorf("size %d exceeds %d", desc.Size, maxSignatureBlobSize)
+	}
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(rc, maxSignatureBlobSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if digest.FromBytes(data) != desc.Digest {
+		return nil, errors.Errorf("digest mismatch, expected %q", desc.Digest)
+	}
+	return data, nil
+}
+
+// verifyCosignPayload verifies the base64 encoded signature of a cosign
+// payload with the keys, and that the payload is for the manifest digest in
+// the repository.
+func verifyCosignPayload(payload []byte, sig string, keys []crypto.PublicKey, repo string, dgst digest.Digest) error {
+	rawSig, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode signature")
+	}
+	hash := sha256.Sum256(payload)
+	verified := false
+	for _, key := range keys {
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			verified = ecdsa.VerifyASN1(k, hash[:], rawSig)
+		case *rsa.PublicKey:
+			verified = rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], rawSig) == nil
+		}
+		if verified {
+			break
+		}
+	}
+	if !verified {
+		return errors.New("signature is not verified by any key")
+	}
+	var p cosignPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return errors.Wrap(err, "failed to unmarshal signature payload")
+	}
+	if p.Critical.Image.DockerManifestDigest != dgst.String() {
+		return errors.Errorf("signature is for digest %q", p.Critical.Image.DockerManifestDigest)
+	}
+	// Cosign may use another name of the repository, e.g. "index.docker.io".
+	identity, err := util.NormalizeImageRef(p.Critical.Identity.DockerReference)
+	if err != nil || identity.Name() != repo {
+		return errors.Errorf("signature is for repository %q", p.Critical.Identity.DockerReference)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// fakeSignatureResolver resolves references and fetches blobs from memory.
+type fakeSignatureResolver struct {
+	refs  map[string]imagespec.Descriptor
+	blobs map[digest.Digest][]byte
+}
+
+func newFakeSignatureResolver() *fakeSignatureResolver {
+	return &fakeSignatureResolver{
+		refs:  make(map[string]imagespec.Descriptor),
+		blobs: make(map[digest.Digest][]byte),
+	}
+}
+
+func (r *fakeSignatureResolver) add(data []byte, annotations map[string]string) imagespec.Descriptor {
+	desc := imagespec.Descriptor{
+		MediaType:   imagespec.MediaTypeImageManifest,
+		Digest:      digest.FromBytes(data),
+		Size:        int64(len(data)),
+		Annotations: annotations,
+	}
+	r.blobs[desc.Digest] = data
+	return desc
+}
+
+func (r *fakeSignatureResolver) Resolve(ctx context.Context, ref string) (string, imagespec.Descriptor, error) {
+	desc, ok := r.refs[ref]
+	if !ok {
+		return "", imagespec.Descriptor{}, errdefs.ErrNotFound
+	}
+	return ref, desc, nil
+}
+
+func (r *fakeSignatureResolver) Fetcher(ctx context.Context, ref string) (remotes.Fetcher, error) {
+	return remotes.FetcherFunc(func(ctx context.Context, desc imagespec.Descriptor) (io.ReadCloser, error) {
+		data, ok := r.blobs[desc.Digest]
+		if !ok {
+			return nil, errdefs.ErrNotFound
+		}
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}), nil
+}
+
+func (r *fakeSignatureResolver) Pusher(ctx context.Context, ref string) (remotes.Pusher, error) {
+	return nil, errdefs.ErrNotImplemented
+}
+
+func TestImagePolicyRequirement(t *testing.T) {
+	policy := &imagePolicy{
+		Default: &imagePolicyRequirement{Type: imagePolicyReject},
+		Scopes: map[string]imagePolicyRequirement{
+			"docker.io":             {Type: imagePolicyAccept},
+			"docker.io/myorg":       {Type: imagePolicyCosignKey, KeyPaths: []string{"key.pub"}},
+			"docker.io/myorg/debug": {Type: imagePolicyReject},
+		},
+	}
+	for repo, expected := range map[string]string{
+		"docker.io/library/busybox":  imagePolicyAccept,
+		"docker.io/myorg/app":        imagePolicyCosignKey,
+		"docker.io/myorg/debug":      imagePolicyReject,
+		"docker.io/myorganization/a": imagePolicyAccept,
+		"gcr.io/project/app":         imagePolicyReject,
+	} {
+		assert.Equal(t, expected, policy.requirement(repo).Type, repo)
+	}
+}
+
+func TestLoadImagePolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-image-policy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "policy.json")
+	for desc, test := range map[string]struct {
+		content   string
+		expectErr bool
+	}{
+		"should load valid policy": {
+			content: `{"default": {"type": "reject"}, "scopes": {"docker.io": {"type": "cosignKey", "keyPaths": ["key.pub"]}}}`,
+		},
+		"should return error without default requirement": {
+			content:   `{"scopes": {"docker.io": {"type": "accept"}}}`,
+			expectErr: true,
+		},
+		"should return error with unsupported requirement type": {
+			content:   `{"default": {"type": "signedBy"}}`,
+			expectErr: true,
+		},
+		"should return error with cosignKey requirement without keys": {
+			content:   `{"default": {"type": "accept"}, "scopes": {"docker.io": {"type": "cosignKey"}}}`,
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		require.NoError(t, ioutil.WriteFile(path, []byte(test.content), 0600))
+		_, err := loadImagePolicy(path)
+		assert.Equal(t, test.expectErr, err != nil)
+	}
+}
+
+func TestVerifyCosignSignature(t *testing.T) {
+	const repo = "docker.io/library/busybox"
+	imageDigest := digest.FromString("manifest")
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	newPayload := func(reference string, dgst digest.Digest) []byte {
+		var p cosignPayload
+		p.Critical.Identity.DockerReference = reference
+		p.Critical.Image.DockerManifestDigest = dgst.String()
+		p.Critical.Type = "cosign container image signature"
+		data, err := json.Marshal(p)
+		require.NoError(t, err)
+		return data
+	}
+	sign := func(k *ecdsa.PrivateKey, payload []byte) string {
+		hash := sha256.Sum256(payload)
+		sig, err := ecdsa.SignASN1(rand.Reader, k, hash[:])
+		require.NoError(t, err)
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+	for desc, test := range map[string]struct {
+		unsigned  bool
+		payload   []byte
+		signer    *ecdsa.PrivateKey
+		tamper    bool
+		expectErr bool
+	}{
+		"should verify signed image": {
+			payload: newPayload(repo, imageDigest),
+			signer:  key,
+		},
+		"should verify signature with another name of the repository": {
+			payload: newPayload("index.docker.io/library/busybox", imageDigest),
+			signer:  key,
+		},
+		"should reject unsigned image": {
+			unsigned:  true,
+			expectErr: true,
+		},
+		"should reject signature by unknown key": {
+			payload:   newPayload(repo, imageDigest),
+			signer:    otherKey,
+			expectErr: true,
+		},
+		"should reject signature of another digest": {
+			payload:   newPayload(repo, digest.FromString("other")),
+			signer:    key,
+			expectErr: true,
+		},
+		"should reject signature of another repository": {
+			payload:   newPayload("docker.io/library/alpine", imageDigest),
+			signer:    key,
+			expectErr: true,
+		},
+		"should reject tampered payload": {
+			payload:   newPayload(repo, imageDigest),
+			signer:    key,
+			tamper:    true,
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		resolver := newFakeSignatureResolver()
+		if !test.unsigned {
+			layer := resolver.add(test.payload, map[string]string{
+				cosignSignatureAnnotation: sign(test.signer, test.payload),
+			})
+			if test.tamper {
+				resolver.blobs[layer.Digest] = newPayload(repo, digest.FromString("tampered"))
+			}
+			manifest, err := json.Marshal(imagespec.Manifest{Layers: []imagespec.Descriptor{layer}})
+			require.NoError(t, err)
+			sigRef := fmt.Sprintf("%s:sha256-%s.sig", repo, imageDigest.Hex())
+			resolver.refs[sigRef] = resolver.add(manifest, nil)
+		}
+		err := verifyCosignSignature(context.Background(), resolver, repo, imageDigest, []crypto.PublicKey{&key.PublicKey})
+		assert.Equal(t, test.expectErr, err != nil, err)
+	}
+}
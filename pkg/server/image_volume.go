@@ -0,0 +1,158 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/mount"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/log"
+	imagestore "github.com/containerd/cri/pkg/store/image"
+)
+
+// imageVolumeSnapshotLabelPrefix is the prefix of the container labels
+// referencing the snapshots of its image volumes, so that containerd garbage
+// collects them after the container is deleted.
+const imageVolumeSnapshotLabelPrefix = "containerd.io/gc.ref.snapshot."
+
+// imageVolume is an image mounted read-only into a container.
+type imageVolume struct {
+	containerPath string
+	image         string
+}
+
+// parseImageVolumes parses image volumes like
+// "/models=docker.io/library/model:v1,/data=data:latest".
+func parseImageVolumes(s string) ([]imageVolume, error) {
+	var volumes []imageVolume
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || !filepath.IsAbs(parts[0]) || parts[1] == "" {
+			return nil, errors.Errorf("invalid image volume %q, expected <absolute path>=<image>", item)
+		}
+		volumes = append(volumes, imageVolume{containerPath: parts[0], image: parts[1]})
+	}
+	return volumes, nil
+}
+
+// imageVolumeSnapshotKey returns the key of the snapshot of an image volume
+// of a container.
+func imageVolumeSnapshotKey(id string, i int) string {
+	return fmt.Sprintf("%s-image-volume-%d", id, i)
+}
+
+// prepareImageVolumes creates read-only snapshots of the image volumes of a
+// container, pulling the images if they are not found. It returns the mounts
+// of the snapshots and the container labels referencing them. The snapshots
+// are only kept from garbage collection by the lease in the context until the
+// container is created with the labels.
+func (c *criService) prepareImageVolumes(ctx context.Context, id, snapshotter string, volumes []imageVolume, sandboxConfig *runtime.PodSandboxConfig) ([]runtimespec.Mount, map[string]string, error) {
+	sn := c.client.SnapshotService(snapshotter)
+	var mounts []runtimespec.Mount
+	labels := make(map[string]string)
+	for i, v := range volumes {
+		image, err := c.ensureImageVolumeImage(ctx, v.image, sandboxConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		unpacked, err := image.Image.IsUnpacked(ctx, snapshotter)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to check whether image %q is unpacked", v.image)
+		}
+		if !unpacked {
+			if err := image.Image.Unpack(ctx, snapshotter); err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to unpack image %q", v.image)
+			}
+		}
+		key := imageVolumeSnapshotKey(id, i)
+		ms, err := sn.View(ctx, key, image.ChainID)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to create snapshot of image %q", v.image)
+		}
+		labels[fmt.Sprintf("%s%s/%d", imageVolumeSnapshotLabelPrefix, snapshotter, i)] = key
+		m, err := toImageVolumeMount(ms, v.containerPath)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid mounts of image %q", v.image)
+		}
+		log.Image.Debugf("Mount image %q at %q of container %q", v.image, v.containerPath, id)
+		mounts = append(mounts, m)
+	}
+	return mounts, labels, nil
+}
+
+// ensureImageVolumeImage returns a local image, pulling it if it is not
+// found. A short name is resolved under the policy of the pod namespace.
+func (c *criService) ensureImageVolumeImage(ctx context.Context, ref string, sandboxConfig *runtime.PodSandboxConfig) (*imagestore.Image, error) {
+	image, err := c.localResolveInNamespace(ctx, ref, sandboxConfig.GetMetadata().GetNamespace())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve image %q", ref)
+	}
+	if image != nil {
+		return image, nil
+	}
+	resp, err := c.PullImage(ctx, &runtime.PullImageRequest{
+		Image:         &runtime.ImageSpec{Image: ref},
+		SandboxConfig: sandboxConfig,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to pull image %q", ref)
+	}
+	image, err = c.localResolve(ctx, resp.GetImageRef())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve image %q", ref)
+	}
+	if image == nil {
+		return nil, errors.Errorf("image %q not found after pull", ref)
+	}
+	return image, nil
+}
+
+// toImageVolumeMount converts the mounts of a view snapshot into a read-only
+// mount of the container.
+func toImageVolumeMount(mounts []mount.Mount, containerPath string) (runtimespec.Mount, error) {
+	if len(mounts) != 1 {
+		return runtimespec.Mount{}, errors.Errorf("expected 1 mount, got %d", len(mounts))
+	}
+	m := mounts[0]
+	options := m.Options
+	readonly := false
+	for _, o := range options {
+		if o == "ro" {
+			readonly = true
+		}
+	}
+	if !readonly {
+		options = append(options, "ro")
+	}
+	return runtimespec.Mount{
+		Source:      m.Source,
+		Destination: containerPath,
+		Type:        m.Type,
+		Options:     options,
+	}, nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/containerd/containerd/mount"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImageVolumes(t *testing.T) {
+	for desc, test := range map[string]struct {
+		value     string
+		expectErr bool
+		expected  []imageVolume
+	}{
+		"should parse empty value": {},
+		"should parse image volumes": {
+			value: "/models=docker.io/library/model:v1, /data=data@sha256:0123",
+			expected: []imageVolume{
+				{containerPath: "/models", image: "docker.io/library/model:v1"},
+				{containerPath: "/data", image: "data@sha256:0123"},
+			},
+		},
+		"should reject relative path": {
+			value:     "models=model:v1",
+			expectErr: true,
+		},
+		"should reject missing image": {
+			value:     "/models=",
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		volumes, err := parseImageVolumes(test.value)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, volumes)
+	}
+}
+
+func TestToImageVolumeMount(t *testing.T) {
+	for desc, test := range map[string]struct {
+		mounts    []mount.Mount
+		expectErr bool
+		expected  runtimespec.Mount
+	}{
+		"should keep readonly bind mount": {
+			mounts: []mount.Mount{{Type: "bind", Source: "/snapshots/1/fs", Options: []string{"ro", "rbind"}}},
+			expected: runtimespec.Mount{
+				Source:      "/snapshots/1/fs",
+				Destination: "/models",
+				Type:        "bind",
+				Options:     []string{"ro", "rbind"},
+			},
+		},
+		"should make overlay mount readonly": {
+			mounts: []mount.Mount{{Type: "overlay", Source: "overlay", Options: []string{"lowerdir=/snapshots/2/fs:/snapshots/1/fs"}}},
+			expected: runtimespec.Mount{
+				Source:      "overlay",
+				Destination: "/models",
+				Type:        "overlay",
+				Options:     []string{"lowerdir=/snapshots/2/fs:/snapshots/1/fs", "ro"},
+			},
+		},
+		"should reject multiple mounts": {
+			mounts:    []mount.Mount{{Type: "bind"}, {Type: "bind"}},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		m, err := toImageVolumeMount(test.mounts, "/models")
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, m)
+	}
+}
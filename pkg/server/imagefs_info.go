@@ -24,28 +24,32 @@ import (
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 )
 
-// ImageFsInfo returns information of the filesystem that is used to store images.
+// ImageFsInfo returns information of the filesystems that are used to store
+// images, one for each snapshotter in use. The default snapshotter comes first.
 func (c *criService) ImageFsInfo(ctx context.Context, r *runtime.ImageFsInfoRequest) (*runtime.ImageFsInfoResponse, error) {
-	snapshots := c.snapshotStore.List()
-	timestamp := time.Now().UnixNano()
-	var usedBytes, inodesUsed uint64
-	for _, sn := range snapshots {
-		// Use the oldest timestamp as the timestamp of imagefs info.
-		if sn.Timestamp < timestamp {
-			timestamp = sn.Timestamp
+	var filesystems []*runtime.FilesystemUsage
+	for _, snapshotter := range c.snapshotters() {
+		store, ok := c.snapshotStores[snapshotter]
+		if !ok {
+			continue
 		}
-		usedBytes += sn.Size
-		inodesUsed += sn.Inodes
+		timestamp := time.Now().UnixNano()
+		var usedBytes, inodesUsed uint64
+		for _, sn := range store.List() {
+			// Use the oldest timestamp as the timestamp of imagefs info.
+			if sn.Timestamp < timestamp {
+				timestamp = sn.Timestamp
+			}
+			usedBytes += sn.Size
+			inodesUsed += sn.Inodes
+		}
+		// TODO(random-liu): Handle content store
+		filesystems = append(filesystems, &runtime.FilesystemUsage{
+			Timestamp:  timestamp,
+			FsId:       &runtime.FilesystemIdentifier{Mountpoint: c.imageFSPaths[snapshotter]},
+			UsedBytes:  &runtime.UInt64Value{Value: usedBytes},
+			InodesUsed: &runtime.UInt64Value{Value: inodesUsed},
+		})
 	}
-	// TODO(random-liu): Handle content store
-	return &runtime.ImageFsInfoResponse{
-		ImageFilesystems: []*runtime.FilesystemUsage{
-			{
-				Timestamp:  timestamp,
-				FsId:       &runtime.FilesystemIdentifier{Mountpoint: c.imageFSPath},
-				UsedBytes:  &runtime.UInt64Value{Value: usedBytes},
-				InodesUsed: &runtime.UInt64Value{Value: inodesUsed},
-			},
-		},
-	}, nil
+	return &runtime.ImageFsInfoResponse{ImageFilesystems: filesystems}, nil
 }
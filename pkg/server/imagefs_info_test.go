@@ -25,6 +25,7 @@ import (
 	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
+	criconfig "github.com/containerd/cri/pkg/config"
 	snapshotstore "github.com/containerd/cri/pkg/store/snapshot"
 )
 
@@ -60,7 +61,7 @@ func TestImageFsInfo(t *testing.T) {
 		InodesUsed: &runtime.UInt64Value{Value: 300},
 	}
 	for _, sn := range snapshots {
-		c.snapshotStore.Add(sn)
+		c.snapshotStores[""].Add(sn)
 	}
 	resp, err := c.ImageFsInfo(context.Background(), &runtime.ImageFsInfoRequest{})
 	require.NoError(t, err)
@@ -68,3 +69,32 @@ func TestImageFsInfo(t *testing.T) {
 	assert.Len(t, stats, 1)
 	assert.Equal(t, expected, stats[0])
 }
+
+func TestImageFsInfoMultipleSnapshotters(t *testing.T) {
+	const testOtherImageFSPath = "/test/other/image/fs/path"
+	c := newTestCRIService()
+	c.config.ContainerdConfig.Runtimes = map[string]criconfig.Runtime{
+		"other": {Snapshotter: "other"},
+	}
+	c.imageFSPaths["other"] = testOtherImageFSPath
+	c.snapshotStores["other"] = snapshotstore.NewStore()
+	c.snapshotStores[""].Add(snapshotstore.Snapshot{Key: "key1", Size: 10, Inodes: 100, Timestamp: 123456})
+	c.snapshotStores["other"].Add(snapshotstore.Snapshot{Key: "key1", Size: 20, Inodes: 200, Timestamp: 234567})
+	expected := []*runtime.FilesystemUsage{
+		{
+			Timestamp:  123456,
+			FsId:       &runtime.FilesystemIdentifier{Mountpoint: testImageFSPath},
+			UsedBytes:  &runtime.UInt64Value{Value: 10},
+			InodesUsed: &runtime.UInt64Value{Value: 100},
+		},
+		{
+			Timestamp:  234567,
+			FsId:       &runtime.FilesystemIdentifier{Mountpoint: testOtherImageFSPath},
+			UsedBytes:  &runtime.UInt64Value{Value: 20},
+			InodesUsed: &runtime.UInt64Value{Value: 200},
+		},
+	}
+	resp, err := c.ImageFsInfo(context.Background(), &runtime.ImageFsInfoRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, expected, resp.GetImageFilesystems())
+}
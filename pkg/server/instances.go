@@ -0,0 +1,128 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/identifiers"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	ctrdutil "github.com/containerd/cri/pkg/containerd/util"
+)
+
+// instancesDir is the directory under the plugin root and state directories
+// holding the directories of the additional cri instances.
+const instancesDir = "instances"
+
+// InstanceConfigs returns the configs of the additional cri instances keyed
+// by name. An instance has the plugin config, except for its containerd
+// namespace, grpc address, stream server port and cni conf dir, and its own
+// root and state directories under the ones of the plugin.
+func InstanceConfigs(config criconfig.Config) map[string]criconfig.Config {
+	configs := make(map[string]criconfig.Config)
+	rootDir, stateDir := config.RootDir, config.StateDir
+	if config.PluginRootDir != "" {
+		rootDir = config.PluginRootDir
+	}
+	if config.PluginStateDir != "" {
+		stateDir = config.PluginStateDir
+	}
+	for name, instance := range config.Instances {
+		c := config
+		c.Instances = nil
+		c.ContainerdConfig.Namespace = instance.Namespace
+		c.GRPC.Address = instance.Address
+		c.StreamServerPort = instance.StreamServerPort
+		if instance.NetworkPluginConfDir != "" {
+			c.NetworkPluginConfDir = instance.NetworkPluginConfDir
+		}
+		c.RootDir = filepath.Join(rootDir, instancesDir, name)
+		c.StateDir = filepath.Join(stateDir, instancesDir, name)
+		c.PluginRootDir, c.PluginStateDir = "", ""
+		if c.Audit.Path != "" {
+			ext := filepath.Ext(c.Audit.Path)
+			c.Audit.Path = strings.TrimSuffix(c.Audit.Path, ext) + "-" + name + ext
+		}
+		configs[name] = c
+	}
+	return configs
+}
+
+// validateInstances validates that the additional cri instances don't share
+// a containerd namespace, a socket or a stream server port with each other
+// or with the plugin.
+func validateInstances(config criconfig.PluginConfig) error {
+	if err := identifiers.Validate(config.ContainerdConfig.Namespace); err != nil {
+		return errors.Wrap(err, "invalid containerd namespace")
+	}
+	namespaces := map[string]string{config.ContainerdConfig.Namespace: "the plugin"}
+	addresses := map[string]string{}
+	if config.GRPC.Address != "" {
+		addresses[config.GRPC.Address] = "the plugin"
+	}
+	ports := map[string]string{config.StreamServerPort: "the plugin"}
+	for name, instance := range config.Instances {
+		if err := identifiers.Validate(name); err != nil {
+			return errors.Wrapf(err, "invalid instance name %q", name)
+		}
+		if err := identifiers.Validate(instance.Namespace); err != nil {
+			return errors.Wrapf(err, "invalid namespace of instance %q", name)
+		}
+		if instance.Address == "" {
+			return errors.Errorf("address of instance %q is not set", name)
+		}
+		if instance.StreamServerPort == "" {
+			return errors.Errorf("stream_server_port of instance %q is not set", name)
+		}
+		for _, used := range []struct {
+			option, value string
+			owners        map[string]string
+		}{
+			{"namespace", instance.Namespace, namespaces},
+			{"address", instance.Address, addresses},
+			{"stream_server_port", instance.StreamServerPort, ports},
+		} {
+			if owner, ok := used.owners[used.value]; ok {
+				return errors.Errorf("%s %q of instance %q is also used by %s", used.option, used.value, name, owner)
+			}
+			used.owners[used.value] = "instance " + name
+		}
+	}
+	return nil
+}
+
+// withNamespace adds the containerd namespace of the cri service to the
+// context.
+func (c *criService) withNamespace(ctx context.Context) context.Context {
+	return ctrdutil.WithNamespace(ctx, c.config.ContainerdConfig.Namespace)
+}
+
+// namespacedContext returns a context with the containerd namespace of the
+// cri service.
+func (c *criService) namespacedContext() context.Context {
+	return ctrdutil.NamespacedContext(c.config.ContainerdConfig.Namespace)
+}
+
+// deferContext returns a context for containerd cleanup operations in defer,
+// with the containerd namespace of the cri service.
+func (c *criService) deferContext() (context.Context, context.CancelFunc) {
+	return ctrdutil.DeferContext(c.config.ContainerdConfig.Namespace)
+}
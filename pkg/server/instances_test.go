@@ -0,0 +1,152 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/containerd/containerd/namespaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestInstanceConfigs(t *testing.T) {
+	config := criconfig.Config{
+		PluginConfig: criconfig.DefaultConfig(),
+		RootDir:      "/var/lib/containerd/io.containerd.grpc.v1.cri",
+		StateDir:     "/run/containerd/io.containerd.grpc.v1.cri",
+	}
+	config.PluginStateDir = "/run/cri"
+	config.Audit.Path = "/var/log/cri-audit.log"
+	config.Instances = map[string]criconfig.InstanceConfig{
+		"user": {
+			Namespace:            "user",
+			Address:              "/run/containerd/cri-user.sock",
+			StreamServerPort:     "10011",
+			NetworkPluginConfDir: "/etc/cni/user.d",
+		},
+	}
+	configs := InstanceConfigs(config)
+	require.Len(t, configs, 1)
+	user := configs["user"]
+	assert.Equal(t, "user", user.ContainerdConfig.Namespace)
+	assert.Equal(t, "/run/containerd/cri-user.sock", user.GRPC.Address)
+	assert.Equal(t, "10011", user.StreamServerPort)
+	assert.Equal(t, "/etc/cni/user.d", user.NetworkPluginConfDir)
+	assert.Equal(t, "/var/lib/containerd/io.containerd.grpc.v1.cri/instances/user", user.RootDir)
+	assert.Equal(t, "/run/cri/instances/user", user.StateDir)
+	assert.Empty(t, user.PluginStateDir)
+	assert.Equal(t, "/var/log/cri-audit-user.log", user.Audit.Path)
+	assert.Nil(t, user.Instances)
+	assert.Equal(t, config.SandboxImage, user.SandboxImage, "other options should be the same as the plugin")
+	assert.Equal(t, "k8s.io", config.ContainerdConfig.Namespace, "plugin config should not be modified")
+}
+
+func TestValidateInstances(t *testing.T) {
+	valid := criconfig.InstanceConfig{
+		Namespace:        "user",
+		Address:          "/run/containerd/cri-user.sock",
+		StreamServerPort: "10011",
+	}
+	for desc, test := range map[string]struct {
+		update    func(*criconfig.PluginConfig)
+		expectErr bool
+	}{
+		"should accept valid instance": {},
+		"should reject invalid plugin namespace": {
+			update:    func(config *criconfig.PluginConfig) { config.ContainerdConfig.Namespace = "" },
+			expectErr: true,
+		},
+		"should reject invalid instance name": {
+			update: func(config *criconfig.PluginConfig) {
+				config.Instances = map[string]criconfig.InstanceConfig{"user/1": valid}
+			},
+			expectErr: true,
+		},
+		"should reject instance without address": {
+			update: func(config *criconfig.PluginConfig) {
+				instance := valid
+				instance.Address = ""
+				config.Instances["user"] = instance
+			},
+			expectErr: true,
+		},
+		"should reject instance without stream server port": {
+			update: func(config *criconfig.PluginConfig) {
+				instance := valid
+				instance.StreamServerPort = ""
+				config.Instances["user"] = instance
+			},
+			expectErr: true,
+		},
+		"should reject namespace of the plugin": {
+			update: func(config *criconfig.PluginConfig) {
+				instance := valid
+				instance.Namespace = config.ContainerdConfig.Namespace
+				config.Instances["user"] = instance
+			},
+			expectErr: true,
+		},
+		"should reject stream server port of the plugin": {
+			update: func(config *criconfig.PluginConfig) {
+				instance := valid
+				instance.StreamServerPort = config.StreamServerPort
+				config.Instances["user"] = instance
+			},
+			expectErr: true,
+		},
+		"should reject address of another instance": {
+			update: func(config *criconfig.PluginConfig) {
+				config.Instances["system"] = criconfig.InstanceConfig{
+					Namespace:        "system",
+					Address:          valid.Address,
+					StreamServerPort: "10012",
+				}
+			},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		config := criconfig.DefaultConfig()
+		config.Instances = map[string]criconfig.InstanceConfig{"user": valid}
+		if test.update != nil {
+			test.update(&config)
+		}
+		err := validateInstances(config)
+		assert.Equal(t, test.expectErr, err != nil, err)
+	}
+}
+
+func TestNamespacedContext(t *testing.T) {
+	c := newTestCRIService()
+	c.config.ContainerdConfig.Namespace = "user"
+	for _, ctx := range []context.Context{
+		c.withNamespace(context.Background()),
+		c.namespacedContext(),
+	} {
+		ns, ok := namespaces.Namespace(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "user", ns)
+	}
+	ctx, cancel := c.deferContext()
+	defer cancel()
+	ns, _ := namespaces.Namespace(ctx)
+	assert.Equal(t, "user", ns)
+}
@@ -17,14 +17,15 @@ limitations under the License.
 package server
 
 import (
-	"errors"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
 	api "github.com/containerd/cri/pkg/api/v1"
-	ctrdutil "github.com/containerd/cri/pkg/containerd/util"
 	"github.com/containerd/cri/pkg/log"
 )
 
@@ -37,376 +38,604 @@ func newInstrumentedService(c *criService) grpcServices {
 	return &instrumentedService{c: c}
 }
 
-// checkInitialized returns error if the server is not fully initialized.
-// GRPC service request handlers should return error before server is fully
-// initialized.
+// checkInitialized returns a retriable error if the server is not fully
+// initialized, e.g. while the plugin is restarting. GRPC service request
+// handlers should return error before server is fully initialized. Streaming
+// sessions don't survive a restart, clients retry Exec, Attach and PortForward
+// to get a new session.
 // NOTE(random-liu): All following functions MUST check initialized at the beginning.
 func (in *instrumentedService) checkInitialized() error {
 	if in.c.initialized.IsSet() {
 		return nil
 	}
-	return errors.New("server is not initialized yet")
+	return status.Error(codes.Unavailable, "server is not initialized yet, retry later")
 }
 
 func (in *instrumentedService) RunPodSandbox(ctx context.Context, r *runtime.RunPodSandboxRequest) (res *runtime.RunPodSandboxResponse, err error) {
+	defer observeRPC("RunPodSandbox", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "RunPodSandbox", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "RunPodSandbox")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("RunPodSandbox with config %+v", r.GetConfig())
+	release, err := in.c.rpcLimiter.acquire("RunPodSandbox")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	done, err := in.c.drain.start()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	log.Sandbox.Infof("RunPodSandbox with config %+v", r.GetConfig())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("RunPodSandbox for %+v failed, error", r.GetConfig().GetMetadata())
+			log.Sandbox.WithError(err).Errorf("RunPodSandbox for %+v failed, error", r.GetConfig().GetMetadata())
 		} else {
-			logrus.Infof("RunPodSandbox for %+v returns sandbox id %q", r.GetConfig().GetMetadata(), res.GetPodSandboxId())
+			log.Sandbox.Infof("RunPodSandbox for %+v returns sandbox id %q", r.GetConfig().GetMetadata(), res.GetPodSandboxId())
 		}
 	}()
-	return in.c.RunPodSandbox(ctrdutil.WithNamespace(ctx), r)
+	return in.c.RunPodSandbox(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) ListPodSandbox(ctx context.Context, r *runtime.ListPodSandboxRequest) (res *runtime.ListPodSandboxResponse, err error) {
+	defer observeRPC("ListPodSandbox", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ListPodSandbox", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ListPodSandbox")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	log.Tracef("ListPodSandbox with filter %+v", r.GetFilter())
+	release, err := in.c.rpcLimiter.acquire("ListPodSandbox")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Sandbox.Tracef("ListPodSandbox with filter %+v", r.GetFilter())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Error("ListPodSandbox failed")
+			log.Sandbox.WithError(err).Error("ListPodSandbox failed")
 		} else {
-			log.Tracef("ListPodSandbox returns pod sandboxes %+v", res.GetItems())
+			log.Sandbox.Tracef("ListPodSandbox returns pod sandboxes %+v", res.GetItems())
 		}
 	}()
-	return in.c.ListPodSandbox(ctrdutil.WithNamespace(ctx), r)
+	return in.c.ListPodSandbox(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) PodSandboxStatus(ctx context.Context, r *runtime.PodSandboxStatusRequest) (res *runtime.PodSandboxStatusResponse, err error) {
+	defer observeRPC("PodSandboxStatus", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "PodSandboxStatus", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "PodSandboxStatus")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	log.Tracef("PodSandboxStatus for %q", r.GetPodSandboxId())
+	release, err := in.c.rpcLimiter.acquire("PodSandboxStatus")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Sandbox.Tracef("PodSandboxStatus for %q", r.GetPodSandboxId())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("PodSandboxStatus for %q failed", r.GetPodSandboxId())
+			log.Sandbox.WithError(err).Errorf("PodSandboxStatus for %q failed", r.GetPodSandboxId())
 		} else {
-			log.Tracef("PodSandboxStatus for %q returns status %+v", r.GetPodSandboxId(), res.GetStatus())
+			log.Sandbox.Tracef("PodSandboxStatus for %q returns status %+v", r.GetPodSandboxId(), res.GetStatus())
 		}
 	}()
-	return in.c.PodSandboxStatus(ctrdutil.WithNamespace(ctx), r)
+	return in.c.PodSandboxStatus(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) StopPodSandbox(ctx context.Context, r *runtime.StopPodSandboxRequest) (_ *runtime.StopPodSandboxResponse, err error) {
+	defer observeRPC("StopPodSandbox", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "StopPodSandbox", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "StopPodSandbox")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("StopPodSandbox for %q", r.GetPodSandboxId())
+	release, err := in.c.rpcLimiter.acquire("StopPodSandbox")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Sandbox.Infof("StopPodSandbox for %q", r.GetPodSandboxId())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("StopPodSandbox for %q failed", r.GetPodSandboxId())
+			log.Sandbox.WithError(err).Errorf("StopPodSandbox for %q failed", r.GetPodSandboxId())
 		} else {
-			logrus.Infof("StopPodSandbox for %q returns successfully", r.GetPodSandboxId())
+			log.Sandbox.Infof("StopPodSandbox for %q returns successfully", r.GetPodSandboxId())
 		}
 	}()
-	return in.c.StopPodSandbox(ctrdutil.WithNamespace(ctx), r)
+	return in.c.StopPodSandbox(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) RemovePodSandbox(ctx context.Context, r *runtime.RemovePodSandboxRequest) (_ *runtime.RemovePodSandboxResponse, err error) {
+	defer observeRPC("RemovePodSandbox", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "RemovePodSandbox", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "RemovePodSandbox")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("RemovePodSandbox for %q", r.GetPodSandboxId())
+	release, err := in.c.rpcLimiter.acquire("RemovePodSandbox")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Sandbox.Infof("RemovePodSandbox for %q", r.GetPodSandboxId())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("RemovePodSandbox for %q failed", r.GetPodSandboxId())
+			log.Sandbox.WithError(err).Errorf("RemovePodSandbox for %q failed", r.GetPodSandboxId())
 		} else {
-			logrus.Infof("RemovePodSandbox %q returns successfully", r.GetPodSandboxId())
+			log.Sandbox.Infof("RemovePodSandbox %q returns successfully", r.GetPodSandboxId())
 		}
 	}()
-	return in.c.RemovePodSandbox(ctrdutil.WithNamespace(ctx), r)
+	return in.c.RemovePodSandbox(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) PortForward(ctx context.Context, r *runtime.PortForwardRequest) (res *runtime.PortForwardResponse, err error) {
+	defer observeRPC("PortForward", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "PortForward", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "PortForward")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("Portforward for %q port %v", r.GetPodSandboxId(), r.GetPort())
+	release, err := in.c.rpcLimiter.acquire("PortForward")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Streaming.Infof("Portforward for %q port %v", r.GetPodSandboxId(), r.GetPort())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("Portforward for %q failed", r.GetPodSandboxId())
+			log.Streaming.WithError(err).Errorf("Portforward for %q failed", r.GetPodSandboxId())
 		} else {
-			logrus.Infof("Portforward for %q returns URL %q", r.GetPodSandboxId(), res.GetUrl())
+			log.Streaming.Infof("Portforward for %q returns URL %q", r.GetPodSandboxId(), res.GetUrl())
 		}
 	}()
-	return in.c.PortForward(ctrdutil.WithNamespace(ctx), r)
+	return in.c.PortForward(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) CreateContainer(ctx context.Context, r *runtime.CreateContainerRequest) (res *runtime.CreateContainerResponse, err error) {
+	defer observeRPC("CreateContainer", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "CreateContainer", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "CreateContainer")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("CreateContainer within sandbox %q with container config %+v and sandbox config %+v",
+	release, err := in.c.rpcLimiter.acquire("CreateContainer")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	done, err := in.c.drain.start()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	log.Container.Infof("CreateContainer within sandbox %q with container config %+v and sandbox config %+v",
 		r.GetPodSandboxId(), r.GetConfig(), r.GetSandboxConfig())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("CreateContainer within sandbox %q for %+v failed",
+			log.Container.WithError(err).Errorf("CreateContainer within sandbox %q for %+v failed",
 				r.GetPodSandboxId(), r.GetConfig().GetMetadata())
 		} else {
-			logrus.Infof("CreateContainer within sandbox %q for %+v returns container id %q",
+			log.Container.Infof("CreateContainer within sandbox %q for %+v returns container id %q",
 				r.GetPodSandboxId(), r.GetConfig().GetMetadata(), res.GetContainerId())
 		}
 	}()
-	return in.c.CreateContainer(ctrdutil.WithNamespace(ctx), r)
+	return in.c.CreateContainer(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) StartContainer(ctx context.Context, r *runtime.StartContainerRequest) (_ *runtime.StartContainerResponse, err error) {
+	defer observeRPC("StartContainer", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "StartContainer", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "StartContainer")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("StartContainer for %q", r.GetContainerId())
+	release, err := in.c.rpcLimiter.acquire("StartContainer")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Container.Infof("StartContainer for %q", r.GetContainerId())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("StartContainer for %q failed", r.GetContainerId())
+			log.Container.WithError(err).Errorf("StartContainer for %q failed", r.GetContainerId())
 		} else {
-			logrus.Infof("StartContainer for %q returns successfully", r.GetContainerId())
+			log.Container.Infof("StartContainer for %q returns successfully", r.GetContainerId())
 		}
 	}()
-	return in.c.StartContainer(ctrdutil.WithNamespace(ctx), r)
+	return in.c.StartContainer(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) ListContainers(ctx context.Context, r *runtime.ListContainersRequest) (res *runtime.ListContainersResponse, err error) {
+	defer observeRPC("ListContainers", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ListContainers", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ListContainers")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	log.Tracef("ListContainers with filter %+v", r.GetFilter())
+	release, err := in.c.rpcLimiter.acquire("ListContainers")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Container.Tracef("ListContainers with filter %+v", r.GetFilter())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("ListContainers with filter %+v failed", r.GetFilter())
+			log.Container.WithError(err).Errorf("ListContainers with filter %+v failed", r.GetFilter())
 		} else {
-			log.Tracef("ListContainers with filter %+v returns containers %+v",
+			log.Container.Tracef("ListContainers with filter %+v returns containers %+v",
 				r.GetFilter(), res.GetContainers())
 		}
 	}()
-	return in.c.ListContainers(ctrdutil.WithNamespace(ctx), r)
+	return in.c.ListContainers(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) ContainerStatus(ctx context.Context, r *runtime.ContainerStatusRequest) (res *runtime.ContainerStatusResponse, err error) {
+	defer observeRPC("ContainerStatus", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ContainerStatus", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ContainerStatus")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	log.Tracef("ContainerStatus for %q", r.GetContainerId())
+	release, err := in.c.rpcLimiter.acquire("ContainerStatus")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Container.Tracef("ContainerStatus for %q", r.GetContainerId())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("ContainerStatus for %q failed", r.GetContainerId())
+			log.Container.WithError(err).Errorf("ContainerStatus for %q failed", r.GetContainerId())
 		} else {
-			log.Tracef("ContainerStatus for %q returns status %+v", r.GetContainerId(), res.GetStatus())
+			log.Container.Tracef("ContainerStatus for %q returns status %+v", r.GetContainerId(), res.GetStatus())
 		}
 	}()
-	return in.c.ContainerStatus(ctrdutil.WithNamespace(ctx), r)
+	return in.c.ContainerStatus(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) StopContainer(ctx context.Context, r *runtime.StopContainerRequest) (res *runtime.StopContainerResponse, err error) {
+	defer observeRPC("StopContainer", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "StopContainer", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "StopContainer")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("StopContainer for %q with timeout %d (s)", r.GetContainerId(), r.GetTimeout())
+	release, err := in.c.rpcLimiter.acquire("StopContainer")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Container.Infof("StopContainer for %q with timeout %d (s)", r.GetContainerId(), r.GetTimeout())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("StopContainer for %q failed", r.GetContainerId())
+			log.Container.WithError(err).Errorf("StopContainer for %q failed", r.GetContainerId())
 		} else {
-			logrus.Infof("StopContainer for %q returns successfully", r.GetContainerId())
+			log.Container.Infof("StopContainer for %q returns successfully", r.GetContainerId())
 		}
 	}()
-	return in.c.StopContainer(ctrdutil.WithNamespace(ctx), r)
+	return in.c.StopContainer(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) RemoveContainer(ctx context.Context, r *runtime.RemoveContainerRequest) (res *runtime.RemoveContainerResponse, err error) {
+	defer observeRPC("RemoveContainer", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "RemoveContainer", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "RemoveContainer")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("RemoveContainer for %q", r.GetContainerId())
+	release, err := in.c.rpcLimiter.acquire("RemoveContainer")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Container.Infof("RemoveContainer for %q", r.GetContainerId())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("RemoveContainer for %q failed", r.GetContainerId())
+			log.Container.WithError(err).Errorf("RemoveContainer for %q failed", r.GetContainerId())
 		} else {
-			logrus.Infof("RemoveContainer for %q returns successfully", r.GetContainerId())
+			log.Container.Infof("RemoveContainer for %q returns successfully", r.GetContainerId())
 		}
 	}()
-	return in.c.RemoveContainer(ctrdutil.WithNamespace(ctx), r)
+	return in.c.RemoveContainer(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) ExecSync(ctx context.Context, r *runtime.ExecSyncRequest) (res *runtime.ExecSyncResponse, err error) {
+	defer observeRPC("ExecSync", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ExecSync", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ExecSync")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("ExecSync for %q with command %+v and timeout %d (s)", r.GetContainerId(), r.GetCmd(), r.GetTimeout())
+	release, err := in.c.rpcLimiter.acquire("ExecSync")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Container.Infof("ExecSync for %q with command %+v and timeout %d (s)", r.GetContainerId(), r.GetCmd(), r.GetTimeout())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("ExecSync for %q failed", r.GetContainerId())
+			log.Container.WithError(err).Errorf("ExecSync for %q failed", r.GetContainerId())
 		} else {
-			logrus.Infof("ExecSync for %q returns with exit code %d", r.GetContainerId(), res.GetExitCode())
-			logrus.Debugf("ExecSync for %q outputs - stdout: %q, stderr: %q", r.GetContainerId(),
+			log.Container.Infof("ExecSync for %q returns with exit code %d", r.GetContainerId(), res.GetExitCode())
+			log.Container.Debugf("ExecSync for %q outputs - stdout: %q, stderr: %q", r.GetContainerId(),
 				res.GetStdout(), res.GetStderr())
 		}
 	}()
-	return in.c.ExecSync(ctrdutil.WithNamespace(ctx), r)
+	return in.c.ExecSync(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) Exec(ctx context.Context, r *runtime.ExecRequest) (res *runtime.ExecResponse, err error) {
+	defer observeRPC("Exec", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "Exec", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "Exec")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("Exec for %q with command %+v, tty %v and stdin %v",
+	release, err := in.c.rpcLimiter.acquire("Exec")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Streaming.Infof("Exec for %q with command %+v, tty %v and stdin %v",
 		r.GetContainerId(), r.GetCmd(), r.GetTty(), r.GetStdin())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("Exec for %q failed", r.GetContainerId())
+			log.Streaming.WithError(err).Errorf("Exec for %q failed", r.GetContainerId())
 		} else {
-			logrus.Infof("Exec for %q returns URL %q", r.GetContainerId(), res.GetUrl())
+			log.Streaming.Infof("Exec for %q returns URL %q", r.GetContainerId(), res.GetUrl())
 		}
 	}()
-	return in.c.Exec(ctrdutil.WithNamespace(ctx), r)
+	return in.c.Exec(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) Attach(ctx context.Context, r *runtime.AttachRequest) (res *runtime.AttachResponse, err error) {
+	defer observeRPC("Attach", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "Attach", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "Attach")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("Attach for %q with tty %v and stdin %v", r.GetContainerId(), r.GetTty(), r.GetStdin())
+	release, err := in.c.rpcLimiter.acquire("Attach")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Streaming.Infof("Attach for %q with tty %v and stdin %v", r.GetContainerId(), r.GetTty(), r.GetStdin())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("Attach for %q failed", r.GetContainerId())
+			log.Streaming.WithError(err).Errorf("Attach for %q failed", r.GetContainerId())
 		} else {
-			logrus.Infof("Attach for %q returns URL %q", r.GetContainerId(), res.Url)
+			log.Streaming.Infof("Attach for %q returns URL %q", r.GetContainerId(), res.Url)
 		}
 	}()
-	return in.c.Attach(ctrdutil.WithNamespace(ctx), r)
+	return in.c.Attach(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) UpdateContainerResources(ctx context.Context, r *runtime.UpdateContainerResourcesRequest) (res *runtime.UpdateContainerResourcesResponse, err error) {
+	defer observeRPC("UpdateContainerResources", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "UpdateContainerResources", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "UpdateContainerResources")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("UpdateContainerResources for %q with %+v", r.GetContainerId(), r.GetLinux())
+	release, err := in.c.rpcLimiter.acquire("UpdateContainerResources")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Container.Infof("UpdateContainerResources for %q with %+v", r.GetContainerId(), r.GetLinux())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("UpdateContainerResources for %q failed", r.GetContainerId())
+			log.Container.WithError(err).Errorf("UpdateContainerResources for %q failed", r.GetContainerId())
 		} else {
-			logrus.Infof("UpdateContainerResources for %q returns successfully", r.GetContainerId())
+			log.Container.Infof("UpdateContainerResources for %q returns successfully", r.GetContainerId())
 		}
 	}()
-	return in.c.UpdateContainerResources(ctrdutil.WithNamespace(ctx), r)
+	return in.c.UpdateContainerResources(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) PullImage(ctx context.Context, r *runtime.PullImageRequest) (res *runtime.PullImageResponse, err error) {
+	defer observeRPC("PullImage", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "PullImage", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "PullImage")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("PullImage %q with auth config %+v", r.GetImage().GetImage(), r.GetAuth())
+	release, err := in.c.rpcLimiter.acquire("PullImage")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Image.Infof("PullImage %q with auth config %+v", r.GetImage().GetImage(), r.GetAuth())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("PullImage %q failed", r.GetImage().GetImage())
+			log.Image.WithError(err).Errorf("PullImage %q failed", r.GetImage().GetImage())
 		} else {
-			logrus.Infof("PullImage %q returns image reference %q",
+			log.Image.Infof("PullImage %q returns image reference %q",
 				r.GetImage().GetImage(), res.GetImageRef())
 		}
 	}()
-	return in.c.PullImage(ctrdutil.WithNamespace(ctx), r)
+	return in.c.PullImage(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) ListImages(ctx context.Context, r *runtime.ListImagesRequest) (res *runtime.ListImagesResponse, err error) {
+	defer observeRPC("ListImages", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ListImages", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ListImages")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	log.Tracef("ListImages with filter %+v", r.GetFilter())
+	release, err := in.c.rpcLimiter.acquire("ListImages")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Image.Tracef("ListImages with filter %+v", r.GetFilter())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("ListImages with filter %+v failed", r.GetFilter())
+			log.Image.WithError(err).Errorf("ListImages with filter %+v failed", r.GetFilter())
 		} else {
-			log.Tracef("ListImages with filter %+v returns image list %+v",
+			log.Image.Tracef("ListImages with filter %+v returns image list %+v",
 				r.GetFilter(), res.GetImages())
 		}
 	}()
-	return in.c.ListImages(ctrdutil.WithNamespace(ctx), r)
+	return in.c.ListImages(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) ImageStatus(ctx context.Context, r *runtime.ImageStatusRequest) (res *runtime.ImageStatusResponse, err error) {
+	defer observeRPC("ImageStatus", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ImageStatus", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ImageStatus")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	log.Tracef("ImageStatus for %q", r.GetImage().GetImage())
+	release, err := in.c.rpcLimiter.acquire("ImageStatus")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Image.Tracef("ImageStatus for %q", r.GetImage().GetImage())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("ImageStatus for %q failed", r.GetImage().GetImage())
+			log.Image.WithError(err).Errorf("ImageStatus for %q failed", r.GetImage().GetImage())
 		} else {
-			log.Tracef("ImageStatus for %q returns image status %+v",
+			log.Image.Tracef("ImageStatus for %q returns image status %+v",
 				r.GetImage().GetImage(), res.GetImage())
 		}
 	}()
-	return in.c.ImageStatus(ctrdutil.WithNamespace(ctx), r)
+	return in.c.ImageStatus(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) RemoveImage(ctx context.Context, r *runtime.RemoveImageRequest) (_ *runtime.RemoveImageResponse, err error) {
+	defer observeRPC("RemoveImage", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "RemoveImage", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "RemoveImage")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Infof("RemoveImage %q", r.GetImage().GetImage())
+	release, err := in.c.rpcLimiter.acquire("RemoveImage")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Image.Infof("RemoveImage %q", r.GetImage().GetImage())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("RemoveImage %q failed", r.GetImage().GetImage())
+			log.Image.WithError(err).Errorf("RemoveImage %q failed", r.GetImage().GetImage())
 		} else {
-			logrus.Infof("RemoveImage %q returns successfully", r.GetImage().GetImage())
+			log.Image.Infof("RemoveImage %q returns successfully", r.GetImage().GetImage())
 		}
 	}()
-	return in.c.RemoveImage(ctrdutil.WithNamespace(ctx), r)
+	return in.c.RemoveImage(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) ImageFsInfo(ctx context.Context, r *runtime.ImageFsInfoRequest) (res *runtime.ImageFsInfoResponse, err error) {
+	defer observeRPC("ImageFsInfo", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ImageFsInfo", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ImageFsInfo")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Debugf("ImageFsInfo")
+	release, err := in.c.rpcLimiter.acquire("ImageFsInfo")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Image.Debugf("ImageFsInfo")
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Error("ImageFsInfo failed")
+			log.Image.WithError(err).Error("ImageFsInfo failed")
 		} else {
-			logrus.Debugf("ImageFsInfo returns filesystem info %+v", res.ImageFilesystems)
+			log.Image.Debugf("ImageFsInfo returns filesystem info %+v", res.ImageFilesystems)
 		}
 	}()
-	return in.c.ImageFsInfo(ctrdutil.WithNamespace(ctx), r)
+	return in.c.ImageFsInfo(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) ContainerStats(ctx context.Context, r *runtime.ContainerStatsRequest) (res *runtime.ContainerStatsResponse, err error) {
+	defer observeRPC("ContainerStats", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ContainerStats", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ContainerStats")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Debugf("ContainerStats for %q", r.GetContainerId())
+	release, err := in.c.rpcLimiter.acquire("ContainerStats")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Container.Debugf("ContainerStats for %q", r.GetContainerId())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("ContainerStats for %q failed", r.GetContainerId())
+			log.Container.WithError(err).Errorf("ContainerStats for %q failed", r.GetContainerId())
 		} else {
-			logrus.Debugf("ContainerStats for %q returns stats %+v", r.GetContainerId(), res.GetStats())
+			log.Container.Debugf("ContainerStats for %q returns stats %+v", r.GetContainerId(), res.GetStats())
 		}
 	}()
-	return in.c.ContainerStats(ctrdutil.WithNamespace(ctx), r)
+	return in.c.ContainerStats(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) ListContainerStats(ctx context.Context, r *runtime.ListContainerStatsRequest) (res *runtime.ListContainerStatsResponse, err error) {
+	defer observeRPC("ListContainerStats", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ListContainerStats", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ListContainerStats")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	log.Tracef("ListContainerStats with filter %+v", r.GetFilter())
+	release, err := in.c.rpcLimiter.acquire("ListContainerStats")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Container.Tracef("ListContainerStats with filter %+v", r.GetFilter())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Error("ListContainerStats failed")
+			log.Container.WithError(err).Error("ListContainerStats failed")
 		} else {
-			log.Tracef("ListContainerStats returns stats %+v", res.GetStats())
+			log.Container.Tracef("ListContainerStats returns stats %+v", res.GetStats())
 		}
 	}()
-	return in.c.ListContainerStats(ctrdutil.WithNamespace(ctx), r)
+	return in.c.ListContainerStats(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) Status(ctx context.Context, r *runtime.StatusRequest) (res *runtime.StatusResponse, err error) {
+	defer observeRPC("Status", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "Status", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "Status")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
+	release, err := in.c.rpcLimiter.acquire("Status")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	log.Tracef("Status")
 	defer func() {
 		if err != nil {
@@ -415,13 +644,22 @@ func (in *instrumentedService) Status(ctx context.Context, r *runtime.StatusRequ
 			log.Tracef("Status returns status %+v", res.GetStatus())
 		}
 	}()
-	return in.c.Status(ctrdutil.WithNamespace(ctx), r)
+	return in.c.Status(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) Version(ctx context.Context, r *runtime.VersionRequest) (res *runtime.VersionResponse, err error) {
+	defer observeRPC("Version", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "Version", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "Version")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
+	release, err := in.c.rpcLimiter.acquire("Version")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	log.Tracef("Version with client side version %q", r.GetVersion())
 	defer func() {
 		if err != nil {
@@ -430,50 +668,337 @@ func (in *instrumentedService) Version(ctx context.Context, r *runtime.VersionRe
 			log.Tracef("Version returns %+v", res)
 		}
 	}()
-	return in.c.Version(ctrdutil.WithNamespace(ctx), r)
+	return in.c.Version(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) UpdateRuntimeConfig(ctx context.Context, r *runtime.UpdateRuntimeConfigRequest) (res *runtime.UpdateRuntimeConfigResponse, err error) {
+	defer observeRPC("UpdateRuntimeConfig", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "UpdateRuntimeConfig", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "UpdateRuntimeConfig")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Debugf("UpdateRuntimeConfig with config %+v", r.GetRuntimeConfig())
+	release, err := in.c.rpcLimiter.acquire("UpdateRuntimeConfig")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.CNI.Debugf("UpdateRuntimeConfig with config %+v", r.GetRuntimeConfig())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Error("UpdateRuntimeConfig failed")
+			log.CNI.WithError(err).Error("UpdateRuntimeConfig failed")
 		} else {
-			logrus.Debug("UpdateRuntimeConfig returns returns successfully")
+			log.CNI.Debug("UpdateRuntimeConfig returns returns successfully")
 		}
 	}()
-	return in.c.UpdateRuntimeConfig(ctrdutil.WithNamespace(ctx), r)
+	return in.c.UpdateRuntimeConfig(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) LoadImage(ctx context.Context, r *api.LoadImageRequest) (res *api.LoadImageResponse, err error) {
+	defer observeRPC("LoadImage", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "LoadImage", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "LoadImage")
+	defer func() { span.end(err) }()
+	if err := in.checkInitialized(); err != nil {
+		return nil, err
+	}
+	release, err := in.c.rpcLimiter.acquire("LoadImage")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Image.Debugf("LoadImage from file %q", r.GetFilePath())
+	defer func() {
+		if err != nil {
+			log.Image.WithError(err).Error("LoadImage failed")
+		} else {
+			log.Image.Debugf("LoadImage returns images %+v", res.GetImages())
+		}
+	}()
+	return in.c.LoadImage(in.c.withNamespace(ctx), r)
+}
+
+func (in *instrumentedService) CheckpointContainer(ctx context.Context, r *api.CheckpointContainerRequest) (res *api.CheckpointContainerResponse, err error) {
+	defer observeRPC("CheckpointContainer", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "CheckpointContainer", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "CheckpointContainer")
+	defer func() { span.end(err) }()
+	if err := in.checkInitialized(); err != nil {
+		return nil, err
+	}
+	release, err := in.c.rpcLimiter.acquire("CheckpointContainer")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Container.Infof("CheckpointContainer for %q to %q", r.GetContainerId(), r.GetLocation())
+	defer func() {
+		if err != nil {
+			log.Container.WithError(err).Errorf("CheckpointContainer for %q failed", r.GetContainerId())
+		} else {
+			log.Container.Infof("CheckpointContainer for %q returns successfully", r.GetContainerId())
+		}
+	}()
+	return in.c.CheckpointContainer(in.c.withNamespace(ctx), r)
+}
+
+func (in *instrumentedService) ListImagePulls(ctx context.Context, r *api.ListImagePullsRequest) (res *api.ListImagePullsResponse, err error) {
+	defer observeRPC("ListImagePulls", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ListImagePulls", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ListImagePulls")
+	defer func() { span.end(err) }()
+	if err := in.checkInitialized(); err != nil {
+		return nil, err
+	}
+	release, err := in.c.rpcLimiter.acquire("ListImagePulls")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Image.Tracef("ListImagePulls")
+	defer func() {
+		if err != nil {
+			log.Image.WithError(err).Error("ListImagePulls failed")
+		} else {
+			log.Image.Tracef("ListImagePulls returns pulls %+v", res.GetPulls())
+		}
+	}()
+	return in.c.ListImagePulls(in.c.withNamespace(ctx), r)
+}
+
+func (in *instrumentedService) PodSandboxStats(ctx context.Context, r *api.PodSandboxStatsRequest) (res *api.PodSandboxStatsResponse, err error) {
+	defer observeRPC("PodSandboxStats", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "PodSandboxStats", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "PodSandboxStats")
+	defer func() { span.end(err) }()
+	if err := in.checkInitialized(); err != nil {
+		return nil, err
+	}
+	release, err := in.c.rpcLimiter.acquire("PodSandboxStats")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Sandbox.Tracef("PodSandboxStats for %q", r.GetPodSandboxId())
+	defer func() {
+		if err != nil {
+			log.Sandbox.WithError(err).Errorf("PodSandboxStats for %q failed", r.GetPodSandboxId())
+		} else {
+			log.Sandbox.Tracef("PodSandboxStats for %q returns stats %+v", r.GetPodSandboxId(), res.GetStats())
+		}
+	}()
+	return in.c.PodSandboxStats(in.c.withNamespace(ctx), r)
+}
+
+func (in *instrumentedService) ListPodSandboxStats(ctx context.Context, r *api.ListPodSandboxStatsRequest) (res *api.ListPodSandboxStatsResponse, err error) {
+	defer observeRPC("ListPodSandboxStats", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ListPodSandboxStats", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ListPodSandboxStats")
+	defer func() { span.end(err) }()
+	if err := in.checkInitialized(); err != nil {
+		return nil, err
+	}
+	release, err := in.c.rpcLimiter.acquire("ListPodSandboxStats")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Sandbox.Tracef("ListPodSandboxStats with filter %+v", r.GetFilter())
+	defer func() {
+		if err != nil {
+			log.Sandbox.WithError(err).Error("ListPodSandboxStats failed")
+		} else {
+			log.Sandbox.Tracef("ListPodSandboxStats returns stats %+v", res.GetStats())
+		}
+	}()
+	return in.c.ListPodSandboxStats(in.c.withNamespace(ctx), r)
+}
+
+func (in *instrumentedService) ListMetricDescriptors(ctx context.Context, r *api.ListMetricDescriptorsRequest) (res *api.ListMetricDescriptorsResponse, err error) {
+	defer observeRPC("ListMetricDescriptors", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ListMetricDescriptors", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ListMetricDescriptors")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Debugf("LoadImage from file %q", r.GetFilePath())
+	release, err := in.c.rpcLimiter.acquire("ListMetricDescriptors")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Tracef("ListMetricDescriptors")
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Error("LoadImage failed")
+			logrus.WithError(err).Error("ListMetricDescriptors failed")
 		} else {
-			logrus.Debugf("LoadImage returns images %+v", res.GetImages())
+			log.Tracef("ListMetricDescriptors returns descriptors %+v", res.GetDescriptors())
 		}
 	}()
-	return in.c.LoadImage(ctrdutil.WithNamespace(ctx), r)
+	return in.c.ListMetricDescriptors(in.c.withNamespace(ctx), r)
+}
+
+func (in *instrumentedService) ListPodSandboxMetrics(ctx context.Context, r *api.ListPodSandboxMetricsRequest) (res *api.ListPodSandboxMetricsResponse, err error) {
+	defer observeRPC("ListPodSandboxMetrics", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ListPodSandboxMetrics", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ListPodSandboxMetrics")
+	defer func() { span.end(err) }()
+	if err := in.checkInitialized(); err != nil {
+		return nil, err
+	}
+	release, err := in.c.rpcLimiter.acquire("ListPodSandboxMetrics")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Sandbox.Tracef("ListPodSandboxMetrics")
+	defer func() {
+		if err != nil {
+			log.Sandbox.WithError(err).Error("ListPodSandboxMetrics failed")
+		} else {
+			log.Sandbox.Tracef("ListPodSandboxMetrics returns metrics %+v", res.GetPodMetrics())
+		}
+	}()
+	return in.c.ListPodSandboxMetrics(in.c.withNamespace(ctx), r)
 }
 
 func (in *instrumentedService) ReopenContainerLog(ctx context.Context, r *runtime.ReopenContainerLogRequest) (res *runtime.ReopenContainerLogResponse, err error) {
+	defer observeRPC("ReopenContainerLog", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ReopenContainerLog", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ReopenContainerLog")
+	defer func() { span.end(err) }()
+	if err := in.checkInitialized(); err != nil {
+		return nil, err
+	}
+	release, err := in.c.rpcLimiter.acquire("ReopenContainerLog")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Container.Debugf("ReopenContainerLog for %q", r.GetContainerId())
+	defer func() {
+		if err != nil {
+			log.Container.WithError(err).Errorf("ReopenContainerLog for %q failed", r.GetContainerId())
+		} else {
+			log.Container.Debugf("ReopenContainerLog for %q returns successfully", r.GetContainerId())
+		}
+	}()
+	return in.c.ReopenContainerLog(in.c.withNamespace(ctx), r)
+}
+
+func (in *instrumentedService) SetDrain(ctx context.Context, r *api.SetDrainRequest) (res *api.SetDrainResponse, err error) {
+	defer observeRPC("SetDrain", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "SetDrain", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "SetDrain")
+	defer func() { span.end(err) }()
+	if err := in.checkInitialized(); err != nil {
+		return nil, err
+	}
+	release, err := in.c.rpcLimiter.acquire("SetDrain")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	logrus.Infof("SetDrain to %t", r.GetDrain())
+	defer func() {
+		if err != nil {
+			logrus.WithError(err).Errorf("SetDrain to %t failed", r.GetDrain())
+		} else {
+			logrus.Infof("SetDrain to %t returns successfully", r.GetDrain())
+		}
+	}()
+	return in.c.SetDrain(in.c.withNamespace(ctx), r)
+}
+
+func (in *instrumentedService) SetLogLevels(ctx context.Context, r *api.SetLogLevelsRequest) (res *api.SetLogLevelsResponse, err error) {
+	defer observeRPC("SetLogLevels", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "SetLogLevels", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "SetLogLevels")
+	defer func() { span.end(err) }()
 	if err := in.checkInitialized(); err != nil {
 		return nil, err
 	}
-	logrus.Debugf("ReopenContainerLog for %q", r.GetContainerId())
+	release, err := in.c.rpcLimiter.acquire("SetLogLevels")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	logrus.Infof("SetLogLevels to %v", r.GetLevels())
+	defer func() {
+		if err != nil {
+			logrus.WithError(err).Errorf("SetLogLevels to %v failed", r.GetLevels())
+		} else {
+			logrus.Infof("SetLogLevels returns levels %v", res.GetLevels())
+		}
+	}()
+	return in.c.SetLogLevels(in.c.withNamespace(ctx), r)
+}
+
+func (in *instrumentedService) GetContainerEvents(r *api.GetEventsRequest, s api.CRIPluginService_GetContainerEventsServer) (err error) {
+	defer observeRPC("GetContainerEvents", time.Now(), &err)
+	defer in.c.auditor.record(s.Context(), "GetContainerEvents", r, time.Now(), &err)
+	if err := in.checkInitialized(); err != nil {
+		return err
+	}
+	release, err := in.c.rpcLimiter.acquire("GetContainerEvents")
+	if err != nil {
+		return err
+	}
+	defer release()
+	log.Events.Debug("GetContainerEvents starts streaming")
+	defer func() {
+		if err != nil {
+			log.Events.WithError(err).Error("GetContainerEvents failed")
+		} else {
+			log.Events.Debug("GetContainerEvents stops streaming")
+		}
+	}()
+	return in.c.GetContainerEvents(r, s)
+}
+
+func (in *instrumentedService) PrePullImages(r *api.PrePullImagesRequest, s api.CRIPluginService_PrePullImagesServer) (err error) {
+	defer observeRPC("PrePullImages", time.Now(), &err)
+	defer in.c.auditor.record(s.Context(), "PrePullImages", r, time.Now(), &err)
+	if err := in.checkInitialized(); err != nil {
+		return err
+	}
+	release, err := in.c.rpcLimiter.acquire("PrePullImages")
+	if err != nil {
+		return err
+	}
+	defer release()
+	log.Image.Infof("PrePullImages %v with max concurrent pulls %d", r.GetImages(), r.GetMaxConcurrentPulls())
+	defer func() {
+		if err != nil {
+			log.Image.WithError(err).Errorf("PrePullImages %v failed", r.GetImages())
+		} else {
+			log.Image.Infof("PrePullImages %v finished", r.GetImages())
+		}
+	}()
+	return in.c.PrePullImages(r, s)
+}
+
+func (in *instrumentedService) ContainerHugetlbStats(ctx context.Context, r *api.ContainerHugetlbStatsRequest) (res *api.ContainerHugetlbStatsResponse, err error) {
+	defer observeRPC("ContainerHugetlbStats", time.Now(), &err)
+	defer in.c.auditor.record(ctx, "ContainerHugetlbStats", r, time.Now(), &err)
+	ctx, span := in.c.tracer.start(ctx, "ContainerHugetlbStats")
+	defer func() { span.end(err) }()
+	if err := in.checkInitialized(); err != nil {
+		return nil, err
+	}
+	release, err := in.c.rpcLimiter.acquire("ContainerHugetlbStats")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	log.Container.Tracef("ContainerHugetlbStats for %q", r.GetContainerId())
 	defer func() {
 		if err != nil {
-			logrus.WithError(err).Errorf("ReopenContainerLog for %q failed", r.GetContainerId())
+			log.Container.WithError(err).Errorf("ContainerHugetlbStats for %q failed", r.GetContainerId())
 		} else {
-			logrus.Debugf("ReopenContainerLog for %q returns successfully", r.GetContainerId())
+			log.Container.Tracef("ContainerHugetlbStats for %q returns stats %+v", r.GetContainerId(), res.GetStats())
 		}
 	}()
-	return in.c.ReopenContainerLog(ctrdutil.WithNamespace(ctx), r)
+	return in.c.ContainerHugetlbStats(in.c.withNamespace(ctx), r)
 }
@@ -0,0 +1,43 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/atomic"
+)
+
+func TestUninitializedServiceIsUnavailable(t *testing.T) {
+	c := newTestCRIService()
+	c.initialized = atomic.NewBool(false)
+	in := newInstrumentedService(c)
+	ctx := context.Background()
+
+	_, err := in.Exec(ctx, &runtime.ExecRequest{ContainerId: "test-id"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = in.Attach(ctx, &runtime.AttachRequest{ContainerId: "test-id"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = in.PortForward(ctx, &runtime.PortForwardRequest{PodSandboxId: "test-id"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
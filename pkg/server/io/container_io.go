@@ -44,6 +44,12 @@ type ContainerIO struct {
 	stdoutGroup *cioutil.WriterGroup
 	stderrGroup *cioutil.WriterGroup
 
+	// stdinMu serializes writes of concurrent attach clients into stdin, so
+	// that chunks of their input are not interleaved.
+	stdinMu sync.Mutex
+	// terminalSizes tracks the terminal sizes of attach clients.
+	terminalSizes *terminalSizes
+
 	closer *wgCloser
 }
 
@@ -74,9 +80,10 @@ func WithNewFIFOs(root string, tty, stdin bool) ContainerIOOpts {
 // NewContainerIO creates container io.
 func NewContainerIO(id string, opts ...ContainerIOOpts) (_ *ContainerIO, err error) {
 	c := &ContainerIO{
-		id:          id,
-		stdoutGroup: cioutil.NewWriterGroup(),
-		stderrGroup: cioutil.NewWriterGroup(),
+		id:            id,
+		stdoutGroup:   cioutil.NewWriterGroup(),
+		stderrGroup:   cioutil.NewWriterGroup(),
+		terminalSizes: newTerminalSizes(),
 	}
 	for _, opt := range opts {
 		if err := opt(c); err != nil {
@@ -130,7 +137,9 @@ func (c *ContainerIO) Pipe() {
 	}
 }
 
-// Attach attaches container stdio.
+// Attach attaches container stdio. Multiple clients can attach at the same
+// time, they all get the output, and the terminal has the size of the client
+// resized last.
 // TODO(random-liu): Use pools.Copy in docker to reduce memory usage?
 func (c *ContainerIO) Attach(opts AttachOptions) {
 	var wg sync.WaitGroup
@@ -139,6 +148,25 @@ func (c *ContainerIO) Attach(opts AttachOptions) {
 	stdoutKey := streamKey(c.id, "attach-"+key, Stdout)
 	stderrKey := streamKey(c.id, "attach-"+key, Stderr)
 
+	if opts.Tty && opts.Resize != nil && opts.ResizeTerminal != nil {
+		c.terminalSizes.add(key)
+		defer func() {
+			if err := c.terminalSizes.remove(key, opts.ResizeTerminal); err != nil {
+				logrus.WithError(err).Errorf("Failed to replay terminal size of container %q", c.id)
+			}
+		}()
+		go func() {
+			for size := range opts.Resize {
+				if size.Height < 1 || size.Width < 1 {
+					continue
+				}
+				if err := c.terminalSizes.resize(key, size, opts.ResizeTerminal); err != nil {
+					logrus.WithError(err).Errorf("Failed to resize terminal of container %q", c.id)
+				}
+			}
+		}()
+	}
+
 	var stdinStreamRC io.ReadCloser
 	if c.stdin != nil && opts.Stdin != nil {
 		// Create a wrapper of stdin which could be closed. Note that the
@@ -147,7 +175,7 @@ func (c *ContainerIO) Attach(opts AttachOptions) {
 		stdinStreamRC = cioutil.NewWrapReadCloser(opts.Stdin)
 		wg.Add(1)
 		go func() {
-			if _, err := io.Copy(c.stdin, stdinStreamRC); err != nil {
+			if _, err := io.Copy(&lockedWriter{mu: &c.stdinMu, w: c.stdin}, stdinStreamRC); err != nil {
 				logrus.WithError(err).Errorf("Failed to pipe stdin for container attach %q", c.id)
 			}
 			logrus.Infof("Attach stream %q closed", stdinKey)
@@ -197,6 +225,18 @@ func (c *ContainerIO) Attach(opts AttachOptions) {
 	wg.Wait()
 }
 
+// lockedWriter holds a lock while writing.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
 // AddOutput adds new write closers to the container stream, and returns existing
 // write closers if there are any.
 func (c *ContainerIO) AddOutput(name string, stdout, stderr io.WriteCloser) (io.WriteCloser, io.WriteCloser) {
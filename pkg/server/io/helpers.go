@@ -26,6 +26,7 @@ import (
 	"github.com/containerd/containerd/cio"
 	"github.com/containerd/fifo"
 	"golang.org/x/net/context"
+	"k8s.io/client-go/tools/remotecommand"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 )
 
@@ -38,6 +39,10 @@ type AttachOptions struct {
 	StdinOnce bool
 	// CloseStdin is the function to close container stdin.
 	CloseStdin func() error
+	// Resize receives the terminal sizes of the client, only used with tty.
+	Resize <-chan remotecommand.TerminalSize
+	// ResizeTerminal is the function to resize the container terminal.
+	ResizeTerminal func(remotecommand.TerminalSize) error
 }
 
 // StreamType is the type of the stream, stdout/stderr.
@@ -19,6 +19,7 @@ package io
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"time"
@@ -40,6 +41,28 @@ const (
 	defaultBufSize = 4096
 )
 
+// LogFormat is the format of container log files.
+type LogFormat string
+
+const (
+	// LogFormatCRI is the CRI defined logging format, e.g.
+	// "2016-10-06T00:17:09.669794202Z stdout F log content".
+	LogFormatCRI LogFormat = "cri"
+	// LogFormatJSON is a JSON lines format compatible with the docker
+	// json-file format, e.g. {"log":"log content\n","stream":"stdout",
+	// "time":"2016-10-06T00:17:09.669794202Z"}. Partial lines have no trailing
+	// newline and are flagged with "partial":true.
+	LogFormatJSON LogFormat = "json"
+)
+
+// jsonLogLine is a log line in the JSON lines format.
+type jsonLogLine struct {
+	Log     string `json:"log"`
+	Stream  string `json:"stream"`
+	Time    string `json:"time"`
+	Partial bool   `json:"partial,omitempty"`
+}
+
 // NewDiscardLogger creates logger which discards all the input.
 func NewDiscardLogger() io.WriteCloser {
 	return cioutil.NewNopWriteCloser(ioutil.Discard)
@@ -49,19 +72,20 @@ func NewDiscardLogger() io.WriteCloser {
 // log file, and decorate the log line into CRI defined format. It also
 // returns a channel which indicates whether the logger is stopped.
 // maxLen is the max length limit of a line. A line longer than the
-// limit will be cut into multiple lines.
-func NewCRILogger(path string, w io.Writer, stream StreamType, maxLen int) (io.WriteCloser, <-chan struct{}) {
+// limit will be cut into multiple lines. format is the format of the log
+// lines, the CRI format is used if it is empty.
+func NewCRILogger(path string, w io.Writer, stream StreamType, maxLen int, format LogFormat) (io.WriteCloser, <-chan struct{}) {
 	logrus.Debugf("Start writing stream %q to log file %q", stream, path)
 	prc, pwc := io.Pipe()
 	stop := make(chan struct{})
 	go func() {
-		redirectLogs(path, prc, w, stream, maxLen)
+		redirectLogs(path, prc, w, stream, maxLen, format)
 		close(stop)
 	}()
 	return pwc, stop
 }
 
-func redirectLogs(path string, rc io.ReadCloser, w io.Writer, s StreamType, maxLen int) {
+func redirectLogs(path string, rc io.ReadCloser, w io.Writer, s StreamType, maxLen int, format LogFormat) {
 	defer rc.Close()
 	var (
 		stream    = []byte(s)
@@ -79,7 +103,26 @@ func redirectLogs(path string, rc io.ReadCloser, w io.Writer, s StreamType, maxL
 	r := bufio.NewReaderSize(rc, bufSize)
 	writeLine := func(tag, line []byte) {
 		timestamp := time.Now().AppendFormat(nil, timestampFormat)
-		data := bytes.Join([][]byte{timestamp, stream, tag, line}, delimiter)
+		var data []byte
+		if format == LogFormatJSON {
+			partialLine := bytes.Equal(tag, partial)
+			if !partialLine {
+				line = append(line, eol)
+			}
+			var err error
+			data, err = json.Marshal(&jsonLogLine{
+				Log:     string(line),
+				Stream:  string(s),
+				Time:    string(timestamp),
+				Partial: partialLine,
+			})
+			if err != nil {
+				logrus.WithError(err).Errorf("Fail to marshal %q log for log file %q", s, path)
+				return
+			}
+		} else {
+			data = bytes.Join([][]byte{timestamp, stream, tag, line}, delimiter)
+		}
 		data = append(data, eol)
 		if _, err := w.Write(data); err != nil {
 			logrus.WithError(err).Errorf("Fail to write %q log to log file %q", s, path)
@@ -18,6 +18,7 @@ package io
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"strings"
 	"testing"
@@ -227,7 +228,7 @@ func TestRedirectLogs(t *testing.T) {
 		rc := ioutil.NopCloser(strings.NewReader(test.input))
 		buf := bytes.NewBuffer(nil)
 		wc := cioutil.NewNopWriteCloser(buf)
-		redirectLogs("test-path", rc, wc, test.stream, test.maxLen)
+		redirectLogs("test-path", rc, wc, test.stream, test.maxLen, LogFormatCRI)
 		output := buf.String()
 		lines := strings.Split(output, "\n")
 		lines = lines[:len(lines)-1] // Discard empty string after last \n
@@ -243,3 +244,26 @@ func TestRedirectLogs(t *testing.T) {
 		}
 	}
 }
+
+func TestRedirectLogsJSON(t *testing.T) {
+	rc := ioutil.NopCloser(strings.NewReader("test log 1\n" + strings.Repeat("a", 12) + "\n"))
+	buf := bytes.NewBuffer(nil)
+	wc := cioutil.NewNopWriteCloser(buf)
+	redirectLogs("test-path", rc, wc, Stderr, 10, LogFormatJSON)
+	lines := strings.Split(buf.String(), "\n")
+	lines = lines[:len(lines)-1] // Discard empty string after last \n
+	expected := []jsonLogLine{
+		{Log: "test log 1\n", Stream: "stderr"},
+		{Log: strings.Repeat("a", 10), Stream: "stderr", Partial: true},
+		{Log: "aa\n", Stream: "stderr"},
+	}
+	require.Len(t, lines, len(expected))
+	for i := range lines {
+		var line jsonLogLine
+		require.NoError(t, json.Unmarshal([]byte(lines[i]), &line))
+		_, err := time.Parse(timestampFormat, line.Time)
+		assert.NoError(t, err)
+		line.Time = ""
+		assert.Equal(t, expected[i], line)
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package io
+
+import (
+	"sync"
+
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// clientTerminalSize is the last terminal size of an attach client.
+type clientTerminalSize struct {
+	client string
+	size   remotecommand.TerminalSize
+}
+
+// terminalSizes tracks the terminal sizes of the clients attached to a
+// container terminal. The size of the client resized last wins, and when
+// it detaches, the size of the client resized before is replayed.
+type terminalSizes struct {
+	mu sync.Mutex
+	// clients are the attached clients.
+	clients map[string]bool
+	// sizes are the last sizes of the attached clients which resized, the
+	// latest last.
+	sizes []clientTerminalSize
+}
+
+func newTerminalSizes() *terminalSizes {
+	return &terminalSizes{clients: make(map[string]bool)}
+}
+
+// add adds an attached client.
+func (t *terminalSizes) add(client string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clients[client] = true
+}
+
+// resize records the size of a client and resizes the terminal. Sizes of
+// clients which are not attached are ignored.
+func (t *terminalSizes) resize(client string, size remotecommand.TerminalSize, resizeFunc func(remotecommand.TerminalSize) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.clients[client] {
+		return nil
+	}
+	t.sizes = append(t.removeSize(client), clientTerminalSize{client: client, size: size})
+	return resizeFunc(size)
+}
+
+// remove removes a detached client. If the terminal has the size of the
+// client, the latest size of the remaining clients is replayed.
+func (t *terminalSizes) remove(client string, resizeFunc func(remotecommand.TerminalSize) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.clients, client)
+	last := len(t.sizes) > 0 && t.sizes[len(t.sizes)-1].client == client
+	t.sizes = t.removeSize(client)
+	if !last || len(t.sizes) == 0 {
+		return nil
+	}
+	return resizeFunc(t.sizes[len(t.sizes)-1].size)
+}
+
+// removeSize returns the sizes without the size of a client.
+func (t *terminalSizes) removeSize(client string) []clientTerminalSize {
+	var sizes []clientTerminalSize
+	for _, s := range t.sizes {
+		if s.client != client {
+			sizes = append(sizes, s)
+		}
+	}
+	return sizes
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package io
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+func TestTerminalSizes(t *testing.T) {
+	var applied []remotecommand.TerminalSize
+	resizeFunc := func(size remotecommand.TerminalSize) error {
+		applied = append(applied, size)
+		return nil
+	}
+	small := remotecommand.TerminalSize{Width: 80, Height: 24}
+	large := remotecommand.TerminalSize{Width: 200, Height: 60}
+	medium := remotecommand.TerminalSize{Width: 120, Height: 40}
+
+	sizes := newTerminalSizes()
+	sizes.add("a")
+	sizes.add("b")
+	sizes.add("c")
+
+	t.Logf("the size of the client resized last should win")
+	assert.NoError(t, sizes.resize("a", small, resizeFunc))
+	assert.NoError(t, sizes.resize("b", large, resizeFunc))
+	assert.NoError(t, sizes.resize("a", medium, resizeFunc))
+	assert.Equal(t, []remotecommand.TerminalSize{small, large, medium}, applied)
+
+	t.Logf("detaching a client which doesn't own the size should not resize")
+	applied = nil
+	assert.NoError(t, sizes.remove("b", resizeFunc))
+	assert.NoError(t, sizes.remove("c", resizeFunc))
+	assert.Empty(t, applied)
+
+	t.Logf("detaching the client owning the size should replay the previous size")
+	sizes.add("d")
+	assert.NoError(t, sizes.resize("d", large, resizeFunc))
+	assert.NoError(t, sizes.remove("d", resizeFunc))
+	assert.Equal(t, []remotecommand.TerminalSize{large, medium}, applied)
+
+	t.Logf("sizes of detached clients should be ignored")
+	applied = nil
+	assert.NoError(t, sizes.resize("d", small, resizeFunc))
+	assert.Empty(t, applied)
+
+	t.Logf("detaching the last client should not resize")
+	assert.NoError(t, sizes.remove("a", resizeFunc))
+	assert.Empty(t, applied)
+}
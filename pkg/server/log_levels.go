@@ -0,0 +1,39 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+	"github.com/containerd/cri/pkg/log"
+)
+
+// SetLogLevels sets the log levels of subsystems, so that e.g. image pulls
+// can be traced without the noise of the event monitor. The levels are not
+// persisted, they are reset when containerd restarts.
+func (c *criService) SetLogLevels(ctx context.Context, r *api.SetLogLevelsRequest) (*api.SetLogLevelsResponse, error) {
+	if err := log.SetLevels(r.GetLevels()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &api.SetLogLevelsResponse{
+		Levels:       log.Levels(),
+		DefaultLevel: log.GetLevel(),
+	}, nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"time"
+
+	metrics "github.com/docker/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	containerstore "github.com/containerd/cri/pkg/store/container"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+// Metrics of the cri plugin. They are registered into the prometheus default
+// registry, and served by containerd on the `/v1/metrics` endpoint of the
+// metrics address.
+var (
+	// imagePullsInProgress is the number of in progress image pulls.
+	imagePullsInProgress metrics.Gauge
+	// imagePulls is the number of finished image pulls by result.
+	imagePulls metrics.LabeledCounter
+	// imagePullBytes is the number of bytes fetched by successful image
+	// pulls, which excludes the contents already in the content store.
+	imagePullBytes metrics.Counter
+	// imagePullDuration is the duration of successful image pulls. Together
+	// with imagePullBytes, it gives the image pull throughput.
+	imagePullDuration metrics.Timer
+	// rpcDuration is the latency of cri grpc requests by method.
+	rpcDuration metrics.LabeledTimer
+	// rpcErrors is the number of failed cri grpc requests by method.
+	rpcErrors metrics.LabeledCounter
+	// streamingSessions is the number of active streaming sessions by type,
+	// i.e. exec, attach and portforward.
+	streamingSessions metrics.LabeledGauge
+	// sandboxCleanups is the number of background retried cleanups of
+	// failed sandboxes by result.
+	sandboxCleanups metrics.LabeledCounter
+	// imageDistributorFallbacks is the number of image resolutions and
+	// content fetches falling back from the distributor to the origin
+	// registry by operation.
+	imageDistributorFallbacks metrics.LabeledCounter
+)
+
+// Image pull results used as the "result" label of imagePulls.
+const (
+	imagePullSucceeded = "succeeded"
+	imagePullFailed    = "failed"
+	imagePullCancelled = "cancelled"
+)
+
+func init() {
+	ns := metrics.NewNamespace("containerd", "cri", nil)
+	imagePullsInProgress = ns.NewGauge("image_pulls_in_progress", "The number of in progress image pulls", metrics.Unit(""))
+	imagePulls = ns.NewLabeledCounter("image_pulls", "The number of finished image pulls by result", "result")
+	imagePullBytes = ns.NewCounter("image_pull_bytes", "The number of bytes fetched by successful image pulls")
+	imagePullDuration = ns.NewTimer("image_pull", "The duration of successful image pulls")
+	rpcDuration = ns.NewLabeledTimer("grpc_request", "The latency of cri grpc requests by method", "method")
+	rpcErrors = ns.NewLabeledCounter("grpc_request_errors", "The number of failed cri grpc requests by method", "method")
+	streamingSessions = ns.NewLabeledGauge("streaming_sessions", "The number of active streaming sessions by type", metrics.Unit(""), "type")
+	sandboxCleanups = ns.NewLabeledCounter("sandbox_cleanups", "The number of retried cleanups of failed sandboxes by result", "result")
+	imageDistributorFallbacks = ns.NewLabeledCounter("image_distributor_fallbacks", "The number of image resolutions and content fetches falling back to the origin registry by operation", "operation")
+	metrics.Register(ns)
+}
+
+// observeRPC records the latency and the result of a grpc request. It is
+// deferred at the beginning of each request handler.
+func observeRPC(method string, start time.Time, err *error) {
+	rpcDuration.WithValues(method).UpdateSince(start)
+	if *err != nil {
+		rpcErrors.WithValues(method).Inc()
+	}
+}
+
+// storeCollector collects the number of sandboxes and containers by state
+// from the stores when metrics are scraped.
+type storeCollector struct {
+	sandboxStore   *sandboxstore.Store
+	containerStore *containerstore.Store
+	sandboxes      *prometheus.Desc
+	containers     *prometheus.Desc
+}
+
+// registerStoreMetrics registers the metrics of sandbox and container counts
+// by state, labeled with the containerd namespace of the stores. It should
+// only be called once per containerd namespace.
+func registerStoreMetrics(namespace string, sandboxStore *sandboxstore.Store, containerStore *containerstore.Store) {
+	ns := metrics.NewNamespace("containerd", "cri", metrics.Labels{"namespace": namespace})
+	ns.Add(newStoreCollector(ns, sandboxStore, containerStore))
+	metrics.Register(ns)
+}
+
+func newStoreCollector(ns *metrics.Namespace, sandboxStore *sandboxstore.Store, containerStore *containerstore.Store) *storeCollector {
+	return &storeCollector{
+		sandboxStore:   sandboxStore,
+		containerStore: containerStore,
+		sandboxes:      ns.NewDesc("sandboxes", "The number of pod sandboxes by state", metrics.Unit(""), "state"),
+		containers:     ns.NewDesc("containers", "The number of containers by state", metrics.Unit(""), "state"),
+	}
+}
+
+// sandboxStateNames are the names of sandbox states used as metric labels.
+var sandboxStateNames = map[sandboxstore.State]string{
+	sandboxstore.StateUnknown:  "unknown",
+	sandboxstore.StateReady:    "ready",
+	sandboxstore.StateNotReady: "notready",
+}
+
+func (s *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.sandboxes
+	ch <- s.containers
+}
+
+func (s *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	sandboxes := make(map[string]int)
+	for _, name := range sandboxStateNames {
+		sandboxes[name] = 0
+	}
+	for _, sb := range s.sandboxStore.List() {
+		sandboxes[sandboxStateNames[sb.Status.Get().State]]++
+	}
+	for state, n := range sandboxes {
+		ch <- prometheus.MustNewConstMetric(s.sandboxes, prometheus.GaugeValue, float64(n), state)
+	}
+	containers := make(map[string]int)
+	for _, name := range runtime.ContainerState_name {
+		containers[name] = 0
+	}
+	for _, cntr := range s.containerStore.List() {
+		containers[criContainerStateToString(cntr.Status.Get().State())]++
+	}
+	for state, n := range containers {
+		ch <- prometheus.MustNewConstMetric(s.containers, prometheus.GaugeValue, float64(n), state)
+	}
+}
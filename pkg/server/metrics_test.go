@@ -0,0 +1,77 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	metrics "github.com/docker/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	containerstore "github.com/containerd/cri/pkg/store/container"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+func TestStoreCollector(t *testing.T) {
+	sandboxStore := sandboxstore.NewStore()
+	containerStore := containerstore.NewStore()
+	for id, state := range map[string]sandboxstore.State{
+		"sandbox-1": sandboxstore.StateReady,
+		"sandbox-2": sandboxstore.StateReady,
+		"sandbox-3": sandboxstore.StateNotReady,
+	} {
+		require.NoError(t, sandboxStore.Add(sandboxstore.NewSandbox(
+			sandboxstore.Metadata{ID: id},
+			sandboxstore.Status{State: state},
+		)))
+	}
+	for id, status := range map[string]containerstore.Status{
+		"container-1": {CreatedAt: time.Now().UnixNano()},
+		"container-2": {CreatedAt: time.Now().UnixNano(), StartedAt: time.Now().UnixNano()},
+	} {
+		cntr, err := containerstore.NewContainer(containerstore.Metadata{ID: id}, containerstore.WithFakeStatus(status))
+		require.NoError(t, err)
+		require.NoError(t, containerStore.Add(cntr))
+	}
+	registry := prometheus.NewRegistry()
+	ns := metrics.NewNamespace("containerd", "cri", nil)
+	registry.MustRegister(newStoreCollector(ns, sandboxStore, containerStore))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	counts := make(map[string]map[string]float64)
+	for _, family := range families {
+		counts[family.GetName()] = make(map[string]float64)
+		for _, m := range family.GetMetric() {
+			counts[family.GetName()][m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+		}
+	}
+	assert.Equal(t, map[string]float64{
+		"ready":    2,
+		"notready": 1,
+		"unknown":  0,
+	}, counts["containerd_cri_sandboxes"])
+	assert.Equal(t, map[string]float64{
+		"CONTAINER_CREATED": 1,
+		"CONTAINER_RUNNING": 1,
+		"CONTAINER_EXITED":  0,
+		"CONTAINER_UNKNOWN": 0,
+	}, counts["containerd_cri_containers"])
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cni "github.com/containerd/go-cni"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/pkg/errors"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+// netNSRecordsDir contains the records of the network namespaces created for
+// sandboxes. It is in the state directory, which goes away with the network
+// namespaces on reboot.
+const netNSRecordsDir = "netns"
+
+// netNSRecord records a network namespace created for a sandbox and the
+// networks set up in it. It is written before the sandbox container exists
+// and removed with the sandbox, so that orphan gc only removes the network
+// namespaces of the plugin, and can tear down their networks.
+type netNSRecord struct {
+	// ID is the id of the sandbox.
+	ID string `json:"id"`
+	// NetNSPath is the network namespace of the sandbox.
+	NetNSPath string `json:"netNSPath"`
+	// Config is the sandbox config the networks are set up with.
+	Config *runtime.PodSandboxConfig `json:"config,omitempty"`
+	// CNIResult, CNINetworks and AdditionalNetworks are the networks of the
+	// sandbox, which are only recorded once they are all set up.
+	CNIResult          *cni.CNIResult                   `json:"cniResult,omitempty"`
+	CNINetworks        []sandboxstore.CNINetwork        `json:"cniNetworks,omitempty"`
+	AdditionalNetworks []sandboxstore.NetworkAttachment `json:"additionalNetworks,omitempty"`
+}
+
+func (c *criService) getNetNSRecordPath(id string) string {
+	return filepath.Join(c.config.StateDir, netNSRecordsDir, id)
+}
+
+// writeNetNSRecord writes the record of a sandbox network namespace.
+func (c *criService) writeNetNSRecord(r netNSRecord) error {
+	if err := os.MkdirAll(filepath.Join(c.config.StateDir, netNSRecordsDir), 0700); err != nil {
+		return errors.Wrap(err, "failed to create network namespace records directory")
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal network namespace record")
+	}
+	return ioutils.AtomicWriteFile(c.getNetNSRecordPath(r.ID), data, 0600)
+}
+
+// removeNetNSRecord removes the record of a sandbox network namespace after
+// the network namespace is removed.
+func (c *criService) removeNetNSRecord(id string) error {
+	if err := os.Remove(c.getNetNSRecordPath(id)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove network namespace record of %q", id)
+	}
+	return nil
+}
+
+// listNetNSRecords returns the records of the sandbox network namespaces.
+func (c *criService) listNetNSRecords() ([]netNSRecord, error) {
+	dir := filepath.Join(c.config.StateDir, netNSRecordsDir)
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read network namespace records directory %q", dir)
+	}
+	var records []netNSRecord
+	for _, fi := range fis {
+		// Skip the temporary files of atomic writes.
+		if strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read network namespace record of %q", fi.Name())
+		}
+		var r netNSRecord
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal network namespace record of %q", fi.Name())
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
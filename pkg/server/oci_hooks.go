@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"path/filepath"
+	"strings"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/pkg/errors"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+// validateOCIHooks validates that the hooks have absolute paths.
+func validateOCIHooks(hooks criconfig.OCIHooks) error {
+	for _, list := range [][]criconfig.OCIHook{hooks.Prestart, hooks.Poststart, hooks.Poststop} {
+		for _, hook := range list {
+			if !filepath.IsAbs(hook.Path) {
+				return errors.Errorf("hook path %q is not absolute", hook.Path)
+			}
+			if hook.Timeout < 0 {
+				return errors.Errorf("invalid timeout %d of hook %q", hook.Timeout, hook.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// setOCIHooks injects the plugin level hooks, the hooks of the runtime and the
+// annotated hooks enabled by the sandbox into a container spec, in order.
+func (c *criService) setOCIHooks(g *generate.Generator, ociRuntime criconfig.Runtime, sandboxConfig *runtime.PodSandboxConfig) error {
+	hooks := []criconfig.OCIHooks{c.config.OCIHooks, ociRuntime.OCIHooks}
+	if names, ok := sandboxConfig.GetAnnotations()[annotations.OCIHooks]; ok {
+		for _, name := range strings.Split(names, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			h, ok := c.config.AnnotatedOCIHooks[name]
+			if !ok {
+				return errors.Errorf("oci hooks %q in %q annotation are not allowed", name, annotations.OCIHooks)
+			}
+			hooks = append(hooks, h)
+		}
+	}
+	for _, h := range hooks {
+		for _, hook := range h.Prestart {
+			g.AddPreStartHook(toOCIHook(hook)) // nolint: errcheck
+		}
+		for _, hook := range h.Poststart {
+			g.AddPostStartHook(toOCIHook(hook)) // nolint: errcheck
+		}
+		for _, hook := range h.Poststop {
+			g.AddPostStopHook(toOCIHook(hook)) // nolint: errcheck
+		}
+	}
+	return nil
+}
+
+// toOCIHook converts a configured hook to an OCI hook.
+func toOCIHook(hook criconfig.OCIHook) runtimespec.Hook {
+	h := runtimespec.Hook{
+		Path: hook.Path,
+		Args: hook.Args,
+		Env:  hook.Env,
+	}
+	if hook.Timeout > 0 {
+		timeout := hook.Timeout
+		h.Timeout = &timeout
+	}
+	return h
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containerd/cri/pkg/annotations"
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestValidateOCIHooks(t *testing.T) {
+	for desc, test := range map[string]struct {
+		hooks     criconfig.OCIHooks
+		expectErr bool
+	}{
+		"should accept absolute hook path": {
+			hooks: criconfig.OCIHooks{Prestart: []criconfig.OCIHook{{Path: "/usr/bin/hook", Timeout: 10}}},
+		},
+		"should reject relative hook path": {
+			hooks:     criconfig.OCIHooks{Poststop: []criconfig.OCIHook{{Path: "hook"}}},
+			expectErr: true,
+		},
+		"should reject negative timeout": {
+			hooks:     criconfig.OCIHooks{Poststart: []criconfig.OCIHook{{Path: "/usr/bin/hook", Timeout: -1}}},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		err := validateOCIHooks(test.hooks)
+		assert.Equal(t, test.expectErr, err != nil)
+	}
+}
+
+func TestContainerSpecOCIHooks(t *testing.T) {
+	testID := "test-id"
+	testSandboxID := "sandbox-id"
+	testPid := uint32(1234)
+	timeout := 10
+	globalHook := criconfig.OCIHook{Path: "/usr/bin/global", Args: []string{"global", "prestart"}, Timeout: timeout}
+	runtimeHook := criconfig.OCIHook{Path: "/usr/bin/runtime"}
+	gpuHook := criconfig.OCIHook{Path: "/usr/bin/gpu", Env: []string{"GPU=all"}}
+	for desc, test := range map[string]struct {
+		annotation string
+		expectErr  bool
+		expected   *runtimespec.Hooks
+	}{
+		"should inject global and runtime hooks": {
+			expected: &runtimespec.Hooks{
+				Prestart: []runtimespec.Hook{{Path: "/usr/bin/global", Args: []string{"global", "prestart"}, Timeout: &timeout}},
+				Poststop: []runtimespec.Hook{{Path: "/usr/bin/runtime"}},
+			},
+		},
+		"should inject annotated hooks after global hooks": {
+			annotation: "gpu",
+			expected: &runtimespec.Hooks{
+				Prestart: []runtimespec.Hook{
+					{Path: "/usr/bin/global", Args: []string{"global", "prestart"}, Timeout: &timeout},
+					{Path: "/usr/bin/gpu", Env: []string{"GPU=all"}},
+				},
+				Poststop: []runtimespec.Hook{{Path: "/usr/bin/runtime"}},
+			},
+		},
+		"should reject annotated hooks which are not configured": {
+			annotation: "gpu, unknown",
+			expectErr:  true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.OCIHooks = criconfig.OCIHooks{Prestart: []criconfig.OCIHook{globalHook}}
+		c.config.AnnotatedOCIHooks = map[string]criconfig.OCIHooks{
+			"gpu": {Prestart: []criconfig.OCIHook{gpuHook}},
+		}
+		ociRuntime := criconfig.Runtime{OCIHooks: criconfig.OCIHooks{Poststop: []criconfig.OCIHook{runtimeHook}}}
+		config, sandboxConfig, imageConfig, _ := getCreateContainerTestData()
+		if test.annotation != "" {
+			sandboxConfig.Annotations = map[string]string{annotations.OCIHooks: test.annotation}
+		}
+		spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, ociRuntime)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, spec.Hooks)
+	}
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd"
+	snapshot "github.com/containerd/containerd/snapshots"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"golang.org/x/sys/unix"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+// shimBinary is the name of the containerd shim binary.
+const shimBinary = "containerd-shim"
+
+// idRegexp matches the ids generated for sandboxes and containers, which are
+// also the keys of their snapshots.
+var idRegexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// cleanupOrphans removes the resources left by sandboxes and containers which
+// were removed while the plugin was down: network namespaces recorded for
+// sandboxes which don't exist and all their networks, CNI allocations of
+// removed sandboxes, shims of removed sandboxes and containers, and snapshots
+// without a container. With dry run, the orphans are only logged. It must be
+// called before the orphaned sandbox and container directories are removed,
+// whose names are the ids of removed sandboxes and containers.
+func (c *criService) cleanupOrphans(ctx context.Context, sandboxes, containers []containerd.Container) {
+	gc := orphanGC{dryRun: c.config.OrphanGC.DryRun}
+
+	exists := make(map[string]bool)
+	for _, sb := range sandboxes {
+		exists[sb.ID()] = true
+	}
+	orphanIDs := make(map[string]bool)
+	// Only the network namespaces recorded by the plugin are removed, the
+	// ones of other programs and other cri instances are not touched.
+	records, err := c.listNetNSRecords()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list network namespace records")
+	}
+	for _, r := range records {
+		if exists[r.ID] {
+			continue
+		}
+		orphanIDs[r.ID] = true
+		r := r
+		gc.remove("network namespace of sandbox", r.ID, func() error {
+			return c.teardownNetNSRecord(r)
+		})
+	}
+
+	ids, err := findOrphanedIDs(filepath.Join(c.config.RootDir, sandboxesDir), sandboxes)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to find orphaned sandboxes")
+	}
+	for _, id := range ids {
+		if orphanIDs[id] {
+			// Its networks are torn down with its record.
+			continue
+		}
+		orphanIDs[id] = true
+		gc.remove("cni allocation of sandbox", id, func() error {
+			if c.netPlugin == nil {
+				return errors.New("cni config not intialized")
+			}
+			// The network namespace is gone, CNI plugins still release the
+			// allocated resources, e.g. IP addresses.
+			return c.netPlugin.Remove(id, "")
+		})
+	}
+
+	ids, err = findOrphanedIDs(filepath.Join(c.config.RootDir, containersDir), containers)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to find orphaned containers")
+	}
+	for _, id := range ids {
+		orphanIDs[id] = true
+	}
+	shims, err := findOrphanedShims("/proc", c.config.ContainerdConfig.Namespace, orphanIDs)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to find orphaned shims")
+	}
+	for pid, id := range shims {
+		pid := pid
+		// The shim socket is an abstract unix socket, which goes away with
+		// the shim.
+		gc.remove("shim of", id, func() error {
+			if err := unix.Kill(pid, unix.SIGKILL); err != nil && err != unix.ESRCH {
+				return errors.Wrapf(err, "failed to kill shim %d", pid)
+			}
+			return nil
+		})
+	}
+
+	if err := c.cleanupOrphanedSnapshots(ctx, gc); err != nil {
+		logrus.WithError(err).Error("Failed to cleanup orphaned snapshots")
+	}
+}
+
+// teardownNetNSRecord tears down all networks of a recorded network namespace
+// and removes it and its record. The networks of a sandbox which failed
+// before they were recorded are torn down with the current cni config.
+func (c *criService) teardownNetNSRecord(r netNSRecord) error {
+	if filepath.Dir(r.NetNSPath) != criNetNSDir {
+		return errors.Errorf("network namespace %q is not in %q", r.NetNSPath, criNetNSDir)
+	}
+	if c.netPlugin == nil {
+		return errors.New("cni config not intialized")
+	}
+	config := r.Config
+	if config == nil {
+		config = &runtime.PodSandboxConfig{}
+	}
+	additional := r.AdditionalNetworks
+	if additional == nil {
+		for i, name := range c.getAdditionalNetworks(config) {
+			additional = append(additional, sandboxstore.NetworkAttachment{
+				Name:   name,
+				IfName: fmt.Sprintf("%s%d", additionalIfNamePrefix, i+1),
+			})
+		}
+	}
+	if err := c.teardownAdditionalNetworks(r.ID, r.NetNSPath, config, additional); err != nil {
+		return errors.Wrap(err, "failed to destroy additional networks")
+	}
+	if err := c.teardownPod(r.ID, r.NetNSPath, config, r.CNIResult, r.CNINetworks); err != nil {
+		return errors.Wrap(err, "failed to destroy network")
+	}
+	netNS, err := sandboxstore.LoadNetNS(r.NetNSPath)
+	if err != nil && err != sandboxstore.ErrClosedNetNS {
+		return err
+	}
+	if err == nil {
+		if err := netNS.Remove(); err != nil {
+			return err
+		}
+	}
+	return c.removeNetNSRecord(r.ID)
+}
+
+// cleanupOrphanedSnapshots removes the active snapshots created for sandboxes
+// and containers which don't exist any more.
+func (c *criService) cleanupOrphanedSnapshots(ctx context.Context, gc orphanGC) error {
+	// All containers in the namespace are listed, including ones not created
+	// by the plugin, whose snapshots must be kept.
+	cntrs, err := c.client.Containers(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list containers")
+	}
+	snapshotters := make(map[string]bool)
+	for _, snapshotter := range c.snapshotters() {
+		snapshotters[snapshotter] = true
+	}
+	inUse := make(map[string]bool)
+	for _, cntr := range cntrs {
+		info, err := cntr.Info(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get container info %q", cntr.ID())
+		}
+		if info.Snapshotter != "" {
+			snapshotters[info.Snapshotter] = true
+			inUse[info.Snapshotter+"/"+info.SnapshotKey] = true
+		}
+	}
+	for name := range snapshotters {
+		sn := c.client.SnapshotService(name)
+		var orphans []string
+		if err := sn.Walk(ctx, func(ctx context.Context, info snapshot.Info) error {
+			if info.Kind == snapshot.KindActive && idRegexp.MatchString(info.Name) &&
+				!inUse[name+"/"+info.Name] {
+				orphans = append(orphans, info.Name)
+			}
+			return nil
+		}); err != nil {
+			return errors.Wrapf(err, "failed to walk snapshots of snapshotter %q", name)
+		}
+		for _, key := range orphans {
+			gc.remove("snapshot", name+"/"+key, func() error {
+				return sn.Remove(ctx, key)
+			})
+		}
+	}
+	return nil
+}
+
+// orphanGC removes orphaned resources, or only logs them with dry run.
+type orphanGC struct {
+	dryRun bool
+}
+
+// remove removes an orphaned resource with the function. Failures are only
+// logged, so that one resource doesn't block the cleanup of the others.
+func (g orphanGC) remove(kind, name string, f func() error) {
+	if g.dryRun {
+		logrus.Infof("Found orphaned %s %q, not removed in dry run", kind, name)
+		return
+	}
+	if err := f(); err != nil {
+		logrus.WithError(err).Warnf("Failed to remove orphaned %s %q", kind, name)
+		return
+	}
+	logrus.Infof("Removed orphaned %s %q", kind, name)
+}
+
+// findOrphanedShims returns the ids served by the containerd shims in the
+// proc directory keyed by shim pid, for the shims in the containerd namespace
+// serving one of the ids.
+func findOrphanedShims(procDir, namespace string, ids map[string]bool) (map[int]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	fis, err := ioutil.ReadDir(procDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read directory %q", procDir)
+	}
+	shims := make(map[int]string)
+	for _, fi := range fis {
+		pid, err := strconv.Atoi(fi.Name())
+		if err != nil || !fi.IsDir() {
+			continue
+		}
+		// The process may exit at any time, skip it if it is gone.
+		cmdline, err := ioutil.ReadFile(filepath.Join(procDir, fi.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+		args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+		if filepath.Base(args[0]) != shimBinary {
+			continue
+		}
+		// The shim is started with "-namespace <namespace>" and
+		// "-workdir <root>/<namespace>/<id>".
+		var ns, workDir string
+		for i := 1; i+1 < len(args); i++ {
+			switch args[i] {
+			case "-namespace":
+				ns = args[i+1]
+			case "-workdir":
+				workDir = args[i+1]
+			}
+		}
+		id := filepath.Base(workDir)
+		if ns != namespace || workDir == "" || filepath.Base(filepath.Dir(workDir)) != namespace || !ids[id] {
+			continue
+		}
+		shims[pid] = id
+	}
+	return shims, nil
+}
+
+// findOrphanedIDs returns the names of the id directories in the base
+// directory without a corresponding containerd container.
+func findOrphanedIDs(base string, cntrs []containerd.Container) ([]string, error) {
+	dirs, err := ioutil.ReadDir(base)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to read base directory")
+	}
+	ids := make(map[string]bool)
+	for _, cntr := range cntrs {
+		ids[cntr.ID()] = true
+	}
+	var orphans []string
+	for _, d := range dirs {
+		if d.IsDir() && !ids[d.Name()] {
+			orphans = append(orphans, d.Name())
+		}
+	}
+	return orphans, nil
+}
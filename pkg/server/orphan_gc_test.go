@@ -0,0 +1,156 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+// fakeIDContainer is a containerd container which only has an id.
+type fakeIDContainer struct {
+	containerd.Container
+	id string
+}
+
+func (f fakeIDContainer) ID() string { return f.id }
+
+func TestNetNSRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-netns-records")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	c := newTestCRIService()
+	c.config.StateDir = dir
+
+	records, err := c.listNetNSRecords()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	expected := []netNSRecord{
+		{ID: "id-1", NetNSPath: "/var/run/netns/cni-1"},
+		{
+			ID:        "id-2",
+			NetNSPath: "/var/run/netns/cni-2",
+			CNINetworks: []sandboxstore.CNINetwork{
+				{IfName: "eth0", ConfList: json.RawMessage(`{"name":"net"}`)},
+			},
+		},
+	}
+	for _, r := range expected {
+		require.NoError(t, c.writeNetNSRecord(r))
+	}
+	records, err = c.listNetNSRecords()
+	require.NoError(t, err)
+	assert.Equal(t, expected, records)
+
+	require.NoError(t, c.removeNetNSRecord("id-1"))
+	require.NoError(t, c.removeNetNSRecord("id-1"))
+	records, err = c.listNetNSRecords()
+	require.NoError(t, err)
+	assert.Equal(t, expected[1:], records)
+}
+
+func TestNetNSRecordsPerInstance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-netns-records")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	c := newTestCRIService()
+	c.config.StateDir = dir
+	c.config.Instances = map[string]criconfig.InstanceConfig{
+		"user": {Namespace: "user", Address: "/run/containerd/cri-user.sock", StreamServerPort: "10011"},
+	}
+	instance := newTestCRIService()
+	instance.config = InstanceConfigs(c.config)["user"]
+
+	require.NoError(t, c.writeNetNSRecord(netNSRecord{ID: "id-1", NetNSPath: "/var/run/netns/cni-1"}))
+	require.NoError(t, instance.writeNetNSRecord(netNSRecord{ID: "id-2", NetNSPath: "/var/run/netns/cni-2"}))
+	records, err := c.listNetNSRecords()
+	require.NoError(t, err)
+	assert.Equal(t, []netNSRecord{{ID: "id-1", NetNSPath: "/var/run/netns/cni-1"}}, records,
+		"plugin should not see the records of an instance")
+	records, err = instance.listNetNSRecords()
+	require.NoError(t, err)
+	assert.Equal(t, []netNSRecord{{ID: "id-2", NetNSPath: "/var/run/netns/cni-2"}}, records,
+		"instance should not see the records of the plugin")
+}
+
+func TestTeardownNetNSRecordOutsideNetNSDir(t *testing.T) {
+	c := newTestCRIService()
+	err := c.teardownNetNSRecord(netNSRecord{ID: "id-1", NetNSPath: "/etc/passwd"})
+	assert.Error(t, err)
+}
+
+func TestFindOrphanedShims(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-proc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for pid, args := range map[string][]string{
+		"100":  {"/usr/bin/containerd-shim", "-namespace", "k8s.io", "-workdir", "/var/lib/containerd/io.containerd.runtime.v1.linux/k8s.io/orphaned-1", "-address", "/run/containerd/containerd.sock"},
+		"101":  {"containerd-shim", "-namespace", "k8s.io", "-workdir", "/var/lib/containerd/io.containerd.runtime.v1.linux/k8s.io/in-use"},
+		"102":  {"containerd-shim", "-namespace", "other", "-workdir", "/var/lib/containerd/io.containerd.runtime.v1.linux/other/orphaned-2"},
+		"103":  {"/usr/bin/sleep", "-namespace", "k8s.io", "-workdir", "/k8s.io/orphaned-2"},
+		"self": {"containerd-shim", "-namespace", "k8s.io", "-workdir", "/k8s.io/orphaned-2"},
+	} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, pid), 0755))
+		cmdline := strings.Join(args, "\x00") + "\x00"
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, pid, "cmdline"), []byte(cmdline), 0644))
+	}
+	// A process which exited after the directory is read has no cmdline.
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "104"), 0755))
+
+	shims, err := findOrphanedShims(dir, "k8s.io", map[string]bool{"orphaned-1": true, "orphaned-2": true})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]string{100: "orphaned-1"}, shims)
+
+	shims, err = findOrphanedShims(filepath.Join(dir, "not-exist"), "k8s.io", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, shims)
+}
+
+func TestFindOrphanedIDs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-orphaned-ids")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for _, id := range []string{"id-1", "id-2", "id-3"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, id), 0755))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0644))
+
+	ids, err := findOrphanedIDs(dir, []containerd.Container{
+		fakeIDContainer{id: "id-1"},
+		fakeIDContainer{id: "id-3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id-2"}, ids)
+}
+
+func TestIDRegexp(t *testing.T) {
+	assert.True(t, idRegexp.MatchString("7b1bd3a0a2b5b5e8ce7e0d8b2cfc9c6bd0f0c7a9b1d66a0f0ad40f7b7a4c7a31"))
+	assert.False(t, idRegexp.MatchString("extract-123-sha256:7b1bd3a0a2b5b5e8ce7e0d8b2cfc9c6bd0f0c7a9b1d66a0f0ad40f7b7a4c7a31"))
+	assert.False(t, idRegexp.MatchString("test-id"))
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/containerd/continuity/fs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+// setupPluginDirs switches the root and state directories to the configured
+// ones, migrating the content of the default ones into them.
+func setupPluginDirs(config *criconfig.Config) error {
+	if dir := config.PluginRootDir; dir != "" && filepath.Clean(dir) != filepath.Clean(config.RootDir) {
+		if err := migrateDir(config.RootDir, dir); err != nil {
+			return errors.Wrapf(err, "failed to migrate root directory %q to %q", config.RootDir, dir)
+		}
+		config.RootDir = dir
+	}
+	if dir := config.PluginStateDir; dir != "" && filepath.Clean(dir) != filepath.Clean(config.StateDir) {
+		// The io fifos and the /dev/shm of running containers are in the
+		// state directory, they can't be moved.
+		for _, sub := range []string{sandboxesDir, containersDir} {
+			if !isEmptyDir(filepath.Join(config.StateDir, sub)) {
+				return errors.Errorf("state directory %q still has %s, remove all pods before moving it to %q",
+					config.StateDir, sub, dir)
+			}
+		}
+		if err := migrateDir(config.StateDir, dir); err != nil {
+			return errors.Wrapf(err, "failed to migrate state directory %q to %q", config.StateDir, dir)
+		}
+		config.StateDir = dir
+	}
+	return nil
+}
+
+// migrateDir moves the content of a directory into another one. Directories
+// existing in both are merged, a file existing in both is a conflict.
+// Content is copied if it can't be renamed, e.g. across filesystems.
+func migrateDir(from, to string) error {
+	entries, err := ioutil.ReadDir(from)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to read directory %q", from)
+	}
+	if err := os.MkdirAll(to, 0711); err != nil {
+		return errors.Wrapf(err, "failed to create directory %q", to)
+	}
+	for _, e := range entries {
+		src := filepath.Join(from, e.Name())
+		dst := filepath.Join(to, e.Name())
+		fi, err := os.Lstat(dst)
+		if err == nil {
+			if !e.IsDir() || !fi.IsDir() {
+				return errors.Errorf("%q already exists", dst)
+			}
+			if err := migrateDir(src, dst); err != nil {
+				return err
+			}
+			if err := os.Remove(src); err != nil {
+				return errors.Wrapf(err, "failed to remove %q", src)
+			}
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to stat %q", dst)
+		}
+		if err := moveEntry(src, dst, e.IsDir()); err != nil {
+			return err
+		}
+		logrus.Debugf("Migrated %q to %q", src, dst)
+	}
+	return nil
+}
+
+// moveEntry moves a file or directory, copying it across filesystems.
+func moveEntry(src, dst string, dir bool) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	if lerr, ok := err.(*os.LinkError); !ok || lerr.Err != syscall.EXDEV {
+		return errors.Wrapf(err, "failed to rename %q to %q", src, dst)
+	}
+	if dir {
+		err = fs.CopyDir(dst, src)
+	} else {
+		err = fs.CopyFile(dst, src)
+	}
+	if err != nil {
+		os.RemoveAll(dst) // nolint: errcheck
+		return errors.Wrapf(err, "failed to copy %q to %q", src, dst)
+	}
+	if err := os.RemoveAll(src); err != nil {
+		return errors.Wrapf(err, "failed to remove %q", src)
+	}
+	return nil
+}
+
+// isEmptyDir returns whether a directory is empty or doesn't exist.
+func isEmptyDir(dir string) bool {
+	entries, err := ioutil.ReadDir(dir)
+	return err == nil && len(entries) == 0 || os.IsNotExist(err)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestSetupPluginDirs(t *testing.T) {
+	for desc, test := range map[string]struct {
+		oldFiles  []string
+		newFiles  []string
+		moveRoot  bool
+		moveState bool
+		expectErr bool
+		expected  []string
+	}{
+		"should keep default directories if not configured": {
+			oldFiles: []string{"root/containers/id/status"},
+			expected: []string{"root/containers/id/status"},
+		},
+		"should migrate root directory": {
+			moveRoot: true,
+			oldFiles: []string{"root/containers/id/status", "root/sandboxes/sid/hosts"},
+			expected: []string{"newroot/containers/id/status", "newroot/sandboxes/sid/hosts"},
+		},
+		"should merge into existing root directory": {
+			moveRoot: true,
+			oldFiles: []string{"root/containers/id-1/status"},
+			newFiles: []string{"newroot/containers/id-2/status"},
+			expected: []string{"newroot/containers/id-1/status", "newroot/containers/id-2/status"},
+		},
+		"should return error on conflicting files": {
+			moveRoot:  true,
+			oldFiles:  []string{"root/containers/id/status"},
+			newFiles:  []string{"newroot/containers/id/status"},
+			expectErr: true,
+		},
+		"should migrate state directory without pods": {
+			oldFiles:  []string{"state/event-backlog"},
+			moveState: true,
+			expected:  []string{"newstate/event-backlog"},
+		},
+		"should not migrate state directory with pods": {
+			oldFiles:  []string{"state/sandboxes/sid/shm"},
+			moveState: true,
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		dir, err := ioutil.TempDir("", "test-plugin-dirs")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		for _, f := range append(test.oldFiles, test.newFiles...) {
+			path := filepath.Join(dir, f)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, ioutil.WriteFile(path, []byte(f), 0644))
+		}
+		config := criconfig.Config{
+			RootDir:  filepath.Join(dir, "root"),
+			StateDir: filepath.Join(dir, "state"),
+		}
+		if test.moveRoot {
+			config.PluginRootDir = filepath.Join(dir, "newroot")
+		}
+		if test.moveState {
+			config.PluginStateDir = filepath.Join(dir, "newstate")
+		}
+		err = setupPluginDirs(&config)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		if config.PluginRootDir != "" {
+			assert.Equal(t, config.PluginRootDir, config.RootDir)
+			assert.True(t, isEmptyDir(filepath.Join(dir, "root")))
+		}
+		for _, f := range test.expected {
+			_, err := os.Stat(filepath.Join(dir, f))
+			assert.NoError(t, err, f)
+		}
+	}
+}
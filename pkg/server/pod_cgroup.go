@@ -0,0 +1,445 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/containerd/cgroups"
+	systemddbus "github.com/coreos/go-systemd/dbus"
+	"github.com/godbus/dbus"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+// podCgroupManager manages the pod level cgroup, which is the cgroup parent
+// of the sandbox and all containers in the pod.
+type podCgroupManager interface {
+	// create creates the pod cgroup. It is a no-op if the cgroup exists.
+	create(parent string) error
+	// remove removes the pod cgroup. It is a no-op if the cgroup doesn't exist.
+	remove(parent string) error
+	// update sets the memory limit and cpu quota of the pod cgroup, -1 means
+	// no limit.
+	update(parent string, resources *specs.LinuxResources) error
+	// memoryUsage returns the memory usage of the pod cgroup, 0 if it is
+	// unknown.
+	memoryUsage(parent string) (uint64, error)
+}
+
+// podCgroupCPUPeriod is the cpu period of pod cgroups in microseconds.
+const podCgroupCPUPeriod = 100000
+
+// newPodCgroupManager creates a pod cgroup manager of the systemd or cgroupfs
+// cgroup driver.
+func newPodCgroupManager(systemdCgroup bool) podCgroupManager {
+	if systemdCgroup {
+		return &systemdPodCgroupManager{unified: isUnifiedCgroupHierarchy()}
+	}
+	return &cgroupfsPodCgroupManager{
+		root:    cgroupRoot,
+		unified: isUnifiedCgroupHierarchy(),
+	}
+}
+
+// cgroupfsPodCgroupManager manages pod cgroups directly in the cgroup
+// filesystem. The cgroup parent is a path, e.g. "/kubepods/pod123".
+type cgroupfsPodCgroupManager struct {
+	// root is the mount point of the cgroup v2 unified hierarchy.
+	root string
+	// unified indicates whether the host uses the cgroup v2 unified hierarchy.
+	unified bool
+}
+
+func (m *cgroupfsPodCgroupManager) create(parent string) error {
+	if m.unified {
+		return os.MkdirAll(filepath.Join(m.root, parent), 0755)
+	}
+	_, err := cgroups.New(cgroups.V1, cgroups.StaticPath(parent), &specs.LinuxResources{})
+	return err
+}
+
+func (m *cgroupfsPodCgroupManager) remove(parent string) error {
+	if m.unified {
+		if err := os.Remove(filepath.Join(m.root, parent)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	cg, err := cgroups.Load(cgroups.V1, cgroups.StaticPath(parent))
+	if err != nil {
+		if err == cgroups.ErrCgroupDeleted {
+			return nil
+		}
+		return err
+	}
+	return cg.Delete()
+}
+
+func (m *cgroupfsPodCgroupManager) update(parent string, resources *specs.LinuxResources) error {
+	if !m.unified {
+		cg, err := cgroups.Load(cgroups.V1, cgroups.StaticPath(parent))
+		if err != nil {
+			return err
+		}
+		return cg.Update(resources)
+	}
+	memory, cpu := "max", "max"
+	if limit := *resources.Memory.Limit; limit >= 0 {
+		memory = strconv.FormatInt(limit, 10)
+	}
+	if quota := *resources.CPU.Quota; quota >= 0 {
+		cpu = strconv.FormatInt(quota, 10)
+	}
+	cpu += " " + strconv.FormatUint(*resources.CPU.Period, 10)
+	dir := filepath.Join(m.root, parent)
+	if err := ioutil.WriteFile(filepath.Join(dir, "memory.max"), []byte(memory), 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "cpu.max"), []byte(cpu), 0644)
+}
+
+func (m *cgroupfsPodCgroupManager) memoryUsage(parent string) (uint64, error) {
+	if !m.unified {
+		cg, err := cgroups.Load(cgroups.V1, cgroups.StaticPath(parent))
+		if err != nil {
+			return 0, err
+		}
+		metrics, err := cg.Stat(cgroups.IgnoreNotExist)
+		if err != nil {
+			return 0, err
+		}
+		if metrics.Memory == nil || metrics.Memory.Usage == nil {
+			return 0, nil
+		}
+		return metrics.Memory.Usage.Usage, nil
+	}
+	data, err := ioutil.ReadFile(filepath.Join(m.root, parent, "memory.current"))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// systemdPodCgroupManager manages pod cgroups as systemd slices. The cgroup
+// parent is a slice, e.g. "kubepods-pod123.slice", and only its last
+// component is used, the same as in the cgroups path of containers.
+type systemdPodCgroupManager struct {
+	// unified indicates whether the host uses the cgroup v2 unified hierarchy,
+	// where systemd limits memory with MemoryMax instead of MemoryLimit.
+	unified bool
+}
+
+func (m *systemdPodCgroupManager) create(parent string) error {
+	slice, err := podSlice(parent)
+	if err != nil {
+		return err
+	}
+	conn, err := systemddbus.New()
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to systemd")
+	}
+	defer conn.Close()
+	properties := []systemddbus.Property{
+		systemddbus.PropDescription("pod cgroup " + slice),
+		{Name: "DefaultDependencies", Value: dbus.MakeVariant(false)},
+		{Name: "MemoryAccounting", Value: dbus.MakeVariant(true)},
+		{Name: "CPUAccounting", Value: dbus.MakeVariant(true)},
+	}
+	ch := make(chan string, 1)
+	if _, err := conn.StartTransientUnit(slice, "replace", properties, ch); err != nil {
+		if isDbusError(err, "org.freedesktop.systemd1.UnitExists") {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to start slice %q", slice)
+	}
+	if result := <-ch; result != "done" {
+		return errors.Errorf("failed to start slice %q: %s", slice, result)
+	}
+	return nil
+}
+
+func (m *systemdPodCgroupManager) remove(parent string) error {
+	slice, err := podSlice(parent)
+	if err != nil {
+		return err
+	}
+	conn, err := systemddbus.New()
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to systemd")
+	}
+	defer conn.Close()
+	ch := make(chan string, 1)
+	if _, err := conn.StopUnit(slice, "replace", ch); err != nil {
+		if isDbusError(err, "org.freedesktop.systemd1.NoSuchUnit") {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to stop slice %q", slice)
+	}
+	if result := <-ch; result != "done" {
+		return errors.Errorf("failed to stop slice %q: %s", slice, result)
+	}
+	return nil
+}
+
+func (m *systemdPodCgroupManager) update(parent string, resources *specs.LinuxResources) error {
+	slice, err := podSlice(parent)
+	if err != nil {
+		return err
+	}
+	conn, err := systemddbus.New()
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to systemd")
+	}
+	defer conn.Close()
+	// systemd uses the max uint64, i.e. "infinity", as no limit.
+	memory, cpu := uint64(math.MaxUint64), uint64(math.MaxUint64)
+	if limit := *resources.Memory.Limit; limit >= 0 {
+		memory = uint64(limit)
+	}
+	if quota := *resources.CPU.Quota; quota >= 0 {
+		cpu = uint64(quota) * 1000000 / *resources.CPU.Period
+	}
+	memoryProperty := "MemoryLimit"
+	if m.unified {
+		memoryProperty = "MemoryMax"
+	}
+	if err := conn.SetUnitProperties(slice, true,
+		systemddbus.Property{Name: memoryProperty, Value: dbus.MakeVariant(memory)},
+		systemddbus.Property{Name: "CPUQuotaPerSecUSec", Value: dbus.MakeVariant(cpu)},
+	); err != nil {
+		return errors.Wrapf(err, "failed to set properties of slice %q", slice)
+	}
+	return nil
+}
+
+func (m *systemdPodCgroupManager) memoryUsage(parent string) (uint64, error) {
+	slice, err := podSlice(parent)
+	if err != nil {
+		return 0, err
+	}
+	conn, err := systemddbus.New()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to connect to systemd")
+	}
+	defer conn.Close()
+	p, err := conn.GetUnitTypeProperty(slice, "Slice", "MemoryCurrent")
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get memory usage of slice %q", slice)
+	}
+	usage, ok := p.Value.Value().(uint64)
+	// The max uint64 means the usage is not available, e.g. without memory
+	// accounting.
+	if !ok || usage == math.MaxUint64 {
+		return 0, nil
+	}
+	return usage, nil
+}
+
+// podSlice returns the slice name of a systemd cgroup parent, e.g.
+// "kubepods.slice/kubepods-pod123.slice" returns "kubepods-pod123.slice".
+func podSlice(parent string) (string, error) {
+	slice := path.Base(parent)
+	if _, err := expandSlice(slice); err != nil {
+		return "", err
+	}
+	return slice, nil
+}
+
+// isDbusError returns whether the error is a dbus error with the name.
+func isDbusError(err error, name string) bool {
+	switch e := err.(type) {
+	case dbus.Error:
+		return e.Name == name
+	case *dbus.Error:
+		return e.Name == name
+	}
+	return false
+}
+
+// createPodCgroup creates the pod cgroup of the sandbox with the cgroup
+// driver of its runtime, if pod cgroups are managed and the cgroup parent is
+// set.
+func (c *criService) createPodCgroup(parent string, ociRuntime criconfig.Runtime) error {
+	if c.podCgroups == nil || parent == "" {
+		return nil
+	}
+	return c.podCgroups(c.runtimeSystemdCgroup(ociRuntime)).create(parent)
+}
+
+// removePodCgroup removes the pod cgroup of the sandbox with the cgroup driver
+// recorded with the sandbox, unless it is still used by another sandbox of
+// the same pod, e.g. a recreated sandbox.
+func (c *criService) removePodCgroup(sandbox sandboxstore.Sandbox) error {
+	parent := sandbox.Config.GetLinux().GetCgroupParent()
+	if c.podCgroups == nil || parent == "" {
+		return nil
+	}
+	for _, s := range c.sandboxStore.List() {
+		if s.ID != sandbox.ID && s.Config.GetLinux().GetCgroupParent() == parent {
+			logrus.Debugf("Pod cgroup %q of sandbox %q is still used by sandbox %q", parent, sandbox.ID, s.ID)
+			return nil
+		}
+	}
+	ociRuntime, err := c.sandboxRuntime(sandbox)
+	if err != nil {
+		return errors.Wrap(err, "failed to get sandbox runtime")
+	}
+	return c.podCgroups(c.runtimeSystemdCgroup(ociRuntime)).remove(parent)
+}
+
+// validatePodOverhead validates the pod overhead of a runtime.
+func validatePodOverhead(overhead criconfig.PodOverhead) error {
+	if overhead.MilliCPU < 0 || overhead.Memory < 0 {
+		return errors.Errorf("negative overhead %+v", overhead)
+	}
+	return nil
+}
+
+// podCgroupResources returns the limits of a pod cgroup, which are the sums
+// of the limits of the containers plus the pod overhead. A pod without
+// containers, or with a container without limit, has no limit.
+func podCgroupResources(resources []*runtime.LinuxContainerResources, overhead criconfig.PodOverhead) *specs.LinuxResources {
+	memory, quota := int64(-1), int64(-1)
+	if len(resources) > 0 {
+		var memorySum, milliCPU int64
+		memoryLimited, cpuLimited := true, true
+		for _, r := range resources {
+			if r.GetMemoryLimitInBytes() > 0 {
+				memorySum += r.GetMemoryLimitInBytes()
+			} else {
+				memoryLimited = false
+			}
+			if r.GetCpuQuota() > 0 && r.GetCpuPeriod() > 0 {
+				milliCPU += r.GetCpuQuota() * 1000 / r.GetCpuPeriod()
+			} else {
+				cpuLimited = false
+			}
+		}
+		if memoryLimited {
+			memory = memorySum + overhead.Memory
+		}
+		if cpuLimited {
+			quota = (milliCPU + overhead.MilliCPU) * podCgroupCPUPeriod / 1000
+		}
+	}
+	period := uint64(podCgroupCPUPeriod)
+	return &specs.LinuxResources{
+		Memory: &specs.LinuxMemory{Limit: &memory},
+		CPU:    &specs.LinuxCPU{Quota: &quota, Period: &period},
+	}
+}
+
+// updatePodCgroup sets the limits of the pod cgroup of a sandbox from the
+// current resources of its containers and the pod overhead of its recorded
+// runtime, so that the pod cgroup is not smaller than what the pod, including
+// the sandbox VM or shim of the runtime, consumes.
+func (c *criService) updatePodCgroup(sandboxID string) error {
+	if c.podCgroups == nil {
+		return nil
+	}
+	// Concurrent updates are serialized, so that the limits written last are
+	// summed from the latest container resources.
+	unlock := c.podCgroupLocks.lock(sandboxID)
+	defer unlock()
+	sandbox, err := c.sandboxStore.Get(sandboxID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get sandbox %q", sandboxID)
+	}
+	parent := sandbox.Config.GetLinux().GetCgroupParent()
+	if parent == "" {
+		return nil
+	}
+	ociRuntime, err := c.sandboxRuntime(sandbox)
+	if err != nil {
+		return errors.Wrap(err, "failed to get sandbox runtime")
+	}
+	var resources []*runtime.LinuxContainerResources
+	for _, cntr := range c.containerStore.List() {
+		if cntr.SandboxID != sandboxID {
+			continue
+		}
+		r := cntr.Status.Get().Resources
+		if r == nil {
+			r = cntr.Config.GetLinux().GetResources()
+		}
+		resources = append(resources, r)
+	}
+	m := c.podCgroups(c.runtimeSystemdCgroup(ociRuntime))
+	limits := podCgroupResources(resources, ociRuntime.PodOverhead)
+	// A memory limit below the usage fails to be set, or OOM kills processes
+	// of the pod, e.g. when a container limit is lowered.
+	if limit := *limits.Memory.Limit; limit >= 0 {
+		usage, err := m.memoryUsage(parent)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get memory usage of pod cgroup %q", parent)
+		}
+		if uint64(limit) < usage {
+			return errors.Errorf("memory limit %d of pod cgroup %q is below its usage %d", limit, parent, usage)
+		}
+	}
+	return m.update(parent, limits)
+}
+
+// keyMutex is a mutex per key. The zero value is ready to use.
+type keyMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock is the mutex of a key, with the number of its holders and waiters.
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock locks the mutex of a key, and returns the function unlocking it.
+func (m *keyMutex) lock(key string) func() {
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*keyLock)
+	}
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyLock{}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(m.locks, key)
+		}
+	}
+}
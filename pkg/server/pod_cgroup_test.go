@@ -0,0 +1,297 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	containerstore "github.com/containerd/cri/pkg/store/container"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+// fakePodCgroupManager records the existing pod cgroups and their resources.
+type fakePodCgroupManager struct {
+	cgroups   map[string]bool
+	resources map[string]*specs.LinuxResources
+	usage     uint64
+}
+
+func (m *fakePodCgroupManager) create(parent string) error {
+	m.cgroups[parent] = true
+	return nil
+}
+
+func (m *fakePodCgroupManager) remove(parent string) error {
+	delete(m.cgroups, parent)
+	return nil
+}
+
+func (m *fakePodCgroupManager) update(parent string, resources *specs.LinuxResources) error {
+	m.resources[parent] = resources
+	return nil
+}
+
+func (m *fakePodCgroupManager) memoryUsage(parent string) (uint64, error) {
+	return m.usage, nil
+}
+
+func TestUnifiedCgroupfsPodCgroupManager(t *testing.T) {
+	root, err := ioutil.TempDir("", "test-pod-cgroup")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	m := &cgroupfsPodCgroupManager{root: root, unified: true}
+	parent := "/kubepods/burstable/pod123"
+
+	t.Logf("should create pod cgroup")
+	require.NoError(t, m.create(parent))
+	require.NoError(t, m.create(parent), "create should be idempotent")
+	_, err = os.Stat(filepath.Join(root, parent))
+	assert.NoError(t, err)
+
+	t.Logf("should update pod cgroup limits")
+	require.NoError(t, m.update(parent, podCgroupResources([]*runtime.LinuxContainerResources{
+		{MemoryLimitInBytes: 1 << 20, CpuQuota: 50000, CpuPeriod: 100000},
+	}, criconfig.PodOverhead{})))
+	for file, expected := range map[string]string{"memory.max": "1048576", "cpu.max": "50000 100000"} {
+		data, err := ioutil.ReadFile(filepath.Join(root, parent, file))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(data))
+	}
+	require.NoError(t, m.update(parent, podCgroupResources(nil, criconfig.PodOverhead{})))
+	for file, expected := range map[string]string{"memory.max": "max", "cpu.max": "max 100000"} {
+		data, err := ioutil.ReadFile(filepath.Join(root, parent, file))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(data))
+	}
+
+	t.Logf("should read pod cgroup memory usage")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, parent, "memory.current"), []byte("4096\n"), 0644))
+	usage, err := m.memoryUsage(parent)
+	require.NoError(t, err)
+	assert.EqualValues(t, 4096, usage)
+
+	for _, file := range []string{"memory.max", "cpu.max", "memory.current"} {
+		require.NoError(t, os.Remove(filepath.Join(root, parent, file)))
+	}
+
+	t.Logf("should remove pod cgroup")
+	require.NoError(t, m.remove(parent))
+	require.NoError(t, m.remove(parent), "remove should be idempotent")
+	_, err = os.Stat(filepath.Join(root, parent))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPodSlice(t *testing.T) {
+	for desc, test := range map[string]struct {
+		parent    string
+		expected  string
+		expectErr bool
+	}{
+		"should use slice as is": {
+			parent:   "kubepods-pod123.slice",
+			expected: "kubepods-pod123.slice",
+		},
+		"should use the last slice of a path": {
+			parent:   "kubepods.slice/kubepods-pod123.slice",
+			expected: "kubepods-pod123.slice",
+		},
+		"should fail with cgroupfs path": {
+			parent:    "/kubepods/pod123",
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		slice, err := podSlice(test.parent)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, slice)
+	}
+}
+
+func TestRemovePodCgroup(t *testing.T) {
+	newSandbox := func(id, parent string) sandboxstore.Sandbox {
+		return sandboxstore.NewSandbox(sandboxstore.Metadata{
+			ID: id,
+			Config: &runtime.PodSandboxConfig{
+				Linux: &runtime.LinuxPodSandboxConfig{CgroupParent: parent},
+			},
+		}, sandboxstore.Status{State: sandboxstore.StateNotReady})
+	}
+	c := newTestCRIService()
+	m := &fakePodCgroupManager{cgroups: make(map[string]bool)}
+	c.podCgroups = func(bool) podCgroupManager { return m }
+	parent := "/kubepods/pod123"
+	old := newSandbox("old", parent)
+	recreated := newSandbox("recreated", parent)
+	require.NoError(t, c.createPodCgroup(parent, criconfig.Runtime{}))
+	require.NoError(t, c.sandboxStore.Add(old))
+	require.NoError(t, c.sandboxStore.Add(recreated))
+
+	t.Logf("should not remove pod cgroup used by another sandbox")
+	require.NoError(t, c.removePodCgroup(old))
+	assert.True(t, m.cgroups[parent])
+	c.sandboxStore.Delete(old.ID)
+
+	t.Logf("should remove pod cgroup when the last sandbox is removed")
+	require.NoError(t, c.removePodCgroup(recreated))
+	assert.False(t, m.cgroups[parent])
+
+	t.Logf("should remove pod cgroup with the cgroup driver recorded with the sandbox")
+	systemdCgroup := true
+	recorded := newSandbox("recorded", "/kubepods/pod456")
+	recorded.Runtime = &criconfig.Runtime{SystemdCgroup: &systemdCgroup}
+	var usedSystemd bool
+	c.podCgroups = func(systemd bool) podCgroupManager {
+		usedSystemd = systemd
+		return m
+	}
+	c.config.SystemdCgroup = false
+	require.NoError(t, c.removePodCgroup(recorded))
+	assert.True(t, usedSystemd)
+
+	t.Logf("should not manage pod cgroup when it is disabled")
+	c.podCgroups = nil
+	assert.NoError(t, c.createPodCgroup(parent, criconfig.Runtime{}))
+	assert.False(t, m.cgroups[parent])
+}
+
+func TestPodCgroupResources(t *testing.T) {
+	overhead := criconfig.PodOverhead{MilliCPU: 250, Memory: 128 << 20}
+	for desc, test := range map[string]struct {
+		resources      []*runtime.LinuxContainerResources
+		expectedMemory int64
+		expectedQuota  int64
+	}{
+		"should not limit pod without containers": {
+			expectedMemory: -1,
+			expectedQuota:  -1,
+		},
+		"should add overhead to the sums of container limits": {
+			resources: []*runtime.LinuxContainerResources{
+				{MemoryLimitInBytes: 256 << 20, CpuQuota: 50000, CpuPeriod: 100000},
+				{MemoryLimitInBytes: 512 << 20, CpuQuota: 10000, CpuPeriod: 10000},
+			},
+			expectedMemory: (256 + 512 + 128) << 20,
+			expectedQuota:  175000,
+		},
+		"should not limit pod with a container without limits": {
+			resources: []*runtime.LinuxContainerResources{
+				{MemoryLimitInBytes: 256 << 20, CpuQuota: 50000, CpuPeriod: 100000},
+				{CpuQuota: 50000, CpuPeriod: 100000},
+				{MemoryLimitInBytes: 256 << 20},
+			},
+			expectedMemory: -1,
+			expectedQuota:  -1,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		r := podCgroupResources(test.resources, overhead)
+		assert.Equal(t, test.expectedMemory, *r.Memory.Limit)
+		assert.Equal(t, test.expectedQuota, *r.CPU.Quota)
+		assert.EqualValues(t, podCgroupCPUPeriod, *r.CPU.Period)
+	}
+}
+
+func TestUpdatePodCgroup(t *testing.T) {
+	c := newTestCRIService()
+	m := &fakePodCgroupManager{cgroups: make(map[string]bool), resources: make(map[string]*specs.LinuxResources)}
+	c.podCgroups = func(bool) podCgroupManager { return m }
+	c.config.ContainerdConfig.DefaultRuntime.PodOverhead = criconfig.PodOverhead{Memory: 100}
+	parent := "/kubepods/pod123"
+	require.NoError(t, c.sandboxStore.Add(sandboxstore.NewSandbox(sandboxstore.Metadata{
+		ID:     "sandbox",
+		Config: &runtime.PodSandboxConfig{Linux: &runtime.LinuxPodSandboxConfig{CgroupParent: parent}},
+	}, sandboxstore.Status{State: sandboxstore.StateReady})))
+	for id, sandboxID := range map[string]string{"container": "sandbox", "other": "other-sandbox"} {
+		cntr, err := containerstore.NewContainer(containerstore.Metadata{
+			ID:        id,
+			SandboxID: sandboxID,
+			Config: &runtime.ContainerConfig{Linux: &runtime.LinuxContainerConfig{
+				Resources: &runtime.LinuxContainerResources{MemoryLimitInBytes: 1000},
+			}},
+		}, containerstore.WithFakeStatus(containerstore.Status{}))
+		require.NoError(t, err)
+		require.NoError(t, c.containerStore.Add(cntr))
+	}
+
+	t.Logf("should set pod cgroup limits from containers of the sandbox and overhead")
+	require.NoError(t, c.updatePodCgroup("sandbox"))
+	assert.EqualValues(t, 1100, *m.resources[parent].Memory.Limit)
+
+	t.Logf("should use updated container resources")
+	cntr, err := c.containerStore.Get("container")
+	require.NoError(t, err)
+	require.NoError(t, cntr.Status.Update(func(status containerstore.Status) (containerstore.Status, error) {
+		status.Resources = &runtime.LinuxContainerResources{MemoryLimitInBytes: 2000}
+		return status, nil
+	}))
+	require.NoError(t, c.updatePodCgroup("sandbox"))
+	assert.EqualValues(t, 2100, *m.resources[parent].Memory.Limit)
+
+	t.Logf("should not lower memory limit below usage")
+	m.usage = 1500
+	require.NoError(t, cntr.Status.Update(func(status containerstore.Status) (containerstore.Status, error) {
+		status.Resources = &runtime.LinuxContainerResources{MemoryLimitInBytes: 1000}
+		return status, nil
+	}))
+	assert.Error(t, c.updatePodCgroup("sandbox"))
+	assert.EqualValues(t, 2100, *m.resources[parent].Memory.Limit)
+}
+
+func TestKeyMutex(t *testing.T) {
+	var m keyMutex
+	unlock := m.lock("sandbox")
+
+	t.Logf("should not block locks of other keys")
+	m.lock("other")()
+
+	t.Logf("should block locks of the same key until unlocked")
+	locked := make(chan struct{})
+	go func() {
+		m.lock("sandbox")()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("lock of the same key is not blocked")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(10 * time.Second):
+		t.Fatal("lock of the same key is not released")
+	}
+
+	t.Logf("should remove unused locks")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	assert.Empty(t, m.locks)
+}
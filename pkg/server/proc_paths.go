@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/pkg/errors"
+
+	"github.com/containerd/cri/pkg/annotations"
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+// validateProcPaths validates masked or readonly paths.
+func validateProcPaths(paths []string) error {
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			return errors.Errorf("path %q is not absolute", p)
+		}
+	}
+	return nil
+}
+
+// setOCIProcPaths sets the masked and readonly paths of a non-privileged
+// container. The container annotations replace the paths from the config
+// and the default spec, but can only drop the paths the config allows.
+func (c *criService) setOCIProcPaths(g *generate.Generator, containerAnnotations map[string]string) error {
+	masked, err := procPaths(g.Config.Linux.MaskedPaths, c.config.MaskedPaths, containerAnnotations, annotations.MaskedPaths)
+	if err != nil {
+		return errors.Wrap(err, "invalid masked paths")
+	}
+	readonly, err := procPaths(g.Config.Linux.ReadonlyPaths, c.config.ReadonlyPaths, containerAnnotations, annotations.ReadonlyPaths)
+	if err != nil {
+		return errors.Wrap(err, "invalid readonly paths")
+	}
+	g.Config.Linux.MaskedPaths = masked
+	g.Config.Linux.ReadonlyPaths = readonly
+	return nil
+}
+
+// procPaths returns the default paths extended or cleared by the config, or
+// the paths in the annotation if it is set. The annotation may add paths, but
+// a path it drops must be in the removable paths of the config.
+func procPaths(defaults []string, config criconfig.ProcPaths, containerAnnotations map[string]string, key string) ([]string, error) {
+	var paths []string
+	if !config.ClearDefaults {
+		paths = append(paths, defaults...)
+	}
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		seen[p] = true
+	}
+	for _, p := range config.Paths {
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	value, ok := containerAnnotations[key]
+	if !ok {
+		return paths, nil
+	}
+	var annotated []string
+	kept := make(map[string]bool)
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			annotated = append(annotated, p)
+			kept[p] = true
+		}
+	}
+	if err := validateProcPaths(annotated); err != nil {
+		return nil, err
+	}
+	removable := make(map[string]bool)
+	for _, p := range config.RemovablePaths {
+		removable[p] = true
+	}
+	for _, p := range paths {
+		if !kept[p] && !removable[p] {
+			return nil, errors.Errorf("path %q can't be removed by annotation %q, it is not in removable_paths", p, key)
+		}
+	}
+	return annotated, nil
+}
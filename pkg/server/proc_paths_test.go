@@ -0,0 +1,123 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestContainerSpecProcPaths(t *testing.T) {
+	testID := "test-id"
+	testSandboxID := "sandbox-id"
+	testPid := uint32(1234)
+	defaultSpec, err := defaultRuntimeSpec(testID)
+	require.NoError(t, err)
+	defaultMasked := defaultSpec.Linux.MaskedPaths
+	defaultReadonly := defaultSpec.Linux.ReadonlyPaths
+	require.NotEmpty(t, defaultMasked)
+	require.NotEmpty(t, defaultReadonly)
+	for desc, test := range map[string]struct {
+		masked           criconfig.ProcPaths
+		readonly         criconfig.ProcPaths
+		annotations      map[string]string
+		privileged       bool
+		expectErr        bool
+		expectedMasked   []string
+		expectedReadonly []string
+	}{
+		"should use default paths": {
+			expectedMasked:   defaultMasked,
+			expectedReadonly: defaultReadonly,
+		},
+		"should extend default paths with config": {
+			masked:           criconfig.ProcPaths{Paths: []string{"/proc/sys/kernel/random", defaultMasked[0]}},
+			expectedMasked:   append(append([]string{}, defaultMasked...), "/proc/sys/kernel/random"),
+			expectedReadonly: defaultReadonly,
+		},
+		"should clear default paths with config": {
+			readonly:         criconfig.ProcPaths{ClearDefaults: true, Paths: []string{"/proc/sys"}},
+			expectedMasked:   defaultMasked,
+			expectedReadonly: []string{"/proc/sys"},
+		},
+		"should replace paths with annotations": {
+			masked: criconfig.ProcPaths{
+				Paths:          []string{"/proc/sys/kernel/random"},
+				RemovablePaths: append([]string{"/proc/sys/kernel/random"}, defaultMasked...),
+			},
+			readonly: criconfig.ProcPaths{RemovablePaths: defaultReadonly},
+			annotations: map[string]string{
+				annotations.MaskedPaths:   "/proc/kcore, /proc/keys",
+				annotations.ReadonlyPaths: "",
+			},
+			expectedMasked: []string{"/proc/kcore", "/proc/keys"},
+		},
+		"should add paths with annotation": {
+			annotations: map[string]string{
+				annotations.MaskedPaths: strings.Join(append([]string{"/proc/sys/kernel/random"}, defaultMasked...), ","),
+			},
+			expectedMasked:   append([]string{"/proc/sys/kernel/random"}, defaultMasked...),
+			expectedReadonly: defaultReadonly,
+		},
+		"should reject annotation removing path by default": {
+			annotations: map[string]string{annotations.ReadonlyPaths: ""},
+			expectErr:   true,
+		},
+		"should reject annotation removing path not in removable paths": {
+			masked: criconfig.ProcPaths{
+				Paths:          []string{"/proc/sys/kernel/random"},
+				RemovablePaths: defaultMasked,
+			},
+			annotations: map[string]string{annotations.MaskedPaths: ""},
+			expectErr:   true,
+		},
+		"should reject relative path in annotation": {
+			annotations: map[string]string{annotations.MaskedPaths: "proc/kcore"},
+			expectErr:   true,
+		},
+		"should not set paths for privileged container": {
+			annotations: map[string]string{annotations.MaskedPaths: "/proc/kcore"},
+			privileged:  true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.MaskedPaths = test.masked
+		c.config.ReadonlyPaths = test.readonly
+		config, sandboxConfig, imageConfig, _ := getCreateContainerTestData()
+		config.Annotations = test.annotations
+		if test.privileged {
+			config.Linux.SecurityContext.Privileged = true
+			sandboxConfig.Linux.SecurityContext = &runtime.LinuxSandboxSecurityContext{Privileged: true}
+		}
+		spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expectedMasked, spec.Linux.MaskedPaths)
+		assert.Equal(t, test.expectedReadonly, spec.Linux.ReadonlyPaths)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"math"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+// rpcLimiter rejects requests over the rate limits of their rpc, so that
+// misbehaving clients hammering the socket can't overload containerd. A nil
+// rpcLimiter limits nothing.
+type rpcLimiter struct {
+	limits map[string]*rpcLimit
+}
+
+// rpcLimit is the limit of requests of a rpc.
+type rpcLimit struct {
+	// rate is nil if the qps is not limited.
+	rate *rate.Limiter
+	// inflight has a slot per concurrent request. It is nil if the concurrent
+	// requests are not limited.
+	inflight chan struct{}
+}
+
+// newRPCLimiter creates a rpc limiter, it returns nil if no rpc is limited.
+func newRPCLimiter(limits map[string]criconfig.RateLimit) (*rpcLimiter, error) {
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	rpcs := criRPCs()
+	l := &rpcLimiter{limits: make(map[string]*rpcLimit)}
+	for rpc, limit := range limits {
+		if !rpcs[rpc] {
+			return nil, errors.Errorf("unknown rpc %q", rpc)
+		}
+		if limit.QPS < 0 || limit.Burst < 0 || limit.MaxConcurrent < 0 {
+			return nil, errors.Errorf("invalid limit of rpc %q, must not be negative", rpc)
+		}
+		rl := &rpcLimit{}
+		if limit.QPS > 0 {
+			burst := limit.Burst
+			if burst == 0 {
+				burst = int(math.Ceil(limit.QPS))
+			}
+			rl.rate = rate.NewLimiter(rate.Limit(limit.QPS), burst)
+		}
+		if limit.MaxConcurrent > 0 {
+			rl.inflight = make(chan struct{}, limit.MaxConcurrent)
+		}
+		l.limits[rpc] = rl
+	}
+	return l, nil
+}
+
+// criRPCs returns the names of the rpcs served by the cri plugin.
+func criRPCs() map[string]bool {
+	rpcs := make(map[string]bool)
+	t := reflect.TypeOf(&instrumentedService{})
+	for i := 0; i < t.NumMethod(); i++ {
+		rpcs[t.Method(i).Name] = true
+	}
+	return rpcs
+}
+
+// acquire admits a request of the rpc, or returns ResourceExhausted if it is
+// over the limits. The returned function must be called when the request
+// finishes.
+func (l *rpcLimiter) acquire(rpc string) (func(), error) {
+	if l == nil || l.limits[rpc] == nil {
+		return func() {}, nil
+	}
+	limit := l.limits[rpc]
+	if limit.inflight != nil {
+		select {
+		case limit.inflight <- struct{}{}:
+		default:
+			return nil, status.Errorf(codes.ResourceExhausted,
+				"%s has reached the limit of %d concurrent requests", rpc, cap(limit.inflight))
+		}
+	}
+	release := func() {
+		if limit.inflight != nil {
+			<-limit.inflight
+		}
+	}
+	if limit.rate != nil && !limit.rate.Allow() {
+		release()
+		return nil, status.Errorf(codes.ResourceExhausted,
+			"%s has reached the limit of %v requests per second", rpc, limit.rate.Limit())
+	}
+	return release, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/atomic"
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestNewRPCLimiter(t *testing.T) {
+	for desc, test := range map[string]struct {
+		limits    map[string]criconfig.RateLimit
+		expectNil bool
+		expectErr bool
+	}{
+		"should not limit without limits": {
+			expectNil: true,
+		},
+		"should accept limits of known rpcs": {
+			limits: map[string]criconfig.RateLimit{
+				"ListContainers": {QPS: 5},
+				"PullImage":      {MaxConcurrent: 2},
+			},
+		},
+		"should reject unknown rpc": {
+			limits:    map[string]criconfig.RateLimit{"ListContainer": {QPS: 5}},
+			expectErr: true,
+		},
+		"should reject negative limit": {
+			limits:    map[string]criconfig.RateLimit{"ListContainers": {QPS: -1}},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		l, err := newRPCLimiter(test.limits)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expectNil, l == nil)
+	}
+}
+
+func TestRPCLimiterAcquire(t *testing.T) {
+	l, err := newRPCLimiter(map[string]criconfig.RateLimit{
+		"ListContainers": {QPS: 0.001, Burst: 2},
+		"PullImage":      {MaxConcurrent: 1},
+	})
+	require.NoError(t, err)
+
+	t.Logf("should reject requests over the qps")
+	for i := 0; i < 2; i++ {
+		release, err := l.acquire("ListContainers")
+		require.NoError(t, err)
+		release()
+	}
+	_, err = l.acquire("ListContainers")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	t.Logf("should reject requests over the concurrency until one finishes")
+	release, err := l.acquire("PullImage")
+	require.NoError(t, err)
+	_, err = l.acquire("PullImage")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	release()
+	release, err = l.acquire("PullImage")
+	require.NoError(t, err)
+	release()
+
+	t.Logf("should not limit other rpcs")
+	for i := 0; i < 10; i++ {
+		release, err := l.acquire("ContainerStatus")
+		require.NoError(t, err)
+		release()
+	}
+
+	t.Logf("should reject requests of the instrumented service")
+	c := newTestCRIService()
+	c.initialized = atomic.NewBool(true)
+	c.rpcLimiter = l
+	_, err = newInstrumentedService(c).ListContainers(context.Background(), &runtime.ListContainersRequest{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -20,9 +20,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/containerd/containerd"
+	eventtypes "github.com/containerd/containerd/api/events"
 	containerdio "github.com/containerd/containerd/cio"
 	"github.com/containerd/containerd/errdefs"
 	containerdimages "github.com/containerd/containerd/images"
@@ -30,6 +32,7 @@ import (
 	"github.com/containerd/typeurl"
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/pkg/system"
+	"github.com/opencontainers/selinux/go-selinux/label"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
@@ -50,17 +53,32 @@ import (
 // 3) Containerd container tasks may exit or be stoppped, deleted. Even though current logic could
 // tolerant tasks being created or started, we prefer that not to happen.
 
+// maxRecoveryWorkers is the max number of sandboxes or containers loaded
+// concurrently during recovery.
+const maxRecoveryWorkers = 32
+
 // recover recovers system state from containerd and status checkpoint.
 func (c *criService) recover(ctx context.Context) error {
+	// Finish the removals interrupted by the restart first.
+	if err := c.finishRemovals(ctx); err != nil {
+		return errors.Wrap(err, "failed to finish interrupted removals")
+	}
+
 	// Recover all sandboxes.
 	sandboxes, err := c.client.Containers(ctx, filterLabel(containerKindLabel, containerKindSandbox))
 	if err != nil {
 		return errors.Wrap(err, "failed to list sandbox containers")
 	}
-	for _, sandbox := range sandboxes {
-		sb, err := loadSandbox(ctx, sandbox)
-		if err != nil {
+	sbs := make([]sandboxstore.Sandbox, len(sandboxes))
+	errs := loadInParallel(len(sandboxes), maxRecoveryWorkers, func(i int) (err error) {
+		sbs[i], err = c.loadSandbox(ctx, sandboxes[i])
+		return err
+	})
+	for i, sandbox := range sandboxes {
+		sb := sbs[i]
+		if err := errs[i]; err != nil {
 			logrus.WithError(err).Errorf("Failed to load sandbox %q", sandbox.ID())
+			c.recoveryErrors = append(c.recoveryErrors, errors.Wrapf(err, "failed to load sandbox %q", sandbox.ID()))
 			continue
 		}
 		logrus.Debugf("Loaded sandbox %+v", sb)
@@ -70,6 +88,22 @@ func (c *criService) recover(ctx context.Context) error {
 		if err := c.sandboxNameIndex.Reserve(sb.Name, sb.ID); err != nil {
 			return errors.Wrapf(err, "failed to reserve sandbox name %q", sb.Name)
 		}
+		// Reserve the SELinux label of the sandbox, so that it is not
+		// reused by new sandboxes.
+		if err := label.ReserveLabel(sb.ProcessLabel); err != nil {
+			return errors.Wrapf(err, "failed to reserve selinux label %q", sb.ProcessLabel)
+		}
+		// Reserve the user namespace ids of the sandbox, so that they are
+		// not allocated to new sandboxes.
+		c.reserveUserNamespace(sb.UserNamespace)
+		// Restore the host ports of the running sandbox, in case they are
+		// flushed while the plugin is down.
+		if sb.Status.Get().State == sandboxstore.StateReady {
+			if err := c.restoreHostPorts(sb); err != nil {
+				logrus.WithError(err).Errorf("Failed to restore host ports of sandbox %q", sb.ID)
+				c.recoveryErrors = append(c.recoveryErrors, errors.Wrapf(err, "failed to restore host ports of sandbox %q", sb.ID))
+			}
+		}
 	}
 
 	// Recover all containers.
@@ -77,10 +111,16 @@ func (c *criService) recover(ctx context.Context) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to list containers")
 	}
-	for _, container := range containers {
-		cntr, err := c.loadContainer(ctx, container)
-		if err != nil {
+	cntrs := make([]containerstore.Container, len(containers))
+	errs = loadInParallel(len(containers), maxRecoveryWorkers, func(i int) (err error) {
+		cntrs[i], err = c.loadContainer(ctx, containers[i])
+		return err
+	})
+	for i, container := range containers {
+		cntr := cntrs[i]
+		if err := errs[i]; err != nil {
 			logrus.WithError(err).Errorf("Failed to load container %q", container.ID())
+			c.recoveryErrors = append(c.recoveryErrors, errors.Wrapf(err, "failed to load container %q", container.ID()))
 			continue
 		}
 		logrus.Debugf("Loaded container %+v", cntr)
@@ -112,6 +152,10 @@ func (c *criService) recover(ctx context.Context) error {
 	// we can't even get metadata, we should cleanup orphaned sandbox/container directories
 	// with best effort.
 
+	if c.config.OrphanGC.Enabled {
+		c.cleanupOrphans(ctx, sandboxes, containers)
+	}
+
 	// Cleanup orphaned sandbox and container directories without corresponding containerd container.
 	for _, cleanup := range []struct {
 		cntrs  []containerd.Container
@@ -146,6 +190,32 @@ func (c *criService) recover(ctx context.Context) error {
 	return nil
 }
 
+// loadInParallel calls load for the indexes from 0 to n-1 with at most workers
+// concurrent calls, and returns the error of each call. A panic of a call is
+// returned as its error, so that one corrupt item doesn't break the recovery
+// of the others.
+func loadInParallel(n, workers int, load func(i int) error) []error {
+	errs := make([]error, n)
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = errors.Errorf("panic: %v", r)
+				}
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = load(i)
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
 // loadContainer loads container from containerd and status checkpoint.
 func (c *criService) loadContainer(ctx context.Context, cntr containerd.Container) (containerstore.Container, error) {
 	id := cntr.ID()
@@ -177,7 +247,7 @@ func (c *criService) loadContainer(ctx context.Context, cntr containerd.Containe
 	// Load up-to-date status from containerd.
 	var containerIO *cio.ContainerIO
 	t, err := cntr.Task(ctx, func(fifos *containerdio.FIFOSet) (_ containerdio.IO, err error) {
-		stdoutWC, stderrWC, err := c.createContainerLoggers(meta.LogPath, meta.Config.GetTty())
+		stdoutWC, stderrWC, err := c.createContainerLoggers(meta.LogPath, meta.Config.GetTty(), meta.LogFormat)
 		if err != nil {
 			return nil, err
 		}
@@ -270,6 +340,20 @@ func (c *criService) loadContainer(ctx context.Context, cntr containerd.Containe
 				status.Pid = t.Pid()
 			}
 		case containerd.Stopped:
+			if status.State() == runtime.ContainerState_CONTAINER_RUNNING {
+				// Task exited while the cri plugin was down. Keep the
+				// container running and replay its exit after recovery,
+				// so that the exit is handled in order with the other
+				// events since the event checkpoint.
+				c.eventMonitor.recordMissedExit(&eventtypes.TaskExit{
+					ContainerID: id,
+					ID:          id,
+					Pid:         status.Pid,
+					ExitStatus:  s.ExitStatus,
+					ExitedAt:    s.ExitTime,
+				})
+				break
+			}
 			// Task is stopped. Updata status and delete the task.
 			if _, err := t.Delete(ctx, containerd.WithProcessKill); err != nil && !errdefs.IsNotFound(err) {
 				return container, errors.Wrap(err, "failed to delete task")
@@ -309,7 +393,7 @@ func unknownContainerStatus() containerstore.Status {
 }
 
 // loadSandbox loads sandbox from containerd.
-func loadSandbox(ctx context.Context, cntr containerd.Container) (sandboxstore.Sandbox, error) {
+func (c *criService) loadSandbox(ctx context.Context, cntr containerd.Container) (sandboxstore.Sandbox, error) {
 	var sandbox sandboxstore.Sandbox
 	// Load sandbox metadata.
 	exts, err := cntr.Extensions(ctx)
@@ -333,6 +417,24 @@ func loadSandbox(ctx context.Context, cntr containerd.Container) (sandboxstore.S
 	}
 	createdAt := info.CreatedAt
 
+	// A sandbox without a pause container has no task, it is ready as long
+	// as its namespaces are still there.
+	if meta.NoPauseContainer {
+		state := sandboxstore.StateNotReady
+		if c.sandboxNamespacesPinned(meta.ID, meta.Config) {
+			state = sandboxstore.StateReady
+		}
+		sandbox = sandboxstore.NewSandbox(
+			*meta,
+			sandboxstore.Status{
+				CreatedAt: createdAt,
+				State:     state,
+			},
+		)
+		sandbox.Container = cntr
+		return c.loadSandboxNetNS(sandbox)
+	}
+
 	// Load sandbox status.
 	t, err := cntr.Task(ctx, nil)
 	if err != nil && !errdefs.IsNotFound(err) {
@@ -364,6 +466,20 @@ func loadSandbox(ctx context.Context, cntr containerd.Container) (sandboxstore.S
 			// Task is running, set sandbox state as READY.
 			state = sandboxstore.StateReady
 			pid = t.Pid()
+		} else if s.Status == containerd.Stopped {
+			// Task exited while the cri plugin was down. Keep the sandbox
+			// ready and replay its exit after recovery, so that the exit
+			// is handled in order with the other events since the event
+			// checkpoint.
+			state = sandboxstore.StateReady
+			pid = t.Pid()
+			c.eventMonitor.recordMissedExit(&eventtypes.TaskExit{
+				ContainerID: meta.ID,
+				ID:          meta.ID,
+				Pid:         pid,
+				ExitStatus:  s.ExitStatus,
+				ExitedAt:    s.ExitTime,
+			})
 		} else {
 			// Task is not running. Delete the task and set sandbox state as NOTREADY.
 			if _, err := t.Delete(ctx, containerd.WithProcessKill); err != nil && !errdefs.IsNotFound(err) {
@@ -383,23 +499,30 @@ func loadSandbox(ctx context.Context, cntr containerd.Container) (sandboxstore.S
 	)
 	sandbox.Container = cntr
 
-	// Load network namespace.
-	if meta.Config.GetLinux().GetSecurityContext().GetNamespaceOptions().GetNetwork() == runtime.NamespaceMode_NODE {
+	// It doesn't matter whether task is running or not. If it is running, sandbox
+	// status will be `READY`; if it is not running, sandbox status will be `NOT_READY`,
+	// kubelet will stop the sandbox which will properly cleanup everything.
+	return c.loadSandboxNetNS(sandbox)
+}
+
+// loadSandboxNetNS loads the network namespace of a sandbox.
+func (c *criService) loadSandboxNetNS(sandbox sandboxstore.Sandbox) (sandboxstore.Sandbox, error) {
+	if sandbox.Config.GetLinux().GetSecurityContext().GetNamespaceOptions().GetNetwork() == runtime.NamespaceMode_NODE {
 		// Don't need to load netns for host network sandbox.
 		return sandbox, nil
 	}
-	netNS, err := sandboxstore.LoadNetNS(meta.NetNSPath)
+	load := sandboxstore.LoadNetNS
+	if sandbox.ExternalNetNS {
+		load = sandboxstore.LoadExternalNetNS
+	}
+	netNS, err := load(sandbox.NetNSPath)
 	if err != nil {
 		if err != sandboxstore.ErrClosedNetNS {
-			return sandbox, errors.Wrapf(err, "failed to load netns %q", meta.NetNSPath)
+			return sandbox, errors.Wrapf(err, "failed to load netns %q", sandbox.NetNSPath)
 		}
 		netNS = nil
 	}
 	sandbox.NetNS = netNS
-
-	// It doesn't matter whether task is running or not. If it is running, sandbox
-	// status will be `READY`; if it is not running, sandbox status will be `NOT_READY`,
-	// kubelet will stop the sandbox which will properly cleanup everything.
 	return sandbox, nil
 }
 
@@ -0,0 +1,59 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadInParallel(t *testing.T) {
+	const n, workers = 20, 3
+	var running, maxRunning int32
+	loaded := make([]bool, n)
+	errs := loadInParallel(n, workers, func(i int) error {
+		cur := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			prev := atomic.LoadInt32(&maxRunning)
+			if cur <= prev || atomic.CompareAndSwapInt32(&maxRunning, prev, cur) {
+				break
+			}
+		}
+		switch i {
+		case 3:
+			return errors.New("corrupt")
+		case 5:
+			panic("corrupt")
+		}
+		loaded[i] = true
+		return nil
+	})
+	assert.True(t, maxRunning <= workers, "at most %d concurrent loads", workers)
+	for i := 0; i < n; i++ {
+		if i == 3 || i == 5 {
+			assert.Error(t, errs[i], "item %d", i)
+			assert.False(t, loaded[i], "item %d", i)
+			continue
+		}
+		assert.NoError(t, errs[i], "item %d", i)
+		assert.True(t, loaded[i], "item %d", i)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/pkg/errors"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+// rlimitTypes are the supported resource limits.
+var rlimitTypes = map[string]bool{
+	"as": true, "core": true, "cpu": true, "data": true, "fsize": true,
+	"locks": true, "memlock": true, "msgqueue": true, "nice": true,
+	"nofile": true, "nproc": true, "rss": true, "rtprio": true,
+	"rttime": true, "sigpending": true, "stack": true,
+}
+
+// rlimitUnlimited is the value of an unlimited resource limit.
+const rlimitUnlimited = "unlimited"
+
+// validateRlimits validates resource limits.
+func validateRlimits(rlimits []criconfig.Rlimit) error {
+	for _, r := range rlimits {
+		if !rlimitTypes[r.Type] {
+			return errors.Errorf("unsupported rlimit type %q", r.Type)
+		}
+		if r.Soft > r.Hard {
+			return errors.Errorf("soft limit %d of rlimit %q is larger than hard limit %d", r.Soft, r.Type, r.Hard)
+		}
+	}
+	return nil
+}
+
+// parseRlimits parses resource limits like "nofile=65536:65536,core=0".
+func parseRlimits(s string) ([]criconfig.Rlimit, error) {
+	var rlimits []criconfig.Rlimit
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("invalid rlimit %q", item)
+		}
+		values := strings.SplitN(parts[1], ":", 2)
+		soft, err := parseRlimitValue(values[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid rlimit %q", item)
+		}
+		hard := soft
+		if len(values) == 2 {
+			if hard, err = parseRlimitValue(values[1]); err != nil {
+				return nil, errors.Wrapf(err, "invalid rlimit %q", item)
+			}
+		}
+		rlimits = append(rlimits, criconfig.Rlimit{Type: parts[0], Soft: soft, Hard: hard})
+	}
+	if err := validateRlimits(rlimits); err != nil {
+		return nil, err
+	}
+	return rlimits, nil
+}
+
+func parseRlimitValue(v string) (uint64, error) {
+	if v == rlimitUnlimited {
+		return math.MaxUint64, nil
+	}
+	return strconv.ParseUint(v, 10, 64)
+}
+
+// setOCIRlimits sets the default resource limits, replacing the runtime
+// defaults of the same type, and then the ones in the sandbox annotation.
+// The annotation is set by the pod user, so it can't raise a hard limit in
+// the spec, or set a limit which is not in the spec.
+func (c *criService) setOCIRlimits(g *generate.Generator, sandboxConfig *runtime.PodSandboxConfig) error {
+	for _, r := range c.config.DefaultRlimits {
+		g.AddProcessRlimits("RLIMIT_"+strings.ToUpper(r.Type), r.Hard, r.Soft)
+	}
+	s, ok := sandboxConfig.GetAnnotations()[annotations.Rlimits]
+	if !ok {
+		return nil
+	}
+	overrides, err := parseRlimits(s)
+	if err != nil {
+		return errors.Wrapf(err, "invalid %q annotation", annotations.Rlimits)
+	}
+	hardLimits := make(map[string]uint64)
+	for _, r := range g.Config.Process.Rlimits {
+		hardLimits[r.Type] = r.Hard
+	}
+	for _, r := range overrides {
+		rlimitType := "RLIMIT_" + strings.ToUpper(r.Type)
+		hard, ok := hardLimits[rlimitType]
+		if !ok {
+			return errors.Errorf("invalid %q annotation: rlimit %q is not set by default, it can't be overridden",
+				annotations.Rlimits, r.Type)
+		}
+		if r.Hard > hard {
+			return errors.Errorf("invalid %q annotation: hard limit %d of rlimit %q exceeds the default hard limit %d",
+				annotations.Rlimits, r.Hard, r.Type, hard)
+		}
+		g.AddProcessRlimits(rlimitType, r.Hard, r.Soft)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"math"
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containerd/cri/pkg/annotations"
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestParseRlimits(t *testing.T) {
+	for desc, test := range map[string]struct {
+		value     string
+		expected  []criconfig.Rlimit
+		expectErr bool
+	}{
+		"should parse soft and hard limits": {
+			value: "nofile=1024:65536, core=0",
+			expected: []criconfig.Rlimit{
+				{Type: "nofile", Soft: 1024, Hard: 65536},
+				{Type: "core", Soft: 0, Hard: 0},
+			},
+		},
+		"should parse unlimited": {
+			value:    "memlock=unlimited",
+			expected: []criconfig.Rlimit{{Type: "memlock", Soft: math.MaxUint64, Hard: math.MaxUint64}},
+		},
+		"should return error for unsupported type": {
+			value:     "files=10",
+			expectErr: true,
+		},
+		"should return error for soft limit larger than hard limit": {
+			value:     "nproc=10:5",
+			expectErr: true,
+		},
+		"should return error for invalid value": {
+			value:     "nofile=many",
+			expectErr: true,
+		},
+		"should return error without value": {
+			value:     "nofile",
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		rlimits, err := parseRlimits(test.value)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, rlimits)
+	}
+}
+
+func TestContainerSpecRlimits(t *testing.T) {
+	testID := "test-id"
+	testSandboxID := "sandbox-id"
+	testPid := uint32(1234)
+	for desc, test := range map[string]struct {
+		defaults   []criconfig.Rlimit
+		annotation string
+		expectErr  bool
+		expected   []runtimespec.POSIXRlimit
+	}{
+		"should replace runtime default with default rlimits": {
+			defaults: []criconfig.Rlimit{{Type: "nofile", Soft: 4096, Hard: 8192}},
+			expected: []runtimespec.POSIXRlimit{{Type: "RLIMIT_NOFILE", Soft: 4096, Hard: 8192}},
+		},
+		"should override default rlimits with annotation": {
+			defaults: []criconfig.Rlimit{
+				{Type: "nofile", Soft: 4096, Hard: 65536},
+				{Type: "core", Soft: 0, Hard: math.MaxUint64},
+			},
+			annotation: "nofile=65536,core=0",
+			expected: []runtimespec.POSIXRlimit{
+				{Type: "RLIMIT_NOFILE", Soft: 65536, Hard: 65536},
+				{Type: "RLIMIT_CORE", Soft: 0, Hard: 0},
+			},
+		},
+		"should reject annotation raising default hard limit": {
+			defaults:   []criconfig.Rlimit{{Type: "nofile", Soft: 4096, Hard: 8192}},
+			annotation: "nofile=4096:unlimited",
+			expectErr:  true,
+		},
+		"should reject annotation setting rlimit which is not set by default": {
+			annotation: "memlock=unlimited",
+			expectErr:  true,
+		},
+		"should return error for invalid annotation": {
+			annotation: "nofile=abc",
+			expectErr:  true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.DefaultRlimits = test.defaults
+		config, sandboxConfig, imageConfig, _ := getCreateContainerTestData()
+		if test.annotation != "" {
+			sandboxConfig.Annotations = map[string]string{annotations.Rlimits: test.annotation}
+		}
+		spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, spec.Process.Rlimits)
+	}
+}
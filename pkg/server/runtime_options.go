@@ -0,0 +1,48 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/pkg/errors"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+// validateRuntimeOptions validates the engine options of a runtime.
+func validateRuntimeOptions(r criconfig.Runtime) error {
+	if len(r.Options) != 0 && r.OptionsAnnotationPrefix == "" {
+		return errors.New("options_annotation_prefix is required by options")
+	}
+	for name := range r.Options {
+		if name == "" {
+			return errors.New("empty option name")
+		}
+	}
+	return nil
+}
+
+// setOCIRuntimeOptions passes the engine options of a runtime through the
+// spec annotations, e.g. "dev.gvisor.flag.platform" for runsc. The shim of
+// the "io.containerd.runtime.v1.linux" runtime type only takes the runc
+// options, so this is how engine specific options reach the engine. They
+// override passed through annotations of the same key.
+func setOCIRuntimeOptions(g *generate.Generator, r criconfig.Runtime) {
+	for name, value := range r.Options {
+		g.AddAnnotation(r.OptionsAnnotationPrefix+name, value)
+	}
+}
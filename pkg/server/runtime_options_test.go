@@ -0,0 +1,86 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestValidateRuntimeOptions(t *testing.T) {
+	for desc, test := range map[string]struct {
+		runtime   criconfig.Runtime
+		expectErr bool
+	}{
+		"should accept runtime without options": {},
+		"should accept options with annotation prefix": {
+			runtime: criconfig.Runtime{
+				Options:                 map[string]string{"platform": "kvm"},
+				OptionsAnnotationPrefix: "dev.gvisor.flag.",
+			},
+		},
+		"should reject options without annotation prefix": {
+			runtime:   criconfig.Runtime{Options: map[string]string{"platform": "kvm"}},
+			expectErr: true,
+		},
+		"should reject empty option name": {
+			runtime: criconfig.Runtime{
+				Options:                 map[string]string{"": "kvm"},
+				OptionsAnnotationPrefix: "dev.gvisor.flag.",
+			},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		err := validateRuntimeOptions(test.runtime)
+		assert.Equal(t, test.expectErr, err != nil)
+	}
+}
+
+func TestSpecRuntimeOptions(t *testing.T) {
+	c := newTestCRIService()
+	ociRuntime := criconfig.Runtime{
+		Options:                 map[string]string{"platform": "kvm", "debug": "true"},
+		OptionsAnnotationPrefix: "dev.gvisor.flag.",
+		PodAnnotations:          []string{"dev.gvisor.*"},
+	}
+	expected := map[string]string{
+		"dev.gvisor.flag.platform": "kvm",
+		"dev.gvisor.flag.debug":    "true",
+	}
+
+	t.Logf("should pass runtime options into container spec")
+	config, sandboxConfig, imageConfig, _ := getCreateContainerTestData()
+	sandboxConfig.Annotations = map[string]string{"dev.gvisor.flag.platform": "ptrace"}
+	spec, err := c.generateContainerSpec("test-id", "sandbox-id", 1234, config, sandboxConfig, imageConfig, nil, ociRuntime)
+	require.NoError(t, err)
+	for k, v := range expected {
+		assert.Equal(t, v, spec.Annotations[k])
+	}
+
+	t.Logf("should pass runtime options into sandbox spec")
+	podConfig, podImageConfig, _ := getRunPodSandboxTestData()
+	spec, err = c.generateSandboxContainerSpec("sandbox-id", podConfig, podImageConfig, "test-netns", ociRuntime, nil)
+	require.NoError(t, err)
+	for k, v := range expected {
+		assert.Equal(t, v, spec.Annotations[k])
+	}
+}
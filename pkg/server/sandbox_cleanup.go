@@ -0,0 +1,107 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"time"
+
+	"github.com/containerd/cri/pkg/log"
+)
+
+const (
+	// sandboxCleanupBackoff is the delay before the first retry of a failed
+	// cleanup, which doubles on each retry.
+	sandboxCleanupBackoff = time.Second
+	// maxSandboxCleanupBackoff is the max delay between retries of a failed
+	// cleanup.
+	maxSandboxCleanupBackoff = time.Minute
+)
+
+// Sandbox cleanup results used as the "result" label of sandboxCleanups.
+const (
+	sandboxCleanupSucceeded = "succeeded"
+	sandboxCleanupAbandoned = "abandoned"
+)
+
+// rollback runs a step rolling back a partially created sandbox, or cleaning
+// up after a removed sandbox. If the step fails, it is retried in the
+// background within the retry budget, so that the resources of failed
+// sandboxes, e.g. the pod IPs, are not leaked until someone cleans them up
+// manually. The step must be idempotent, and must not
+// depend on state which is changed by the later steps. It returns a channel
+// closed once the step succeeds or is given up.
+func (c *criService) rollback(desc string, step func() error) <-chan struct{} {
+	done := make(chan struct{})
+	err := step()
+	if err == nil {
+		close(done)
+		return done
+	}
+	if c.config.SandboxCleanupRetries <= 0 {
+		log.Sandbox.WithError(err).Errorf("Failed to %s", desc)
+		close(done)
+		return done
+	}
+	log.Sandbox.WithError(err).Warnf("Failed to %s, retrying in background", desc)
+	go func() {
+		defer close(done)
+		c.retryRollback(desc, step)
+	}()
+	return done
+}
+
+// rollbackAfter runs a rollback step after the earlier rollback steps are
+// finished, e.g. the network namespace is only removed after the network in
+// it is torn down, which may still be retried in the background.
+func (c *criService) rollbackAfter(earlier []<-chan struct{}, desc string, step func() error) {
+	for _, done := range earlier {
+		select {
+		case <-done:
+		default:
+			log.Sandbox.Infof("Waiting for the earlier cleanup to %s", desc)
+			go func() {
+				for _, done := range earlier {
+					<-done
+				}
+				c.rollback(desc, step)
+			}()
+			return
+		}
+	}
+	c.rollback(desc, step)
+}
+
+// retryRollback retries a failed rollback step with exponential backoff until
+// it succeeds or the retry budget is used up.
+func (c *criService) retryRollback(desc string, step func() error) {
+	backoff := c.cleanupBackoff
+	for i := 1; i <= c.config.SandboxCleanupRetries; i++ {
+		time.Sleep(backoff)
+		err := step()
+		if err == nil {
+			log.Sandbox.Infof("Succeeded to %s after %d retries", desc, i)
+			sandboxCleanups.WithValues(sandboxCleanupSucceeded).Inc()
+			return
+		}
+		log.Sandbox.WithError(err).Warnf("Retry %d of %d to %s failed", i, c.config.SandboxCleanupRetries, desc)
+		if backoff *= 2; backoff > maxSandboxCleanupBackoff {
+			backoff = maxSandboxCleanupBackoff
+		}
+	}
+	log.Sandbox.Errorf("Failed to %s within %d retries, manual cleanup is required", desc, c.config.SandboxCleanupRetries)
+	sandboxCleanups.WithValues(sandboxCleanupAbandoned).Inc()
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollback(t *testing.T) {
+	for desc, test := range map[string]struct {
+		retries       int
+		failures      int
+		expectedCalls int
+	}{
+		"should not retry succeeded step": {
+			retries:       3,
+			expectedCalls: 1,
+		},
+		"should retry failed step until it succeeds": {
+			retries:       3,
+			failures:      2,
+			expectedCalls: 3,
+		},
+		"should give up failed step when retry budget is used up": {
+			retries:       3,
+			failures:      10,
+			expectedCalls: 4,
+		},
+		"should not retry failed step without retry budget": {
+			failures:      10,
+			expectedCalls: 1,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.SandboxCleanupRetries = test.retries
+		c.cleanupBackoff = time.Millisecond
+		calls := make(chan struct{}, 100)
+		failures := test.failures
+		done := c.rollback("test step", func() error {
+			calls <- struct{}{}
+			if failures > 0 {
+				failures--
+				return errors.New("test error")
+			}
+			return nil
+		})
+		for i := 0; i < test.expectedCalls; i++ {
+			select {
+			case <-calls:
+			case <-time.After(10 * time.Second):
+				t.Fatalf("step is called %d times, expected %d", i, test.expectedCalls)
+			}
+		}
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			assert.Fail(t, "rollback should be finished")
+		}
+		select {
+		case <-calls:
+			assert.Fail(t, "step is called more than expected")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func TestRollbackAfter(t *testing.T) {
+	c := newTestCRIService()
+
+	t.Logf("should run the step immediately if the earlier steps are finished")
+	finished := make(chan struct{})
+	close(finished)
+	called := false
+	c.rollbackAfter([]<-chan struct{}{finished}, "test step", func() error {
+		called = true
+		return nil
+	})
+	assert.True(t, called)
+
+	t.Logf("should run the step after the earlier steps are finished")
+	running := make(chan struct{})
+	calls := make(chan struct{}, 1)
+	c.rollbackAfter([]<-chan struct{}{finished, running}, "test step", func() error {
+		calls <- struct{}{}
+		return nil
+	})
+	select {
+	case <-calls:
+		assert.Fail(t, "step should wait for the earlier steps")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(running)
+	select {
+	case <-calls:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "step should be run")
+	}
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	cni "github.com/containerd/go-cni"
+	"github.com/containernetworking/cni/libcni"
+	"github.com/containernetworking/cni/pkg/types/current"
+	"github.com/pkg/errors"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+const (
+	// loopbackIfName is the interface name of the loopback network.
+	loopbackIfName = "lo"
+	// loopbackConfList is the conf of the loopback network, same with the
+	// one of the cni library.
+	loopbackConfList = `{
+"cniVersion": "0.3.1",
+"name": "cni-loopback",
+"plugins": [{
+  "type": "loopback"
+}]
+}`
+)
+
+// podNetwork is a network all pods are attached to.
+type podNetwork struct {
+	ifName   string
+	confList *libcni.NetworkConfigList
+	// result is the result of the network setup, nil if the network is not
+	// set up.
+	result *current.Result
+}
+
+// setupPodNetworks attaches the pod to the networks in order with the cni
+// library conventions. It returns the networks with the results of the ones
+// set up, also on failure, so that exactly those networks can be torn down.
+func (c *criService) setupPodNetworks(id, path string, config *runtime.PodSandboxConfig, networks []podNetwork) ([]podNetwork, error) {
+	cniConfig := &libcni.CNIConfig{Path: []string{c.config.NetworkPluginBinDir}}
+	setup := make([]podNetwork, len(networks))
+	copy(setup, networks)
+	for i, network := range setup {
+		rt := getAdditionalNetworkRuntimeConf(id, path, network.ifName, config)
+		rt.CapabilityArgs = map[string]interface{}{
+			portMappingsCapability: toCNIPortMappings(config.GetPortMappings()),
+		}
+		r, err := cniConfig.AddNetworkList(network.confList, rt)
+		if err != nil {
+			return setup, errors.Wrapf(err, "failed to setup network %q", network.confList.Name)
+		}
+		if setup[i].result, err = current.NewResultFromResult(r); err != nil {
+			return setup, errors.Wrapf(err, "failed to convert result of network %q", network.confList.Name)
+		}
+	}
+	return setup, nil
+}
+
+// toCNIResult aggregates the results of the pod networks in the same way as
+// the cni library, which keeps the ips, dns and routes of the setup in the
+// sandbox metadata.
+func toCNIResult(networks []podNetwork) (*cni.CNIResult, error) {
+	r := &cni.CNIResult{
+		Interfaces: map[string]*cni.Config{defaultIfName: {}},
+	}
+	for _, network := range networks {
+		result := network.result
+		for _, iface := range result.Interfaces {
+			r.Interfaces[iface.Name] = &cni.Config{Mac: iface.Mac, Sandbox: iface.Sandbox}
+		}
+		for _, ipConfig := range result.IPs {
+			// IPs not associated with any interface belong to the default
+			// interface.
+			name := defaultIfName
+			if ipConfig.Interface != nil {
+				if *ipConfig.Interface < 0 || *ipConfig.Interface >= len(result.Interfaces) {
+					return nil, errors.Errorf("invalid interface index %d of network %q", *ipConfig.Interface, network.confList.Name)
+				}
+				name = result.Interfaces[*ipConfig.Interface].Name
+			}
+			r.Interfaces[name].IPConfigs = append(r.Interfaces[name].IPConfigs,
+				&cni.IPConfig{IP: ipConfig.Address.IP, Gateway: ipConfig.Gateway})
+		}
+		r.DNS = append(r.DNS, result.DNS)
+		r.Routes = append(r.Routes, result.Routes...)
+	}
+	return r, nil
+}
+
+// teardownPodWithResult removes the networks of the network setup from the
+// pod with the cni library conventions, and passes the result of the network
+// setup as the prevResult of each network, so that it doesn't depend on the
+// cni cache of the plugins. Networks persisted without their result get the
+// part of the aggregated result of their interface.
+func (c *criService) teardownPodWithResult(id, path string, config *runtime.PodSandboxConfig, result *cni.CNIResult,
+	cniNetworks []sandboxstore.CNINetwork) error {
+	networks, err := fromCNINetworks(cniNetworks)
+	if err != nil {
+		return errors.Wrap(err, "failed to load cni networks of the network setup")
+	}
+	return c.teardownPodNetworks(id, path, config, result, networks)
+}
+
+// teardownPodNetworks removes the pod networks from the pod, see
+// teardownPodWithResult.
+func (c *criService) teardownPodNetworks(id, path string, config *runtime.PodSandboxConfig, result *cni.CNIResult,
+	networks []podNetwork) error {
+	cniConfig := &libcni.CNIConfig{Path: []string{c.config.NetworkPluginBinDir}}
+	for i, network := range networks {
+		rt := getAdditionalNetworkRuntimeConf(id, path, network.ifName, config)
+		rt.CapabilityArgs = map[string]interface{}{
+			portMappingsCapability: toCNIPortMappings(config.GetPortMappings()),
+		}
+		prevResult := network.result
+		if prevResult == nil {
+			prevResult = toCurrentResult(result, i, network.ifName)
+		}
+		if err := delNetworkList(cniConfig, network.confList, prevResult, rt); err != nil {
+			return errors.Wrapf(err, "failed to remove network %q", network.confList.Name)
+		}
+	}
+	return nil
+}
+
+// loadPodNetworks loads the networks all pods are attached to in the same
+// way as the cni library, i.e. the loopback network, and the network of each
+// conf file in lexicographical order.
+func loadPodNetworks(confDir string) ([]podNetwork, error) {
+	lo, err := libcni.ConfListFromBytes([]byte(loopbackConfList))
+	if err != nil {
+		return nil, err
+	}
+	networks := []podNetwork{{ifName: loopbackIfName, confList: lo}}
+	files, err := libcni.ConfFiles(confDir, []string{".conf", ".conflist", ".json"})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read conf dir %q", confDir)
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf("no network config found in %s", confDir)
+	}
+	sort.Strings(files)
+	for i, file := range files {
+		var confList *libcni.NetworkConfigList
+		if strings.HasSuffix(file, ".conflist") {
+			confList, err = libcni.ConfListFromFile(file)
+		} else {
+			var conf *libcni.NetworkConfig
+			if conf, err = libcni.ConfFromFile(file); err == nil {
+				confList, err = libcni.ConfListFromConf(conf)
+			}
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load cni config file %q", file)
+		}
+		networks = append(networks, podNetwork{
+			ifName:   fmt.Sprintf("%s%d", cni.DefaultPrefix, i),
+			confList: confList,
+		})
+	}
+	return networks, nil
+}
+
+// toCNINetworks returns the pod networks to be persisted in the sandbox
+// metadata.
+func toCNINetworks(networks []podNetwork) ([]sandboxstore.CNINetwork, error) {
+	var cniNetworks []sandboxstore.CNINetwork
+	for _, network := range networks {
+		cniNetwork := sandboxstore.CNINetwork{
+			IfName:   network.ifName,
+			ConfList: network.confList.Bytes,
+		}
+		if network.result != nil {
+			data, err := json.Marshal(network.result)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to marshal result of network %q", network.confList.Name)
+			}
+			cniNetwork.Result = data
+		}
+		cniNetworks = append(cniNetworks, cniNetwork)
+	}
+	return cniNetworks, nil
+}
+
+// fromCNINetworks returns the pod networks persisted in the sandbox metadata.
+func fromCNINetworks(cniNetworks []sandboxstore.CNINetwork) ([]podNetwork, error) {
+	var networks []podNetwork
+	for _, network := range cniNetworks {
+		confList, err := libcni.ConfListFromBytes(network.ConfList)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load cni config of interface %q", network.IfName)
+		}
+		var result *current.Result
+		if len(network.Result) > 0 {
+			result = &current.Result{}
+			if err := json.Unmarshal(network.Result, result); err != nil {
+				return nil, errors.Wrapf(err, "failed to load cni result of interface %q", network.IfName)
+			}
+		}
+		networks = append(networks, podNetwork{ifName: network.IfName, confList: confList, result: result})
+	}
+	return networks, nil
+}
+
+// toCurrentResult returns the result of the i-th pod network with the
+// interface in the sandbox, or nil if there is no result or the network set
+// up no such interface.
+// The cni library only keeps the addresses of the interfaces, so the IPs
+// have a host prefix length. The routes are aggregated across networks by the
+// cni library, they are returned for the default interface.
+func toCurrentResult(result *cni.CNIResult, i int, ifName string) *current.Result {
+	if result == nil {
+		return nil
+	}
+	iface, ok := result.Interfaces[ifName]
+	if !ok {
+		return nil
+	}
+	r := &current.Result{
+		Interfaces: []*current.Interface{{Name: ifName, Mac: iface.Mac, Sandbox: iface.Sandbox}},
+	}
+	for _, ipConfig := range iface.IPConfigs {
+		version, bits := "4", 32
+		if ipConfig.IP.To4() == nil {
+			version, bits = "6", 128
+		}
+		r.IPs = append(r.IPs, &current.IPConfig{
+			Version:   version,
+			Interface: current.Int(0),
+			Address:   net.IPNet{IP: ipConfig.IP, Mask: net.CIDRMask(bits, bits)},
+			Gateway:   ipConfig.Gateway,
+		})
+	}
+	if ifName == defaultIfName {
+		r.Routes = result.Routes
+	}
+	// The cni library appends the dns of each network in order.
+	if i < len(result.DNS) {
+		r.DNS = result.DNS[i]
+	}
+	return r
+}
+
+// delNetworkList is the same with libcni.DelNetworkList, except that the
+// result is passed to each plugin as prevResult.
+func delNetworkList(cniConfig *libcni.CNIConfig, list *libcni.NetworkConfigList, result *current.Result, rt *libcni.RuntimeConf) error {
+	inject := map[string]interface{}{
+		"name":       list.Name,
+		"cniVersion": list.CNIVersion,
+	}
+	if result != nil {
+		// Only results of 0.3.0 and later have interfaces, plugins of older
+		// versions get no prevResult.
+		if r, err := result.GetAsVersion(list.CNIVersion); err == nil {
+			if _, ok := r.(*current.Result); ok {
+				inject["prevResult"] = r
+			}
+		}
+	}
+	for i := len(list.Plugins) - 1; i >= 0; i-- {
+		conf, err := libcni.InjectConf(list.Plugins[i], inject)
+		if err != nil {
+			return err
+		}
+		if err := cniConfig.DelNetwork(conf, rt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cni "github.com/containerd/go-cni"
+	"github.com/containernetworking/cni/libcni"
+	"github.com/containernetworking/cni/pkg/types"
+	"github.com/containernetworking/cni/pkg/types/current"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+func TestLoadPodNetworks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-pod-networks")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = loadPodNetworks(dir)
+	assert.Error(t, err, "should fail without network config")
+
+	for name, conf := range map[string]string{
+		"20-second.conf": `{"cniVersion": "0.3.1", "name": "second", "type": "macvlan"}`,
+		"10-first.conflist": `{"cniVersion": "0.3.1", "name": "first", "plugins": [
+			{"type": "bridge"}, {"type": "portmap", "capabilities": {"portMappings": true}}]}`,
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(conf), 0644))
+	}
+	networks, err := loadPodNetworks(dir)
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+	for i, expected := range []struct {
+		ifName  string
+		name    string
+		plugins int
+	}{
+		{ifName: "lo", name: "cni-loopback", plugins: 1},
+		{ifName: "eth0", name: "first", plugins: 2},
+		{ifName: "eth1", name: "second", plugins: 1},
+	} {
+		assert.Equal(t, expected.ifName, networks[i].ifName)
+		assert.Equal(t, expected.name, networks[i].confList.Name)
+		assert.Len(t, networks[i].confList.Plugins, expected.plugins)
+	}
+
+	t.Logf("should load the same networks from the sandbox metadata after the conf dir changed")
+	networks[1].result = &current.Result{
+		CNIVersion: "0.3.1",
+		Interfaces: []*current.Interface{{Name: "eth0", Sandbox: "/var/run/netns/cni-test"}},
+		IPs: []*current.IPConfig{{
+			Version:   "4",
+			Interface: current.Int(0),
+			Address:   net.IPNet{IP: net.ParseIP("10.0.0.2"), Mask: net.CIDRMask(24, 32)},
+		}},
+	}
+	cniNetworks, err := toCNINetworks(networks)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(dir, "10-first.conflist")))
+	loaded, err := fromCNINetworks(cniNetworks)
+	require.NoError(t, err)
+	require.Len(t, loaded, len(networks))
+	for i := range networks {
+		assert.Equal(t, networks[i].ifName, loaded[i].ifName)
+		assert.Equal(t, networks[i].confList.Name, loaded[i].confList.Name)
+		assert.Equal(t, networks[i].confList.Bytes, loaded[i].confList.Bytes)
+		assert.Equal(t, networks[i].result, loaded[i].result, "should keep the full result with its prefix length")
+	}
+
+	_, err = fromCNINetworks([]sandboxstore.CNINetwork{{IfName: "eth0", ConfList: []byte("{")}})
+	assert.Error(t, err, "should fail with invalid conf list")
+}
+
+func TestToCNIResult(t *testing.T) {
+	route := &types.Route{Dst: net.IPNet{IP: net.ParseIP("0.0.0.0"), Mask: net.CIDRMask(0, 32)}}
+	dns := types.DNS{Nameservers: []string{"10.0.0.10"}}
+	networks := []podNetwork{
+		{
+			ifName:   "lo",
+			confList: &libcni.NetworkConfigList{Name: "cni-loopback"},
+			result:   &current.Result{Interfaces: []*current.Interface{{Name: "lo"}}},
+		},
+		{
+			ifName:   "eth0",
+			confList: &libcni.NetworkConfigList{Name: "first"},
+			result: &current.Result{
+				Interfaces: []*current.Interface{
+					{Name: "cni0"},
+					{Name: "eth0", Mac: "0a:58:0a:00:00:02", Sandbox: "/var/run/netns/cni-test"},
+				},
+				IPs: []*current.IPConfig{{
+					Version:   "4",
+					Interface: current.Int(1),
+					Address:   net.IPNet{IP: net.ParseIP("10.0.0.2"), Mask: net.CIDRMask(24, 32)},
+					Gateway:   net.ParseIP("10.0.0.1"),
+				}},
+				Routes: []*types.Route{route},
+				DNS:    dns,
+			},
+		},
+	}
+	result, err := toCNIResult(networks)
+	require.NoError(t, err)
+	assert.Equal(t, &cni.CNIResult{
+		Interfaces: map[string]*cni.Config{
+			"lo":   {},
+			"cni0": {},
+			"eth0": {
+				IPConfigs: []*cni.IPConfig{{IP: net.ParseIP("10.0.0.2"), Gateway: net.ParseIP("10.0.0.1")}},
+				Mac:       "0a:58:0a:00:00:02",
+				Sandbox:   "/var/run/netns/cni-test",
+			},
+		},
+		DNS:    []types.DNS{{}, dns},
+		Routes: []*types.Route{route},
+	}, result)
+
+	networks[1].result.IPs[0].Interface = current.Int(2)
+	_, err = toCNIResult(networks)
+	assert.Error(t, err, "should reject invalid interface index")
+}
+
+func TestToCurrentResult(t *testing.T) {
+	route := &types.Route{Dst: net.IPNet{IP: net.ParseIP("0.0.0.0"), Mask: net.CIDRMask(0, 32)}}
+	dns := types.DNS{Nameservers: []string{"10.0.0.10"}}
+	result := &cni.CNIResult{
+		Interfaces: map[string]*cni.Config{
+			"lo": {},
+			"eth0": {
+				IPConfigs: []*cni.IPConfig{
+					{IP: net.ParseIP("10.0.0.2"), Gateway: net.ParseIP("10.0.0.1")},
+					{IP: net.ParseIP("fd00::2")},
+				},
+				Mac:     "0a:58:0a:00:00:02",
+				Sandbox: "/var/run/netns/cni-test",
+			},
+		},
+		DNS:    []types.DNS{{}, dns},
+		Routes: []*types.Route{route},
+	}
+
+	assert.Nil(t, toCurrentResult(result, 2, "eth1"), "should return nil for unknown interface")
+	assert.Nil(t, toCurrentResult(nil, 1, "eth0"), "should return nil without result")
+
+	lo := toCurrentResult(result, 0, "lo")
+	require.NotNil(t, lo)
+	assert.Equal(t, []*current.Interface{{Name: "lo"}}, lo.Interfaces)
+	assert.Empty(t, lo.IPs)
+	assert.Empty(t, lo.Routes)
+
+	eth0 := toCurrentResult(result, 1, "eth0")
+	require.NotNil(t, eth0)
+	assert.Equal(t, []*current.Interface{{
+		Name:    "eth0",
+		Mac:     "0a:58:0a:00:00:02",
+		Sandbox: "/var/run/netns/cni-test",
+	}}, eth0.Interfaces)
+	assert.Equal(t, []*current.IPConfig{
+		{
+			Version:   "4",
+			Interface: current.Int(0),
+			Address:   net.IPNet{IP: net.ParseIP("10.0.0.2"), Mask: net.CIDRMask(32, 32)},
+			Gateway:   net.ParseIP("10.0.0.1"),
+		},
+		{
+			Version:   "6",
+			Interface: current.Int(0),
+			Address:   net.IPNet{IP: net.ParseIP("fd00::2"), Mask: net.CIDRMask(128, 128)},
+		},
+	}, eth0.IPs)
+	assert.Equal(t, []*types.Route{route}, eth0.Routes)
+	assert.Equal(t, dns, eth0.DNS)
+}
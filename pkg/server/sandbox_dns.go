@@ -0,0 +1,92 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/log"
+)
+
+// hostResolvConfSyncPeriod is the period of syncing the host resolv.conf to
+// the sandboxes following it.
+const hostResolvConfSyncPeriod = 10 * time.Second
+
+// followsHostResolvConf returns whether the resolv.conf of a sandbox follows
+// the host resolv.conf, i.e. the sandbox is in the host network and has no
+// dns config, e.g. with an empty kubelet "--resolv-conf".
+func (c *criService) followsHostResolvConf(config *runtime.PodSandboxConfig) bool {
+	if !c.config.HostNetworkDNSPassthrough {
+		return false
+	}
+	if config.GetLinux().GetSecurityContext().GetNamespaceOptions().GetNetwork() != runtime.NamespaceMode_NODE {
+		return false
+	}
+	dnsConfig := config.GetDnsConfig()
+	return len(dnsConfig.GetServers()) == 0 && len(dnsConfig.GetSearches()) == 0 && len(dnsConfig.GetOptions()) == 0
+}
+
+// syncHostResolvConfs rewrites the resolv.conf of the sandboxes following the
+// host resolv.conf if it changed. The file is rewritten in place, because it
+// is bind mounted into the containers. Failures of a sandbox are logged, so
+// that they don't block other sandboxes.
+func (c *criService) syncHostResolvConfs(hostResolvConf string) error {
+	if !c.config.HostNetworkDNSPassthrough {
+		return nil
+	}
+	content, err := ioutil.ReadFile(hostResolvConf)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read host resolv.conf %q", hostResolvConf)
+	}
+	for _, sandbox := range c.sandboxStore.List() {
+		if !c.followsHostResolvConf(sandbox.Config) {
+			continue
+		}
+		resolvPath := c.getResolvPath(sandbox.ID)
+		if existing, err := ioutil.ReadFile(resolvPath); err == nil && bytes.Equal(existing, content) {
+			continue
+		}
+		if err := c.os.WriteFile(resolvPath, content, 0644); err != nil {
+			log.Sandbox.WithError(err).Errorf("Failed to update resolv.conf of sandbox %q", sandbox.ID)
+			continue
+		}
+		log.Sandbox.Infof("Updated resolv.conf of sandbox %q from the host", sandbox.ID)
+	}
+	return nil
+}
+
+// startHostResolvConfSyncer syncs the host resolv.conf to the sandboxes
+// following it periodically, so that the changes of the host dns, e.g. by
+// dhcp, are picked up without UpdateRuntimeConfig. The host resolv.conf is
+// often replaced or a symlink to a file replaced by its manager, so it is
+// polled instead of watched. The syncer doesn't need to be stopped.
+func (c *criService) startHostResolvConfSyncer() {
+	go func() {
+		ticker := time.NewTicker(hostResolvConfSyncPeriod)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := c.syncHostResolvConfs(resolvConfPath); err != nil {
+				log.Sandbox.WithError(err).Error("Failed to update resolv.conf of sandboxes")
+			}
+		}
+	}()
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	ostesting "github.com/containerd/cri/pkg/os/testing"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+func TestFollowsHostResolvConf(t *testing.T) {
+	for desc, test := range map[string]struct {
+		passthrough bool
+		network     runtime.NamespaceMode
+		dnsConfig   *runtime.DNSConfig
+		expected    bool
+	}{
+		"should follow host resolv.conf for host network sandbox without dns config": {
+			passthrough: true,
+			network:     runtime.NamespaceMode_NODE,
+			expected:    true,
+		},
+		"should follow host resolv.conf for host network sandbox with empty dns config": {
+			passthrough: true,
+			network:     runtime.NamespaceMode_NODE,
+			dnsConfig:   &runtime.DNSConfig{},
+			expected:    true,
+		},
+		"should not follow host resolv.conf when passthrough is disabled": {
+			network: runtime.NamespaceMode_NODE,
+		},
+		"should not follow host resolv.conf for pod network sandbox": {
+			passthrough: true,
+			network:     runtime.NamespaceMode_POD,
+		},
+		"should not follow host resolv.conf for sandbox with dns config": {
+			passthrough: true,
+			network:     runtime.NamespaceMode_NODE,
+			dnsConfig:   &runtime.DNSConfig{Servers: []string{"10.0.0.10"}},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.HostNetworkDNSPassthrough = test.passthrough
+		config := &runtime.PodSandboxConfig{
+			DnsConfig: test.dnsConfig,
+			Linux: &runtime.LinuxPodSandboxConfig{
+				SecurityContext: &runtime.LinuxSandboxSecurityContext{
+					NamespaceOptions: &runtime.NamespaceOption{Network: test.network},
+				},
+			},
+		}
+		assert.Equal(t, test.expected, c.followsHostResolvConf(config))
+	}
+}
+
+func TestSyncHostResolvConfs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-sync-resolv-conf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	hostResolvConf := filepath.Join(dir, "resolv.conf")
+	content := []byte("nameserver 10.0.0.53\n")
+	require.NoError(t, ioutil.WriteFile(hostResolvConf, content, 0644))
+
+	c := newTestCRIService()
+	c.config.HostNetworkDNSPassthrough = true
+	for id, network := range map[string]runtime.NamespaceMode{
+		"host-network": runtime.NamespaceMode_NODE,
+		"pod-network":  runtime.NamespaceMode_POD,
+	} {
+		require.NoError(t, c.sandboxStore.Add(sandboxstore.NewSandbox(
+			sandboxstore.Metadata{
+				ID: id,
+				Config: &runtime.PodSandboxConfig{
+					Linux: &runtime.LinuxPodSandboxConfig{
+						SecurityContext: &runtime.LinuxSandboxSecurityContext{
+							NamespaceOptions: &runtime.NamespaceOption{Network: network},
+						},
+					},
+				},
+			},
+			sandboxstore.Status{State: sandboxstore.StateReady},
+		)))
+	}
+	written := map[string][]byte{}
+	c.os.(*ostesting.FakeOS).WriteFileFn = func(filename string, data []byte, perm os.FileMode) error {
+		written[filename] = data
+		return nil
+	}
+	require.NoError(t, c.syncHostResolvConfs(hostResolvConf))
+	assert.Equal(t, map[string][]byte{c.getResolvPath("host-network"): content}, written)
+
+	assert.Error(t, c.syncHostResolvConfs(filepath.Join(dir, "not-exist")))
+}
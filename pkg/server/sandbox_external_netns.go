@@ -0,0 +1,136 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net"
+	"path/filepath"
+
+	cni "github.com/containerd/go-cni"
+	cnins "github.com/containernetworking/plugins/pkg/ns"
+	"github.com/pkg/errors"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+// criNetNSDir is the directory the network namespaces of the sandboxes are
+// created in by cnins.NewNS.
+const criNetNSDir = "/var/run/netns"
+
+// getExternalNetNSPath returns the path of the external network namespace
+// the sandbox joins, or empty if the sandbox has its own network namespace.
+// The path must be in one of the allowed directories, and must not be the
+// network namespace of another sandbox created by the cri plugin.
+func (c *criService) getExternalNetNSPath(config *runtime.PodSandboxConfig) (string, error) {
+	path, ok := config.GetAnnotations()[annotations.NetNSPath]
+	if !ok {
+		return "", nil
+	}
+	if config.GetLinux().GetSecurityContext().GetNamespaceOptions().GetNetwork() == runtime.NamespaceMode_NODE {
+		return "", errors.New("host network sandbox can't join an external network namespace")
+	}
+	if !filepath.IsAbs(path) {
+		return "", errors.Errorf("network namespace path %q is not absolute", path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve network namespace path %q", path)
+	}
+	if !inDirs(resolved, c.config.AllowedNetNSDirs) {
+		return "", errors.Errorf("network namespace path %q is not in allowed_netns_dirs", path)
+	}
+	// Joining the network namespace of another pod would share its network,
+	// and tear it down with the other pod.
+	for _, sb := range c.sandboxStore.List() {
+		if sb.ExternalNetNS || sb.NetNSPath == "" {
+			continue
+		}
+		if netNSPath, err := filepath.EvalSymlinks(sb.NetNSPath); err == nil && netNSPath == resolved {
+			return "", errors.Errorf("network namespace path %q is the network namespace of sandbox %q", path, sb.ID)
+		}
+	}
+	return resolved, nil
+}
+
+// validateNetNSDirs validates the directories of external network namespaces.
+// They must not contain the network namespaces created by the cri plugin.
+func validateNetNSDirs(dirs []string) error {
+	netNSDirs := []string{criNetNSDir, resolveDir(criNetNSDir)}
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			return errors.Errorf("directory %q is not absolute", dir)
+		}
+		for _, d := range []string{filepath.Clean(dir), resolveDir(dir)} {
+			for _, netNSDir := range netNSDirs {
+				if d == netNSDir || inDirs(netNSDir, []string{d}) {
+					return errors.Errorf("directory %q contains the sandbox network namespaces in %q", dir, criNetNSDir)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// resolveDir returns a directory with the symlinks in it resolved. Only its
+// parent is resolved if the directory doesn't exist yet, e.g. the network
+// namespace directory before the first sandbox.
+func resolveDir(dir string) string {
+	dir = filepath.Clean(dir)
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved
+	}
+	if parent, err := filepath.EvalSymlinks(filepath.Dir(dir)); err == nil {
+		return filepath.Join(parent, filepath.Base(dir))
+	}
+	return dir
+}
+
+// getNetNSPodIPs returns the pod ip and additional ips of an external
+// network namespace, which are the addresses of its default interface. They
+// are empty if the network namespace has no default interface, e.g. with VM
+// style networking.
+func getNetNSPodIPs(netNS *sandboxstore.NetNS) (string, []string, error) {
+	var ipConfigs []*cni.IPConfig
+	if err := netNS.GetNs().Do(func(cnins.NetNS) error {
+		iface, err := net.InterfaceByName(defaultIfName)
+		if err != nil {
+			// The network namespace has no default interface.
+			return nil
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return errors.Wrapf(err, "failed to get addresses of %q", defaultIfName)
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || !ipNet.IP.IsGlobalUnicast() {
+				continue
+			}
+			ipConfigs = append(ipConfigs, &cni.IPConfig{IP: ipNet.IP})
+		}
+		return nil
+	}); err != nil {
+		return "", nil, err
+	}
+	if len(ipConfigs) == 0 {
+		return "", nil, nil
+	}
+	ip, additionalIPs := selectPodIPs(ipConfigs)
+	return ip, additionalIPs, nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+func TestGetExternalNetNSPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-external-netns")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	allowedDir := filepath.Join(dir, "allowed")
+	otherDir := filepath.Join(dir, "other")
+	for _, d := range []string{allowedDir, otherDir} {
+		require.NoError(t, os.MkdirAll(d, 0755))
+	}
+	allowedNS := filepath.Join(allowedDir, "ns1")
+	otherNS := filepath.Join(otherDir, "ns2")
+	for _, f := range []string{allowedNS, otherNS} {
+		require.NoError(t, ioutil.WriteFile(f, nil, 0644))
+	}
+	escapeLink := filepath.Join(allowedDir, "escape")
+	require.NoError(t, os.Symlink(otherNS, escapeLink))
+
+	for desc, test := range map[string]struct {
+		path         string
+		noAnnotation bool
+		hostNetwork  bool
+		sandboxNetNS string
+		externalNS   bool
+		expected     string
+		expectErr    bool
+	}{
+		"should return empty path without annotation": {
+			noAnnotation: true,
+		},
+		"should return path in allowed directory": {
+			path:     allowedNS,
+			expected: allowedNS,
+		},
+		"should reject path not in allowed directories": {
+			path:      otherNS,
+			expectErr: true,
+		},
+		"should reject symlink out of allowed directories": {
+			path:      escapeLink,
+			expectErr: true,
+		},
+		"should reject relative path": {
+			path:      "allowed/ns1",
+			expectErr: true,
+		},
+		"should reject non-existent path": {
+			path:      filepath.Join(allowedDir, "ns3"),
+			expectErr: true,
+		},
+		"should reject host network sandbox": {
+			path:        allowedNS,
+			hostNetwork: true,
+			expectErr:   true,
+		},
+		"should reject network namespace of another sandbox": {
+			path:         allowedNS,
+			sandboxNetNS: allowedNS,
+			expectErr:    true,
+		},
+		"should accept network namespace joined by another sandbox": {
+			path:         allowedNS,
+			sandboxNetNS: allowedNS,
+			externalNS:   true,
+			expected:     allowedNS,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.AllowedNetNSDirs = []string{allowedDir}
+		if test.sandboxNetNS != "" {
+			require.NoError(t, c.sandboxStore.Add(sandboxstore.NewSandbox(
+				sandboxstore.Metadata{
+					ID:            "sandbox",
+					NetNSPath:     test.sandboxNetNS,
+					ExternalNetNS: test.externalNS,
+				},
+				sandboxstore.Status{State: sandboxstore.StateReady},
+			)))
+		}
+		config := &runtime.PodSandboxConfig{
+			Linux: &runtime.LinuxPodSandboxConfig{
+				SecurityContext: &runtime.LinuxSandboxSecurityContext{
+					NamespaceOptions: &runtime.NamespaceOption{},
+				},
+			},
+		}
+		if !test.noAnnotation {
+			config.Annotations = map[string]string{annotations.NetNSPath: test.path}
+		}
+		if test.hostNetwork {
+			config.Linux.SecurityContext.NamespaceOptions.Network = runtime.NamespaceMode_NODE
+		}
+		path, err := c.getExternalNetNSPath(config)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		expected := test.expected
+		if expected != "" {
+			expected, err = filepath.EvalSymlinks(expected)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, expected, path)
+	}
+}
+
+func TestValidateNetNSDirs(t *testing.T) {
+	for desc, test := range map[string]struct {
+		dirs      []string
+		expectErr bool
+	}{
+		"should accept no directory": {},
+		"should accept absolute directory": {
+			dirs: []string{"/run/external-netns"},
+		},
+		"should reject relative directory": {
+			dirs:      []string{"run/external-netns"},
+			expectErr: true,
+		},
+		"should reject the cri network namespace directory": {
+			dirs:      []string{criNetNSDir},
+			expectErr: true,
+		},
+		"should reject the cri network namespace directory through symlinks": {
+			dirs:      []string{resolveDir(criNetNSDir)},
+			expectErr: true,
+		},
+		"should reject directory containing the cri network namespace directory": {
+			dirs:      []string{"/var/run/"},
+			expectErr: true,
+		},
+		"should reject root directory": {
+			dirs:      []string{"/"},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		err := validateNetNSDirs(test.dirs)
+		assert.Equal(t, test.expectErr, err != nil, err)
+	}
+}
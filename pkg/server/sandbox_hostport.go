@@ -0,0 +1,103 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"strings"
+
+	"github.com/containernetworking/cni/libcni"
+	"github.com/pkg/errors"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/log"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+// portMappingsCapability is the cni capability of plugins handling port
+// mappings, e.g. the portmap plugin.
+const portMappingsCapability = "portMappings"
+
+// setupHostPorts programs the host ports of a sandbox when no cni plugin
+// handles port mappings. It returns whether the host ports are programmed
+// by the cri plugin.
+func (c *criService) setupHostPorts(id, ip string, config *runtime.PodSandboxConfig) (bool, error) {
+	mappings := toCNIPortMappings(config.GetPortMappings())
+	if len(mappings) == 0 {
+		return false, nil
+	}
+	capable, err := cniPortMappingsCapable(c.config.NetworkPluginConfDir)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to check cni port mappings capability")
+	}
+	if capable {
+		return false, nil
+	}
+	log.Sandbox.Debugf("No cni plugin handles port mappings, program host ports of sandbox %q", id)
+	if err := c.hostPortManager.Add(id, ip, mappings); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// teardownHostPorts removes the host ports of a sandbox if they are
+// programmed by the cri plugin.
+func (c *criService) teardownHostPorts(sandbox sandboxstore.Sandbox) error {
+	if !sandbox.HostPortManaged {
+		return nil
+	}
+	return c.hostPortManager.Remove(sandbox.ID, sandbox.IP, toCNIPortMappings(sandbox.Config.GetPortMappings()))
+}
+
+// restoreHostPorts programs the host ports of a sandbox again if they are
+// programmed by the cri plugin. It is used during recovery.
+func (c *criService) restoreHostPorts(sandbox sandboxstore.Sandbox) error {
+	if !sandbox.HostPortManaged {
+		return nil
+	}
+	return c.hostPortManager.Add(sandbox.ID, sandbox.IP, toCNIPortMappings(sandbox.Config.GetPortMappings()))
+}
+
+// cniPortMappingsCapable returns whether any cni network config in the
+// config directory has a plugin handling port mappings.
+func cniPortMappingsCapable(confDir string) (bool, error) {
+	files, err := libcni.ConfFiles(confDir, []string{".conf", ".conflist", ".json"})
+	if err != nil {
+		return false, err
+	}
+	for _, file := range files {
+		var plugins []*libcni.NetworkConfig
+		if strings.HasSuffix(file, ".conflist") {
+			confList, err := libcni.ConfListFromFile(file)
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to load cni config list file %q", file)
+			}
+			plugins = confList.Plugins
+		} else {
+			conf, err := libcni.ConfFromFile(file)
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to load cni config file %q", file)
+			}
+			plugins = []*libcni.NetworkConfig{conf}
+		}
+		for _, plugin := range plugins {
+			if plugin.Network != nil && plugin.Network.Capabilities[portMappingsCapability] {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
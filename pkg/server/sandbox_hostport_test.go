@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCNIPortMappingsCapable(t *testing.T) {
+	for desc, test := range map[string]struct {
+		files    map[string]string
+		expected bool
+	}{
+		"should not be capable without cni config": {},
+		"should not be capable without portmap plugin": {
+			files: map[string]string{
+				"10-bridge.conf": `{"cniVersion": "0.3.1", "name": "net", "type": "bridge"}`,
+			},
+		},
+		"should be capable with portmap plugin in config list": {
+			files: map[string]string{
+				"10-bridge.conflist": `{"cniVersion": "0.3.1", "name": "net", "plugins": [
+					{"type": "bridge"},
+					{"type": "portmap", "capabilities": {"portMappings": true}}
+				]}`,
+			},
+			expected: true,
+		},
+		"should be capable with port mappings capability in config": {
+			files: map[string]string{
+				"10-bridge.conf": `{"cniVersion": "0.3.1", "name": "net", "type": "bridge"}`,
+				"20-custom.conf": `{"cniVersion": "0.3.1", "name": "custom", "type": "custom", "capabilities": {"portMappings": true}}`,
+			},
+			expected: true,
+		},
+		"should not be capable when port mappings capability is disabled": {
+			files: map[string]string{
+				"10-bridge.conflist": `{"cniVersion": "0.3.1", "name": "net", "plugins": [
+					{"type": "bridge"},
+					{"type": "portmap", "capabilities": {"portMappings": false}}
+				]}`,
+			},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		dir, err := ioutil.TempDir("", "test-cni-conf")
+		require.NoError(t, err)
+		for name, content := range test.files {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+		}
+		capable, err := cniPortMappingsCapable(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, capable)
+		os.RemoveAll(dir)
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+)
+
+// managedHostsHeader is the default content of the hosts file of pod network
+// sandboxes, same with the kubelet managed one.
+const managedHostsHeader = `# Kubernetes-managed hosts file.
+127.0.0.1	localhost
+::1	localhost ip6-localhost ip6-loopback
+fe00::0	ip6-localnet
+fe00::0	ip6-mcastprefix
+fe00::1	ip6-allnodes
+fe00::2	ip6-allrouters
+`
+
+// hostAlias is an entry of the hosts file added by the host aliases
+// annotation.
+type hostAlias struct {
+	ip        string
+	hostnames []string
+}
+
+// parseHostAliases parses the host aliases annotation, e.g.
+// "10.0.0.1=foo,foo.local;fd00::1=bar".
+func parseHostAliases(s string) ([]hostAlias, error) {
+	var aliases []hostAlias
+	for _, item := range strings.Split(s, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("host alias %q is not in the form ip=hostnames", item)
+		}
+		ip := strings.TrimSpace(parts[0])
+		if net.ParseIP(ip) == nil {
+			return nil, errors.Errorf("invalid ip of host alias %q", item)
+		}
+		alias := hostAlias{ip: ip}
+		for _, hostname := range strings.Split(parts[1], ",") {
+			hostname = strings.TrimSpace(hostname)
+			if hostname == "" || strings.ContainsAny(hostname, " \t#") {
+				return nil, errors.Errorf("invalid hostname %q of host alias %q", hostname, item)
+			}
+			alias.hostnames = append(alias.hostnames, hostname)
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
+// getHostAliases returns the host aliases of a sandbox.
+func getHostAliases(config *runtime.PodSandboxConfig) ([]hostAlias, error) {
+	s, ok := config.GetAnnotations()[annotations.HostAliases]
+	if !ok {
+		return nil, nil
+	}
+	aliases, err := parseHostAliases(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %q annotation", annotations.HostAliases)
+	}
+	return aliases, nil
+}
+
+// generateHostsAliases returns the hosts file entries of the host aliases.
+func generateHostsAliases(aliases []hostAlias) []byte {
+	if len(aliases) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	buf.WriteString("\n# Entries added by HostAliases.\n")
+	for _, alias := range aliases {
+		fmt.Fprintf(&buf, "%s\t%s\n", alias.ip, strings.Join(alias.hostnames, "\t"))
+	}
+	return buf.Bytes()
+}
+
+// generateManagedHosts returns the hosts file of a pod network sandbox, with
+// its hostname resolving to the pod ip.
+func generateManagedHosts(config *runtime.PodSandboxConfig, ip string, aliases []hostAlias) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(managedHostsHeader)
+	if hostname := config.GetHostname(); hostname != "" && ip != "" {
+		fmt.Fprintf(&buf, "%s\t%s\n", ip, hostname)
+	}
+	buf.Write(generateHostsAliases(aliases))
+	return buf.Bytes()
+}
+
+// setupSandboxHosts creates the hosts file of a sandbox. A host network
+// sandbox gets the host hosts file, which is consistent with its hostname,
+// other sandboxes get a managed one with the pod ip. The host aliases are
+// appended in both cases.
+func (c *criService) setupSandboxHosts(id, ip string, config *runtime.PodSandboxConfig) error {
+	aliases, err := getHostAliases(config)
+	if err != nil {
+		return err
+	}
+	sandboxEtcHosts := c.getSandboxHosts(id)
+	if config.GetLinux().GetSecurityContext().GetNamespaceOptions().GetNetwork() != runtime.NamespaceMode_NODE {
+		if err := c.os.WriteFile(sandboxEtcHosts, generateManagedHosts(config, ip, aliases), 0644); err != nil {
+			return errors.Wrapf(err, "failed to write sandbox hosts file %q", sandboxEtcHosts)
+		}
+		return nil
+	}
+	if len(aliases) == 0 {
+		if err := c.os.CopyFile(etcHosts, sandboxEtcHosts, 0644); err != nil {
+			return errors.Wrapf(err, "failed to generate sandbox hosts file %q", sandboxEtcHosts)
+		}
+		return nil
+	}
+	content, err := ioutil.ReadFile(etcHosts)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %q", etcHosts)
+	}
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		content = append(content, '\n')
+	}
+	content = append(content, generateHostsAliases(aliases)...)
+	if err := c.os.WriteFile(sandboxEtcHosts, content, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write sandbox hosts file %q", sandboxEtcHosts)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+)
+
+func TestParseHostAliases(t *testing.T) {
+	for desc, test := range map[string]struct {
+		value     string
+		expected  []hostAlias
+		expectErr bool
+	}{
+		"should parse host aliases": {
+			value: "10.0.0.1=foo, foo.local; fd00::1=bar;",
+			expected: []hostAlias{
+				{ip: "10.0.0.1", hostnames: []string{"foo", "foo.local"}},
+				{ip: "fd00::1", hostnames: []string{"bar"}},
+			},
+		},
+		"should return nothing for empty value": {},
+		"should reject alias without hostnames": {
+			value:     "10.0.0.1",
+			expectErr: true,
+		},
+		"should reject invalid ip": {
+			value:     "foo=bar",
+			expectErr: true,
+		},
+		"should reject empty hostname": {
+			value:     "10.0.0.1=foo,,bar",
+			expectErr: true,
+		},
+		"should reject hostname with whitespace": {
+			value:     "10.0.0.1=foo bar",
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		aliases, err := parseHostAliases(test.value)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, aliases)
+	}
+}
+
+func TestGenerateManagedHosts(t *testing.T) {
+	config := &runtime.PodSandboxConfig{
+		Hostname:    "test-host",
+		Annotations: map[string]string{annotations.HostAliases: "10.0.0.1=foo,foo.local"},
+	}
+	aliases, err := getHostAliases(config)
+	assert.NoError(t, err)
+	assert.Equal(t, managedHostsHeader+`10.10.10.10	test-host
+
+# Entries added by HostAliases.
+10.0.0.1	foo	foo.local
+`, string(generateManagedHosts(config, "10.10.10.10", aliases)))
+
+	t.Logf("should not add the hostname without pod ip")
+	assert.Equal(t, managedHostsHeader, string(generateManagedHosts(config, "", nil)))
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"time"
+
+	"github.com/containerd/cri/pkg/log"
+)
+
+const (
+	// sandboxImagePullInitialBackoff is the backoff before the first retry
+	// of pulling the sandbox image at startup.
+	sandboxImagePullInitialBackoff = 5 * time.Second
+	// sandboxImagePullMaxBackoff is the maximum backoff between retries of
+	// pulling the sandbox image at startup.
+	sandboxImagePullMaxBackoff = 5 * time.Minute
+)
+
+// pullSandboxImage pulls the sandbox image in the background if it is
+// missing, so that the first RunPodSandbox on a fresh node doesn't fail or
+// wait for the pull. It retries with exponential backoff until the image
+// exists. No stop function is needed, it's fine to let it exit with the
+// process.
+func (c *criService) pullSandboxImage() {
+	go func() {
+		backoff := sandboxImagePullInitialBackoff
+		for {
+			sandboxImage := c.reloadableConfig().SandboxImage
+			_, err := c.ensureImageExists(c.namespacedContext(), sandboxImage)
+			if err == nil {
+				log.Image.Infof("Sandbox image %q is ready", sandboxImage)
+				return
+			}
+			log.Image.WithError(err).Errorf("Failed to pull sandbox image %q, retrying in %v", sandboxImage, backoff)
+			time.Sleep(backoff)
+			if backoff *= 2; backoff > sandboxImagePullMaxBackoff {
+				backoff = sandboxImagePullMaxBackoff
+			}
+		}
+	}()
+}
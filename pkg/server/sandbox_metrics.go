@@ -0,0 +1,322 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"time"
+
+	"github.com/containerd/cgroups"
+	"github.com/containerd/typeurl"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+	"github.com/containerd/cri/pkg/log"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+// containerMetricLabelKeys are the label keys of container metrics, same
+// with cadvisor.
+var containerMetricLabelKeys = []string{"id", "name", "image", "container", "pod", "namespace"}
+
+// networkMetricLabelKeys are the label keys of pod network metrics.
+var networkMetricLabelKeys = []string{"pod", "namespace", "interface"}
+
+// containerMetric is a container metric read from cgroup metrics.
+type containerMetric struct {
+	name       string
+	help       string
+	metricType api.MetricType
+	// value returns the value of the metric, and false if the cgroup metrics
+	// don't have it.
+	value func(*cgroups.Metrics) (uint64, bool)
+}
+
+// containerMetrics are the container metrics, named the same with cadvisor.
+// Unlike cadvisor, cpu time is in nanoseconds, because metric values are
+// integers.
+var containerMetrics = []containerMetric{
+	{
+		name:       "container_cpu_usage_seconds_total",
+		help:       "Cumulative cpu time consumed in nanoseconds.",
+		metricType: api.MetricType_COUNTER,
+		value: func(m *cgroups.Metrics) (uint64, bool) {
+			if m.CPU == nil || m.CPU.Usage == nil {
+				return 0, false
+			}
+			return m.CPU.Usage.Total, true
+		},
+	},
+	{
+		name:       "container_cpu_user_seconds_total",
+		help:       "Cumulative user cpu time consumed in nanoseconds.",
+		metricType: api.MetricType_COUNTER,
+		value: func(m *cgroups.Metrics) (uint64, bool) {
+			if m.CPU == nil || m.CPU.Usage == nil {
+				return 0, false
+			}
+			return m.CPU.Usage.User, true
+		},
+	},
+	{
+		name:       "container_cpu_system_seconds_total",
+		help:       "Cumulative system cpu time consumed in nanoseconds.",
+		metricType: api.MetricType_COUNTER,
+		value: func(m *cgroups.Metrics) (uint64, bool) {
+			if m.CPU == nil || m.CPU.Usage == nil {
+				return 0, false
+			}
+			return m.CPU.Usage.Kernel, true
+		},
+	},
+	{
+		name:       "container_cpu_cfs_periods_total",
+		help:       "Number of elapsed enforcement period intervals.",
+		metricType: api.MetricType_COUNTER,
+		value: func(m *cgroups.Metrics) (uint64, bool) {
+			if m.CPU == nil || m.CPU.Throttling == nil {
+				return 0, false
+			}
+			return m.CPU.Throttling.Periods, true
+		},
+	},
+	{
+		name:       "container_cpu_cfs_throttled_periods_total",
+		help:       "Number of throttled period intervals.",
+		metricType: api.MetricType_COUNTER,
+		value: func(m *cgroups.Metrics) (uint64, bool) {
+			if m.CPU == nil || m.CPU.Throttling == nil {
+				return 0, false
+			}
+			return m.CPU.Throttling.ThrottledPeriods, true
+		},
+	},
+	{
+		name:       "container_cpu_cfs_throttled_seconds_total",
+		help:       "Total time duration the container has been throttled in nanoseconds.",
+		metricType: api.MetricType_COUNTER,
+		value: func(m *cgroups.Metrics) (uint64, bool) {
+			if m.CPU == nil || m.CPU.Throttling == nil {
+				return 0, false
+			}
+			return m.CPU.Throttling.ThrottledTime, true
+		},
+	},
+	{
+		name:       "container_memory_usage_bytes",
+		help:       "Current memory usage in bytes, including all memory regardless of when it was accessed.",
+		metricType: api.MetricType_GAUGE,
+		value: func(m *cgroups.Metrics) (uint64, bool) {
+			if m.Memory == nil || m.Memory.Usage == nil {
+				return 0, false
+			}
+			return m.Memory.Usage.Usage, true
+		},
+	},
+	{
+		name:       "container_memory_working_set_bytes",
+		help:       "Current working set in bytes.",
+		metricType: api.MetricType_GAUGE,
+		value: func(m *cgroups.Metrics) (uint64, bool) {
+			if m.Memory == nil {
+				return 0, false
+			}
+			return getWorkingSet(m.Memory), true
+		},
+	},
+	{
+		name:       "container_memory_rss",
+		help:       "Size of RSS in bytes.",
+		metricType: api.MetricType_GAUGE,
+		value: func(m *cgroups.Metrics) (uint64, bool) {
+			if m.Memory == nil {
+				return 0, false
+			}
+			// Metrics read from the unified hierarchy have no total
+			// (hierarchical) stats, which are the same there.
+			if m.Memory.TotalRSS != 0 {
+				return m.Memory.TotalRSS, true
+			}
+			return m.Memory.RSS, true
+		},
+	},
+	{
+		name:       "container_memory_cache",
+		help:       "Number of bytes of page cache memory.",
+		metricType: api.MetricType_GAUGE,
+		value: func(m *cgroups.Metrics) (uint64, bool) {
+			if m.Memory == nil {
+				return 0, false
+			}
+			if m.Memory.TotalCache != 0 {
+				return m.Memory.TotalCache, true
+			}
+			return m.Memory.Cache, true
+		},
+	},
+	{
+		name:       "container_memory_failcnt",
+		help:       "Number of memory usage hits limits.",
+		metricType: api.MetricType_COUNTER,
+		value: func(m *cgroups.Metrics) (uint64, bool) {
+			if m.Memory == nil || m.Memory.Usage == nil {
+				return 0, false
+			}
+			return m.Memory.Usage.Failcnt, true
+		},
+	},
+}
+
+// networkMetric is a pod network metric of an interface.
+type networkMetric struct {
+	name  string
+	help  string
+	value func(*api.NetworkInterfaceStats) uint64
+}
+
+// networkMetrics are the pod network metrics.
+var networkMetrics = []networkMetric{
+	{
+		name:  "container_network_receive_bytes_total",
+		help:  "Cumulative count of bytes received.",
+		value: func(s *api.NetworkInterfaceStats) uint64 { return s.RxBytes },
+	},
+	{
+		name:  "container_network_receive_errors_total",
+		help:  "Cumulative count of errors encountered while receiving.",
+		value: func(s *api.NetworkInterfaceStats) uint64 { return s.RxErrors },
+	},
+	{
+		name:  "container_network_transmit_bytes_total",
+		help:  "Cumulative count of bytes transmitted.",
+		value: func(s *api.NetworkInterfaceStats) uint64 { return s.TxBytes },
+	},
+	{
+		name:  "container_network_transmit_errors_total",
+		help:  "Cumulative count of errors encountered while transmitting.",
+		value: func(s *api.NetworkInterfaceStats) uint64 { return s.TxErrors },
+	},
+}
+
+// ListMetricDescriptors lists the descriptors of the metrics returned by
+// ListPodSandboxMetrics.
+func (c *criService) ListMetricDescriptors(ctx context.Context, r *api.ListMetricDescriptorsRequest) (*api.ListMetricDescriptorsResponse, error) {
+	var descriptors []*api.MetricDescriptor
+	for _, m := range containerMetrics {
+		descriptors = append(descriptors, &api.MetricDescriptor{
+			Name:      m.name,
+			Help:      m.help,
+			LabelKeys: containerMetricLabelKeys,
+		})
+	}
+	for _, m := range networkMetrics {
+		descriptors = append(descriptors, &api.MetricDescriptor{
+			Name:      m.name,
+			Help:      m.help,
+			LabelKeys: networkMetricLabelKeys,
+		})
+	}
+	return &api.ListMetricDescriptorsResponse{Descriptors: descriptors}, nil
+}
+
+// ListPodSandboxMetrics returns metrics of all ready pod sandboxes and their
+// running containers.
+func (c *criService) ListPodSandboxMetrics(ctx context.Context, r *api.ListPodSandboxMetricsRequest) (*api.ListPodSandboxMetricsResponse, error) {
+	var podMetrics []*api.PodSandboxMetrics
+	for _, sandbox := range c.sandboxStore.List() {
+		if sandbox.Status.Get().State != sandboxstore.StateReady {
+			continue
+		}
+		m, err := c.podSandboxMetrics(ctx, sandbox)
+		if err != nil {
+			// The sandbox may be stopped after the state check, skip it.
+			log.Sandbox.WithError(err).Warnf("Failed to get metrics of sandbox %q", sandbox.ID)
+			continue
+		}
+		podMetrics = append(podMetrics, m)
+	}
+	return &api.ListPodSandboxMetricsResponse{PodMetrics: podMetrics}, nil
+}
+
+// podSandboxMetrics collects metrics of a ready pod sandbox.
+func (c *criService) podSandboxMetrics(ctx context.Context, sandbox sandboxstore.Sandbox) (*api.PodSandboxMetrics, error) {
+	meta := sandbox.Config.GetMetadata()
+	podMetrics := &api.PodSandboxMetrics{PodSandboxId: sandbox.ID}
+
+	metrics, err := c.getSandboxMetrics(ctx, sandbox)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get sandbox metrics")
+	}
+	for _, m := range metrics {
+		// The sandbox container is not reported like a container.
+		if m.ID == sandbox.ID {
+			continue
+		}
+		cntr, err := c.containerStore.Get(m.ID)
+		if err != nil {
+			// The container may be removed after the metrics are collected.
+			continue
+		}
+		s, err := typeurl.UnmarshalAny(m.Data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to extract metrics for %q", m.ID)
+		}
+		labelValues := []string{cntr.ID, cntr.Name, cntr.ImageRef, cntr.Config.GetMetadata().GetName(),
+			meta.GetName(), meta.GetNamespace()}
+		podMetrics.ContainerMetrics = append(podMetrics.ContainerMetrics, &api.ContainerMetrics{
+			ContainerId: cntr.ID,
+			Metrics:     toContainerMetrics(s.(*cgroups.Metrics), m.Timestamp.UnixNano(), labelValues),
+		})
+	}
+
+	interfaces, err := getSandboxInterfaceStats(sandbox)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get network interface stats")
+	}
+	timestamp := time.Now().UnixNano()
+	for _, i := range interfaces {
+		for _, nm := range networkMetrics {
+			podMetrics.Metrics = append(podMetrics.Metrics, &api.Metric{
+				Name:        nm.name,
+				Timestamp:   timestamp,
+				MetricType:  api.MetricType_COUNTER,
+				LabelValues: []string{meta.GetName(), meta.GetNamespace(), i.Name},
+				Value:       nm.value(i),
+			})
+		}
+	}
+	return podMetrics, nil
+}
+
+// toContainerMetrics converts cgroup metrics into container metrics.
+func toContainerMetrics(cm *cgroups.Metrics, timestamp int64, labelValues []string) []*api.Metric {
+	var metrics []*api.Metric
+	for _, m := range containerMetrics {
+		value, ok := m.value(cm)
+		if !ok {
+			continue
+		}
+		metrics = append(metrics, &api.Metric{
+			Name:        m.name,
+			Timestamp:   timestamp,
+			MetricType:  m.metricType,
+			LabelValues: labelValues,
+			Value:       value,
+		})
+	}
+	return metrics
+}
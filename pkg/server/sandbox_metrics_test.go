@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/containerd/cgroups"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+)
+
+func TestListMetricDescriptors(t *testing.T) {
+	c := newTestCRIService()
+	resp, err := c.ListMetricDescriptors(context.Background(), &api.ListMetricDescriptorsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Descriptors, len(containerMetrics)+len(networkMetrics))
+	names := make(map[string]bool)
+	for _, d := range resp.Descriptors {
+		assert.False(t, names[d.Name], "duplicated descriptor %q", d.Name)
+		names[d.Name] = true
+		assert.NotEmpty(t, d.Help)
+		assert.NotEmpty(t, d.LabelKeys)
+	}
+	assert.True(t, names["container_memory_working_set_bytes"])
+	assert.True(t, names["container_network_receive_bytes_total"])
+}
+
+func TestToContainerMetrics(t *testing.T) {
+	const timestamp = int64(1234)
+	labelValues := []string{"id", "name", "image", "container", "pod", "namespace"}
+	for desc, test := range map[string]struct {
+		metrics  *cgroups.Metrics
+		expected map[string]uint64
+	}{
+		"should convert cgroup v1 metrics": {
+			metrics: &cgroups.Metrics{
+				CPU: &cgroups.CPUStat{
+					Usage:      &cgroups.CPUUsage{Total: 300, User: 200, Kernel: 100},
+					Throttling: &cgroups.Throttle{Periods: 10, ThrottledPeriods: 2, ThrottledTime: 50},
+				},
+				Memory: &cgroups.MemoryStat{
+					Usage:             &cgroups.MemoryEntry{Usage: 1000, Failcnt: 3},
+					TotalInactiveFile: 100,
+					RSS:               10,
+					TotalRSS:          600,
+					Cache:             20,
+					TotalCache:        300,
+				},
+			},
+			expected: map[string]uint64{
+				"container_cpu_usage_seconds_total":         300,
+				"container_cpu_user_seconds_total":          200,
+				"container_cpu_system_seconds_total":        100,
+				"container_cpu_cfs_periods_total":           10,
+				"container_cpu_cfs_throttled_periods_total": 2,
+				"container_cpu_cfs_throttled_seconds_total": 50,
+				"container_memory_usage_bytes":              1000,
+				"container_memory_working_set_bytes":        900,
+				"container_memory_rss":                      600,
+				"container_memory_cache":                    300,
+				"container_memory_failcnt":                  3,
+			},
+		},
+		"should use non total memory stats of unified metrics": {
+			metrics: &cgroups.Metrics{
+				Memory: &cgroups.MemoryStat{
+					Usage: &cgroups.MemoryEntry{Usage: 1000},
+					RSS:   600,
+					Cache: 300,
+				},
+			},
+			expected: map[string]uint64{
+				"container_memory_usage_bytes":       1000,
+				"container_memory_working_set_bytes": 1000,
+				"container_memory_rss":               600,
+				"container_memory_cache":             300,
+				"container_memory_failcnt":           0,
+			},
+		},
+		"should skip missing metrics": {
+			metrics:  &cgroups.Metrics{},
+			expected: map[string]uint64{},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		metrics := toContainerMetrics(test.metrics, timestamp, labelValues)
+		values := make(map[string]uint64)
+		for _, m := range metrics {
+			assert.Equal(t, timestamp, m.Timestamp)
+			assert.Equal(t, labelValues, m.LabelValues)
+			values[m.Name] = m.Value
+		}
+		assert.Equal(t, test.expected, values)
+	}
+}
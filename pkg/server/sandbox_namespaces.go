@@ -0,0 +1,254 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+const (
+	// sandboxModePause runs a pause container holding the pod namespaces.
+	sandboxModePause = "pause"
+	// sandboxModeNoPause creates the pod namespaces directly without a
+	// pause container.
+	sandboxModeNoPause = "no_pause"
+)
+
+// validateSandboxMode validates the sandbox mode of a runtime.
+func validateSandboxMode(mode string) error {
+	switch mode {
+	case "", sandboxModePause, sandboxModeNoPause:
+		return nil
+	}
+	return errors.Errorf("unsupported sandbox mode %q", mode)
+}
+
+// noPauseSandbox returns whether sandboxes running with a runtime have no
+// pause container.
+func noPauseSandbox(r criconfig.Runtime) bool {
+	return r.SandboxMode == sandboxModeNoPause
+}
+
+// sharesPodPIDNamespace returns whether the containers of a sandbox share the
+// pod pid namespace. The pause container is the init process holding the
+// namespace, so that it survives container restarts. With the pod pid reaper,
+// containers share it unless they use the host pid namespace.
+func (c *criService) sharesPodPIDNamespace(config *runtime.PodSandboxConfig) bool {
+	switch config.GetLinux().GetSecurityContext().GetNamespaceOptions().GetPid() {
+	case runtime.NamespaceMode_POD:
+		return true
+	case runtime.NamespaceMode_CONTAINER:
+		return c.config.PodPIDReaper
+	}
+	return false
+}
+
+// containerNamespaceOptions returns the namespace options a container is
+// created with. A container requesting its own pid namespace joins the pod
+// pid namespace with the pod pid reaper, so that the pause container reaps
+// its zombie processes.
+func (c *criService) containerNamespaceOptions(namespaces *runtime.NamespaceOption, sandboxConfig *runtime.PodSandboxConfig) *runtime.NamespaceOption {
+	if namespaces.GetPid() != runtime.NamespaceMode_CONTAINER || !c.config.PodPIDReaper ||
+		!c.sharesPodPIDNamespace(sandboxConfig) {
+		return namespaces
+	}
+	shared := *namespaces
+	shared.Pid = runtime.NamespaceMode_POD
+	return &shared
+}
+
+// validateNoPauseSandbox returns an error if a sandbox can't run without a
+// pause container, i.e. it runs in a user namespace, which is owned by the
+// pause container.
+func validateNoPauseSandbox(config *runtime.PodSandboxConfig) error {
+	if userNamespaceEnabled(config) {
+		return errors.New("user namespace is not supported without a pause container")
+	}
+	return nil
+}
+
+// getSandboxNamespacePath returns the path of a pinned namespace of a sandbox
+// without a pause container.
+func (c *criService) getSandboxNamespacePath(id, ns string) string {
+	return filepath.Join(c.getVolatileSandboxRootDir(id), "ns", ns)
+}
+
+// getPinnedNamespaces returns the namespaces pinned for a sandbox without a
+// pause container. The ipc and uts namespaces of the host are used with host
+// ipc and host network respectively.
+func getPinnedNamespaces(config *runtime.PodSandboxConfig) []string {
+	nsOptions := config.GetLinux().GetSecurityContext().GetNamespaceOptions()
+	var namespaces []string
+	if nsOptions.GetIpc() != runtime.NamespaceMode_NODE {
+		namespaces = append(namespaces, "ipc")
+	}
+	if nsOptions.GetNetwork() != runtime.NamespaceMode_NODE {
+		namespaces = append(namespaces, "uts")
+	}
+	return namespaces
+}
+
+// pinSandboxNamespaces creates the ipc and uts namespaces of a sandbox without
+// a pause container, and bind mounts them into the volatile sandbox root
+// directory, like the network namespace. The hostname and the sysctls of the
+// sandbox are set in the namespaces.
+func (c *criService) pinSandboxNamespaces(id string, config *runtime.PodSandboxConfig, netNSPath string) (retErr error) {
+	namespaces := getPinnedNamespaces(config)
+	if err := c.os.MkdirAll(filepath.Dir(c.getSandboxNamespacePath(id, "")), 0755); err != nil {
+		return errors.Wrap(err, "failed to create namespace directory")
+	}
+	defer func() {
+		if retErr != nil {
+			c.unpinSandboxNamespaces(id) // nolint: errcheck
+		}
+	}()
+	var flags int
+	for _, ns := range namespaces {
+		switch ns {
+		case "ipc":
+			flags |= unix.CLONE_NEWIPC
+		case "uts":
+			flags |= unix.CLONE_NEWUTS
+		}
+		f, err := os.Create(c.getSandboxNamespacePath(id, ns))
+		if err != nil {
+			return errors.Wrapf(err, "failed to create %s namespace mount point", ns)
+		}
+		f.Close()
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		// The thread is never unlocked, so that it exits with the goroutine
+		// instead of being reused with the sandbox namespaces.
+		goruntime.LockOSThread()
+		errCh <- func() error {
+			if err := unix.Unshare(flags); err != nil {
+				return errors.Wrap(err, "failed to unshare namespaces")
+			}
+			if hostname := config.GetHostname(); flags&unix.CLONE_NEWUTS != 0 && hostname != "" {
+				if err := unix.Sethostname([]byte(hostname)); err != nil {
+					return errors.Wrapf(err, "failed to set hostname %q", hostname)
+				}
+			}
+			tid := unix.Gettid()
+			for _, ns := range namespaces {
+				src := fmt.Sprintf("/proc/self/task/%d/ns/%s", tid, ns)
+				if err := unix.Mount(src, c.getSandboxNamespacePath(id, ns), "none", unix.MS_BIND, ""); err != nil {
+					return errors.Wrapf(err, "failed to bind mount %s namespace", ns)
+				}
+			}
+			return setSandboxSysctls(config, netNSPath)
+		}()
+	}()
+	return <-errCh
+}
+
+// setSandboxSysctls sets the sysctls of a sandbox in the namespaces of the
+// current thread, after joining the sandbox network namespace. The sysctls
+// are validated by validateSandboxSysctls.
+func setSandboxSysctls(config *runtime.PodSandboxConfig, netNSPath string) error {
+	sysctls := config.GetLinux().GetSysctls()
+	if len(sysctls) == 0 {
+		return nil
+	}
+	if netNSPath != "" {
+		f, err := os.Open(netNSPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to open network namespace %q", netNSPath)
+		}
+		defer f.Close()
+		if err := unix.Setns(int(f.Fd()), unix.CLONE_NEWNET); err != nil {
+			return errors.Wrapf(err, "failed to join network namespace %q", netNSPath)
+		}
+	}
+	for key, value := range sysctls {
+		path := filepath.Join("/proc/sys", strings.Replace(key, ".", "/", -1))
+		if err := ioutil.WriteFile(path, []byte(value), 0644); err != nil {
+			return errors.Wrapf(err, "failed to set sysctl %q to %q", key, value)
+		}
+	}
+	return nil
+}
+
+// unpinSandboxNamespaces unmounts and removes the pinned namespaces of a
+// sandbox without a pause container. It is idempotent.
+func (c *criService) unpinSandboxNamespaces(id string) error {
+	for _, ns := range []string{"ipc", "uts"} {
+		path := c.getSandboxNamespacePath(id, ns)
+		if err := unix.Unmount(path, unix.MNT_DETACH); err != nil && err != unix.EINVAL && err != unix.ENOENT {
+			return errors.Wrapf(err, "failed to unmount %s namespace %q", ns, path)
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to remove %s namespace %q", ns, path)
+		}
+	}
+	return nil
+}
+
+// sandboxNamespacesPinned returns whether all namespaces of a sandbox without
+// a pause container are still pinned.
+func (c *criService) sandboxNamespacesPinned(id string, config *runtime.PodSandboxConfig) bool {
+	for _, ns := range getPinnedNamespaces(config) {
+		if _, err := c.os.Stat(c.getSandboxNamespacePath(id, ns)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// setOCINoPauseNamespaces sets the namespaces of a container in a sandbox
+// without a pause container. The container joins the pinned sandbox
+// namespaces instead of the namespaces of the pause container.
+func (c *criService) setOCINoPauseNamespaces(g *generate.Generator, sandbox sandboxstore.Sandbox, namespaces *runtime.NamespaceOption) {
+	sandboxNamespaces := sandbox.Config.GetLinux().GetSecurityContext().GetNamespaceOptions()
+	if sandbox.NetNSPath == "" {
+		g.RemoveLinuxNamespace(string(runtimespec.NetworkNamespace)) // nolint: errcheck
+	} else {
+		g.AddOrReplaceLinuxNamespace(string(runtimespec.NetworkNamespace), sandbox.NetNSPath) // nolint: errcheck
+	}
+	if sandboxNamespaces.GetIpc() == runtime.NamespaceMode_NODE {
+		g.RemoveLinuxNamespace(string(runtimespec.IPCNamespace)) // nolint: errcheck
+	} else {
+		g.AddOrReplaceLinuxNamespace(string(runtimespec.IPCNamespace), c.getSandboxNamespacePath(sandbox.ID, "ipc")) // nolint: errcheck
+	}
+	if sandboxNamespaces.GetNetwork() == runtime.NamespaceMode_NODE {
+		g.RemoveLinuxNamespace(string(runtimespec.UTSNamespace)) // nolint: errcheck
+	} else {
+		g.AddOrReplaceLinuxNamespace(string(runtimespec.UTSNamespace), c.getSandboxNamespacePath(sandbox.ID, "uts")) // nolint: errcheck
+	}
+	// Each container has its own pid namespace unless it uses the host one.
+	if namespaces.GetPid() == runtime.NamespaceMode_NODE {
+		g.RemoveLinuxNamespace(string(runtimespec.PIDNamespace)) // nolint: errcheck
+	} else {
+		g.AddOrReplaceLinuxNamespace(string(runtimespec.PIDNamespace), "") // nolint: errcheck
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+func TestValidateSandboxMode(t *testing.T) {
+	for mode, expectErr := range map[string]bool{
+		"":                 false,
+		sandboxModePause:   false,
+		sandboxModeNoPause: false,
+		"vm":               true,
+	} {
+		t.Logf("TestCase %q", mode)
+		err := validateSandboxMode(mode)
+		assert.Equal(t, expectErr, err != nil)
+	}
+	assert.True(t, noPauseSandbox(criconfig.Runtime{SandboxMode: sandboxModeNoPause}))
+	assert.False(t, noPauseSandbox(criconfig.Runtime{}))
+}
+
+func TestValidateNoPauseSandbox(t *testing.T) {
+	for desc, test := range map[string]struct {
+		nsOptions *runtime.NamespaceOption
+		expectErr bool
+	}{
+		"should allow container pid namespace": {
+			nsOptions: &runtime.NamespaceOption{Pid: runtime.NamespaceMode_CONTAINER},
+		},
+		"should allow host pid namespace": {
+			nsOptions: &runtime.NamespaceOption{Pid: runtime.NamespaceMode_NODE},
+		},
+		"should allow pod pid namespace": {
+			nsOptions: &runtime.NamespaceOption{Pid: runtime.NamespaceMode_POD},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		config := &runtime.PodSandboxConfig{
+			Linux: &runtime.LinuxPodSandboxConfig{
+				SecurityContext: &runtime.LinuxSandboxSecurityContext{
+					NamespaceOptions: test.nsOptions,
+				},
+			},
+		}
+		err := validateNoPauseSandbox(config)
+		assert.Equal(t, test.expectErr, err != nil)
+	}
+}
+
+func TestSharesPodPIDNamespace(t *testing.T) {
+	for desc, test := range map[string]struct {
+		mode         runtime.NamespaceMode
+		podPIDReaper bool
+		expected     bool
+	}{
+		"pod namespace mode":                           {mode: runtime.NamespaceMode_POD, expected: true},
+		"container namespace mode":                     {mode: runtime.NamespaceMode_CONTAINER},
+		"node namespace mode":                          {mode: runtime.NamespaceMode_NODE},
+		"container namespace mode with pod pid reaper": {mode: runtime.NamespaceMode_CONTAINER, podPIDReaper: true, expected: true},
+		"node namespace mode with pod pid reaper":      {mode: runtime.NamespaceMode_NODE, podPIDReaper: true},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.PodPIDReaper = test.podPIDReaper
+		config := &runtime.PodSandboxConfig{
+			Linux: &runtime.LinuxPodSandboxConfig{
+				SecurityContext: &runtime.LinuxSandboxSecurityContext{
+					NamespaceOptions: &runtime.NamespaceOption{Pid: test.mode},
+				},
+			},
+		}
+		assert.Equal(t, test.expected, c.sharesPodPIDNamespace(config))
+	}
+}
+
+func TestGetPinnedNamespaces(t *testing.T) {
+	for desc, test := range map[string]struct {
+		nsOptions *runtime.NamespaceOption
+		expected  []string
+	}{
+		"should pin ipc and uts namespaces": {
+			nsOptions: &runtime.NamespaceOption{},
+			expected:  []string{"ipc", "uts"},
+		},
+		"should not pin ipc namespace with host ipc": {
+			nsOptions: &runtime.NamespaceOption{Ipc: runtime.NamespaceMode_NODE},
+			expected:  []string{"uts"},
+		},
+		"should not pin uts namespace with host network": {
+			nsOptions: &runtime.NamespaceOption{Network: runtime.NamespaceMode_NODE},
+			expected:  []string{"ipc"},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		config := &runtime.PodSandboxConfig{
+			Linux: &runtime.LinuxPodSandboxConfig{
+				SecurityContext: &runtime.LinuxSandboxSecurityContext{
+					NamespaceOptions: test.nsOptions,
+				},
+			},
+		}
+		assert.Equal(t, test.expected, getPinnedNamespaces(config))
+	}
+}
+
+func TestSetOCINoPauseNamespaces(t *testing.T) {
+	testID := "test-id"
+	testSandboxID := "sandbox-id"
+	testNetNSPath := "/var/run/netns/test"
+	config, sandboxConfig, imageConfig, _ := getCreateContainerTestData()
+	c := newTestCRIService()
+	for desc, test := range map[string]struct {
+		sandboxNSOptions *runtime.NamespaceOption
+		pidNS            runtime.NamespaceMode
+		netNSPath        string
+		expected         []runtimespec.LinuxNamespace
+		unexpected       []runtimespec.LinuxNamespaceType
+	}{
+		"should join pinned namespaces": {
+			sandboxNSOptions: &runtime.NamespaceOption{},
+			pidNS:            runtime.NamespaceMode_CONTAINER,
+			netNSPath:        testNetNSPath,
+			expected: []runtimespec.LinuxNamespace{
+				{Type: runtimespec.NetworkNamespace, Path: testNetNSPath},
+				{Type: runtimespec.IPCNamespace, Path: c.getSandboxNamespacePath(testSandboxID, "ipc")},
+				{Type: runtimespec.UTSNamespace, Path: c.getSandboxNamespacePath(testSandboxID, "uts")},
+				{Type: runtimespec.PIDNamespace},
+			},
+		},
+		"should use host namespaces": {
+			sandboxNSOptions: &runtime.NamespaceOption{
+				Network: runtime.NamespaceMode_NODE,
+				Ipc:     runtime.NamespaceMode_NODE,
+			},
+			pidNS: runtime.NamespaceMode_NODE,
+			unexpected: []runtimespec.LinuxNamespaceType{
+				runtimespec.NetworkNamespace,
+				runtimespec.IPCNamespace,
+				runtimespec.UTSNamespace,
+				runtimespec.PIDNamespace,
+			},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		sandboxConfig.Linux.SecurityContext = &runtime.LinuxSandboxSecurityContext{
+			NamespaceOptions: test.sandboxNSOptions,
+		}
+		config.Linux.SecurityContext.NamespaceOptions = &runtime.NamespaceOption{Pid: test.pidNS}
+		sandbox := sandboxstore.NewSandbox(
+			sandboxstore.Metadata{
+				ID:               testSandboxID,
+				Config:           sandboxConfig,
+				NetNSPath:        test.netNSPath,
+				NoPauseContainer: true,
+			},
+			sandboxstore.Status{State: sandboxstore.StateReady},
+		)
+		spec, err := c.generateContainerSpec(testID, testSandboxID, 0, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
+		require.NoError(t, err)
+		g := newSpecGenerator(spec)
+		c.setOCINoPauseNamespaces(&g, sandbox, config.GetLinux().GetSecurityContext().GetNamespaceOptions())
+		for _, ns := range test.expected {
+			assert.Contains(t, spec.Linux.Namespaces, ns)
+		}
+		for _, nsType := range test.unexpected {
+			for _, ns := range spec.Linux.Namespaces {
+				assert.NotEqual(t, nsType, ns.Type)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containernetworking/cni/libcni"
+	"github.com/containernetworking/cni/pkg/types/current"
+	"github.com/pkg/errors"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/log"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+// additionalIfNamePrefix is the interface name prefix of additional networks.
+// It is different from the prefix used by the cni library, so that the
+// interface names don't conflict with the networks all pods are attached to.
+const additionalIfNamePrefix = "net"
+
+// getAdditionalNetworks returns the names of the additional networks
+// requested by a sandbox.
+func (c *criService) getAdditionalNetworks(config *runtime.PodSandboxConfig) []string {
+	if c.config.NetworksAnnotation == "" {
+		return nil
+	}
+	var networks []string
+	for _, name := range strings.Split(config.GetAnnotations()[c.config.NetworksAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			networks = append(networks, name)
+		}
+	}
+	return networks
+}
+
+// setupAdditionalNetworks attaches the sandbox network namespace to the
+// additional networks requested by the sandbox.
+func (c *criService) setupAdditionalNetworks(id, path string, config *runtime.PodSandboxConfig) (_ []sandboxstore.NetworkAttachment, retErr error) {
+	networks := c.getAdditionalNetworks(config)
+	if len(networks) == 0 {
+		return nil, nil
+	}
+	if c.config.NetworksConfDir == "" {
+		return nil, errors.New("additional networks conf dir is not configured")
+	}
+	var attachments []sandboxstore.NetworkAttachment
+	defer func() {
+		if retErr != nil {
+			if err := c.teardownAdditionalNetworks(id, path, config, attachments); err != nil {
+				log.CNI.WithError(err).Errorf("Failed to destroy additional networks for sandbox %q", id)
+			}
+		}
+	}()
+	cniConfig := &libcni.CNIConfig{Path: []string{c.config.NetworkPluginBinDir}}
+	for i, name := range networks {
+		confList, err := libcni.LoadConfList(c.config.NetworksConfDir, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load conf of network %q", name)
+		}
+		ifName := fmt.Sprintf("%s%d", additionalIfNamePrefix, i+1)
+		r, err := cniConfig.AddNetworkList(confList, getAdditionalNetworkRuntimeConf(id, path, ifName, config))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to attach network %q", name)
+		}
+		result, err := current.NewResultFromResult(r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert result of network %q", name)
+		}
+		attachments = append(attachments, sandboxstore.NetworkAttachment{
+			Name:     name,
+			IfName:   ifName,
+			IPs:      getInterfaceIPs(result, ifName),
+			ConfList: confList.Bytes,
+		})
+	}
+	return attachments, nil
+}
+
+// teardownAdditionalNetworks detaches the sandbox network namespace from the
+// additional networks in reverse order. It tries all the networks, and
+// returns the last error.
+func (c *criService) teardownAdditionalNetworks(id, path string, config *runtime.PodSandboxConfig, attachments []sandboxstore.NetworkAttachment) error {
+	var lastErr error
+	cniConfig := &libcni.CNIConfig{Path: []string{c.config.NetworkPluginBinDir}}
+	for i := len(attachments) - 1; i >= 0; i-- {
+		a := attachments[i]
+		confList, err := c.getAttachmentConfList(a)
+		if err != nil {
+			lastErr = errors.Wrapf(err, "failed to load conf of network %q", a.Name)
+			continue
+		}
+		if err := cniConfig.DelNetworkList(confList, getAdditionalNetworkRuntimeConf(id, path, a.IfName, config)); err != nil {
+			lastErr = errors.Wrapf(err, "failed to detach network %q", a.Name)
+		}
+	}
+	return lastErr
+}
+
+// getAttachmentConfList returns the cni conf list of an additional network
+// attachment. Attachments persisted without their conf list use the current
+// conf of the network.
+func (c *criService) getAttachmentConfList(a sandboxstore.NetworkAttachment) (*libcni.NetworkConfigList, error) {
+	if len(a.ConfList) == 0 {
+		return libcni.LoadConfList(c.config.NetworksConfDir, a.Name)
+	}
+	return libcni.ConfListFromBytes(a.ConfList)
+}
+
+// getAdditionalNetworkRuntimeConf returns the cni runtime conf of an
+// additional network, with the same args as other networks of the pod.
+func getAdditionalNetworkRuntimeConf(id, path, ifName string, config *runtime.PodSandboxConfig) *libcni.RuntimeConf {
+	rt := &libcni.RuntimeConf{
+		ContainerID: id,
+		NetNS:       path,
+		IfName:      ifName,
+	}
+	for k, v := range getPodCNILabels(id, config) {
+		rt.Args = append(rt.Args, [2]string{k, v})
+	}
+	return rt
+}
+
+// getInterfaceIPs returns the IPs of an interface in a cni result. IPs not
+// associated with any interface belong to the interface being attached.
+func getInterfaceIPs(result *current.Result, ifName string) []string {
+	var ips []string
+	for _, ip := range result.IPs {
+		if ip.Interface != nil && *ip.Interface < len(result.Interfaces) &&
+			result.Interfaces[*ip.Interface].Name != ifName {
+			continue
+		}
+		ips = append(ips, ip.Address.IP.String())
+	}
+	return ips
+}
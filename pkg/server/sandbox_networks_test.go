@@ -0,0 +1,123 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containernetworking/cni/pkg/types/current"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+func TestGetAdditionalNetworks(t *testing.T) {
+	const testAnnotation = "test.networks"
+	for desc, test := range map[string]struct {
+		annotationKey string
+		annotations   map[string]string
+		expected      []string
+	}{
+		"should not return networks when the annotation is not configured": {
+			annotations: map[string]string{testAnnotation: "net-a"},
+		},
+		"should not return networks when the annotation is not set": {
+			annotationKey: testAnnotation,
+		},
+		"should return networks in the annotation": {
+			annotationKey: testAnnotation,
+			annotations:   map[string]string{testAnnotation: "net-a, net-b,,"},
+			expected:      []string{"net-a", "net-b"},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.NetworksAnnotation = test.annotationKey
+		config := &runtime.PodSandboxConfig{Annotations: test.annotations}
+		assert.Equal(t, test.expected, c.getAdditionalNetworks(config))
+	}
+}
+
+func TestSetupAdditionalNetworksErrors(t *testing.T) {
+	const testAnnotation = "test.networks"
+	dir, err := ioutil.TempDir("", "test-networks")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	config := &runtime.PodSandboxConfig{
+		Annotations: map[string]string{testAnnotation: "net-a"},
+	}
+	c := newTestCRIService()
+	c.config.NetworksAnnotation = testAnnotation
+
+	t.Logf("should fail when the conf dir is not configured")
+	_, err = c.setupAdditionalNetworks("test-id", "/test/netns", config)
+	assert.Error(t, err)
+
+	t.Logf("should fail when the network is not found")
+	c.config.NetworksConfDir = dir
+	_, err = c.setupAdditionalNetworks("test-id", "/test/netns", config)
+	assert.Error(t, err)
+}
+
+func TestGetAttachmentConfList(t *testing.T) {
+	const testConfList = `{"cniVersion": "0.3.1", "name": "net-a", "plugins": [{"type": "bridge"}]}`
+	dir, err := ioutil.TempDir("", "test-networks")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	c := newTestCRIService()
+	c.config.NetworksConfDir = dir
+
+	t.Logf("should use the persisted conf list even if the conf file is removed")
+	confList, err := c.getAttachmentConfList(sandboxstore.NetworkAttachment{
+		Name:     "net-a",
+		IfName:   "net1",
+		ConfList: []byte(testConfList),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "net-a", confList.Name)
+	assert.Equal(t, "bridge", confList.Plugins[0].Network.Type)
+
+	t.Logf("should load the conf file for attachments without conf list")
+	_, err = c.getAttachmentConfList(sandboxstore.NetworkAttachment{Name: "net-a", IfName: "net1"})
+	assert.Error(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "net-a.conflist"), []byte(testConfList), 0644))
+	confList, err = c.getAttachmentConfList(sandboxstore.NetworkAttachment{Name: "net-a", IfName: "net1"})
+	require.NoError(t, err)
+	assert.Equal(t, "net-a", confList.Name)
+}
+
+func TestGetInterfaceIPs(t *testing.T) {
+	hostIf, sandboxIf := 0, 1
+	result := &current.Result{
+		Interfaces: []*current.Interface{
+			{Name: "veth1234"},
+			{Name: "net1", Sandbox: "/test/netns"},
+		},
+		IPs: []*current.IPConfig{
+			{Interface: &hostIf, Address: net.IPNet{IP: net.ParseIP("10.0.0.1")}},
+			{Interface: &sandboxIf, Address: net.IPNet{IP: net.ParseIP("10.0.0.2")}},
+			{Address: net.IPNet{IP: net.ParseIP("fd00::2")}},
+		},
+	}
+	assert.Equal(t, []string{"10.0.0.2", "fd00::2"}, getInterfaceIPs(result, "net1"))
+}
@@ -17,18 +17,16 @@ limitations under the License.
 package server
 
 import (
-	"bytes"
 	"fmt"
 	"io"
-	"os/exec"
-	"strings"
+	"net"
 
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
+	"github.com/containerd/cri/pkg/log"
 	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
 )
 
@@ -45,9 +43,10 @@ func (c *criService) PortForward(ctx context.Context, r *runtime.PortForwardRequ
 	return c.streamServer.GetPortForward(r)
 }
 
-// portForward requires `socat` on the node. It uses netns to enter the sandbox namespace,
-// and run `socat` insidethe namespace to forward stream for a specific port. The `socat`
-// command keeps running until it exits or client disconnect.
+// portForward uses netns to enter the sandbox namespace, and connects to a
+// specific port on localhost inside the namespace. IPv4 is tried first, then
+// IPv6 for IPv6 only pods. The stream is forwarded until either side closes
+// the connection.
 func (c *criService) portForward(id string, port int32, stream io.ReadWriteCloser) error {
 	s, err := c.sandboxStore.Get(id)
 	if err != nil {
@@ -72,52 +71,47 @@ func (c *criService) portForward(id string, port int32, stream io.ReadWriteClose
 		netNSPath = "host"
 	}
 
-	socat, err := exec.LookPath("socat")
-	if err != nil {
-		return errors.Wrap(err, "failed to find socat")
+	log.Streaming.Infof("Connecting to port %d in network namespace %q", port, netNSPath)
+	var conn net.Conn
+	// The socket stays in the network namespace it is created in, so only
+	// the dial needs to be done in the namespace.
+	if err := netNSDo(func(_ ns.NetNS) error {
+		conn, err = dialLocalhost(port)
+		return err
+	}); err != nil {
+		return errors.Wrapf(err, "failed to connect to port %d in network namespace %q", port, netNSPath)
 	}
+	defer conn.Close()
 
-	// Check https://linux.die.net/man/1/socat for meaning of the options.
-	args := []string{socat, "-", fmt.Sprintf("TCP4:localhost:%d", port)}
-
-	logrus.Infof("Executing port forwarding command %q in network namespace %q", strings.Join(args, " "), netNSPath)
-	err = netNSDo(func(_ ns.NetNS) error {
-		cmd := exec.Command(args[0], args[1:]...)
-		cmd.Stdout = stream
-
-		stderr := new(bytes.Buffer)
-		cmd.Stderr = stderr
-
-		// If we use Stdin, command.Run() won't return until the goroutine that's copying
-		// from stream finishes. Unfortunately, if you have a client like telnet connected
-		// via port forwarding, as long as the user's telnet client is connected to the user's
-		// local listener that port forwarding sets up, the telnet session never exits. This
-		// means that even if socat has finished running, command.Run() won't ever return
-		// (because the client still has the connection and stream open).
-		//
-		// The work around is to use StdinPipe(), as Wait() (called by Run()) closes the pipe
-		// when the command (socat) exits.
-		in, err := cmd.StdinPipe()
-		if err != nil {
-			return errors.Wrap(err, "failed to create stdin pipe")
+	go func() {
+		if _, err := io.Copy(conn, stream); err != nil {
+			log.Streaming.WithError(err).Errorf("Failed to copy port forward input for %q port %d", id, port)
 		}
-		go func() {
-			if _, err := io.Copy(in, stream); err != nil {
-				logrus.WithError(err).Errorf("Failed to copy port forward input for %q port %d", id, port)
-			}
-			in.Close()
-			logrus.Debugf("Finish copying port forward input for %q port %d", id, port)
-		}()
-
-		if err := cmd.Run(); err != nil {
-			return errors.Errorf("socat command returns error: %v, stderr: %q", err, stderr.String())
+		// Half close the connection, so that the response to the input
+		// is still forwarded.
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			tcpConn.CloseWrite() // nolint: errcheck
 		}
-		return nil
-	})
-	if err != nil {
-		return errors.Wrapf(err, "failed to execute portforward in network namespace %q", netNSPath)
+		log.Streaming.Debugf("Finish copying port forward input for %q port %d", id, port)
+	}()
+	// The input copy stops when the stream is closed after return.
+	if _, err := io.Copy(stream, conn); err != nil {
+		return errors.Wrap(err, "failed to copy port forward output")
 	}
-	logrus.Infof("Finish port forwarding for %q port %d", id, port)
-
+	log.Streaming.Infof("Finish port forwarding for %q port %d", id, port)
 	return nil
 }
+
+// dialLocalhost connects to a port on localhost over IPv4, and falls back to
+// IPv6.
+func dialLocalhost(port int32) (net.Conn, error) {
+	conn, err := net.Dial("tcp4", fmt.Sprintf("127.0.0.1:%d", port))
+	if err == nil {
+		return conn, nil
+	}
+	conn, err6 := net.Dial("tcp6", fmt.Sprintf("[::1]:%d", port))
+	if err6 != nil {
+		return nil, errors.Errorf("ipv4: %v, ipv6: %v", err, err6)
+	}
+	return conn, nil
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialLocalhost(t *testing.T) {
+	for desc, addr := range map[string]string{
+		"should connect to ipv4 localhost": "127.0.0.1:0",
+		"should connect to ipv6 localhost": "[::1]:0",
+	} {
+		t.Logf("TestCase %q", desc)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Logf("Skip because listen on %q failed: %v", addr, err)
+			continue
+		}
+		port := l.Addr().(*net.TCPAddr).Port
+		conn, err := dialLocalhost(int32(port))
+		require.NoError(t, err)
+		assert.Equal(t, port, conn.RemoteAddr().(*net.TCPAddr).Port)
+		conn.Close()
+		l.Close()
+
+		t.Logf("should fail when nothing listens on the port")
+		_, err = dialLocalhost(int32(port))
+		assert.Error(t, err)
+	}
+}
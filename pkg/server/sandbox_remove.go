@@ -17,13 +17,17 @@ limitations under the License.
 package server
 
 import (
+	"fmt"
+
 	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/docker/docker/pkg/system"
+	"github.com/opencontainers/selinux/go-selinux/label"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
+	api "github.com/containerd/cri/pkg/api/v1"
 	"github.com/containerd/cri/pkg/log"
 	"github.com/containerd/cri/pkg/store"
 	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
@@ -38,8 +42,13 @@ func (c *criService) RemovePodSandbox(ctx context.Context, r *runtime.RemovePodS
 			return nil, errors.Wrapf(err, "an error occurred when try to find sandbox %q",
 				r.GetPodSandboxId())
 		}
+		// Finish the removal left by a previous attempt, whose metadata is
+		// already deleted.
+		if err := c.finishRemovalByTombstone(ctx, r.GetPodSandboxId()); err != nil {
+			return nil, err
+		}
 		// Do not return error if the id doesn't exist.
-		log.Tracef("RemovePodSandbox called for sandbox %q that does not exist",
+		log.Sandbox.Tracef("RemovePodSandbox called for sandbox %q that does not exist",
 			r.GetPodSandboxId())
 		return &runtime.RemovePodSandboxResponse{}, nil
 	}
@@ -72,6 +81,29 @@ func (c *criService) RemovePodSandbox(ctx context.Context, r *runtime.RemovePodS
 		}
 	}
 
+	// Record what is to be removed after the containers are removed, in case
+	// the removal is interrupted.
+	t, err := newTombstone(ctx, id, true, sandbox.Container)
+	if err != nil {
+		return nil, err
+	}
+	t.NoPauseContainer = sandbox.NoPauseContainer
+	if !sandbox.ExternalNetNS {
+		// An external network namespace is never removed.
+		t.NetNSPath = sandbox.NetNSPath
+	}
+	if err := c.writeTombstone(t); err != nil {
+		return nil, errors.Wrapf(err, "failed to write tombstone of sandbox %q", id)
+	}
+
+	// Remove the namespaces of a sandbox without a pause container, in case
+	// the sandbox was not stopped after a restart.
+	if sandbox.NoPauseContainer {
+		if err := c.unpinSandboxNamespaces(id); err != nil {
+			return nil, errors.Wrap(err, "failed to remove sandbox namespaces")
+		}
+	}
+
 	// Cleanup the sandbox root directories.
 	sandboxRootDir := c.getSandboxRootDir(id)
 	if err := system.EnsureRemoveAll(sandboxRootDir); err != nil {
@@ -89,18 +121,50 @@ func (c *criService) RemovePodSandbox(ctx context.Context, r *runtime.RemovePodS
 		if !errdefs.IsNotFound(err) {
 			return nil, errors.Wrapf(err, "failed to delete sandbox container %q", id)
 		}
-		log.Tracef("Remove called for sandbox container %q that does not exist", id)
+		log.Sandbox.Tracef("Remove called for sandbox container %q that does not exist", id)
 	}
 
+	// Remove the pod level cgroup after the sandbox container is deleted.
+	// The cgroup may still be busy, e.g. when the kubelet manages the same
+	// cgroup, so a failure is retried in the background instead of failing
+	// the removal of an otherwise removed sandbox.
+	c.rollback(fmt.Sprintf("remove pod cgroup of sandbox %q", id), func() error {
+		return c.removePodCgroup(sandbox)
+	})
+
 	// Remove sandbox from sandbox store. Note that once the sandbox is successfully
 	// deleted:
 	// 1) ListPodSandbox will not include this sandbox.
 	// 2) PodSandboxStatus and StopPodSandbox will return error.
 	// 3) On-going operations which have held the reference will not be affected.
+	if err := c.removeNetNSRecord(id); err != nil {
+		log.Sandbox.WithError(err).Errorf("Failed to remove network namespace record of sandbox %q", id)
+	}
+	if err := c.removeTombstone(id); err != nil {
+		log.Sandbox.WithError(err).Errorf("Failed to remove tombstone of sandbox %q", id)
+	}
+
 	c.sandboxStore.Delete(id)
 
 	// Release the sandbox name reserved for the sandbox.
 	c.sandboxNameIndex.ReleaseByKey(id)
 
+	c.containerEvents.publish(id, id, api.ContainerEventType_CONTAINER_DELETED_EVENT)
+
+	// Release the SELinux label reserved for the sandbox.
+	if err := label.ReleaseLabel(sandbox.ProcessLabel); err != nil {
+		log.Sandbox.WithError(err).Errorf("Failed to release selinux label %q of sandbox %q", sandbox.ProcessLabel, id)
+	}
+
+	// Release the user namespace ids allocated to the sandbox.
+	c.releaseUserNamespace(sandbox.UserNamespace)
+
+	c.specHooks.notify(ctx, specHookRequest{
+		Event:       specHookRemoveSandbox,
+		ID:          id,
+		SandboxID:   id,
+		Labels:      sandbox.Config.GetLabels(),
+		Annotations: sandbox.Config.GetAnnotations(),
+	})
 	return &runtime.RemovePodSandboxResponse{}, nil
 }
@@ -30,16 +30,17 @@ import (
 	"github.com/containerd/typeurl"
 	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/selinux/go-selinux/label"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/status"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
 	"github.com/containerd/cri/pkg/annotations"
+	api "github.com/containerd/cri/pkg/api/v1"
 	criconfig "github.com/containerd/cri/pkg/config"
 	customopts "github.com/containerd/cri/pkg/containerd/opts"
-	ctrdutil "github.com/containerd/cri/pkg/containerd/util"
 	"github.com/containerd/cri/pkg/log"
 	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
 	"github.com/containerd/cri/pkg/util"
@@ -58,7 +59,7 @@ func (c *criService) RunPodSandbox(ctx context.Context, r *runtime.RunPodSandbox
 	// Generate unique id and name for the sandbox and reserve the name.
 	id := util.GenerateID()
 	name := makeSandboxName(config.GetMetadata())
-	logrus.Debugf("Generated id %q for sandbox %q", id, name)
+	log.Sandbox.Debugf("Generated id %q for sandbox %q", id, name)
 	// Reserve the sandbox name to avoid concurrent `RunPodSandbox` request starting the
 	// same sandbox.
 	if err := c.sandboxNameIndex.Reserve(name, id); err != nil {
@@ -71,27 +72,145 @@ func (c *criService) RunPodSandbox(ctx context.Context, r *runtime.RunPodSandbox
 		}
 	}()
 
+	runtimeHandler := config.GetAnnotations()[annotations.RuntimeHandler]
+	// Validate the log format early, containers of the sandbox use it.
+	if _, err := c.getContainerLogFormat(config); err != nil {
+		return nil, err
+	}
+	// Validate sysctls before any resource is created, the error code tells
+	// kubelet the pod is rejected.
+	if err := c.validateSandboxSysctls(config); err != nil {
+		return nil, err
+	}
+	if s, ok := config.GetAnnotations()[annotations.StopSignals]; ok {
+		if _, err := parseStopSignals(s); err != nil {
+			return nil, errors.Wrapf(err, "invalid %q annotation", annotations.StopSignals)
+		}
+	}
+	if _, err := getHostAliases(config); err != nil {
+		return nil, err
+	}
+	if err := c.validateSandboxAppArmorProfiles(config); err != nil {
+		return nil, errors.Wrap(err, "failed to validate apparmor profiles")
+	}
+	if _, err := getSandboxShmSize(config); err != nil {
+		return nil, errors.Wrapf(err, "invalid %q annotation", annotations.ShmSize)
+	}
+	externalNetNSPath, err := c.getExternalNetNSPath(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %q annotation", annotations.NetNSPath)
+	}
+
+	ociRuntime, err := c.getSandboxRuntime(config, runtimeHandler)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get sandbox runtime")
+	}
+	// Record the cgroup driver with the sandbox, so that its pod cgroup is
+	// updated and removed with the driver it was created with, even if the
+	// plugin level option changes.
+	systemdCgroup := c.runtimeSystemdCgroup(ociRuntime)
+	ociRuntime.SystemdCgroup = &systemdCgroup
+	log.Sandbox.Debugf("Use OCI %+v for sandbox %q", ociRuntime, id)
+	noPause := noPauseSandbox(ociRuntime)
+	if noPause && c.sharesPodPIDNamespace(config) {
+		// A pid namespace can't outlive its init process, so the pause
+		// container is kept to hold the shared pod pid namespace.
+		log.Sandbox.Debugf("Run sandbox %q with a pause container to share the pod pid namespace", id)
+		noPause = false
+	}
+	if noPause {
+		if err := validateNoPauseSandbox(config); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create initial internal sandbox object.
 	sandbox := sandboxstore.NewSandbox(
 		sandboxstore.Metadata{
-			ID:     id,
-			Name:   name,
-			Config: config,
+			ID:               id,
+			Name:             name,
+			Config:           config,
+			RuntimeHandler:   runtimeHandler,
+			Runtime:          &ociRuntime,
+			NoPauseContainer: noPause,
 		},
 		sandboxstore.Status{
 			State: sandboxstore.StateUnknown,
 		},
 	)
+	securityContext := config.GetLinux().GetSecurityContext()
+	if userNamespaceEnabled(config) {
+		if securityContext.GetPrivileged() {
+			return nil, errors.New("privileged sandbox is not supported in user namespace")
+		}
+		userns, err := c.allocateUserNamespace()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to allocate user namespace")
+		}
+		sandbox.UserNamespace = userns
+		defer func() {
+			if retErr != nil {
+				c.releaseUserNamespace(userns)
+			}
+		}()
+	}
 
-	// Ensure sandbox container image snapshot.
-	image, err := c.ensureImageExists(ctx, c.config.SandboxImage)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get sandbox image %q", c.config.SandboxImage)
+	// A sandbox without a pause container has no image or snapshot, its
+	// containerd container is only used to checkpoint the metadata.
+	var (
+		imageConfig *imagespec.ImageConfig
+		opts        []containerd.NewContainerOpts
+	)
+	if !noPause {
+		// Ensure sandbox container image snapshot.
+		imageCtx, imageCancel := withPhaseTimeout(ctx, c.timeouts.imageResolve)
+		_, span := startSpan(imageCtx, "sandbox image ensure")
+		sandboxImage := c.reloadableConfig().SandboxImage
+		image, err := c.ensureImageExists(imageCtx, sandboxImage)
+		span.end(err)
+		imageCancel()
+		if err != nil {
+			return nil, phaseError(imageCtx, err, "failed to get sandbox image %q", sandboxImage)
+		}
+		imageConfig = &image.ImageSpec.Config
+
+		snapshotter := c.runtimeSnapshotter(ociRuntime)
+		if err := c.ensureImageUnpacked(ctx, image.Image, snapshotter); err != nil {
+			return nil, errors.Wrapf(err, "failed to unpack sandbox image %q", sandboxImage)
+		}
+		snapshotOpt := customopts.WithNewSnapshot(id, image.Image)
+		if sandbox.UserNamespace != nil {
+			uid, gid, err := getRemappedRoot(sandbox.UserNamespace)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to get remapped root")
+			}
+			snapshotOpt = customopts.WithRemappedSnapshot(id, image.Image, uid, gid)
+		}
+		opts = append(opts, containerd.WithSnapshotter(snapshotter), snapshotOpt)
 	}
-	securityContext := config.GetLinux().GetSecurityContext()
+
 	//Create Network Namespace if it is not in host network
 	hostNet := securityContext.GetNamespaceOptions().GetNetwork() == runtime.NamespaceMode_NODE
-	if !hostNet {
+	if externalNetNSPath != "" {
+		// The network of an external network namespace is managed by the
+		// external network agent, only the pod ips are read from it.
+		sandbox.NetNS, err = sandboxstore.LoadExternalNetNS(externalNetNSPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load external network namespace %q", externalNetNSPath)
+		}
+		sandbox.NetNSPath, sandbox.ExternalNetNS = externalNetNSPath, true
+		defer func() {
+			if retErr != nil {
+				netNS := sandbox.NetNS
+				c.rollback(fmt.Sprintf("close network namespace %s for sandbox %q", sandbox.NetNSPath, id), netNS.Remove)
+				sandbox.NetNSPath = ""
+			}
+		}()
+		sandbox.IP, sandbox.AdditionalIPs, err = getNetNSPodIPs(sandbox.NetNS)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get pod ips of external network namespace %q", externalNetNSPath)
+		}
+	} else if !hostNet {
 		// If it is not in host network namespace then create a namespace and set the sandbox
 		// handle. NetNSPath in sandbox metadata and NetNS is non empty only for non host network
 		// namespaces. If the pod is in host network namespace then both are empty and should not
@@ -101,14 +220,26 @@ func (c *criService) RunPodSandbox(ctx context.Context, r *runtime.RunPodSandbox
 			return nil, errors.Wrapf(err, "failed to create network namespace for sandbox %q", id)
 		}
 		sandbox.NetNSPath = sandbox.NetNS.GetPath()
+		// netNSCleanups are the rollbacks of the network set up in the
+		// network namespace, which must be finished before it is removed.
+		var netNSCleanups []<-chan struct{}
 		defer func() {
 			if retErr != nil {
-				if err := sandbox.NetNS.Remove(); err != nil {
-					logrus.WithError(err).Errorf("Failed to remove network namespace %s for sandbox %q", sandbox.NetNSPath, id)
-				}
+				netNS := sandbox.NetNS
+				c.rollbackAfter(netNSCleanups, fmt.Sprintf("remove network namespace %s for sandbox %q", sandbox.NetNSPath, id), func() error {
+					if err := netNS.Remove(); err != nil {
+						return err
+					}
+					return c.removeNetNSRecord(id)
+				})
 				sandbox.NetNSPath = ""
 			}
 		}()
+		// Record the network namespace before the network is set up, so that
+		// orphan gc can remove it if the sandbox is never created.
+		if err := c.writeNetNSRecord(netNSRecord{ID: id, NetNSPath: sandbox.NetNSPath, Config: config}); err != nil {
+			return nil, errors.Wrapf(err, "failed to record network namespace of sandbox %q", id)
+		}
 		// Setup network for sandbox.
 		// Certain VM based solutions like clear containers (Issue containerd/cri-containerd#524)
 		// rely on the assumption that CRI shim will not be querying the network namespace to check the
@@ -117,32 +248,127 @@ func (c *criService) RunPodSandbox(ctx context.Context, r *runtime.RunPodSandbox
 		// In this case however caching the IP will add a subtle performance enhancement by avoiding
 		// calls to network namespace of the pod to query the IP of the veth interface on every
 		// SandboxStatus request.
-		sandbox.IP, err = c.setupPod(id, sandbox.NetNSPath, config)
+		// CNI plugins can't be cancelled, the network set up after the
+		// timeout is torn down once the plugin returns.
+		cniCtx, cniCancel := withPhaseTimeout(ctx, c.timeouts.cniSetup)
+		defer cniCancel()
+		netNSPath := sandbox.NetNSPath
+		var (
+			result      *cni.CNIResult
+			cniNetworks []sandboxstore.CNINetwork
+		)
+		_, span := startSpan(ctx, "cni setup")
+		setupDone, err := waitWithContext(cniCtx, func() (err error) {
+			result, cniNetworks, err = c.setupPod(id, netNSPath, config)
+			return err
+		})
+		span.end(err)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to setup network for sandbox %q", id)
+			netNSCleanups = append(netNSCleanups, c.afterTimedOut(setupDone, fmt.Sprintf("destroy network for sandbox %q", id), func() error {
+				return c.teardownPod(id, netNSPath, config, result, cniNetworks)
+			}))
+			return nil, phaseError(cniCtx, err, "failed to setup network for sandbox %q", id)
 		}
+		sandbox.IP, sandbox.AdditionalIPs = selectPodIPs(result.Interfaces[defaultIfName].IPConfigs)
+		sandbox.CNIResult, sandbox.CNINetworks = result, cniNetworks
 		defer func() {
 			if retErr != nil {
-				// Teardown network if an error is returned.
-				if err := c.teardownPod(id, sandbox.NetNSPath, config); err != nil {
-					logrus.WithError(err).Errorf("Failed to destroy network for sandbox %q", id)
-				}
+				// Teardown network if an error is returned, which releases
+				// the pod IPs.
+				netNSPath := sandbox.NetNSPath
+				netNSCleanups = append(netNSCleanups, c.rollback(fmt.Sprintf("destroy network for sandbox %q", id), func() error {
+					return c.teardownPod(id, netNSPath, config, result, cniNetworks)
+				}))
+			}
+		}()
+		// Attach the sandbox to the additional networks it requests.
+		var networks []sandboxstore.NetworkAttachment
+		_, span = startSpan(ctx, "additional networks setup")
+		setupDone, err = waitWithContext(cniCtx, func() (err error) {
+			networks, err = c.setupAdditionalNetworks(id, netNSPath, config)
+			return err
+		})
+		span.end(err)
+		if err != nil {
+			netNSCleanups = append(netNSCleanups, c.afterTimedOut(setupDone, fmt.Sprintf("destroy additional networks for sandbox %q", id), func() error {
+				return c.teardownAdditionalNetworks(id, netNSPath, config, networks)
+			}))
+			return nil, phaseError(cniCtx, err, "failed to setup additional networks for sandbox %q", id)
+		}
+		sandbox.AdditionalNetworks = networks
+		defer func() {
+			if retErr != nil {
+				netNSPath, networks := sandbox.NetNSPath, sandbox.AdditionalNetworks
+				netNSCleanups = append(netNSCleanups, c.rollback(fmt.Sprintf("destroy additional networks for sandbox %q", id), func() error {
+					return c.teardownAdditionalNetworks(id, netNSPath, config, networks)
+				}))
+			}
+		}()
+		if err := c.writeNetNSRecord(netNSRecord{
+			ID:                 id,
+			NetNSPath:          netNSPath,
+			Config:             config,
+			CNIResult:          result,
+			CNINetworks:        cniNetworks,
+			AdditionalNetworks: networks,
+		}); err != nil {
+			return nil, errors.Wrapf(err, "failed to record networks of sandbox %q", id)
+		}
+		// Program the host ports if no cni plugin handles port mappings.
+		sandbox.HostPortManaged, err = c.setupHostPorts(id, sandbox.IP, config)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to setup host ports for sandbox %q", id)
+		}
+		defer func() {
+			if retErr != nil {
+				sb := sandbox
+				c.rollback(fmt.Sprintf("remove host ports for sandbox %q", id), func() error {
+					return c.teardownHostPorts(sb)
+				})
 			}
 		}()
 	}
 
-	ociRuntime, err := c.getSandboxRuntime(config)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get sandbox runtime")
+	// Create the pod level cgroup before the sandbox container is placed in it.
+	if err := c.createPodCgroup(config.GetLinux().GetCgroupParent(), ociRuntime); err != nil {
+		return nil, errors.Wrapf(err, "failed to create pod cgroup %q", config.GetLinux().GetCgroupParent())
 	}
-	logrus.Debugf("Use OCI %+v for sandbox %q", ociRuntime, id)
+	defer func() {
+		if retErr != nil {
+			sb := sandbox
+			c.rollback(fmt.Sprintf("remove pod cgroup of sandbox %q", id), func() error {
+				return c.removePodCgroup(sb)
+			})
+		}
+	}()
 
 	// Create sandbox container.
-	spec, err := c.generateSandboxContainerSpec(id, config, &image.ImageSpec.Config, sandbox.NetNSPath)
+	spec, err := c.generateSandboxContainerSpec(id, config, imageConfig, sandbox.NetNSPath, ociRuntime, sandbox.UserNamespace)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to generate sandbox container spec")
 	}
-	logrus.Debugf("Sandbox container spec: %+v", spec)
+	spec, err = c.specHooks.adjustSpec(ctx, specHookRequest{
+		Event:       specHookCreateSandbox,
+		ID:          id,
+		SandboxID:   id,
+		Labels:      config.GetLabels(),
+		Annotations: config.GetAnnotations(),
+		Spec:        spec,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to adjust sandbox container spec")
+	}
+	log.Sandbox.Debugf("Sandbox container spec: %+v", spec)
+
+	sandbox.ProcessLabel = spec.Process.SelinuxLabel
+	defer func() {
+		if retErr != nil {
+			// Release the SELinux label reserved for the sandbox.
+			if err := label.ReleaseLabel(sandbox.ProcessLabel); err != nil {
+				log.Sandbox.WithError(err).Errorf("Failed to release selinux label %q", sandbox.ProcessLabel)
+			}
+		}
+	}()
 
 	var specOpts []oci.SpecOpts
 	userstr, err := generateUserString(
@@ -157,7 +383,7 @@ func (c *criService) RunPodSandbox(ctx context.Context, r *runtime.RunPodSandbox
 		specOpts = append(specOpts, oci.WithUser(userstr))
 	}
 
-	seccompSpecOpts, err := generateSeccompSpecOpts(
+	seccompSpecOpts, err := c.generateSeccompSpecOpts(
 		securityContext.GetSeccompProfilePath(),
 		securityContext.GetPrivileged(),
 		c.seccompEnabled)
@@ -170,9 +396,7 @@ func (c *criService) RunPodSandbox(ctx context.Context, r *runtime.RunPodSandbox
 
 	sandboxLabels := buildLabels(config.Labels, containerKindSandbox)
 
-	opts := []containerd.NewContainerOpts{
-		containerd.WithSnapshotter(c.config.ContainerdConfig.Snapshotter),
-		customopts.WithNewSnapshot(id, image.Image),
+	opts = append(opts,
 		containerd.WithSpec(spec, specOpts...),
 		containerd.WithContainerLabels(sandboxLabels),
 		containerd.WithContainerExtension(sandboxMetadataExtension, &sandbox.Metadata),
@@ -181,19 +405,26 @@ func (c *criService) RunPodSandbox(ctx context.Context, r *runtime.RunPodSandbox
 			&runctypes.RuncOptions{
 				Runtime:       ociRuntime.Engine,
 				RuntimeRoot:   ociRuntime.Root,
-				SystemdCgroup: c.config.SystemdCgroup})} // TODO (mikebrow): add CriuPath when we add support for pause
+				SystemdCgroup: c.runtimeSystemdCgroup(ociRuntime)})) // TODO (mikebrow): add CriuPath when we add support for pause
 
-	container, err := c.client.NewContainer(ctx, id, opts...)
+	snapshotCtx, snapshotCancel := withPhaseTimeout(ctx, c.timeouts.snapshotPrepare)
+	_, span := startSpan(snapshotCtx, "snapshot prepare")
+	container, err := c.client.NewContainer(snapshotCtx, id, opts...)
+	span.end(err)
+	snapshotCancel()
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create containerd container")
+		return nil, phaseError(snapshotCtx, err, "failed to create containerd container")
 	}
 	defer func() {
 		if retErr != nil {
-			deferCtx, deferCancel := ctrdutil.DeferContext()
-			defer deferCancel()
-			if err := container.Delete(deferCtx, containerd.WithSnapshotCleanup); err != nil {
-				logrus.WithError(err).Errorf("Failed to delete containerd container %q", id)
-			}
+			c.rollback(fmt.Sprintf("delete containerd container %q", id), func() error {
+				deferCtx, deferCancel := c.deferContext()
+				defer deferCancel()
+				if err := container.Delete(deferCtx, containerd.WithSnapshotCleanup); err != nil && !errdefs.IsNotFound(err) {
+					return err
+				}
+				return nil
+			})
 		}
 	}()
 
@@ -203,13 +434,16 @@ func (c *criService) RunPodSandbox(ctx context.Context, r *runtime.RunPodSandbox
 		return nil, errors.Wrapf(err, "failed to create sandbox root directory %q",
 			sandboxRootDir)
 	}
+	// sandboxFilesCleanups are the rollbacks of the sandbox files mounted in
+	// the sandbox root directories, which must be finished before they are
+	// removed.
+	var sandboxFilesCleanups []<-chan struct{}
 	defer func() {
 		if retErr != nil {
 			// Cleanup the sandbox root directory.
-			if err := c.os.RemoveAll(sandboxRootDir); err != nil {
-				logrus.WithError(err).Errorf("Failed to remove sandbox root directory %q",
-					sandboxRootDir)
-			}
+			c.rollbackAfter(sandboxFilesCleanups, fmt.Sprintf("remove sandbox root directory %q", sandboxRootDir), func() error {
+				return c.os.RemoveAll(sandboxRootDir)
+			})
 		}
 	}()
 	volatileSandboxRootDir := c.getVolatileSandboxRootDir(id)
@@ -220,26 +454,38 @@ func (c *criService) RunPodSandbox(ctx context.Context, r *runtime.RunPodSandbox
 	defer func() {
 		if retErr != nil {
 			// Cleanup the volatile sandbox root directory.
-			if err := c.os.RemoveAll(volatileSandboxRootDir); err != nil {
-				logrus.WithError(err).Errorf("Failed to remove volatile sandbox root directory %q",
-					volatileSandboxRootDir)
-			}
+			c.rollbackAfter(sandboxFilesCleanups, fmt.Sprintf("remove volatile sandbox root directory %q", volatileSandboxRootDir), func() error {
+				return c.os.RemoveAll(volatileSandboxRootDir)
+			})
 		}
 	}()
 
 	// Setup sandbox /dev/shm, /etc/hosts and /etc/resolv.conf.
-	if err = c.setupSandboxFiles(id, config); err != nil {
+	if err = c.setupSandboxFiles(id, sandbox.IP, config); err != nil {
 		return nil, errors.Wrapf(err, "failed to setup sandbox files")
 	}
 	defer func() {
 		if retErr != nil {
-			if err = c.unmountSandboxFiles(id, config); err != nil {
-				logrus.WithError(err).Errorf("Failed to unmount sandbox files in %q",
-					sandboxRootDir)
-			}
+			sandboxFilesCleanups = append(sandboxFilesCleanups, c.rollback(fmt.Sprintf("unmount sandbox files in %q", sandboxRootDir), func() error {
+				return c.unmountSandboxFiles(id, config)
+			}))
 		}
 	}()
 
+	// Create the sandbox namespaces held by the pause container otherwise.
+	if noPause {
+		if err := c.pinSandboxNamespaces(id, config, sandbox.NetNSPath); err != nil {
+			return nil, errors.Wrap(err, "failed to create sandbox namespaces")
+		}
+		defer func() {
+			if retErr != nil {
+				c.rollback(fmt.Sprintf("remove namespaces of sandbox %q", id), func() error {
+					return c.unpinSandboxNamespaces(id)
+				})
+			}
+		}()
+	}
+
 	// Update sandbox created timestamp.
 	info, err := container.Info(ctx)
 	if err != nil {
@@ -290,28 +536,44 @@ func (c *criService) RunPodSandbox(ctx context.Context, r *runtime.RunPodSandbox
 		// Given so, we should keep the sandbox in UNKNOWN state if `Update` fails,
 		// and ignore sandbox in UNKNOWN state in all the inspection functions.
 
+		// There is no task to start without a pause container.
+		if noPause {
+			status.State = sandboxstore.StateReady
+			return status, nil
+		}
+
 		// Create sandbox task in containerd.
-		log.Tracef("Create sandbox container (id=%q, name=%q).",
+		log.Sandbox.Tracef("Create sandbox container (id=%q, name=%q).",
 			id, name)
+		taskCtx, taskCancel := withPhaseTimeout(ctx, c.timeouts.taskStart)
+		defer taskCancel()
 		// We don't need stdio for sandbox container.
-		task, err := container.NewTask(ctx, containerdio.NullIO)
+		_, span := startSpan(taskCtx, "task create")
+		task, err := container.NewTask(taskCtx, containerdio.NullIO)
+		span.end(err)
 		if err != nil {
-			return status, errors.Wrap(err, "failed to create containerd task")
+			return status, phaseError(taskCtx, err, "failed to create containerd task")
 		}
 		defer func() {
 			if retErr != nil {
-				deferCtx, deferCancel := ctrdutil.DeferContext()
-				defer deferCancel()
 				// Cleanup the sandbox container if an error is returned.
 				// It's possible that task is deleted by event monitor.
-				if _, err := task.Delete(deferCtx, containerd.WithProcessKill); err != nil && !errdefs.IsNotFound(err) {
-					logrus.WithError(err).Errorf("Failed to delete sandbox container %q", id)
-				}
+				c.rollback(fmt.Sprintf("delete sandbox container %q", id), func() error {
+					deferCtx, deferCancel := c.deferContext()
+					defer deferCancel()
+					if _, err := task.Delete(deferCtx, containerd.WithProcessKill); err != nil && !errdefs.IsNotFound(err) {
+						return err
+					}
+					return nil
+				})
 			}
 		}()
 
-		if err := task.Start(ctx); err != nil {
-			return status, errors.Wrapf(err, "failed to start sandbox container task %q", id)
+		_, span = startSpan(taskCtx, "task start")
+		err = task.Start(taskCtx)
+		span.end(err)
+		if err != nil {
+			return status, phaseError(taskCtx, err, "failed to start sandbox container task %q", id)
 		}
 
 		// Set the pod sandbox as ready after successfully start sandbox container.
@@ -319,14 +581,22 @@ func (c *criService) RunPodSandbox(ctx context.Context, r *runtime.RunPodSandbox
 		status.State = sandboxstore.StateReady
 		return status, nil
 	}); err != nil {
+		if _, ok := status.FromError(err); ok {
+			// Keep the grpc code of a timed out phase.
+			return nil, err
+		}
 		return nil, errors.Wrap(err, "failed to start sandbox container")
 	}
 
+	// The sandbox is only visible after it is started, so both events are
+	// published at once.
+	c.containerEvents.publish(id, id, api.ContainerEventType_CONTAINER_CREATED_EVENT)
+	c.containerEvents.publish(id, id, api.ContainerEventType_CONTAINER_STARTED_EVENT)
 	return &runtime.RunPodSandboxResponse{PodSandboxId: id}, nil
 }
 
 func (c *criService) generateSandboxContainerSpec(id string, config *runtime.PodSandboxConfig,
-	imageConfig *imagespec.ImageConfig, nsPath string) (*runtimespec.Spec, error) {
+	imageConfig *imagespec.ImageConfig, nsPath string, ociRuntime criconfig.Runtime, userns *sandboxstore.UserNamespace) (*runtimespec.Spec, error) {
 	// Creates a spec Generator with the default spec.
 	// TODO(random-liu): [P1] Compare the default settings with docker and containerd default.
 	spec, err := defaultRuntimeSpec(id)
@@ -335,21 +605,25 @@ func (c *criService) generateSandboxContainerSpec(id string, config *runtime.Pod
 	}
 	g := newSpecGenerator(spec)
 
-	// Apply default config from image config.
-	if err := addImageEnvs(&g, imageConfig.Env); err != nil {
-		return nil, err
-	}
+	// The image config is nil for a sandbox without a pause container, whose
+	// spec is never run.
+	if imageConfig != nil {
+		// Apply default config from image config.
+		if err := addImageEnvs(&g, imageConfig.Env); err != nil {
+			return nil, err
+		}
 
-	if imageConfig.WorkingDir != "" {
-		g.SetProcessCwd(imageConfig.WorkingDir)
-	}
+		if imageConfig.WorkingDir != "" {
+			g.SetProcessCwd(imageConfig.WorkingDir)
+		}
 
-	if len(imageConfig.Entrypoint) == 0 && len(imageConfig.Cmd) == 0 {
-		// Pause image must have entrypoint or cmd.
-		return nil, errors.Errorf("invalid empty entrypoint and cmd in image config %+v", imageConfig)
+		if len(imageConfig.Entrypoint) == 0 && len(imageConfig.Cmd) == 0 {
+			// Pause image must have entrypoint or cmd.
+			return nil, errors.Errorf("invalid empty entrypoint and cmd in image config %+v", imageConfig)
+		}
+		// Set process commands.
+		g.SetProcessArgs(append(imageConfig.Entrypoint, imageConfig.Cmd...))
 	}
-	// Set process commands.
-	g.SetProcessArgs(append(imageConfig.Entrypoint, imageConfig.Cmd...))
 
 	// Set relative root path.
 	g.SetRootPath(relativeRootfsPath)
@@ -365,7 +639,7 @@ func (c *criService) generateSandboxContainerSpec(id string, config *runtime.Pod
 	// Set cgroups parent.
 	if config.GetLinux().GetCgroupParent() != "" {
 		cgroupsPath := getCgroupsPath(config.GetLinux().GetCgroupParent(), id,
-			c.config.SystemdCgroup)
+			c.runtimeSystemdCgroup(ociRuntime))
 		g.SetLinuxCgroupsPath(cgroupsPath)
 	}
 	// When cgroup parent is not set, containerd-shim will create container in a child cgroup
@@ -387,6 +661,9 @@ func (c *criService) generateSandboxContainerSpec(id string, config *runtime.Pod
 	if nsOptions.GetIpc() == runtime.NamespaceMode_NODE {
 		g.RemoveLinuxNamespace(string(runtimespec.IPCNamespace)) // nolint: errcheck
 	}
+	if userns != nil {
+		setOCIUserNamespace(&g, "", userns)
+	}
 
 	// It's fine to generate the spec before the sandbox /dev/shm
 	// is actually created.
@@ -425,6 +702,8 @@ func (c *criService) generateSandboxContainerSpec(id string, config *runtime.Pod
 	g.SetLinuxResourcesCPUShares(uint64(defaultSandboxCPUshares))
 	g.SetProcessOOMScoreAdj(int(defaultSandboxOOMAdj))
 
+	addSpecAnnotations(&g, config.GetAnnotations(), ociRuntime.PodAnnotations)
+	setOCIRuntimeOptions(&g, ociRuntime)
 	g.AddAnnotation(annotations.ContainerType, annotations.ContainerTypeSandbox)
 	g.AddAnnotation(annotations.SandboxID, id)
 
@@ -433,11 +712,9 @@ func (c *criService) generateSandboxContainerSpec(id string, config *runtime.Pod
 
 // setupSandboxFiles sets up necessary sandbox files including /dev/shm, /etc/hosts
 // and /etc/resolv.conf.
-func (c *criService) setupSandboxFiles(id string, config *runtime.PodSandboxConfig) error {
-	// TODO(random-liu): Consider whether we should maintain /etc/hosts and /etc/resolv.conf in kubelet.
-	sandboxEtcHosts := c.getSandboxHosts(id)
-	if err := c.os.CopyFile(etcHosts, sandboxEtcHosts, 0644); err != nil {
-		return errors.Wrapf(err, "failed to generate sandbox hosts file %q", sandboxEtcHosts)
+func (c *criService) setupSandboxFiles(id, ip string, config *runtime.PodSandboxConfig) error {
+	if err := c.setupSandboxHosts(id, ip, config); err != nil {
+		return err
 	}
 
 	// Set DNS options. Maintain a resolv.conf for the sandbox.
@@ -469,11 +746,15 @@ func (c *criService) setupSandboxFiles(id string, config *runtime.PodSandboxConf
 			return errors.Wrapf(err, "host %q is not available for host ipc", devShm)
 		}
 	} else {
+		shmSize, err := getSandboxShmSize(config)
+		if err != nil {
+			return errors.Wrapf(err, "invalid %q annotation", annotations.ShmSize)
+		}
 		sandboxDevShm := c.getSandboxDevShm(id)
 		if err := c.os.MkdirAll(sandboxDevShm, 0700); err != nil {
 			return errors.Wrap(err, "failed to create sandbox shm")
 		}
-		shmproperty := fmt.Sprintf("mode=1777,size=%d", defaultShmSize)
+		shmproperty := fmt.Sprintf("mode=1777,size=%d", shmSize)
 		if err := c.os.Mount("shm", sandboxDevShm, "tmpfs", uintptr(unix.MS_NOEXEC|unix.MS_NOSUID|unix.MS_NODEV), shmproperty); err != nil {
 			return errors.Wrap(err, "failed to mount sandbox shm")
 		}
@@ -521,29 +802,43 @@ func (c *criService) unmountSandboxFiles(id string, config *runtime.PodSandboxCo
 	return nil
 }
 
-// setupPod setups up the network for a pod
-func (c *criService) setupPod(id string, path string, config *runtime.PodSandboxConfig) (string, error) {
+// setupPod setups up the network for a pod, and returns the cni result with
+// the ips of the default interface, and the networks of the setup with their
+// results. The networks are the ones of the loaded cni config. On failure,
+// the networks set up are torn down.
+func (c *criService) setupPod(id string, path string, config *runtime.PodSandboxConfig) (_ *cni.CNIResult, _ []sandboxstore.CNINetwork, retErr error) {
 	if c.netPlugin == nil {
-		return "", errors.New("cni config not intialized")
+		return nil, nil, errors.New("cni config not intialized")
 	}
 
-	labels := getPodCNILabels(id, config)
-	result, err := c.netPlugin.Setup(id,
-		path,
-		cni.WithLabels(labels),
-		cni.WithCapabilityPortMap(toCNIPortMappings(config.GetPortMappings())))
+	podNetworks, err := c.cniNetConfMonitor.podNetworks()
 	if err != nil {
-		return "", err
+		return nil, nil, errors.Wrap(err, "failed to load cni networks")
 	}
-	// Check if the default interface has IP config
-	if configs, ok := result.Interfaces[defaultIfName]; ok && len(configs.IPConfigs) > 0 {
-		return selectPodIP(configs.IPConfigs), nil
+	defer func() {
+		if retErr != nil {
+			if err := c.teardownPodNetworks(id, path, config, nil, podNetworks); err != nil {
+				log.Sandbox.WithError(err).Errorf("Failed to destroy network for sandbox %q", id)
+			}
+		}
+	}()
+	podNetworks, err = c.setupPodNetworks(id, path, config, podNetworks)
+	if err != nil {
+		return nil, nil, err
+	}
+	networks, err := toCNINetworks(podNetworks)
+	if err != nil {
+		return nil, nil, err
 	}
-	// If it comes here then the result was invalid so destroy the pod network and return error
-	if err := c.teardownPod(id, path, config); err != nil {
-		logrus.WithError(err).Errorf("Failed to destroy network for sandbox %q", id)
+	result, err := toCNIResult(podNetworks)
+	if err != nil {
+		return nil, nil, err
 	}
-	return "", errors.Errorf("failed to find network info for sandbox %q", id)
+	// Check if the default interface has IP config
+	if len(result.Interfaces[defaultIfName].IPConfigs) == 0 {
+		return nil, nil, errors.Errorf("failed to find network info for sandbox %q", id)
+	}
+	return result, networks, nil
 }
 
 // toCNIPortMappings converts CRI port mappings to CNI.
@@ -563,14 +858,23 @@ func toCNIPortMappings(criPortMappings []*runtime.PortMapping) []cni.PortMapping
 	return portMappings
 }
 
-// selectPodIP select an ip from the ip list. It prefers ipv4 more than ipv6.
-func selectPodIP(ipConfigs []*cni.IPConfig) string {
-	for _, c := range ipConfigs {
+// selectPodIPs select an ip from the ip list as the pod ip, and returns the
+// others as additional ips. It prefers ipv4 more than ipv6.
+func selectPodIPs(ipConfigs []*cni.IPConfig) (string, []string) {
+	selected := 0
+	for i, c := range ipConfigs {
 		if c.IP.To4() != nil {
-			return c.IP.String()
+			selected = i
+			break
+		}
+	}
+	var additionalIPs []string
+	for i, c := range ipConfigs {
+		if i != selected {
+			additionalIPs = append(additionalIPs, c.IP.String())
 		}
 	}
-	return ipConfigs[0].IP.String()
+	return ipConfigs[selected].IP.String(), additionalIPs
 }
 
 // untrustedWorkload returns true if the sandbox contains untrusted workload.
@@ -598,14 +902,18 @@ func hostPrivilegedSandbox(config *runtime.PodSandboxConfig) bool {
 
 // getSandboxRuntime returns the runtime configuration for sandbox.
 // If the sandbox contains untrusted workload, runtime for untrusted workload will be returned,
-// or else default runtime will be returned.
-func (c *criService) getSandboxRuntime(config *runtime.PodSandboxConfig) (criconfig.Runtime, error) {
+// or else if a runtime handler is specified, the runtime configured for the handler will be
+// returned, or else default runtime will be returned.
+func (c *criService) getSandboxRuntime(config *runtime.PodSandboxConfig, runtimeHandler string) (criconfig.Runtime, error) {
 	untrusted := false
 	if untrustedWorkload(config) {
 		// TODO(random-liu): Figure out we should return error or not.
 		if hostPrivilegedSandbox(config) {
 			return criconfig.Runtime{}, errors.New("untrusted workload with host privilege is not allowed")
 		}
+		if runtimeHandler != "" {
+			return criconfig.Runtime{}, errors.New("untrusted workload with explicit runtime handler is not allowed")
+		}
 		untrusted = true
 	}
 
@@ -615,5 +923,39 @@ func (c *criService) getSandboxRuntime(config *runtime.PodSandboxConfig) (cricon
 		}
 		return c.config.ContainerdConfig.UntrustedWorkloadRuntime, nil
 	}
-	return c.config.ContainerdConfig.DefaultRuntime, nil
+	if runtimeHandler == "" {
+		return c.config.ContainerdConfig.DefaultRuntime, nil
+	}
+	handler, ok := c.config.ContainerdConfig.Runtimes[runtimeHandler]
+	if !ok {
+		return criconfig.Runtime{}, errors.Errorf("no runtime for %q is configured", runtimeHandler)
+	}
+	return handler, nil
+}
+
+// sandboxRuntime returns the runtime a sandbox was created with. The runtime
+// is resolved from the current config for sandboxes which didn't record it.
+func (c *criService) sandboxRuntime(sandbox sandboxstore.Sandbox) (criconfig.Runtime, error) {
+	if sandbox.Runtime != nil {
+		return *sandbox.Runtime, nil
+	}
+	return c.getSandboxRuntime(sandbox.Config, sandbox.RuntimeHandler)
+}
+
+// runtimeSnapshotter returns the snapshotter used for containers running with
+// a runtime.
+func (c *criService) runtimeSnapshotter(r criconfig.Runtime) string {
+	if r.Snapshotter == "" {
+		return c.config.ContainerdConfig.Snapshotter
+	}
+	return r.Snapshotter
+}
+
+// runtimeSystemdCgroup returns whether containers running with a runtime use
+// the systemd cgroup driver.
+func (c *criService) runtimeSystemdCgroup(r criconfig.Runtime) bool {
+	if r.SystemdCgroup == nil {
+		return c.config.SystemdCgroup
+	}
+	return *r.SystemdCgroup
 }
@@ -128,6 +128,23 @@ func TestGenerateSandboxContainerSpec(t *testing.T) {
 				})
 			},
 		},
+		"pod pid namespace": {
+			configChange: func(c *runtime.PodSandboxConfig) {
+				c.Linux.SecurityContext = &runtime.LinuxSandboxSecurityContext{
+					NamespaceOptions: &runtime.NamespaceOption{
+						Pid: runtime.NamespaceMode_POD,
+					},
+				}
+			},
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				// The sandbox container holds a new pid namespace, which
+				// containers of the pod join.
+				require.NotNil(t, spec.Linux)
+				assert.Contains(t, spec.Linux.Namespaces, runtimespec.LinuxNamespace{
+					Type: runtimespec.PIDNamespace,
+				})
+			},
+		},
 		"should return error when entrypoint and cmd are empty": {
 			imageConfigChange: func(c *imagespec.ImageConfig) {
 				c.Entrypoint = nil
@@ -164,7 +181,7 @@ func TestGenerateSandboxContainerSpec(t *testing.T) {
 		if test.imageConfigChange != nil {
 			test.imageConfigChange(imageConfig)
 		}
-		spec, err := c.generateSandboxContainerSpec(testID, config, imageConfig, nsPath)
+		spec, err := c.generateSandboxContainerSpec(testID, config, imageConfig, nsPath, criconfig.Runtime{}, nil)
 		if test.expectErr {
 			assert.Error(t, err)
 			assert.Nil(t, spec)
@@ -190,10 +207,10 @@ func TestSetupSandboxFiles(t *testing.T) {
 			ipcMode: runtime.NamespaceMode_NODE,
 			expectedCalls: []ostesting.CalledDetail{
 				{
-					Name: "CopyFile",
+					Name: "WriteFile",
 					Arguments: []interface{}{
-						"/etc/hosts",
 						filepath.Join(testRootDir, sandboxesDir, testID, "hosts"),
+						[]byte(managedHostsHeader + "10.10.10.10\ttest-host\n"),
 						os.FileMode(0644),
 					},
 				},
@@ -220,10 +237,10 @@ func TestSetupSandboxFiles(t *testing.T) {
 			ipcMode: runtime.NamespaceMode_NODE,
 			expectedCalls: []ostesting.CalledDetail{
 				{
-					Name: "CopyFile",
+					Name: "WriteFile",
 					Arguments: []interface{}{
-						"/etc/hosts",
 						filepath.Join(testRootDir, sandboxesDir, testID, "hosts"),
+						[]byte(managedHostsHeader + "10.10.10.10\ttest-host\n"),
 						os.FileMode(0644),
 					},
 				},
@@ -247,10 +264,10 @@ options timeout:1
 			ipcMode: runtime.NamespaceMode_POD,
 			expectedCalls: []ostesting.CalledDetail{
 				{
-					Name: "CopyFile",
+					Name: "WriteFile",
 					Arguments: []interface{}{
-						"/etc/hosts",
 						filepath.Join(testRootDir, sandboxesDir, testID, "hosts"),
+						[]byte(managedHostsHeader + "10.10.10.10\ttest-host\n"),
 						os.FileMode(0644),
 					},
 				},
@@ -279,6 +296,7 @@ options timeout:1
 		t.Logf("TestCase %q", desc)
 		c := newTestCRIService()
 		cfg := &runtime.PodSandboxConfig{
+			Hostname:  "test-host",
 			DnsConfig: test.dnsConfig,
 			Linux: &runtime.LinuxPodSandboxConfig{
 				SecurityContext: &runtime.LinuxSandboxSecurityContext{
@@ -288,7 +306,7 @@ options timeout:1
 				},
 			},
 		}
-		c.setupSandboxFiles(testID, cfg)
+		c.setupSandboxFiles(testID, "10.10.10.10", cfg)
 		calls := c.os.(*ostesting.FakeOS).GetCalls()
 		assert.Len(t, calls, len(test.expectedCalls))
 		for i, expected := range test.expectedCalls {
@@ -409,19 +427,26 @@ func TestToCNIPortMappings(t *testing.T) {
 	}
 }
 
-func TestSelectPodIP(t *testing.T) {
+func TestSelectPodIPs(t *testing.T) {
 	for desc, test := range map[string]struct {
-		ips      []string
-		expected string
+		ips                   []string
+		expected              string
+		expectedAdditionalIPs []string
 	}{
 		"ipv4 should be picked even if ipv6 comes first": {
-			ips:      []string{"2001:db8:85a3::8a2e:370:7334", "192.168.17.43"},
-			expected: "192.168.17.43",
+			ips:                   []string{"2001:db8:85a3::8a2e:370:7334", "192.168.17.43"},
+			expected:              "192.168.17.43",
+			expectedAdditionalIPs: []string{"2001:db8:85a3::8a2e:370:7334"},
 		},
 		"ipv6 should be picked when there is no ipv4": {
 			ips:      []string{"2001:db8:85a3::8a2e:370:7334"},
 			expected: "2001:db8:85a3::8a2e:370:7334",
 		},
+		"first ipv4 should be picked when there are multiple ipv4": {
+			ips:                   []string{"192.168.17.43", "2001:db8:85a3::8a2e:370:7334", "192.168.17.44"},
+			expected:              "192.168.17.43",
+			expectedAdditionalIPs: []string{"2001:db8:85a3::8a2e:370:7334", "192.168.17.44"},
+		},
 	} {
 		t.Logf("TestCase %q", desc)
 		var ipConfigs []*cni.IPConfig
@@ -430,7 +455,9 @@ func TestSelectPodIP(t *testing.T) {
 				IP: net.ParseIP(ip),
 			})
 		}
-		assert.Equal(t, test.expected, selectPodIP(ipConfigs))
+		ip, additionalIPs := selectPodIPs(ipConfigs)
+		assert.Equal(t, test.expected, ip)
+		assert.Equal(t, test.expectedAdditionalIPs, additionalIPs)
 	}
 }
 
@@ -454,9 +481,10 @@ func TestTypeurlMarshalUnmarshalSandboxMeta(t *testing.T) {
 	} {
 		t.Logf("TestCase %q", desc)
 		meta := &sandboxstore.Metadata{
-			ID:        "1",
-			Name:      "sandbox_1",
-			NetNSPath: "/home/cloud",
+			ID:             "1",
+			Name:           "sandbox_1",
+			NetNSPath:      "/home/cloud",
+			RuntimeHandler: "foo",
 		}
 		meta.Config, _, _ = getRunPodSandboxTestData()
 		if test.configChange != nil {
@@ -533,10 +561,18 @@ func TestGetSandboxRuntime(t *testing.T) {
 		Root:   "",
 	}
 
+	fooRuntime := criconfig.Runtime{
+		Type:   "io.containerd.runtime.v1.linux",
+		Engine: "foo-bar",
+		Root:   "",
+	}
+
 	for desc, test := range map[string]struct {
 		sandboxConfig            *runtime.PodSandboxConfig
+		runtimeHandler           string
 		defaultRuntime           criconfig.Runtime
 		untrustedWorkloadRuntime criconfig.Runtime
+		runtimes                 map[string]criconfig.Runtime
 		expectErr                bool
 		expectedRuntime          criconfig.Runtime
 	}{
@@ -590,6 +626,38 @@ func TestGetSandboxRuntime(t *testing.T) {
 			defaultRuntime: defaultRuntime,
 			expectErr:      true,
 		},
+		"should use runtime for the specified runtime handler": {
+			sandboxConfig:  &runtime.PodSandboxConfig{},
+			runtimeHandler: "foo",
+			defaultRuntime: defaultRuntime,
+			runtimes: map[string]criconfig.Runtime{
+				"foo": fooRuntime,
+			},
+			expectedRuntime: fooRuntime,
+		},
+		"should return error if runtime handler is not configured": {
+			sandboxConfig:  &runtime.PodSandboxConfig{},
+			runtimeHandler: "bar",
+			defaultRuntime: defaultRuntime,
+			runtimes: map[string]criconfig.Runtime{
+				"foo": fooRuntime,
+			},
+			expectErr: true,
+		},
+		"should return error if untrusted workload specifies runtime handler": {
+			sandboxConfig: &runtime.PodSandboxConfig{
+				Annotations: map[string]string{
+					annotations.UntrustedWorkload: "true",
+				},
+			},
+			runtimeHandler:           "foo",
+			defaultRuntime:           defaultRuntime,
+			untrustedWorkloadRuntime: untrustedWorkloadRuntime,
+			runtimes: map[string]criconfig.Runtime{
+				"foo": fooRuntime,
+			},
+			expectErr: true,
+		},
 	} {
 		t.Run(desc, func(t *testing.T) {
 			cri := newTestCRIService()
@@ -598,12 +666,90 @@ func TestGetSandboxRuntime(t *testing.T) {
 			}
 			cri.config.ContainerdConfig.DefaultRuntime = test.defaultRuntime
 			cri.config.ContainerdConfig.UntrustedWorkloadRuntime = test.untrustedWorkloadRuntime
-			r, err := cri.getSandboxRuntime(test.sandboxConfig)
+			cri.config.ContainerdConfig.Runtimes = test.runtimes
+			r, err := cri.getSandboxRuntime(test.sandboxConfig, test.runtimeHandler)
 			assert.Equal(t, test.expectErr, err != nil)
 			assert.Equal(t, test.expectedRuntime, r)
 		})
 	}
 }
 
+func TestSandboxRuntime(t *testing.T) {
+	c := newTestCRIService()
+	c.config.ContainerdConfig.DefaultRuntime = criconfig.Runtime{Type: "io.containerd.runtime.v1.linux"}
+	recorded := criconfig.Runtime{Type: "io.containerd.runsc.v1"}
+	for desc, test := range map[string]struct {
+		runtime  *criconfig.Runtime
+		expected criconfig.Runtime
+	}{
+		"should use the runtime recorded with the sandbox": {
+			runtime:  &recorded,
+			expected: recorded,
+		},
+		"should resolve the runtime from the config if it is not recorded": {
+			expected: c.config.ContainerdConfig.DefaultRuntime,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		sandbox := sandboxstore.NewSandbox(sandboxstore.Metadata{
+			ID:      "test-id",
+			Config:  &runtime.PodSandboxConfig{},
+			Runtime: test.runtime,
+		}, sandboxstore.Status{})
+		r, err := c.sandboxRuntime(sandbox)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, r)
+	}
+}
+
+func TestRuntimeSnapshotter(t *testing.T) {
+	c := newTestCRIService()
+	c.config.ContainerdConfig.Snapshotter = "overlayfs"
+	for desc, test := range map[string]struct {
+		runtime  criconfig.Runtime
+		expected string
+	}{
+		"should use default snapshotter if runtime snapshotter is not set": {
+			runtime:  criconfig.Runtime{Type: "io.containerd.runtime.v1.linux"},
+			expected: "overlayfs",
+		},
+		"should use runtime snapshotter if it is set": {
+			runtime: criconfig.Runtime{
+				Type:        "io.containerd.runtime.v1.linux",
+				Snapshotter: "devmapper",
+			},
+			expected: "devmapper",
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		assert.Equal(t, test.expected, c.runtimeSnapshotter(test.runtime))
+	}
+}
+
+func TestRuntimeSystemdCgroup(t *testing.T) {
+	c := newTestCRIService()
+	c.config.SystemdCgroup = true
+	disabled := false
+	for desc, test := range map[string]struct {
+		runtime  criconfig.Runtime
+		expected bool
+	}{
+		"should use plugin level option if runtime doesn't override it": {
+			runtime:  criconfig.Runtime{Type: "io.containerd.runtime.v1.linux"},
+			expected: true,
+		},
+		"should use runtime option if it is set": {
+			runtime: criconfig.Runtime{
+				Type:          "io.containerd.runtime.v1.linux",
+				SystemdCgroup: &disabled,
+			},
+			expected: false,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		assert.Equal(t, test.expected, c.runtimeSystemdCgroup(test.runtime))
+	}
+}
+
 // TODO(random-liu): [P1] Add unit test for different error cases to make sure
 // the function cleans up on error properly.
@@ -0,0 +1,275 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containerd/cgroups"
+	tasks "github.com/containerd/containerd/api/services/tasks/v1"
+	"github.com/containerd/containerd/api/types"
+	"github.com/containerd/typeurl"
+	cnins "github.com/containernetworking/plugins/pkg/ns"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"golang.org/x/sys/unix"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+	"github.com/containerd/cri/pkg/log"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+// loopbackInterface is the name of the loopback interface, which is not
+// reported in pod sandbox network stats.
+const loopbackInterface = "lo"
+
+// PodSandboxStats returns stats of the pod sandbox, including the aggregated
+// cpu and memory usage of the sandbox and all its containers, and the network
+// interface counters of the pod network namespace.
+func (c *criService) PodSandboxStats(ctx context.Context, r *api.PodSandboxStatsRequest) (*api.PodSandboxStatsResponse, error) {
+	sandbox, err := c.sandboxStore.Get(r.GetPodSandboxId())
+	if err != nil {
+		return nil, errors.Wrapf(err, "an error occurred when try to find sandbox %q", r.GetPodSandboxId())
+	}
+	stats, err := c.podSandboxStats(ctx, sandbox)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get stats of sandbox %q", sandbox.ID)
+	}
+	return &api.PodSandboxStatsResponse{Stats: stats}, nil
+}
+
+// ListPodSandboxStats returns stats of all ready pod sandboxes matching the filter.
+func (c *criService) ListPodSandboxStats(ctx context.Context, r *api.ListPodSandboxStatsRequest) (*api.ListPodSandboxStatsResponse, error) {
+	filter := r.GetFilter()
+	id := filter.GetId()
+	if id != "" {
+		if sb, err := c.sandboxStore.Get(id); err == nil {
+			id = sb.ID
+		}
+	}
+	var stats []*api.PodSandboxStats
+	for _, sandbox := range c.sandboxStore.List() {
+		if id != "" && id != sandbox.ID {
+			continue
+		}
+		if !matchLabelSelector(filter.GetLabelSelector(), sandbox.Config.GetLabels()) {
+			continue
+		}
+		if sandbox.Status.Get().State != sandboxstore.StateReady {
+			continue
+		}
+		s, err := c.podSandboxStats(ctx, sandbox)
+		if err != nil {
+			// The sandbox may be stopped after the state check, skip it.
+			log.Sandbox.WithError(err).Warnf("Failed to get stats of sandbox %q", sandbox.ID)
+			continue
+		}
+		stats = append(stats, s)
+	}
+	return &api.ListPodSandboxStatsResponse{Stats: stats}, nil
+}
+
+// podSandboxStats collects stats of a ready pod sandbox.
+func (c *criService) podSandboxStats(ctx context.Context, sandbox sandboxstore.Sandbox) (*api.PodSandboxStats, error) {
+	status := sandbox.Status.Get()
+	if status.State != sandboxstore.StateReady {
+		return nil, errors.Errorf("sandbox is not ready")
+	}
+	meta := sandbox.Config.GetMetadata()
+	stats := &api.PodSandboxStats{
+		Id:          sandbox.ID,
+		Name:        meta.GetName(),
+		Namespace:   meta.GetNamespace(),
+		Uid:         meta.GetUid(),
+		Labels:      sandbox.Config.GetLabels(),
+		Annotations: sandbox.Config.GetAnnotations(),
+		Timestamp:   time.Now().UnixNano(),
+	}
+
+	metrics, err := c.getSandboxMetrics(ctx, sandbox)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get sandbox metrics")
+	}
+	for _, m := range metrics {
+		s, err := typeurl.UnmarshalAny(m.Data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to extract metrics for %q", m.ID)
+		}
+		cm := s.(*cgroups.Metrics)
+		if cm.CPU != nil && cm.CPU.Usage != nil {
+			stats.UsageCoreNanoSeconds += cm.CPU.Usage.Total
+		}
+		if cm.Memory != nil {
+			stats.WorkingSetBytes += getWorkingSet(cm.Memory)
+		}
+	}
+
+	stats.Interfaces, err = getSandboxInterfaceStats(sandbox)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get network interface stats")
+	}
+	return stats, nil
+}
+
+// getSandboxInterfaceStats returns counters of the network interfaces in the
+// network namespace of a ready sandbox.
+func getSandboxInterfaceStats(sandbox sandboxstore.Sandbox) ([]*api.NetworkInterfaceStats, error) {
+	// Interfaces in the host network namespace don't belong to the sandbox.
+	if sandbox.Config.GetLinux().GetSecurityContext().GetNamespaceOptions().GetNetwork() == runtime.NamespaceMode_NODE {
+		return nil, nil
+	}
+	if sandbox.NoPauseContainer {
+		return getNetNSInterfaceStats(sandbox.NetNS)
+	}
+	return getNetworkInterfaceStats(sandbox.Status.Get().Pid)
+}
+
+// getSandboxMetrics returns metrics of the sandbox container and all running
+// containers in the sandbox.
+func (c *criService) getSandboxMetrics(ctx context.Context, sandbox sandboxstore.Sandbox) ([]*types.Metric, error) {
+	var containers []string
+	for _, cntr := range c.containerStore.List() {
+		if cntr.SandboxID != sandbox.ID {
+			continue
+		}
+		if cntr.Status.Get().State() != runtime.ContainerState_CONTAINER_RUNNING {
+			continue
+		}
+		containers = append(containers, cntr.ID)
+	}
+
+	if !c.unifiedCgroup {
+		request := &tasks.MetricsRequest{Filters: []string{"id==" + sandbox.ID}}
+		for _, id := range containers {
+			request.Filters = append(request.Filters, "id=="+id)
+		}
+		resp, err := c.client.TaskService().Metrics(ctx, request)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to fetch metrics for tasks")
+		}
+		return resp.Metrics, nil
+	}
+
+	var metrics []*types.Metric
+	// A sandbox without a pause container has no sandbox container cgroup.
+	if !sandbox.NoPauseContainer {
+		metric, err := c.getUnifiedCgroupMetrics(ctx, sandbox.Container)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to fetch metrics for sandbox container")
+		}
+		metrics = append(metrics, metric)
+	}
+	for _, id := range containers {
+		cntr, err := c.containerStore.Get(id)
+		if err != nil {
+			continue
+		}
+		metric, err := c.getUnifiedCgroupMetrics(ctx, cntr.Container)
+		if err != nil {
+			// The container may exit after the state check, skip it
+			// like task service does for tasks not running.
+			log.Sandbox.WithError(err).Warnf("Failed to fetch metrics from cgroup for %q", id)
+			continue
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
+
+// getNetworkInterfaceStats returns counters of the network interfaces in the
+// network namespace of a process.
+func getNetworkInterfaceStats(pid uint32) ([]*api.NetworkInterfaceStats, error) {
+	return readNetDev(fmt.Sprintf("/proc/%d/net/dev", pid))
+}
+
+// getNetNSInterfaceStats returns counters of the network interfaces in a
+// network namespace, which no process of the sandbox may be in.
+func getNetNSInterfaceStats(netNS *sandboxstore.NetNS) ([]*api.NetworkInterfaceStats, error) {
+	if netNS == nil || netNS.Closed() {
+		return nil, errors.New("network namespace is closed")
+	}
+	var stats []*api.NetworkInterfaceStats
+	err := netNS.GetNs().Do(func(cnins.NetNS) error {
+		var err error
+		stats, err = readNetDev(fmt.Sprintf("/proc/self/task/%d/net/dev", unix.Gettid()))
+		return err
+	})
+	return stats, err
+}
+
+// readNetDev reads the network interface counters from a net/dev file.
+func readNetDev(path string) ([]*api.NetworkInterfaceStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %q", path)
+	}
+	defer f.Close()
+	stats, err := parseNetDev(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %q", path)
+	}
+	return stats, nil
+}
+
+// parseNetDev parses network interface counters in the format of /proc/net/dev.
+// The loopback interface is skipped.
+func parseNetDev(r io.Reader) ([]*api.NetworkInterfaceStats, error) {
+	var stats []*api.NetworkInterfaceStats
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		// Interface lines are in the form of "name: <8 receive counters>
+		// <8 transmit counters>". The header lines are skipped.
+		parts := strings.SplitN(s.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(parts[0])
+		fields := strings.Fields(parts[1])
+		if len(fields) != 16 {
+			continue
+		}
+		if name == loopbackInterface {
+			continue
+		}
+		var counters [16]uint64
+		for i, field := range fields {
+			v, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse counters of interface %q", name)
+			}
+			counters[i] = v
+		}
+		stats = append(stats, &api.NetworkInterfaceStats{
+			Name:     name,
+			RxBytes:  counters[0],
+			RxErrors: counters[2],
+			TxBytes:  counters[8],
+			TxErrors: counters[10],
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
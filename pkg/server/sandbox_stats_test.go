@@ -0,0 +1,66 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	api "github.com/containerd/cri/pkg/api/v1"
+)
+
+func TestParseNetDev(t *testing.T) {
+	for desc, test := range map[string]struct {
+		content     string
+		expected    []*api.NetworkInterfaceStats
+		expectedErr bool
+	}{
+		"should skip headers and loopback interface": {
+			content: `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:     100       2    0    0    0     0          0         0      100       2    0    0    0     0       0          0
+  eth0:  123456     100    1    0    0     0          0         0    65432      50    2    0    0     0       0          0
+  eth1:      10       1    0    0    0     0          0         0       20       1    0    0    0     0       0          0
+`,
+			expected: []*api.NetworkInterfaceStats{
+				{Name: "eth0", RxBytes: 123456, RxErrors: 1, TxBytes: 65432, TxErrors: 2},
+				{Name: "eth1", RxBytes: 10, TxBytes: 20, TxErrors: 0},
+			},
+		},
+		"should handle counters without separating space": {
+			content: "eth0:123 1 0 0 0 0 0 0 456 1 0 0 0 0 0 0\n",
+			expected: []*api.NetworkInterfaceStats{
+				{Name: "eth0", RxBytes: 123, TxBytes: 456},
+			},
+		},
+		"should return error for invalid counters": {
+			content:     "eth0: 1 1 0 0 0 0 0 0 x 1 0 0 0 0 0 0\n",
+			expectedErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		stats, err := parseNetDev(strings.NewReader(test.content))
+		if test.expectedErr {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, stats)
+	}
+}
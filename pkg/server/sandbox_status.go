@@ -37,14 +37,15 @@ func (c *criService) PodSandboxStatus(ctx context.Context, r *runtime.PodSandbox
 		return nil, errors.Wrap(err, "an error occurred when try to find sandbox")
 	}
 
-	ip := c.getIP(sandbox)
+	ip, additionalIPs := c.getIPs(sandbox)
 	status := toCRISandboxStatus(sandbox.Metadata, sandbox.Status.Get(), ip)
 	if !r.GetVerbose() {
 		return &runtime.PodSandboxStatusResponse{Status: status}, nil
 	}
 
-	// Generate verbose information.
-	info, err := toCRISandboxInfo(ctx, sandbox)
+	// Generate verbose information. The CRI version in use has no field for
+	// multiple pod ips, so the additional ips are only in the verbose info.
+	info, err := toCRISandboxInfo(ctx, sandbox, additionalIPs)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get verbose sandbox container info")
 	}
@@ -55,21 +56,22 @@ func (c *criService) PodSandboxStatus(ctx context.Context, r *runtime.PodSandbox
 	}, nil
 }
 
-func (c *criService) getIP(sandbox sandboxstore.Sandbox) string {
+// getIPs returns the ip and the additional ips of a sandbox.
+func (c *criService) getIPs(sandbox sandboxstore.Sandbox) (string, []string) {
 	config := sandbox.Config
 
 	if config.GetLinux().GetSecurityContext().GetNamespaceOptions().GetNetwork() == runtime.NamespaceMode_NODE {
 		// For sandboxes using the node network we are not
 		// responsible for reporting the IP.
-		return ""
+		return "", nil
 	}
 
 	// The network namespace has been closed.
 	if sandbox.NetNS == nil || sandbox.NetNS.Closed() {
-		return ""
+		return "", nil
 	}
 
-	return sandbox.IP
+	return sandbox.IP, sandbox.AdditionalIPs
 }
 
 // toCRISandboxStatus converts sandbox metadata into CRI pod sandbox status.
@@ -102,19 +104,23 @@ func toCRISandboxStatus(meta sandboxstore.Metadata, status sandboxstore.Status,
 
 // TODO (mikebrow): discuss predefining constants structures for some or all of these field names in CRI
 type sandboxInfo struct {
-	Pid         uint32                    `json:"pid"`
-	Status      string                    `json:"processStatus"`
-	NetNSClosed bool                      `json:"netNamespaceClosed"`
-	Image       string                    `json:"image"`
-	SnapshotKey string                    `json:"snapshotKey"`
-	Snapshotter string                    `json:"snapshotter"`
-	Runtime     *criconfig.Runtime        `json:"runtime"`
-	Config      *runtime.PodSandboxConfig `json:"config"`
-	RuntimeSpec *runtimespec.Spec         `json:"runtimeSpec"`
+	Pid                uint32                           `json:"pid"`
+	Status             string                           `json:"processStatus"`
+	NoPauseContainer   bool                             `json:"noPauseContainer"`
+	NetNSClosed        bool                             `json:"netNamespaceClosed"`
+	AdditionalIPs      []string                         `json:"additionalIPs"`
+	AdditionalNetworks []sandboxstore.NetworkAttachment `json:"additionalNetworks"`
+	Image              string                           `json:"image"`
+	SnapshotKey        string                           `json:"snapshotKey"`
+	Snapshotter        string                           `json:"snapshotter"`
+	Runtime            *criconfig.Runtime               `json:"runtime"`
+	RuntimeHandler     string                           `json:"runtimeHandler"`
+	Config             *runtime.PodSandboxConfig        `json:"config"`
+	RuntimeSpec        *runtimespec.Spec                `json:"runtimeSpec"`
 }
 
 // toCRISandboxInfo converts internal container object information to CRI sandbox status response info map.
-func toCRISandboxInfo(ctx context.Context, sandbox sandboxstore.Sandbox) (map[string]string, error) {
+func toCRISandboxInfo(ctx context.Context, sandbox sandboxstore.Sandbox, additionalIPs []string) (map[string]string, error) {
 	container := sandbox.Container
 	task, err := container.Task(ctx, nil)
 	if err != nil && !errdefs.IsNotFound(err) {
@@ -132,12 +138,19 @@ func toCRISandboxInfo(ctx context.Context, sandbox sandboxstore.Sandbox) (map[st
 	}
 
 	si := &sandboxInfo{
-		Pid:    sandbox.Status.Get().Pid,
-		Status: string(processStatus),
-		Config: sandbox.Config,
-	}
-
-	if si.Status == "" {
+		Pid:                sandbox.Status.Get().Pid,
+		Status:             string(processStatus),
+		AdditionalIPs:      additionalIPs,
+		AdditionalNetworks: sandbox.AdditionalNetworks,
+		NoPauseContainer:   sandbox.NoPauseContainer,
+		RuntimeHandler:     sandbox.RuntimeHandler,
+		Config:             sandbox.Config,
+	}
+
+	if sandbox.NoPauseContainer {
+		// A sandbox without a pause container never has a task.
+		si.Status = "none"
+	} else if si.Status == "" {
 		// If processStatus is empty, it means that the task is deleted. Apply "deleted"
 		// status which does not exist in containerd.
 		si.Status = "deleted"
@@ -24,11 +24,11 @@ import (
 	"github.com/containerd/containerd/errdefs"
 	cni "github.com/containerd/go-cni"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"golang.org/x/sys/unix"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
+	"github.com/containerd/cri/pkg/log"
 	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
 )
 
@@ -59,14 +59,25 @@ func (c *criService) StopPodSandbox(ctx context.Context, r *runtime.StopPodSandb
 		}
 	}
 
+	// Remove the host ports before the network, they don't depend on the
+	// network namespace.
+	if err := c.teardownHostPorts(sandbox); err != nil {
+		return nil, errors.Wrapf(err, "failed to remove host ports for sandbox %q", id)
+	}
+
 	// Teardown network for sandbox.
 	if sandbox.NetNSPath != "" && sandbox.NetNS != nil {
 		if _, err := os.Stat(sandbox.NetNSPath); err != nil {
 			if !os.IsNotExist(err) {
 				return nil, errors.Wrapf(err, "failed to stat network namespace path %s", sandbox.NetNSPath)
 			}
-		} else {
-			if teardownErr := c.teardownPod(id, sandbox.NetNSPath, sandbox.Config); teardownErr != nil {
+		} else if !sandbox.ExternalNetNS {
+			// The network of an external network namespace is torn down by
+			// the external network agent.
+			if teardownErr := c.teardownAdditionalNetworks(id, sandbox.NetNSPath, sandbox.Config, sandbox.AdditionalNetworks); teardownErr != nil {
+				return nil, errors.Wrapf(teardownErr, "failed to destroy additional networks for sandbox %q", id)
+			}
+			if teardownErr := c.teardownPod(id, sandbox.NetNSPath, sandbox.Config, sandbox.CNIResult, sandbox.CNINetworks); teardownErr != nil {
 				return nil, errors.Wrapf(teardownErr, "failed to destroy network for sandbox %q", id)
 			}
 		}
@@ -74,13 +85,14 @@ func (c *criService) StopPodSandbox(ctx context.Context, r *runtime.StopPodSandb
 		In that case, we'll not be able to remove the sandbox anymore. The chance is slim, but we should be aware of that.
 		In the future, once TearDownPod is idempotent, this will be fixed.*/
 
-		//Close the sandbox network namespace if it was created
+		//Close the sandbox network namespace if it was created, an external
+		//network namespace is only closed.
 		if err = sandbox.NetNS.Remove(); err != nil {
 			return nil, errors.Wrapf(err, "failed to remove network namespace for sandbox %q", id)
 		}
 	}
 
-	logrus.Infof("TearDown network for sandbox %q successfully", id)
+	log.Sandbox.Infof("TearDown network for sandbox %q successfully", id)
 
 	if err := c.unmountSandboxFiles(id, sandbox.Config); err != nil {
 		return nil, errors.Wrap(err, "failed to unmount sandbox files")
@@ -99,6 +111,9 @@ func (c *criService) StopPodSandbox(ctx context.Context, r *runtime.StopPodSandb
 // `task.Delete` is not called here because it will be called when
 // the event monitor handles the `TaskExit` event.
 func (c *criService) stopSandboxContainer(ctx context.Context, sandbox sandboxstore.Sandbox) error {
+	if sandbox.NoPauseContainer {
+		return c.stopNoPauseSandbox(sandbox)
+	}
 	container := sandbox.Container
 	task, err := container.Task(ctx, nil)
 	if err != nil {
@@ -117,6 +132,24 @@ func (c *criService) stopSandboxContainer(ctx context.Context, sandbox sandboxst
 	return c.waitSandboxStop(ctx, sandbox, killContainerTimeout)
 }
 
+// stopNoPauseSandbox stops a sandbox without a pause container by removing
+// its pinned namespaces. There is no task exit event for it, so the sandbox
+// status is updated here.
+func (c *criService) stopNoPauseSandbox(sandbox sandboxstore.Sandbox) error {
+	if err := c.unpinSandboxNamespaces(sandbox.ID); err != nil {
+		return errors.Wrap(err, "failed to remove sandbox namespaces")
+	}
+	if err := sandbox.Status.Update(func(status sandboxstore.Status) (sandboxstore.Status, error) {
+		status.State = sandboxstore.StateNotReady
+		status.Pid = 0
+		return status, nil
+	}); err != nil {
+		return errors.Wrap(err, "failed to update sandbox state")
+	}
+	sandbox.Stop()
+	return nil
+}
+
 // waitSandboxStop waits for sandbox to be stopped until timeout exceeds or context is cancelled.
 func (c *criService) waitSandboxStop(ctx context.Context, sandbox sandboxstore.Sandbox, timeout time.Duration) error {
 	timeoutTimer := time.NewTimer(timeout)
@@ -131,11 +164,18 @@ func (c *criService) waitSandboxStop(ctx context.Context, sandbox sandboxstore.S
 	}
 }
 
-// teardownPod removes the network from the pod
-func (c *criService) teardownPod(id string, path string, config *runtime.PodSandboxConfig) error {
+// teardownPod removes the network from the pod. If the result and the networks
+// of the network setup are known, exactly those networks are removed, and the
+// result is passed to the cni plugins. Otherwise, e.g. for sandboxes created
+// by an older version, the networks of the current cni config are removed.
+func (c *criService) teardownPod(id string, path string, config *runtime.PodSandboxConfig, result *cni.CNIResult,
+	networks []sandboxstore.CNINetwork) error {
 	if c.netPlugin == nil {
 		return errors.New("cni config not intialized")
 	}
+	if result != nil && len(networks) > 0 {
+		return c.teardownPodWithResult(id, path, config, result, networks)
+	}
 
 	labels := getPodCNILabels(id, config)
 	return c.netPlugin.Remove(id,
@@ -0,0 +1,114 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/oci"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// seccompProfileCache caches parsed localhost seccomp profiles, so that a
+// profile shared by many containers is not read and decoded for each of them.
+// A cached profile is invalidated when the profile file is modified.
+type seccompProfileCache struct {
+	sync.Mutex
+	profiles map[string]cachedSeccompProfile
+}
+
+// cachedSeccompProfile is a parsed seccomp profile and the file info used to
+// detect changes of the profile file.
+type cachedSeccompProfile struct {
+	modTime time.Time
+	size    int64
+	profile *runtimespec.LinuxSeccomp
+}
+
+// newSeccompProfileCache creates a seccomp profile cache.
+func newSeccompProfileCache() *seccompProfileCache {
+	return &seccompProfileCache{profiles: make(map[string]cachedSeccompProfile)}
+}
+
+// get returns a copy of the seccomp profile at path. The profile is loaded
+// from the file if it is not cached or the file has changed since cached.
+func (c *seccompProfileCache) get(path string) (*runtimespec.LinuxSeccomp, error) {
+	c.Lock()
+	defer c.Unlock()
+	fi, err := os.Stat(path)
+	if err != nil {
+		delete(c.profiles, path)
+		return nil, errors.Wrapf(err, "failed to stat seccomp profile %q", path)
+	}
+	if cached, ok := c.profiles[path]; ok && cached.modTime.Equal(fi.ModTime()) && cached.size == fi.Size() {
+		return copySeccomp(cached.profile), nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load seccomp profile %q", path)
+	}
+	profile := &runtimespec.LinuxSeccomp{}
+	if err := json.Unmarshal(data, profile); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode seccomp profile %q", path)
+	}
+	c.profiles[path] = cachedSeccompProfile{
+		modTime: fi.ModTime(),
+		size:    fi.Size(),
+		profile: profile,
+	}
+	return copySeccomp(profile), nil
+}
+
+// withSeccompProfile sets the localhost seccomp profile at path to the spec,
+// loading it through the profile cache.
+func withSeccompProfile(cache *seccompProfileCache, path string) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *runtimespec.Spec) error {
+		profile, err := cache.get(path)
+		if err != nil {
+			return err
+		}
+		if s.Linux == nil {
+			s.Linux = &runtimespec.Linux{}
+		}
+		s.Linux.Seccomp = profile
+		return nil
+	}
+}
+
+// copySeccomp returns a deep copy of a seccomp profile, so that the cached
+// profile is not mutated through the spec it is set to.
+func copySeccomp(s *runtimespec.LinuxSeccomp) *runtimespec.LinuxSeccomp {
+	c := &runtimespec.LinuxSeccomp{
+		DefaultAction: s.DefaultAction,
+		Architectures: append([]runtimespec.Arch(nil), s.Architectures...),
+	}
+	for _, syscall := range s.Syscalls {
+		c.Syscalls = append(c.Syscalls, runtimespec.LinuxSyscall{
+			Names:  append([]string(nil), syscall.Names...),
+			Action: syscall.Action,
+			Args:   append([]runtimespec.LinuxSeccompArg(nil), syscall.Args...),
+		})
+	}
+	return c
+}
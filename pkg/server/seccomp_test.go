@@ -0,0 +1,74 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeccompProfileCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-seccomp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "profile.json")
+	cache := newSeccompProfileCache()
+
+	t.Logf("should return error if the profile doesn't exist")
+	_, err = cache.get(path)
+	assert.Error(t, err)
+
+	t.Logf("should load the profile")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"defaultAction":"SCMP_ACT_ERRNO","syscalls":[{"names":["read"],"action":"SCMP_ACT_ALLOW"}]}`), 0644))
+	profile, err := cache.get(path)
+	require.NoError(t, err)
+	assert.Equal(t, runtimespec.ActErrno, profile.DefaultAction)
+	require.Len(t, profile.Syscalls, 1)
+	assert.Equal(t, []string{"read"}, profile.Syscalls[0].Names)
+
+	t.Logf("should not mutate the cached profile through the returned copy")
+	profile.Syscalls[0].Names[0] = "write"
+	profile, err = cache.get(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read"}, profile.Syscalls[0].Names)
+
+	t.Logf("should reload the profile after it is changed")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"defaultAction":"SCMP_ACT_ALLOW"}`), 0644))
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	profile, err = cache.get(path)
+	require.NoError(t, err)
+	assert.Equal(t, runtimespec.ActAllow, profile.DefaultAction)
+	assert.Empty(t, profile.Syscalls)
+
+	t.Logf("should return error for invalid profile")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`invalid`), 0644))
+	_, err = cache.get(path)
+	assert.Error(t, err)
+
+	t.Logf("should return error after the profile is removed")
+	require.NoError(t, os.Remove(path))
+	_, err = cache.get(path)
+	assert.Error(t, err)
+}
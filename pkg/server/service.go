@@ -28,6 +28,7 @@ import (
 	cni "github.com/containerd/go-cni"
 	runcapparmor "github.com/opencontainers/runc/libcontainer/apparmor"
 	runcseccomp "github.com/opencontainers/runc/libcontainer/seccomp"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/selinux/go-selinux"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -38,7 +39,7 @@ import (
 	api "github.com/containerd/cri/pkg/api/v1"
 	"github.com/containerd/cri/pkg/atomic"
 	criconfig "github.com/containerd/cri/pkg/config"
-	ctrdutil "github.com/containerd/cri/pkg/containerd/util"
+	"github.com/containerd/cri/pkg/hostport"
 	osinterface "github.com/containerd/cri/pkg/os"
 	"github.com/containerd/cri/pkg/registrar"
 	containerstore "github.com/containerd/cri/pkg/store/container"
@@ -67,12 +68,16 @@ type CRIService interface {
 type criService struct {
 	// config contains all configurations.
 	config criconfig.Config
-	// imageFSPath is the path to image filesystem.
-	imageFSPath string
+	// imageFSPaths are the paths to image filesystems, keyed by snapshotter.
+	imageFSPaths map[string]string
 	// apparmorEnabled indicates whether apparmor is enabled.
 	apparmorEnabled bool
 	// seccompEnabled indicates whether seccomp is enabled.
 	seccompEnabled bool
+	// seccompProfiles caches localhost seccomp profiles.
+	seccompProfiles *seccompProfileCache
+	// unifiedCgroup indicates whether the host uses the cgroup v2 unified hierarchy.
+	unifiedCgroup bool
 	// os is an interface for all required os operations.
 	os osinterface.OS
 	// sandboxStore stores all resources associated with sandboxes.
@@ -87,19 +92,87 @@ type criService struct {
 	containerNameIndex *registrar.Registrar
 	// imageStore stores all resources associated with images.
 	imageStore *imagestore.Store
-	// snapshotStore stores information of all snapshots.
-	snapshotStore *snapshotstore.Store
+	// snapshotStores store information of all snapshots, keyed by snapshotter.
+	snapshotStores map[string]*snapshotstore.Store
+	// execLimiter enforces the limits of exec sessions.
+	execLimiter *execLimiter
+	// timeouts are the timeouts of the internal phases of sandbox and
+	// container operations.
+	timeouts operationTimeouts
+	// cleanupBackoff is the delay before the first retry of a failed rollback
+	// step.
+	cleanupBackoff time.Duration
+	// specHooks invokes the spec hooks.
+	specHooks *specHooks
+	// distributor routes image pulls through a peer-to-peer image
+	// distributor. It is nil if no distributor is configured.
+	distributor imageDistributor
+	// baseOCISpecs are the base runtime specs of runtimes, keyed by path.
+	baseOCISpecs map[string]*runtimespec.Spec
+	// snapshotsSyncers sync snapshot stats into snapshotStores, keyed by
+	// snapshotter.
+	snapshotsSyncers map[string]*snapshotsSyncer
 	// netPlugin is used to setup and teardown network when run/stop pod sandbox.
 	netPlugin cni.CNI
+	// cniNetConfMonitor reloads the cni config when it is changed.
+	cniNetConfMonitor *cniNetConfSyncer
+	// configReloader reloads the reloadable config file. It is nil if no
+	// reloadable config file is configured.
+	configReloader *configReloader
+	// registryHTTPClient caches the registry http client of the reloadable
+	// config in effect.
+	registryHTTPClient registryHTTPClientCache
+	// userNamespaceRanges are the host id ranges allocated to the user
+	// namespaces of pods.
+	userNamespaceRanges userNamespaceRanges
+	// hostPortManager programs host ports when no cni plugin handles port
+	// mappings.
+	hostPortManager *hostport.Manager
+	// podCgroups returns the pod cgroup manager of a cgroup driver. It is nil
+	// if pod cgroups are not managed.
+	podCgroups func(systemdCgroup bool) podCgroupManager
+	// podCgroupLocks serializes the pod cgroup updates of each sandbox.
+	podCgroupLocks keyMutex
 	// client is an instance of the containerd client
 	client *containerd.Client
 	// streamServer is the streaming server serves container streaming request.
 	streamServer streaming.Server
+	// streamServerStatus is the error of the streaming server if it fails.
+	streamServerStatus lastError
+	// recoveryErrors are the errors of the sandboxes and containers failing
+	// to be recovered on restart. They are only written before the service
+	// is initialized.
+	recoveryErrors []error
+	// grpcServer is the dedicated grpc server serving cri services. It is nil
+	// if no dedicated address is configured.
+	grpcServer *grpc.Server
 	// eventMonitor is the monitor monitors containerd events.
 	eventMonitor *eventMonitor
+	// tracer traces cri requests. It is nil if tracing is disabled.
+	tracer *tracer
+	// auditor records cri requests into the audit log. It is nil if the
+	// audit log is disabled.
+	auditor *auditor
+	// rpcLimiter rejects requests over the rate limits of their rpc. It is
+	// nil if no rpc is limited.
+	rpcLimiter *rpcLimiter
+	// containerEvents broadcasts lifecycle events of containers and
+	// sandboxes to GetContainerEvents streams.
+	containerEvents *containerEventBroadcaster
+	// imagePullTracker tracks in progress image pulls.
+	imagePullTracker *imagePullTracker
+	// unpackLimiter limits the number of concurrent image unpacks. It is nil
+	// if there is no limit.
+	unpackLimiter chan struct{}
+	// imageGC is the image garbage collection manager. It is nil if image
+	// garbage collection is disabled.
+	imageGC *imageGCManager
 	// initialized indicates whether the server is initialized. All GRPC services
 	// should return error before the server is initialized.
 	initialized atomic.Bool
+	// drain tracks whether the server is in drain mode, which rejects new
+	// pod sandboxes and containers, and the in-flight requests creating them.
+	drain drainTracker
 }
 
 // NewCRIService returns a new instance of CRIService
@@ -110,16 +183,41 @@ func NewCRIService(config criconfig.Config, client *containerd.Client) (CRIServi
 		client:             client,
 		apparmorEnabled:    runcapparmor.IsEnabled(),
 		seccompEnabled:     runcseccomp.IsEnabled(),
+		seccompProfiles:    newSeccompProfileCache(),
+		unifiedCgroup:      isUnifiedCgroupHierarchy(),
 		os:                 osinterface.RealOS{},
 		sandboxStore:       sandboxstore.NewStore(),
 		containerStore:     containerstore.NewStore(),
 		imageStore:         imagestore.NewStore(),
-		snapshotStore:      snapshotstore.NewStore(),
+		imageFSPaths:       make(map[string]string),
+		snapshotStores:     make(map[string]*snapshotstore.Store),
+		snapshotsSyncers:   make(map[string]*snapshotsSyncer),
 		sandboxNameIndex:   registrar.NewRegistrar(),
 		containerNameIndex: registrar.NewRegistrar(),
+		imagePullTracker:   newImagePullTracker(),
+		hostPortManager:    hostport.NewManager(),
+		containerEvents:    newContainerEventBroadcaster(),
+		cleanupBackoff:     sandboxCleanupBackoff,
 		initialized:        atomic.NewBool(false),
 	}
 
+	// The configs of the components are validated when they are built below.
+	if err := validatePluginConfig(c.config.PluginConfig); err != nil {
+		return nil, err
+	}
+	for _, warning := range PluginConfigWarnings(c.config.PluginConfig) {
+		logrus.Warn(warning)
+	}
+	// Invalid registry configs only fail the pulls using them, as before they
+	// were validated.
+	if err := validateReloadableConfig(baseReloadableConfig(c.config.PluginConfig)); err != nil {
+		logrus.WithError(err).Warn("Invalid registry or sandbox_image config, image pulls may fail")
+	}
+
+	if err := setupPluginDirs(&c.config); err != nil {
+		return nil, errors.Wrap(err, "failed to setup plugin directories")
+	}
+
 	if c.config.EnableSelinux {
 		if !selinux.GetEnabled() {
 			logrus.Warn("Selinux is not supported")
@@ -128,12 +226,96 @@ func NewCRIService(config criconfig.Config, client *containerd.Client) (CRIServi
 		selinux.SetDisabled()
 	}
 
+	if n := c.config.ContainerdConfig.MaxConcurrentUnpacks; n > 0 {
+		c.unpackLimiter = make(chan struct{}, n)
+	}
+
+	if c.unifiedCgroup {
+		logrus.Info("Cgroup v2 unified hierarchy is detected")
+	}
+
+	c.execLimiter, err = newExecLimiter(c.config.ExecLimits)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid exec limits")
+	}
+
+	c.timeouts, err = newOperationTimeouts(c.config.OperationTimeouts)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid operation timeouts")
+	}
+
+	c.specHooks, err = newSpecHooks(c.config.SpecHooks)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid spec_hooks config")
+	}
+
+	c.distributor, err = newImageDistributor(c.config.Registry.P2P)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid p2p config")
+	}
+
+	if c.config.ManagePodCgroup {
+		c.podCgroups = newPodCgroupManager
+	}
+
+	c.tracer, err = newTracer(c.config.Tracing)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create tracer")
+	}
+
+	c.rpcLimiter, err = newRPCLimiter(c.config.RateLimits)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid rate_limits")
+	}
+
+	c.auditor, err = newAuditor(c.config.Audit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create auditor")
+	}
+
+	if c.config.ImageGC.Enabled {
+		c.imageGC, err = newImageGCManager(c, c.config.ImageGC)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create image gc manager")
+		}
+	}
+
+	if c.config.ReloadableConfigPath != "" {
+		c.configReloader, err = newConfigReloader(c.config.ReloadableConfigPath, c.config)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create config reloader")
+		}
+	}
+
 	if client.SnapshotService(c.config.ContainerdConfig.Snapshotter) == nil {
 		return nil, errors.Errorf("failed to find snapshotter %q", c.config.ContainerdConfig.Snapshotter)
 	}
+	for handler, r := range c.namedRuntimes() {
+		if r.Snapshotter != "" && client.SnapshotService(r.Snapshotter) == nil {
+			return nil, errors.Errorf("failed to find snapshotter %q for runtime %q", r.Snapshotter, handler)
+		}
+	}
+	runtimes := []criconfig.Runtime{c.config.ContainerdConfig.DefaultRuntime, c.config.ContainerdConfig.UntrustedWorkloadRuntime}
+	for _, r := range c.config.ContainerdConfig.Runtimes {
+		runtimes = append(runtimes, r)
+	}
+	c.baseOCISpecs, err = loadBaseOCISpecs(runtimes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load base_runtime_spec")
+	}
 
-	c.imageFSPath = imageFSPath(config.ContainerdRootDir, config.ContainerdConfig.Snapshotter)
-	logrus.Infof("Get image filesystem path %q", c.imageFSPath)
+	for _, snapshotter := range c.snapshotters() {
+		c.imageFSPaths[snapshotter] = imageFSPath(config.ContainerdRootDir, snapshotter)
+		c.snapshotStores[snapshotter] = snapshotstore.NewStore()
+		c.snapshotsSyncers[snapshotter] = newSnapshotsSyncer(
+			c.snapshotStores[snapshotter],
+			client.SnapshotService(snapshotter),
+			c.config.ContainerdConfig.Namespace,
+			time.Duration(c.config.StatsCollectPeriod)*time.Second,
+			time.Duration(c.config.StatsMaxStaleness)*time.Second,
+		)
+		logrus.Infof("Get image filesystem path %q for snapshotter %q", c.imageFSPaths[snapshotter], snapshotter)
+	}
 
 	// Pod needs to attach to atleast loopback network and a non host network,
 	// hence networkAttachCount is 2. If there are more network configs the
@@ -146,10 +328,13 @@ func NewCRIService(config criconfig.Config, client *containerd.Client) (CRIServi
 		return nil, errors.Wrap(err, "failed to initialize cni")
 	}
 
-	// Try to load the config if it exists. Just log the error if load fails
-	// This is not disruptive for containerd to panic
-	if err := c.netPlugin.Load(cni.WithLoNetwork, cni.WithDefaultConf); err != nil {
-		logrus.WithError(err).Error("Failed to load cni during init, please check CRI plugin status before setting up network for pods")
+	// Try to load the config if it exists, and reload it when it is changed.
+	// Just log the error if load fails. This is not disruptive for containerd
+	// to panic.
+	c.cniNetConfMonitor, err = newCNINetConfSyncer(config.NetworkPluginConfDir, c.netPlugin,
+		[]cni.CNIOpt{cni.WithLoNetwork, cni.WithDefaultConf})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cni conf monitor")
 	}
 	// prepare streaming server
 	c.streamServer, err = newStreamServer(c, config.StreamServerAddress, config.StreamServerPort)
@@ -157,17 +342,28 @@ func NewCRIService(config criconfig.Config, client *containerd.Client) (CRIServi
 		return nil, errors.Wrap(err, "failed to create stream server")
 	}
 
-	c.eventMonitor = newEventMonitor(c.containerStore, c.sandboxStore)
+	c.grpcServer, err = newGRPCServer(c, config.GRPC)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create grpc server")
+	}
+
+	registerStoreMetrics(c.config.ContainerdConfig.Namespace, c.sandboxStore, c.containerStore)
+
+	c.eventMonitor = newEventMonitor(c.containerStore, c.sandboxStore, c.containerEvents, c.config.ContainerdConfig.Namespace,
+		filepath.Join(config.StateDir, eventBacklogFile),
+		filepath.Join(config.StateDir, eventCheckpointFile))
 
 	return c, nil
 }
 
 // Register registers all required services onto a specific grpc server.
-// This is used by containerd cri plugin.
+// This is used by containerd cri plugin. Both CRI v1alpha2 and CRI v1 are
+// registered, so that older and newer kubelets can connect to the same socket.
 func (c *criService) Register(s *grpc.Server) error {
 	instrumented := newInstrumentedService(c)
 	runtime.RegisterRuntimeServiceServer(s, instrumented)
 	runtime.RegisterImageServiceServer(s, instrumented)
+	registerCRIV1(s, instrumented)
 	api.RegisterCRIPluginServiceServer(s, instrumented)
 	return nil
 }
@@ -178,22 +374,65 @@ func (c *criService) Run() error {
 	c.eventMonitor.subscribe(c.client)
 
 	logrus.Infof("Start recovering state")
-	if err := c.recover(ctrdutil.NamespacedContext()); err != nil {
+	if err := c.recover(c.namespacedContext()); err != nil {
 		return errors.Wrap(err, "failed to recover state")
 	}
 
+	// Replay events which were not handled before restart.
+	logrus.Info("Start replaying event backlog")
+	if err := c.eventMonitor.replayBacklog(); err != nil {
+		logrus.WithError(err).Error("Failed to replay event backlog")
+	}
+	if err := c.eventMonitor.replayCheckpoint(); err != nil {
+		logrus.WithError(err).Error("Failed to replay event checkpoint")
+	}
+
 	// Start event handler.
 	logrus.Info("Start event monitor")
 	eventMonitorErrCh := c.eventMonitor.start()
 
-	// Start snapshot stats syncer, it doesn't need to be stopped.
-	logrus.Info("Start snapshots syncer")
-	snapshotsSyncer := newSnapshotsSyncer(
-		c.snapshotStore,
-		c.client.SnapshotService(c.config.ContainerdConfig.Snapshotter),
-		time.Duration(c.config.StatsCollectPeriod)*time.Second,
-	)
-	snapshotsSyncer.start()
+	// Start snapshot stats syncers, they don't need to be stopped.
+	for snapshotter, syncer := range c.snapshotsSyncers {
+		logrus.Infof("Start snapshots syncer for snapshotter %q", snapshotter)
+		syncer.start()
+	}
+
+	logrus.Info("Start pulling sandbox image if it is missing")
+	c.pullSandboxImage()
+
+	if c.imageGC != nil {
+		logrus.Info("Start image garbage collection")
+		c.imageGC.start()
+	}
+
+	if c.config.HostNetworkDNSPassthrough {
+		logrus.Info("Start host resolv.conf syncer")
+		c.startHostResolvConfSyncer()
+	}
+
+	// Start cni network conf syncer. It is not critical, the cni config just
+	// won't be reloaded automatically if it fails, which is reported in the
+	// network status.
+	logrus.Info("Start cni network conf syncer")
+	cniNetConfMonitorDoneCh := make(chan struct{})
+	go func() {
+		defer close(cniNetConfMonitorDoneCh)
+		if err := c.cniNetConfMonitor.syncLoop(); err != nil {
+			logrus.WithError(err).Error("CNI network conf syncer exited")
+			c.cniNetConfMonitor.updateLastStatus(errors.Wrap(err, "cni network conf syncer exited"))
+		}
+	}()
+
+	// Start config reloader, reloading with SIGHUP still works if it fails
+	// to watch the config file, so it is not critical.
+	if c.configReloader != nil {
+		logrus.Infof("Start config reloader of %q", c.config.ReloadableConfigPath)
+		go func() {
+			if err := c.configReloader.run(); err != nil {
+				logrus.WithError(err).Error("Failed to watch reloadable config file")
+			}
+		}()
+	}
 
 	// Start streaming server.
 	logrus.Info("Start streaming server")
@@ -202,18 +441,33 @@ func (c *criService) Run() error {
 		defer close(streamServerErrCh)
 		if err := c.streamServer.Start(true); err != nil && err != http.ErrServerClosed {
 			logrus.WithError(err).Error("Failed to start streaming server")
+			c.streamServerStatus.set(err)
 			streamServerErrCh <- err
 		}
 	}()
 
+	// Start dedicated grpc server.
+	grpcServerErrCh := make(chan error, 1)
+	if c.grpcServer != nil {
+		logrus.Infof("Start grpc server on %q", c.config.GRPC.Address)
+		go func() {
+			defer close(grpcServerErrCh)
+			if err := c.serveGRPC(); err != nil && err != grpc.ErrServerStopped {
+				logrus.WithError(err).Error("Failed to serve grpc server")
+				grpcServerErrCh <- err
+			}
+		}()
+	}
+
 	// Set the server as initialized. GRPC services could start serving traffic.
 	c.initialized.Set()
 
-	var eventMonitorErr, streamServerErr error
+	var eventMonitorErr, streamServerErr, grpcServerErr error
 	// Stop the whole CRI service if any of the critical service exits.
 	select {
 	case eventMonitorErr = <-eventMonitorErrCh:
 	case streamServerErr = <-streamServerErrCh:
+	case grpcServerErr = <-grpcServerErrCh:
 	}
 	if err := c.Close(); err != nil {
 		return errors.Wrap(err, "failed to stop cri service")
@@ -224,6 +478,14 @@ func (c *criService) Run() error {
 		eventMonitorErr = err
 	}
 	logrus.Info("Event monitor stopped")
+	<-cniNetConfMonitorDoneCh
+	logrus.Info("CNI network conf syncer stopped")
+	if c.grpcServer != nil {
+		if err := <-grpcServerErrCh; err != nil {
+			grpcServerErr = err
+		}
+		logrus.Info("Grpc server stopped")
+	}
 	// There is a race condition with http.Server.Serve.
 	// When `Close` is called at the same time with `Serve`, `Close`
 	// may finish first, and `Serve` may still block.
@@ -248,6 +510,9 @@ func (c *criService) Run() error {
 	if streamServerErr != nil {
 		return errors.Wrap(streamServerErr, "stream server error")
 	}
+	if grpcServerErr != nil {
+		return errors.Wrap(grpcServerErr, "grpc server error")
+	}
 	return nil
 }
 
@@ -256,6 +521,20 @@ func (c *criService) Run() error {
 func (c *criService) Close() error {
 	logrus.Info("Stop CRI service")
 	c.eventMonitor.stop()
+	if err := c.cniNetConfMonitor.stop(); err != nil {
+		logrus.WithError(err).Error("Failed to stop cni network conf monitor")
+	}
+	if c.configReloader != nil {
+		if err := c.configReloader.stop(); err != nil {
+			logrus.WithError(err).Error("Failed to stop config reloader")
+		}
+	}
+	if c.grpcServer != nil {
+		c.grpcServer.Stop()
+	}
+	if err := c.auditor.close(); err != nil {
+		logrus.WithError(err).Error("Failed to close audit log")
+	}
 	if err := c.streamServer.Stop(); err != nil {
 		return errors.Wrap(err, "failed to stop stream server")
 	}
@@ -39,6 +39,7 @@ const (
 
 // newTestCRIService creates a fake criService for test.
 func newTestCRIService() *criService {
+	netPlugin := servertesting.NewFakeCNIPlugin()
 	return &criService{
 		config: criconfig.Config{
 			RootDir:  testRootDir,
@@ -47,14 +48,19 @@ func newTestCRIService() *criService {
 				SandboxImage: testSandboxImage,
 			},
 		},
-		imageFSPath:        testImageFSPath,
+		imageFSPaths:       map[string]string{"": testImageFSPath},
 		os:                 ostesting.NewFakeOS(),
 		sandboxStore:       sandboxstore.NewStore(),
 		imageStore:         imagestore.NewStore(),
-		snapshotStore:      snapshotstore.NewStore(),
+		snapshotStores:     map[string]*snapshotstore.Store{"": snapshotstore.NewStore()},
 		sandboxNameIndex:   registrar.NewRegistrar(),
 		containerStore:     containerstore.NewStore(),
 		containerNameIndex: registrar.NewRegistrar(),
-		netPlugin:          servertesting.NewFakeCNIPlugin(),
+		netPlugin:          netPlugin,
+		cniNetConfMonitor:  &cniNetConfSyncer{netPlugin: netPlugin},
+		imagePullTracker:   newImagePullTracker(),
+		seccompProfiles:    newSeccompProfileCache(),
+		containerEvents:    newContainerEventBroadcaster(),
+		execLimiter:        &execLimiter{sessions: make(map[string]int)},
 	}
 }
@@ -0,0 +1,195 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"sort"
+	"strings"
+
+	imagedigest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	"github.com/containerd/cri/pkg/log"
+	imagestore "github.com/containerd/cri/pkg/store/image"
+	"github.com/containerd/cri/pkg/util"
+)
+
+const (
+	// shortNameDocker assumes the docker.io registry for short names.
+	shortNameDocker = "docker"
+	// shortNameSearch tries the configured registries in order.
+	shortNameSearch = "search"
+	// shortNameReject rejects short names.
+	shortNameReject = "reject"
+)
+
+// validateShortNameConfig validates the short name policies.
+func validateShortNameConfig(config criconfig.ShortNameConfig) error {
+	if err := validateShortNamePolicy(config.Default); err != nil {
+		return errors.Wrap(err, "invalid default policy")
+	}
+	for ns, policy := range config.Namespaces {
+		if err := validateShortNamePolicy(policy); err != nil {
+			return errors.Wrapf(err, "invalid policy of namespace %q", ns)
+		}
+	}
+	return nil
+}
+
+func validateShortNamePolicy(policy criconfig.ShortNamePolicy) error {
+	switch policy.Mode {
+	case "", shortNameDocker, shortNameReject:
+		return nil
+	case shortNameSearch:
+		if len(policy.Registries) == 0 {
+			return errors.New("no registries to search")
+		}
+		for _, r := range policy.Registries {
+			ref := r + "/image"
+			if isShortName(ref) {
+				return errors.Errorf("registry %q has no domain", r)
+			}
+			if _, err := util.NormalizeImageRef(ref); err != nil {
+				return errors.Wrapf(err, "invalid registry %q", r)
+			}
+		}
+		return nil
+	}
+	return errors.Errorf("unsupported mode %q", policy.Mode)
+}
+
+// isShortName returns whether an image reference has no registry domain. The
+// same rule as the docker reference library is used, i.e. the first component
+// is a domain if it contains "." or ":", or is "localhost".
+func isShortName(ref string) bool {
+	i := strings.IndexRune(ref, '/')
+	if i == -1 {
+		return true
+	}
+	domain := ref[:i]
+	return !strings.ContainsAny(domain, ".:") && domain != "localhost"
+}
+
+// expandShortName returns the candidate references of an image reference in
+// the order to try. A reference with registry is returned as is.
+func expandShortName(ref string, policy criconfig.ShortNamePolicy) ([]string, error) {
+	if !isShortName(ref) {
+		return []string{ref}, nil
+	}
+	switch policy.Mode {
+	case shortNameReject:
+		return nil, errors.Errorf("short name %q is rejected, use a fully qualified image name", ref)
+	case shortNameSearch:
+		var refs []string
+		for _, r := range policy.Registries {
+			refs = append(refs, r+"/"+ref)
+		}
+		return refs, nil
+	}
+	return []string{ref}, nil
+}
+
+// shortNamePolicy returns the short name policy of image pulls of pods in a
+// kubernetes namespace.
+func (c *criService) shortNamePolicy(namespace string) criconfig.ShortNamePolicy {
+	if policy, ok := c.config.Registry.ShortNames.Namespaces[namespace]; ok {
+		return policy
+	}
+	return c.config.Registry.ShortNames.Default
+}
+
+// localShortNameCandidates returns the candidate references to look up an
+// already pulled image with. Short names are looked up in the registries of
+// all policies, the default first and then by namespace, because the
+// namespace of the lookup is unknown.
+func (c *criService) localShortNameCandidates(ref string) []string {
+	if !isShortName(ref) {
+		return []string{ref}
+	}
+	var namespaces []string
+	for ns := range c.config.Registry.ShortNames.Namespaces {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	policies := []criconfig.ShortNamePolicy{c.config.Registry.ShortNames.Default}
+	for _, ns := range namespaces {
+		policies = append(policies, c.config.Registry.ShortNames.Namespaces[ns])
+	}
+	var refs []string
+	seen := make(map[string]bool)
+	for _, policy := range policies {
+		candidates, _ := expandShortName(ref, policy)
+		for _, candidate := range candidates {
+			if !seen[candidate] {
+				seen[candidate] = true
+				refs = append(refs, candidate)
+			}
+		}
+	}
+	return refs
+}
+
+// localResolveInNamespace resolves an image reference locally under the
+// short name policy of a kubernetes namespace, e.g. for a container of a pod
+// in the namespace, so that a short name doesn't resolve to an image of a
+// registry the policy of the namespace doesn't search. Image ids are
+// resolved as is.
+func (c *criService) localResolveInNamespace(ctx context.Context, refOrID, namespace string) (*imagestore.Image, error) {
+	if _, err := imagedigest.Parse(refOrID); err == nil {
+		return c.localResolve(ctx, refOrID)
+	}
+	candidates, err := expandShortName(refOrID, c.shortNamePolicy(namespace))
+	if err != nil {
+		return nil, err
+	}
+	return c.localResolveCandidates(ctx, refOrID, candidates)
+}
+
+// resolveShortName resolves the image reference of a pull under the short
+// name policy of the namespace. In "search" mode, the first candidate which
+// can be resolved by its registry is returned.
+func (c *criService) resolveShortName(ctx context.Context, ref string, auth *runtime.AuthConfig, namespace string) (string, error) {
+	candidates, err := expandShortName(ref, c.shortNamePolicy(namespace))
+	if err != nil {
+		return "", err
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	var lastErr error
+	for _, candidate := range candidates {
+		namedRef, err := util.NormalizeImageRef(candidate)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to parse image reference %q", candidate)
+		}
+		resolver, err := c.getResolver(ctx, namedRef, auth)
+		if err != nil {
+			return "", err
+		}
+		if _, _, err := resolver.Resolve(ctx, namedRef.String()); err != nil {
+			log.Image.WithError(err).Debugf("Failed to resolve short name %q as %q", ref, candidate)
+			lastErr = err
+			continue
+		}
+		log.Image.Debugf("Resolved short name %q as %q", ref, candidate)
+		return candidate, nil
+	}
+	return "", errors.Wrapf(lastErr, "failed to resolve short name %q in any registry", ref)
+}
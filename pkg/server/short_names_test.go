@@ -0,0 +1,204 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	imagestore "github.com/containerd/cri/pkg/store/image"
+)
+
+func TestValidateShortNameConfig(t *testing.T) {
+	for desc, test := range map[string]struct {
+		config    criconfig.ShortNameConfig
+		expectErr bool
+	}{
+		"should accept empty config": {},
+		"should accept search policy": {
+			config: criconfig.ShortNameConfig{
+				Default: criconfig.ShortNamePolicy{Mode: "search", Registries: []string{"registry.example.com/mirror", "docker.io"}},
+				Namespaces: map[string]criconfig.ShortNamePolicy{
+					"kube-system": {Mode: "reject"},
+				},
+			},
+		},
+		"should reject search policy without registries": {
+			config:    criconfig.ShortNameConfig{Default: criconfig.ShortNamePolicy{Mode: "search"}},
+			expectErr: true,
+		},
+		"should reject registry without domain": {
+			config: criconfig.ShortNameConfig{
+				Namespaces: map[string]criconfig.ShortNamePolicy{
+					"test": {Mode: "search", Registries: []string{"mirror"}},
+				},
+			},
+			expectErr: true,
+		},
+		"should reject unsupported mode": {
+			config:    criconfig.ShortNameConfig{Default: criconfig.ShortNamePolicy{Mode: "guess"}},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		err := validateShortNameConfig(test.config)
+		assert.Equal(t, test.expectErr, err != nil)
+	}
+}
+
+func TestExpandShortName(t *testing.T) {
+	search := criconfig.ShortNamePolicy{Mode: "search", Registries: []string{"quay.io", "docker.io"}}
+	for desc, test := range map[string]struct {
+		ref       string
+		policy    criconfig.ShortNamePolicy
+		expected  []string
+		expectErr bool
+	}{
+		"should keep short name in docker mode": {
+			ref:      "busybox",
+			expected: []string{"busybox"},
+		},
+		"should keep qualified name": {
+			ref:      "gcr.io/test/image:latest",
+			policy:   criconfig.ShortNamePolicy{Mode: "reject"},
+			expected: []string{"gcr.io/test/image:latest"},
+		},
+		"should keep localhost name": {
+			ref:      "localhost/image",
+			policy:   criconfig.ShortNamePolicy{Mode: "reject"},
+			expected: []string{"localhost/image"},
+		},
+		"should reject short name": {
+			ref:       "library/busybox",
+			policy:    criconfig.ShortNamePolicy{Mode: "reject"},
+			expectErr: true,
+		},
+		"should expand short name to search registries": {
+			ref:      "test/image:latest",
+			policy:   search,
+			expected: []string{"quay.io/test/image:latest", "docker.io/test/image:latest"},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		refs, err := expandShortName(test.ref, test.policy)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, refs)
+	}
+}
+
+func TestLocalShortNameCandidates(t *testing.T) {
+	c := newTestCRIService()
+	c.config.Registry.ShortNames = criconfig.ShortNameConfig{
+		Default: criconfig.ShortNamePolicy{Mode: "search", Registries: []string{"quay.io", "docker.io"}},
+		Namespaces: map[string]criconfig.ShortNamePolicy{
+			"b": {Mode: "reject"},
+			"a": {Mode: "search", Registries: []string{"gcr.io", "quay.io"}},
+		},
+	}
+	assert.Equal(t, []string{"quay.io/busybox", "docker.io/busybox", "gcr.io/busybox"},
+		c.localShortNameCandidates("busybox"))
+	assert.Equal(t, []string{"gcr.io/busybox"}, c.localShortNameCandidates("gcr.io/busybox"))
+}
+
+func TestLocalResolveInNamespace(t *testing.T) {
+	c := newTestCRIService()
+	c.config.Registry.ShortNames = criconfig.ShortNameConfig{
+		Namespaces: map[string]criconfig.ShortNamePolicy{
+			"rejected": {Mode: "reject"},
+		},
+	}
+	imageID := "sha256:c75bebcdd211f41b3a460c7bf82970ed6c75acaab9cd4c9a4e125b03ca113798"
+	require.NoError(t, c.imageStore.Add(imagestore.Image{ID: imageID}))
+
+	t.Logf("should reject short name under the policy of the namespace")
+	_, err := c.localResolveInNamespace(context.Background(), "busybox", "rejected")
+	assert.Error(t, err)
+
+	t.Logf("should resolve image id under any policy")
+	image, err := c.localResolveInNamespace(context.Background(), imageID, "rejected")
+	require.NoError(t, err)
+	require.NotNil(t, image)
+	assert.Equal(t, imageID, image.ID)
+}
+
+func TestResolveShortName(t *testing.T) {
+	// missing has no image, and found has all images.
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	found := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Content-Digest", "sha256:4d4e0b59b37f8cbd4cd1e8f3eb9bf1b5f1ff19f4a2c1c7ef2f86e0c86a8b3f4a")
+		w.Header().Set("Content-Length", "100")
+	}))
+	defer found.Close()
+	localhost := func(s *httptest.Server) string {
+		return strings.Replace(s.URL, "http://127.0.0.1", "localhost", 1)
+	}
+	c := newTestCRIService()
+	c.config.Registry.ShortNames = criconfig.ShortNameConfig{
+		Default: criconfig.ShortNamePolicy{Mode: "search", Registries: []string{localhost(missing), localhost(found)}},
+		Namespaces: map[string]criconfig.ShortNamePolicy{
+			"strict":  {Mode: "reject"},
+			"missing": {Mode: "search", Registries: []string{localhost(missing), localhost(missing) + "/mirror"}},
+		},
+	}
+	for desc, test := range map[string]struct {
+		ref       string
+		namespace string
+		expected  string
+		expectErr bool
+	}{
+		"should resolve short name in the first registry having it": {
+			ref:      "busybox:latest",
+			expected: localhost(found) + "/busybox:latest",
+		},
+		"should not resolve qualified name": {
+			ref:       "gcr.io/test/image",
+			namespace: "strict",
+			expected:  "gcr.io/test/image",
+		},
+		"should reject short name by namespace policy": {
+			ref:       "busybox",
+			namespace: "strict",
+			expectErr: true,
+		},
+		"should return error if no registry has the image": {
+			ref:       "busybox",
+			namespace: "missing",
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		ref, err := c.resolveShortName(context.Background(), test.ref, nil, test.namespace)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, ref)
+	}
+}
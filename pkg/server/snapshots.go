@@ -18,6 +18,8 @@ package server
 
 import (
 	"context"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/containerd/containerd/errdefs"
@@ -25,7 +27,9 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	criconfig "github.com/containerd/cri/pkg/config"
 	ctrdutil "github.com/containerd/cri/pkg/containerd/util"
+	containerstore "github.com/containerd/cri/pkg/store/container"
 	snapshotstore "github.com/containerd/cri/pkg/store/snapshot"
 )
 
@@ -37,15 +41,33 @@ type snapshotsSyncer struct {
 	store       *snapshotstore.Store
 	snapshotter snapshot.Snapshotter
 	syncPeriod  time.Duration
+	// namespace is the containerd namespace of the snapshots.
+	namespace string
+	// maxStaleness is the max age of the usage of an active snapshot.
+	maxStaleness time.Duration
+	// requested contains the snapshots with an on demand usage update queued
+	// or in progress.
+	requested map[string]bool
+	// queue contains the snapshots waiting for an on demand usage update.
+	queue []string
+	// workers is the number of workers running on demand usage updates.
+	workers       int
+	requestedLock sync.Mutex
+	// lastSyncStatus is the error of the last sync.
+	lastSyncStatus     error
+	lastSyncStatusLock sync.RWMutex
 }
 
 // newSnapshotsSyncer creates a snapshot syncer.
-func newSnapshotsSyncer(store *snapshotstore.Store, snapshotter snapshot.Snapshotter,
-	period time.Duration) *snapshotsSyncer {
+func newSnapshotsSyncer(store *snapshotstore.Store, snapshotter snapshot.Snapshotter, namespace string,
+	period, maxStaleness time.Duration) *snapshotsSyncer {
 	return &snapshotsSyncer{
-		store:       store,
-		snapshotter: snapshotter,
-		syncPeriod:  period,
+		store:        store,
+		snapshotter:  snapshotter,
+		namespace:    namespace,
+		syncPeriod:   period,
+		maxStaleness: maxStaleness,
+		requested:    make(map[string]bool),
 	}
 }
 
@@ -59,17 +81,28 @@ func (s *snapshotsSyncer) start() {
 		// TODO(random-liu): This is expensive. We should do benchmark to
 		// check the resource usage and optimize this.
 		for {
-			if err := s.sync(); err != nil {
+			err := s.sync()
+			if err != nil {
 				logrus.WithError(err).Error("Failed to sync snapshot stats")
 			}
+			s.lastSyncStatusLock.Lock()
+			s.lastSyncStatus = err
+			s.lastSyncStatusLock.Unlock()
 			<-tick.C
 		}
 	}()
 }
 
+// lastStatus returns the error of the last sync.
+func (s *snapshotsSyncer) lastStatus() error {
+	s.lastSyncStatusLock.RLock()
+	defer s.lastSyncStatusLock.RUnlock()
+	return s.lastSyncStatus
+}
+
 // sync updates all snapshots stats.
 func (s *snapshotsSyncer) sync() error {
-	ctx := ctrdutil.NamespacedContext()
+	ctx := ctrdutil.NamespacedContext(s.namespace)
 	start := time.Now().UnixNano()
 	var snapshots []snapshot.Info
 	// Do not call `Usage` directly in collect function, because
@@ -82,35 +115,31 @@ func (s *snapshotsSyncer) sync() error {
 	}); err != nil {
 		return errors.Wrap(err, "walk all snapshots failed")
 	}
+	seen := make(map[string]bool)
 	for _, info := range snapshots {
+		seen[info.Name] = true
 		sn, err := s.store.Get(info.Name)
-		if err == nil {
+		if err == nil && sn.Kind == info.Kind {
 			// Only update timestamp for non-active snapshot.
-			if sn.Kind == info.Kind && sn.Kind != snapshot.KindActive {
+			if sn.Kind != snapshot.KindActive {
 				sn.Timestamp = time.Now().UnixNano()
 				s.store.Add(sn)
 				continue
 			}
+			// Keep the usage of an active snapshot if it won't be stale
+			// by the next sync.
+			age := time.Duration(time.Now().UnixNano() - sn.Timestamp)
+			if age+s.syncPeriod <= s.maxStaleness {
+				continue
+			}
 		}
 		// Get newest stats if the snapshot is new or active.
-		sn = snapshotstore.Snapshot{
-			Key:       info.Name,
-			Kind:      info.Kind,
-			Timestamp: time.Now().UnixNano(),
+		if err := s.update(ctx, info); err != nil && !errdefs.IsNotFound(err) {
+			logrus.WithError(err).Errorf("Failed to get usage for snapshot %q", info.Name)
 		}
-		usage, err := s.snapshotter.Usage(ctx, info.Name)
-		if err != nil {
-			if !errdefs.IsNotFound(err) {
-				logrus.WithError(err).Errorf("Failed to get usage for snapshot %q", info.Name)
-			}
-			continue
-		}
-		sn.Size = uint64(usage.Size)
-		sn.Inodes = uint64(usage.Inodes)
-		s.store.Add(sn)
 	}
 	for _, sn := range s.store.List() {
-		if sn.Timestamp >= start {
+		if seen[sn.Key] || sn.Timestamp >= start {
 			continue
 		}
 		// Delete the snapshot stats if it's not updated this time.
@@ -118,3 +147,108 @@ func (s *snapshotsSyncer) sync() error {
 	}
 	return nil
 }
+
+// update gets the usage of a snapshot and adds it into the store.
+func (s *snapshotsSyncer) update(ctx context.Context, info snapshot.Info) error {
+	usage, err := s.snapshotter.Usage(ctx, info.Name)
+	if err != nil {
+		return err
+	}
+	s.store.Add(snapshotstore.Snapshot{
+		Key:       info.Name,
+		Kind:      info.Kind,
+		Size:      uint64(usage.Size),
+		Inodes:    uint64(usage.Inodes),
+		Timestamp: time.Now().UnixNano(),
+	})
+	return nil
+}
+
+// maxSnapshotUsageWorkers is the max number of on demand snapshot usage
+// updates running at the same time, so that listing the stats of many new
+// containers doesn't run a disk usage walk per container at once.
+const maxSnapshotUsageWorkers = 4
+
+// request updates the usage of a snapshot missing from the store in the
+// background, so that a new container doesn't report an empty writable
+// layer until the next sync. Concurrent requests of a snapshot are merged,
+// and the updates are run by a bounded number of workers.
+func (s *snapshotsSyncer) request(key string) {
+	s.requestedLock.Lock()
+	defer s.requestedLock.Unlock()
+	if s.requested[key] {
+		return
+	}
+	s.requested[key] = true
+	s.queue = append(s.queue, key)
+	if s.workers < maxSnapshotUsageWorkers {
+		s.workers++
+		go s.runRequests()
+	}
+}
+
+// runRequests runs the queued on demand usage updates until the queue is
+// empty.
+func (s *snapshotsSyncer) runRequests() {
+	ctx := ctrdutil.NamespacedContext(s.namespace)
+	for {
+		s.requestedLock.Lock()
+		if len(s.queue) == 0 {
+			s.workers--
+			s.requestedLock.Unlock()
+			return
+		}
+		key := s.queue[0]
+		s.queue = s.queue[1:]
+		s.requestedLock.Unlock()
+
+		info, err := s.snapshotter.Stat(ctx, key)
+		if err == nil {
+			err = s.update(ctx, info)
+		}
+		if err != nil && !errdefs.IsNotFound(err) {
+			logrus.WithError(err).Errorf("Failed to get usage for snapshot %q", key)
+		}
+
+		s.requestedLock.Lock()
+		delete(s.requested, key)
+		s.requestedLock.Unlock()
+	}
+}
+
+// namedRuntimes returns all configured runtimes by name, including the
+// default and untrusted workload runtimes.
+func (c *criService) namedRuntimes() map[string]criconfig.Runtime {
+	runtimes := map[string]criconfig.Runtime{
+		"default_runtime":            c.config.ContainerdConfig.DefaultRuntime,
+		"untrusted_workload_runtime": c.config.ContainerdConfig.UntrustedWorkloadRuntime,
+	}
+	for handler, r := range c.config.ContainerdConfig.Runtimes {
+		runtimes[handler] = r
+	}
+	return runtimes
+}
+
+// snapshotters returns all snapshotters used by the configured runtimes,
+// with the default snapshotter first.
+func (c *criService) snapshotters() []string {
+	var others []string
+	seen := map[string]bool{c.config.ContainerdConfig.Snapshotter: true}
+	for _, r := range c.namedRuntimes() {
+		if r.Snapshotter != "" && !seen[r.Snapshotter] {
+			seen[r.Snapshotter] = true
+			others = append(others, r.Snapshotter)
+		}
+	}
+	sort.Strings(others)
+	return append([]string{c.config.ContainerdConfig.Snapshotter}, others...)
+}
+
+// containerSnapshotter returns the snapshotter of a container. Containers
+// created before per runtime snapshotters use the default snapshotter.
+func (c *criService) containerSnapshotter(meta containerstore.Metadata) string {
+	if meta.Snapshotter == "" {
+		return c.config.ContainerdConfig.Snapshotter
+	}
+	return meta.Snapshotter
+}
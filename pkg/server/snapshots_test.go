@@ -0,0 +1,192 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	snapshot "github.com/containerd/containerd/snapshots"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+	"github.com/containerd/cri/pkg/constants"
+	snapshotstore "github.com/containerd/cri/pkg/store/snapshot"
+)
+
+// fakeSnapshotter is a snapshotter of active snapshots, which only supports
+// Stat, Walk and Usage.
+type fakeSnapshotter struct {
+	snapshot.Snapshotter
+	usages map[string]snapshot.Usage
+}
+
+func (f *fakeSnapshotter) Stat(ctx context.Context, key string) (snapshot.Info, error) {
+	if _, ok := f.usages[key]; !ok {
+		return snapshot.Info{}, errdefs.ErrNotFound
+	}
+	return snapshot.Info{Name: key, Kind: snapshot.KindActive}, nil
+}
+
+func (f *fakeSnapshotter) Walk(ctx context.Context, fn func(context.Context, snapshot.Info) error) error {
+	for key := range f.usages {
+		if err := fn(ctx, snapshot.Info{Name: key, Kind: snapshot.KindActive}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeSnapshotter) Usage(ctx context.Context, key string) (snapshot.Usage, error) {
+	u, ok := f.usages[key]
+	if !ok {
+		return snapshot.Usage{}, errdefs.ErrNotFound
+	}
+	return u, nil
+}
+
+func TestSnapshotsSyncerRequest(t *testing.T) {
+	store := snapshotstore.NewStore()
+	s := newSnapshotsSyncer(store, &fakeSnapshotter{
+		usages: map[string]snapshot.Usage{"key1": {Size: 10, Inodes: 100}},
+	}, constants.K8sContainerdNamespace, time.Minute, 0)
+
+	s.request("key1")
+	s.request("key2")
+	var sn snapshotstore.Snapshot
+	var err error
+	for i := 0; i < 100; i++ {
+		if sn, err = store.Get("key1"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err)
+	assert.Equal(t, "key1", sn.Key)
+	assert.Equal(t, snapshot.KindActive, sn.Kind)
+	assert.EqualValues(t, 10, sn.Size)
+	assert.EqualValues(t, 100, sn.Inodes)
+	assert.NotZero(t, sn.Timestamp)
+
+	t.Logf("missing snapshot should not be added")
+	_, err = store.Get("key2")
+	assert.Error(t, err)
+}
+
+// blockingSnapshotter is a fakeSnapshotter whose Usage blocks until it is
+// released, and which records the max number of concurrent Usage calls.
+type blockingSnapshotter struct {
+	fakeSnapshotter
+	release chan struct{}
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (b *blockingSnapshotter) Usage(ctx context.Context, key string) (snapshot.Usage, error) {
+	b.mu.Lock()
+	b.running++
+	if b.running > b.max {
+		b.max = b.running
+	}
+	b.mu.Unlock()
+	<-b.release
+	b.mu.Lock()
+	b.running--
+	b.mu.Unlock()
+	return b.fakeSnapshotter.Usage(ctx, key)
+}
+
+func TestSnapshotsSyncerRequestWorkers(t *testing.T) {
+	usages := make(map[string]snapshot.Usage)
+	for i := 0; i < 3*maxSnapshotUsageWorkers; i++ {
+		usages[fmt.Sprintf("key%d", i)] = snapshot.Usage{Size: int64(i)}
+	}
+	store := snapshotstore.NewStore()
+	b := &blockingSnapshotter{
+		fakeSnapshotter: fakeSnapshotter{usages: usages},
+		release:         make(chan struct{}),
+	}
+	s := newSnapshotsSyncer(store, b, constants.K8sContainerdNamespace, time.Minute, 0)
+	for key := range usages {
+		s.request(key)
+	}
+	close(b.release)
+	for i := 0; i < 100 && len(store.List()) < len(usages); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Len(t, store.List(), len(usages))
+
+	t.Logf("should not run more usage updates than the max workers at the same time")
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	assert.True(t, b.max <= maxSnapshotUsageWorkers, "max concurrent usage updates %d", b.max)
+}
+
+func TestSnapshotsSyncerSyncMaxStaleness(t *testing.T) {
+	now := time.Now().UnixNano()
+	store := snapshotstore.NewStore()
+	for _, sn := range []snapshotstore.Snapshot{
+		{Key: "fresh", Kind: snapshot.KindActive, Size: 1, Inodes: 1, Timestamp: now},
+		{Key: "stale", Kind: snapshot.KindActive, Size: 1, Inodes: 1, Timestamp: now - int64(50*time.Minute)},
+		{Key: "removed", Kind: snapshot.KindActive, Size: 1, Inodes: 1, Timestamp: now},
+	} {
+		store.Add(sn)
+	}
+	s := newSnapshotsSyncer(store, &fakeSnapshotter{
+		usages: map[string]snapshot.Usage{
+			"fresh": {Size: 10, Inodes: 100},
+			"stale": {Size: 20, Inodes: 200},
+			"new":   {Size: 30, Inodes: 300},
+		},
+	}, constants.K8sContainerdNamespace, 15*time.Minute, time.Hour)
+	// The removed snapshot must be older than the sync to be deleted.
+	time.Sleep(time.Millisecond)
+	require.NoError(t, s.sync())
+
+	for key, expected := range map[string]uint64{
+		"fresh": 1,
+		"stale": 20,
+		"new":   30,
+	} {
+		t.Logf("snapshot %q", key)
+		sn, err := store.Get(key)
+		require.NoError(t, err)
+		assert.Equal(t, expected, sn.Size)
+	}
+	_, err := store.Get("removed")
+	assert.Error(t, err)
+}
+
+func TestSnapshotters(t *testing.T) {
+	c := newTestCRIService()
+	c.config.ContainerdConfig.Snapshotter = "overlayfs"
+	c.config.ContainerdConfig.Runtimes = map[string]criconfig.Runtime{
+		"runc":   {},
+		"kata":   {Snapshotter: "devmapper"},
+		"gvisor": {Snapshotter: "btrfs"},
+		"runsc":  {Snapshotter: "overlayfs"},
+	}
+	c.config.ContainerdConfig.DefaultRuntime = criconfig.Runtime{Snapshotter: "native"}
+	c.config.ContainerdConfig.UntrustedWorkloadRuntime = criconfig.Runtime{Snapshotter: "zfs"}
+	assert.Equal(t, []string{"overlayfs", "btrfs", "devmapper", "native", "zfs"}, c.snapshotters())
+}
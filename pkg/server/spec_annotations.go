@@ -0,0 +1,58 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"path/filepath"
+
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/pkg/errors"
+)
+
+// validateAnnotationPatterns validates the patterns of a runtime annotation
+// allow-list.
+func validateAnnotationPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return errors.Wrapf(err, "invalid annotation pattern %q", p)
+		}
+	}
+	return nil
+}
+
+// passthroughAnnotations returns the annotations whose keys match any of the
+// patterns of an allow-list, e.g. "io.katacontainers.*".
+func passthroughAnnotations(annotations map[string]string, patterns []string) map[string]string {
+	passthrough := make(map[string]string)
+	for key, value := range annotations {
+		for _, p := range patterns {
+			if ok, _ := filepath.Match(p, key); ok {
+				passthrough[key] = value
+				break
+			}
+		}
+	}
+	return passthrough
+}
+
+// addSpecAnnotations adds the allowed annotations to the spec. They are
+// added before the cri annotations, which can't be overridden by them.
+func addSpecAnnotations(g *generate.Generator, annotations map[string]string, patterns []string) {
+	for key, value := range passthroughAnnotations(annotations, patterns) {
+		g.AddAnnotation(key, value)
+	}
+}
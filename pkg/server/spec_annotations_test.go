@@ -0,0 +1,98 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containerd/cri/pkg/annotations"
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestPassthroughAnnotations(t *testing.T) {
+	podAnnotations := map[string]string{
+		"io.katacontainers.config.hypervisor.default_vcpus":  "2",
+		"io.katacontainers.config.hypervisor.default_memory": "512",
+		"example.com/owner": "team",
+	}
+	for desc, test := range map[string]struct {
+		patterns []string
+		expected map[string]string
+	}{
+		"should pass nothing without patterns": {
+			expected: map[string]string{},
+		},
+		"should pass annotations matching a glob": {
+			patterns: []string{"io.katacontainers.*"},
+			expected: map[string]string{
+				"io.katacontainers.config.hypervisor.default_vcpus":  "2",
+				"io.katacontainers.config.hypervisor.default_memory": "512",
+			},
+		},
+		"should pass annotations matching exactly": {
+			patterns: []string{"example.com/owner", "example.com/unknown"},
+			expected: map[string]string{"example.com/owner": "team"},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		assert.Equal(t, test.expected, passthroughAnnotations(podAnnotations, test.patterns))
+	}
+}
+
+func TestValidateAnnotationPatterns(t *testing.T) {
+	assert.NoError(t, validateAnnotationPatterns([]string{"io.katacontainers.*", "example.com/owner"}))
+	assert.Error(t, validateAnnotationPatterns([]string{"io.katacontainers.["}))
+}
+
+func TestSpecAnnotationsPassthrough(t *testing.T) {
+	c := newTestCRIService()
+	ociRuntime := criconfig.Runtime{
+		PodAnnotations:       []string{"io.katacontainers.*", annotations.SandboxID},
+		ContainerAnnotations: []string{"example.com/*"},
+	}
+	config, sandboxConfig, imageConfig, _ := getCreateContainerTestData()
+	sandboxConfig.Annotations = map[string]string{
+		"io.katacontainers.config.hypervisor.default_vcpus": "2",
+		annotations.SandboxID:                               "fake-sandbox-id",
+		"pod.example.com/owner":                             "team",
+	}
+	config.Annotations = map[string]string{
+		"example.com/profile":                                "fast",
+		"io.katacontainers.config.hypervisor.default_memory": "512",
+	}
+
+	t.Logf("should pass allowed pod and container annotations into container spec")
+	spec, err := c.generateContainerSpec("test-id", "sandbox-id", 1234, config, sandboxConfig, imageConfig, nil, ociRuntime)
+	require.NoError(t, err)
+	assert.Equal(t, "2", spec.Annotations["io.katacontainers.config.hypervisor.default_vcpus"])
+	assert.Equal(t, "fast", spec.Annotations["example.com/profile"])
+	assert.NotContains(t, spec.Annotations, "pod.example.com/owner")
+	assert.NotContains(t, spec.Annotations, "io.katacontainers.config.hypervisor.default_memory")
+	assert.Equal(t, "sandbox-id", spec.Annotations[annotations.SandboxID], "cri annotations should not be overridden")
+
+	t.Logf("should pass allowed pod annotations into sandbox spec")
+	podConfig, podImageConfig, _ := getRunPodSandboxTestData()
+	podConfig.Annotations = sandboxConfig.Annotations
+	spec, err = c.generateSandboxContainerSpec("sandbox-id", podConfig, podImageConfig, "test-netns", ociRuntime, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2", spec.Annotations["io.katacontainers.config.hypervisor.default_vcpus"])
+	assert.NotContains(t, spec.Annotations, "pod.example.com/owner")
+	assert.Equal(t, "sandbox-id", spec.Annotations[annotations.SandboxID], "cri annotations should not be overridden")
+}
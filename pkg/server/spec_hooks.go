@@ -0,0 +1,146 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+// Spec hooks are executables adjusting the OCI spec of sandboxes and
+// containers, invoked with the event name as argument and a json request on
+// stdin. This is a simple protocol of the cri plugin, it is not the node
+// resource interface (NRI) of containerd, and NRI plugins can't be used as
+// spec hooks.
+
+const (
+	// specHookCreateSandbox is the event of creating a sandbox.
+	specHookCreateSandbox = "CreateSandbox"
+	// specHookCreateContainer is the event of creating a container.
+	specHookCreateContainer = "CreateContainer"
+	// specHookUpdateContainer is the event of updating container resources.
+	specHookUpdateContainer = "UpdateContainer"
+	// specHookRemoveContainer is the event of removing a container.
+	specHookRemoveContainer = "RemoveContainer"
+	// specHookRemoveSandbox is the event of removing a sandbox.
+	specHookRemoveSandbox = "RemoveSandbox"
+)
+
+// specHookRequest is the request written to the stdin of a spec hook.
+type specHookRequest struct {
+	Event       string            `json:"event"`
+	ID          string            `json:"id"`
+	SandboxID   string            `json:"sandboxID"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Spec        *runtimespec.Spec `json:"spec,omitempty"`
+}
+
+// specHooks invokes the configured spec hooks. A nil *specHooks invokes
+// nothing.
+type specHooks struct {
+	paths   []string
+	timeout time.Duration
+}
+
+// newSpecHooks creates the spec hooks from the config. It returns nil if no
+// hook is configured.
+func newSpecHooks(config criconfig.SpecHooksConfig) (*specHooks, error) {
+	if len(config.Plugins) == 0 {
+		return nil, nil
+	}
+	timeout, err := parseOptionalDuration(config.PluginTimeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid plugin timeout")
+	}
+	n := &specHooks{timeout: timeout}
+	for _, name := range config.Plugins {
+		n.paths = append(n.paths, filepath.Join(config.PluginDir, name))
+	}
+	return n, nil
+}
+
+// adjustSpec invokes the hooks in order with the spec, and returns the spec
+// adjusted by them. Each hook gets the spec adjusted by the previous one.
+// An adjusted spec without process, linux or root is rejected.
+func (n *specHooks) adjustSpec(ctx context.Context, req specHookRequest) (*runtimespec.Spec, error) {
+	if n == nil {
+		return req.Spec, nil
+	}
+	for _, path := range n.paths {
+		out, err := n.invoke(ctx, path, req)
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(out)) == 0 {
+			continue
+		}
+		var spec runtimespec.Spec
+		if err := json.Unmarshal(out, &spec); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal spec from spec hook %q", path)
+		}
+		if spec.Process == nil || spec.Linux == nil || spec.Root == nil {
+			return nil, errors.Errorf("spec hook %q returned spec without process, linux or root", path)
+		}
+		req.Spec = &spec
+	}
+	return req.Spec, nil
+}
+
+// notify invokes the hooks in order, failures are only logged.
+func (n *specHooks) notify(ctx context.Context, req specHookRequest) {
+	if n == nil {
+		return
+	}
+	for _, path := range n.paths {
+		if _, err := n.invoke(ctx, path, req); err != nil {
+			logrus.WithError(err).Errorf("Failed to notify spec hook of %s %q", req.Event, req.ID)
+		}
+	}
+}
+
+// invoke runs a hook with the request and returns its stdout.
+func (n *specHooks) invoke(ctx context.Context, path string, req specHookRequest) ([]byte, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal spec hook request")
+	}
+	if n.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.timeout)
+		defer cancel()
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, req.Event)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "spec hook %q failed on %s with stderr %q", path, req.Event, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestSpecHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-spec-hooks")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	hooks := map[string]string{
+		// noop consumes the request and keeps the spec.
+		"noop": "#!/bin/sh\ncat >/dev/null\n",
+		// env replaces the spec, checking the event argument.
+		"env": "#!/bin/sh\ncat >/dev/null\n" +
+			`[ "$1" = CreateContainer ] || exit 1` + "\n" +
+			`echo '{"ociVersion": "1.0.1", "process": {"cwd": "/", "env": ["INJECTED=true"]}, "root": {"path": "rootfs"}, "linux": {}}'` + "\n",
+		// empty returns an empty spec.
+		"empty": "#!/bin/sh\ncat >/dev/null\necho '{}'\n",
+		// fail always fails.
+		"fail": "#!/bin/sh\necho failure >&2\nexit 1\n",
+		// record records the request.
+		"record": "#!/bin/sh\ncat >" + filepath.Join(dir, "request") + "\n",
+	}
+	for name, script := range hooks {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755))
+	}
+	spec := &runtimespec.Spec{Version: "1.0.1", Process: &runtimespec.Process{Cwd: "/"}}
+
+	for desc, test := range map[string]struct {
+		plugins     []string
+		event       string
+		expectErr   bool
+		expectedEnv []string
+	}{
+		"should keep spec without hooks": {
+			event: specHookCreateContainer,
+		},
+		"should keep spec if hook prints nothing": {
+			plugins: []string{"noop"},
+			event:   specHookCreateContainer,
+		},
+		"should adjust spec": {
+			plugins:     []string{"noop", "env"},
+			event:       specHookCreateContainer,
+			expectedEnv: []string{"INJECTED=true"},
+		},
+		"should pass adjusted spec to the next hook": {
+			plugins:     []string{"env", "noop"},
+			event:       specHookCreateContainer,
+			expectedEnv: []string{"INJECTED=true"},
+		},
+		"should reject spec without process, linux or root": {
+			plugins:   []string{"empty"},
+			event:     specHookCreateContainer,
+			expectErr: true,
+		},
+		"should return error if hook fails": {
+			plugins:   []string{"env"},
+			event:     specHookCreateSandbox,
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		n, err := newSpecHooks(criconfig.SpecHooksConfig{PluginDir: dir, Plugins: test.plugins, PluginTimeout: "10s"})
+		require.NoError(t, err)
+		adjusted, err := n.adjustSpec(context.Background(), specHookRequest{Event: test.event, ID: "test-id", Spec: spec})
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expectedEnv, adjusted.Process.Env)
+	}
+
+	t.Logf("should only log failures of notified hooks")
+	n, err := newSpecHooks(criconfig.SpecHooksConfig{PluginDir: dir, Plugins: []string{"fail", "record"}})
+	require.NoError(t, err)
+	n.notify(context.Background(), specHookRequest{Event: specHookRemoveContainer, ID: "test-id", SandboxID: "sandbox-id"})
+	data, err := ioutil.ReadFile(filepath.Join(dir, "request"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"event": "RemoveContainer", "id": "test-id", "sandboxID": "sandbox-id"}`, string(data))
+
+	t.Logf("should return error with invalid plugin timeout")
+	_, err = newSpecHooks(criconfig.SpecHooksConfig{Plugins: []string{"noop"}, PluginTimeout: "invalid"})
+	assert.Error(t, err)
+}
@@ -20,9 +20,10 @@ import (
 	"encoding/json"
 	"fmt"
 	goruntime "runtime"
+	"strings"
+	"sync"
 
-	cni "github.com/containerd/go-cni"
-	"github.com/sirupsen/logrus"
+	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 )
@@ -30,6 +31,47 @@ import (
 // networkNotReadyReason is the reason reported when network is not ready.
 const networkNotReadyReason = "NetworkPluginNotReady"
 
+// Conditions of the subsystems of the cri plugin, in addition to the ones
+// required by kubelet. They are only informational, kubelet ignores them.
+const (
+	// cniConfigValid is whether the cni config in the config directory is
+	// loaded successfully.
+	cniConfigValid = "CNIConfigValid"
+	// snapshotterReady is whether the snapshots of all snapshotters are
+	// listed successfully.
+	snapshotterReady = "SnapshotterReady"
+	// streamingServerReady is whether the streaming server is serving.
+	streamingServerReady = "StreamingServerReady"
+	// recoverySucceeded is whether all sandboxes and containers are
+	// recovered on restart.
+	recoverySucceeded = "RecoverySucceeded"
+	// configReloaded is whether the last reload of the reloadable config
+	// file succeeded. It is only reported if the file is configured.
+	configReloaded = "ConfigReloaded"
+)
+
+// maxRecoveryErrorsReported is the max number of recovery errors in the
+// message of the recovery condition.
+const maxRecoveryErrorsReported = 10
+
+// lastError is the last error of a subsystem.
+type lastError struct {
+	sync.RWMutex
+	err error
+}
+
+func (l *lastError) set(err error) {
+	l.Lock()
+	defer l.Unlock()
+	l.err = err
+}
+
+func (l *lastError) get() error {
+	l.RLock()
+	defer l.RUnlock()
+	return l.err
+}
+
 // Status returns the status of the runtime.
 func (c *criService) Status(ctx context.Context, r *runtime.StatusRequest) (*runtime.StatusResponse, error) {
 	// As a containerd plugin, if CRI plugin is serving request,
@@ -43,22 +85,23 @@ func (c *criService) Status(ctx context.Context, r *runtime.StatusRequest) (*run
 		Status: true,
 	}
 
-	// Load the latest cni configuration to be in sync with the latest network configuration
-	if err := c.netPlugin.Load(cni.WithLoNetwork, cni.WithDefaultConf); err != nil {
-		logrus.WithError(err).Errorf("Failed to load cni configuration")
-	}
-	// Check the status of the cni initialization
+	// Check the status of the cni initialization. The cni configuration is
+	// reloaded by the cni network conf monitor whenever it is changed.
 	if err := c.netPlugin.Status(); err != nil {
 		networkCondition.Status = false
 		networkCondition.Reason = networkNotReadyReason
 		networkCondition.Message = fmt.Sprintf("Network plugin returns error: %v", err)
+	} else if err := c.cniNetConfMonitor.lastStatus(); err != nil {
+		networkCondition.Status = false
+		networkCondition.Reason = networkNotReadyReason
+		networkCondition.Message = fmt.Sprintf("Failed to load cni configuration: %v", err)
 	}
 
 	resp := &runtime.StatusResponse{
-		Status: &runtime.RuntimeStatus{Conditions: []*runtime.RuntimeCondition{
+		Status: &runtime.RuntimeStatus{Conditions: append([]*runtime.RuntimeCondition{
 			runtimeCondition,
 			networkCondition,
-		}},
+		}, c.subsystemConditions()...)},
 	}
 	if r.Verbose {
 		configByt, err := json.Marshal(c.config)
@@ -67,11 +110,74 @@ func (c *criService) Status(ctx context.Context, r *runtime.StatusRequest) (*run
 		}
 		resp.Info = make(map[string]string)
 		resp.Info["config"] = string(configByt)
+		reloadableConfigByt, err := json.Marshal(c.reloadableConfig())
+		if err != nil {
+			return nil, err
+		}
+		resp.Info["reloadableConfig"] = string(reloadableConfigByt)
 		versionByt, err := json.Marshal(goruntime.Version())
 		if err != nil {
 			return nil, err
 		}
 		resp.Info["golang"] = string(versionByt)
+		drainingByt, err := json.Marshal(c.drain.isDraining())
+		if err != nil {
+			return nil, err
+		}
+		resp.Info["draining"] = string(drainingByt)
 	}
 	return resp, nil
 }
+
+// subsystemConditions returns the conditions of the subsystems, with the
+// last errors of the failed ones in the messages.
+func (c *criService) subsystemConditions() []*runtime.RuntimeCondition {
+	var snapshotterErrs []string
+	for _, snapshotter := range c.snapshotters() {
+		if syncer, ok := c.snapshotsSyncers[snapshotter]; ok {
+			if err := syncer.lastStatus(); err != nil {
+				snapshotterErrs = append(snapshotterErrs, fmt.Sprintf("%s: %v", snapshotter, err))
+			}
+		}
+	}
+	var snapshotterErr error
+	if len(snapshotterErrs) > 0 {
+		snapshotterErr = errors.New(strings.Join(snapshotterErrs, "; "))
+	}
+	var recoveryErr error
+	if n := len(c.recoveryErrors); n > 0 {
+		var msgs []string
+		for i, err := range c.recoveryErrors {
+			if i == maxRecoveryErrorsReported {
+				msgs = append(msgs, fmt.Sprintf("and %d more", n-i))
+				break
+			}
+			msgs = append(msgs, err.Error())
+		}
+		recoveryErr = errors.Errorf("%d sandboxes or containers are not recovered: %s", n, strings.Join(msgs, "; "))
+	}
+	conditions := []*runtime.RuntimeCondition{
+		toRuntimeCondition(cniConfigValid, "CNIConfigInvalid", c.cniNetConfMonitor.lastStatus()),
+		toRuntimeCondition(snapshotterReady, "SnapshotterNotReady", snapshotterErr),
+		toRuntimeCondition(streamingServerReady, "StreamingServerNotReady", c.streamServerStatus.get()),
+		toRuntimeCondition(recoverySucceeded, "RecoveryFailed", recoveryErr),
+	}
+	if c.configReloader != nil {
+		conditions = append(conditions, toRuntimeCondition(configReloaded, "ConfigReloadFailed", c.lastConfigReloadStatus()))
+	}
+	return conditions
+}
+
+// toRuntimeCondition returns a condition which is true if err is nil, and
+// false with the reason and the error as message otherwise.
+func toRuntimeCondition(conditionType, reason string, err error) *runtime.RuntimeCondition {
+	if err == nil {
+		return &runtime.RuntimeCondition{Type: conditionType, Status: true}
+	}
+	return &runtime.RuntimeCondition{
+		Type:    conditionType,
+		Status:  false,
+		Reason:  reason,
+		Message: err.Error(),
+	}
+}
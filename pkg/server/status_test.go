@@ -0,0 +1,58 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func TestSubsystemConditions(t *testing.T) {
+	c := newTestCRIService()
+	c.config.ContainerdConfig.Snapshotter = "overlayfs"
+	c.snapshotsSyncers = map[string]*snapshotsSyncer{"overlayfs": {}}
+
+	t.Logf("should report all subsystems ready")
+	for _, condition := range c.subsystemConditions() {
+		assert.True(t, condition.Status, condition.Type)
+	}
+
+	t.Logf("should report last errors of failed subsystems")
+	c.cniNetConfMonitor.updateLastStatus(errors.New("invalid character '}'"))
+	c.snapshotsSyncers["overlayfs"].lastSyncStatus = errors.New("walk all snapshots failed")
+	c.streamServerStatus.set(errors.New("address already in use"))
+	for i := 0; i < maxRecoveryErrorsReported+2; i++ {
+		c.recoveryErrors = append(c.recoveryErrors, fmt.Errorf("failed to load container %d", i))
+	}
+	conditions := make(map[string]*runtime.RuntimeCondition)
+	for _, condition := range c.subsystemConditions() {
+		assert.False(t, condition.Status, condition.Type)
+		conditions[condition.Type] = condition
+	}
+	require.Len(t, conditions, 4)
+	assert.Equal(t, "CNIConfigInvalid", conditions[cniConfigValid].Reason)
+	assert.Equal(t, "invalid character '}'", conditions[cniConfigValid].Message)
+	assert.Equal(t, "overlayfs: walk all snapshots failed", conditions[snapshotterReady].Message)
+	assert.Equal(t, "address already in use", conditions[streamingServerReady].Message)
+	assert.Contains(t, conditions[recoverySucceeded].Message, "12 sandboxes or containers are not recovered")
+	assert.Contains(t, conditions[recoverySucceeded].Message, "failed to load container 9; and 2 more")
+}
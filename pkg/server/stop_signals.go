@@ -0,0 +1,104 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/pkg/signal"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/containerd/cri/pkg/annotations"
+	containerstore "github.com/containerd/cri/pkg/store/container"
+)
+
+// stopStep is a signal sent to stop a container, and how long to wait for
+// the container to stop before the next step. Zero timeout waits for the
+// rest of the grace period.
+type stopStep struct {
+	signal  syscall.Signal
+	timeout time.Duration
+}
+
+// parseStopSignals parses stop steps like "SIGTERM:10s,SIGINT:5s".
+func parseStopSignals(s string) ([]stopStep, error) {
+	var steps []stopStep
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, ":", 2)
+		sig, err := signal.ParseSignal(parts[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid stop signal %q", item)
+		}
+		step := stopStep{signal: sig}
+		if len(parts) == 2 {
+			step.timeout, err = time.ParseDuration(parts[1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid stop signal %q", item)
+			}
+			if step.timeout <= 0 {
+				return nil, errors.Errorf("non-positive timeout of stop signal %q", item)
+			}
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 {
+		return nil, errors.New("no stop signal")
+	}
+	return steps, nil
+}
+
+// getStopSteps returns the steps stopping a container. The stop signals
+// annotation of the sandbox overrides the stop signal of the image, which
+// defaults to SIGTERM.
+func (c *criService) getStopSteps(container containerstore.Container) ([]stopStep, error) {
+	// The sandbox may be removed already, e.g. when the container is
+	// stopped after being recovered without its sandbox.
+	if sandbox, err := c.sandboxStore.Get(container.SandboxID); err == nil {
+		if s, ok := sandbox.Config.GetAnnotations()[annotations.StopSignals]; ok {
+			steps, err := parseStopSignals(s)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %q annotation", annotations.StopSignals)
+			}
+			return steps, nil
+		}
+	}
+
+	stopSignal := unix.SIGTERM
+	image, err := c.imageStore.Get(container.ImageRef)
+	if err != nil {
+		// NOTE(random-liu): It's possible that the container is stopped,
+		// deleted and image is garbage collected before this point. However,
+		// the chance is really slim, even it happens, it's still fine to return
+		// an error here.
+		return nil, errors.Wrapf(err, "failed to get image metadata %q", container.ImageRef)
+	}
+	if image.ImageSpec.Config.StopSignal != "" {
+		stopSignal, err = signal.ParseSignal(image.ImageSpec.Config.StopSignal)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse stop signal %q",
+				image.ImageSpec.Config.StopSignal)
+		}
+	}
+	return []stopStep{{signal: stopSignal}}, nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+	containerstore "github.com/containerd/cri/pkg/store/container"
+	imagestore "github.com/containerd/cri/pkg/store/image"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+func TestParseStopSignals(t *testing.T) {
+	for desc, test := range map[string]struct {
+		value     string
+		expectErr bool
+		expected  []stopStep
+	}{
+		"should parse signal sequence": {
+			value: "SIGTERM:10s, INT:5s,SIGQUIT",
+			expected: []stopStep{
+				{signal: unix.SIGTERM, timeout: 10 * time.Second},
+				{signal: unix.SIGINT, timeout: 5 * time.Second},
+				{signal: unix.SIGQUIT},
+			},
+		},
+		"should parse numeric signal": {
+			value:    "2",
+			expected: []stopStep{{signal: unix.SIGINT}},
+		},
+		"should reject unknown signal": {
+			value:     "SIGUNKNOWN:1s",
+			expectErr: true,
+		},
+		"should reject invalid timeout": {
+			value:     "SIGTERM:ten",
+			expectErr: true,
+		},
+		"should reject non-positive timeout": {
+			value:     "SIGTERM:0s",
+			expectErr: true,
+		},
+		"should reject empty value": {
+			value:     " , ",
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		steps, err := parseStopSignals(test.value)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, steps)
+	}
+}
+
+func TestGetStopSteps(t *testing.T) {
+	const (
+		testSandboxID = "sandbox-id"
+		testImageID   = "sha256:c75bebcdd211f41b3a460c7bf82970ed6c75acaab9cd4c9a4e125b03ca113798"
+	)
+	for desc, test := range map[string]struct {
+		annotations map[string]string
+		stopSignal  string
+		expectErr   bool
+		expected    []stopStep
+	}{
+		"should default to SIGTERM": {
+			expected: []stopStep{{signal: unix.SIGTERM}},
+		},
+		"should use the image stop signal": {
+			stopSignal: "SIGQUIT",
+			expected:   []stopStep{{signal: unix.SIGQUIT}},
+		},
+		"should override the image stop signal with the annotation": {
+			annotations: map[string]string{annotations.StopSignals: "SIGINT:3s,SIGTERM"},
+			stopSignal:  "SIGQUIT",
+			expected: []stopStep{
+				{signal: unix.SIGINT, timeout: 3 * time.Second},
+				{signal: unix.SIGTERM},
+			},
+		},
+		"should return error with invalid annotation": {
+			annotations: map[string]string{annotations.StopSignals: "SIGUNKNOWN"},
+			expectErr:   true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		require.NoError(t, c.sandboxStore.Add(sandboxstore.NewSandbox(
+			sandboxstore.Metadata{
+				ID:     testSandboxID,
+				Config: &runtime.PodSandboxConfig{Annotations: test.annotations},
+			},
+			sandboxstore.Status{State: sandboxstore.StateReady},
+		)))
+		image := imagestore.Image{ID: testImageID}
+		image.ImageSpec.Config.StopSignal = test.stopSignal
+		require.NoError(t, c.imageStore.Add(image))
+		container := containerstore.Container{
+			Metadata: containerstore.Metadata{
+				ID:        "test-id",
+				SandboxID: testSandboxID,
+				ImageRef:  testImageID,
+			},
+		}
+		steps, err := c.getStopSteps(container)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, steps)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"math"
 	"net"
 	"os"
+	"time"
 
 	"github.com/pkg/errors"
 	k8snet "k8s.io/apimachinery/pkg/util/net"
@@ -30,8 +31,6 @@ import (
 	k8scert "k8s.io/client-go/util/cert"
 	"k8s.io/kubernetes/pkg/kubelet/server/streaming"
 	"k8s.io/utils/exec"
-
-	ctrdutil "github.com/containerd/cri/pkg/containerd/util"
 )
 
 func newStreamServer(c *criService, addr, port string) (streaming.Server, error) {
@@ -43,9 +42,29 @@ func newStreamServer(c *criService, addr, port string) (streaming.Server, error)
 		addr = a.String()
 	}
 	config := streaming.DefaultConfig
+	if c.config.StreamIdleTimeout != "" {
+		var err error
+		config.StreamIdleTimeout, err = time.ParseDuration(c.config.StreamIdleTimeout)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid stream idle timeout %q", c.config.StreamIdleTimeout)
+		}
+	}
 	config.Addr = net.JoinHostPort(addr, port)
 	runtime := newStreamRuntime(c)
-	if c.config.EnableTLSStreaming {
+	tlsMode, err := getStreamListenerMode(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid stream server configuration")
+	}
+	switch tlsMode {
+	case x509KeyPairTLS:
+		tlsCert, err := tls.LoadX509KeyPair(c.config.X509KeyPairStreaming.TLSCertFile, c.config.X509KeyPairStreaming.TLSKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load x509 key pair for stream server")
+		}
+		config.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{tlsCert},
+		}
+	case selfSignTLS:
 		tlsCert, err := newTLSCert()
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to generate tls certificate for stream server")
@@ -58,6 +77,37 @@ func newStreamServer(c *criService, addr, port string) (streaming.Server, error)
 	return streaming.NewServer(config, runtime)
 }
 
+type streamListenerMode int
+
+const (
+	x509KeyPairTLS streamListenerMode = iota
+	selfSignTLS
+	withoutTLS
+)
+
+// getStreamListenerMode returns how the stream server serves TLS, based on
+// the TLS streaming config.
+func getStreamListenerMode(c *criService) (streamListenerMode, error) {
+	enableTLS := c.config.EnableTLSStreaming
+	certFile := c.config.X509KeyPairStreaming.TLSCertFile
+	keyFile := c.config.X509KeyPairStreaming.TLSKeyFile
+
+	// Validate certificate configuration
+	if (certFile != "") != (keyFile != "") {
+		return -1, errors.New("must set both tls_cert_file and tls_key_file")
+	}
+	if !enableTLS {
+		if certFile != "" {
+			return -1, errors.New("x509_key_pair_streaming is set but enable_tls_streaming is false")
+		}
+		return withoutTLS, nil
+	}
+	if certFile != "" {
+		return x509KeyPairTLS, nil
+	}
+	return selfSignTLS, nil
+}
+
 type streamRuntime struct {
 	c *criService
 }
@@ -70,7 +120,9 @@ func newStreamRuntime(c *criService) streaming.Runtime {
 // returns non-zero exit code.
 func (s *streamRuntime) Exec(containerID string, cmd []string, stdin io.Reader, stdout, stderr io.WriteCloser,
 	tty bool, resize <-chan remotecommand.TerminalSize) error {
-	exitCode, err := s.c.execInContainer(ctrdutil.NamespacedContext(), containerID, execOptions{
+	streamingSessions.WithValues("exec").Inc()
+	defer streamingSessions.WithValues("exec").Dec()
+	exitCode, err := s.c.execInContainer(s.c.namespacedContext(), containerID, execOptions{
 		cmd:    cmd,
 		stdin:  stdin,
 		stdout: stdout,
@@ -92,13 +144,17 @@ func (s *streamRuntime) Exec(containerID string, cmd []string, stdin io.Reader,
 
 func (s *streamRuntime) Attach(containerID string, in io.Reader, out, err io.WriteCloser, tty bool,
 	resize <-chan remotecommand.TerminalSize) error {
-	return s.c.attachContainer(ctrdutil.NamespacedContext(), containerID, in, out, err, tty, resize)
+	streamingSessions.WithValues("attach").Inc()
+	defer streamingSessions.WithValues("attach").Dec()
+	return s.c.attachContainer(s.c.namespacedContext(), containerID, in, out, err, tty, resize)
 }
 
 func (s *streamRuntime) PortForward(podSandboxID string, port int32, stream io.ReadWriteCloser) error {
 	if port <= 0 || port > math.MaxUint16 {
 		return errors.Errorf("invalid port %d", port)
 	}
+	streamingSessions.WithValues("portforward").Inc()
+	defer streamingSessions.WithValues("portforward").Dec()
 	return s.c.portForward(podSandboxID, port, stream)
 }
 
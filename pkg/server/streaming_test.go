@@ -0,0 +1,144 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestValidateStreamServer(t *testing.T) {
+	for desc, test := range map[string]struct {
+		enableTLS    bool
+		keyPair      criconfig.X509KeyPairStreaming
+		expectErr    bool
+		expectedMode streamListenerMode
+	}{
+		"should pass with default withoutTLS": {
+			expectedMode: withoutTLS,
+		},
+		"should pass with x509KeyPair": {
+			enableTLS: true,
+			keyPair: criconfig.X509KeyPairStreaming{
+				TLSKeyFile:  "non-empty",
+				TLSCertFile: "non-empty",
+			},
+			expectedMode: x509KeyPairTLS,
+		},
+		"should pass with selfSign": {
+			enableTLS:    true,
+			expectedMode: selfSignTLS,
+		},
+		"should fail with TLSKeyFile empty": {
+			enableTLS: true,
+			keyPair: criconfig.X509KeyPairStreaming{
+				TLSCertFile: "non-empty",
+			},
+			expectErr: true,
+		},
+		"should fail with TLSCertFile empty": {
+			enableTLS: true,
+			keyPair: criconfig.X509KeyPairStreaming{
+				TLSKeyFile: "non-empty",
+			},
+			expectErr: true,
+		},
+		"should fail with x509KeyPair but without TLS enabled": {
+			keyPair: criconfig.X509KeyPairStreaming{
+				TLSKeyFile:  "non-empty",
+				TLSCertFile: "non-empty",
+			},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.EnableTLSStreaming = test.enableTLS
+		c.config.X509KeyPairStreaming = test.keyPair
+		mode, err := getStreamListenerMode(c)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expectedMode, mode)
+	}
+}
+
+func TestStreamServerWebSocket(t *testing.T) {
+	c := newTestCRIService()
+	s, err := newStreamServer(c, "127.0.0.1", "0")
+	require.NoError(t, err)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	for desc, test := range map[string]struct {
+		getURL     func() (string, error)
+		errChannel byte
+		expectErr  string
+	}{
+		"exec over websocket": {
+			getURL: func() (string, error) {
+				resp, err := s.GetExec(&runtime.ExecRequest{ContainerId: "missing", Cmd: []string{"sh"}, Stdout: true})
+				return resp.GetUrl(), err
+			},
+			errChannel: 3,
+			expectErr:  `failed to find container \"missing\"`,
+		},
+		"port forward over websocket": {
+			getURL: func() (string, error) {
+				resp, err := s.GetPortForward(&runtime.PortForwardRequest{PodSandboxId: "missing", Port: []int32{8080}})
+				return resp.GetUrl(), err
+			},
+			errChannel: 1,
+			expectErr:  `failed to find sandbox "missing"`,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		streamURL, err := test.getURL()
+		require.NoError(t, err)
+		u, err := url.Parse(streamURL)
+		require.NoError(t, err)
+		config, err := websocket.NewConfig(strings.Replace(ts.URL, "http", "ws", 1)+u.Path, ts.URL)
+		require.NoError(t, err)
+		config.Protocol = []string{"v4.channel.k8s.io"}
+		ws, err := websocket.DialConfig(config)
+		require.NoError(t, err)
+
+		var errMsg string
+		for {
+			var frame []byte
+			if err := websocket.Message.Receive(ws, &frame); err != nil {
+				break
+			}
+			if len(frame) > 0 && frame[0] == test.errChannel {
+				errMsg += string(frame[1:])
+			}
+		}
+		ws.Close()
+		assert.Contains(t, errMsg, test.expectErr)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// safeSysctls are the sysctls always allowed, same with the safe sysctls of
+// kubelet. They are namespaced and isolated from other pods.
+var safeSysctls = map[string]bool{
+	"kernel.shm_rmid_forced":              true,
+	"net.ipv4.ip_local_port_range":        true,
+	"net.ipv4.ip_unprivileged_port_start": true,
+	"net.ipv4.ping_group_range":           true,
+	"net.ipv4.tcp_syncookies":             true,
+}
+
+// ipcSysctls are the sysctls in the ipc namespace, in addition to the ones
+// with the ipcSysctlPrefixes.
+var ipcSysctls = map[string]bool{
+	"kernel.msgmax":          true,
+	"kernel.msgmnb":          true,
+	"kernel.msgmni":          true,
+	"kernel.sem":             true,
+	"kernel.shmall":          true,
+	"kernel.shmmax":          true,
+	"kernel.shmmni":          true,
+	"kernel.shm_rmid_forced": true,
+}
+
+// ipcSysctlPrefixes are the prefixes of sysctls in the ipc namespace.
+var ipcSysctlPrefixes = []string{"fs.mqueue."}
+
+// validateSysctlPatterns validates the patterns of allowed unsafe sysctls.
+// A pattern is a sysctl name, or a prefix ending with "*".
+func validateSysctlPatterns(patterns []string) error {
+	for _, p := range patterns {
+		name := strings.TrimSuffix(p, "*")
+		if name == "" || strings.Contains(name, "*") {
+			return errors.Errorf("invalid sysctl pattern %q", p)
+		}
+		if !strings.HasSuffix(p, "*") && sysctlNamespace(name) == "" {
+			return errors.Errorf("sysctl %q is not in a separate kernel namespace", p)
+		}
+	}
+	return nil
+}
+
+// validateSandboxSysctls validates the sysctls of a sandbox like kubelet. A
+// sysctl must be in a kernel namespace of the sandbox which is not shared
+// with the host. If unsafe sysctls are restricted, it must also be safe or
+// match an allowed unsafe sysctl pattern. The returned error has the
+// InvalidArgument code.
+func (c *criService) validateSandboxSysctls(config *runtime.PodSandboxConfig) error {
+	nsOptions := config.GetLinux().GetSecurityContext().GetNamespaceOptions()
+	for key := range config.GetLinux().GetSysctls() {
+		if c.config.RestrictUnsafeSysctls && !safeSysctls[key] && !c.unsafeSysctlAllowed(key) {
+			return status.Errorf(codes.InvalidArgument, "sysctl %q is not allowed", key)
+		}
+		var hostNS bool
+		ns := sysctlNamespace(key)
+		switch ns {
+		case "ipc":
+			hostNS = nsOptions.GetIpc() == runtime.NamespaceMode_NODE
+		case "net", "uts":
+			// The uts namespace is shared with the host with host network.
+			hostNS = nsOptions.GetNetwork() == runtime.NamespaceMode_NODE
+		default:
+			return status.Errorf(codes.InvalidArgument, "sysctl %q is not in a separate kernel namespace", key)
+		}
+		if hostNS {
+			return status.Errorf(codes.InvalidArgument, "sysctl %q is not allowed in the host %s namespace", key, ns)
+		}
+	}
+	return nil
+}
+
+// unsafeSysctlAllowed returns whether a sysctl matches an allowed unsafe
+// sysctl pattern.
+func (c *criService) unsafeSysctlAllowed(key string) bool {
+	for _, p := range c.config.AllowedUnsafeSysctls {
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(key, strings.TrimSuffix(p, "*")) {
+				return true
+			}
+		} else if key == p {
+			return true
+		}
+	}
+	return false
+}
+
+// sysctlNamespace returns the kernel namespace of a sysctl, or "" if the
+// sysctl is not namespaced.
+func sysctlNamespace(key string) string {
+	switch {
+	case isIPCSysctl(key):
+		return "ipc"
+	case strings.HasPrefix(key, "net."):
+		return "net"
+	case key == "kernel.hostname" || key == "kernel.domainname":
+		return "uts"
+	}
+	return ""
+}
+
+// isIPCSysctl returns whether a sysctl is in the ipc namespace.
+func isIPCSysctl(key string) bool {
+	if ipcSysctls[key] {
+		return true
+	}
+	for _, prefix := range ipcSysctlPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
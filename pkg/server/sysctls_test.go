@@ -0,0 +1,138 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func TestValidateSysctlPatterns(t *testing.T) {
+	for desc, test := range map[string]struct {
+		patterns  []string
+		expectErr bool
+	}{
+		"should accept namespaced sysctl and prefix": {
+			patterns: []string{"kernel.msgmax", "net.core.*"},
+		},
+		"should reject sysctl which is not namespaced": {
+			patterns:  []string{"kernel.pid_max"},
+			expectErr: true,
+		},
+		"should reject wildcard in the middle": {
+			patterns:  []string{"net.*.somaxconn"},
+			expectErr: true,
+		},
+		"should reject single wildcard": {
+			patterns:  []string{"*"},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		err := validateSysctlPatterns(test.patterns)
+		assert.Equal(t, test.expectErr, err != nil)
+	}
+}
+
+func TestValidateSandboxSysctls(t *testing.T) {
+	for desc, test := range map[string]struct {
+		sysctls      map[string]string
+		nsOptions    *runtime.NamespaceOption
+		unrestricted bool
+		expectErr    bool
+	}{
+		"should allow safe sysctls": {
+			sysctls: map[string]string{
+				"kernel.shm_rmid_forced":       "1",
+				"net.ipv4.ip_local_port_range": "1024 65000",
+			},
+		},
+		"should allow unsafe sysctl in the allow-list": {
+			sysctls: map[string]string{"kernel.msgmax": "65536"},
+		},
+		"should allow unsafe sysctl matching an allowed prefix": {
+			sysctls: map[string]string{"net.core.somaxconn": "1024"},
+		},
+		"should reject unsafe sysctl not in the allow-list": {
+			sysctls:   map[string]string{"kernel.sem": "250 32000 32 128"},
+			expectErr: true,
+		},
+		"should allow namespaced sysctl not in the allow-list if not restricted": {
+			sysctls:      map[string]string{"kernel.sem": "250 32000 32 128"},
+			unrestricted: true,
+		},
+		"should reject sysctl which is not namespaced if not restricted": {
+			sysctls:      map[string]string{"vm.swappiness": "10"},
+			unrestricted: true,
+			expectErr:    true,
+		},
+		"should reject namespaced sysctl with host network if not restricted": {
+			sysctls:      map[string]string{"net.core.somaxconn": "1024"},
+			nsOptions:    &runtime.NamespaceOption{Network: runtime.NamespaceMode_NODE},
+			unrestricted: true,
+			expectErr:    true,
+		},
+		"should reject allowed ipc sysctl with host ipc": {
+			sysctls:   map[string]string{"kernel.msgmax": "65536"},
+			nsOptions: &runtime.NamespaceOption{Ipc: runtime.NamespaceMode_NODE},
+			expectErr: true,
+		},
+		"should reject safe net sysctl with host network": {
+			sysctls:   map[string]string{"net.ipv4.tcp_syncookies": "1"},
+			nsOptions: &runtime.NamespaceOption{Network: runtime.NamespaceMode_NODE},
+			expectErr: true,
+		},
+		"should reject allowed sysctl which is not namespaced": {
+			sysctls:   map[string]string{"kernel.pid_max": "65536"},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.RestrictUnsafeSysctls = !test.unrestricted
+		c.config.AllowedUnsafeSysctls = []string{"kernel.msgmax", "net.core.*", "kernel.pid_max"}
+		config := &runtime.PodSandboxConfig{
+			Linux: &runtime.LinuxPodSandboxConfig{
+				Sysctls: test.sysctls,
+				SecurityContext: &runtime.LinuxSandboxSecurityContext{
+					NamespaceOptions: test.nsOptions,
+				},
+			},
+		}
+		err := c.validateSandboxSysctls(config)
+		if test.expectErr {
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestIsIPCSysctl(t *testing.T) {
+	for key, expected := range map[string]bool{
+		"kernel.shmmax":       true,
+		"fs.mqueue.msg_max":   true,
+		"net.ipv4.ip_forward": false,
+		"kernel.pid_max":      false,
+	} {
+		assert.Equal(t, expected, isIPCSysctl(key), key)
+	}
+}
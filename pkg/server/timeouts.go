@@ -0,0 +1,117 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+// operationTimeouts are the timeouts of the internal phases of sandbox and
+// container operations, 0 means no timeout.
+type operationTimeouts struct {
+	imageResolve    time.Duration
+	snapshotPrepare time.Duration
+	cniSetup        time.Duration
+	taskStart       time.Duration
+}
+
+// newOperationTimeouts parses the operation timeouts config.
+func newOperationTimeouts(config criconfig.OperationTimeouts) (operationTimeouts, error) {
+	var t operationTimeouts
+	for _, d := range []struct {
+		name   string
+		value  string
+		parsed *time.Duration
+	}{
+		{name: "image_resolve", value: config.ImageResolve, parsed: &t.imageResolve},
+		{name: "snapshot_prepare", value: config.SnapshotPrepare, parsed: &t.snapshotPrepare},
+		{name: "cni_setup", value: config.CNISetup, parsed: &t.cniSetup},
+		{name: "task_start", value: config.TaskStart, parsed: &t.taskStart},
+	} {
+		parsed, err := parseOptionalDuration(d.value)
+		if err != nil || parsed < 0 {
+			return operationTimeouts{}, errors.Errorf("invalid %s timeout %q", d.name, d.value)
+		}
+		*d.parsed = parsed
+	}
+	return t, nil
+}
+
+// withPhaseTimeout returns the context of a phase of an operation with the
+// timeout of the phase. The deadline of the request still applies.
+func withPhaseTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// waitWithContext runs f, which can't be cancelled, e.g. a CNI plugin, and
+// waits for it until the context is done. It returns the error of f, or the
+// context error if f is not finished, and a channel closed when f returns.
+// f keeps running in the background after the context is done, whatever it
+// sets must only be used after the channel is closed.
+func waitWithContext(ctx context.Context, f func() error) (<-chan struct{}, error) {
+	done := make(chan struct{})
+	var err error
+	go func() {
+		defer close(done)
+		err = f()
+	}()
+	select {
+	case <-done:
+		return done, err
+	case <-ctx.Done():
+		return done, errors.Wrap(ctx.Err(), "not finished in time")
+	}
+}
+
+// phaseError wraps the error of a phase of an operation. If the phase
+// exceeded its timeout, a DeadlineExceeded error is returned instead, so that
+// clients can tell a timed out phase from the other failures.
+func phaseError(ctx context.Context, err error, format string, args ...interface{}) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return status.Errorf(codes.DeadlineExceeded, "%s: %v", fmt.Sprintf(format, args...), err)
+	}
+	return errors.Wrapf(err, format, args...)
+}
+
+// afterTimedOut rolls back what f set up with the rollback step after f
+// returns, if f is not finished, i.e. it exceeded its timeout. It returns a
+// channel closed once f returned and the rollback is finished.
+func (c *criService) afterTimedOut(done <-chan struct{}, desc string, step func() error) <-chan struct{} {
+	select {
+	case <-done:
+		return done
+	default:
+	}
+	rolledBack := make(chan struct{})
+	go func() {
+		<-done
+		<-c.rollback(desc, step)
+		close(rolledBack)
+	}()
+	return rolledBack
+}
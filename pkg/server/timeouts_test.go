@@ -0,0 +1,151 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestNewOperationTimeouts(t *testing.T) {
+	for desc, test := range map[string]struct {
+		config    criconfig.OperationTimeouts
+		expected  operationTimeouts
+		expectErr bool
+	}{
+		"should not set timeouts by default": {},
+		"should parse timeouts": {
+			config:   criconfig.OperationTimeouts{ImageResolve: "5m", CNISetup: "30s", TaskStart: "1m"},
+			expected: operationTimeouts{imageResolve: 5 * time.Minute, cniSetup: 30 * time.Second, taskStart: time.Minute},
+		},
+		"should reject invalid timeout": {
+			config:    criconfig.OperationTimeouts{SnapshotPrepare: "invalid"},
+			expectErr: true,
+		},
+		"should reject negative timeout": {
+			config:    criconfig.OperationTimeouts{TaskStart: "-1s"},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		timeouts, err := newOperationTimeouts(test.config)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, timeouts)
+	}
+}
+
+func TestWithPhaseTimeout(t *testing.T) {
+	t.Logf("should not set deadline without timeout")
+	ctx, cancel := withPhaseTimeout(context.Background(), 0)
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+	cancel()
+
+	t.Logf("should keep the earlier deadline of the request")
+	reqCtx, reqCancel := context.WithTimeout(context.Background(), time.Minute)
+	defer reqCancel()
+	reqDeadline, _ := reqCtx.Deadline()
+	ctx, cancel = withPhaseTimeout(reqCtx, time.Hour)
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, reqDeadline, deadline)
+	cancel()
+}
+
+func TestWaitWithContext(t *testing.T) {
+	t.Logf("should return the error of finished function")
+	done, err := waitWithContext(context.Background(), func() error {
+		return errors.New("test error")
+	})
+	assert.EqualError(t, err, "test error")
+	<-done
+
+	t.Logf("should return when the context is done before the function")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	release := make(chan struct{})
+	done, err = waitWithContext(ctx, func() error {
+		<-release
+		return nil
+	})
+	assert.Error(t, err)
+	select {
+	case <-done:
+		assert.Fail(t, "function should still be running")
+	default:
+	}
+
+	t.Logf("should roll back after the timed out function returns")
+	c := newTestCRIService()
+	rolledBack := make(chan struct{})
+	finished := c.afterTimedOut(done, "test rollback", func() error {
+		close(rolledBack)
+		return nil
+	})
+	select {
+	case <-finished:
+		assert.Fail(t, "rollback should not be finished before the function returns")
+	default:
+	}
+	close(release)
+	select {
+	case <-rolledBack:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "rollback should be run")
+	}
+	select {
+	case <-finished:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "rollback should be finished")
+	}
+}
+
+func TestPhaseError(t *testing.T) {
+	testErr := errors.New("test error")
+
+	t.Logf("should wrap the error of a phase within its timeout")
+	err := phaseError(context.Background(), testErr, "failed to %s", "test")
+	assert.EqualError(t, err, "failed to test: test error")
+	_, ok := status.FromError(err)
+	assert.False(t, ok)
+
+	t.Logf("should return DeadlineExceeded for a phase exceeding its timeout")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	err = phaseError(ctx, testErr, "failed to %s", "test")
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	t.Logf("should not return DeadlineExceeded for a cancelled phase")
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	err = phaseError(ctx, testErr, "failed to %s", "test")
+	assert.EqualError(t, err, "failed to test: test error")
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+)
+
+// parseTmpfsSizes parses the sizes in bytes of tmpfs mounts keyed by the
+// container path, e.g. "/cache=67108864,/tmp=1073741824".
+func parseTmpfsSizes(s string) (map[string]int64, error) {
+	sizes := make(map[string]int64)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || !filepath.IsAbs(parts[0]) {
+			return nil, errors.Errorf("invalid tmpfs size %q, expected <absolute path>=<bytes>", item)
+		}
+		size, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || size <= 0 {
+			return nil, errors.Errorf("invalid tmpfs size %q, expected positive bytes", item)
+		}
+		sizes[filepath.Clean(parts[0])] = size
+	}
+	return sizes, nil
+}
+
+// tmpfsMount returns the tmpfs mount of a CRI mount without a host path. The
+// size is in bytes, zero leaves it to the kernel default of half of the RAM.
+func tmpfsMount(mount *runtime.Mount, size int64) runtimespec.Mount {
+	options := []string{"nosuid", "nodev", "mode=1777"}
+	if size > 0 {
+		options = append(options, fmt.Sprintf("size=%d", size))
+	}
+	if mount.GetReadonly() {
+		options = append(options, "ro")
+	} else {
+		options = append(options, "rw")
+	}
+	return runtimespec.Mount{
+		Source:      "tmpfs",
+		Destination: mount.GetContainerPath(),
+		Type:        "tmpfs",
+		Options:     options,
+	}
+}
+
+// getSandboxShmSize returns the size in bytes of the sandbox shm, which is
+// set by the shm size annotation, or defaultShmSize.
+func getSandboxShmSize(config *runtime.PodSandboxConfig) (int64, error) {
+	s, ok := config.GetAnnotations()[annotations.ShmSize]
+	if !ok {
+		return defaultShmSize, nil
+	}
+	if config.GetLinux().GetSecurityContext().GetNamespaceOptions().GetIpc() == runtime.NamespaceMode_NODE {
+		return 0, errors.New("shm size can't be set for a sandbox in the host ipc namespace")
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || size <= 0 {
+		return 0, errors.Errorf("invalid shm size %q, expected positive bytes", s)
+	}
+	return size, nil
+}
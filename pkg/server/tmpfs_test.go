@@ -0,0 +1,172 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestParseTmpfsSizes(t *testing.T) {
+	for desc, test := range map[string]struct {
+		value     string
+		expectErr bool
+		expected  map[string]int64
+	}{
+		"should parse empty value": {
+			expected: map[string]int64{},
+		},
+		"should parse sizes": {
+			value:    "/cache=1024, /tmp/=2048",
+			expected: map[string]int64{"/cache": 1024, "/tmp": 2048},
+		},
+		"should reject relative path": {
+			value:     "cache=1024",
+			expectErr: true,
+		},
+		"should reject missing size": {
+			value:     "/cache",
+			expectErr: true,
+		},
+		"should reject non-positive size": {
+			value:     "/cache=0",
+			expectErr: true,
+		},
+		"should reject non-numeric size": {
+			value:     "/cache=1Gi",
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		sizes, err := parseTmpfsSizes(test.value)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, sizes)
+	}
+}
+
+func TestContainerSpecTmpfsMounts(t *testing.T) {
+	testID := "test-id"
+	testSandboxID := "sandbox-id"
+	testPid := uint32(1234)
+	for desc, test := range map[string]struct {
+		annotation  string
+		defaultSize int64
+		readonly    bool
+		expectErr   bool
+		expected    []string
+	}{
+		"should mount tmpfs with kernel default size": {
+			expected: []string{"nosuid", "nodev", "mode=1777", "rw"},
+		},
+		"should mount tmpfs with default size": {
+			defaultSize: 1024,
+			expected:    []string{"nosuid", "nodev", "mode=1777", "size=1024", "rw"},
+		},
+		"should mount tmpfs with annotated size": {
+			annotation:  "/cache=2048",
+			defaultSize: 1024,
+			expected:    []string{"nosuid", "nodev", "mode=1777", "size=2048", "rw"},
+		},
+		"should mount readonly tmpfs": {
+			readonly: true,
+			expected: []string{"nosuid", "nodev", "mode=1777", "ro"},
+		},
+		"should return error with invalid annotation": {
+			annotation: "/cache=invalid",
+			expectErr:  true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		c.config.DefaultTmpfsSize = test.defaultSize
+		config, sandboxConfig, imageConfig, _ := getCreateContainerTestData()
+		config.Mounts = []*runtime.Mount{{ContainerPath: "/cache", Readonly: test.readonly}}
+		if test.annotation != "" {
+			config.Annotations = map[string]string{annotations.TmpfsSizes: test.annotation}
+		}
+		spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Contains(t, spec.Mounts, runtimespec.Mount{
+			Source:      "tmpfs",
+			Destination: "/cache",
+			Type:        "tmpfs",
+			Options:     test.expected,
+		})
+	}
+}
+
+func TestGetSandboxShmSize(t *testing.T) {
+	for desc, test := range map[string]struct {
+		annotations map[string]string
+		ipcMode     runtime.NamespaceMode
+		expectErr   bool
+		expected    int64
+	}{
+		"should return default size without annotation": {
+			expected: defaultShmSize,
+		},
+		"should return size of annotation": {
+			annotations: map[string]string{annotations.ShmSize: "1073741824"},
+			expected:    1073741824,
+		},
+		"should reject non-positive size": {
+			annotations: map[string]string{annotations.ShmSize: "0"},
+			expectErr:   true,
+		},
+		"should reject non-numeric size": {
+			annotations: map[string]string{annotations.ShmSize: "1Gi"},
+			expectErr:   true,
+		},
+		"should reject size for host ipc sandbox": {
+			annotations: map[string]string{annotations.ShmSize: "1073741824"},
+			ipcMode:     runtime.NamespaceMode_NODE,
+			expectErr:   true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		config := &runtime.PodSandboxConfig{
+			Annotations: test.annotations,
+			Linux: &runtime.LinuxPodSandboxConfig{
+				SecurityContext: &runtime.LinuxSandboxSecurityContext{
+					NamespaceOptions: &runtime.NamespaceOption{Ipc: test.ipcMode},
+				},
+			},
+		}
+		size, err := getSandboxShmSize(config)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, size)
+	}
+}
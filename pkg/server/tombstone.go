@@ -0,0 +1,221 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/system"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+// tombstonesDir contains the tombstones of sandboxes and containers being
+// removed.
+const tombstonesDir = "tombstones"
+
+// tombstone records the resources of a sandbox or container being removed.
+// It is written before anything is removed, and removed after everything is,
+// so that the removal can be finished with it after a partial failure or a
+// restart, even if the metadata is already deleted.
+type tombstone struct {
+	// ID is the id of the sandbox or container.
+	ID string `json:"id"`
+	// Sandbox indicates whether it is a sandbox or a container.
+	Sandbox bool `json:"sandbox"`
+	// Snapshotter and SnapshotKey are the rootfs snapshot.
+	Snapshotter string `json:"snapshotter,omitempty"`
+	SnapshotKey string `json:"snapshotKey,omitempty"`
+	// NetNSPath is the network namespace of a sandbox.
+	NetNSPath string `json:"netNSPath,omitempty"`
+	// NoPauseContainer indicates a sandbox whose namespaces are pinned
+	// without a pause container.
+	NoPauseContainer bool `json:"noPauseContainer,omitempty"`
+	// Checkpoint is the checkpoint image a container is restored from.
+	Checkpoint string `json:"checkpoint,omitempty"`
+}
+
+// newTombstone creates the tombstone of a sandbox or container with the
+// snapshot of its containerd container.
+func newTombstone(ctx context.Context, id string, sandbox bool, cntr containerd.Container) (tombstone, error) {
+	t := tombstone{ID: id, Sandbox: sandbox}
+	info, err := cntr.Info(ctx)
+	if err != nil {
+		if !errdefs.IsNotFound(err) {
+			return tombstone{}, errors.Wrapf(err, "failed to get containerd container info of %q", id)
+		}
+		return t, nil
+	}
+	t.Snapshotter, t.SnapshotKey = info.Snapshotter, info.SnapshotKey
+	return t, nil
+}
+
+func (c *criService) getTombstonePath(id string) string {
+	return filepath.Join(c.config.RootDir, tombstonesDir, id)
+}
+
+// writeTombstone writes the tombstone before the removal starts.
+func (c *criService) writeTombstone(t tombstone) error {
+	if err := os.MkdirAll(filepath.Join(c.config.RootDir, tombstonesDir), 0700); err != nil {
+		return errors.Wrap(err, "failed to create tombstones directory")
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal tombstone")
+	}
+	return ioutils.AtomicWriteFile(c.getTombstonePath(t.ID), data, 0600)
+}
+
+// removeTombstone removes the tombstone after the removal is finished.
+func (c *criService) removeTombstone(id string) error {
+	if err := os.Remove(c.getTombstonePath(id)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove tombstone of %q", id)
+	}
+	return nil
+}
+
+// getTombstone returns the tombstone of an id, and false if there is none.
+func (c *criService) getTombstone(id string) (tombstone, bool, error) {
+	data, err := ioutil.ReadFile(c.getTombstonePath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tombstone{}, false, nil
+		}
+		return tombstone{}, false, errors.Wrapf(err, "failed to read tombstone of %q", id)
+	}
+	var t tombstone
+	if err := json.Unmarshal(data, &t); err != nil {
+		return tombstone{}, false, errors.Wrapf(err, "failed to unmarshal tombstone of %q", id)
+	}
+	return t, true, nil
+}
+
+// finishRemoval removes whatever is left of a sandbox or container with its
+// tombstone, and then the tombstone. Each step is a no-op if the resource is
+// already removed.
+func (c *criService) finishRemoval(ctx context.Context, t tombstone) error {
+	cntr, err := c.client.LoadContainer(ctx, t.ID)
+	if err == nil {
+		if err := cntr.Delete(ctx, containerd.WithSnapshotCleanup); err != nil && !errdefs.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete containerd container %q", t.ID)
+		}
+	} else if !errdefs.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to load containerd container %q", t.ID)
+	}
+	if t.SnapshotKey != "" {
+		if err := c.client.SnapshotService(t.Snapshotter).Remove(ctx, t.SnapshotKey); err != nil && !errdefs.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to remove snapshot %q", t.SnapshotKey)
+		}
+	}
+	if t.Checkpoint != "" {
+		if err := c.client.ImageService().Delete(ctx, t.Checkpoint); err != nil && !errdefs.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete checkpoint image %q", t.Checkpoint)
+		}
+	}
+	rootDir, volatileRootDir := c.getContainerRootDir(t.ID), c.getVolatileContainerRootDir(t.ID)
+	if t.Sandbox {
+		if t.NetNSPath != "" {
+			netNS, err := sandboxstore.LoadNetNS(t.NetNSPath)
+			if err != nil && err != sandboxstore.ErrClosedNetNS {
+				return errors.Wrapf(err, "failed to load network namespace %q", t.NetNSPath)
+			}
+			if err == nil {
+				if err := netNS.Remove(); err != nil {
+					return errors.Wrapf(err, "failed to remove network namespace %q", t.NetNSPath)
+				}
+			}
+		}
+		if t.NoPauseContainer {
+			if err := c.unpinSandboxNamespaces(t.ID); err != nil {
+				return errors.Wrap(err, "failed to remove sandbox namespaces")
+			}
+		}
+		if err := c.removeNetNSRecord(t.ID); err != nil {
+			return err
+		}
+		rootDir, volatileRootDir = c.getSandboxRootDir(t.ID), c.getVolatileSandboxRootDir(t.ID)
+	}
+	for _, dir := range []string{rootDir, volatileRootDir} {
+		if err := system.EnsureRemoveAll(dir); err != nil {
+			return errors.Wrapf(err, "failed to remove directory %q", dir)
+		}
+	}
+	return c.removeTombstone(t.ID)
+}
+
+// finishRemovalByTombstone finishes the removal of a sandbox or container
+// whose metadata is already deleted, if it has a tombstone.
+func (c *criService) finishRemovalByTombstone(ctx context.Context, id string) error {
+	// The id may be anything from the request, e.g. a truncated id.
+	if !idRegexp.MatchString(id) {
+		return nil
+	}
+	t, ok, err := c.getTombstone(id)
+	if err != nil || !ok {
+		return err
+	}
+	return errors.Wrapf(c.finishRemoval(ctx, t), "failed to finish removal of %q", id)
+}
+
+// finishRemovals finishes the removals interrupted by a restart. It is called
+// before the sandboxes and containers are loaded, so that the ones being
+// removed are not loaded again.
+func (c *criService) finishRemovals(ctx context.Context) error {
+	dir := filepath.Join(c.config.RootDir, tombstonesDir)
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to read tombstones directory %q", dir)
+	}
+	var sandboxes, containers []tombstone
+	for _, fi := range fis {
+		// Skip the temporary files of atomic writes.
+		if strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		t, _, err := c.getTombstone(fi.Name())
+		if err != nil {
+			logrus.WithError(err).Errorf("Failed to get tombstone %q", fi.Name())
+			continue
+		}
+		if t.Sandbox {
+			sandboxes = append(sandboxes, t)
+		} else {
+			containers = append(containers, t)
+		}
+	}
+	// Containers are removed before their sandboxes.
+	for _, t := range append(containers, sandboxes...) {
+		logrus.Infof("Finish removal of %q interrupted by restart", t.ID)
+		if err := c.finishRemoval(ctx, t); err != nil {
+			logrus.WithError(err).Errorf("Failed to finish removal of %q", t.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestTombstone(t *testing.T) {
+	root, err := ioutil.TempDir("", "test-tombstone")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	c := newTestCRIService()
+	c.config.RootDir = root
+	id := strings.Repeat("a", 64)
+
+	t.Logf("should return no tombstone if it is not written")
+	_, ok, err := c.getTombstone(id)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	t.Logf("should read written tombstone")
+	expected := tombstone{
+		ID:          id,
+		Sandbox:     true,
+		Snapshotter: "overlayfs",
+		SnapshotKey: id,
+		NetNSPath:   "/var/run/netns/cni-test",
+	}
+	require.NoError(t, c.writeTombstone(expected))
+	got, ok, err := c.getTombstone(id)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, expected, got)
+
+	t.Logf("should remove tombstone idempotently")
+	require.NoError(t, c.removeTombstone(id))
+	require.NoError(t, c.removeTombstone(id))
+	_, ok, err = c.getTombstone(id)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	t.Logf("should ignore ids which are not generated ids")
+	assert.NoError(t, c.finishRemovalByTombstone(context.Background(), "../"+id))
+	assert.NoError(t, c.finishRemovalByTombstone(context.Background(), id))
+
+	t.Logf("should do nothing without tombstones")
+	assert.NoError(t, c.finishRemovals(context.Background()))
+}
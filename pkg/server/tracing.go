@@ -0,0 +1,218 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+// tracer records spans of cri grpc requests and the phases they go through,
+// and exports the traces of requests slower than the threshold, so that slow
+// requests can be broken down per phase. Traces are either logged, or sent to
+// an OpenTelemetry collector. The W3C trace context of the caller is taken
+// from the "traceparent" grpc metadata, so that the spans join the trace of
+// the caller. A nil tracer records nothing.
+type tracer struct {
+	threshold time.Duration
+	// export exports a finished trace.
+	export func(*span)
+}
+
+// span is a traced operation. A nil span is not traced, so that callers
+// don't need to check whether tracing is enabled.
+type span struct {
+	name  string
+	start time.Time
+	// traceID is the id of the trace the span belongs to.
+	traceID [16]byte
+	// spanID is the id of the span.
+	spanID [8]byte
+	// parentID is the id of the parent span, it is zero for a root span
+	// without a caller trace context.
+	parentID [8]byte
+	mu       sync.Mutex
+	// duration is set when the span ends.
+	duration time.Duration
+	err      error
+	children []*span
+	// tracer is only set on the root span.
+	tracer *tracer
+}
+
+type spanKey struct{}
+
+// newTracer creates a tracer, it returns nil if tracing is disabled.
+func newTracer(config criconfig.TracingConfig) (*tracer, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	threshold, err := time.ParseDuration(config.Threshold)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid tracing threshold %q", config.Threshold)
+	}
+	t := &tracer{threshold: threshold}
+	switch config.Exporter {
+	case "", criconfig.TracingExporterLog:
+		t.export = logSpan
+	case criconfig.TracingExporterOTLP:
+		e, err := newOTLPExporter(config.OTLPEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		t.export = e.export
+	default:
+		return nil, errors.Errorf("unknown tracing exporter %q", config.Exporter)
+	}
+	return t, nil
+}
+
+// start starts the root span of a request. The span joins the trace of the
+// caller if the request carries a valid "traceparent" grpc metadata.
+func (t *tracer) start(ctx context.Context, name string) (context.Context, *span) {
+	if t == nil {
+		return ctx, nil
+	}
+	s := &span{name: name, start: time.Now(), tracer: t}
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md[traceparentKey]) > 0 {
+		var err error
+		s.traceID, s.parentID, err = parseTraceparent(md[traceparentKey][0])
+		if err != nil {
+			logrus.WithError(err).Debugf("Ignore trace context of %s", name)
+		}
+	}
+	if s.traceID == [16]byte{} {
+		rand.Read(s.traceID[:]) // nolint: errcheck
+	}
+	s.spanID = newSpanID()
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// traceparentKey is the grpc metadata key of the W3C trace context.
+const traceparentKey = "traceparent"
+
+// parseTraceparent parses a W3C traceparent header, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", into the trace
+// id and the parent span id. Versions other than "00" are parsed as "00",
+// as the W3C trace context requires.
+func parseTraceparent(value string) (traceID [16]byte, parentID [8]byte, err error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return traceID, parentID, errors.Errorf("invalid traceparent %q", value)
+	}
+	if len(parts[1]) != 2*len(traceID) || len(parts[2]) != 2*len(parentID) || len(parts[3]) != 2 {
+		return traceID, parentID, errors.Errorf("invalid traceparent %q", value)
+	}
+	var tid [16]byte
+	var pid [8]byte
+	if _, err := hex.Decode(tid[:], []byte(parts[1])); err != nil {
+		return traceID, parentID, errors.Wrapf(err, "invalid trace id in traceparent %q", value)
+	}
+	if _, err := hex.Decode(pid[:], []byte(parts[2])); err != nil {
+		return traceID, parentID, errors.Wrapf(err, "invalid parent id in traceparent %q", value)
+	}
+	if tid == [16]byte{} || pid == [8]byte{} {
+		return traceID, parentID, errors.Errorf("zero id in traceparent %q", value)
+	}
+	return tid, pid, nil
+}
+
+// startSpan starts a span of a phase, as a child of the span in the context.
+// It returns a nil span if the request is not traced.
+func startSpan(ctx context.Context, name string) (context.Context, *span) {
+	parent, ok := ctx.Value(spanKey{}).(*span)
+	if !ok || parent == nil {
+		return ctx, nil
+	}
+	s := &span{name: name, start: time.Now(), traceID: parent.traceID, spanID: newSpanID()}
+	s.parentID = parent.spanID
+	parent.mu.Lock()
+	parent.children = append(parent.children, s)
+	parent.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// end ends the span with the error of the operation. The trace is exported
+// when its root span ends.
+func (s *span) end(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.duration = time.Since(s.start)
+	s.err = err
+	s.mu.Unlock()
+	if s.tracer != nil && s.duration >= s.tracer.threshold {
+		s.tracer.export(s)
+	}
+}
+
+// String formats the span and its children, e.g.
+// "RunPodSandbox 1.2s (cni setup 800ms, task start 100ms)".
+func (s *span) String() string {
+	var buf bytes.Buffer
+	s.format(&buf)
+	return buf.String()
+}
+
+func (s *span) format(buf *bytes.Buffer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.duration == 0 && s.err == nil {
+		fmt.Fprintf(buf, "%s unfinished", s.name)
+	} else {
+		fmt.Fprintf(buf, "%s %v", s.name, s.duration)
+	}
+	if s.err != nil {
+		fmt.Fprintf(buf, " failed: %v", s.err)
+	}
+	if len(s.children) == 0 {
+		return
+	}
+	buf.WriteString(" (")
+	for i, c := range s.children {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		c.format(buf)
+	}
+	buf.WriteString(")")
+}
+
+// newSpanID returns a random span id.
+func newSpanID() [8]byte {
+	var id [8]byte
+	rand.Read(id[:]) // nolint: errcheck
+	return id
+}
+
+// logSpan exports a trace into the log.
+func logSpan(s *span) {
+	logrus.Infof("Trace %x %s", s.traceID, s)
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// otlpExportTimeout is the timeout of sending a trace to the collector.
+	otlpExportTimeout = 10 * time.Second
+	// otlpMaxPendingExports is the maximum number of traces being sent at
+	// the same time. Traces are dropped when the collector can't keep up,
+	// so that a slow collector doesn't pile up goroutines.
+	otlpMaxPendingExports = 16
+	// otlpServiceName is the "service.name" resource attribute of the spans.
+	otlpServiceName = "containerd"
+	// otlpScopeName is the instrumentation scope of the spans.
+	otlpScopeName = "github.com/containerd/cri"
+)
+
+// OTLP span kinds and status codes, see opentelemetry-proto trace.proto.
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindServer   = 2
+	otlpStatusCodeOK     = 1
+	otlpStatusCodeError  = 2
+)
+
+// otlpExporter sends traces to an OpenTelemetry collector with OTLP/HTTP
+// in the json encoding.
+type otlpExporter struct {
+	endpoint string
+	client   *http.Client
+	// pending bounds the traces being sent.
+	pending chan struct{}
+}
+
+// newOTLPExporter creates an exporter sending traces to the OTLP/HTTP traces
+// endpoint, e.g. "http://localhost:4318/v1/traces".
+func newOTLPExporter(endpoint string) (*otlpExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid otlp endpoint %q", endpoint)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.Errorf("invalid otlp endpoint %q, it must be an http or https url", endpoint)
+	}
+	return &otlpExporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: otlpExportTimeout},
+		pending:  make(chan struct{}, otlpMaxPendingExports),
+	}, nil
+}
+
+// export sends a trace to the collector in the background. Errors are only
+// logged, because tracing must not affect the traced requests.
+func (e *otlpExporter) export(s *span) {
+	data, err := json.Marshal(newOTLPTraceRequest(s))
+	if err != nil {
+		logrus.WithError(err).Errorf("Failed to marshal trace %x", s.traceID)
+		return
+	}
+	select {
+	case e.pending <- struct{}{}:
+	default:
+		logrus.Warnf("Drop trace %x, too many traces are being sent", s.traceID)
+		return
+	}
+	go func() {
+		defer func() { <-e.pending }()
+		if err := e.send(data); err != nil {
+			logrus.WithError(err).Warnf("Failed to export trace %x", s.traceID)
+		}
+	}()
+}
+
+// send posts an OTLP/HTTP json request to the collector.
+func (e *otlpExporter) send(data []byte) error {
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrapf(err, "failed to post to %q", e.endpoint)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body) // nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("unexpected status %q from %q", resp.Status, e.endpoint)
+	}
+	return nil
+}
+
+// otlpTraceRequest is the json encoding of an OTLP ExportTraceServiceRequest.
+type otlpTraceRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId,omitempty"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+	// Timestamps are 64 bit integers, which are encoded as strings in the
+	// OTLP json encoding.
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Status            otlpStatus `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// newOTLPTraceRequest converts a trace into an OTLP request.
+func newOTLPTraceRequest(root *span) *otlpTraceRequest {
+	var spans []otlpSpan
+	root.mu.Lock()
+	end := root.start.Add(root.duration)
+	root.mu.Unlock()
+	appendOTLPSpans(&spans, root, end, otlpSpanKindServer)
+	return &otlpTraceRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpKeyValue{{
+					Key:   "service.name",
+					Value: otlpAnyValue{StringValue: otlpServiceName},
+				}},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: otlpScopeName},
+				Spans: spans,
+			}},
+		}},
+	}
+}
+
+// appendOTLPSpans appends the span and its children. A span not ended when
+// its trace is exported ends with the root span.
+func appendOTLPSpans(spans *[]otlpSpan, s *span, rootEnd time.Time, kind int) {
+	s.mu.Lock()
+	end := rootEnd
+	if s.duration != 0 || s.err != nil {
+		end = s.start.Add(s.duration)
+	}
+	o := otlpSpan{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+		Status:            otlpStatus{Code: otlpStatusCodeOK},
+	}
+	if s.parentID != [8]byte{} {
+		o.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	if s.err != nil {
+		o.Status = otlpStatus{Code: otlpStatusCodeError, Message: s.err.Error()}
+	}
+	children := append([]*span(nil), s.children...)
+	s.mu.Unlock()
+	*spans = append(*spans, o)
+	for _, c := range children {
+		appendOTLPSpans(spans, c, rootEnd, otlpSpanKindInternal)
+	}
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+
+	criconfig "github.com/containerd/cri/pkg/config"
+)
+
+func TestNewTracer(t *testing.T) {
+	t.Logf("should not trace when tracing is disabled")
+	tr, err := newTracer(criconfig.TracingConfig{Enabled: false, Threshold: "invalid"})
+	require.NoError(t, err)
+	assert.Nil(t, tr)
+	ctx, root := tr.start(context.Background(), "RunPodSandbox")
+	assert.Nil(t, root)
+	_, child := startSpan(ctx, "cni setup")
+	assert.Nil(t, child)
+	child.end(nil)
+	root.end(nil)
+
+	t.Logf("should fail with invalid threshold")
+	_, err = newTracer(criconfig.TracingConfig{Enabled: true, Threshold: "invalid"})
+	assert.Error(t, err)
+
+	for desc, test := range map[string]struct {
+		config      criconfig.TracingConfig
+		expectedErr bool
+	}{
+		"should create log exporter": {
+			config: criconfig.TracingConfig{Enabled: true, Threshold: "1s", Exporter: criconfig.TracingExporterLog},
+		},
+		"should create otlp exporter": {
+			config: criconfig.TracingConfig{Enabled: true, Threshold: "1s", Exporter: criconfig.TracingExporterOTLP,
+				OTLPEndpoint: "http://localhost:4318/v1/traces"},
+		},
+		"should fail with otlp endpoint without scheme": {
+			config: criconfig.TracingConfig{Enabled: true, Threshold: "1s", Exporter: criconfig.TracingExporterOTLP,
+				OTLPEndpoint: "localhost:4318"},
+			expectedErr: true,
+		},
+		"should fail with unknown exporter": {
+			config:      criconfig.TracingConfig{Enabled: true, Threshold: "1s", Exporter: "jaeger"},
+			expectedErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		tr, err := newTracer(test.config)
+		if test.expectedErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.NotNil(t, tr.export)
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	for desc, test := range map[string]struct {
+		value            string
+		expectedTraceID  string
+		expectedParentID string
+		expectedErr      bool
+	}{
+		"should parse version 00": {
+			value:            "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			expectedTraceID:  "4bf92f3577b34da6a3ce929d0e0e4736",
+			expectedParentID: "00f067aa0ba902b7",
+		},
+		"should parse future version with extra fields": {
+			value:            "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra",
+			expectedTraceID:  "4bf92f3577b34da6a3ce929d0e0e4736",
+			expectedParentID: "00f067aa0ba902b7",
+		},
+		"should reject version 00 with extra fields": {
+			value:       "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			expectedErr: true,
+		},
+		"should reject version ff": {
+			value:       "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			expectedErr: true,
+		},
+		"should reject zero trace id": {
+			value:       "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			expectedErr: true,
+		},
+		"should reject short parent id": {
+			value:       "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa-01",
+			expectedErr: true,
+		},
+		"should reject non hex trace id": {
+			value:       "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01",
+			expectedErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		traceID, parentID, err := parseTraceparent(test.value)
+		if test.expectedErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expectedTraceID, hex.EncodeToString(traceID[:]))
+		assert.Equal(t, test.expectedParentID, hex.EncodeToString(parentID[:]))
+	}
+}
+
+func TestTracerTraceContext(t *testing.T) {
+	tr := &tracer{export: func(*span) {}}
+
+	t.Logf("should join the trace of the caller")
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(traceparentKey, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	ctx, root := tr.start(ctx, "RunPodSandbox")
+	_, child := startSpan(ctx, "cni setup")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", hex.EncodeToString(root.traceID[:]))
+	assert.Equal(t, "00f067aa0ba902b7", hex.EncodeToString(root.parentID[:]))
+	assert.Equal(t, root.traceID, child.traceID)
+	assert.Equal(t, root.spanID, child.parentID)
+	assert.NotEqual(t, root.spanID, child.spanID)
+
+	t.Logf("should start a new trace without valid trace context")
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(traceparentKey, "invalid"))
+	_, root = tr.start(ctx, "RunPodSandbox")
+	assert.NotEqual(t, [16]byte{}, root.traceID)
+	assert.Equal(t, [8]byte{}, root.parentID)
+	assert.NotEqual(t, [8]byte{}, root.spanID)
+}
+
+func TestOTLPExporter(t *testing.T) {
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		data, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		received <- data
+	}))
+	defer server.Close()
+	e, err := newOTLPExporter(server.URL + "/v1/traces")
+	require.NoError(t, err)
+
+	tr := &tracer{export: e.export}
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(traceparentKey, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	ctx, root := tr.start(ctx, "RunPodSandbox")
+	_, task := startSpan(ctx, "task create")
+	_, unfinished := startSpan(ctx, "task start")
+	task.end(errors.New("create failed"))
+	root.end(nil)
+
+	var data []byte
+	select {
+	case data = <-received:
+	case <-time.After(10 * time.Second):
+		t.Fatal("trace is not exported")
+	}
+	var req otlpTraceRequest
+	require.NoError(t, json.Unmarshal(data, &req))
+	require.Len(t, req.ResourceSpans, 1)
+	require.Len(t, req.ResourceSpans[0].ScopeSpans, 1)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 3)
+
+	t.Logf("root span should be a server span with the caller as parent")
+	assert.Equal(t, "RunPodSandbox", spans[0].Name)
+	assert.Equal(t, otlpSpanKindServer, spans[0].Kind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].ParentSpanID)
+	assert.Equal(t, otlpStatusCodeOK, spans[0].Status.Code)
+
+	t.Logf("failed span should have error status")
+	assert.Equal(t, "task create", spans[1].Name)
+	assert.Equal(t, otlpSpanKindInternal, spans[1].Kind)
+	assert.Equal(t, spans[0].TraceID, spans[1].TraceID)
+	assert.Equal(t, spans[0].SpanID, spans[1].ParentSpanID)
+	assert.Equal(t, otlpStatus{Code: otlpStatusCodeError, Message: "create failed"}, spans[1].Status)
+
+	t.Logf("unfinished span should end with the root span")
+	assert.Equal(t, "task start", spans[2].Name)
+	assert.Equal(t, spans[0].EndTimeUnixNano, spans[2].EndTimeUnixNano)
+	unfinished.end(nil)
+}
+
+func TestTracer(t *testing.T) {
+	for desc, test := range map[string]struct {
+		threshold time.Duration
+		exported  bool
+	}{
+		"should export trace slower than threshold": {
+			threshold: 0,
+			exported:  true,
+		},
+		"should not export trace faster than threshold": {
+			threshold: time.Hour,
+			exported:  false,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		var exported *span
+		tr := &tracer{threshold: test.threshold, export: func(s *span) { exported = s }}
+		ctx, root := tr.start(context.Background(), "RunPodSandbox")
+		_, network := startSpan(ctx, "cni setup")
+		network.end(nil)
+		taskCtx, task := startSpan(ctx, "task create")
+		_, nested := startSpan(taskCtx, "nested")
+		nested.end(nil)
+		task.end(errors.New("create failed"))
+		root.end(nil)
+		if !test.exported {
+			assert.Nil(t, exported)
+			continue
+		}
+		require.NotNil(t, exported)
+		assert.Regexp(t, regexp.MustCompile(`^RunPodSandbox \S+ \(cni setup \S+, task create \S+ failed: create failed \(nested \S+\)\)$`), exported.String())
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/containerd/containerd"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/containerd/cri/pkg/annotations"
+)
+
+// NOTE: The vendored runtime spec has no rdma or unified resources, so the
+// limits of these controllers are written into the cgroup of the container
+// after the task is created, before the container process starts.
+
+// unifiedResourceControllers are the cgroup controllers whose files can be
+// set by the unified resource annotations on cgroup v2.
+var unifiedResourceControllers = map[string]bool{
+	"rdma": true,
+	"misc": true,
+}
+
+// v1ResourceControllers are the cgroup controllers whose files can be set by
+// the unified resource annotations on cgroup v1. The misc controller is only
+// in the unified hierarchy.
+var v1ResourceControllers = map[string]bool{
+	"rdma": true,
+}
+
+// getUnifiedResources returns the cgroup controller files and their values
+// set by the container annotations. Controllers which are not supported on
+// the cgroup hierarchy of the host are rejected.
+func getUnifiedResources(containerAnnotations map[string]string, unified bool) (map[string]string, error) {
+	controllers := v1ResourceControllers
+	if unified {
+		controllers = unifiedResourceControllers
+	}
+	resources := make(map[string]string)
+	for k, v := range containerAnnotations {
+		if !strings.HasPrefix(k, annotations.UnifiedResourcePrefix) {
+			continue
+		}
+		file := strings.TrimPrefix(k, annotations.UnifiedResourcePrefix)
+		parts := strings.SplitN(file, ".", 2)
+		if len(parts) != 2 || parts[1] == "" || strings.Contains(file, "/") {
+			return nil, errors.Errorf("invalid cgroup file %q", file)
+		}
+		if !controllers[parts[0]] {
+			if unifiedResourceControllers[parts[0]] {
+				return nil, errors.Errorf("cgroup controller %q of %q is not supported on cgroup v1", parts[0], file)
+			}
+			return nil, errors.Errorf("cgroup controller %q of %q is not supported", parts[0], file)
+		}
+		if strings.TrimSpace(v) == "" || strings.Contains(v, "\n") {
+			return nil, errors.Errorf("invalid value %q of cgroup file %q", v, file)
+		}
+		resources[file] = v
+	}
+	return resources, nil
+}
+
+// setUnifiedResources writes the cgroup controller files set by the container
+// annotations into the cgroup of the container.
+func (c *criService) setUnifiedResources(ctx context.Context, cntr containerd.Container, containerAnnotations map[string]string) error {
+	resources, err := getUnifiedResources(containerAnnotations, c.unifiedCgroup)
+	if err != nil || len(resources) == 0 {
+		return err
+	}
+	path, err := c.getContainerCgroupPath(ctx, cntr)
+	if err != nil {
+		return err
+	}
+	return writeCgroupFiles(cgroupRoot, path, c.unifiedCgroup, resources)
+}
+
+// writeCgroupFiles writes the controller files into a cgroup. On cgroup v1,
+// the files are in the hierarchy of their controller, in which the runtime
+// may not have created the cgroup if it doesn't support the controller.
+func writeCgroupFiles(root, path string, unified bool, resources map[string]string) error {
+	var files []string
+	for file := range resources {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		dir := filepath.Join(root, path)
+		if !unified {
+			controller := strings.SplitN(file, ".", 2)[0]
+			dir = filepath.Join(root, controller, path)
+			if _, err := os.Stat(dir); os.IsNotExist(err) {
+				return errors.Errorf("cgroup %q is not in the %s hierarchy, the runtime may not support the controller",
+					path, controller)
+			}
+		}
+		f, err := os.OpenFile(filepath.Join(dir, file), os.O_WRONLY|os.O_TRUNC, 0)
+		if err != nil {
+			return errors.Wrapf(err, "failed to open cgroup file %q", file)
+		}
+		_, err = f.WriteString(resources[file])
+		f.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to write %q into cgroup file %q", resources[file], file)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUnifiedResources(t *testing.T) {
+	for desc, test := range map[string]struct {
+		annotations map[string]string
+		v1          bool
+		expectErr   bool
+		expected    map[string]string
+	}{
+		"should return rdma and misc resources": {
+			annotations: map[string]string{
+				"io.kubernetes.cri.unified.rdma.max": "mlx5_0 hca_handle=2 hca_object=2000",
+				"io.kubernetes.cri.unified.misc.max": "sev 2",
+				"other.io/annotation":                "value",
+			},
+			expected: map[string]string{
+				"rdma.max": "mlx5_0 hca_handle=2 hca_object=2000",
+				"misc.max": "sev 2",
+			},
+		},
+		"should return rdma resources on cgroup v1": {
+			annotations: map[string]string{
+				"io.kubernetes.cri.unified.rdma.max": "mlx5_0 hca_handle=2 hca_object=2000",
+			},
+			v1:       true,
+			expected: map[string]string{"rdma.max": "mlx5_0 hca_handle=2 hca_object=2000"},
+		},
+		"should reject misc controller on cgroup v1": {
+			annotations: map[string]string{"io.kubernetes.cri.unified.misc.max": "sev 2"},
+			v1:          true,
+			expectErr:   true,
+		},
+		"should return nothing without annotation": {
+			expected: map[string]string{},
+		},
+		"should reject unsupported controller": {
+			annotations: map[string]string{"io.kubernetes.cri.unified.memory.max": "1000"},
+			expectErr:   true,
+		},
+		"should reject file without controller": {
+			annotations: map[string]string{"io.kubernetes.cri.unified.rdma": "mlx5_0 hca_handle=2"},
+			expectErr:   true,
+		},
+		"should reject path in file": {
+			annotations: map[string]string{"io.kubernetes.cri.unified.rdma.max/../x": "mlx5_0 hca_handle=2"},
+			expectErr:   true,
+		},
+		"should reject empty value": {
+			annotations: map[string]string{"io.kubernetes.cri.unified.rdma.max": " "},
+			expectErr:   true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		resources, err := getUnifiedResources(test.annotations, !test.v1)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, resources)
+	}
+}
+
+func TestWriteCgroupFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "test-cgroup-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	for desc, test := range map[string]struct {
+		unified   bool
+		resources map[string]string
+		dirs      map[string]string
+	}{
+		"should write files into unified cgroup": {
+			unified: true,
+			resources: map[string]string{
+				"rdma.max": "mlx5_0 hca_handle=2 hca_object=2000",
+				"misc.max": "sev 2",
+			},
+			dirs: map[string]string{
+				"rdma.max": "v2/kubepods/test",
+				"misc.max": "v2/kubepods/test",
+			},
+		},
+		"should write files into cgroup v1 controller hierarchies": {
+			resources: map[string]string{"rdma.max": "mlx5_0 hca_handle=2 hca_object=2000"},
+			dirs:      map[string]string{"rdma.max": "v1/rdma/kubepods/test"},
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		for file, dir := range test.dirs {
+			require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(root, dir, file), nil, 0644))
+		}
+		base := "v1"
+		if test.unified {
+			base = "v2"
+		}
+		require.NoError(t, writeCgroupFiles(filepath.Join(root, base), "kubepods/test", test.unified, test.resources))
+		for file, dir := range test.dirs {
+			data, err := ioutil.ReadFile(filepath.Join(root, dir, file))
+			require.NoError(t, err)
+			assert.Equal(t, test.resources[file], string(data))
+		}
+	}
+
+	resources := map[string]string{"rdma.max": "mlx5_0 hca_handle=2 hca_object=2000"}
+	assert.Error(t, writeCgroupFiles(filepath.Join(root, "not-exist"), "kubepods/test", true, resources),
+		"should fail if the cgroup doesn't exist")
+	err = writeCgroupFiles(filepath.Join(root, "v1"), "kubepods/other", false, resources)
+	require.Error(t, err, "should fail if the runtime didn't create the cgroup in the controller hierarchy")
+	assert.Contains(t, err.Error(), "not in the rdma hierarchy")
+}
@@ -17,15 +17,18 @@ limitations under the License.
 package server
 
 import (
+	"bytes"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"text/template"
 
-	cni "github.com/containerd/go-cni"
+	"github.com/docker/docker/pkg/ioutils"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/log"
 )
 
 // cniConfigTemplate contains the values containerd will overwrite
@@ -39,40 +42,64 @@ type cniConfigTemplate struct {
 const cniConfigFileName = "10-containerd-net.conflist"
 
 // UpdateRuntimeConfig updates the runtime config. Currently only handles podCIDR updates.
+// The cni config is generated from the template if no other cni config is
+// ready, and regenerated and reloaded when the pod cidr changes. The
+// resolv.conf of sandboxes following the host one is also updated.
 func (c *criService) UpdateRuntimeConfig(ctx context.Context, r *runtime.UpdateRuntimeConfigRequest) (*runtime.UpdateRuntimeConfigResponse, error) {
+	if err := c.syncHostResolvConfs(resolvConfPath); err != nil {
+		log.Sandbox.WithError(err).Error("Failed to update resolv.conf of sandboxes")
+	}
 	podCIDR := r.GetRuntimeConfig().GetNetworkConfig().GetPodCidr()
 	if podCIDR == "" {
 		return &runtime.UpdateRuntimeConfigResponse{}, nil
 	}
 	confTemplate := c.config.NetworkPluginConfTemplate
 	if confTemplate == "" {
-		logrus.Info("No cni config template is specified, wait for other system components to drop the config.")
+		log.CNI.Info("No cni config template is specified, wait for other system components to drop the config.")
 		return &runtime.UpdateRuntimeConfigResponse{}, nil
 	}
-	if err := c.netPlugin.Status(); err == nil {
-		logrus.Infof("Network plugin is ready, skip generating cni config from template %q", confTemplate)
-		return &runtime.UpdateRuntimeConfigResponse{}, nil
-	} else if err := c.netPlugin.Load(cni.WithLoNetwork, cni.WithDefaultConf); err == nil {
-		logrus.Infof("CNI config is successfully loaded, skip generating cni config from template %q", confTemplate)
-		return &runtime.UpdateRuntimeConfigResponse{}, nil
+	confFile := filepath.Join(c.config.NetworkPluginConfDir, cniConfigFileName)
+	existing, err := ioutil.ReadFile(confFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "failed to read cni config file %q", confFile)
+	}
+	generated := err == nil
+	if !generated {
+		if err := c.netPlugin.Status(); err == nil {
+			log.CNI.Infof("Network plugin is ready, skip generating cni config from template %q", confTemplate)
+			return &runtime.UpdateRuntimeConfigResponse{}, nil
+		} else if err := c.cniNetConfMonitor.reload(); err == nil {
+			log.CNI.Infof("CNI config is successfully loaded, skip generating cni config from template %q", confTemplate)
+			return &runtime.UpdateRuntimeConfigResponse{}, nil
+		}
 	}
-	logrus.Infof("Generating cni config from template %q", confTemplate)
 	// generate cni config file from the template with updated pod cidr.
 	t, err := template.ParseFiles(confTemplate)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse cni config template %q", confTemplate)
 	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, cniConfigTemplate{PodCIDR: podCIDR}); err != nil {
+		return nil, errors.Wrapf(err, "failed to generate cni config file %q", confFile)
+	}
+	if generated && bytes.Equal(existing, buf.Bytes()) {
+		return &runtime.UpdateRuntimeConfigResponse{}, nil
+	}
+	log.CNI.Infof("Generating cni config from template %q with pod cidr %q", confTemplate, podCIDR)
 	if err := os.MkdirAll(c.config.NetworkPluginConfDir, 0755); err != nil {
 		return nil, errors.Wrapf(err, "failed to create cni config directory: %q", c.config.NetworkPluginConfDir)
 	}
-	confFile := filepath.Join(c.config.NetworkPluginConfDir, cniConfigFileName)
-	f, err := os.OpenFile(confFile, os.O_WRONLY|os.O_CREATE, 0644)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to open cni config file %q", confFile)
+	// Write atomically, so that the cni config monitor never loads a
+	// partially written config.
+	if err := ioutils.AtomicWriteFile(confFile, buf.Bytes(), 0644); err != nil {
+		return nil, errors.Wrapf(err, "failed to write cni config file %q", confFile)
 	}
-	defer f.Close()
-	if err := t.Execute(f, cniConfigTemplate{PodCIDR: podCIDR}); err != nil {
-		return nil, errors.Wrapf(err, "failed to generate cni config file %q", confFile)
+	if generated {
+		// Reload the regenerated config now instead of on the inotify event,
+		// so that new pods get ips in the new pod cidr right away.
+		if err := c.cniNetConfMonitor.reload(); err != nil {
+			return nil, errors.Wrapf(err, "failed to reload cni config with pod cidr %q", podCIDR)
+		}
 	}
 	return &runtime.UpdateRuntimeConfigResponse{}, nil
 }
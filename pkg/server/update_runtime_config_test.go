@@ -20,6 +20,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -77,7 +78,10 @@ func TestUpdateRuntimeConfig(t *testing.T) {
 		noTemplate      bool
 		emptyCIDR       bool
 		networkReady    bool
+		existingConfig  string
+		reloadErr       bool
 		expectCNIConfig bool
+		expectErr       bool
 	}{
 		"should not generate cni config if cidr is empty": {
 			emptyCIDR:       true,
@@ -94,6 +98,18 @@ func TestUpdateRuntimeConfig(t *testing.T) {
 		"should generate cni config if template is specified and cidr is provided": {
 			expectCNIConfig: true,
 		},
+		"should regenerate cni config if pod cidr is changed": {
+			networkReady:    true,
+			existingConfig:  strings.Replace(expected, testCIDR, "10.1.0.0/24", 1),
+			expectCNIConfig: true,
+		},
+		"should return error if regenerated cni config fails to be reloaded": {
+			networkReady:    true,
+			existingConfig:  strings.Replace(expected, testCIDR, "10.1.0.0/24", 1),
+			reloadErr:       true,
+			expectCNIConfig: true,
+			expectErr:       true,
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			testDir, err := ioutil.TempDir(os.TempDir(), "test-runtime-config")
@@ -127,8 +143,18 @@ func TestUpdateRuntimeConfig(t *testing.T) {
 				c.netPlugin.(*servertesting.FakeCNIPlugin).StatusErr = errors.New("random error")
 				c.netPlugin.(*servertesting.FakeCNIPlugin).LoadErr = errors.New("random error")
 			}
+			if test.existingConfig != "" {
+				require.NoError(t, os.MkdirAll(confDir, 0755))
+				require.NoError(t, ioutil.WriteFile(confName, []byte(test.existingConfig), 0644))
+			}
+			if test.reloadErr {
+				c.netPlugin.(*servertesting.FakeCNIPlugin).LoadErr = errors.New("random error")
+			}
 			_, err = c.UpdateRuntimeConfig(context.Background(), req)
-			assert.NoError(t, err)
+			assert.Equal(t, test.expectErr, err != nil)
+			if test.reloadErr {
+				assert.Error(t, c.cniNetConfMonitor.lastStatus())
+			}
 			if !test.expectCNIConfig {
 				_, err := os.Stat(confName)
 				assert.Error(t, err)
@@ -0,0 +1,234 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"math"
+	"os"
+	"sync"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/pkg/errors"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+	criconfig "github.com/containerd/cri/pkg/config"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+// userNamespaceEnabled returns whether a pod runs in its own user namespace.
+func userNamespaceEnabled(config *runtime.PodSandboxConfig) bool {
+	return config.GetAnnotations()[annotations.UserNamespaceMode] == annotations.UserNamespaceModePod
+}
+
+// validateUserNamespaceConfig validates the host id ranges of the user
+// namespaces of pods.
+func validateUserNamespaceConfig(config criconfig.UserNamespaceConfig) error {
+	if config.HostIDCount == 0 {
+		return nil
+	}
+	if config.HostIDStart == 0 {
+		return errors.New("host_id_start must not map host root")
+	}
+	if uint64(config.HostIDStart)+uint64(config.HostIDCount) > math.MaxUint32+1 {
+		return errors.New("host id range exceeds the maximum id")
+	}
+	if config.PodIDCount == 0 || config.PodIDCount > config.HostIDCount {
+		return errors.Errorf("pod_id_count %d must be between 1 and host_id_count %d", config.PodIDCount, config.HostIDCount)
+	}
+	return nil
+}
+
+// userNamespaceRanges tracks the host id ranges allocated to the user
+// namespaces of pods, so that no two pods share host ids.
+type userNamespaceRanges struct {
+	mu sync.Mutex
+	// used maps the first host id of each allocated range to its size.
+	used map[uint32]uint32
+}
+
+// allocate allocates the first free range of the given size in the host ids
+// of the config, and returns its first host id.
+func (r *userNamespaceRanges) allocate(config criconfig.UserNamespaceConfig) (uint32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	size := uint64(config.PodIDCount)
+	end := uint64(config.HostIDStart) + uint64(config.HostIDCount)
+	for start := uint64(config.HostIDStart); start+size <= end; start += size {
+		if !r.overlaps(start, size) {
+			r.reserveLocked(uint32(start), uint32(size))
+			return uint32(start), nil
+		}
+	}
+	return 0, errors.New("no free host id range for the user namespace")
+}
+
+// overlaps returns whether a range overlaps with an allocated range. The
+// ranges of recovered pods may not be aligned with the ranges of the current
+// config, so all of them are checked.
+func (r *userNamespaceRanges) overlaps(start, size uint64) bool {
+	for usedStart, usedSize := range r.used {
+		if start < uint64(usedStart)+uint64(usedSize) && uint64(usedStart) < start+size {
+			return true
+		}
+	}
+	return false
+}
+
+// reserve marks a range as allocated, e.g. the range of a recovered pod.
+func (r *userNamespaceRanges) reserve(start, size uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reserveLocked(start, size)
+}
+
+func (r *userNamespaceRanges) reserveLocked(start, size uint32) {
+	if r.used == nil {
+		r.used = make(map[uint32]uint32)
+	}
+	r.used[start] = size
+}
+
+// release frees an allocated range.
+func (r *userNamespaceRanges) release(start uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.used, start)
+}
+
+// allocateUserNamespace allocates the id mappings of the user namespace of a
+// pod. The uids and gids of a pod are mapped to the same host id range.
+func (c *criService) allocateUserNamespace() (*sandboxstore.UserNamespace, error) {
+	config := c.config.UserNamespace
+	if config.HostIDCount == 0 {
+		return nil, errors.New("user namespace is not configured")
+	}
+	hostID, err := c.userNamespaceRanges.allocate(config)
+	if err != nil {
+		return nil, err
+	}
+	mappings := []criconfig.IDMapping{{ContainerID: 0, HostID: hostID, Size: config.PodIDCount}}
+	return &sandboxstore.UserNamespace{UIDMappings: mappings, GIDMappings: mappings}, nil
+}
+
+// reserveUserNamespace reserves the host id range of the user namespace of a
+// recovered pod, so that it is not allocated to new pods.
+func (c *criService) reserveUserNamespace(userns *sandboxstore.UserNamespace) {
+	if userns == nil {
+		return
+	}
+	for _, m := range userns.UIDMappings {
+		c.userNamespaceRanges.reserve(m.HostID, m.Size)
+	}
+}
+
+// releaseUserNamespace releases the host id range of the user namespace of a
+// pod.
+func (c *criService) releaseUserNamespace(userns *sandboxstore.UserNamespace) {
+	if userns == nil {
+		return
+	}
+	for _, m := range userns.UIDMappings {
+		c.userNamespaceRanges.release(m.HostID)
+	}
+}
+
+// getSandboxUserNamespace returns the user namespace of the containers in a
+// sandbox, nil if they run in the host user namespace.
+func (c *criService) getSandboxUserNamespace(sandboxID string, sandboxConfig *runtime.PodSandboxConfig) (*sandboxstore.UserNamespace, error) {
+	if !userNamespaceEnabled(sandboxConfig) {
+		return nil, nil
+	}
+	sandbox, err := c.sandboxStore.Get(sandboxID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get sandbox %q", sandboxID)
+	}
+	if sandbox.UserNamespace == nil {
+		return nil, errors.Errorf("sandbox %q has no user namespace", sandboxID)
+	}
+	return sandbox.UserNamespace, nil
+}
+
+// getRemappedRoot returns the host uid and gid container root is mapped to in
+// a user namespace.
+func getRemappedRoot(userns *sandboxstore.UserNamespace) (uint32, uint32, error) {
+	uid, ok := toHostID(userns.UIDMappings, 0)
+	if !ok {
+		return 0, 0, errors.New("container root is not mapped in uid mappings")
+	}
+	gid, ok := toHostID(userns.GIDMappings, 0)
+	if !ok {
+		return 0, 0, errors.New("container root is not mapped in gid mappings")
+	}
+	return uid, gid, nil
+}
+
+// toHostID maps a container id to the host id.
+func toHostID(mappings []criconfig.IDMapping, id uint32) (uint32, bool) {
+	for _, m := range mappings {
+		if id >= m.ContainerID && id-m.ContainerID < m.Size {
+			return m.HostID + id - m.ContainerID, true
+		}
+	}
+	return 0, false
+}
+
+// setOCIUserNamespace sets the user namespace of a pod and its id mappings.
+// An empty path creates a new user namespace.
+func setOCIUserNamespace(g *generate.Generator, path string, userns *sandboxstore.UserNamespace) {
+	g.AddOrReplaceLinuxNamespace(string(runtimespec.UserNamespace), path) // nolint: errcheck
+	for _, m := range userns.UIDMappings {
+		g.AddLinuxUIDMapping(m.HostID, m.ContainerID, m.Size)
+	}
+	for _, m := range userns.GIDMappings {
+		g.AddLinuxGIDMapping(m.HostID, m.ContainerID, m.Size)
+	}
+	// sysfs can only be mounted by a user namespace owning the network
+	// namespace, while the pod network namespace is created outside of the
+	// user namespace. Bind mount sysfs from the host instead, read-only and
+	// without the host mounts under it, which a read-only remount of a
+	// recursive bind mount would leave writable.
+	for i, m := range g.Config.Mounts {
+		if m.Type != "sysfs" {
+			continue
+		}
+		options := []string{"bind", "ro"}
+		for _, o := range m.Options {
+			if o != "rw" && o != "ro" {
+				options = append(options, o)
+			}
+		}
+		g.Config.Mounts[i] = runtimespec.Mount{
+			Source:      "/sys",
+			Destination: m.Destination,
+			Type:        "bind",
+			Options:     options,
+		}
+	}
+}
+
+// chownVolumes changes the owner of the volumes created for a container to
+// container root, so that they are writable in the user namespace.
+func chownVolumes(volumes []*runtime.Mount, uid, gid uint32) error {
+	for _, v := range volumes {
+		if err := os.Chown(v.GetHostPath(), int(uid), int(gid)); err != nil {
+			return errors.Wrapf(err, "failed to chown volume %q", v.GetHostPath())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"math"
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+	criconfig "github.com/containerd/cri/pkg/config"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+var testUserNamespace = &sandboxstore.UserNamespace{
+	UIDMappings: []criconfig.IDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}},
+	GIDMappings: []criconfig.IDMapping{{ContainerID: 0, HostID: 200000, Size: 65536}},
+}
+
+func TestToHostID(t *testing.T) {
+	mappings := []criconfig.IDMapping{
+		{ContainerID: 0, HostID: 100000, Size: 1000},
+		{ContainerID: 1000, HostID: 300000, Size: 10},
+	}
+	for desc, test := range map[string]struct {
+		id       uint32
+		expected uint32
+		mapped   bool
+	}{
+		"root should be mapped": {
+			id:       0,
+			expected: 100000,
+			mapped:   true,
+		},
+		"id in the first range should be mapped": {
+			id:       999,
+			expected: 100999,
+			mapped:   true,
+		},
+		"id in the second range should be mapped": {
+			id:       1005,
+			expected: 300005,
+			mapped:   true,
+		},
+		"id out of ranges should not be mapped": {
+			id: 1010,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		id, mapped := toHostID(mappings, test.id)
+		assert.Equal(t, test.mapped, mapped)
+		assert.Equal(t, test.expected, id)
+	}
+}
+
+func TestGetRemappedRoot(t *testing.T) {
+	_, _, err := getRemappedRoot(&sandboxstore.UserNamespace{})
+	assert.Error(t, err, "should fail when root is not mapped")
+
+	uid, gid, err := getRemappedRoot(testUserNamespace)
+	require.NoError(t, err)
+	assert.EqualValues(t, 100000, uid)
+	assert.EqualValues(t, 200000, gid)
+}
+
+func TestAllocateUserNamespace(t *testing.T) {
+	c := newTestCRIService()
+	_, err := c.allocateUserNamespace()
+	assert.Error(t, err, "should fail when user namespace is not configured")
+
+	c.config.UserNamespace = criconfig.UserNamespaceConfig{
+		HostIDStart: 100000,
+		HostIDCount: 3 * 65536,
+		PodIDCount:  65536,
+	}
+	// A recovered pod allocated with a different config.
+	c.reserveUserNamespace(&sandboxstore.UserNamespace{
+		UIDMappings: []criconfig.IDMapping{{ContainerID: 0, HostID: 100000 + 65536 + 100, Size: 1000}},
+	})
+
+	t.Logf("should allocate the first free range to a pod")
+	first, err := c.allocateUserNamespace()
+	require.NoError(t, err)
+	expected := []criconfig.IDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}}
+	assert.Equal(t, expected, first.UIDMappings)
+	assert.Equal(t, expected, first.GIDMappings)
+
+	t.Logf("should skip ranges overlapping with reserved ranges")
+	second, err := c.allocateUserNamespace()
+	require.NoError(t, err)
+	assert.EqualValues(t, 100000+2*65536, second.UIDMappings[0].HostID)
+
+	t.Logf("should fail when all ranges are allocated")
+	_, err = c.allocateUserNamespace()
+	assert.Error(t, err)
+
+	t.Logf("should reuse a released range")
+	c.releaseUserNamespace(first)
+	third, err := c.allocateUserNamespace()
+	require.NoError(t, err)
+	assert.Equal(t, first, third)
+}
+
+func TestValidateUserNamespaceConfig(t *testing.T) {
+	for desc, test := range map[string]struct {
+		config    criconfig.UserNamespaceConfig
+		expectErr bool
+	}{
+		"should accept disabled user namespace": {
+			config: criconfig.UserNamespaceConfig{PodIDCount: 65536},
+		},
+		"should accept valid ranges": {
+			config: criconfig.UserNamespaceConfig{HostIDStart: 100000, HostIDCount: 65536 * 10, PodIDCount: 65536},
+		},
+		"should reject ranges mapping host root": {
+			config:    criconfig.UserNamespaceConfig{HostIDStart: 0, HostIDCount: 65536 * 10, PodIDCount: 65536},
+			expectErr: true,
+		},
+		"should reject ranges exceeding the maximum id": {
+			config:    criconfig.UserNamespaceConfig{HostIDStart: math.MaxUint32, HostIDCount: 65536, PodIDCount: 65536},
+			expectErr: true,
+		},
+		"should reject pod range larger than host ids": {
+			config:    criconfig.UserNamespaceConfig{HostIDStart: 100000, HostIDCount: 1000, PodIDCount: 65536},
+			expectErr: true,
+		},
+		"should reject empty pod range": {
+			config:    criconfig.UserNamespaceConfig{HostIDStart: 100000, HostIDCount: 65536},
+			expectErr: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		err := validateUserNamespaceConfig(test.config)
+		if test.expectErr {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestContainerSpecUserNamespace(t *testing.T) {
+	testID := "test-id"
+	testSandboxID := "sandbox-id"
+	testPid := uint32(1234)
+	config, sandboxConfig, imageConfig, _ := getCreateContainerTestData()
+	c := newTestCRIService()
+	require.NoError(t, c.sandboxStore.Add(sandboxstore.NewSandbox(
+		sandboxstore.Metadata{
+			ID:            testSandboxID,
+			UserNamespace: testUserNamespace,
+		},
+		sandboxstore.Status{State: sandboxstore.StateReady},
+	)))
+
+	t.Logf("should not set user namespace by default")
+	spec, err := c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
+	require.NoError(t, err)
+	for _, ns := range spec.Linux.Namespaces {
+		assert.NotEqual(t, runtimespec.UserNamespace, ns.Type)
+	}
+	assert.Empty(t, spec.Linux.UIDMappings)
+
+	t.Logf("should join the sandbox user namespace when enabled")
+	sandboxConfig.Annotations = map[string]string{
+		annotations.UserNamespaceMode: annotations.UserNamespaceModePod,
+	}
+	spec, err = c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
+	require.NoError(t, err)
+	assert.Contains(t, spec.Linux.Namespaces, runtimespec.LinuxNamespace{
+		Type: runtimespec.UserNamespace,
+		Path: getUserNamespace(testPid),
+	})
+	assert.Equal(t, []runtimespec.LinuxIDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}}, spec.Linux.UIDMappings)
+	assert.Equal(t, []runtimespec.LinuxIDMapping{{ContainerID: 0, HostID: 200000, Size: 65536}}, spec.Linux.GIDMappings)
+	for _, m := range spec.Mounts {
+		if m.Destination == "/sys" {
+			assert.Equal(t, "bind", m.Type)
+			assert.Equal(t, "/sys", m.Source)
+			assert.Contains(t, m.Options, "bind")
+			assert.Contains(t, m.Options, "ro")
+			assert.NotContains(t, m.Options, "rbind")
+			assert.NotContains(t, m.Options, "rw")
+		}
+	}
+
+	t.Logf("should fail when the sandbox has no user namespace")
+	_, err = c.generateContainerSpec(testID, "unknown-sandbox-id", testPid, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
+	assert.Error(t, err)
+
+	t.Logf("should not allow privileged container in user namespace")
+	config.Linux.SecurityContext.Privileged = true
+	sandboxConfig.Linux.SecurityContext = &runtime.LinuxSandboxSecurityContext{Privileged: true}
+	_, err = c.generateContainerSpec(testID, testSandboxID, testPid, config, sandboxConfig, imageConfig, nil, criconfig.Runtime{})
+	assert.Error(t, err)
+}
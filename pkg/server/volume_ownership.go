@@ -0,0 +1,224 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+	criconfig "github.com/containerd/cri/pkg/config"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+const (
+	// volumeOwnershipOnDemand changes the ownership of a volume only if its
+	// root directory doesn't have the expected ownership yet.
+	volumeOwnershipOnDemand = "on_demand"
+	// volumeOwnershipAlways always changes the ownership of all files in a
+	// volume.
+	volumeOwnershipAlways = "always"
+
+	// volumeGroupMask is the permission added for the group of volume files,
+	// volume directories also get the setgid and group execute bits.
+	volumeGroupMask = os.FileMode(0660)
+	volumeDirMask   = volumeGroupMask | os.ModeSetgid | 0110
+
+	// kubeletPodsDir is the directory of the kubelet pod directories, the
+	// volumes of a pod are in "<pod uid>/volumes" of it.
+	kubeletPodsDir = "/var/lib/kubelet/pods"
+)
+
+// validateVolumeOwnershipPolicy validates the volume ownership policy, empty
+// disables volume ownership management.
+func validateVolumeOwnershipPolicy(policy string) error {
+	switch policy {
+	case "", volumeOwnershipOnDemand, volumeOwnershipAlways:
+		return nil
+	}
+	return errors.Errorf("unsupported volume ownership policy %q", policy)
+}
+
+// volumeOwner returns the host uid and gid the volumes of a container are
+// owned by, -1 keeps the current one. The uid is the runAsUser of the
+// container, the gid is the fs group of the pod or the runAsGroup of the
+// container. Both are mapped to host ids in the user namespace of the pod.
+func (c *criService) volumeOwner(config *runtime.ContainerConfig, sandboxConfig *runtime.PodSandboxConfig, userns *sandboxstore.UserNamespace) (int, int, error) {
+	uid, gid := int64(-1), int64(-1)
+	securityContext := config.GetLinux().GetSecurityContext()
+	if securityContext.GetRunAsUser() != nil {
+		uid = securityContext.GetRunAsUser().GetValue()
+	}
+	if securityContext.GetRunAsGroup() != nil {
+		gid = securityContext.GetRunAsGroup().GetValue()
+	}
+	if s, ok := sandboxConfig.GetAnnotations()[annotations.FSGroup]; ok {
+		fsGroup, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "invalid %q annotation", annotations.FSGroup)
+		}
+		gid = int64(fsGroup)
+	}
+	if userns != nil {
+		for _, id := range []struct {
+			id       *int64
+			mappings []criconfig.IDMapping
+		}{
+			{&uid, userns.UIDMappings},
+			{&gid, userns.GIDMappings},
+		} {
+			if *id.id < 0 {
+				continue
+			}
+			hostID, ok := toHostID(id.mappings, uint32(*id.id))
+			if !ok {
+				return 0, 0, errors.Errorf("id %d is not mapped in the user namespace", *id.id)
+			}
+			*id.id = int64(hostID)
+		}
+	}
+	return int(uid), int(gid), nil
+}
+
+// volumeOwnershipDirs returns the directories which volumes with changed
+// ownership can be in: the kubelet volume directory of the pod, and the
+// configured directories.
+func (c *criService) volumeOwnershipDirs(sandboxConfig *runtime.PodSandboxConfig) []string {
+	var dirs []string
+	if uid := sandboxConfig.GetMetadata().GetUid(); uid != "" && uid != "." && uid != ".." && !strings.Contains(uid, "/") {
+		dirs = append(dirs, filepath.Join(kubeletPodsDir, uid, "volumes"))
+	}
+	return append(dirs, c.config.VolumeOwnershipDirs...)
+}
+
+// inDirs returns whether a path is under one of the directories.
+func inDirs(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		}
+		// Trim the separator of the root directory.
+		dir = strings.TrimSuffix(filepath.Clean(dir), string(filepath.Separator))
+		if strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// setVolumeOwnership changes the ownership of the writable directory volumes
+// of a container to the volume owner, so that a non-root container can write
+// to volumes prepared as root. Volumes which are not in the volume ownership
+// directories are skipped, so that a pod can't change the ownership of
+// arbitrary host paths.
+func (c *criService) setVolumeOwnership(config *runtime.ContainerConfig, sandboxConfig *runtime.PodSandboxConfig, userns *sandboxstore.UserNamespace) error {
+	policy := c.config.VolumeOwnershipPolicy
+	if policy == "" {
+		return nil
+	}
+	uid, gid, err := c.volumeOwner(config, sandboxConfig, userns)
+	if err != nil {
+		return err
+	}
+	if uid < 0 && gid < 0 {
+		return nil
+	}
+	dirs := c.volumeOwnershipDirs(sandboxConfig)
+	for _, mount := range config.GetMounts() {
+		path := mount.GetHostPath()
+		if path == "" || mount.GetReadonly() {
+			continue
+		}
+		path, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve volume %q", mount.GetHostPath())
+		}
+		if !inDirs(path, dirs) {
+			logrus.Warnf("Skip changing ownership of volume %q, which is not in the volume ownership dirs", path)
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to stat volume %q", path)
+		}
+		if !fi.IsDir() {
+			continue
+		}
+		if policy == volumeOwnershipOnDemand && ownedBy(fi, uid, gid) {
+			continue
+		}
+		logrus.Debugf("Change ownership of volume %q to %d:%d", path, uid, gid)
+		if err := changeOwnership(path, uid, gid); err != nil {
+			return errors.Wrapf(err, "failed to change ownership of volume %q", path)
+		}
+	}
+	return nil
+}
+
+// ownedBy returns whether a file has the ownership and group permissions
+// set by changeOwnership.
+func ownedBy(fi os.FileInfo, uid, gid int) bool {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+	if uid >= 0 && int(st.Uid) != uid {
+		return false
+	}
+	if gid >= 0 {
+		if int(st.Gid) != gid {
+			return false
+		}
+		mask := volumeGroupMask
+		if fi.IsDir() {
+			mask = volumeDirMask
+		}
+		if fi.Mode()&mask != mask {
+			return false
+		}
+	}
+	return true
+}
+
+// changeOwnership recursively changes the owner of the files in a directory,
+// and makes them readable and writable by the group if gid is set. Symbolic
+// links are not followed.
+func changeOwnership(root string, uid, gid int) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := os.Lchown(path, uid, gid); err != nil {
+			return err
+		}
+		if gid < 0 || info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		mask := volumeGroupMask
+		if info.IsDir() {
+			mask = volumeDirMask
+		}
+		return os.Chmod(path, info.Mode()|mask)
+	})
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2018 The Containerd Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/containerd/cri/pkg/annotations"
+	criconfig "github.com/containerd/cri/pkg/config"
+	sandboxstore "github.com/containerd/cri/pkg/store/sandbox"
+)
+
+func TestVolumeOwner(t *testing.T) {
+	for desc, test := range map[string]struct {
+		runAsUser   *runtime.Int64Value
+		runAsGroup  *runtime.Int64Value
+		annotations map[string]string
+		userns      *sandboxstore.UserNamespace
+		expectErr   bool
+		expectedUID int
+		expectedGID int
+	}{
+		"should keep ownership without user and group": {
+			expectedUID: -1,
+			expectedGID: -1,
+		},
+		"should use runAsUser and runAsGroup": {
+			runAsUser:   &runtime.Int64Value{Value: 1000},
+			runAsGroup:  &runtime.Int64Value{Value: 2000},
+			expectedUID: 1000,
+			expectedGID: 2000,
+		},
+		"should prefer fs group over runAsGroup": {
+			runAsUser:   &runtime.Int64Value{Value: 1000},
+			runAsGroup:  &runtime.Int64Value{Value: 2000},
+			annotations: map[string]string{annotations.FSGroup: "3000"},
+			expectedUID: 1000,
+			expectedGID: 3000,
+		},
+		"should map ids in the user namespace": {
+			runAsUser:   &runtime.Int64Value{Value: 1000},
+			annotations: map[string]string{annotations.FSGroup: "3000"},
+			userns: &sandboxstore.UserNamespace{
+				UIDMappings: []criconfig.IDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}},
+				GIDMappings: []criconfig.IDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}},
+			},
+			expectedUID: 101000,
+			expectedGID: 103000,
+		},
+		"should return error with invalid fs group": {
+			annotations: map[string]string{annotations.FSGroup: "invalid"},
+			expectErr:   true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		c := newTestCRIService()
+		config := &runtime.ContainerConfig{
+			Linux: &runtime.LinuxContainerConfig{
+				SecurityContext: &runtime.LinuxContainerSecurityContext{
+					RunAsUser:  test.runAsUser,
+					RunAsGroup: test.runAsGroup,
+				},
+			},
+		}
+		sandboxConfig := &runtime.PodSandboxConfig{Annotations: test.annotations}
+		uid, gid, err := c.volumeOwner(config, sandboxConfig, test.userns)
+		if test.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expectedUID, uid)
+		assert.Equal(t, test.expectedGID, gid)
+	}
+}
+
+func TestSetVolumeOwnership(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing ownership requires root")
+	}
+	for desc, test := range map[string]struct {
+		policy     string
+		readonly   bool
+		preOwned   bool
+		notAllowed bool
+		changed    bool
+	}{
+		"should not change ownership without policy": {},
+		"should change ownership on demand": {
+			policy:  volumeOwnershipOnDemand,
+			changed: true,
+		},
+		"should not change ownership on demand if the root is owned": {
+			policy:   volumeOwnershipOnDemand,
+			preOwned: true,
+		},
+		"should always change ownership": {
+			policy:   volumeOwnershipAlways,
+			preOwned: true,
+			changed:  true,
+		},
+		"should not change ownership of readonly volume": {
+			policy:   volumeOwnershipAlways,
+			readonly: true,
+		},
+		"should not change ownership of volume outside volume ownership dirs": {
+			policy:     volumeOwnershipAlways,
+			notAllowed: true,
+		},
+	} {
+		t.Logf("TestCase %q", desc)
+		root, err := ioutil.TempDir("", "test-volume-ownership")
+		require.NoError(t, err)
+		defer os.RemoveAll(root)
+		dir := filepath.Join(root, "volume")
+		file := filepath.Join(dir, "sub", "file")
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, ioutil.WriteFile(file, nil, 0600))
+		if test.preOwned {
+			require.NoError(t, os.Chown(dir, 1000, 2000))
+			require.NoError(t, os.Chmod(dir, 0755|volumeDirMask))
+		}
+
+		c := newTestCRIService()
+		c.config.VolumeOwnershipPolicy = test.policy
+		if !test.notAllowed {
+			c.config.VolumeOwnershipDirs = []string{root}
+		}
+		config := &runtime.ContainerConfig{
+			Mounts: []*runtime.Mount{{ContainerPath: "/data", HostPath: dir, Readonly: test.readonly}},
+			Linux: &runtime.LinuxContainerConfig{
+				SecurityContext: &runtime.LinuxContainerSecurityContext{
+					RunAsUser: &runtime.Int64Value{Value: 1000},
+				},
+			},
+		}
+		sandboxConfig := &runtime.PodSandboxConfig{
+			Annotations: map[string]string{annotations.FSGroup: "2000"},
+		}
+		require.NoError(t, c.setVolumeOwnership(config, sandboxConfig, nil))
+
+		fi, err := os.Stat(file)
+		require.NoError(t, err)
+		st := fi.Sys().(*syscall.Stat_t)
+		if test.changed {
+			assert.EqualValues(t, 1000, st.Uid)
+			assert.EqualValues(t, 2000, st.Gid)
+			assert.Equal(t, os.FileMode(0660), fi.Mode().Perm())
+		} else {
+			assert.EqualValues(t, 0, st.Uid)
+			assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+		}
+	}
+}
+
+func TestInVolumeOwnershipDirs(t *testing.T) {
+	c := newTestCRIService()
+	c.config.VolumeOwnershipDirs = []string{"/data/volumes"}
+	sandboxConfig := &runtime.PodSandboxConfig{Metadata: &runtime.PodSandboxMetadata{Uid: "pod-uid"}}
+	for path, expected := range map[string]bool{
+		"/var/lib/kubelet/pods/pod-uid/volumes/kubernetes.io~empty-dir/cache":   true,
+		"/var/lib/kubelet/pods/other-uid/volumes/kubernetes.io~empty-dir/cache": false,
+		"/var/lib/kubelet/pods/pod-uid":                                         false,
+		"/data/volumes/cache":                                                   true,
+		"/data/volumes":                                                         false,
+		"/data/volumes-other":                                                   false,
+		"/etc":                                                                  false,
+	} {
+		t.Logf("TestCase %q", path)
+		assert.Equal(t, expected, inDirs(path, c.volumeOwnershipDirs(sandboxConfig)))
+	}
+
+	t.Logf("should not allow kubelet volume dir of pod with invalid uid")
+	sandboxConfig.Metadata.Uid = ".."
+	assert.Equal(t, []string{"/data/volumes"}, c.volumeOwnershipDirs(sandboxConfig))
+}
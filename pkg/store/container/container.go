@@ -95,8 +95,14 @@ func (c *Container) Delete() error {
 	return c.Status.Delete()
 }
 
-// Store stores all Containers.
+// Store stores all Containers. Containers are sharded by id, and the status
+// of each container has its own lock.
 type Store struct {
+	shards [store.Shards]shard
+}
+
+// shard stores the containers of a shard.
+type shard struct {
 	lock       sync.RWMutex
 	containers map[string]Container
 	idIndex    *truncindex.TruncIndex
@@ -104,40 +110,48 @@ type Store struct {
 
 // NewStore creates a container store.
 func NewStore() *Store {
-	return &Store{
-		containers: make(map[string]Container),
-		idIndex:    truncindex.NewTruncIndex([]string{}),
+	s := &Store{}
+	for i := range s.shards {
+		s.shards[i].containers = make(map[string]Container)
+		s.shards[i].idIndex = truncindex.NewTruncIndex([]string{})
 	}
+	return s
+}
+
+func (s *Store) shard(id string) *shard {
+	return &s.shards[store.ShardOf(id)]
 }
 
 // Add a container into the store. Returns store.ErrAlreadyExist if the
 // container already exists.
 func (s *Store) Add(c Container) error {
-	s.lock.Lock()
-	defer s.lock.Unlock()
-	if _, ok := s.containers[c.ID]; ok {
+	sh := s.shard(c.ID)
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+	if _, ok := sh.containers[c.ID]; ok {
 		return store.ErrAlreadyExist
 	}
-	if err := s.idIndex.Add(c.ID); err != nil {
+	if err := sh.idIndex.Add(c.ID); err != nil {
 		return err
 	}
-	s.containers[c.ID] = c
+	sh.containers[c.ID] = c
 	return nil
 }
 
 // Get returns the container with specified id. Returns store.ErrNotExist
 // if the container doesn't exist.
 func (s *Store) Get(id string) (Container, error) {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-	id, err := s.idIndex.Get(id)
+	sh := s.shard(id)
+	sh.lock.RLock()
+	defer sh.lock.RUnlock()
+	id, err := sh.idIndex.Get(id)
 	if err != nil {
 		if err == truncindex.ErrNotExist {
 			err = store.ErrNotExist
 		}
 		return Container{}, err
 	}
-	if c, ok := s.containers[id]; ok {
+	if c, ok := sh.containers[id]; ok {
 		return c, nil
 	}
 	return Container{}, store.ErrNotExist
@@ -145,25 +159,29 @@ func (s *Store) Get(id string) (Container, error) {
 
 // List lists all containers.
 func (s *Store) List() []Container {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
 	var containers []Container
-	for _, c := range s.containers {
-		containers = append(containers, c)
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.lock.RLock()
+		for _, c := range sh.containers {
+			containers = append(containers, c)
+		}
+		sh.lock.RUnlock()
 	}
 	return containers
 }
 
 // Delete deletes the container from store with specified id.
 func (s *Store) Delete(id string) {
-	s.lock.Lock()
-	defer s.lock.Unlock()
-	id, err := s.idIndex.Get(id)
+	sh := s.shard(id)
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+	id, err := sh.idIndex.Get(id)
 	if err != nil {
 		// Note: The idIndex.Delete and delete doesn't handle truncated index.
 		// So we need to return if there are error.
 		return
 	}
-	s.idIndex.Delete(id) // nolint: errcheck
-	delete(s.containers, id)
+	sh.idIndex.Delete(id) // nolint: errcheck
+	delete(sh.containers, id)
 }
@@ -58,6 +58,14 @@ type Metadata struct {
 	ImageRef string
 	// LogPath is the container log path.
 	LogPath string
+	// Checkpoint is the name of the checkpoint image the container is
+	// restored from. Empty if the container is not restored from a checkpoint.
+	Checkpoint string
+	// Snapshotter is the snapshotter the container rootfs is created with.
+	Snapshotter string
+	// LogFormat is the format of the container log file. Empty means the CRI
+	// logging format.
+	LogFormat string
 }
 
 // MarshalJSON encodes Metadata into bytes in json format.
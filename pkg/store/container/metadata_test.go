@@ -35,8 +35,10 @@ func TestMetadataMarshalUnmarshal(t *testing.T) {
 				Attempt: 1,
 			},
 		},
-		ImageRef: "test-image-ref",
-		LogPath:  "/test/log/path",
+		ImageRef:    "test-image-ref",
+		LogPath:     "/test/log/path",
+		Checkpoint:  "checkpoint/test-id",
+		Snapshotter: "test-snapshotter",
 	}
 
 	assert := assertlib.New(t)
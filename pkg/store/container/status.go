@@ -56,6 +56,9 @@ type Status struct {
 	// Human-readable message indicating details about why container is in its
 	// current state.
 	Message string
+	// Resources has the container resource limits updated by
+	// UpdateContainerResources. It is nil if resources are never updated.
+	Resources *runtime.LinuxContainerResources
 	// Removing indicates that the container is in removing state.
 	// This field doesn't need to be checkpointed.
 	Removing bool `json:"-"`
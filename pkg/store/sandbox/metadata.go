@@ -19,6 +19,8 @@ package sandbox
 import (
 	"encoding/json"
 
+	criconfig "github.com/containerd/cri/pkg/config"
+	cni "github.com/containerd/go-cni"
 	"github.com/pkg/errors"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 )
@@ -54,6 +56,76 @@ type Metadata struct {
 	NetNSPath string
 	// IP of Pod if it is attached to non host network
 	IP string
+	// AdditionalIPs are the IPs of Pod other than IP, e.g. the IPv6 address
+	// of a dual-stack Pod.
+	AdditionalIPs []string
+	// AdditionalNetworks are the networks attached to the Pod other than the
+	// networks all pods are attached to.
+	AdditionalNetworks []NetworkAttachment
+	// CNIResult is the result of the cni network setup of the Pod, i.e. its
+	// interfaces, IPs and routes. It is passed to the network teardown, so
+	// that the network is torn down correctly even if the cni plugins lost
+	// their own state, e.g. their cache directory.
+	CNIResult *cni.CNIResult
+	// CNINetworks are the networks of the cni network setup of the Pod in
+	// order, with the conf lists used at setup. The network teardown removes
+	// exactly these networks, even if the cni conf dir changed since.
+	CNINetworks []CNINetwork
+	// HostPortManaged indicates whether the host ports of the Pod are
+	// programmed by the cri plugin instead of a cni plugin.
+	HostPortManaged bool
+	// RuntimeHandler is the runtime handler name of the pod.
+	RuntimeHandler string
+	// Runtime is the runtime the sandbox was created with. Containers of the
+	// sandbox run with it even if the runtime config changed since. It is nil
+	// for sandboxes created before it was recorded.
+	Runtime *criconfig.Runtime
+	// UserNamespace is the user namespace of the sandbox and its containers,
+	// nil if they run in the host user namespace.
+	UserNamespace *UserNamespace
+	// ProcessLabel is the SELinux process label of the sandbox container.
+	// Containers in the sandbox use the same label level by default.
+	ProcessLabel string
+	// NoPauseContainer indicates that the sandbox has no pause container.
+	// Its ipc and uts namespaces are pinned by bind mounts instead, and the
+	// sandbox container is only used to checkpoint the metadata.
+	NoPauseContainer bool
+	// ExternalNetNS indicates that NetNSPath is managed by an external network
+	// agent. The network of the sandbox is not set up or torn down by CNI,
+	// and the network namespace is never removed.
+	ExternalNetNS bool
+}
+
+// NetworkAttachment is an additional network attached to a Pod.
+type NetworkAttachment struct {
+	// Name is the network name.
+	Name string `json:"name"`
+	// IfName is the interface name in the Pod network namespace.
+	IfName string `json:"ifName"`
+	// IPs are the IPs of the interface.
+	IPs []string `json:"ips"`
+	// ConfList is the cni conf list the network was attached with. The
+	// network is detached with it, even if the conf file changed since.
+	ConfList json.RawMessage `json:"confList,omitempty"`
+}
+
+// UserNamespace is the user namespace of a Pod.
+type UserNamespace struct {
+	// UIDMappings are the user id mappings of the user namespace.
+	UIDMappings []criconfig.IDMapping `json:"uidMappings"`
+	// GIDMappings are the group id mappings of the user namespace.
+	GIDMappings []criconfig.IDMapping `json:"gidMappings"`
+}
+
+// CNINetwork is a network of the cni network setup of a Pod.
+type CNINetwork struct {
+	// IfName is the interface name in the Pod network namespace.
+	IfName string `json:"ifName"`
+	// ConfList is the cni conf list of the network.
+	ConfList json.RawMessage `json:"confList"`
+	// Result is the cni result of the network. It is empty if the network
+	// was not set up, or was set up by an older version.
+	Result json.RawMessage `json:"result,omitempty"`
 }
 
 // MarshalJSON encodes Metadata into bytes in json format.
@@ -18,8 +18,11 @@ package sandbox
 
 import (
 	"encoding/json"
+	"net"
 	"testing"
 
+	cni "github.com/containerd/go-cni"
+	"github.com/containernetworking/cni/pkg/types"
 	assertlib "github.com/stretchr/testify/assert"
 	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 )
@@ -36,6 +39,29 @@ func TestMetadataMarshalUnmarshal(t *testing.T) {
 				Attempt:   1,
 			},
 		},
+		AdditionalIPs: []string{"fd00::2"},
+		AdditionalNetworks: []NetworkAttachment{
+			{Name: "test-network", IfName: "net1", IPs: []string{"10.0.0.2"}},
+		},
+		CNIResult: &cni.CNIResult{
+			Interfaces: map[string]*cni.Config{
+				"eth0": {
+					IPConfigs: []*cni.IPConfig{{IP: net.ParseIP("10.0.0.2"), Gateway: net.ParseIP("10.0.0.1")}},
+					Mac:       "0a:58:0a:00:00:02",
+					Sandbox:   "/var/run/netns/cni-test",
+				},
+			},
+			Routes: []*types.Route{{Dst: net.IPNet{IP: net.ParseIP("0.0.0.0"), Mask: net.CIDRMask(0, 32)}}},
+		},
+		CNINetworks: []CNINetwork{
+			{
+				IfName:   "eth0",
+				ConfList: []byte(`{"cniVersion":"0.3.1","name":"test","plugins":[{"type":"bridge"}]}`),
+				Result:   []byte(`{"cniVersion":"0.3.1","ips":[{"version":"4","address":"10.0.0.2/24"}],"dns":{}}`),
+			},
+		},
+		HostPortManaged: true,
+		ProcessLabel:    "system_u:system_r:container_t:s0:c1,c2",
 	}
 	assert := assertlib.New(t)
 	newMeta := &Metadata{}
@@ -67,6 +67,20 @@ func LoadNetNS(path string) (*NetNS, error) {
 	return &NetNS{ns: ns, restored: true}, nil
 }
 
+// LoadExternalNetNS loads a network namespace managed outside of the cri
+// plugin. It returns ErrClosedNetNS if the network namespace doesn't exist.
+// Remove only closes it, the network namespace is never removed.
+func LoadExternalNetNS(path string) (*NetNS, error) {
+	ns, err := cnins.GetNS(path)
+	if err != nil {
+		if _, ok := err.(cnins.NSPathNotExistErr); ok {
+			return nil, ErrClosedNetNS
+		}
+		return nil, errors.Wrap(err, "failed to load network namespace")
+	}
+	return &NetNS{ns: ns}, nil
+}
+
 // Remove removes network namepace if it exists and not closed. Remove is idempotent,
 // meaning it might be invoked multiple times and provides consistent result.
 func (n *NetNS) Remove() error {
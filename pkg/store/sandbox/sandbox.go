@@ -54,8 +54,14 @@ func NewSandbox(metadata Metadata, status Status) Sandbox {
 	return s
 }
 
-// Store stores all sandboxes.
+// Store stores all sandboxes. Sandboxes are sharded by id, and the status of
+// each sandbox has its own lock.
 type Store struct {
+	shards [store.Shards]shard
+}
+
+// shard stores the sandboxes of a shard.
+type shard struct {
 	lock      sync.RWMutex
 	sandboxes map[string]Sandbox
 	idIndex   *truncindex.TruncIndex
@@ -63,23 +69,30 @@ type Store struct {
 
 // NewStore creates a sandbox store.
 func NewStore() *Store {
-	return &Store{
-		sandboxes: make(map[string]Sandbox),
-		idIndex:   truncindex.NewTruncIndex([]string{}),
+	s := &Store{}
+	for i := range s.shards {
+		s.shards[i].sandboxes = make(map[string]Sandbox)
+		s.shards[i].idIndex = truncindex.NewTruncIndex([]string{})
 	}
+	return s
+}
+
+func (s *Store) shard(id string) *shard {
+	return &s.shards[store.ShardOf(id)]
 }
 
 // Add a sandbox into the store.
 func (s *Store) Add(sb Sandbox) error {
-	s.lock.Lock()
-	defer s.lock.Unlock()
-	if _, ok := s.sandboxes[sb.ID]; ok {
+	sh := s.shard(sb.ID)
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+	if _, ok := sh.sandboxes[sb.ID]; ok {
 		return store.ErrAlreadyExist
 	}
-	if err := s.idIndex.Add(sb.ID); err != nil {
+	if err := sh.idIndex.Add(sb.ID); err != nil {
 		return err
 	}
-	s.sandboxes[sb.ID] = sb
+	sh.sandboxes[sb.ID] = sb
 	return nil
 }
 
@@ -99,16 +112,17 @@ func (s *Store) Get(id string) (Sandbox, error) {
 // GetAll returns the sandbox with specified id, including sandbox in unknown
 // state. Returns store.ErrNotExist if the sandbox doesn't exist.
 func (s *Store) GetAll(id string) (Sandbox, error) {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-	id, err := s.idIndex.Get(id)
+	sh := s.shard(id)
+	sh.lock.RLock()
+	defer sh.lock.RUnlock()
+	id, err := sh.idIndex.Get(id)
 	if err != nil {
 		if err == truncindex.ErrNotExist {
 			err = store.ErrNotExist
 		}
 		return Sandbox{}, err
 	}
-	if sb, ok := s.sandboxes[id]; ok {
+	if sb, ok := sh.sandboxes[id]; ok {
 		return sb, nil
 	}
 	return Sandbox{}, store.ErrNotExist
@@ -116,10 +130,19 @@ func (s *Store) GetAll(id string) (Sandbox, error) {
 
 // List lists all sandboxes.
 func (s *Store) List() []Sandbox {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
+	var all []Sandbox
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.lock.RLock()
+		for _, sb := range sh.sandboxes {
+			all = append(all, sb)
+		}
+		sh.lock.RUnlock()
+	}
+	// Check the states without holding the shard locks, the status of each
+	// sandbox has its own lock.
 	var sandboxes []Sandbox
-	for _, sb := range s.sandboxes {
+	for _, sb := range all {
 		if sb.Status.Get().State == StateUnknown {
 			continue
 		}
@@ -130,14 +153,15 @@ func (s *Store) List() []Sandbox {
 
 // Delete deletes the sandbox with specified id.
 func (s *Store) Delete(id string) {
-	s.lock.Lock()
-	defer s.lock.Unlock()
-	id, err := s.idIndex.Get(id)
+	sh := s.shard(id)
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+	id, err := sh.idIndex.Get(id)
 	if err != nil {
 		// Note: The idIndex.Delete and delete doesn't handle truncated index.
 		// So we need to return if there are error.
 		return
 	}
-	s.idIndex.Delete(id) // nolint: errcheck
-	delete(s.sandboxes, id)
+	sh.idIndex.Delete(id) // nolint: errcheck
+	delete(sh.sandboxes, id)
 }
@@ -40,3 +40,25 @@ func (s *StopCh) Stop() {
 func (s *StopCh) Stopped() <-chan struct{} {
 	return s.ch
 }
+
+// Shards is the number of shards of the sandbox and container stores, each
+// with its own lock and id index, so that operations on different sandboxes
+// and containers don't contend on one lock.
+const Shards = 16
+
+// ShardOf returns the shard of an id or a truncated id. Ids are sharded by
+// their first character, which is in every truncated id, so that a truncated
+// id is looked up in one shard.
+func ShardOf(id string) int {
+	if id == "" {
+		return 0
+	}
+	switch c := id[0]; {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	default:
+		return int(c) % Shards
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardOf(t *testing.T) {
+	id := "3f8a1c5e9b2d4f6a8c0e1b3d5f7a9c2e4b6d8f0a1c3e5b7d9f2a4c6e8b0d1f3a"
+	t.Logf("should shard truncated ids with the id")
+	for i := 1; i <= len(id); i++ {
+		assert.Equal(t, ShardOf(id), ShardOf(id[:i]))
+	}
+
+	t.Logf("should spread hex ids over all shards")
+	shards := make(map[int]bool)
+	for _, c := range "0123456789abcdef" {
+		shard := ShardOf(string(c))
+		assert.True(t, shard >= 0 && shard < Shards)
+		shards[shard] = true
+	}
+	assert.Len(t, shards, Shards)
+
+	t.Logf("should shard other ids within the shards")
+	for _, id := range []string{"", "container-1", "Z"} {
+		shard := ShardOf(id)
+		assert.True(t, shard >= 0 && shard < Shards)
+	}
+}